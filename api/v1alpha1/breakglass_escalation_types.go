@@ -22,6 +22,7 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -84,6 +85,11 @@ type BreakglassEscalationSpec struct {
 	// +optional
 	ClusterConfigRefs []string `json:"clusterConfigRefs,omitempty"`
 
+	// clusterSelector selects the ClusterConfigs this escalation applies to by label and cluster metadata,
+	// in addition to allowed.clusters and clusterConfigRefs. The matching clusters are listed in status.resolvedClusters.
+	// +optional
+	ClusterSelector *ClusterSelector `json:"clusterSelector,omitempty"`
+
 	// denyPolicyRefs (optional) attach default deny policies to any session created via this escalation.
 	// +optional
 	DenyPolicyRefs []string `json:"denyPolicyRefs,omitempty"`
@@ -145,6 +151,18 @@ type BreakglassEscalationSpec struct {
 	// +optional
 	NotificationExclusions *NotificationExclusions `json:"notificationExclusions,omitempty"`
 
+	// notificationDigest batches session request notifications for approvers of this escalation
+	// into a periodic summary instead of one email per request. Urgent requests are always sent immediately.
+	// Pending digests are kept in memory by the replica that handled the request and are lost if it crashes.
+	// +optional
+	NotificationDigest *NotificationDigestConfig `json:"notificationDigest,omitempty"`
+
+	// approvalAuthRequirements requires approvers to have authenticated in a specific way, e.g. recently
+	// and with MFA, before they can approve sessions for this escalation. The requirements are checked
+	// against the acr, amr and auth_time claims of the approver's token.
+	// +optional
+	ApprovalAuthRequirements *ApprovalAuthRequirements `json:"approvalAuthRequirements,omitempty"`
+
 	// mailProvider specifies which MailProvider to use for email notifications for this escalation.
 	// If empty, falls back to the cluster's MailProvider, then to the default MailProvider.
 	// +optional
@@ -164,6 +182,43 @@ type NotificationExclusions struct {
 	Groups []string `json:"groups,omitempty"`
 }
 
+// NotificationDigestConfig configures digest delivery of session request notifications.
+// Approvers can override the escalation setting with their own notification preference.
+type NotificationDigestConfig struct {
+	// enabled sends approvers a periodic digest of pending requests instead of one email per request.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// interval between two digests sent to the same approver (e.g. "15m").
+	// Defaults to the globally configured digest interval (15m if unset). Must be between 1m and 24h.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// urgentReasonKeywords marks requests as urgent when their reason contains one of the keywords
+	// (case-insensitive). Urgent requests bypass the digest and are sent immediately.
+	// +optional
+	UrgentReasonKeywords []string `json:"urgentReasonKeywords,omitempty"`
+}
+
+// ApprovalAuthRequirements describes the authentication an approver must have performed (step-up authentication).
+// Unset fields are not checked; an approval must satisfy all configured fields.
+type ApprovalAuthRequirements struct {
+	// acrValues lists the accepted authentication context class references.
+	// The acr claim of the approver's token must equal one of them.
+	// +optional
+	ACRValues []string `json:"acrValues,omitempty"`
+
+	// amrValues lists accepted authentication methods (e.g. "mfa", "otp", "hwk").
+	// The amr claim of the approver's token must contain at least one of them.
+	// +optional
+	AMRValues []string `json:"amrValues,omitempty"`
+
+	// maxAuthAge is the maximum time since the approver last authenticated (auth_time claim), e.g. "15m".
+	// +optional
+	// +kubebuilder:validation:Pattern="^([0-9]+(ns|us|ms|s|m|h|d))+$"
+	MaxAuthAge string `json:"maxAuthAge,omitempty"`
+}
+
 type ReasonConfig struct {
 	// mandatory indicates whether the field is required (true) or optional (false).
 	// +optional
@@ -189,6 +244,51 @@ type BreakglassEscalationAllowed struct {
 	Groups []string `json:"groups,omitempty"`
 }
 
+// ClusterSelector selects ClusterConfigs. All set criteria must match.
+type ClusterSelector struct {
+	// labelSelector matches the labels of the ClusterConfig
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// tenant matches spec.tenant of the ClusterConfig
+	// +optional
+	Tenant string `json:"tenant,omitempty"`
+	// environment matches spec.environment of the ClusterConfig
+	// +optional
+	Environment string `json:"environment,omitempty"`
+	// site matches spec.site of the ClusterConfig
+	// +optional
+	Site string `json:"site,omitempty"`
+}
+
+// IsEmpty reports whether the selector sets no criteria. An empty selector matches no cluster.
+func (s *ClusterSelector) IsEmpty() bool {
+	return s == nil || (s.LabelSelector == nil && s.Tenant == "" && s.Environment == "" && s.Site == "")
+}
+
+// Matches reports whether the ClusterConfig meets all criteria of the selector
+func (s *ClusterSelector) Matches(cc *ClusterConfig) (bool, error) {
+	if s.IsEmpty() {
+		return false, nil
+	}
+	if s.Tenant != "" && s.Tenant != cc.Spec.Tenant {
+		return false, nil
+	}
+	if s.Environment != "" && s.Environment != cc.Spec.Environment {
+		return false, nil
+	}
+	if s.Site != "" && s.Site != cc.Spec.Site {
+		return false, nil
+	}
+	if s.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(s.LabelSelector)
+		if err != nil {
+			return false, err
+		}
+		return selector.Matches(labels.Set(cc.Labels)), nil
+	}
+	return true, nil
+}
+
 // BreakglassEscalationApprovers
 type BreakglassEscalationApprovers struct {
 	// users that are allowed to approve a session for this escalation
@@ -215,6 +315,10 @@ type BreakglassEscalationStatus struct {
 	// +optional
 	IDPGroupMemberships map[string]map[string][]string `json:"idpGroupMemberships,omitempty"`
 
+	// resolvedClusters lists the ClusterConfigs currently matched by spec.clusterSelector
+	// +optional
+	ResolvedClusters []string `json:"resolvedClusters,omitempty"`
+
 	// ObservedGeneration reflects the generation of the most recently observed BreakglassEscalation
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -333,10 +437,10 @@ func validateBreakglassEscalationSpec(ctx context.Context, escalation *Breakglas
 	allowedGroupsPath := specPath.Child("allowed").Child("groups")
 	allowedClustersPath := specPath.Child("allowed").Child("clusters")
 
-	clustersProvided := len(escalation.Spec.Allowed.Clusters) > 0 || len(escalation.Spec.ClusterConfigRefs) > 0
-	// Validate allowed groups and cluster targets are not both empty (cluster targets include allowed.clusters + clusterConfigRefs)
+	clustersProvided := len(escalation.Spec.Allowed.Clusters) > 0 || len(escalation.Spec.ClusterConfigRefs) > 0 || escalation.Spec.ClusterSelector != nil
+	// Validate allowed groups and cluster targets are not both empty (cluster targets include allowed.clusters, clusterConfigRefs and clusterSelector)
 	if len(escalation.Spec.Allowed.Groups) == 0 && !clustersProvided {
-		allErrs = append(allErrs, field.Required(specPath.Child("allowed"), "either groups or cluster targets (allowed.clusters or clusterConfigRefs) must be specified"))
+		allErrs = append(allErrs, field.Required(specPath.Child("allowed"), "either groups or cluster targets (allowed.clusters, clusterConfigRefs or clusterSelector) must be specified"))
 	}
 
 	// Validate allowed groups
@@ -411,6 +515,18 @@ func validateBreakglassEscalationAdditionalLists(spec *BreakglassEscalationSpec,
 	allErrs = append(allErrs, validateStringListEntriesNotEmpty(spec.ClusterConfigRefs, clusterConfigRefsPath)...)
 	allErrs = append(allErrs, validateStringListNoDuplicates(spec.ClusterConfigRefs, clusterConfigRefsPath)...)
 
+	if spec.ClusterSelector != nil {
+		clusterSelectorPath := specPath.Child("clusterSelector")
+		if spec.ClusterSelector.IsEmpty() {
+			allErrs = append(allErrs, field.Required(clusterSelectorPath, "clusterSelector must set at least one of labelSelector, tenant, environment or site"))
+		}
+		if spec.ClusterSelector.LabelSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(spec.ClusterSelector.LabelSelector); err != nil {
+				allErrs = append(allErrs, field.Invalid(clusterSelectorPath.Child("labelSelector"), spec.ClusterSelector.LabelSelector, err.Error()))
+			}
+		}
+	}
+
 	denyPolicyRefsPath := specPath.Child("denyPolicyRefs")
 	allErrs = append(allErrs, validateStringListEntriesNotEmpty(spec.DenyPolicyRefs, denyPolicyRefsPath)...)
 	allErrs = append(allErrs, validateStringListNoDuplicates(spec.DenyPolicyRefs, denyPolicyRefsPath)...)
@@ -424,6 +540,18 @@ func validateBreakglassEscalationAdditionalLists(spec *BreakglassEscalationSpec,
 		allErrs = append(allErrs, validateStringListNoDuplicates(spec.NotificationExclusions.Groups, groupsPath)...)
 	}
 
+	if spec.NotificationDigest != nil {
+		digestPath := specPath.Child("notificationDigest")
+		allErrs = append(allErrs, validateNotificationDigestInterval(spec.NotificationDigest.Interval, digestPath.Child("interval"))...)
+		keywordsPath := digestPath.Child("urgentReasonKeywords")
+		allErrs = append(allErrs, validateStringListEntriesNotEmpty(spec.NotificationDigest.UrgentReasonKeywords, keywordsPath)...)
+		allErrs = append(allErrs, validateStringListNoDuplicates(spec.NotificationDigest.UrgentReasonKeywords, keywordsPath)...)
+	}
+
+	if spec.ApprovalAuthRequirements != nil {
+		allErrs = append(allErrs, validateApprovalAuthRequirements(spec.ApprovalAuthRequirements, specPath.Child("approvalAuthRequirements"))...)
+	}
+
 	return allErrs
 }
 
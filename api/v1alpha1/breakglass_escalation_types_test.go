@@ -92,7 +92,7 @@ func TestBreakglassEscalationClusterConfigRefsSatisfyClusterRequirement(t *testi
 	clusterConfig := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cluster-a", Namespace: "team-a"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "kc", Namespace: "system"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "kc", Namespace: "system"},
 		},
 	}
 	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterConfig).Build()
@@ -277,3 +277,144 @@ func TestBreakglassEscalationMailProviderHappyPath(t *testing.T) {
 		t.Fatalf("expected success when referencing enabled MailProvider, got %v", err)
 	}
 }
+
+func TestBreakglassEscalationNotificationDigestValidation(t *testing.T) {
+	newEscalation := func(digest *NotificationDigestConfig) *BreakglassEscalation {
+		return &BreakglassEscalation{
+			ObjectMeta: metav1.ObjectMeta{Name: "esc-digest"},
+			Spec: BreakglassEscalationSpec{
+				EscalatedGroup:     "g",
+				Allowed:            BreakglassEscalationAllowed{Clusters: []string{"c"}},
+				Approvers:          BreakglassEscalationApprovers{Groups: []string{"oncall"}},
+				NotificationDigest: digest,
+			},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		digest  *NotificationDigestConfig
+		wantErr bool
+	}{
+		{name: "enabled with default interval", digest: &NotificationDigestConfig{Enabled: true}},
+		{name: "valid interval and keywords", digest: &NotificationDigestConfig{Enabled: true, Interval: "30m", UrgentReasonKeywords: []string{"SEV1", "P1"}}},
+		{name: "unparsable interval", digest: &NotificationDigestConfig{Interval: "soon"}, wantErr: true},
+		{name: "interval too short", digest: &NotificationDigestConfig{Interval: "30s"}, wantErr: true},
+		{name: "interval too long", digest: &NotificationDigestConfig{Interval: "48h"}, wantErr: true},
+		{name: "empty keyword", digest: &NotificationDigestConfig{UrgentReasonKeywords: []string{""}}, wantErr: true},
+		{name: "duplicate keyword", digest: &NotificationDigestConfig{UrgentReasonKeywords: []string{"P1", "P1"}}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			be := newEscalation(tc.digest)
+			_, err := be.ValidateCreate(context.Background(), be)
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected validation error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("expected no validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBreakglassEscalationApprovalAuthRequirementsValidation(t *testing.T) {
+	newEscalation := func(req *ApprovalAuthRequirements) *BreakglassEscalation {
+		return &BreakglassEscalation{
+			ObjectMeta: metav1.ObjectMeta{Name: "esc-step-up"},
+			Spec: BreakglassEscalationSpec{
+				EscalatedGroup:           "g",
+				Allowed:                  BreakglassEscalationAllowed{Clusters: []string{"c"}},
+				Approvers:                BreakglassEscalationApprovers{Groups: []string{"oncall"}},
+				ApprovalAuthRequirements: req,
+			},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		req     *ApprovalAuthRequirements
+		wantErr bool
+	}{
+		{name: "unset", req: nil},
+		{name: "all fields", req: &ApprovalAuthRequirements{ACRValues: []string{"gold"}, AMRValues: []string{"mfa", "hwk"}, MaxAuthAge: "15m"}},
+		{name: "only maxAuthAge", req: &ApprovalAuthRequirements{MaxAuthAge: "5m"}},
+		{name: "empty requirements", req: &ApprovalAuthRequirements{}, wantErr: true},
+		{name: "empty acr value", req: &ApprovalAuthRequirements{ACRValues: []string{""}}, wantErr: true},
+		{name: "duplicate amr value", req: &ApprovalAuthRequirements{AMRValues: []string{"mfa", "mfa"}}, wantErr: true},
+		{name: "unparsable maxAuthAge", req: &ApprovalAuthRequirements{MaxAuthAge: "recently"}, wantErr: true},
+		{name: "zero maxAuthAge", req: &ApprovalAuthRequirements{MaxAuthAge: "0s"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			be := newEscalation(tc.req)
+			_, err := be.ValidateCreate(context.Background(), be)
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected validation error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("expected no validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClusterSelectorMatches(t *testing.T) {
+	cc := &ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-1", Labels: map[string]string{"tier": "prod", "region": "eu"}},
+		Spec:       ClusterConfigSpec{Tenant: "team-a", Environment: "prod", Site: "bonn"},
+	}
+	tests := []struct {
+		name     string
+		selector *ClusterSelector
+		want     bool
+	}{
+		{name: "nil selector", selector: nil, want: false},
+		{name: "empty selector", selector: &ClusterSelector{}, want: false},
+		{name: "tenant", selector: &ClusterSelector{Tenant: "team-a"}, want: true},
+		{name: "tenant and site", selector: &ClusterSelector{Tenant: "team-a", Site: "berlin"}, want: false},
+		{name: "labels", selector: &ClusterSelector{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}}, want: true},
+		{name: "expression", selector: &ClusterSelector{Environment: "prod", LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "region", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"eu"}}},
+		}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Matches(cc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBreakglassEscalationClusterSelectorValidation(t *testing.T) {
+	newEscalation := func(selector *ClusterSelector) *BreakglassEscalation {
+		return &BreakglassEscalation{
+			ObjectMeta: metav1.ObjectMeta{Name: "esc-selector"},
+			Spec: BreakglassEscalationSpec{
+				EscalatedGroup:  "ops",
+				Approvers:       BreakglassEscalationApprovers{Users: []string{"approver@example.com"}},
+				ClusterSelector: selector,
+			},
+		}
+	}
+
+	esc := newEscalation(&ClusterSelector{Environment: "prod"})
+	if _, err := esc.ValidateCreate(context.Background(), esc); err != nil {
+		t.Fatalf("expected clusterSelector to satisfy the cluster requirement, got %v", err)
+	}
+	esc = newEscalation(&ClusterSelector{})
+	if _, err := esc.ValidateCreate(context.Background(), esc); err == nil {
+		t.Fatalf("expected empty clusterSelector to be rejected")
+	}
+	esc = newEscalation(&ClusterSelector{LabelSelector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}},
+	}})
+	if _, err := esc.ValidateCreate(context.Background(), esc); err == nil {
+		t.Fatalf("expected invalid labelSelector to be rejected")
+	}
+}
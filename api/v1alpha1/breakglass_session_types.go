@@ -111,6 +111,11 @@ type BreakglassSessionSpec struct {
 	// +optional
 	IdentityProviderName string `json:"identityProviderName,omitempty"`
 
+	// userID is the subject (sub claim) of the token the session was requested with.
+	// Used to end the sessions of a user on back-channel logout.
+	// +optional
+	UserID string `json:"userID,omitempty"`
+
 	// identityProviderIssuer is the OIDC issuer URL (from JWT 'iss' claim) of the IDP that authenticated the user.
 	// Set during session creation for validation and audit purposes.
 	// Must match the IdentityProvider.spec.issuer of the provider that authenticated the user.
@@ -200,6 +205,51 @@ type BreakglassSessionStatus struct {
 	// Possible values: "timeExpired", "canceled", "dropped", "withdrawn", "rejected"
 	// +optional
 	ReasonEnded string `json:"reasonEnded,omitempty"`
+
+	// approvalLinks records the use and revocation of signed one-click approval links
+	// that were sent to approvers by email.
+	// +optional
+	ApprovalLinks *ApprovalLinksStatus `json:"approvalLinks,omitempty"`
+}
+
+// ApprovalLinksStatus records the use and revocation of one-click approval links for a session
+type ApprovalLinksStatus struct {
+	// links lists approval links that were used or individually revoked.
+	// +optional
+	Links []ApprovalLinkRecord `json:"links,omitempty"`
+
+	// revokedAt invalidates all approval links issued at or before this time.
+	// +optional
+	RevokedAt metav1.Time `json:"revokedAt,omitempty"`
+
+	// revokedBy is the identity (email) that revoked all approval links of the session.
+	// +optional
+	RevokedBy string `json:"revokedBy,omitempty"`
+}
+
+// ApprovalLinkRecord describes a single approval link token that was used or revoked
+type ApprovalLinkRecord struct {
+	// id is the unique token identifier (jti claim).
+	ID string `json:"id"`
+
+	// action is the decision carried by the link ("approve" or "reject").
+	Action string `json:"action"`
+
+	// approver is the email address the link was issued to.
+	// +optional
+	Approver string `json:"approver,omitempty"`
+
+	// usedAt is the time when the link was redeemed.
+	// +optional
+	UsedAt metav1.Time `json:"usedAt,omitempty"`
+
+	// usedBy is the authenticated identity that redeemed the link.
+	// +optional
+	UsedBy string `json:"usedBy,omitempty"`
+
+	// revokedAt is the time when the link was revoked.
+	// +optional
+	RevokedAt metav1.Time `json:"revokedAt,omitempty"`
 }
 
 // +kubebuilder:resource:scope=Namespaced,shortName=bgs
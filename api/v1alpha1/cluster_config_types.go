@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
@@ -21,6 +22,12 @@ const (
 	// ClusterConfigConditionReady indicates the ClusterConfig is ready for use.
 	// Condition fails when kubeconfig is invalid, cluster is unreachable, or other checks fail.
 	ClusterConfigConditionReady ClusterConfigConditionType = "Ready"
+	// ClusterConfigConditionLeastPrivilege reports whether the cluster credentials hold more rights
+	// than breakglass needs. It is informational and does not affect Ready.
+	ClusterConfigConditionLeastPrivilege ClusterConfigConditionType = "LeastPrivilege"
+	// ClusterConfigConditionCertificatesValid reports whether the client certificate and certificate
+	// authority of the cluster credentials are far enough from expiry. It is informational and does not affect Ready.
+	ClusterConfigConditionCertificatesValid ClusterConfigConditionType = "CertificatesValid"
 )
 
 // ClusterConfigSpec defines metadata and secret reference for a managed tenant cluster.
@@ -53,7 +60,14 @@ type ClusterConfigSpec struct {
 
 	// kubeconfigSecretRef references a secret containing an admin-level kubeconfig for the target cluster.
 	// The referenced Secret MUST exist in the specified namespace and contain the key (default: kubeconfig).
-	KubeconfigSecretRef SecretKeyReference `json:"kubeconfigSecretRef"`
+	// Exactly one of kubeconfigSecretRef and auth must be set.
+	// +optional
+	KubeconfigSecretRef *SecretKeyReference `json:"kubeconfigSecretRef,omitempty"`
+
+	// auth configures access to the target cluster from an API server URL and a single credential,
+	// as an alternative to a kubeconfig stored in a Secret.
+	// +optional
+	Auth *ClusterAuth `json:"auth,omitempty"`
 
 	// qps configures the client QPS against the target cluster.
 	// +optional
@@ -87,6 +101,159 @@ type ClusterConfigSpec struct {
 	// +kubebuilder:validation:MaxLength=63
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
 	MailProvider string `json:"mailProvider,omitempty"`
+
+	// groupMapping translates group names between the identity provider and this cluster.
+	// When set, it replaces the global kubernetes.oidcPrefixes heuristics for this cluster.
+	// +optional
+	GroupMapping *GroupMapping `json:"groupMapping,omitempty"`
+
+	// accessReviewMode selects how breakglass asks this cluster whether groups may perform a request.
+	// Impersonation (default) requires the cluster credentials to impersonate users and groups;
+	// SubjectAccessReview only requires create on subjectaccessreviews.
+	// +optional
+	// +kubebuilder:default=Impersonation
+	AccessReviewMode AccessReviewMode `json:"accessReviewMode,omitempty"`
+}
+
+// AccessReviewMode selects how authorization checks are performed on a cluster
+// +kubebuilder:validation:Enum=Impersonation;SubjectAccessReview
+type AccessReviewMode string
+
+const (
+	// AccessReviewModeImpersonation creates SelfSubjectAccessReviews while impersonating the
+	// groups under review, and resolves user groups by impersonating the user
+	AccessReviewModeImpersonation AccessReviewMode = "Impersonation"
+	// AccessReviewModeSubjectAccessReview creates SubjectAccessReviews naming the groups under review.
+	// User groups are taken from identity tokens only.
+	AccessReviewModeSubjectAccessReview AccessReviewMode = "SubjectAccessReview"
+)
+
+// GroupMapping describes how the API server of a cluster names the groups of the identity provider.
+// Escalations, sessions and tokens use identity provider group names; RBAC on the cluster uses
+// cluster group names.
+type GroupMapping struct {
+	// oidcPrefix is the prefix the cluster's API server adds to groups of OIDC tokens
+	// (--oidc-groups-prefix), e.g. "oidc:". It is added to all groups without a rule.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	OIDCPrefix string `json:"oidcPrefix,omitempty"`
+
+	// rules rename individual groups. The cluster group of a rule is used as is, oidcPrefix is not added.
+	// +optional
+	Rules []GroupMappingRule `json:"rules,omitempty"`
+}
+
+// GroupMappingRule maps one identity provider group to its name on the cluster.
+type GroupMappingRule struct {
+	// idpGroup is the group name in tokens and escalations.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	IDPGroup string `json:"idpGroup"`
+
+	// clusterGroup is the group name used by RBAC on the cluster.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	ClusterGroup string `json:"clusterGroup"`
+}
+
+// ToCluster returns the cluster name of an identity provider group. A nil mapping keeps the name.
+func (gm *GroupMapping) ToCluster(group string) string {
+	if gm == nil {
+		return group
+	}
+	for _, r := range gm.Rules {
+		if r.IDPGroup == group {
+			return r.ClusterGroup
+		}
+	}
+	return gm.OIDCPrefix + group
+}
+
+// FromCluster returns the identity provider name of a cluster group, e.g. of a group reported
+// by the cluster's API server. A nil mapping keeps the name.
+func (gm *GroupMapping) FromCluster(group string) string {
+	if gm == nil {
+		return group
+	}
+	for _, r := range gm.Rules {
+		if r.ClusterGroup == group {
+			return r.IDPGroup
+		}
+	}
+	return strings.TrimPrefix(group, gm.OIDCPrefix)
+}
+
+// GroupsToCluster maps identity provider groups to cluster groups
+func (gm *GroupMapping) GroupsToCluster(groups []string) []string {
+	out := make([]string, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, gm.ToCluster(g))
+	}
+	return out
+}
+
+// GroupsFromCluster maps cluster groups to identity provider groups
+func (gm *GroupMapping) GroupsFromCluster(groups []string) []string {
+	out := make([]string, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, gm.FromCluster(g))
+	}
+	return out
+}
+
+// ClusterAuth configures access to a cluster's API server without a kubeconfig.
+// Exactly one of tokenSecretRef, clientCertificateSecretRef and workloadIdentity must be set.
+type ClusterAuth struct {
+	// server is the URL of the cluster's API server, e.g. https://api.cluster.example.com:6443
+	// +kubebuilder:validation:Pattern=`^https://`
+	Server string `json:"server"`
+
+	// certificateAuthority is the PEM-encoded CA bundle used to verify the API server.
+	// The system trust store is used when empty.
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+
+	// tlsServerName overrides the server name used to verify the API server certificate.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// tokenSecretRef references a Secret holding a bearer token (default key: token), e.g. a
+	// ServiceAccount token Secret. Rotated tokens are picked up when the Secret changes.
+	// +optional
+	TokenSecretRef *SecretKeyReference `json:"tokenSecretRef,omitempty"`
+
+	// clientCertificateSecretRef references a kubernetes.io/tls Secret whose tls.crt and tls.key
+	// entries authenticate to the API server. Renewed certificates are picked up when the Secret changes.
+	// +optional
+	ClientCertificateSecretRef *SecretReference `json:"clientCertificateSecretRef,omitempty"`
+
+	// workloadIdentity exchanges the controller's projected ServiceAccount token for an access token
+	// accepted by the cluster, so no long-lived credential is stored.
+	// +optional
+	WorkloadIdentity *WorkloadIdentityAuth `json:"workloadIdentity,omitempty"`
+}
+
+// WorkloadIdentityAuth configures an OAuth 2.0 token exchange (RFC 8693) of the controller's projected
+// ServiceAccount token. The token file is configured on the controller (kubernetes.workloadIdentityTokenFile).
+type WorkloadIdentityAuth struct {
+	// tokenURL is the token endpoint of the security token service. The controller's projected
+	// ServiceAccount token is sent to it, so it must be listed in kubernetes.workloadIdentityTokenURLs
+	// of the controller configuration.
+	// +kubebuilder:validation:Pattern=`^https://`
+	TokenURL string `json:"tokenURL"`
+
+	// audience requested for the exchanged token, e.g. the OIDC client ID trusted by the cluster.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+
+	// scopes requested for the exchanged token.
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+
+	// clientID is sent as client_id when the security token service requires it.
+	// +optional
+	ClientID string `json:"clientID,omitempty"`
 }
 
 // SecretKeyReference is a namespaced secret key reference supporting cross-namespace references.
@@ -111,6 +278,35 @@ type ClusterConfigStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// KubernetesVersion is the version reported by the cluster's API server on the last successful probe
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// APIEndpoint is the API server URL taken from the cluster credentials
+	// +optional
+	APIEndpoint string `json:"apiEndpoint,omitempty"`
+
+	// LastProbeTime is the time of the last successful reachability probe
+	// +optional
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// LastProbeLatency is the round trip time of the last successful reachability probe
+	// +optional
+	LastProbeLatency *metav1.Duration `json:"lastProbeLatency,omitempty"`
+
+	// ClientCertificateExpiry is the earliest expiry of the client certificates in the cluster credentials
+	// +optional
+	ClientCertificateExpiry *metav1.Time `json:"clientCertificateExpiry,omitempty"`
+
+	// CertificateAuthorityExpiry is the earliest expiry of the CA certificates in the cluster credentials
+	// +optional
+	CertificateAuthorityExpiry *metav1.Time `json:"certificateAuthorityExpiry,omitempty"`
+
+	// AuthorizationWebhookConfigured reports whether the cluster's API server consults the breakglass
+	// authorization webhook. It is unset when the probe could not be run.
+	// +optional
+	AuthorizationWebhookConfigured *bool `json:"authorizationWebhookConfigured,omitempty"`
+
 	// Conditions track ClusterConfig state (kubeconfig validation, connection status, etc.)
 	// All status information is conveyed through conditions.
 	// +optional
@@ -127,6 +323,7 @@ type ClusterConfigStatus struct {
 // +kubebuilder:printcolumn:name="Tenant",type=string,JSONPath=`.spec.tenant`
 // +kubebuilder:printcolumn:name="ClusterID",type=string,JSONPath=`.spec.clusterID`
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.kubernetesVersion`
 type ClusterConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -147,9 +344,7 @@ func (cc *ClusterConfig) ValidateCreate(ctx context.Context, obj runtime.Object)
 	var allErrs field.ErrorList
 	specPath := field.NewPath("spec")
 
-	if clusterConfig.Spec.KubeconfigSecretRef.Name == "" || clusterConfig.Spec.KubeconfigSecretRef.Namespace == "" {
-		allErrs = append(allErrs, field.Required(specPath.Child("kubeconfigSecretRef"), "kubeconfigSecretRef name and namespace are required"))
-	}
+	allErrs = append(allErrs, validateClusterAccess(&clusterConfig.Spec, specPath)...)
 	allErrs = append(allErrs, ensureClusterWideUniqueName(ctx, &ClusterConfigList{}, clusterConfig.Namespace, clusterConfig.Name, field.NewPath("metadata").Child("name"))...)
 
 	// Multi-IDP: Validate IdentityProviderRefs (empty refs is valid - means accept all enabled IDPs)
@@ -161,6 +356,7 @@ func (cc *ClusterConfig) ValidateCreate(ctx context.Context, obj runtime.Object)
 	// Validate optional mail provider reference
 	allErrs = append(allErrs, validateIdentifierFormat(clusterConfig.Spec.MailProvider, specPath.Child("mailProvider"))...)
 	allErrs = append(allErrs, validateMailProviderReference(ctx, clusterConfig.Spec.MailProvider, specPath.Child("mailProvider"))...)
+	allErrs = append(allErrs, validateGroupMapping(clusterConfig.Spec.GroupMapping, specPath.Child("groupMapping"))...)
 
 	if len(allErrs) == 0 {
 		return nil, nil
@@ -178,6 +374,7 @@ func (cc *ClusterConfig) ValidateUpdate(ctx context.Context, oldObj, newObj runt
 	var allErrs field.ErrorList
 	specPath := field.NewPath("spec")
 	// no immutability enforcement for ClusterConfig
+	allErrs = append(allErrs, validateClusterAccess(&clusterConfig.Spec, specPath)...)
 	// still ensure the name is unique across the cluster
 	allErrs = append(allErrs, ensureClusterWideUniqueName(ctx, &ClusterConfigList{}, clusterConfig.Namespace, clusterConfig.Name, field.NewPath("metadata").Child("name"))...)
 
@@ -186,6 +383,7 @@ func (cc *ClusterConfig) ValidateUpdate(ctx context.Context, oldObj, newObj runt
 	allErrs = append(allErrs, validateEmailDomainList(clusterConfig.Spec.AllowedApproverDomains, specPath.Child("allowedApproverDomains"))...)
 	allErrs = append(allErrs, validateIdentifierFormat(clusterConfig.Spec.MailProvider, specPath.Child("mailProvider"))...)
 	allErrs = append(allErrs, validateMailProviderReference(ctx, clusterConfig.Spec.MailProvider, specPath.Child("mailProvider"))...)
+	allErrs = append(allErrs, validateGroupMapping(clusterConfig.Spec.GroupMapping, specPath.Child("groupMapping"))...)
 
 	if len(allErrs) == 0 {
 		return nil, nil
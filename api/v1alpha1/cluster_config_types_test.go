@@ -21,7 +21,7 @@ func TestClusterConfig_ValidateCreate_Success(t *testing.T) {
 	cc := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-1"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
 		},
 	}
 	_, err := cc.ValidateCreate(context.Background(), cc)
@@ -34,7 +34,7 @@ func TestClusterConfig_ValidateCreate_DuplicateIdentityProviderRefs(t *testing.T
 	cc := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-dup"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef:  SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef:  &SecretKeyReference{Name: "k", Namespace: "ns"},
 			IdentityProviderRefs: []string{"idp-a", "idp-a"},
 		},
 	}
@@ -49,7 +49,7 @@ func TestClusterConfig_ValidateCreate_InvalidApproverDomains(t *testing.T) {
 	cc := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-domain"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef:    SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef:    &SecretKeyReference{Name: "k", Namespace: "ns"},
 			AllowedApproverDomains: []string{"invalid_domain"},
 		},
 	}
@@ -91,7 +91,7 @@ func TestClusterConfig_ValidateCreate_MailProviderReference(t *testing.T) {
 	valid := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-mail-valid"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
 			MailProvider:        "mail-enabled",
 		},
 	}
@@ -103,7 +103,7 @@ func TestClusterConfig_ValidateCreate_MailProviderReference(t *testing.T) {
 	disabled := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-mail-disabled"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
 			MailProvider:        "mail-disabled",
 		},
 	}
@@ -115,7 +115,7 @@ func TestClusterConfig_ValidateCreate_MailProviderReference(t *testing.T) {
 	missing := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-mail-missing"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
 			MailProvider:        "does-not-exist",
 		},
 	}
@@ -129,7 +129,7 @@ func TestClusterConfig_ValidateUpdate_Immutable(t *testing.T) {
 	old := &ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cc-2"},
 		Spec: ClusterConfigSpec{
-			KubeconfigSecretRef: SecretKeyReference{Name: "k", Namespace: "ns"},
+			KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
 		},
 	}
 	modified := old.DeepCopy()
@@ -172,3 +172,101 @@ func TestClusterConfigValidateDelete(t *testing.T) {
 		t.Fatalf("expected ValidateDelete to allow deletes, warnings=%v err=%v", warnings, err)
 	}
 }
+
+func TestClusterConfig_ValidateCreate_GroupMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *GroupMapping
+		wantErr bool
+	}{
+		{name: "prefix only", mapping: &GroupMapping{OIDCPrefix: "oidc:"}},
+		{name: "rules", mapping: &GroupMapping{Rules: []GroupMappingRule{{IDPGroup: "a", ClusterGroup: "x"}, {IDPGroup: "b", ClusterGroup: "y"}}}},
+		{name: "prefix with whitespace", mapping: &GroupMapping{OIDCPrefix: " oidc:"}, wantErr: true},
+		{name: "empty cluster group", mapping: &GroupMapping{Rules: []GroupMappingRule{{IDPGroup: "a"}}}, wantErr: true},
+		{name: "duplicate idp group", mapping: &GroupMapping{Rules: []GroupMappingRule{{IDPGroup: "a", ClusterGroup: "x"}, {IDPGroup: "a", ClusterGroup: "y"}}}, wantErr: true},
+		{name: "duplicate cluster group", mapping: &GroupMapping{Rules: []GroupMappingRule{{IDPGroup: "a", ClusterGroup: "x"}, {IDPGroup: "b", ClusterGroup: "x"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &ClusterConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "cc-mapping"},
+				Spec: ClusterConfigSpec{
+					KubeconfigSecretRef: &SecretKeyReference{Name: "k", Namespace: "ns"},
+					GroupMapping:        tt.mapping,
+				},
+			}
+			_, err := cc.ValidateCreate(context.Background(), cc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClusterConfig_ValidateCreate_Auth(t *testing.T) {
+	token := &SecretKeyReference{Name: "t", Namespace: "ns"}
+	tests := []struct {
+		name       string
+		kubeconfig *SecretKeyReference
+		auth       *ClusterAuth
+		wantErr    bool
+	}{
+		{name: "token", auth: &ClusterAuth{Server: "https://api.example.com:6443", TokenSecretRef: token}},
+		{name: "client certificate", auth: &ClusterAuth{Server: "https://api.example.com", ClientCertificateSecretRef: &SecretReference{Name: "c", Namespace: "ns"}}},
+		{name: "workload identity", auth: &ClusterAuth{Server: "https://api.example.com", WorkloadIdentity: &WorkloadIdentityAuth{TokenURL: "https://sts.example.com/token", Audience: "spoke"}}},
+		{name: "kubeconfig and auth", kubeconfig: &SecretKeyReference{Name: "k", Namespace: "ns"}, auth: &ClusterAuth{Server: "https://api.example.com", TokenSecretRef: token}, wantErr: true},
+		{name: "no credential", auth: &ClusterAuth{Server: "https://api.example.com"}, wantErr: true},
+		{name: "two credentials", auth: &ClusterAuth{Server: "https://api.example.com", TokenSecretRef: token, ClientCertificateSecretRef: &SecretReference{Name: "c", Namespace: "ns"}}, wantErr: true},
+		{name: "missing server", auth: &ClusterAuth{TokenSecretRef: token}, wantErr: true},
+		{name: "plain http server", auth: &ClusterAuth{Server: "http://api.example.com", TokenSecretRef: token}, wantErr: true},
+		{name: "token secret without namespace", auth: &ClusterAuth{Server: "https://api.example.com", TokenSecretRef: &SecretKeyReference{Name: "t"}}, wantErr: true},
+		{name: "workload identity without token URL", auth: &ClusterAuth{Server: "https://api.example.com", WorkloadIdentity: &WorkloadIdentityAuth{}}, wantErr: true},
+		{name: "workload identity with empty scope", auth: &ClusterAuth{Server: "https://api.example.com", WorkloadIdentity: &WorkloadIdentityAuth{TokenURL: "https://sts.example.com/token", Scopes: []string{""}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &ClusterConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "cc-auth"},
+				Spec:       ClusterConfigSpec{KubeconfigSecretRef: tt.kubeconfig, Auth: tt.auth},
+			}
+			_, err := cc.ValidateCreate(context.Background(), cc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupMapping(t *testing.T) {
+	var none *GroupMapping
+	if got := none.ToCluster("ops"); got != "ops" {
+		t.Fatalf("nil mapping ToCluster = %q", got)
+	}
+	if got := none.FromCluster("oidc:ops"); got != "oidc:ops" {
+		t.Fatalf("nil mapping FromCluster = %q", got)
+	}
+
+	gm := &GroupMapping{
+		OIDCPrefix: "oidc:",
+		Rules:      []GroupMappingRule{{IDPGroup: "platform-admins", ClusterGroup: "cluster-admins"}},
+	}
+	cases := []struct{ idp, cluster string }{
+		{"ops", "oidc:ops"},
+		{"platform-admins", "cluster-admins"},
+	}
+	for _, c := range cases {
+		if got := gm.ToCluster(c.idp); got != c.cluster {
+			t.Errorf("ToCluster(%q) = %q, want %q", c.idp, got, c.cluster)
+		}
+		if got := gm.FromCluster(c.cluster); got != c.idp {
+			t.Errorf("FromCluster(%q) = %q, want %q", c.cluster, got, c.idp)
+		}
+	}
+	// groups added by the API server itself carry no prefix and stay unchanged
+	if got := gm.GroupsFromCluster([]string{"system:authenticated", "oidc:ops"}); got[0] != "system:authenticated" || got[1] != "ops" {
+		t.Fatalf("GroupsFromCluster = %v", got)
+	}
+	if got := gm.GroupsToCluster([]string{"ops", "platform-admins"}); got[0] != "oidc:ops" || got[1] != "cluster-admins" {
+		t.Fatalf("GroupsToCluster = %v", got)
+	}
+}
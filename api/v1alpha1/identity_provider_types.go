@@ -19,24 +19,35 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // GroupSyncProvider defines which provider to use for group synchronization
-// +kubebuilder:validation:Enum=Keycloak
+// +kubebuilder:validation:Enum=Keycloak;LDAP;SCIM;MicrosoftGraph;HTTPJSON
 type GroupSyncProvider string
 
 const (
 	// GroupSyncProviderKeycloak uses Keycloak for group/user synchronization
 	GroupSyncProviderKeycloak GroupSyncProvider = "Keycloak"
+	// GroupSyncProviderLDAP uses an LDAP directory (e.g. Active Directory, OpenLDAP) for group/user synchronization
+	GroupSyncProviderLDAP GroupSyncProvider = "LDAP"
+	// GroupSyncProviderSCIM stores users and groups pushed by the identity provider through the SCIM API
+	GroupSyncProviderSCIM GroupSyncProvider = "SCIM"
+	// GroupSyncProviderMicrosoftGraph uses the Microsoft Graph API (Entra ID) for group/user synchronization
+	GroupSyncProviderMicrosoftGraph GroupSyncProvider = "MicrosoftGraph"
+	// GroupSyncProviderHTTPJSON queries a generic HTTP endpoint returning group members as JSON
+	GroupSyncProviderHTTPJSON GroupSyncProvider = "HTTPJSON"
 )
 
 // IdentityProviderConditionType defines the type of condition for IdentityProvider status
@@ -78,6 +89,11 @@ type OIDCConfig struct {
 	// +kubebuilder:validation:Pattern=`^\S+$`
 	ClientID string `json:"clientID"`
 
+	// ClientSecretRef references the client secret of confidential clients. It is only used by
+	// the server-side login (backend-for-frontend mode); public clients use PKCE alone.
+	// +optional
+	ClientSecretRef *SecretKeyReference `json:"clientSecretRef,omitempty"`
+
 	// InsecureSkipVerify allows skipping TLS verification (NOT for production!)
 	// +optional
 	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
@@ -127,6 +143,233 @@ type KeycloakGroupSync struct {
 	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
 	// +optional
 	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+
+	// RequestsPerSecond limits the Keycloak admin API requests made by group sync (default: 10)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the number of requests allowed above requestsPerSecond for short periods (default: 20)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// LDAPNestedGroupMode controls how members of nested groups are resolved
+// +kubebuilder:validation:Enum=None;Recursive;InChain
+type LDAPNestedGroupMode string
+
+const (
+	// LDAPNestedGroupsNone only returns direct members of the group
+	LDAPNestedGroupsNone LDAPNestedGroupMode = "None"
+	// LDAPNestedGroupsRecursive walks subgroups (groups whose memberOf contains the group) on the client
+	LDAPNestedGroupsRecursive LDAPNestedGroupMode = "Recursive"
+	// LDAPNestedGroupsInChain lets Active Directory resolve nested memberships with the
+	// LDAP_MATCHING_RULE_IN_CHAIN matching rule in a single search
+	LDAPNestedGroupsInChain LDAPNestedGroupMode = "InChain"
+)
+
+// LDAPGroupSync holds LDAP-specific group synchronization configuration.
+// Users are found by their memberOf attribute, which Active Directory maintains natively
+// and OpenLDAP maintains with the memberof overlay.
+type LDAPGroupSync struct {
+	// URL is the LDAP server URL
+	// Example: ldaps://ad.example.com:636 or ldap://ldap.example.com
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^ldaps?://.+`
+	URL string `json:"url"`
+
+	// StartTLS upgrades an ldap:// connection with the StartTLS extended operation
+	// +optional
+	StartTLS bool `json:"startTLS,omitempty"`
+
+	// BindDN is the distinguished name of the service account used for searches (should have read access only)
+	// Example: cn=svc-breakglass,ou=services,dc=example,dc=com
+	// +kubebuilder:validation:MinLength=1
+	BindDN string `json:"bindDN"`
+
+	// BindPasswordRef references a Secret containing the bind password
+	BindPasswordRef SecretKeyReference `json:"bindPasswordRef"`
+
+	// UserSearchBase is the DN under which users are searched
+	// +kubebuilder:validation:MinLength=1
+	UserSearchBase string `json:"userSearchBase"`
+
+	// UserFilter restricts which entries are users (default: (objectClass=person))
+	// +optional
+	UserFilter string `json:"userFilter,omitempty"`
+
+	// UserIdentifierAttributes lists the attributes that identify a user, in order of preference.
+	// The first non-empty value is returned as the member, so it should match the email claim of tokens.
+	// Default: ["mail", "userPrincipalName", "uid"]
+	// +optional
+	UserIdentifierAttributes []string `json:"userIdentifierAttributes,omitempty"`
+
+	// MemberOfAttribute is the user attribute holding group DNs (default: memberOf)
+	// +optional
+	MemberOfAttribute string `json:"memberOfAttribute,omitempty"`
+
+	// GroupSearchBase is the DN under which groups are searched
+	// +kubebuilder:validation:MinLength=1
+	GroupSearchBase string `json:"groupSearchBase"`
+
+	// GroupFilter restricts which entries are groups
+	// Default: (|(objectClass=group)(objectClass=groupOfNames)(objectClass=groupOfUniqueNames))
+	// +optional
+	GroupFilter string `json:"groupFilter,omitempty"`
+
+	// GroupNameAttribute is the group attribute matched against escalation approver group names (default: cn)
+	// +optional
+	GroupNameAttribute string `json:"groupNameAttribute,omitempty"`
+
+	// NestedGroups controls how members of nested groups are resolved (default: Recursive)
+	// +optional
+	NestedGroups LDAPNestedGroupMode `json:"nestedGroups,omitempty"`
+
+	// MaxNestingDepth limits how deep Recursive mode follows subgroups (default: 10)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxNestingDepth int32 `json:"maxNestingDepth,omitempty"`
+
+	// PageSize is the page size for paged searches (default: 500)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10000
+	PageSize int32 `json:"pageSize,omitempty"`
+
+	// CacheTTL is the duration to cache group memberships (default: 10m)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	CacheTTL string `json:"cacheTTL,omitempty"`
+
+	// RequestTimeout is the timeout for resolving the members of one group (default: 10s)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+
+	// InsecureSkipVerify allows skipping TLS verification (NOT for production!)
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+}
+
+// SCIMGroupSync configures the SCIM 2.0 endpoints through which the identity provider
+// pushes users and groups
+type SCIMGroupSync struct {
+	// BearerTokenRef references a Secret containing the bearer token the identity provider
+	// presents to the SCIM endpoints. Each IdentityProvider must use a distinct token.
+	BearerTokenRef SecretKeyReference `json:"bearerTokenRef"`
+}
+
+// MicrosoftGraphGroupSync holds Microsoft Graph (Entra ID) group synchronization configuration.
+// The app registration needs the GroupMember.Read.All and User.Read.All application permissions.
+type MicrosoftGraphGroupSync struct {
+	// TenantID is the directory (tenant) ID of the app registration
+	// +kubebuilder:validation:MinLength=1
+	TenantID string `json:"tenantID"`
+
+	// ClientID is the application (client) ID of the app registration
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^\S+$`
+	ClientID string `json:"clientID"`
+
+	// ClientSecretRef references a Secret containing the client secret
+	ClientSecretRef SecretKeyReference `json:"clientSecretRef"`
+
+	// AuthorityURL is the login endpoint for national clouds (default: https://login.microsoftonline.com)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https://.+`
+	AuthorityURL string `json:"authorityURL,omitempty"`
+
+	// GraphURL is the Graph endpoint for national clouds (default: https://graph.microsoft.com)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https://.+`
+	GraphURL string `json:"graphURL,omitempty"`
+
+	// UserIdentifierAttributes lists the user properties that identify a member, in order of preference.
+	// Default: ["mail", "userPrincipalName"]
+	// +optional
+	UserIdentifierAttributes []string `json:"userIdentifierAttributes,omitempty"`
+
+	// CacheTTL is the duration to cache group memberships (default: 10m)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	CacheTTL string `json:"cacheTTL,omitempty"`
+
+	// RequestTimeout is the timeout for resolving the members of one group (default: 10s)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+
+	// InsecureSkipVerify allows skipping TLS verification (NOT for production!)
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+}
+
+// HTTPJSONGroupSync configures group synchronization against a generic HTTP endpoint that
+// returns the members of a group as JSON
+type HTTPJSONGroupSync struct {
+	// MembersURL is the URL template for the members of a group. {group} is replaced by the
+	// URL-escaped group name.
+	// Example: https://directory.example.com/api/groups/{group}/members
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://.+`
+	MembersURL string `json:"membersURL"`
+
+	// MembersPath is a JSONPath expression selecting the member identifiers in the response
+	// Example: $.members[*].email
+	// +kubebuilder:validation:MinLength=1
+	MembersPath string `json:"membersPath"`
+
+	// NextPagePath is a JSONPath expression selecting the URL of the next page; an empty
+	// result ends paging. Next page URLs must point to the host of MembersURL.
+	// Example: $.links.next
+	// +optional
+	NextPagePath string `json:"nextPagePath,omitempty"`
+
+	// MaxPages limits the number of pages fetched for one group (default: 50)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	MaxPages int32 `json:"maxPages,omitempty"`
+
+	// TokenRef references a Secret containing a token sent with every request
+	// +optional
+	TokenRef *SecretKeyReference `json:"tokenRef,omitempty"`
+
+	// TokenHeader is the header carrying the token (default: Authorization, sent as "Bearer <token>").
+	// Any other header receives the token as is, e.g. X-API-Key.
+	// +optional
+	TokenHeader string `json:"tokenHeader,omitempty"`
+
+	// CacheTTL is the duration to cache group memberships (default: 10m)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	CacheTTL string `json:"cacheTTL,omitempty"`
+
+	// RequestTimeout is the timeout for resolving the members of one group (default: 10s)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+
+	// InsecureSkipVerify allows skipping TLS verification (NOT for production!)
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
 }
 
 // IdentityProviderSpec defines the desired state of an IdentityProvider
@@ -145,6 +388,26 @@ type IdentityProviderSpec struct {
 	// +optional
 	Keycloak *KeycloakGroupSync `json:"keycloak,omitempty"`
 
+	// LDAP holds LDAP-specific configuration for group synchronization
+	// Required when groupSyncProvider is "LDAP"
+	// +optional
+	LDAP *LDAPGroupSync `json:"ldap,omitempty"`
+
+	// SCIM holds the SCIM provisioning configuration for group synchronization
+	// Required when groupSyncProvider is "SCIM"
+	// +optional
+	SCIM *SCIMGroupSync `json:"scim,omitempty"`
+
+	// MicrosoftGraph holds Microsoft Graph configuration for group synchronization
+	// Required when groupSyncProvider is "MicrosoftGraph"
+	// +optional
+	MicrosoftGraph *MicrosoftGraphGroupSync `json:"microsoftGraph,omitempty"`
+
+	// HTTPJSON holds the generic HTTP JSON configuration for group synchronization
+	// Required when groupSyncProvider is "HTTPJSON"
+	// +optional
+	HTTPJSON *HTTPJSONGroupSync `json:"httpJSON,omitempty"`
+
 	// Issuer is the OIDC issuer URL, which must match the 'iss' claim in JWT tokens
 	// This uniquely identifies the identity provider and is used to determine which provider
 	// authenticated a user based on their JWT token.
@@ -167,6 +430,475 @@ type IdentityProviderSpec struct {
 	// Disabled can be set to true to temporarily disable this provider without deleting it
 	// +optional
 	Disabled bool `json:"disabled,omitempty"`
+
+	// ClaimMappings configures which token claims carry the username, email and groups
+	// for tokens issued by this provider. Unset fields use the defaults
+	// (preferred_username, email, groups with a realm_access.roles fallback).
+	// +optional
+	ClaimMappings *ClaimMappings `json:"claimMappings,omitempty"`
+
+	// TokenValidation configures audience, authorized party and required claim checks
+	// for tokens issued by this provider. By default the token must name the OIDC clientID
+	// in its aud or azp claim.
+	// +optional
+	TokenValidation *TokenValidation `json:"tokenValidation,omitempty"`
+
+	// TokenRevocation makes the API honour tokens revoked before they expire, through token
+	// introspection and/or OIDC Back-Channel Logout
+	// +optional
+	TokenRevocation *TokenRevocation `json:"tokenRevocation,omitempty"`
+}
+
+// TokenRevocation configures how revoked tokens and logged out users are detected
+type TokenRevocation struct {
+	// Introspection checks every access token at the RFC 7662 introspection endpoint of the provider
+	// +optional
+	Introspection *TokenIntrospection `json:"introspection,omitempty"`
+
+	// BackChannelLogout accepts OIDC Back-Channel Logout tokens from the provider at
+	// /api/oidc/backchannel-logout. Access tokens of logged out users are rejected afterwards.
+	// +optional
+	BackChannelLogout bool `json:"backChannelLogout,omitempty"`
+
+	// LogoutRetention is how long logout events are remembered (default: 1h). It should be
+	// at least the access token lifetime of the provider.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	LogoutRetention string `json:"logoutRetention,omitempty"`
+
+	// TerminateSessions ends the pending and active breakglass sessions of a user when the user
+	// logs out or introspection reports their token as inactive
+	// +optional
+	TerminateSessions bool `json:"terminateSessions,omitempty"`
+}
+
+// TokenIntrospection configures RFC 7662 token introspection
+type TokenIntrospection struct {
+	// Endpoint is the introspection endpoint URL
+	// Example: https://keycloak.example.com/realms/master/protocol/openid-connect/token/introspect
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://.+`
+	Endpoint string `json:"endpoint"`
+
+	// ClientID authenticates Breakglass at the introspection endpoint
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// ClientSecretRef references a Secret containing the client secret
+	ClientSecretRef SecretKeyReference `json:"clientSecretRef"`
+
+	// CacheTTL is how long an introspection result is reused (default: 30s). Results are
+	// never reused past the expiry of the token.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	CacheTTL string `json:"cacheTTL,omitempty"`
+
+	// RequestTimeout is the timeout for one introspection request (default: 5s)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+
+	// FailOpen accepts tokens when the introspection endpoint cannot be reached. By default
+	// such requests are rejected.
+	// +optional
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// LogoutRevocation records an OIDC Back-Channel Logout event. Access tokens with the subject
+// issued up to RevokedAt, and all access tokens of the IdP session SessionID, are rejected.
+type LogoutRevocation struct {
+	// Subject is the sub claim of the logout token
+	// +optional
+	Subject string `json:"subject,omitempty"`
+
+	// SessionID is the sid claim of the logout token
+	// +optional
+	SessionID string `json:"sessionID,omitempty"`
+
+	// RevokedAt is the issue time of the logout token
+	RevokedAt metav1.Time `json:"revokedAt"`
+}
+
+// AudienceValidationMode controls how the aud and azp claims are checked
+// +kubebuilder:validation:Enum=AudienceOrAuthorizedParty;Audience;Disabled
+type AudienceValidationMode string
+
+const (
+	// AudienceValidationAudienceOrAuthorizedParty accepts tokens whose aud contains an accepted
+	// audience or whose azp is an authorized party (Keycloak access tokens carry the client in azp)
+	AudienceValidationAudienceOrAuthorizedParty AudienceValidationMode = "AudienceOrAuthorizedParty"
+	// AudienceValidationAudience requires an accepted audience in aud
+	AudienceValidationAudience AudienceValidationMode = "Audience"
+	// AudienceValidationDisabled skips aud and azp checks (not recommended)
+	AudienceValidationDisabled AudienceValidationMode = "Disabled"
+)
+
+// TokenValidation defines additional checks applied to verified tokens
+type TokenValidation struct {
+	// AudienceValidation selects how aud and azp are checked (default: AudienceOrAuthorizedParty).
+	// In every mode except Disabled, a present azp claim must name an authorized party.
+	// +optional
+	AudienceValidation AudienceValidationMode `json:"audienceValidation,omitempty"`
+
+	// Audiences accepted in the aud claim (default: the OIDC clientID)
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+
+	// AuthorizedParties accepted in the azp claim (default: the OIDC clientID)
+	// +optional
+	AuthorizedParties []string `json:"authorizedParties,omitempty"`
+
+	// RequiredClaims lists claims every token must carry
+	// +optional
+	RequiredClaims []RequiredClaim `json:"requiredClaims,omitempty"`
+}
+
+// RequiredClaim requires a claim to be present and, optionally, to have one of the given values
+type RequiredClaim struct {
+	// Claim is the claim path, using the same syntax as claimMappings (e.g. email_verified, acr)
+	// +kubebuilder:validation:MinLength=1
+	Claim string `json:"claim"`
+
+	// Values lists accepted values. Booleans and numbers are compared in their JSON form
+	// (e.g. "true"); for list claims, one element must match. When empty, the claim must
+	// only be present and non-empty.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// GroupPathMode controls how path-style group names (e.g. "/team/role") are normalized
+// +kubebuilder:validation:Enum=LastSegment;FullPath
+type GroupPathMode string
+
+const (
+	// GroupPathModeLastSegment keeps only the final path segment ("/team/role" -> "role")
+	GroupPathModeLastSegment GroupPathMode = "LastSegment"
+	// GroupPathModeFullPath keeps the full path without leading slashes ("/team/role" -> "team/role")
+	GroupPathModeFullPath GroupPathMode = "FullPath"
+)
+
+// ClaimMappings maps token claims to the user identity used by breakglass.
+// Claim paths are dot-separated (e.g. "realm_access.roles"); claim names containing dots
+// are written in brackets (e.g. `["https://example.com/groups"]`). Each field is a fallback
+// chain: the first path that yields a non-empty value is used.
+type ClaimMappings struct {
+	// Username lists claim paths for the username
+	// Default: ["preferred_username"]
+	// +optional
+	Username []string `json:"username,omitempty"`
+
+	// Email lists claim paths for the email address, which identifies requesters and approvers
+	// Example for Azure AD: ["email", "upn"]
+	// Default: ["email"]
+	// +optional
+	Email []string `json:"email,omitempty"`
+
+	// Groups lists claim paths for group memberships. Claims may hold a list of strings or a single string.
+	// Default: ["groups", "realm_access.roles"]
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// GroupTransform rewrites group names taken from the token
+	// +optional
+	GroupTransform *GroupTransform `json:"groupTransform,omitempty"`
+}
+
+// GroupTransform describes how group names from the token are rewritten before they are
+// matched against escalations and approver groups
+type GroupTransform struct {
+	// StripPrefixes removes the first matching prefix from each group (e.g. "oidc:")
+	// +optional
+	StripPrefixes []string `json:"stripPrefixes,omitempty"`
+
+	// Prefix is prepended to each group after stripping and path normalization
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// PathMode controls how path-style groups are normalized (default: LastSegment)
+	// +optional
+	PathMode GroupPathMode `json:"pathMode,omitempty"`
+
+	// Lowercase converts group names to lower case
+	// +optional
+	Lowercase bool `json:"lowercase,omitempty"`
+}
+
+// ParseClaimPath splits a claim path into its segments.
+// Segments are separated by dots; bracketed segments (["a.b"] or ['a.b']) may contain dots.
+// A leading "$." is accepted for JSONPath familiarity.
+func ParseClaimPath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$.")
+	if p == "" {
+		return nil, fmt.Errorf("claim path is empty")
+	}
+	var segments []string
+	for len(p) > 0 {
+		if p[0] == '[' {
+			if len(p) < 4 || (p[1] != '"' && p[1] != '\'') {
+				return nil, fmt.Errorf("invalid bracket segment in claim path %q", path)
+			}
+			quote := p[1]
+			end := strings.IndexByte(p[2:], quote)
+			if end < 0 || len(p) < end+4 || p[end+3] != ']' {
+				return nil, fmt.Errorf("unterminated bracket segment in claim path %q", path)
+			}
+			segment := p[2 : end+2]
+			if segment == "" {
+				return nil, fmt.Errorf("empty segment in claim path %q", path)
+			}
+			segments = append(segments, segment)
+			p = p[end+4:]
+		} else {
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty segment in claim path %q", path)
+			}
+			segments = append(segments, p[:end])
+			p = p[end:]
+		}
+		if len(p) == 0 {
+			break
+		}
+		if p[0] == '.' {
+			p = p[1:]
+			if len(p) == 0 {
+				return nil, fmt.Errorf("claim path %q ends with a dot", path)
+			}
+		} else if p[0] != '[' {
+			return nil, fmt.Errorf("invalid claim path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// validateTokenRevocation validates the introspection endpoint, credentials and durations
+func validateTokenRevocation(r *TokenRevocation, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if r == nil {
+		return allErrs
+	}
+	if r.Introspection == nil && !r.BackChannelLogout {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of introspection or backChannelLogout must be enabled"))
+	}
+	if r.LogoutRetention != "" {
+		if _, err := time.ParseDuration(r.LogoutRetention); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("logoutRetention"), r.LogoutRetention, fmt.Sprintf("invalid duration: %v", err)))
+		}
+	}
+	if i := r.Introspection; i != nil {
+		introPath := fldPath.Child("introspection")
+		if i.Endpoint == "" {
+			allErrs = append(allErrs, field.Required(introPath.Child("endpoint"), "endpoint is required"))
+		} else {
+			allErrs = append(allErrs, validateHTTPSURL(i.Endpoint, introPath.Child("endpoint"))...)
+		}
+		if i.ClientID == "" {
+			allErrs = append(allErrs, field.Required(introPath.Child("clientID"), "clientID is required"))
+		}
+		if i.ClientSecretRef.Name == "" || i.ClientSecretRef.Namespace == "" {
+			allErrs = append(allErrs, field.Required(introPath.Child("clientSecretRef"), "clientSecretRef name and namespace are required"))
+		}
+		allErrs = append(allErrs, validateCacheTTLAndTimeout(i.CacheTTL, i.RequestTimeout, introPath)...)
+	}
+	return allErrs
+}
+
+// validateTokenValidation validates audience settings and required claim rules
+func validateTokenValidation(v *TokenValidation, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if v == nil {
+		return allErrs
+	}
+	switch v.AudienceValidation {
+	case "", AudienceValidationAudienceOrAuthorizedParty, AudienceValidationAudience, AudienceValidationDisabled:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("audienceValidation"), v.AudienceValidation,
+			[]string{string(AudienceValidationAudienceOrAuthorizedParty), string(AudienceValidationAudience), string(AudienceValidationDisabled)}))
+	}
+	for i, aud := range v.Audiences {
+		if strings.TrimSpace(aud) == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("audiences").Index(i), aud, "audience must not be empty"))
+		}
+	}
+	for i, azp := range v.AuthorizedParties {
+		if strings.TrimSpace(azp) == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("authorizedParties").Index(i), azp, "authorized party must not be empty"))
+		}
+	}
+	for i, rc := range v.RequiredClaims {
+		if _, err := ParseClaimPath(rc.Claim); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requiredClaims").Index(i).Child("claim"), rc.Claim, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateClaimMappings validates claim paths and group transformation settings
+func validateClaimMappings(m *ClaimMappings, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if m == nil {
+		return allErrs
+	}
+	chains := []struct {
+		name  string
+		paths []string
+	}{{"username", m.Username}, {"email", m.Email}, {"groups", m.Groups}}
+	for _, chain := range chains {
+		for i, p := range chain.paths {
+			if _, err := ParseClaimPath(p); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child(chain.name).Index(i), p, err.Error()))
+			}
+		}
+	}
+	if t := m.GroupTransform; t != nil {
+		tPath := fldPath.Child("groupTransform")
+		for i, prefix := range t.StripPrefixes {
+			if prefix == "" {
+				allErrs = append(allErrs, field.Invalid(tPath.Child("stripPrefixes").Index(i), prefix, "prefix must not be empty"))
+			}
+		}
+		switch t.PathMode {
+		case "", GroupPathModeLastSegment, GroupPathModeFullPath:
+		default:
+			allErrs = append(allErrs, field.NotSupported(tPath.Child("pathMode"), t.PathMode,
+				[]string{string(GroupPathModeLastSegment), string(GroupPathModeFullPath)}))
+		}
+	}
+	return allErrs
+}
+
+// validateLDAPGroupSync validates the connection, search and nesting settings of the LDAP provider
+func validateLDAPGroupSync(l *LDAPGroupSync, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if l.URL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("url"), "url is required"))
+	} else if u, err := url.Parse(l.URL); err != nil || u.Host == "" || (u.Scheme != "ldap" && u.Scheme != "ldaps") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), l.URL, "url must be an ldap:// or ldaps:// URL with a host"))
+	} else if u.Scheme == "ldaps" && l.StartTLS {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("startTLS"), l.StartTLS, "startTLS cannot be combined with an ldaps:// url"))
+	}
+	if l.BindDN == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("bindDN"), "bindDN is required"))
+	}
+	if l.BindPasswordRef.Name == "" || l.BindPasswordRef.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("bindPasswordRef"), "bindPasswordRef name and namespace are required"))
+	}
+	if l.UserSearchBase == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("userSearchBase"), "userSearchBase is required"))
+	}
+	if l.GroupSearchBase == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("groupSearchBase"), "groupSearchBase is required"))
+	}
+	filters := []struct{ name, filter string }{{"userFilter", l.UserFilter}, {"groupFilter", l.GroupFilter}}
+	for _, f := range filters {
+		if f.filter != "" && !balancedLDAPFilter(f.filter) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(f.name), f.filter, "filter must be enclosed in parentheses and balanced"))
+		}
+	}
+	allErrs = append(allErrs, validateStringListEntriesNotEmpty(l.UserIdentifierAttributes, fldPath.Child("userIdentifierAttributes"))...)
+	allErrs = append(allErrs, validateStringListNoDuplicates(l.UserIdentifierAttributes, fldPath.Child("userIdentifierAttributes"))...)
+	switch l.NestedGroups {
+	case "", LDAPNestedGroupsNone, LDAPNestedGroupsRecursive, LDAPNestedGroupsInChain:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("nestedGroups"), l.NestedGroups,
+			[]string{string(LDAPNestedGroupsNone), string(LDAPNestedGroupsRecursive), string(LDAPNestedGroupsInChain)}))
+	}
+	if l.MaxNestingDepth < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxNestingDepth"), l.MaxNestingDepth, "maxNestingDepth must not be negative"))
+	}
+	if l.PageSize < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("pageSize"), l.PageSize, "pageSize must not be negative"))
+	}
+	allErrs = append(allErrs, validateCacheTTLAndTimeout(l.CacheTTL, l.RequestTimeout, fldPath)...)
+	return allErrs
+}
+
+func validateMicrosoftGraphGroupSync(g *MicrosoftGraphGroupSync, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if g.TenantID == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("tenantID"), "tenantID is required"))
+	}
+	if g.ClientID == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clientID"), "clientID is required"))
+	}
+	if g.ClientSecretRef.Name == "" || g.ClientSecretRef.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clientSecretRef"), "clientSecretRef name and namespace are required"))
+	}
+	if g.AuthorityURL != "" {
+		allErrs = append(allErrs, validateHTTPSURL(g.AuthorityURL, fldPath.Child("authorityURL"))...)
+	}
+	if g.GraphURL != "" {
+		allErrs = append(allErrs, validateHTTPSURL(g.GraphURL, fldPath.Child("graphURL"))...)
+	}
+	allErrs = append(allErrs, validateStringListEntriesNotEmpty(g.UserIdentifierAttributes, fldPath.Child("userIdentifierAttributes"))...)
+	allErrs = append(allErrs, validateStringListNoDuplicates(g.UserIdentifierAttributes, fldPath.Child("userIdentifierAttributes"))...)
+	allErrs = append(allErrs, validateCacheTTLAndTimeout(g.CacheTTL, g.RequestTimeout, fldPath)...)
+	return allErrs
+}
+
+func validateHTTPJSONGroupSync(h *HTTPJSONGroupSync, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if h.MembersURL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("membersURL"), "membersURL is required"))
+	} else if !strings.Contains(h.MembersURL, "{group}") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("membersURL"), h.MembersURL, "membersURL must contain the {group} placeholder"))
+	} else {
+		allErrs = append(allErrs, validateHTTPSURL(strings.ReplaceAll(h.MembersURL, "{group}", "group"), fldPath.Child("membersURL"))...)
+	}
+	if strings.TrimSpace(h.MembersPath) == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("membersPath"), "membersPath is required"))
+	}
+	if h.MaxPages < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxPages"), h.MaxPages, "maxPages must not be negative"))
+	}
+	if h.TokenRef != nil && (h.TokenRef.Name == "" || h.TokenRef.Namespace == "") {
+		allErrs = append(allErrs, field.Required(fldPath.Child("tokenRef"), "tokenRef name and namespace are required"))
+	}
+	if h.TokenHeader != "" && strings.ContainsAny(h.TokenHeader, " :\t\r\n") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("tokenHeader"), h.TokenHeader, "tokenHeader must be a valid header name"))
+	}
+	allErrs = append(allErrs, validateCacheTTLAndTimeout(h.CacheTTL, h.RequestTimeout, fldPath)...)
+	return allErrs
+}
+
+// validateCacheTTLAndTimeout checks the cacheTTL and requestTimeout fields shared by remote lookups
+func validateCacheTTLAndTimeout(cacheTTL, requestTimeout string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if cacheTTL != "" {
+		if _, err := time.ParseDuration(cacheTTL); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cacheTTL"), cacheTTL, fmt.Sprintf("invalid duration: %v", err)))
+		}
+	}
+	if requestTimeout != "" {
+		if _, err := time.ParseDuration(requestTimeout); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requestTimeout"), requestTimeout, fmt.Sprintf("invalid duration: %v", err)))
+		}
+	}
+	return allErrs
+}
+
+// balancedLDAPFilter performs a structural check of a string filter; the full RFC 4515
+// syntax is checked when the provider is loaded
+func balancedLDAPFilter(filter string) bool {
+	if !strings.HasPrefix(filter, "(") || !strings.HasSuffix(filter, ")") {
+		return false
+	}
+	depth := 0
+	for i, ch := range filter {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 || (depth == 0 && i != len(filter)-1) {
+				return false
+			}
+		}
+	}
+	return depth == 0
 }
 
 // IdentityProviderStatus defines the observed state of an IdentityProvider
@@ -188,6 +920,10 @@ type IdentityProviderStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// LogoutRevocations lists the back-channel logout events received within the logout retention
+	// +optional
+	LogoutRevocations []LogoutRevocation `json:"logoutRevocations,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -247,6 +983,10 @@ func (idp *IdentityProvider) ValidateCreate(ctx context.Context, obj runtime.Obj
 		allErrs = append(allErrs, validateHTTPSURL(identityProvider.Spec.OIDC.JWKSEndpoint, jwksPath)...)
 	}
 
+	if ref := identityProvider.Spec.OIDC.ClientSecretRef; ref != nil && (ref.Name == "" || ref.Namespace == "") {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("oidc").Child("clientSecretRef"), "clientSecretRef name and namespace are required"))
+	}
+
 	// Validate Keycloak configuration if group sync is enabled
 	if identityProvider.Spec.GroupSyncProvider == GroupSyncProviderKeycloak {
 		if identityProvider.Spec.Keycloak == nil {
@@ -297,12 +1037,60 @@ func (idp *IdentityProvider) ValidateCreate(ctx context.Context, obj runtime.Obj
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("keycloak"), identityProvider.Spec.Keycloak, "groupSyncProvider must be set to 'Keycloak' when keycloak configuration is provided"))
 	}
 
+	if identityProvider.Spec.GroupSyncProvider == GroupSyncProviderLDAP {
+		if identityProvider.Spec.LDAP == nil {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("ldap"), "ldap configuration is required when groupSyncProvider is LDAP"))
+		} else {
+			allErrs = append(allErrs, validateLDAPGroupSync(identityProvider.Spec.LDAP, field.NewPath("spec").Child("ldap"))...)
+		}
+	} else if identityProvider.Spec.LDAP != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("ldap"), identityProvider.Spec.LDAP, "groupSyncProvider must be set to 'LDAP' when ldap configuration is provided"))
+	}
+
+	if identityProvider.Spec.GroupSyncProvider == GroupSyncProviderSCIM {
+		if identityProvider.Spec.SCIM == nil {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("scim"), "scim configuration is required when groupSyncProvider is SCIM"))
+		} else if identityProvider.Spec.SCIM.BearerTokenRef.Name == "" || identityProvider.Spec.SCIM.BearerTokenRef.Namespace == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("scim").Child("bearerTokenRef"), "bearerTokenRef name and namespace are required"))
+		}
+		// SCIM resources carry the IdentityProvider name as a label value
+		if len(identityProvider.Name) > validation.LabelValueMaxLength {
+			allErrs = append(allErrs, field.TooLong(field.NewPath("metadata").Child("name"), identityProvider.Name, validation.LabelValueMaxLength))
+		}
+	} else if identityProvider.Spec.SCIM != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("scim"), identityProvider.Spec.SCIM, "groupSyncProvider must be set to 'SCIM' when scim configuration is provided"))
+	}
+
+	if identityProvider.Spec.GroupSyncProvider == GroupSyncProviderMicrosoftGraph {
+		if identityProvider.Spec.MicrosoftGraph == nil {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("microsoftGraph"), "microsoftGraph configuration is required when groupSyncProvider is MicrosoftGraph"))
+		} else {
+			allErrs = append(allErrs, validateMicrosoftGraphGroupSync(identityProvider.Spec.MicrosoftGraph, field.NewPath("spec").Child("microsoftGraph"))...)
+		}
+	} else if identityProvider.Spec.MicrosoftGraph != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("microsoftGraph"), identityProvider.Spec.MicrosoftGraph, "groupSyncProvider must be set to 'MicrosoftGraph' when microsoftGraph configuration is provided"))
+	}
+
+	if identityProvider.Spec.GroupSyncProvider == GroupSyncProviderHTTPJSON {
+		if identityProvider.Spec.HTTPJSON == nil {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("httpJSON"), "httpJSON configuration is required when groupSyncProvider is HTTPJSON"))
+		} else {
+			allErrs = append(allErrs, validateHTTPJSONGroupSync(identityProvider.Spec.HTTPJSON, field.NewPath("spec").Child("httpJSON"))...)
+		}
+	} else if identityProvider.Spec.HTTPJSON != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("httpJSON"), identityProvider.Spec.HTTPJSON, "groupSyncProvider must be set to 'HTTPJSON' when httpJSON configuration is provided"))
+	}
+
 	if identityProvider.Spec.Issuer != "" {
 		issuerPath := field.NewPath("spec").Child("issuer")
 		allErrs = append(allErrs, validateURLFormat(identityProvider.Spec.Issuer, issuerPath)...)
 		allErrs = append(allErrs, validateHTTPSURL(identityProvider.Spec.Issuer, issuerPath)...)
 	}
 
+	allErrs = append(allErrs, validateClaimMappings(identityProvider.Spec.ClaimMappings, field.NewPath("spec").Child("claimMappings"))...)
+	allErrs = append(allErrs, validateTokenValidation(identityProvider.Spec.TokenValidation, field.NewPath("spec").Child("tokenValidation"))...)
+	allErrs = append(allErrs, validateTokenRevocation(identityProvider.Spec.TokenRevocation, field.NewPath("spec").Child("tokenRevocation"))...)
+
 	// Multi-IDP: Validate Issuer field for multi-IDP mode (must be unique and valid URL)
 	allErrs = append(allErrs, ensureClusterWideUniqueIssuer(ctx, identityProvider.Spec.Issuer, identityProvider.Name, field.NewPath("spec").Child("issuer"))...)
 
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -610,3 +611,405 @@ func TestIdentityProviderIntegration(t *testing.T) {
 	require.NotNil(t, readyCondition)
 	assert.Equal(t, metav1.ConditionTrue, readyCondition.Status)
 }
+
+func TestParseClaimPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "email", want: []string{"email"}},
+		{path: "realm_access.roles", want: []string{"realm_access", "roles"}},
+		{path: "$.realm_access.roles", want: []string{"realm_access", "roles"}},
+		{path: `["https://example.com/groups"]`, want: []string{"https://example.com/groups"}},
+		{path: `['https://example.com/claims'].groups`, want: []string{"https://example.com/claims", "groups"}},
+		{path: `resource_access["my.client"].roles`, want: []string{"resource_access", "my.client", "roles"}},
+		{path: "", wantErr: true},
+		{path: "groups.", wantErr: true},
+		{path: "a..b", wantErr: true},
+		{path: `["unterminated`, wantErr: true},
+		{path: `[""]`, wantErr: true},
+		{path: `["a"]b`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseClaimPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIdentityProviderValidateCreateClaimMappings(t *testing.T) {
+	newIDP := func(m *ClaimMappings) *IdentityProvider {
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "azure"},
+			Spec: IdentityProviderSpec{
+				OIDC:          OIDCConfig{Authority: "https://login.example.com", ClientID: "client-id"},
+				ClaimMappings: m,
+			},
+		}
+	}
+
+	valid := newIDP(&ClaimMappings{
+		Username:       []string{"upn", "preferred_username"},
+		Email:          []string{"email", "upn"},
+		Groups:         []string{`["https://example.com/groups"]`},
+		GroupTransform: &GroupTransform{StripPrefixes: []string{"oidc:"}, PathMode: GroupPathModeFullPath},
+	})
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	invalid := newIDP(&ClaimMappings{
+		Groups:         []string{"groups."},
+		GroupTransform: &GroupTransform{StripPrefixes: []string{""}, PathMode: "Flatten"},
+	})
+	_, err = invalid.ValidateCreate(context.Background(), invalid)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "spec.claimMappings.groups[0]")
+	assert.Contains(t, err.Error(), "spec.claimMappings.groupTransform.stripPrefixes[0]")
+	assert.Contains(t, err.Error(), "spec.claimMappings.groupTransform.pathMode")
+}
+
+func TestIdentityProviderValidateCreateTokenValidation(t *testing.T) {
+	newIDP := func(v *TokenValidation) *IdentityProvider {
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+			Spec: IdentityProviderSpec{
+				OIDC:            OIDCConfig{Authority: "https://login.example.com", ClientID: "client-id"},
+				TokenValidation: v,
+			},
+		}
+	}
+
+	valid := newIDP(&TokenValidation{
+		AudienceValidation: AudienceValidationAudience,
+		Audiences:          []string{"breakglass-api"},
+		AuthorizedParties:  []string{"breakglass-ui", "breakglass-cli"},
+		RequiredClaims:     []RequiredClaim{{Claim: "email_verified", Values: []string{"true"}}, {Claim: "acr"}},
+	})
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	invalid := newIDP(&TokenValidation{
+		AudienceValidation: "Loose",
+		Audiences:          []string{" "},
+		RequiredClaims:     []RequiredClaim{{Claim: "a..b"}},
+	})
+	_, err = invalid.ValidateCreate(context.Background(), invalid)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.tokenValidation.audienceValidation")
+	assert.Contains(t, err.Error(), "spec.tokenValidation.audiences[0]")
+	assert.Contains(t, err.Error(), "spec.tokenValidation.requiredClaims[0].claim")
+}
+
+func TestIdentityProviderValidateCreateLDAPGroupSync(t *testing.T) {
+	newIDP := func(provider GroupSyncProvider, l *LDAPGroupSync) *IdentityProvider {
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "dex-ad"},
+			Spec: IdentityProviderSpec{
+				OIDC:              OIDCConfig{Authority: "https://dex.example.com", ClientID: "client-id"},
+				GroupSyncProvider: provider,
+				LDAP:              l,
+			},
+		}
+	}
+	validLDAP := func() *LDAPGroupSync {
+		return &LDAPGroupSync{
+			URL:                      "ldap://ad.example.com:389",
+			StartTLS:                 true,
+			BindDN:                   "cn=svc-breakglass,ou=services,dc=example,dc=com",
+			BindPasswordRef:          SecretKeyReference{Name: "ldap-bind", Namespace: "breakglass"},
+			UserSearchBase:           "ou=people,dc=example,dc=com",
+			UserFilter:               "(&(objectClass=user)(!(userAccountControl:1.2.840.113556.1.4.803:=2)))",
+			UserIdentifierAttributes: []string{"mail", "userPrincipalName"},
+			GroupSearchBase:          "ou=groups,dc=example,dc=com",
+			NestedGroups:             LDAPNestedGroupsInChain,
+			CacheTTL:                 "5m",
+		}
+	}
+
+	valid := newIDP(GroupSyncProviderLDAP, validLDAP())
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	missing := newIDP(GroupSyncProviderLDAP, nil)
+	_, err = missing.ValidateCreate(context.Background(), missing)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.ldap")
+
+	mismatched := newIDP(GroupSyncProviderKeycloak, validLDAP())
+	mismatched.Spec.Keycloak = &KeycloakGroupSync{
+		BaseURL: "https://keycloak.example.com", Realm: "master", ClientID: "sync",
+		ClientSecretRef: SecretKeyReference{Name: "kc", Namespace: "breakglass"},
+	}
+	_, err = mismatched.ValidateCreate(context.Background(), mismatched)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "groupSyncProvider must be set to 'LDAP'")
+
+	invalidLDAP := validLDAP()
+	invalidLDAP.URL = "ldaps://ad.example.com"
+	invalidLDAP.BindDN = ""
+	invalidLDAP.BindPasswordRef.Namespace = ""
+	invalidLDAP.GroupFilter = "(objectClass=group"
+	invalidLDAP.UserIdentifierAttributes = []string{"mail", "mail"}
+	invalidLDAP.NestedGroups = "Deep"
+	invalidLDAP.RequestTimeout = "soon"
+	invalid := newIDP(GroupSyncProviderLDAP, invalidLDAP)
+	_, err = invalid.ValidateCreate(context.Background(), invalid)
+	require.Error(t, err)
+	for _, path := range []string{
+		"spec.ldap.startTLS",
+		"spec.ldap.bindDN",
+		"spec.ldap.bindPasswordRef",
+		"spec.ldap.groupFilter",
+		"spec.ldap.userIdentifierAttributes[1]",
+		"spec.ldap.nestedGroups",
+		"spec.ldap.requestTimeout",
+	} {
+		assert.Contains(t, err.Error(), path)
+	}
+
+	badURL := validLDAP()
+	badURL.URL = "https://ad.example.com"
+	invalid = newIDP(GroupSyncProviderLDAP, badURL)
+	_, err = invalid.ValidateCreate(context.Background(), invalid)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.ldap.url")
+}
+
+func TestIdentityProviderValidateCreateSCIMGroupSync(t *testing.T) {
+	newIDP := func(name string, provider GroupSyncProvider, s *SCIMGroupSync) *IdentityProvider {
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: IdentityProviderSpec{
+				OIDC:              OIDCConfig{Authority: "https://login.example.com", ClientID: "client-id"},
+				GroupSyncProvider: provider,
+				SCIM:              s,
+			},
+		}
+	}
+	validSCIM := &SCIMGroupSync{BearerTokenRef: SecretKeyReference{Name: "scim-token", Namespace: "breakglass"}}
+
+	valid := newIDP("entra", GroupSyncProviderSCIM, validSCIM)
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		idp  *IdentityProvider
+		want string
+	}{
+		{name: "missing config", idp: newIDP("entra", GroupSyncProviderSCIM, nil), want: "spec.scim"},
+		{name: "missing token namespace", idp: newIDP("entra", GroupSyncProviderSCIM, &SCIMGroupSync{BearerTokenRef: SecretKeyReference{Name: "scim-token"}}),
+			want: "spec.scim.bearerTokenRef"},
+		{name: "provider mismatch", idp: newIDP("entra", "", validSCIM), want: "groupSyncProvider must be set to 'SCIM'"},
+		{name: "name too long for a label", idp: newIDP(strings.Repeat("a", 64), GroupSyncProviderSCIM, validSCIM), want: "metadata.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.idp.ValidateCreate(context.Background(), tt.idp)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestIdentityProviderValidateCreateMicrosoftGraphGroupSync(t *testing.T) {
+	newIDP := func(provider GroupSyncProvider, mutate func(*MicrosoftGraphGroupSync)) *IdentityProvider {
+		g := &MicrosoftGraphGroupSync{
+			TenantID:        "tenant",
+			ClientID:        "client",
+			ClientSecretRef: SecretKeyReference{Name: "graph", Namespace: "breakglass"},
+		}
+		if mutate != nil {
+			mutate(g)
+		}
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "entra"},
+			Spec: IdentityProviderSpec{
+				OIDC:              OIDCConfig{Authority: "https://login.example.com", ClientID: "client-id"},
+				GroupSyncProvider: provider,
+				MicrosoftGraph:    g,
+			},
+		}
+	}
+
+	valid := newIDP(GroupSyncProviderMicrosoftGraph, func(g *MicrosoftGraphGroupSync) {
+		g.GraphURL = "https://graph.microsoft.us"
+		g.UserIdentifierAttributes = []string{"userPrincipalName"}
+	})
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		idp  *IdentityProvider
+		want string
+	}{
+		{name: "missing config", idp: func() *IdentityProvider {
+			idp := newIDP(GroupSyncProviderMicrosoftGraph, nil)
+			idp.Spec.MicrosoftGraph = nil
+			return idp
+		}(), want: "spec.microsoftGraph"},
+		{name: "missing tenant", idp: newIDP(GroupSyncProviderMicrosoftGraph, func(g *MicrosoftGraphGroupSync) { g.TenantID = "" }),
+			want: "spec.microsoftGraph.tenantID"},
+		{name: "insecure graph URL", idp: newIDP(GroupSyncProviderMicrosoftGraph, func(g *MicrosoftGraphGroupSync) { g.GraphURL = "http://graph.example.com" }),
+			want: "spec.microsoftGraph.graphURL"},
+		{name: "duplicate identifier attributes", idp: newIDP(GroupSyncProviderMicrosoftGraph, func(g *MicrosoftGraphGroupSync) {
+			g.UserIdentifierAttributes = []string{"mail", "mail"}
+		}), want: "spec.microsoftGraph.userIdentifierAttributes"},
+		{name: "invalid cache TTL", idp: newIDP(GroupSyncProviderMicrosoftGraph, func(g *MicrosoftGraphGroupSync) { g.CacheTTL = "soon" }),
+			want: "spec.microsoftGraph.cacheTTL"},
+		{name: "provider mismatch", idp: newIDP(GroupSyncProviderKeycloak, nil), want: "groupSyncProvider must be set to 'MicrosoftGraph'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.idp.ValidateCreate(context.Background(), tt.idp)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestIdentityProviderValidateCreateHTTPJSONGroupSync(t *testing.T) {
+	newIDP := func(provider GroupSyncProvider, mutate func(*HTTPJSONGroupSync)) *IdentityProvider {
+		h := &HTTPJSONGroupSync{
+			MembersURL:  "https://directory.example.com/groups/{group}/members",
+			MembersPath: "$.members[*].email",
+		}
+		if mutate != nil {
+			mutate(h)
+		}
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "directory"},
+			Spec: IdentityProviderSpec{
+				OIDC:              OIDCConfig{Authority: "https://login.example.com", ClientID: "client-id"},
+				GroupSyncProvider: provider,
+				HTTPJSON:          h,
+			},
+		}
+	}
+
+	valid := newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) {
+		h.TokenRef = &SecretKeyReference{Name: "directory", Namespace: "breakglass"}
+		h.TokenHeader = "X-API-Key"
+	})
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		idp  *IdentityProvider
+		want string
+	}{
+		{name: "missing placeholder", idp: newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) {
+			h.MembersURL = "https://directory.example.com/members"
+		}), want: "{group} placeholder"},
+		{name: "insecure URL", idp: newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) {
+			h.MembersURL = "http://directory.example.com/{group}"
+		}), want: "spec.httpJSON.membersURL"},
+		{name: "missing members path", idp: newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) { h.MembersPath = " " }),
+			want: "spec.httpJSON.membersPath"},
+		{name: "token ref without namespace", idp: newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) {
+			h.TokenRef = &SecretKeyReference{Name: "directory"}
+		}), want: "spec.httpJSON.tokenRef"},
+		{name: "invalid token header", idp: newIDP(GroupSyncProviderHTTPJSON, func(h *HTTPJSONGroupSync) { h.TokenHeader = "X-API Key" }),
+			want: "spec.httpJSON.tokenHeader"},
+		{name: "provider mismatch", idp: newIDP("", nil), want: "groupSyncProvider must be set to 'HTTPJSON'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.idp.ValidateCreate(context.Background(), tt.idp)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestIdentityProviderValidateCreateTokenRevocation(t *testing.T) {
+	newIDP := func(mutate func(*TokenRevocation)) *IdentityProvider {
+		r := &TokenRevocation{
+			Introspection: &TokenIntrospection{
+				Endpoint:        "https://keycloak.example.com/realms/corp/protocol/openid-connect/token/introspect",
+				ClientID:        "breakglass",
+				ClientSecretRef: SecretKeyReference{Name: "introspection", Namespace: "breakglass"},
+			},
+			BackChannelLogout: true,
+		}
+		if mutate != nil {
+			mutate(r)
+		}
+		return &IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+			Spec: IdentityProviderSpec{
+				OIDC:            OIDCConfig{Authority: "https://keycloak.example.com/realms/corp", ClientID: "client-id"},
+				TokenRevocation: r,
+			},
+		}
+	}
+
+	valid := newIDP(func(r *TokenRevocation) {
+		r.LogoutRetention = "2h"
+		r.Introspection.CacheTTL = "10s"
+	})
+	_, err := valid.ValidateCreate(context.Background(), valid)
+	require.NoError(t, err)
+
+	logoutOnly := newIDP(func(r *TokenRevocation) { r.Introspection = nil })
+	_, err = logoutOnly.ValidateCreate(context.Background(), logoutOnly)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		idp  *IdentityProvider
+		want string
+	}{
+		{name: "nothing enabled", idp: newIDP(func(r *TokenRevocation) {
+			r.Introspection = nil
+			r.BackChannelLogout = false
+		}), want: "at least one of introspection or backChannelLogout"},
+		{name: "invalid retention", idp: newIDP(func(r *TokenRevocation) { r.LogoutRetention = "1 hour" }),
+			want: "spec.tokenRevocation.logoutRetention"},
+		{name: "insecure endpoint", idp: newIDP(func(r *TokenRevocation) { r.Introspection.Endpoint = "http://keycloak.example.com/introspect" }),
+			want: "spec.tokenRevocation.introspection.endpoint"},
+		{name: "missing client ID", idp: newIDP(func(r *TokenRevocation) { r.Introspection.ClientID = "" }),
+			want: "spec.tokenRevocation.introspection.clientID"},
+		{name: "secret without namespace", idp: newIDP(func(r *TokenRevocation) { r.Introspection.ClientSecretRef.Namespace = "" }),
+			want: "spec.tokenRevocation.introspection.clientSecretRef"},
+		{name: "invalid cache TTL", idp: newIDP(func(r *TokenRevocation) { r.Introspection.CacheTTL = "soon" }),
+			want: "spec.tokenRevocation.introspection.cacheTTL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.idp.ValidateCreate(context.Background(), tt.idp)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestIdentityProviderValidateCreateOIDCClientSecret(t *testing.T) {
+	idp := &IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: IdentityProviderSpec{
+			OIDC: OIDCConfig{
+				Authority:       "https://keycloak.example.com/realms/corp",
+				ClientID:        "breakglass",
+				ClientSecretRef: &SecretKeyReference{Name: "oidc-client", Namespace: "breakglass"},
+			},
+		},
+	}
+	_, err := idp.ValidateCreate(context.Background(), idp)
+	require.NoError(t, err)
+
+	idp.Spec.OIDC.ClientSecretRef.Namespace = ""
+	_, err = idp.ValidateCreate(context.Background(), idp)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.oidc.clientSecretRef")
+}
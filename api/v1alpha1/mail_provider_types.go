@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -54,6 +55,11 @@ type MailProviderSpec struct {
 	// Retry contains retry and queue configuration
 	// +optional
 	Retry RetryConfig `json:"retry,omitempty"`
+
+	// Templates references operator-supplied overrides for the built-in email templates
+	// When unset, the embedded default templates are used
+	// +optional
+	Templates *MailTemplatesConfig `json:"templates,omitempty"`
 }
 
 // SMTPConfig defines SMTP server connection settings
@@ -86,6 +92,71 @@ type SMTPConfig struct {
 	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
 	// +optional
 	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+
+	// TLSMode selects how TLS is negotiated with the SMTP server:
+	// Opportunistic upgrades the connection with STARTTLS when the server offers it,
+	// StartTLS requires STARTTLS, Implicit connects over TLS (SMTPS) and None never uses TLS.
+	// Defaults to Implicit on port 465 and Opportunistic otherwise.
+	// +optional
+	// +kubebuilder:validation:Enum=Opportunistic;StartTLS;Implicit;None
+	TLSMode SMTPTLSMode `json:"tlsMode,omitempty"`
+
+	// AuthMechanism selects the SMTP authentication mechanism.
+	// When unset and credentials are configured, PLAIN, LOGIN or CRAM-MD5 is negotiated
+	// from the mechanisms advertised by the server.
+	// +optional
+	// +kubebuilder:validation:Enum=None;Plain;Login;XOAUTH2
+	AuthMechanism SMTPAuthMechanism `json:"authMechanism,omitempty"`
+
+	// OAuth2 configures token acquisition for the XOAUTH2 mechanism using the
+	// OAuth2 client-credentials grant. The username is used as the mailbox to authenticate as.
+	// +optional
+	OAuth2 *SMTPOAuth2Config `json:"oauth2,omitempty"`
+
+	// ClientCertificateSecretRef references a kubernetes.io/tls Secret whose tls.crt and tls.key
+	// entries are presented as client certificate during the TLS handshake
+	// +optional
+	ClientCertificateSecretRef *SecretReference `json:"clientCertificateSecretRef,omitempty"`
+}
+
+// SMTPTLSMode defines how TLS is negotiated with the SMTP server
+type SMTPTLSMode string
+
+const (
+	SMTPTLSModeOpportunistic SMTPTLSMode = "Opportunistic"
+	SMTPTLSModeStartTLS      SMTPTLSMode = "StartTLS"
+	SMTPTLSModeImplicit      SMTPTLSMode = "Implicit"
+	SMTPTLSModeNone          SMTPTLSMode = "None"
+)
+
+// SMTPAuthMechanism defines the SMTP authentication mechanism
+type SMTPAuthMechanism string
+
+const (
+	SMTPAuthMechanismNone    SMTPAuthMechanism = "None"
+	SMTPAuthMechanismPlain   SMTPAuthMechanism = "Plain"
+	SMTPAuthMechanismLogin   SMTPAuthMechanism = "Login"
+	SMTPAuthMechanismXOAUTH2 SMTPAuthMechanism = "XOAUTH2"
+)
+
+// SMTPOAuth2Config defines the OAuth2 client-credentials grant used to obtain XOAUTH2 access tokens
+type SMTPOAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server
+	// Example: https://login.microsoftonline.com/<tenant>/oauth2/v2.0/token
+	// +kubebuilder:validation:MinLength=1
+	TokenURL string `json:"tokenURL"`
+
+	// ClientID is the OAuth2 client identifier
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// ClientSecretRef references a Secret containing the OAuth2 client secret
+	ClientSecretRef SecretKeyReference `json:"clientSecretRef"`
+
+	// Scopes requested for the access token
+	// Example: https://outlook.office365.com/.default
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 // SenderConfig defines email sender information
@@ -132,6 +203,47 @@ type RetryConfig struct {
 	QueueSize int `json:"queueSize,omitempty"`
 }
 
+// MailTemplatesConfig defines where template overrides are loaded from
+type MailTemplatesConfig struct {
+	// ConfigMapRef references a ConfigMap containing template overrides.
+	// Keys follow the pattern <template>[.<locale>].<part>, where template is one of
+	// request, approved, breakglassSessionRequest or breakglassSessionNotification,
+	// and part is one of subject, html or txt.
+	// Example: breakglassSessionRequest.subject, approved.de.html
+	ConfigMapRef ConfigMapReference `json:"configMapRef"`
+
+	// Locale selects the locale variant used when rendering templates (e.g. "de", "en-GB").
+	// Lookups fall back from the full locale to its base language and then to the
+	// locale-independent override before using the embedded default.
+	// +optional
+	// +kubebuilder:validation:MaxLength=35
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z]{2,8}(-[a-zA-Z0-9]{1,8})*$`
+	Locale string `json:"locale,omitempty"`
+}
+
+// ConfigMapReference is a namespaced reference to a ConfigMap.
+// This allows cluster-scoped resources (like MailProvider) to reference ConfigMaps in any namespace.
+type ConfigMapReference struct {
+	// Name is the name of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace is the namespace containing the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// SecretReference is a namespaced reference to a Secret
+type SecretReference struct {
+	// Name is the name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace is the namespace containing the Secret
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
 // MailProviderStatus defines the observed state of MailProvider
 type MailProviderStatus struct {
 	// Conditions represent the latest available observations of the MailProvider's state
@@ -149,6 +261,11 @@ type MailProviderStatus struct {
 	// LastSendError contains the error message from the last failed send attempt
 	// +optional
 	LastSendError string `json:"lastSendError,omitempty"`
+
+	// TemplateOverrides lists the template override keys that were loaded and validated
+	// from the referenced ConfigMap (e.g. "approved.subject", "approved.de.html")
+	// +optional
+	TemplateOverrides []string `json:"templateOverrides,omitempty"`
 }
 
 // MailProviderConditionType defines the type of condition for MailProvider status
@@ -161,6 +278,8 @@ const (
 	MailProviderConditionHealthy MailProviderConditionType = "Healthy"
 	// MailProviderConditionPasswordLoaded indicates the password was successfully loaded from secret
 	MailProviderConditionPasswordLoaded MailProviderConditionType = "PasswordLoaded"
+	// MailProviderConditionTemplatesValid indicates the referenced template overrides parsed and rendered successfully
+	MailProviderConditionTemplatesValid MailProviderConditionType = "TemplatesValid"
 )
 
 // +kubebuilder:object:root=true
@@ -266,13 +385,23 @@ func (mp *MailProvider) validate() (admission.Warnings, error) {
 		allErrs = append(allErrs, err...)
 	}
 
+	// Validate template override reference
+	if err := mp.validateTemplates(); err != nil {
+		allErrs = append(allErrs, err...)
+	}
+
+	// Warn if credentials may be sent over an unencrypted connection
+	if mp.Spec.SMTP.TLSMode == SMTPTLSModeNone && mp.Spec.SMTP.PasswordRef != nil {
+		warnings = append(warnings, "tlsMode None sends SMTP credentials unencrypted; most servers reject authentication without TLS")
+	}
+
 	// Warn if insecureSkipVerify is enabled
 	if mp.Spec.SMTP.InsecureSkipVerify {
 		warnings = append(warnings, "insecureSkipVerify is enabled - TLS certificate validation is disabled. This should only be used for testing!")
 	}
 
 	// Warn if authentication is not configured
-	if mp.Spec.SMTP.Username == "" && mp.Spec.SMTP.PasswordRef == nil {
+	if mp.Spec.SMTP.Username == "" && mp.Spec.SMTP.PasswordRef == nil && mp.Spec.SMTP.ClientCertificateSecretRef == nil {
 		warnings = append(warnings, "No SMTP authentication configured - ensure your SMTP server allows unauthenticated connections")
 	}
 
@@ -302,14 +431,17 @@ func (mp *MailProvider) validateSMTP() field.ErrorList {
 		allErrs = append(allErrs, field.Invalid(smtpPath.Child("port"), mp.Spec.SMTP.Port, "port must be between 1 and 65535"))
 	}
 
-	// Validate username/password consistency
-	if mp.Spec.SMTP.Username != "" && mp.Spec.SMTP.PasswordRef == nil {
+	// Validate username/password consistency (XOAUTH2 authenticates the username with a token instead)
+	usesXOAUTH2 := mp.Spec.SMTP.AuthMechanism == SMTPAuthMechanismXOAUTH2
+	if mp.Spec.SMTP.Username != "" && mp.Spec.SMTP.PasswordRef == nil && !usesXOAUTH2 {
 		allErrs = append(allErrs, field.Invalid(smtpPath.Child("passwordRef"), nil, "passwordRef must be specified when username is provided"))
 	}
 	if mp.Spec.SMTP.PasswordRef != nil && mp.Spec.SMTP.Username == "" {
 		allErrs = append(allErrs, field.Invalid(smtpPath.Child("username"), "", "username must be specified when passwordRef is provided"))
 	}
 
+	allErrs = append(allErrs, mp.validateSMTPAuth(smtpPath)...)
+
 	// Validate passwordRef structure if present
 	if mp.Spec.SMTP.PasswordRef != nil {
 		if mp.Spec.SMTP.PasswordRef.Name == "" {
@@ -326,6 +458,71 @@ func (mp *MailProvider) validateSMTP() field.ErrorList {
 	return allErrs
 }
 
+// validateSMTPAuth validates the authentication mechanism, OAuth2 and client certificate settings
+func (mp *MailProvider) validateSMTPAuth(smtpPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	smtp := mp.Spec.SMTP
+
+	switch smtp.AuthMechanism {
+	case SMTPAuthMechanismPlain, SMTPAuthMechanismLogin:
+		if smtp.Username == "" || smtp.PasswordRef == nil {
+			allErrs = append(allErrs, field.Required(smtpPath.Child("passwordRef"),
+				fmt.Sprintf("username and passwordRef are required for authMechanism %s", smtp.AuthMechanism)))
+		}
+	case SMTPAuthMechanismXOAUTH2:
+		if smtp.Username == "" {
+			allErrs = append(allErrs, field.Required(smtpPath.Child("username"), "username (mailbox) is required for XOAUTH2"))
+		}
+		if smtp.OAuth2 == nil {
+			allErrs = append(allErrs, field.Required(smtpPath.Child("oauth2"), "oauth2 is required for XOAUTH2"))
+		}
+		if smtp.PasswordRef != nil {
+			allErrs = append(allErrs, field.Forbidden(smtpPath.Child("passwordRef"), "passwordRef cannot be combined with XOAUTH2"))
+		}
+		if smtp.TLSMode == SMTPTLSModeNone {
+			allErrs = append(allErrs, field.Invalid(smtpPath.Child("tlsMode"), smtp.TLSMode, "XOAUTH2 requires TLS"))
+		}
+	}
+
+	if smtp.OAuth2 != nil {
+		oauthPath := smtpPath.Child("oauth2")
+		if smtp.AuthMechanism != SMTPAuthMechanismXOAUTH2 {
+			allErrs = append(allErrs, field.Invalid(smtpPath.Child("authMechanism"), smtp.AuthMechanism, "authMechanism must be XOAUTH2 when oauth2 is set"))
+		}
+		if u, err := url.Parse(smtp.OAuth2.TokenURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(oauthPath.Child("tokenURL"), smtp.OAuth2.TokenURL, "tokenURL must be an absolute https URL"))
+		}
+		if smtp.OAuth2.ClientID == "" {
+			allErrs = append(allErrs, field.Required(oauthPath.Child("clientID"), "client ID is required"))
+		}
+		secretPath := oauthPath.Child("clientSecretRef")
+		if smtp.OAuth2.ClientSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(secretPath.Child("name"), "secret name is required"))
+		}
+		if smtp.OAuth2.ClientSecretRef.Namespace == "" {
+			allErrs = append(allErrs, field.Required(secretPath.Child("namespace"), "secret namespace is required"))
+		}
+		if smtp.OAuth2.ClientSecretRef.Key == "" {
+			allErrs = append(allErrs, field.Required(secretPath.Child("key"), "secret key is required"))
+		}
+	}
+
+	if ref := smtp.ClientCertificateSecretRef; ref != nil {
+		refPath := smtpPath.Child("clientCertificateSecretRef")
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("name"), "secret name is required"))
+		}
+		if ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("namespace"), "secret namespace is required"))
+		}
+		if smtp.TLSMode == SMTPTLSModeNone {
+			allErrs = append(allErrs, field.Invalid(smtpPath.Child("tlsMode"), smtp.TLSMode, "client certificates require TLS"))
+		}
+	}
+
+	return allErrs
+}
+
 // validateSender validates sender configuration
 func (mp *MailProvider) validateSender() field.ErrorList {
 	var allErrs field.ErrorList
@@ -339,6 +536,24 @@ func (mp *MailProvider) validateSender() field.ErrorList {
 	return allErrs
 }
 
+// validateTemplates validates the template override configuration
+func (mp *MailProvider) validateTemplates() field.ErrorList {
+	var allErrs field.ErrorList
+	if mp.Spec.Templates == nil {
+		return allErrs
+	}
+	refPath := field.NewPath("spec", "templates", "configMapRef")
+
+	if mp.Spec.Templates.ConfigMapRef.Name == "" {
+		allErrs = append(allErrs, field.Required(refPath.Child("name"), "configMap name is required"))
+	}
+	if mp.Spec.Templates.ConfigMapRef.Namespace == "" {
+		allErrs = append(allErrs, field.Required(refPath.Child("namespace"), "configMap namespace is required"))
+	}
+
+	return allErrs
+}
+
 // validateDefaultUniqueness ensures only one MailProvider is marked as default
 func (mp *MailProvider) validateDefaultUniqueness(ctx context.Context, excludeName string) error {
 	reader := getWebhookReader()
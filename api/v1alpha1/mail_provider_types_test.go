@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -246,6 +247,44 @@ func TestMailProviderValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "sender address is required",
 		},
+		{
+			name: "valid template override reference",
+			mp: &MailProvider{
+				Spec: MailProviderSpec{
+					SMTP: SMTPConfig{
+						Host: "smtp.example.com",
+						Port: 587,
+					},
+					Sender: SenderConfig{
+						Address: "test@example.com",
+					},
+					Templates: &MailTemplatesConfig{
+						ConfigMapRef: ConfigMapReference{Name: "mail-templates", Namespace: "breakglass"},
+						Locale:       "de",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "template override reference without namespace",
+			mp: &MailProvider{
+				Spec: MailProviderSpec{
+					SMTP: SMTPConfig{
+						Host: "smtp.example.com",
+						Port: 587,
+					},
+					Sender: SenderConfig{
+						Address: "test@example.com",
+					},
+					Templates: &MailTemplatesConfig{
+						ConfigMapRef: ConfigMapReference{Name: "mail-templates"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "configMap namespace is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1334,3 +1373,113 @@ func TestMailProviderRealWorldConfigurations(t *testing.T) {
 		})
 	}
 }
+
+func TestMailProviderSMTPAuthMechanisms(t *testing.T) {
+	oauth := func() *SMTPOAuth2Config {
+		return &SMTPOAuth2Config{
+			TokenURL:        "https://login.microsoftonline.com/tenant/oauth2/v2.0/token",
+			ClientID:        "client",
+			ClientSecretRef: SecretKeyReference{Name: "smtp-oauth", Namespace: "breakglass", Key: "client-secret"},
+			Scopes:          []string{"https://outlook.office365.com/.default"},
+		}
+	}
+	password := &SecretKeyReference{Name: "smtp-secret", Namespace: "breakglass", Key: "password"}
+
+	tests := []struct {
+		name    string
+		smtp    SMTPConfig
+		wantErr string
+	}{
+		{
+			name: "XOAUTH2 with client credentials",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				TLSMode: SMTPTLSModeStartTLS, AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: oauth()},
+		},
+		{
+			name:    "XOAUTH2 without oauth2",
+			smtp:    SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com", AuthMechanism: SMTPAuthMechanismXOAUTH2},
+			wantErr: "oauth2 is required for XOAUTH2",
+		},
+		{
+			name:    "XOAUTH2 without username",
+			smtp:    SMTPConfig{Host: "smtp.office365.com", Port: 587, AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: oauth()},
+			wantErr: "username (mailbox) is required",
+		},
+		{
+			name: "XOAUTH2 with password",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				PasswordRef: password, AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: oauth()},
+			wantErr: "passwordRef cannot be combined with XOAUTH2",
+		},
+		{
+			name: "XOAUTH2 without TLS",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				TLSMode: SMTPTLSModeNone, AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: oauth()},
+			wantErr: "XOAUTH2 requires TLS",
+		},
+		{
+			name: "oauth2 token URL must be https",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: func() *SMTPOAuth2Config {
+					o := oauth()
+					o.TokenURL = "http://login.example.com/token"
+					return o
+				}()},
+			wantErr: "tokenURL must be an absolute https URL",
+		},
+		{
+			name: "oauth2 without XOAUTH2 mechanism",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				PasswordRef: password, OAuth2: oauth()},
+			wantErr: "authMechanism must be XOAUTH2",
+		},
+		{
+			name: "oauth2 client secret key missing",
+			smtp: SMTPConfig{Host: "smtp.office365.com", Port: 587, Username: "breakglass@example.com",
+				AuthMechanism: SMTPAuthMechanismXOAUTH2, OAuth2: func() *SMTPOAuth2Config {
+					o := oauth()
+					o.ClientSecretRef.Key = ""
+					return o
+				}()},
+			wantErr: "secret key is required",
+		},
+		{
+			name:    "LOGIN requires password",
+			smtp:    SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "user", AuthMechanism: SMTPAuthMechanismLogin},
+			wantErr: "username and passwordRef are required for authMechanism Login",
+		},
+		{
+			name: "implicit TLS with client certificate",
+			smtp: SMTPConfig{Host: "smtp.example.com", Port: 465, TLSMode: SMTPTLSModeImplicit,
+				ClientCertificateSecretRef: &SecretReference{Name: "smtp-client-cert", Namespace: "breakglass"}},
+		},
+		{
+			name: "client certificate without TLS",
+			smtp: SMTPConfig{Host: "smtp.example.com", Port: 25, TLSMode: SMTPTLSModeNone,
+				ClientCertificateSecretRef: &SecretReference{Name: "smtp-client-cert", Namespace: "breakglass"}},
+			wantErr: "client certificates require TLS",
+		},
+		{
+			name: "client certificate without namespace",
+			smtp: SMTPConfig{Host: "smtp.example.com", Port: 465,
+				ClientCertificateSecretRef: &SecretReference{Name: "smtp-client-cert"}},
+			wantErr: "secret namespace is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := &MailProvider{Spec: MailProviderSpec{SMTP: tt.smtp, Sender: SenderConfig{Address: "noreply@example.com"}}}
+			_, err := mp.ValidateCreate(context.Background(), mp)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid MailProvider, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SCIMIdentityProviderLabel is set on SCIMUser and SCIMGroup resources to the name of the
+// IdentityProvider that provisioned them
+const SCIMIdentityProviderLabel = "breakglass.t-caas.telekom.com/identity-provider"
+
+// SCIMMemberType is the type of a SCIM group member
+type SCIMMemberType string
+
+const (
+	SCIMMemberTypeUser  SCIMMemberType = "User"
+	SCIMMemberTypeGroup SCIMMemberType = "Group"
+)
+
+// SCIMEmail is an email address of a SCIM user
+type SCIMEmail struct {
+	// Value is the email address
+	Value string `json:"value"`
+	// Type is the kind of address (e.g. work)
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Primary marks the preferred address
+	// +optional
+	Primary bool `json:"primary,omitempty"`
+}
+
+// SCIMUserSpec holds a user pushed by an identity provider through the SCIM API
+type SCIMUserSpec struct {
+	// IdentityProvider is the name of the IdentityProvider that owns this user
+	IdentityProvider string `json:"identityProvider"`
+	// UserName is the unique user name within the identity provider
+	UserName string `json:"userName"`
+	// ExternalID is the identifier of the user in the identity provider
+	// +optional
+	ExternalID string `json:"externalId,omitempty"`
+	// DisplayName is the human-readable name of the user
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+	// Emails of the user; the primary address identifies the user as an approver
+	// +optional
+	Emails []SCIMEmail `json:"emails,omitempty"`
+	// Active is false for deprovisioned users, which are not resolved as group members
+	Active bool `json:"active"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="IdentityProvider",type=string,JSONPath=`.spec.identityProvider`
+// +kubebuilder:printcolumn:name="UserName",type=string,JSONPath=`.spec.userName`
+// +kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.spec.active`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SCIMUser is a user provisioned through the SCIM API. The resource name is the SCIM id.
+// SCIMUsers are managed by the SCIM endpoints and should not be edited by hand.
+type SCIMUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SCIMUserSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// SCIMUserList contains a list of SCIMUser resources
+type SCIMUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SCIMUser `json:"items"`
+}
+
+// SCIMMember is a member of a SCIM group
+type SCIMMember struct {
+	// Value is the SCIM id of the member
+	Value string `json:"value"`
+	// Type is User or Group (default: User)
+	// +optional
+	Type SCIMMemberType `json:"type,omitempty"`
+	// Display is the display name of the member
+	// +optional
+	Display string `json:"display,omitempty"`
+}
+
+// SCIMGroupSpec holds a group pushed by an identity provider through the SCIM API
+type SCIMGroupSpec struct {
+	// IdentityProvider is the name of the IdentityProvider that owns this group
+	IdentityProvider string `json:"identityProvider"`
+	// DisplayName is the group name, matched against approver groups of escalations
+	DisplayName string `json:"displayName"`
+	// ExternalID is the identifier of the group in the identity provider
+	// +optional
+	ExternalID string `json:"externalId,omitempty"`
+	// Members of the group
+	// +optional
+	Members []SCIMMember `json:"members,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="IdentityProvider",type=string,JSONPath=`.spec.identityProvider`
+// +kubebuilder:printcolumn:name="DisplayName",type=string,JSONPath=`.spec.displayName`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SCIMGroup is a group provisioned through the SCIM API. The resource name is the SCIM id.
+// SCIMGroups are managed by the SCIM endpoints and should not be edited by hand.
+type SCIMGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SCIMGroupSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// SCIMGroupList contains a list of SCIMGroup resources
+type SCIMGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SCIMGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SCIMUser{}, &SCIMUserList{}, &SCIMGroup{}, &SCIMGroupList{})
+}
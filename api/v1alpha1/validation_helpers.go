@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -407,6 +408,129 @@ func validateTimeoutRelationships(spec *BreakglassEscalationSpec, specPath *fiel
 	return errs
 }
 
+// Bounds for notification digest intervals
+const (
+	MinNotificationDigestInterval = time.Minute
+	MaxNotificationDigestInterval = 24 * time.Hour
+)
+
+// validateNotificationDigestInterval ensures a digest interval is a valid duration within the allowed bounds
+func validateNotificationDigestInterval(interval string, path *field.Path) field.ErrorList {
+	if interval == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, interval, fmt.Sprintf("invalid duration format: %v", err))}
+	}
+	if d < MinNotificationDigestInterval || d > MaxNotificationDigestInterval {
+		return field.ErrorList{field.Invalid(path, interval,
+			fmt.Sprintf("interval must be between %v and %v", MinNotificationDigestInterval, MaxNotificationDigestInterval))}
+	}
+	return nil
+}
+
+// validateApprovalAuthRequirements checks the step-up authentication requirements of an escalation
+func validateApprovalAuthRequirements(req *ApprovalAuthRequirements, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(req.ACRValues) == 0 && len(req.AMRValues) == 0 && req.MaxAuthAge == "" {
+		allErrs = append(allErrs, field.Required(path, "at least one of acrValues, amrValues or maxAuthAge must be specified"))
+	}
+	allErrs = append(allErrs, validateStringListEntriesNotEmpty(req.ACRValues, path.Child("acrValues"))...)
+	allErrs = append(allErrs, validateStringListNoDuplicates(req.ACRValues, path.Child("acrValues"))...)
+	allErrs = append(allErrs, validateStringListEntriesNotEmpty(req.AMRValues, path.Child("amrValues"))...)
+	allErrs = append(allErrs, validateStringListNoDuplicates(req.AMRValues, path.Child("amrValues"))...)
+	if req.MaxAuthAge != "" {
+		d, err := time.ParseDuration(req.MaxAuthAge)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("maxAuthAge"), req.MaxAuthAge, fmt.Sprintf("invalid duration format: %v", err)))
+		} else if d <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("maxAuthAge"), req.MaxAuthAge, "maxAuthAge must be positive"))
+		}
+	}
+	return allErrs
+}
+
+// validateClusterAccess ensures a ClusterConfig configures exactly one way to reach its cluster
+// and that the auth settings name exactly one credential
+func validateClusterAccess(spec *ClusterConfigSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case spec.KubeconfigSecretRef != nil && spec.Auth != nil:
+		return field.ErrorList{field.Forbidden(specPath.Child("auth"), "kubeconfigSecretRef and auth are mutually exclusive")}
+	case spec.KubeconfigSecretRef != nil:
+		ref := spec.KubeconfigSecretRef
+		if ref.Name == "" || ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("kubeconfigSecretRef"), "kubeconfigSecretRef name and namespace are required"))
+		}
+		return allErrs
+	case spec.Auth == nil:
+		return field.ErrorList{field.Required(specPath.Child("kubeconfigSecretRef"), "one of kubeconfigSecretRef or auth is required")}
+	}
+
+	auth := spec.Auth
+	authPath := specPath.Child("auth")
+	if auth.Server == "" {
+		allErrs = append(allErrs, field.Required(authPath.Child("server"), "server is required"))
+	}
+	allErrs = append(allErrs, validateHTTPSURL(auth.Server, authPath.Child("server"))...)
+	credentials := 0
+	if ref := auth.TokenSecretRef; ref != nil {
+		credentials++
+		if ref.Name == "" || ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(authPath.Child("tokenSecretRef"), "tokenSecretRef name and namespace are required"))
+		}
+	}
+	if ref := auth.ClientCertificateSecretRef; ref != nil {
+		credentials++
+		if ref.Name == "" || ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(authPath.Child("clientCertificateSecretRef"), "clientCertificateSecretRef name and namespace are required"))
+		}
+	}
+	if wi := auth.WorkloadIdentity; wi != nil {
+		credentials++
+		if wi.TokenURL == "" {
+			allErrs = append(allErrs, field.Required(authPath.Child("workloadIdentity", "tokenURL"), "tokenURL is required"))
+		}
+		allErrs = append(allErrs, validateHTTPSURL(wi.TokenURL, authPath.Child("workloadIdentity", "tokenURL"))...)
+		allErrs = append(allErrs, validateStringListEntriesNotEmpty(wi.Scopes, authPath.Child("workloadIdentity", "scopes"))...)
+	}
+	if credentials != 1 {
+		allErrs = append(allErrs, field.Invalid(authPath, credentials,
+			"exactly one of tokenSecretRef, clientCertificateSecretRef or workloadIdentity must be set"))
+	}
+	return allErrs
+}
+
+// validateGroupMapping ensures every group of a ClusterConfig group mapping has exactly one counterpart
+func validateGroupMapping(gm *GroupMapping, path *field.Path) field.ErrorList {
+	if gm == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if strings.TrimSpace(gm.OIDCPrefix) != gm.OIDCPrefix {
+		allErrs = append(allErrs, field.Invalid(path.Child("oidcPrefix"), gm.OIDCPrefix, "oidcPrefix must not contain leading or trailing whitespace"))
+	}
+	idpGroups := make(map[string]bool, len(gm.Rules))
+	clusterGroups := make(map[string]bool, len(gm.Rules))
+	for i, r := range gm.Rules {
+		rulePath := path.Child("rules").Index(i)
+		if strings.TrimSpace(r.IDPGroup) == "" {
+			allErrs = append(allErrs, field.Required(rulePath.Child("idpGroup"), "idpGroup is required"))
+		} else if idpGroups[r.IDPGroup] {
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("idpGroup"), r.IDPGroup))
+		}
+		if strings.TrimSpace(r.ClusterGroup) == "" {
+			allErrs = append(allErrs, field.Required(rulePath.Child("clusterGroup"), "clusterGroup is required"))
+		} else if clusterGroups[r.ClusterGroup] {
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("clusterGroup"), r.ClusterGroup))
+		}
+		idpGroups[r.IDPGroup] = true
+		clusterGroups[r.ClusterGroup] = true
+	}
+	return allErrs
+}
+
 // the session is allowed by the associated escalation rule.
 // This is Session Authorization Webhook validation.
 //
@@ -459,13 +583,8 @@ func validateSessionIdentityProviderAuthorization(
 		}
 
 		// Check if escalation's clusters include this session's cluster
-		clusterMatches := false
-		for _, allowedCluster := range esc.Spec.Allowed.Clusters {
-			if allowedCluster == sessionCluster {
-				clusterMatches = true
-				break
-			}
-		}
+		clusterMatches := slices.Contains(esc.Spec.Allowed.Clusters, sessionCluster) ||
+			slices.Contains(esc.Status.ResolvedClusters, sessionCluster)
 
 		if clusterMatches {
 			relevantEscalations = append(relevantEscalations, esc)
@@ -15,6 +15,71 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalAuthRequirements) DeepCopyInto(out *ApprovalAuthRequirements) {
+	*out = *in
+	if in.ACRValues != nil {
+		in, out := &in.ACRValues, &out.ACRValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AMRValues != nil {
+		in, out := &in.AMRValues, &out.AMRValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalAuthRequirements.
+func (in *ApprovalAuthRequirements) DeepCopy() *ApprovalAuthRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalAuthRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalLinkRecord) DeepCopyInto(out *ApprovalLinkRecord) {
+	*out = *in
+	in.UsedAt.DeepCopyInto(&out.UsedAt)
+	in.RevokedAt.DeepCopyInto(&out.RevokedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalLinkRecord.
+func (in *ApprovalLinkRecord) DeepCopy() *ApprovalLinkRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalLinkRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalLinksStatus) DeepCopyInto(out *ApprovalLinksStatus) {
+	*out = *in
+	if in.Links != nil {
+		in, out := &in.Links, &out.Links
+		*out = make([]ApprovalLinkRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.RevokedAt.DeepCopyInto(&out.RevokedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalLinksStatus.
+func (in *ApprovalLinksStatus) DeepCopy() *ApprovalLinksStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalLinksStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BreakglassEscalation) DeepCopyInto(out *BreakglassEscalation) {
 	*out = *in
@@ -139,6 +204,11 @@ func (in *BreakglassEscalationSpec) DeepCopyInto(out *BreakglassEscalationSpec)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(ClusterSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DenyPolicyRefs != nil {
 		in, out := &in.DenyPolicyRefs, &out.DenyPolicyRefs
 		*out = make([]string, len(*in))
@@ -189,6 +259,16 @@ func (in *BreakglassEscalationSpec) DeepCopyInto(out *BreakglassEscalationSpec)
 		*out = new(NotificationExclusions)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.NotificationDigest != nil {
+		in, out := &in.NotificationDigest, &out.NotificationDigest
+		*out = new(NotificationDigestConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApprovalAuthRequirements != nil {
+		in, out := &in.ApprovalAuthRequirements, &out.ApprovalAuthRequirements
+		*out = new(ApprovalAuthRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BreakglassEscalationSpec.
@@ -247,6 +327,11 @@ func (in *BreakglassEscalationStatus) DeepCopyInto(out *BreakglassEscalationStat
 			(*out)[key] = outVal
 		}
 	}
+	if in.ResolvedClusters != nil {
+		in, out := &in.ResolvedClusters, &out.ResolvedClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -380,6 +465,11 @@ func (in *BreakglassSessionStatus) DeepCopyInto(out *BreakglassSessionStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ApprovalLinks != nil {
+		in, out := &in.ApprovalLinks, &out.ApprovalLinks
+		*out = new(ApprovalLinksStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BreakglassSessionStatus.
@@ -392,6 +482,71 @@ func (in *BreakglassSessionStatus) DeepCopy() *BreakglassSessionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimMappings) DeepCopyInto(out *ClaimMappings) {
+	*out = *in
+	if in.Username != nil {
+		in, out := &in.Username, &out.Username
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GroupTransform != nil {
+		in, out := &in.GroupTransform, &out.GroupTransform
+		*out = new(GroupTransform)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimMappings.
+func (in *ClaimMappings) DeepCopy() *ClaimMappings {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimMappings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAuth) DeepCopyInto(out *ClusterAuth) {
+	*out = *in
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentityAuth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAuth.
+func (in *ClusterAuth) DeepCopy() *ClusterAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfig) DeepCopyInto(out *ClusterConfig) {
 	*out = *in
@@ -454,7 +609,16 @@ func (in *ClusterConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfigSpec) DeepCopyInto(out *ClusterConfigSpec) {
 	*out = *in
-	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(ClusterAuth)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.QPS != nil {
 		in, out := &in.QPS, &out.QPS
 		*out = new(int32)
@@ -475,6 +639,11 @@ func (in *ClusterConfigSpec) DeepCopyInto(out *ClusterConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.GroupMapping != nil {
+		in, out := &in.GroupMapping, &out.GroupMapping
+		*out = new(GroupMapping)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConfigSpec.
@@ -490,6 +659,28 @@ func (in *ClusterConfigSpec) DeepCopy() *ClusterConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfigStatus) DeepCopyInto(out *ClusterConfigStatus) {
 	*out = *in
+	if in.LastProbeTime != nil {
+		in, out := &in.LastProbeTime, &out.LastProbeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastProbeLatency != nil {
+		in, out := &in.LastProbeLatency, &out.LastProbeLatency
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ClientCertificateExpiry != nil {
+		in, out := &in.ClientCertificateExpiry, &out.ClientCertificateExpiry
+		*out = (*in).DeepCopy()
+	}
+	if in.CertificateAuthorityExpiry != nil {
+		in, out := &in.CertificateAuthorityExpiry, &out.CertificateAuthorityExpiry
+		*out = (*in).DeepCopy()
+	}
+	if in.AuthorizationWebhookConfigured != nil {
+		in, out := &in.AuthorizationWebhookConfigured, &out.AuthorizationWebhookConfigured
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -509,6 +700,41 @@ func (in *ClusterConfigStatus) DeepCopy() *ClusterConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSelector) DeepCopyInto(out *ClusterSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSelector.
+func (in *ClusterSelector) DeepCopy() *ClusterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DenyPolicy) DeepCopyInto(out *DenyPolicy) {
 	*out = *in
@@ -697,6 +923,81 @@ func (in *DenyRule) DeepCopy() *DenyRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupMapping) DeepCopyInto(out *GroupMapping) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]GroupMappingRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupMapping.
+func (in *GroupMapping) DeepCopy() *GroupMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupMappingRule) DeepCopyInto(out *GroupMappingRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupMappingRule.
+func (in *GroupMappingRule) DeepCopy() *GroupMappingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupMappingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupTransform) DeepCopyInto(out *GroupTransform) {
+	*out = *in
+	if in.StripPrefixes != nil {
+		in, out := &in.StripPrefixes, &out.StripPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupTransform.
+func (in *GroupTransform) DeepCopy() *GroupTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPJSONGroupSync) DeepCopyInto(out *HTTPJSONGroupSync) {
+	*out = *in
+	if in.TokenRef != nil {
+		in, out := &in.TokenRef, &out.TokenRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPJSONGroupSync.
+func (in *HTTPJSONGroupSync) DeepCopy() *HTTPJSONGroupSync {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPJSONGroupSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IdentityProvider) DeepCopyInto(out *IdentityProvider) {
 	*out = *in
@@ -759,12 +1060,47 @@ func (in *IdentityProviderList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IdentityProviderSpec) DeepCopyInto(out *IdentityProviderSpec) {
 	*out = *in
-	out.OIDC = in.OIDC
+	in.OIDC.DeepCopyInto(&out.OIDC)
 	if in.Keycloak != nil {
 		in, out := &in.Keycloak, &out.Keycloak
 		*out = new(KeycloakGroupSync)
 		**out = **in
 	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(LDAPGroupSync)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SCIM != nil {
+		in, out := &in.SCIM, &out.SCIM
+		*out = new(SCIMGroupSync)
+		**out = **in
+	}
+	if in.MicrosoftGraph != nil {
+		in, out := &in.MicrosoftGraph, &out.MicrosoftGraph
+		*out = new(MicrosoftGraphGroupSync)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPJSON != nil {
+		in, out := &in.HTTPJSON, &out.HTTPJSON
+		*out = new(HTTPJSONGroupSync)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClaimMappings != nil {
+		in, out := &in.ClaimMappings, &out.ClaimMappings
+		*out = new(ClaimMappings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenValidation != nil {
+		in, out := &in.TokenValidation, &out.TokenValidation
+		*out = new(TokenValidation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenRevocation != nil {
+		in, out := &in.TokenRevocation, &out.TokenRevocation
+		*out = new(TokenRevocation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderSpec.
@@ -787,6 +1123,13 @@ func (in *IdentityProviderStatus) DeepCopyInto(out *IdentityProviderStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LogoutRevocations != nil {
+		in, out := &in.LogoutRevocations, &out.LogoutRevocations
+		*out = make([]LogoutRevocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderStatus.
@@ -815,6 +1158,43 @@ func (in *KeycloakGroupSync) DeepCopy() *KeycloakGroupSync {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPGroupSync) DeepCopyInto(out *LDAPGroupSync) {
+	*out = *in
+	out.BindPasswordRef = in.BindPasswordRef
+	if in.UserIdentifierAttributes != nil {
+		in, out := &in.UserIdentifierAttributes, &out.UserIdentifierAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPGroupSync.
+func (in *LDAPGroupSync) DeepCopy() *LDAPGroupSync {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPGroupSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogoutRevocation) DeepCopyInto(out *LogoutRevocation) {
+	*out = *in
+	in.RevokedAt.DeepCopyInto(&out.RevokedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogoutRevocation.
+func (in *LogoutRevocation) DeepCopy() *LogoutRevocation {
+	if in == nil {
+		return nil
+	}
+	out := new(LogoutRevocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MailProvider) DeepCopyInto(out *MailProvider) {
 	*out = *in
@@ -880,6 +1260,11 @@ func (in *MailProviderSpec) DeepCopyInto(out *MailProviderSpec) {
 	in.SMTP.DeepCopyInto(&out.SMTP)
 	out.Sender = in.Sender
 	out.Retry = in.Retry
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = new(MailTemplatesConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MailProviderSpec.
@@ -910,6 +1295,11 @@ func (in *MailProviderStatus) DeepCopyInto(out *MailProviderStatus) {
 		in, out := &in.LastSendAttempt, &out.LastSendAttempt
 		*out = (*in).DeepCopy()
 	}
+	if in.TemplateOverrides != nil {
+		in, out := &in.TemplateOverrides, &out.TemplateOverrides
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MailProviderStatus.
@@ -923,34 +1313,96 @@ func (in *MailProviderStatus) DeepCopy() *MailProviderStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NotificationExclusions) DeepCopyInto(out *NotificationExclusions) {
+func (in *MailTemplatesConfig) DeepCopyInto(out *MailTemplatesConfig) {
 	*out = *in
-	if in.Users != nil {
-		in, out := &in.Users, &out.Users
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Groups != nil {
-		in, out := &in.Groups, &out.Groups
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
+	out.ConfigMapRef = in.ConfigMapRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationExclusions.
-func (in *NotificationExclusions) DeepCopy() *NotificationExclusions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MailTemplatesConfig.
+func (in *MailTemplatesConfig) DeepCopy() *MailTemplatesConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(NotificationExclusions)
+	out := new(MailTemplatesConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OIDCConfig) DeepCopyInto(out *OIDCConfig) {
+func (in *MicrosoftGraphGroupSync) DeepCopyInto(out *MicrosoftGraphGroupSync) {
 	*out = *in
-}
+	out.ClientSecretRef = in.ClientSecretRef
+	if in.UserIdentifierAttributes != nil {
+		in, out := &in.UserIdentifierAttributes, &out.UserIdentifierAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MicrosoftGraphGroupSync.
+func (in *MicrosoftGraphGroupSync) DeepCopy() *MicrosoftGraphGroupSync {
+	if in == nil {
+		return nil
+	}
+	out := new(MicrosoftGraphGroupSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationDigestConfig) DeepCopyInto(out *NotificationDigestConfig) {
+	*out = *in
+	if in.UrgentReasonKeywords != nil {
+		in, out := &in.UrgentReasonKeywords, &out.UrgentReasonKeywords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationDigestConfig.
+func (in *NotificationDigestConfig) DeepCopy() *NotificationDigestConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationDigestConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationExclusions) DeepCopyInto(out *NotificationExclusions) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationExclusions.
+func (in *NotificationExclusions) DeepCopy() *NotificationExclusions {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationExclusions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCConfig) DeepCopyInto(out *OIDCConfig) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+}
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCConfig.
 func (in *OIDCConfig) DeepCopy() *OIDCConfig {
@@ -977,6 +1429,26 @@ func (in *ReasonConfig) DeepCopy() *ReasonConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequiredClaim) DeepCopyInto(out *RequiredClaim) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequiredClaim.
+func (in *RequiredClaim) DeepCopy() *RequiredClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(RequiredClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetryConfig) DeepCopyInto(out *RetryConfig) {
 	*out = *in
@@ -992,6 +1464,208 @@ func (in *RetryConfig) DeepCopy() *RetryConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMEmail) DeepCopyInto(out *SCIMEmail) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMEmail.
+func (in *SCIMEmail) DeepCopy() *SCIMEmail {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMEmail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMGroup) DeepCopyInto(out *SCIMGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMGroup.
+func (in *SCIMGroup) DeepCopy() *SCIMGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SCIMGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMGroupList) DeepCopyInto(out *SCIMGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SCIMGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMGroupList.
+func (in *SCIMGroupList) DeepCopy() *SCIMGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SCIMGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMGroupSpec) DeepCopyInto(out *SCIMGroupSpec) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]SCIMMember, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMGroupSpec.
+func (in *SCIMGroupSpec) DeepCopy() *SCIMGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMGroupSync) DeepCopyInto(out *SCIMGroupSync) {
+	*out = *in
+	out.BearerTokenRef = in.BearerTokenRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMGroupSync.
+func (in *SCIMGroupSync) DeepCopy() *SCIMGroupSync {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMGroupSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMMember) DeepCopyInto(out *SCIMMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMMember.
+func (in *SCIMMember) DeepCopy() *SCIMMember {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMUser) DeepCopyInto(out *SCIMUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMUser.
+func (in *SCIMUser) DeepCopy() *SCIMUser {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SCIMUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMUserList) DeepCopyInto(out *SCIMUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SCIMUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMUserList.
+func (in *SCIMUserList) DeepCopy() *SCIMUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SCIMUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SCIMUserSpec) DeepCopyInto(out *SCIMUserSpec) {
+	*out = *in
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
+		*out = make([]SCIMEmail, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SCIMUserSpec.
+func (in *SCIMUserSpec) DeepCopy() *SCIMUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SCIMUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SMTPConfig) DeepCopyInto(out *SMTPConfig) {
 	*out = *in
@@ -1000,6 +1674,16 @@ func (in *SMTPConfig) DeepCopyInto(out *SMTPConfig) {
 		*out = new(SecretKeyReference)
 		**out = **in
 	}
+	if in.OAuth2 != nil {
+		in, out := &in.OAuth2, &out.OAuth2
+		*out = new(SMTPOAuth2Config)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPConfig.
@@ -1012,6 +1696,27 @@ func (in *SMTPConfig) DeepCopy() *SMTPConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPOAuth2Config) DeepCopyInto(out *SMTPOAuth2Config) {
+	*out = *in
+	out.ClientSecretRef = in.ClientSecretRef
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPOAuth2Config.
+func (in *SMTPOAuth2Config) DeepCopy() *SMTPOAuth2Config {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPOAuth2Config)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
 	*out = *in
@@ -1027,6 +1732,21 @@ func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SenderConfig) DeepCopyInto(out *SenderConfig) {
 	*out = *in
@@ -1041,3 +1761,91 @@ func (in *SenderConfig) DeepCopy() *SenderConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenIntrospection) DeepCopyInto(out *TokenIntrospection) {
+	*out = *in
+	out.ClientSecretRef = in.ClientSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenIntrospection.
+func (in *TokenIntrospection) DeepCopy() *TokenIntrospection {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenIntrospection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenRevocation) DeepCopyInto(out *TokenRevocation) {
+	*out = *in
+	if in.Introspection != nil {
+		in, out := &in.Introspection, &out.Introspection
+		*out = new(TokenIntrospection)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenRevocation.
+func (in *TokenRevocation) DeepCopy() *TokenRevocation {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenRevocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenValidation) DeepCopyInto(out *TokenValidation) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthorizedParties != nil {
+		in, out := &in.AuthorizedParties, &out.AuthorizedParties
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredClaims != nil {
+		in, out := &in.RequiredClaims, &out.RequiredClaims
+		*out = make([]RequiredClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenValidation.
+func (in *TokenValidation) DeepCopy() *TokenValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityAuth) DeepCopyInto(out *WorkloadIdentityAuth) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityAuth.
+func (in *WorkloadIdentityAuth) DeepCopy() *WorkloadIdentityAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityAuth)
+	in.DeepCopyInto(out)
+	return out
+}
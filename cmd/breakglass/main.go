@@ -0,0 +1,284 @@
+// Command breakglass holds operator tooling for breakglass installations.
+//
+//	breakglass onboard --cluster-config prod-1 --hub-url https://breakglass.example.com --hub-ca-file ca.crt
+//
+// generates the webhook kubeconfig and AuthorizationConfiguration that make the cluster's API server
+// call the breakglass authorization webhook, and the RBAC the hub needs on the cluster.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
+	"github.com/telekom/k8s-breakglass/pkg/onboard"
+)
+
+const (
+	webhookKubeconfigFile   = "breakglass-webhook-kubeconfig.yaml"
+	authorizationConfigFile = "authorization-config.yaml"
+	rbacFile                = "breakglass-hub-rbac.yaml"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "onboard" {
+		fmt.Fprintln(os.Stderr, "usage: breakglass onboard [flags]")
+		os.Exit(2)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if err := runOnboard(ctx, os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+type onboardFlags struct {
+	clusterConfig     string
+	namespace         string
+	clusterConfigFile string
+	hubKubeconfig     string
+	spokeKubeconfig   string
+	hubCAFile         string
+	webhookTokenFile  string
+	outputDir         string
+	matchConditions   stringList
+	apply             bool
+	diff              bool
+	validate          bool
+	opts              onboard.Options
+}
+
+type stringList []string
+
+func (s *stringList) String() string     { return strings.Join(*s, ", ") }
+func (s *stringList) Set(v string) error { *s = append(*s, v); return nil }
+
+func parseOnboardFlags(args []string) (*onboardFlags, error) {
+	f := &onboardFlags{}
+	fs := flag.NewFlagSet("onboard", flag.ContinueOnError)
+	fs.StringVar(&f.clusterConfig, "cluster-config", "", "Name of the ClusterConfig on the hub to onboard")
+	fs.StringVar(&f.namespace, "namespace", "", "Namespace of the ClusterConfig (default: search all namespaces)")
+	fs.StringVar(&f.clusterConfigFile, "cluster-config-file", "", "Read the ClusterConfig from a file instead of the hub")
+	fs.StringVar(&f.hubKubeconfig, "hub-kubeconfig", "", "Kubeconfig of the hub cluster (default: KUBECONFIG or in-cluster)")
+	fs.StringVar(&f.spokeKubeconfig, "spoke-kubeconfig", "", "Admin kubeconfig of the cluster being onboarded, needed for --apply and --diff")
+	fs.StringVar(&f.opts.HubURL, "hub-url", "", "External base URL of the breakglass hub, e.g. https://breakglass.example.com")
+	fs.StringVar(&f.opts.WebhookPath, "webhook-path", onboard.DefaultWebhookPath, "Path of the authorization webhook on the hub")
+	fs.StringVar(&f.hubCAFile, "hub-ca-file", "", "PEM CA bundle the API server uses to verify the hub")
+	fs.BoolVar(&f.opts.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Do not verify the hub certificate (testing only)")
+	fs.StringVar(&f.webhookTokenFile, "webhook-token-file", "", "File with the bearer token the API server presents to the hub")
+	fs.StringVar(&f.opts.KubeconfigPath, "kubeconfig-path", onboard.DefaultKubeconfigPath, "Path of the webhook kubeconfig on the control plane nodes")
+	fs.StringVar(&f.opts.APIVersion, "api-version", onboard.DefaultAuthorizationConfigAPIVersion, "apiVersion of the generated AuthorizationConfiguration")
+	fs.DurationVar(&f.opts.Timeout, "timeout", 3*time.Second, "Timeout of a webhook call (1s to 30s)")
+	fs.DurationVar(&f.opts.AuthorizedTTL, "authorized-ttl", 30*time.Second, "How long the API server caches allowed decisions")
+	fs.DurationVar(&f.opts.UnauthorizedTTL, "unauthorized-ttl", 30*time.Second, "How long the API server caches denied decisions")
+	fs.StringVar(&f.opts.FailurePolicy, "failure-policy", onboard.FailurePolicyDeny, "Decision when the webhook fails: Deny or NoOpinion")
+	fs.Var(&f.matchConditions, "match-condition", "CEL match condition replacing the default (repeatable)")
+	fs.StringVar(&f.opts.HubNamespace, "hub-namespace", onboard.DefaultHubNamespace, "Namespace of the hub's ServiceAccount on the cluster")
+	fs.StringVar(&f.opts.HubServiceAccount, "hub-service-account", onboard.DefaultHubServiceAccount, "Name of the hub's ServiceAccount on the cluster")
+	fs.StringVar(&f.outputDir, "output-dir", "", "Write the generated files to this directory instead of stdout")
+	fs.BoolVar(&f.apply, "apply", false, "Apply the RBAC to the cluster")
+	fs.BoolVar(&f.diff, "diff", false, "Print a diff against the files in --output-dir and the RBAC on the cluster, without changing anything")
+	fs.BoolVar(&f.validate, "validate", false, "Check the cluster with the hub's ClusterConfig credentials")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if (f.clusterConfig == "") == (f.clusterConfigFile == "") {
+		return nil, errors.New("exactly one of --cluster-config and --cluster-config-file is required")
+	}
+	if (f.apply || f.diff) && f.spokeKubeconfig == "" && f.outputDir == "" {
+		return nil, errors.New("--apply and --diff need --spoke-kubeconfig or --output-dir")
+	}
+	if f.apply && f.spokeKubeconfig == "" {
+		return nil, errors.New("--apply needs --spoke-kubeconfig")
+	}
+	if f.validate && f.clusterConfig == "" {
+		return nil, errors.New("--validate needs --cluster-config to read the hub's credentials")
+	}
+	f.opts.MatchConditions = f.matchConditions
+	return f, nil
+}
+
+func runOnboard(ctx context.Context, args []string, out io.Writer) error {
+	f, err := parseOnboardFlags(args)
+	if err != nil {
+		return err
+	}
+
+	var hubClient client.Client
+	if f.clusterConfig != "" {
+		if hubClient, err = newClient(f.hubKubeconfig); err != nil {
+			return fmt.Errorf("hub client: %w", err)
+		}
+	}
+	cc, err := loadClusterConfig(ctx, f, hubClient)
+	if err != nil {
+		return err
+	}
+
+	opts := f.opts
+	opts.ClusterName = cc.Name
+	opts.AccessReviewMode = cc.Spec.AccessReviewMode
+	if f.hubCAFile != "" {
+		if opts.HubCAData, err = os.ReadFile(f.hubCAFile); err != nil {
+			return fmt.Errorf("failed to read hub CA: %w", err)
+		}
+	}
+	if f.webhookTokenFile != "" {
+		token, err := os.ReadFile(f.webhookTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read webhook token: %w", err)
+		}
+		opts.WebhookToken = strings.TrimSpace(string(token))
+	}
+	result, err := onboard.Generate(opts)
+	if err != nil {
+		return err
+	}
+	rbac, err := onboard.RenderObjects(result.RBAC)
+	if err != nil {
+		return err
+	}
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{webhookKubeconfigFile, result.WebhookKubeconfig},
+		{authorizationConfigFile, result.AuthorizationConfig},
+		{rbacFile, rbac},
+	}
+
+	var spokeClient client.Client
+	if f.spokeKubeconfig != "" {
+		if spokeClient, err = newClient(f.spokeKubeconfig); err != nil {
+			return fmt.Errorf("spoke client: %w", err)
+		}
+	}
+
+	if f.diff {
+		if f.outputDir != "" {
+			for _, file := range files {
+				path := filepath.Join(f.outputDir, file.name)
+				current, err := os.ReadFile(path)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+				d, err := onboard.DiffText(path, path+" (generated)", current, file.content)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, d)
+			}
+		}
+		if spokeClient != nil {
+			d, err := onboard.DiffRBAC(ctx, spokeClient, result.RBAC)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, d)
+		}
+		return nil
+	}
+
+	if f.outputDir != "" {
+		if err := os.MkdirAll(f.outputDir, 0o755); err != nil {
+			return err
+		}
+		for _, file := range files {
+			// the webhook kubeconfig can contain a bearer token
+			if err := os.WriteFile(filepath.Join(f.outputDir, file.name), file.content, 0o600); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "wrote %s\n", filepath.Join(f.outputDir, file.name))
+		}
+	} else if !f.apply && !f.validate {
+		for _, file := range files {
+			fmt.Fprintf(out, "# %s\n%s", file.name, ensureNewline(file.content))
+			if file.name != rbacFile {
+				fmt.Fprintln(out, "---")
+			}
+		}
+	}
+
+	if f.apply {
+		actions, err := onboard.ApplyRBAC(ctx, spokeClient, result.RBAC)
+		for _, action := range actions {
+			fmt.Fprintln(out, action)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "point the ClusterConfig credentials at the token in Secret %s/%s-token on the cluster\n", opts.HubNamespace, opts.HubServiceAccount)
+	}
+
+	if f.validate {
+		restCfg, err := cluster.NewClientProvider(hubClient, zap.NewNop().Sugar()).GetRESTConfig(ctx, cc.Name)
+		if err != nil {
+			return fmt.Errorf("failed to build the hub's credentials for %s: %w", cc.Name, err)
+		}
+		v, err := onboard.Validate(ctx, restCfg, cc.Spec.AccessReviewMode)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, v.String())
+		if !v.OK() {
+			return errors.New("cluster is not fully onboarded")
+		}
+	}
+	return nil
+}
+
+func loadClusterConfig(ctx context.Context, f *onboardFlags, hubClient client.Client) (*telekomv1alpha1.ClusterConfig, error) {
+	if f.clusterConfigFile != "" {
+		raw, err := os.ReadFile(f.clusterConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		cc := &telekomv1alpha1.ClusterConfig{}
+		if err := yaml.UnmarshalStrict(raw, cc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.clusterConfigFile, err)
+		}
+		return cc, nil
+	}
+	provider := cluster.NewClientProvider(hubClient, zap.NewNop().Sugar())
+	if f.namespace != "" {
+		return provider.GetInNamespace(ctx, f.namespace, f.clusterConfig)
+	}
+	return provider.GetAcrossAllNamespaces(ctx, f.clusterConfig)
+}
+
+func newClient(kubeconfig string) (client.Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: breakglass.Scheme})
+}
+
+func ensureNewline(b []byte) []byte {
+	if len(b) > 0 && !bytes.HasSuffix(b, []byte("\n")) {
+		return append(b, '\n')
+	}
+	return b
+}
@@ -180,6 +180,14 @@ func main() {
 
 	escalationManager := breakglass.NewEscalationManagerWithClient(reconcilerMgr.GetClient(), resolver)
 
+	if cfg.Kubernetes.WorkloadIdentityTokenFile != "" {
+		cluster.WorkloadIdentityTokenFile = cfg.Kubernetes.WorkloadIdentityTokenFile
+	}
+	cluster.WorkloadIdentityTokenURLs = cfg.Kubernetes.WorkloadIdentityTokenURLs
+
+	// Clients for managed clusters are shared by the webhook, the checker and the session controller
+	cluster.DefaultClientPool = cluster.NewClientPool(cli.ParseSpokeClientPoolOptions(cfg.Kubernetes.SpokeClients, log))
+
 	// Build shared cluster config provider & deny policy evaluator reusing kubernetes client
 	ccProvider := cluster.NewClientProvider(escalationManager.Client, log)
 	denyEval := policy.NewEvaluator(escalationManager.Client, log)
@@ -189,6 +197,12 @@ func main() {
 		log.Warn(err)
 	}
 
+	// Template overrides from the default MailProvider (or global mail config); embedded templates otherwise
+	mailTemplateLoader := mail.NewTemplateLoader(uncachedClient, cfg.Mail, log)
+	if err := mailTemplateLoader.Reload(ctx); err != nil {
+		log.Warnw("Failed to load mail template overrides, using embedded templates", "error", err)
+	}
+
 	// Enable multi-IDP support in auth handler for token verification
 	// This allows the backend to verify tokens from any configured IDP, not just the default one
 	auth.WithIdentityProviderLoader(idpLoader)
@@ -197,11 +211,41 @@ func main() {
 
 	// Setup session controller with all dependencies
 	sessionController := breakglass.NewBreakglassSessionController(log, cfg, &sessionManager, &escalationManager,
-		auth.Middleware(), cliConfig.ConfigPath, ccProvider, escalationManager.Client, cliConfig.DisableEmail).WithQueue(mailQueue)
+		auth.Middleware(), cliConfig.ConfigPath, ccProvider, escalationManager.Client, cliConfig.DisableEmail).WithQueue(mailQueue).WithMailTemplates(mailTemplateLoader.Templates()).
+		WithNotificationDigest(breakglass.NewNotificationDigest())
+	sessionWatch := breakglass.NewSessionWatchHub(log)
+	sessionController.WithSessionWatch(sessionWatch)
+	// End the sessions of users who logged out or were disabled (tokenRevocation.terminateSessions)
+	auth.WithSessionTerminator(sessionController.EndSessionsOfUser)
+
+	approvalLinkSigner, err := breakglass.LoadApprovalLinkSigner(cfg.ApprovalLinks)
+	if err != nil {
+		log.Fatalf("Invalid approval link configuration: %v", err)
+	}
+	if approvalLinkSigner != nil {
+		sessionController.WithApprovalLinks(approvalLinkSigner)
+		log.Infow("Email approval links enabled", "ttl", approvalLinkSigner.TTL())
+	}
 
 	// Register API controllers based on component flags
 	apiControllers := api.Setup(sessionController, &escalationManager, &sessionManager, cliConfig.EnableFrontend,
 		cliConfig.EnableAPI, cliConfig.ConfigPath, auth, ccProvider, denyEval, &cfg, log)
+	if cliConfig.EnableAPI {
+		// SCIM endpoints for identity providers that push users and groups (groupSyncProvider SCIM)
+		apiControllers = append(apiControllers, breakglass.NewSCIMController(log, uncachedClient, idpLoader))
+		// OIDC Back-Channel Logout for identity providers with tokenRevocation.backChannelLogout
+		apiControllers = append(apiControllers, api.NewBackChannelLogoutController(log, auth, uncachedClient))
+		// Server-side OIDC login with cookie sessions (bff section of config.yaml)
+		bff, err := api.NewBFF(log, cfg, auth)
+		if err != nil {
+			log.Fatalf("Invalid server-side login configuration: %v", err)
+		}
+		if bff != nil {
+			auth.WithBFF(bff)
+			apiControllers = append(apiControllers, bff)
+			log.Infow("Server-side login enabled", "redirectURI", cfg.Frontend.BaseURL+"/api/auth/callback")
+		}
+	}
 
 	// Make IdentityProvider available to API server for frontend configuration
 	if idpConfig != nil {
@@ -243,9 +287,21 @@ func main() {
 		log.Infow("Cleanup routine disabled via --enable-cleanup=false")
 	}
 
+	// Notification digests are buffered per replica, so every replica sends its own due digests
+	if !cliConfig.DisableEmail {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sessionController.RunNotificationDigest(managerCtx)
+		}()
+	}
+
 	if err := cluster.RegisterInvalidationHandlers(managerCtx, reconcilerMgr, ccProvider, log); err != nil {
 		log.Warnw("Failed to register cluster cache invalidation handlers", "error", err)
 	}
+	if err := sessionWatch.Register(managerCtx, reconcilerMgr); err != nil {
+		log.Warnw("Failed to register session watch handler", "error", err)
+	}
 
 	// Event recorder for emitting Kubernetes events (persisted to API server)
 	kubeClientset, err := kubernetes.NewForConfig(restConfig)
@@ -269,6 +325,7 @@ func main() {
 			Resolver:      escalationManager.Resolver,
 			EventRecorder: eventRecorder,
 			IDPLoader:     idpLoader,
+			Resolvers:     breakglass.NewResolverRegistry(),
 			Interval:      cli.ParseEscalationStatusUpdateInterval(cliConfig.EscalationStatusUpdateInt, log),
 			LeaderElected: leaderElectedCh,
 		}.Start(managerCtx)
@@ -319,14 +376,31 @@ func main() {
 		intervalStr = cfg.Kubernetes.ClusterConfigCheckInterval
 	}
 	interval := cli.ParseClusterConfigCheckInterval(intervalStr, log)
+	certificateExpiryWarning := cli.ParseCertificateExpiryWarning(cfg.Kubernetes.CertificateExpiryWarning, log)
 
 	// ClusterConfig checker: validates that referenced kubeconfig secrets contain the expected key
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		breakglass.ClusterConfigChecker{Log: log, Client: escalationManager.Client, Recorder: recorder, Interval: interval, LeaderElected: leaderElectedCh}.Start(managerCtx)
+		breakglass.ClusterConfigChecker{Log: log, Client: escalationManager.Client, Recorder: recorder, Interval: interval, LeaderElected: leaderElectedCh, CertificateExpiryWarning: certificateExpiryWarning}.Start(managerCtx)
 	}()
 
+	// Cluster discovery: manages ClusterConfigs for Cluster API clusters or kubeconfig Secrets
+	if cfg.ClusterDiscovery.Enabled {
+		discoverer, err := cluster.NewDiscoverer(uncachedClient, cfg.ClusterDiscovery, leaderElectedCh, log.With("component", "cluster-discovery"))
+		if err != nil {
+			log.Fatalf("Invalid cluster discovery configuration: %v", err)
+		}
+		if err := discoverer.SetupWithManager(reconcilerMgr); err != nil {
+			log.Fatalf("Failed to set up cluster discovery controller: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			discoverer.Start(managerCtx)
+		}()
+	}
+
 	var certsReady chan struct{}
 	certMgrErr := make(chan error)
 	defer close(certMgrErr)
@@ -366,7 +440,7 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := reconciler.Setup(managerCtx, reconcilerMgr, idpLoader, server, log); err != nil {
+		if err := reconciler.Setup(managerCtx, reconcilerMgr, idpLoader, server, mailTemplateLoader, log); err != nil {
 			recMgrErr <- err
 		}
 	}()
@@ -5,6 +5,7 @@ import BreaglassSessionReviewView from "@/views/BreakglassSessionReview.vue";
 import PendingApprovalsView from "@/views/PendingApprovalsView.vue";
 import SessionBrowser from "@/views/SessionBrowser.vue";
 import NotFoundView from "@/views/NotFoundView.vue";
+import ApprovalLinkView from "@/views/ApprovalLinkView.vue";
 
 import { createRouter, createWebHistory } from "vue-router";
 
@@ -27,6 +28,13 @@ const router = createRouter({
       name: "pendingApprovals",
       component: PendingApprovalsView,
     },
+    {
+      // Confirmation page for one-click approval links from request emails; usable before signing in
+      path: "/approvals/link",
+      name: "approvalLink",
+      component: ApprovalLinkView,
+      meta: { public: true },
+    },
     {
       path: "/requests/mine",
       name: "myPendingRequests",
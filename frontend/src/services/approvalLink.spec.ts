@@ -0,0 +1,24 @@
+/**
+ * Tests for approval link helpers
+ *
+ * @jest-environment jsdom
+ */
+
+/// <reference types="jest" />
+
+import { readApprovalLinkToken } from "@/services/approvalLink";
+
+describe("readApprovalLinkToken", () => {
+  it("reads the token from the URL fragment", () => {
+    expect(readApprovalLinkToken("#token=abc.def.ghi")).toBe("abc.def.ghi");
+  });
+
+  it("ignores other fragment parameters", () => {
+    expect(readApprovalLinkToken("#foo=bar&token=abc")).toBe("abc");
+  });
+
+  it("returns an empty string without a token", () => {
+    expect(readApprovalLinkToken("")).toBe("");
+    expect(readApprovalLinkToken("#other=1")).toBe("");
+  });
+});
@@ -0,0 +1,64 @@
+import axios, { type AxiosInstance } from "axios";
+import { handleAxiosError } from "@/services/logger";
+import { createAuthenticatedApiClient } from "@/services/httpClient";
+
+import type AuthService from "@/services/auth";
+
+export interface ApprovalLinkPreview {
+  session: string;
+  action: "approve" | "reject";
+  approver: string;
+  expiresAt: string;
+  requester: string;
+  cluster: string;
+  group: string;
+  reason?: string;
+  scheduledStartTime?: string;
+  state: string;
+}
+
+// readApprovalLinkToken extracts the link token from the URL fragment (#token=...).
+// The token is kept in the fragment so it is never sent to the server as part of the page URL.
+export function readApprovalLinkToken(hash: string): string {
+  const params = new URLSearchParams(hash.replace(/^#/, ""));
+  return params.get("token") || "";
+}
+
+export default class ApprovalLinkService {
+  private publicClient: AxiosInstance;
+  private client: AxiosInstance;
+
+  constructor(auth: AuthService) {
+    // Preview and revoke are authorized by the link token itself and work before signing in
+    this.publicClient = axios.create({ baseURL: "/api" });
+    this.client = createAuthenticatedApiClient(auth);
+  }
+
+  public async preview(token: string): Promise<ApprovalLinkPreview> {
+    // POST /approvalLinks/preview
+    const resp = await this.publicClient.post<ApprovalLinkPreview>("/approvalLinks/preview", { token });
+    return resp.data;
+  }
+
+  public async revoke(token: string) {
+    // POST /approvalLinks/revoke
+    try {
+      return await this.publicClient.post("/approvalLinks/revoke", { token });
+    } catch (e) {
+      handleAxiosError("ApprovalLinkService.revoke", e, "Failed to revoke approval link");
+      throw e;
+    }
+  }
+
+  public async confirm(session: string, token: string, reason?: string) {
+    // POST /breakglassSessions/:name/approvalLink (requires a signed-in approver)
+    try {
+      const body: Record<string, any> = { token };
+      if (reason && reason.trim().length > 0) body.reason = reason;
+      return await this.client.post(`/breakglassSessions/${encodeURIComponent(session)}/approvalLink`, body);
+    } catch (e) {
+      handleAxiosError("ApprovalLinkService.confirm", e, "Failed to apply approval link");
+      throw e;
+    }
+  }
+}
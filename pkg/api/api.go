@@ -209,8 +209,8 @@ func NewServer(log *zap.Logger, cfg config.Config,
 		cors.New(cors.Config{
 			AllowOrigins:     allowedOrigins,
 			AllowMethods:     []string{"GET", "PUT", "PATCH", "POST", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-			ExposeHeaders:    []string{"Authorization"},
+			AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", CSRFHeader},
+			ExposeHeaders:    []string{"Authorization", breakglass.TotalCountHeader, breakglass.ContinueHeader},
 			AllowCredentials: true,
 			MaxAge:           12 * time.Hour,
 		}),
@@ -458,6 +458,9 @@ type FrontendConfig struct {
 	OIDCClientID  string `json:"oidcClientID"`
 	BrandingName  string `json:"brandingName,omitempty"`
 	UIFlavour     string `json:"uiFlavour,omitempty"`
+	// SessionLogin is true if the server-side login (/api/auth/login) should be used instead
+	// of the browser OIDC flow
+	SessionLogin bool `json:"sessionLogin,omitempty"`
 }
 
 type PublicConfig struct {
@@ -544,6 +547,7 @@ func (s *Server) getConfig(c *gin.Context) {
 			OIDCClientID:  clientID,
 			BrandingName:  s.config.Frontend.BrandingName,
 			UIFlavour:     s.config.Frontend.UIFlavour,
+			SessionLogin:  s.config.BFF.Enabled,
 		},
 	})
 }
@@ -948,6 +952,8 @@ func Setup(sessionController *breakglass.BreakglassSessionController, escalation
 	if enableAPI {
 		apiControllers = append(apiControllers, sessionController)
 		apiControllers = append(apiControllers, breakglass.NewBreakglassEscalationController(log, escalationManager, auth.Middleware(), configPath))
+		apiControllers = append(apiControllers, breakglass.NewApprovalLinkController(sessionController))
+		apiControllers = append(apiControllers, breakglass.NewSessionBatchController(sessionController))
 		log.Infow("API controllers enabled", "components", "BreakglassSession, BreakglassEscalation")
 	}
 
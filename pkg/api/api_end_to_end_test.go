@@ -132,7 +132,7 @@ func setupAPIEndToEndEnv(t *testing.T) *apiEndToEndEnv {
 			Namespace: e2eNamespace,
 		},
 		Spec: v1alpha1.ClusterConfigSpec{
-			KubeconfigSecretRef: v1alpha1.SecretKeyReference{
+			KubeconfigSecretRef: &v1alpha1.SecretKeyReference{
 				Name:      "ccfg-secret",
 				Namespace: e2eNamespace,
 				Key:       "value",
@@ -146,7 +146,7 @@ func setupAPIEndToEndEnv(t *testing.T) *apiEndToEndEnv {
 			Namespace: e2eNamespace,
 		},
 		Spec: v1alpha1.ClusterConfigSpec{
-			KubeconfigSecretRef: v1alpha1.SecretKeyReference{
+			KubeconfigSecretRef: &v1alpha1.SecretKeyReference{
 				Name:      "ccfg-secret",
 				Namespace: e2eNamespace,
 				Key:       "value",
@@ -184,7 +184,7 @@ func setupAPIEndToEndEnv(t *testing.T) *apiEndToEndEnv {
 	})
 
 	server := NewServer(logger, cfg, true, auth)
-	require.NoError(t, server.RegisterAll([]APIController{sessionController, escalationController, webhookController}))
+	require.NoError(t, server.RegisterAll([]APIController{sessionController, breakglass.NewSessionBatchController(sessionController), escalationController, webhookController}))
 
 	env.server = server
 	env.handler = server.Handler()
@@ -314,7 +314,8 @@ func testIdentityMiddleware() gin.HandlerFunc {
 		case c.Request.Method == http.MethodGet,
 			strings.Contains(path, "/approve"),
 			strings.Contains(path, "/reject"),
-			strings.Contains(path, "/cancel"):
+			strings.Contains(path, "/cancel"),
+			strings.HasSuffix(path, ":batch"):
 			c.Set("email", approverEmail)
 			c.Set("username", approverName)
 		default:
@@ -343,6 +344,40 @@ func TestEndToEndSessionFlowAuthorizesSAR(t *testing.T) {
 	require.GreaterOrEqual(t, env.sarRequests, 1, "session SAR should have contacted the target cluster")
 }
 
+func TestEndToEndBatchApproveAndCancel(t *testing.T) {
+	env := setupAPIEndToEndEnv(t)
+	defer env.Close()
+
+	env.createSession(t)
+	sessions := env.listSessions(t)
+	require.Len(t, sessions, 1)
+
+	batch := func(action string, names ...string) breakglass.SessionBatchResponse {
+		body, err := json.Marshal(breakglass.SessionBatchRequest{Sessions: names, Action: action, Reason: "incident INC-1"})
+		require.NoError(t, err)
+		rr := env.doRequest(t, http.MethodPost, sessionsBasePath+":batch", body)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp breakglass.SessionBatchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := batch(breakglass.SessionBatchApprove, sessions[0].Name, "does-not-exist")
+	require.Equal(t, 1, resp.Succeeded)
+	require.Equal(t, 1, resp.Failed)
+	require.Equal(t, http.StatusOK, resp.Results[0].Status)
+	require.Equal(t, http.StatusNotFound, resp.Results[1].Status)
+	require.True(t, env.invokeSAR(t).Status.Allowed, "expected SAR to be allowed after batch approval")
+
+	resp = batch(breakglass.SessionBatchCancel, sessions[0].Name)
+	require.Equal(t, 1, resp.Succeeded)
+	require.Equal(t, string(v1alpha1.SessionStateExpired), resp.Results[0].State)
+	require.False(t, env.invokeSAR(t).Status.Allowed, "expected SAR to be denied after batch cancel")
+
+	rr := env.doRequest(t, http.MethodPost, sessionsBasePath+":unknown", []byte(`{}`))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 func TestEndToEndSARDeniedWithoutSession(t *testing.T) {
 	env := setupAPIEndToEndEnv(t)
 	defer env.Close()
@@ -13,6 +13,7 @@ import (
 	"github.com/MicahParks/keyfunc"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
 	"github.com/telekom/k8s-breakglass/pkg/config"
 	"github.com/telekom/k8s-breakglass/pkg/metrics"
 	"go.uber.org/zap"
@@ -34,14 +35,24 @@ type AuthHandler struct {
 
 	// IDPLoader for multi-IDP mode
 	idpLoader *config.IdentityProviderLoader
+
+	// introspector checks tokens of IDPs with token introspection enabled
+	introspector *tokenIntrospector
+
+	// terminateSessions ends the sessions of users whose tokens became inactive
+	terminateSessions SessionTerminator
+
+	// bff authenticates requests without a bearer token by their cookie session
+	bff *BFF
 }
 
 func NewAuth(log *zap.SugaredLogger, cfg config.Config) *AuthHandler {
 	// JWKS loading happens dynamically via WithIdentityProviderLoader()
 	// using IdentityProvider CRDs configured in the cluster
 	return &AuthHandler{
-		jwksCache: make(map[string]*keyfunc.JWKS),
-		log:       log,
+		jwksCache:    make(map[string]*keyfunc.JWKS),
+		log:          log,
+		introspector: newTokenIntrospector(),
 	}
 }
 
@@ -51,6 +62,19 @@ func (a *AuthHandler) WithIdentityProviderLoader(loader *config.IdentityProvider
 	return a
 }
 
+// WithSessionTerminator sets the function ending the sessions of users who logged out or
+// whose tokens were reported inactive
+func (a *AuthHandler) WithSessionTerminator(terminate SessionTerminator) *AuthHandler {
+	a.terminateSessions = terminate
+	return a
+}
+
+// WithBFF accepts the cookie sessions of the server-side login on requests without a bearer token
+func (a *AuthHandler) WithBFF(bff *BFF) *AuthHandler {
+	a.bff = bff
+	return a
+}
+
 // getJWKSForIssuer returns the JWKS for a given issuer URL, loading it if necessary
 // For single-IDP mode (no idpLoader), returns the default JWKS
 func (a *AuthHandler) getJWKSForIssuer(ctx context.Context, issuer string) (*keyfunc.JWKS, error) {
@@ -157,6 +181,21 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 		authHeader := c.GetHeader(AuthHeaderKey)
 		// delete the header to avoid logging it by accident
 		c.Request.Header.Del(AuthHeaderKey)
+		if authHeader == "" && a.bff != nil {
+			token, found, berr := a.bff.sessionToken(c)
+			if berr != nil {
+				metrics.JWTValidationFailure.WithLabelValues("", berr.reason).Inc()
+				c.JSON(berr.status, gin.H{
+					"error":  berr.message,
+					"reason": berr.reason,
+				})
+				c.Abort()
+				return
+			}
+			if found {
+				authHeader = "Bearer " + token
+			}
+		}
 		if !strings.HasPrefix(authHeader, "Bearer ") {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "No Bearer token provided in Authorization header",
@@ -191,6 +230,8 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 		// Get appropriate JWKS (based on issuer or default)
 		var jwks *keyfunc.JWKS
 		var selectedIDP string
+		var idpCfg *config.IdentityProviderConfig
+		mapper := defaultClaimMapper
 
 		if a.idpLoader != nil && issuer != "" {
 			// Multi-IDP mode: load JWKS for specific issuer
@@ -225,11 +266,22 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 				return
 			}
 			jwks = loadedJwks
-			idpName, err := a.idpLoader.GetIDPNameByIssuer(c.Request.Context(), issuer)
+			idpCfg, err = a.idpLoader.LoadIdentityProviderByIssuer(c.Request.Context(), issuer)
 			if err != nil {
-				a.log.Debugw("failed to get IDP name by issuer", "issuer", issuer, "error", err)
-			} else {
-				selectedIDP = idpName
+				// The JWKS may still be cached for a provider that was since disabled or deleted
+				a.log.Debugw("failed to get IDP by issuer", "issuer", issuer, "error", err)
+				metrics.JWTValidationFailure.WithLabelValues(issuer, "unknown_identity_provider").Inc()
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":  fmt.Sprintf("token issuer '%s' is not an enabled identity provider", issuer),
+					"issuer": issuer,
+					"reason": "unknown_identity_provider",
+				})
+				c.Abort()
+				return
+			}
+			selectedIDP = idpCfg.Name
+			if idpCfg.ClaimMappings != nil {
+				mapper = newClaimMapper(idpCfg.ClaimMappings)
 			}
 		} else if a.idpLoader != nil && issuer == "" {
 			// Multi-IDP mode but no issuer in token: require issuer claim
@@ -281,14 +333,50 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 			return
 		}
 
+		// Check audience, authorized party and required claims of the issuing IDP
+		if idpCfg != nil {
+			if verr := validateTokenClaims(idpCfg, claims); verr != nil {
+				a.log.Debugw("token rejected by claim validation", "issuer", issuer, "idp", idpCfg.Name, "reason", verr.reason)
+				metrics.JWTValidationFailure.WithLabelValues(issuer, verr.reason).Inc()
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":  verr.message,
+					"issuer": issuer,
+					"reason": verr.reason,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		// Reject tokens revoked at the IDP before they expire
+		if idpCfg != nil && idpCfg.TokenRevocation != nil {
+			rerr, fresh := a.checkTokenRevocation(c.Request.Context(), idpCfg, bearer, claims)
+			if rerr != nil {
+				a.log.Debugw("token rejected by revocation check", "issuer", issuer, "idp", idpCfg.Name, "reason", rerr.reason)
+				metrics.JWTValidationFailure.WithLabelValues(issuer, rerr.reason).Inc()
+				if fresh && idpCfg.TokenRevocation.TerminateSessions && a.terminateSessions != nil {
+					if sub, _ := claims["sub"].(string); sub != "" {
+						go a.terminateSessions(context.Background(), idpCfg.Name, sub, "token is no longer active at the identity provider")
+					}
+				}
+				c.JSON(rerr.status, gin.H{
+					"error":  rerr.message,
+					"issuer": issuer,
+					"reason": rerr.reason,
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Record successful validation with duration
 		metrics.JWTValidationSuccess.WithLabelValues(issuer).Inc()
 		metrics.JWTValidationDuration.WithLabelValues(issuer).Observe(time.Since(startTime).Seconds())
 
-		// Extract core identity claims
+		// Extract core identity claims using the claim mappings of the issuing IDP
 		user_id := claims["sub"]
-		email := claims["email"]
-		username := claims["preferred_username"]
+		email := mapper.Email(claims)
+		username := mapper.Username(claims)
 
 		// Multi-IDP: Store issuer and IDP name for downstream use
 		if issuer != "" {
@@ -302,63 +390,8 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 		// Note: this is only used for debug logs and should not be exposed to end users.
 		c.Set("raw_claims", claims)
 
-		// Attempt to extract groups from common Keycloak / OIDC claims
-		var groups []string
-		if rawGroups, ok := claims["groups"]; ok {
-			switch g := rawGroups.(type) {
-			case []interface{}:
-				for _, v := range g {
-					if s, ok := v.(string); ok && s != "" {
-						groups = append(groups, s)
-					}
-				}
-			case []string:
-				groups = append(groups, g...)
-			}
-		} else if rawRealm, ok := claims["realm_access"]; ok { // Keycloak specific structure
-			if m, ok := rawRealm.(map[string]interface{}); ok {
-				if rolesRaw, ok := m["roles"]; ok {
-					switch roles := rolesRaw.(type) {
-					case []interface{}:
-						for _, v := range roles {
-							if s, ok := v.(string); ok && s != "" {
-								groups = append(groups, s)
-							}
-						}
-					case []string:
-						groups = append(groups, roles...)
-					}
-				}
-			}
-		}
-
-		// Normalize group names: strip leading slashes and reduce nested paths to final segment.
-		if len(groups) > 0 {
-			seen := make(map[string]struct{}, len(groups))
-			normalized := make([]string, 0, len(groups))
-			for _, g := range groups {
-				g = strings.TrimSpace(g)
-				if g == "" { // skip empty
-					continue
-				}
-				// Remove leading slash (Keycloak group path style: /team/role)
-				for strings.HasPrefix(g, "/") {
-					g = strings.TrimPrefix(g, "/")
-				}
-				if idx := strings.LastIndex(g, "/"); idx != -1 && idx < len(g)-1 { // keep only final path element
-					g = g[idx+1:]
-				}
-				if g == "" { // after normalization
-					continue
-				}
-				if _, exists := seen[g]; exists {
-					continue
-				}
-				seen[g] = struct{}{}
-				normalized = append(normalized, g)
-			}
-			groups = normalized
-		}
+		// Extract and normalize groups (defaults: "groups", then Keycloak's realm_access.roles)
+		groups := mapper.Groups(claims)
 
 		// If groups are empty, log claims at debug so we can diagnose missing group mappers
 		if len(groups) == 0 {
@@ -381,6 +414,9 @@ func (a *AuthHandler) Middleware() gin.HandlerFunc {
 		if len(groups) > 0 {
 			c.Set("groups", groups)
 		}
+		if idpCfg != nil {
+			c.Set(breakglass.TokenRecheckKey, a.tokenRecheck(issuer, bearer, claims))
+		}
 
 		c.Next()
 	}
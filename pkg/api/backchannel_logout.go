@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// backChannelLogoutEvent is the member of the events claim identifying a logout token
+	backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+	// maxLogoutRevocations bounds the logout events stored in an IdentityProvider status
+	maxLogoutRevocations = 1000
+)
+
+// BackChannelLogoutController receives OIDC Back-Channel Logout tokens. Logout events are
+// recorded in the status of the IdentityProvider so that every replica rejects the access
+// tokens of the logged out user.
+type BackChannelLogoutController struct {
+	log    *zap.SugaredLogger
+	auth   *AuthHandler
+	client client.Client
+	now    func() time.Time
+}
+
+// NewBackChannelLogoutController creates the logout endpoint. Logout tokens are verified with
+// the JWKS and identity providers of auth.
+func NewBackChannelLogoutController(log *zap.SugaredLogger, auth *AuthHandler, cli client.Client) *BackChannelLogoutController {
+	return &BackChannelLogoutController{log: log, auth: auth, client: cli, now: time.Now}
+}
+
+func (*BackChannelLogoutController) BasePath() string {
+	return "oidc/backchannel-logout"
+}
+
+// Handlers returns no middleware; the identity provider authenticates with the signed logout token
+func (*BackChannelLogoutController) Handlers() []gin.HandlerFunc {
+	return nil
+}
+
+func (lc *BackChannelLogoutController) Register(rg *gin.RouterGroup) error {
+	rg.POST("", lc.handleLogout)
+	return nil
+}
+
+// logoutError is answered with 400 as required by OIDC Back-Channel Logout, section 2.8
+func logoutError(c *gin.Context, format string, args ...any) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":             "invalid_request",
+		"error_description": fmt.Sprintf(format, args...),
+	})
+}
+
+func (lc *BackChannelLogoutController) handleLogout(c *gin.Context) {
+	logoutToken := c.PostForm("logout_token")
+	if logoutToken == "" {
+		logoutError(c, "logout_token is required")
+		return
+	}
+	if lc.auth.idpLoader == nil {
+		logoutError(c, "back-channel logout is not supported")
+		return
+	}
+
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(logoutToken, unverified); err != nil {
+		logoutError(c, "logout_token is not a JWT")
+		return
+	}
+	issuer, _ := unverified["iss"].(string)
+	ctx := c.Request.Context()
+	idp, err := lc.auth.idpLoader.LoadIdentityProviderByIssuer(ctx, issuer)
+	if err != nil {
+		logoutError(c, "unknown issuer")
+		return
+	}
+	if idp.TokenRevocation == nil || !idp.TokenRevocation.BackChannelLogout {
+		logoutError(c, "back-channel logout is not enabled for this identity provider")
+		return
+	}
+	jwks, err := lc.auth.getJWKSForIssuer(ctx, issuer)
+	if err != nil {
+		lc.log.Warnw("failed to load JWKS for logout token", "idp", idp.Name, "error", err)
+		logoutError(c, "unable to verify logout_token")
+		return
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(logoutToken, &claims, jwks.Keyfunc); err != nil {
+		logoutError(c, "invalid logout_token: %v", err)
+		return
+	}
+
+	revocation, err := validateLogoutToken(idp, claims, lc.now())
+	if err != nil {
+		logoutError(c, "invalid logout_token: %v", err)
+		return
+	}
+	if err := lc.recordRevocation(ctx, idp, revocation); err != nil {
+		lc.log.Errorw("failed to record logout", "idp", idp.Name, "error", err)
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	lc.log.Infow("recorded back-channel logout", "idp", idp.Name, "subject", revocation.Subject, "sid", revocation.SessionID)
+
+	if idp.TokenRevocation.TerminateSessions && revocation.Subject != "" && lc.auth.terminateSessions != nil {
+		lc.auth.terminateSessions(ctx, idp.Name, revocation.Subject, "user logged out at the identity provider")
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// validateLogoutToken checks the claims of a verified logout token (OIDC Back-Channel Logout,
+// section 2.6) and returns the resulting revocation
+func validateLogoutToken(idp *config.IdentityProviderConfig, claims jwt.MapClaims, now time.Time) (breakglassv1alpha1.LogoutRevocation, error) {
+	var rev breakglassv1alpha1.LogoutRevocation
+
+	audiences := []string{idp.ClientID}
+	if idp.TokenValidation != nil {
+		audiences = append(audiences, idp.TokenValidation.Audiences...)
+	}
+	if !slices.ContainsFunc(stringsClaim(claims["aud"]), func(aud string) bool {
+		return aud != "" && slices.Contains(audiences, aud)
+	}) {
+		return rev, fmt.Errorf("audience does not match")
+	}
+
+	events, ok := claims["events"].(map[string]interface{})
+	if !ok {
+		return rev, fmt.Errorf("events claim is missing")
+	}
+	if _, ok := events[backChannelLogoutEvent].(map[string]interface{}); !ok {
+		return rev, fmt.Errorf("events claim does not contain a back-channel logout event")
+	}
+	if _, ok := claims["nonce"]; ok {
+		return rev, fmt.Errorf("nonce is not allowed")
+	}
+
+	iat := claimTime(claims["iat"])
+	if iat.IsZero() {
+		return rev, fmt.Errorf("iat is required")
+	}
+	if iat.Before(now.Add(-idp.TokenRevocation.LogoutRetention)) {
+		return rev, fmt.Errorf("token was issued before the logout retention")
+	}
+
+	rev.Subject, _ = claims["sub"].(string)
+	rev.SessionID, _ = claims["sid"].(string)
+	if rev.Subject == "" && rev.SessionID == "" {
+		return rev, fmt.Errorf("sub or sid is required")
+	}
+	rev.RevokedAt = metav1.NewTime(iat)
+	return rev, nil
+}
+
+// recordRevocation appends the logout event to the IdentityProvider status, dropping events
+// older than the logout retention
+func (lc *BackChannelLogoutController) recordRevocation(ctx context.Context, idpCfg *config.IdentityProviderConfig, rev breakglassv1alpha1.LogoutRevocation) error {
+	cutoff := lc.now().Add(-idpCfg.TokenRevocation.LogoutRetention)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		idp := &breakglassv1alpha1.IdentityProvider{}
+		if err := lc.client.Get(ctx, client.ObjectKey{Name: idpCfg.Name}, idp); err != nil {
+			return err
+		}
+		kept := make([]breakglassv1alpha1.LogoutRevocation, 0, len(idp.Status.LogoutRevocations)+1)
+		for _, r := range idp.Status.LogoutRevocations {
+			if r.Subject == rev.Subject && r.SessionID == rev.SessionID {
+				if !r.RevokedAt.Before(&rev.RevokedAt) {
+					// A newer logout of the same session is already recorded
+					return nil
+				}
+				continue
+			}
+			if r.RevokedAt.After(cutoff) {
+				kept = append(kept, r)
+			}
+		}
+		kept = append(kept, rev)
+		if len(kept) > maxLogoutRevocations {
+			lc.log.Warnw("too many logout events within the retention, dropping the oldest", "idp", idpCfg.Name, "dropped", len(kept)-maxLogoutRevocations)
+			slices.SortStableFunc(kept, func(a, b breakglassv1alpha1.LogoutRevocation) int {
+				return a.RevokedAt.Compare(b.RevokedAt.Time)
+			})
+			kept = kept[len(kept)-maxLogoutRevocations:]
+		}
+		idp.Status.LogoutRevocations = kept
+		return lc.client.Status().Update(ctx, idp)
+	})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+)
+
+func postLogoutToken(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	form := url.Values{"logout_token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/api/oidc/backchannel-logout", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestBackChannelLogout(t *testing.T) {
+	const issuer = "https://keycloak.example.com/realms/corp"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC:   breakglassv1alpha1.OIDCConfig{Authority: issuer, ClientID: "breakglass-ui"},
+			Issuer: issuer,
+			TokenRevocation: &breakglassv1alpha1.TokenRevocation{
+				BackChannelLogout: true,
+				TerminateSessions: true,
+			},
+		},
+		Status: breakglassv1alpha1.IdentityProviderStatus{
+			LogoutRevocations: []breakglassv1alpha1.LogoutRevocation{
+				{Subject: "stale", RevokedAt: metav1.NewTime(time.Now().Add(-2 * time.Hour))},
+			},
+		},
+	}
+	auth, cli, sign := newMultiIDPTestAuth(t, idp)
+	var terminated []string
+	auth.WithSessionTerminator(func(_ context.Context, idpName, subject, _ string) {
+		terminated = append(terminated, idpName+"/"+subject)
+	})
+
+	r := gin.New()
+	lc := NewBackChannelLogoutController(zaptest.NewLogger(t).Sugar(), auth, cli)
+	require.NoError(t, lc.Register(r.Group("/api/"+lc.BasePath())))
+	r.GET("/whoami", auth.Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	logoutAt := time.Now().Add(-time.Minute)
+	logoutClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":    "alice",
+			"aud":    "breakglass-ui",
+			"iat":    logoutAt.Unix(),
+			"jti":    "logout-1",
+			"events": map[string]any{backChannelLogoutEvent: map[string]any{}},
+		}
+	}
+	oldAccessToken := sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "iat": logoutAt.Add(-time.Minute).Unix()})
+	require.Equal(t, http.StatusOK, serveWithToken(r, oldAccessToken).Code)
+
+	t.Run("invalid logout tokens", func(t *testing.T) {
+		tests := map[string]func(jwt.MapClaims){
+			"other audience": func(c jwt.MapClaims) { c["aud"] = "grafana" },
+			"missing event":  func(c jwt.MapClaims) { c["events"] = map[string]any{} },
+			"nonce":          func(c jwt.MapClaims) { c["nonce"] = "n" },
+			"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+			"too old":        func(c jwt.MapClaims) { c["iat"] = time.Now().Add(-2 * time.Hour).Unix() },
+		}
+		for name, mutate := range tests {
+			t.Run(name, func(t *testing.T) {
+				claims := logoutClaims()
+				mutate(claims)
+				w := postLogoutToken(r, sign(claims))
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "invalid_request")
+			})
+		}
+		assert.Equal(t, http.StatusBadRequest, postLogoutToken(r, "").Code)
+		assert.Empty(t, terminated)
+	})
+
+	t.Run("logout revokes earlier tokens", func(t *testing.T) {
+		w := postLogoutToken(r, sign(logoutClaims()))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, []string{"corp/alice"}, terminated)
+
+		stored := &breakglassv1alpha1.IdentityProvider{}
+		require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(idp), stored))
+		require.Len(t, stored.Status.LogoutRevocations, 1, "stale events are pruned")
+		assert.Equal(t, "alice", stored.Status.LogoutRevocations[0].Subject)
+		assert.Equal(t, logoutAt.Unix(), stored.Status.LogoutRevocations[0].RevokedAt.Unix())
+
+		w = serveWithToken(r, oldAccessToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), tokenRejectRevoked)
+		newAccessToken := sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "iat": time.Now().Unix()})
+		assert.Equal(t, http.StatusOK, serveWithToken(r, newAccessToken).Code)
+
+		// Replaying the logout token does not add another event
+		require.Equal(t, http.StatusOK, postLogoutToken(r, sign(logoutClaims())).Code)
+		require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(idp), stored))
+		assert.Len(t, stored.Status.LogoutRevocations, 1)
+	})
+
+	t.Run("disabled for identity provider", func(t *testing.T) {
+		current := &breakglassv1alpha1.IdentityProvider{}
+		require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(idp), current))
+		current.Spec.TokenRevocation.BackChannelLogout = false
+		require.NoError(t, cli.Update(context.Background(), current))
+		w := postLogoutToken(r, sign(logoutClaims()))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not enabled")
+	})
+}
@@ -0,0 +1,603 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+)
+
+// CSRFHeader carries the CSRF token of a cookie session on state-changing requests
+const CSRFHeader = "X-CSRF-Token"
+
+// Cookie session failure reasons, used as the reason label of JWTValidationFailure
+const (
+	sessionRejectExpired = "session_expired"
+	sessionRejectCSRF    = "csrf_token_invalid"
+)
+
+const (
+	defaultBFFSessionTTL = 12 * time.Hour
+	// bffLoginTTL bounds the time between starting a login and the callback
+	bffLoginTTL = 10 * time.Minute
+	// bffRefreshLeeway refreshes access tokens shortly before they expire
+	bffRefreshLeeway = 30 * time.Second
+	// bffDiscoveryTTL is how long OIDC discovery documents are cached
+	bffDiscoveryTTL     = time.Hour
+	bffRequestTimeout   = 10 * time.Second
+	maxBFFResponseBytes = 1 << 20
+)
+
+var defaultBFFScopes = []string{"openid", "profile", "email"}
+
+// bffSession is the content of the encrypted session cookie
+type bffSession struct {
+	IDP          string    `json:"idp"`
+	AccessToken  string    `json:"at"`
+	RefreshToken string    `json:"rt,omitempty"`
+	Expiry       time.Time `json:"exp"`
+	CSRF         string    `json:"csrf"`
+	Created      time.Time `json:"created"`
+}
+
+// bffLogin is the content of the encrypted login cookie, kept between login and callback
+type bffLogin struct {
+	IDP      string    `json:"idp"`
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	Redirect string    `json:"redirect"`
+	Created  time.Time `json:"created"`
+}
+
+// oidcEndpoints holds the parts of an OIDC discovery document used by the server-side login
+type oidcEndpoints struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+	fetched               time.Time
+}
+
+// tokenResponse is the token endpoint response of the code and refresh grants
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// bffError describes why a cookie session was rejected
+type bffError struct {
+	status  int
+	reason  string
+	message string
+}
+
+// BFF implements the server-side OIDC login (backend-for-frontend). The server runs the
+// authorization code flow with PKCE and stores the tokens in encrypted HttpOnly cookies; the
+// AuthHandler middleware then authenticates requests with the access token of the session.
+type BFF struct {
+	log     *zap.SugaredLogger
+	auth    *AuthHandler
+	codec   *cookieCodec
+	baseURL string
+	ttl     time.Duration
+	scopes  []string
+	secure  bool
+	clients *idpHTTPClients
+	now     func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]oidcEndpoints
+}
+
+// NewBFF creates the server-side login from the bff section of the configuration. It returns
+// nil if the server-side login is disabled.
+func NewBFF(log *zap.SugaredLogger, cfg config.Config, auth *AuthHandler) (*BFF, error) {
+	if !cfg.BFF.Enabled {
+		return nil, nil
+	}
+	if cfg.Frontend.BaseURL == "" {
+		return nil, errors.New("frontend.baseURL is required when the server-side login is enabled")
+	}
+	if cfg.BFF.CookieKeyFile == "" {
+		return nil, errors.New("bff.cookieKeyFile is required when the server-side login is enabled")
+	}
+	key, err := os.ReadFile(cfg.BFF.CookieKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading bff cookie key: %w", err)
+	}
+	if cfg.BFF.SessionTTL != "" {
+		if ttl, err := time.ParseDuration(cfg.BFF.SessionTTL); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid bff.sessionTTL %q", cfg.BFF.SessionTTL)
+		}
+	}
+	return newBFF(log, bytes.TrimSpace(key), cfg, auth)
+}
+
+func newBFF(log *zap.SugaredLogger, key []byte, cfg config.Config, auth *AuthHandler) (*BFF, error) {
+	codec, err := newCookieCodec(key)
+	if err != nil {
+		return nil, err
+	}
+	scopes := cfg.BFF.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultBFFScopes
+	}
+	return &BFF{
+		log:       log,
+		auth:      auth,
+		codec:     codec,
+		baseURL:   strings.TrimRight(cfg.Frontend.BaseURL, "/"),
+		ttl:       parseDurationOr(cfg.BFF.SessionTTL, defaultBFFSessionTTL),
+		scopes:    scopes,
+		secure:    !cfg.BFF.InsecureCookies,
+		clients:   newIDPHTTPClients(),
+		now:       time.Now,
+		endpoints: map[string]oidcEndpoints{},
+	}, nil
+}
+
+func (*BFF) BasePath() string {
+	return "auth"
+}
+
+// Handlers returns no middleware; the endpoints establish and end the cookie session
+func (*BFF) Handlers() []gin.HandlerFunc {
+	return nil
+}
+
+func (b *BFF) Register(rg *gin.RouterGroup) error {
+	rg.GET("login", b.handleLogin)
+	rg.GET("callback", b.handleCallback)
+	rg.GET("session", b.handleSession)
+	rg.POST("logout", b.handleLogout)
+	return nil
+}
+
+func (b *BFF) redirectURI() string {
+	return b.baseURL + "/api/auth/callback"
+}
+
+// handleLogin starts the authorization code flow with the identity provider given by the idp
+// query parameter, or the primary identity provider
+func (b *BFF) handleLogin(c *gin.Context) {
+	idp, err := b.identityProvider(c.Request.Context(), c.Query("idp"))
+	if err != nil {
+		b.log.Warnw("server-side login: identity provider unavailable", "idp", c.Query("idp"), "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown or disabled identity provider"})
+		return
+	}
+	endpoints, err := b.discover(c.Request.Context(), idp)
+	if err != nil {
+		b.log.Warnw("server-side login: OIDC discovery failed", "idp", idp.Name, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider is unavailable"})
+		return
+	}
+
+	login := bffLogin{
+		IDP:      idp.Name,
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: randomToken(),
+		Redirect: safeRedirect(c.Query("redirect")),
+		Created:  b.now(),
+	}
+	sealed, err := b.codec.seal(bffLoginCookie, login)
+	if err != nil {
+		b.log.Errorw("server-side login: failed to seal login state", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	// Lax: the callback is a top-level navigation coming from the identity provider
+	b.setCookie(c, bffLoginCookie, sealed, "/api/auth", int(bffLoginTTL.Seconds()), http.SameSiteLaxMode, true)
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {idp.ClientID},
+		"redirect_uri":          {b.redirectURI()},
+		"scope":                 {strings.Join(b.scopes, " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, withQuery(endpoints.AuthorizationEndpoint, query))
+}
+
+// handleCallback completes the authorization code flow and establishes the cookie session
+func (b *BFF) handleCallback(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	raw, _ := c.Cookie(bffLoginCookie)
+	b.setCookie(c, bffLoginCookie, "", "/api/auth", -1, http.SameSiteLaxMode, true)
+
+	var login bffLogin
+	if raw == "" || b.codec.open(bffLoginCookie, raw, &login) != nil || b.now().After(login.Created.Add(bffLoginTTL)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login expired or was not started here; please log in again"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(login.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid login state; please log in again"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		b.log.Infow("server-side login: identity provider returned an error", "idp", login.IDP, "error", e, "description", c.Query("error_description"))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed at the identity provider", "reason": e})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing authorization code"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	idp, err := b.identityProvider(ctx, login.IDP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown or disabled identity provider"})
+		return
+	}
+	tokens, err := b.exchange(ctx, idp, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {b.redirectURI()},
+		"code_verifier": {login.Verifier},
+	})
+	if err != nil {
+		b.log.Warnw("server-side login: code exchange failed", "idp", idp.Name, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to complete login with the identity provider"})
+		return
+	}
+	if err := b.verifyIDToken(ctx, idp, tokens.IDToken, login.Nonce); err != nil {
+		b.log.Warnw("server-side login: invalid ID token", "idp", idp.Name, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider returned an invalid ID token"})
+		return
+	}
+
+	session := bffSession{
+		IDP:     idp.Name,
+		CSRF:    randomToken(),
+		Created: b.now(),
+	}
+	session.update(tokens, b.now())
+	if err := b.writeSession(c, session); err != nil {
+		b.log.Errorw("server-side login: failed to write session cookie", "idp", idp.Name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to establish session"})
+		return
+	}
+	c.Redirect(http.StatusFound, login.Redirect)
+}
+
+// handleSession tells the frontend whether a cookie session exists and returns its CSRF token
+func (b *BFF) handleSession(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	session, ok := b.readSession(c)
+	if !ok || b.expired(session) {
+		c.JSON(http.StatusOK, gin.H{"authenticated": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"authenticated":    true,
+		"identityProvider": session.IDP,
+		"csrfToken":        session.CSRF,
+		"expiresAt":        session.Created.Add(b.ttl),
+	})
+}
+
+// handleLogout ends the cookie session and returns the RP-initiated logout URL of the identity
+// provider, if it has one
+func (b *BFF) handleLogout(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	session, ok := b.readSession(c)
+	if !ok {
+		b.clearSession(c)
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	if !validCSRF(c, session) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token", "reason": sessionRejectCSRF})
+		return
+	}
+	b.clearSession(c)
+
+	resp := gin.H{}
+	if idp, err := b.identityProvider(c.Request.Context(), session.IDP); err == nil {
+		if endpoints, err := b.discover(c.Request.Context(), idp); err == nil && endpoints.EndSessionEndpoint != "" {
+			resp["logoutURL"] = withQuery(endpoints.EndSessionEndpoint, url.Values{
+				"client_id":                {idp.ClientID},
+				"post_logout_redirect_uri": {b.baseURL + "/"},
+			})
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// sessionToken returns the access token of the cookie session of the request, refreshing it
+// when it is about to expire. found is false if the request has no session cookie.
+func (b *BFF) sessionToken(c *gin.Context) (token string, found bool, berr *bffError) {
+	session, ok := b.readSession(c)
+	if !ok {
+		return "", false, nil
+	}
+	expired := &bffError{
+		status:  http.StatusUnauthorized,
+		reason:  sessionRejectExpired,
+		message: "session expired; please log in again",
+	}
+	if b.expired(session) {
+		b.clearSession(c)
+		return "", true, expired
+	}
+	if !safeMethod(c.Request.Method) && !validCSRF(c, session) {
+		return "", true, &bffError{
+			status:  http.StatusForbidden,
+			reason:  sessionRejectCSRF,
+			message: fmt.Sprintf("missing or invalid %s header", CSRFHeader),
+		}
+	}
+	if b.now().Add(bffRefreshLeeway).Before(session.Expiry) {
+		return session.AccessToken, true, nil
+	}
+
+	if err := b.refresh(c.Request.Context(), &session); err != nil {
+		b.log.Infow("server-side login: token refresh failed", "idp", session.IDP, "error", err)
+		b.clearSession(c)
+		return "", true, expired
+	}
+	if err := b.writeSession(c, session); err != nil {
+		b.log.Errorw("server-side login: failed to write session cookie", "idp", session.IDP, "error", err)
+		b.clearSession(c)
+		return "", true, expired
+	}
+	return session.AccessToken, true, nil
+}
+
+func (b *BFF) refresh(ctx context.Context, session *bffSession) error {
+	if session.RefreshToken == "" {
+		return errors.New("session has no refresh token")
+	}
+	idp, err := b.identityProvider(ctx, session.IDP)
+	if err != nil {
+		return err
+	}
+	tokens, err := b.exchange(ctx, idp, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {session.RefreshToken},
+	})
+	if err != nil {
+		return err
+	}
+	session.update(tokens, b.now())
+	return nil
+}
+
+// update stores the tokens of a token response; the refresh token is kept unless rotated
+func (s *bffSession) update(tokens *tokenResponse, now time.Time) {
+	s.AccessToken = tokens.AccessToken
+	if tokens.RefreshToken != "" {
+		s.RefreshToken = tokens.RefreshToken
+	}
+	s.Expiry = now.Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	if tokens.ExpiresIn <= 0 {
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, claims); err == nil {
+			s.Expiry = claimTime(claims["exp"])
+		}
+	}
+}
+
+func (b *BFF) expired(session bffSession) bool {
+	return !b.now().Before(session.Created.Add(b.ttl))
+}
+
+func (b *BFF) readSession(c *gin.Context) (bffSession, bool) {
+	var session bffSession
+	raw := readChunkedCookie(c, bffSessionCookie)
+	if raw == "" || b.codec.open(bffSessionCookie, raw, &session) != nil {
+		return bffSession{}, false
+	}
+	return session, true
+}
+
+func (b *BFF) writeSession(c *gin.Context, session bffSession) error {
+	sealed, err := b.codec.seal(bffSessionCookie, session)
+	if err != nil {
+		return err
+	}
+	maxAge := int(session.Created.Add(b.ttl).Sub(b.now()).Seconds())
+	if err := b.writeChunkedCookie(c, bffSessionCookie, sealed, maxAge); err != nil {
+		return err
+	}
+	// Readable by the frontend, which echoes it in the CSRF header (double submit)
+	b.setCookie(c, bffCSRFCookie, session.CSRF, "/", maxAge, http.SameSiteStrictMode, false)
+	return nil
+}
+
+func (b *BFF) clearSession(c *gin.Context) {
+	b.clearChunkedCookie(c, bffSessionCookie)
+	b.setCookie(c, bffCSRFCookie, "", "/", -1, http.SameSiteStrictMode, false)
+}
+
+// identityProvider returns the named enabled identity provider, or the primary one if name is empty
+func (b *BFF) identityProvider(ctx context.Context, name string) (*config.IdentityProviderConfig, error) {
+	loader := b.auth.idpLoader
+	if loader == nil {
+		return nil, errors.New("no identity provider loader configured")
+	}
+	if name == "" {
+		return loader.LoadIdentityProvider(ctx)
+	}
+	idps, err := loader.LoadAllIdentityProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idp, ok := idps[name]
+	if !ok {
+		return nil, fmt.Errorf("identity provider %q not found or disabled", name)
+	}
+	return idp, nil
+}
+
+// discover returns the OIDC endpoints of the identity provider from its discovery document
+func (b *BFF) discover(ctx context.Context, idp *config.IdentityProviderConfig) (oidcEndpoints, error) {
+	authority := strings.TrimRight(idp.Authority, "/")
+	b.mu.Lock()
+	cached, ok := b.endpoints[authority]
+	b.mu.Unlock()
+	if ok && b.now().Before(cached.fetched.Add(bffDiscoveryTTL)) {
+		return cached, nil
+	}
+
+	client, err := b.clients.get(idp)
+	if err != nil {
+		return oidcEndpoints{}, err
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, bffRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, authority+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return oidcEndpoints{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return oidcEndpoints{}, fmt.Errorf("discovery request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return oidcEndpoints{}, fmt.Errorf("discovery endpoint returned %d", resp.StatusCode)
+	}
+	var endpoints oidcEndpoints
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBFFResponseBytes)).Decode(&endpoints); err != nil {
+		return oidcEndpoints{}, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" {
+		return oidcEndpoints{}, errors.New("discovery document lacks the authorization or token endpoint")
+	}
+	endpoints.fetched = b.now()
+	b.mu.Lock()
+	b.endpoints[authority] = endpoints
+	b.mu.Unlock()
+	return endpoints, nil
+}
+
+// exchange calls the token endpoint of the identity provider with the given grant
+func (b *BFF) exchange(ctx context.Context, idp *config.IdentityProviderConfig, form url.Values) (*tokenResponse, error) {
+	endpoints, err := b.discover(ctx, idp)
+	if err != nil {
+		return nil, err
+	}
+	client, err := b.clients.get(idp)
+	if err != nil {
+		return nil, err
+	}
+	if idp.ClientSecret == "" {
+		// Public client: identified by client_id, the code is bound by PKCE
+		form.Set("client_id", idp.ClientID)
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, bffRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if idp.ClientSecret != "" {
+		// client_secret_basic requires the form-encoded credentials (RFC 6749 section 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(idp.ClientID), url.QueryEscape(idp.ClientSecret))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBFFResponseBytes)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+	return &tokens, nil
+}
+
+// verifyIDToken checks signature, issuer, audience and nonce of the ID token of a login
+func (b *BFF) verifyIDToken(ctx context.Context, idp *config.IdentityProviderConfig, idToken, nonce string) error {
+	if idToken == "" {
+		return errors.New("token response has no ID token")
+	}
+	jwks, err := b.auth.getJWKSForIssuer(ctx, idp.Issuer)
+	if err != nil {
+		return err
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(idToken, &claims, jwks.Keyfunc); err != nil {
+		return err
+	}
+	if iss, _ := claims["iss"].(string); iss != idp.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !claims.VerifyAudience(idp.ClientID, true) {
+		return errors.New("ID token was not issued to this client")
+	}
+	if got, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return errors.New("nonce mismatch")
+	}
+	return nil
+}
+
+func validCSRF(c *gin.Context, session bffSession) bool {
+	got := c.GetHeader(CSRFHeader)
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(session.CSRF)) == 1
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// safeRedirect only allows local paths as the target after login, so the login cannot be used
+// as an open redirect
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") ||
+		strings.ContainsAny(target, "\r\n") {
+		return "/"
+	}
+	return target
+}
+
+func withQuery(endpoint string, query url.Values) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + query.Encode()
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
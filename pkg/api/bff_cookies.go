@@ -0,0 +1,140 @@
+package api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MinBFFCookieKeyLength is the minimum length of the cookie encryption key
+	MinBFFCookieKeyLength = 32
+
+	bffSessionCookie = "breakglass_session"
+	bffLoginCookie   = "breakglass_login"
+	bffCSRFCookie    = "breakglass_csrf"
+
+	// bffCookieChunkSize keeps every cookie below the 4096 byte browser limit
+	bffCookieChunkSize = 3800
+	// bffMaxCookieChunks bounds the total size of a session cookie
+	bffMaxCookieChunks = 8
+)
+
+// cookieCodec encrypts and authenticates cookie values with AES-256-GCM. The cookie name is
+// bound as additional data so a value cannot be replayed under another cookie.
+type cookieCodec struct {
+	aead cipher.AEAD
+}
+
+func newCookieCodec(key []byte) (*cookieCodec, error) {
+	if len(key) < MinBFFCookieKeyLength {
+		return nil, fmt.Errorf("cookie encryption key must be at least %d bytes", MinBFFCookieKeyLength)
+	}
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &cookieCodec{aead: aead}, nil
+}
+
+func (cc *cookieCodec) seal(name string, v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, cc.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(cc.aead.Seal(nonce, nonce, plain, []byte(name))), nil
+}
+
+func (cc *cookieCodec) open(name, value string, v any) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(sealed) < cc.aead.NonceSize() {
+		return errors.New("cookie value too short")
+	}
+	nonce, ciphertext := sealed[:cc.aead.NonceSize()], sealed[cc.aead.NonceSize():]
+	plain, err := cc.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// chunkName returns the cookie name of the i-th chunk of a value
+func chunkName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return name + "_" + strconv.Itoa(i)
+}
+
+func (b *BFF) setCookie(c *gin.Context, name, value, path string, maxAge int, sameSite http.SameSite, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   b.secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
+
+// writeChunkedCookie splits value over as many HttpOnly cookies as needed and deletes
+// chunks left over from a longer previous value
+func (b *BFF) writeChunkedCookie(c *gin.Context, name, value string, maxAge int) error {
+	chunks := (len(value) + bffCookieChunkSize - 1) / bffCookieChunkSize
+	if chunks > bffMaxCookieChunks {
+		return fmt.Errorf("cookie %s would need %d chunks", name, chunks)
+	}
+	for i := 0; i < chunks; i++ {
+		end := min((i+1)*bffCookieChunkSize, len(value))
+		b.setCookie(c, chunkName(name, i), value[i*bffCookieChunkSize:end], "/", maxAge, http.SameSiteStrictMode, true)
+	}
+	for i := chunks; i < bffMaxCookieChunks; i++ {
+		if _, err := c.Cookie(chunkName(name, i)); err == nil {
+			b.setCookie(c, chunkName(name, i), "", "/", -1, http.SameSiteStrictMode, true)
+		}
+	}
+	return nil
+}
+
+// readChunkedCookie joins the chunks of a cookie; empty if the cookie is absent
+func readChunkedCookie(c *gin.Context, name string) string {
+	var value string
+	for i := 0; i < bffMaxCookieChunks; i++ {
+		chunk, err := c.Cookie(chunkName(name, i))
+		if err != nil {
+			break
+		}
+		value += chunk
+	}
+	return value
+}
+
+func (b *BFF) clearChunkedCookie(c *gin.Context, name string) {
+	for i := 0; i < bffMaxCookieChunks; i++ {
+		if _, err := c.Cookie(chunkName(name, i)); err == nil || i == 0 {
+			b.setCookie(c, chunkName(name, i), "", "/", -1, http.SameSiteStrictMode, true)
+		}
+	}
+}
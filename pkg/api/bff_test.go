@@ -0,0 +1,363 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+var testCookieKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestCookieCodec(t *testing.T) {
+	_, err := newCookieCodec([]byte("short"))
+	require.Error(t, err)
+
+	codec, err := newCookieCodec(testCookieKey)
+	require.NoError(t, err)
+	sealed, err := codec.seal(bffSessionCookie, bffSession{IDP: "corp", AccessToken: "at"})
+	require.NoError(t, err)
+
+	var got bffSession
+	require.NoError(t, codec.open(bffSessionCookie, sealed, &got))
+	assert.Equal(t, "corp", got.IDP)
+	assert.Equal(t, "at", got.AccessToken)
+
+	assert.Error(t, codec.open(bffLoginCookie, sealed, &got), "value must be bound to the cookie name")
+	tampered := []byte(sealed)
+	tampered[len(tampered)/2] ^= 1
+	assert.Error(t, codec.open(bffSessionCookie, string(tampered), &got))
+	assert.Error(t, codec.open(bffSessionCookie, "", &got))
+
+	other, err := newCookieCodec([]byte("another-key-another-key-another-key"))
+	require.NoError(t, err)
+	assert.Error(t, other.open(bffSessionCookie, sealed, &got))
+}
+
+func TestChunkedCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	b, err := newBFF(zaptest.NewLogger(t).Sugar(), testCookieKey, config.Config{}, nil)
+	require.NoError(t, err)
+	value := strings.Repeat("x", 2*bffCookieChunkSize+10)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, b.writeChunkedCookie(c, bffSessionCookie, value, 60))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 3)
+	for _, ck := range cookies {
+		assert.True(t, ck.HttpOnly)
+		assert.True(t, ck.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, ck.SameSite)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, ck := range cookies {
+		req.AddCookie(ck)
+	}
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = req
+	assert.Equal(t, value, readChunkedCookie(c, bffSessionCookie))
+
+	// A shorter value deletes the chunks that are no longer needed
+	require.NoError(t, b.writeChunkedCookie(c, bffSessionCookie, "short", 60))
+	written := map[string]int{}
+	for _, ck := range w.Result().Cookies() {
+		written[ck.Name] = ck.MaxAge
+	}
+	assert.Equal(t, map[string]int{bffSessionCookie: 60, bffSessionCookie + "_1": -1, bffSessionCookie + "_2": -1}, written)
+
+	assert.Error(t, b.writeChunkedCookie(c, bffSessionCookie, strings.Repeat("x", bffMaxCookieChunks*bffCookieChunkSize+1), 60))
+}
+
+func TestSafeRedirect(t *testing.T) {
+	tests := map[string]string{
+		"":                          "/",
+		"/sessions?x=1":             "/sessions?x=1",
+		"https://evil.example.com/": "/",
+		"//evil.example.com/":       "/",
+		"/\\evil.example.com/":      "/",
+		"/ok\r\nSet-Cookie: x=y":    "/",
+		"sessions":                  "/",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, safeRedirect(in), in)
+	}
+}
+
+// cookieJar keeps the cookies of the responses for the following requests
+type cookieJar map[string]*http.Cookie
+
+func (j cookieJar) do(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	for _, ck := range j {
+		req.AddCookie(ck)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for _, ck := range w.Result().Cookies() {
+		if ck.MaxAge < 0 {
+			delete(j, ck.Name)
+		} else {
+			j[ck.Name] = ck
+		}
+	}
+	return w
+}
+
+func TestBFFLoginFlow(t *testing.T) {
+	const issuer = "https://keycloak.example.com/realms/corp"
+	var (
+		sign          func(jwt.MapClaims) string
+		idpURL        string
+		challenge     string
+		nonce         string
+		refreshes     atomic.Int32
+		failRefreshes atomic.Bool
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"authorization_endpoint": idpURL + "/auth",
+				"token_endpoint":         idpURL + "/token",
+				"end_session_endpoint":   idpURL + "/logout",
+			})
+		case "/token":
+			user, pass, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "breakglass-ui", user)
+			assert.Equal(t, "s3cr%3Aet", pass, "credentials must be form-encoded")
+			require.NoError(t, r.ParseForm())
+			resp := map[string]any{"expires_in": 300}
+			switch r.PostForm.Get("grant_type") {
+			case "authorization_code":
+				sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+				if r.PostForm.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				assert.Equal(t, "https://breakglass.example.com/api/auth/callback", r.PostForm.Get("redirect_uri"))
+				resp["access_token"] = sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "email": "alice@example.com"})
+				resp["id_token"] = sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "nonce": nonce})
+				resp["refresh_token"] = "refresh-1"
+			case "refresh_token":
+				refreshes.Add(1)
+				if failRefreshes.Load() || r.PostForm.Get("refresh_token") != "refresh-1" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				resp["access_token"] = sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "email": "refreshed@example.com"})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	idpURL = srv.URL
+
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC: breakglassv1alpha1.OIDCConfig{
+				Authority:            srv.URL,
+				ClientID:             "breakglass-ui",
+				ClientSecretRef:      &breakglassv1alpha1.SecretKeyReference{Name: "oidc", Namespace: "breakglass", Key: "secret"},
+				CertificateAuthority: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})),
+			},
+			Issuer: issuer,
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "oidc", Namespace: "breakglass"},
+		Data:       map[string][]byte{"secret": []byte("s3cr:et")},
+	}
+	auth, _, signer := newMultiIDPTestAuth(t, idp, secret)
+	sign = signer
+
+	cfg := config.Config{
+		Frontend: config.Frontend{BaseURL: "https://breakglass.example.com/"},
+		BFF:      config.BFF{Enabled: true, SessionTTL: "1h"},
+	}
+	bff, err := newBFF(zaptest.NewLogger(t).Sugar(), testCookieKey, cfg, auth)
+	require.NoError(t, err)
+	now := time.Now()
+	bff.now = func() time.Time { return now }
+	auth.WithBFF(bff)
+
+	r := gin.New()
+	require.NoError(t, bff.Register(r.Group("/api/"+bff.BasePath())))
+	whoami := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("email")) }
+	r.GET("/whoami", auth.Middleware(), whoami)
+	r.POST("/whoami", auth.Middleware(), whoami)
+
+	jar := cookieJar{}
+	login := func() *url.URL {
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/login?redirect=/sessions", nil))
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		challenge = loc.Query().Get("code_challenge")
+		nonce = loc.Query().Get("nonce")
+		return loc
+	}
+
+	t.Run("login redirects to the identity provider", func(t *testing.T) {
+		loc := login()
+		assert.Equal(t, srv.URL+"/auth", loc.Scheme+"://"+loc.Host+loc.Path)
+		q := loc.Query()
+		assert.Equal(t, "code", q.Get("response_type"))
+		assert.Equal(t, "breakglass-ui", q.Get("client_id"))
+		assert.Equal(t, "https://breakglass.example.com/api/auth/callback", q.Get("redirect_uri"))
+		assert.Equal(t, "openid profile email", q.Get("scope"))
+		assert.Equal(t, "S256", q.Get("code_challenge_method"))
+		assert.NotEmpty(t, q.Get("state"))
+		require.Contains(t, jar, bffLoginCookie)
+		assert.Equal(t, "/api/auth", jar[bffLoginCookie].Path)
+	})
+
+	t.Run("callback rejects a foreign state", func(t *testing.T) {
+		login()
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/callback?state=forged&code=code-1", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotContains(t, jar, bffLoginCookie, "login state is single-use")
+		assert.NotContains(t, jar, bffSessionCookie)
+	})
+
+	t.Run("callback establishes the session", func(t *testing.T) {
+		state := login().Query().Get("state")
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/callback?state="+state+"&code=code-1", nil))
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		assert.Equal(t, "/sessions", w.Header().Get("Location"))
+		require.Contains(t, jar, bffSessionCookie)
+		assert.True(t, jar[bffSessionCookie].HttpOnly)
+		assert.NotContains(t, jar[bffSessionCookie].Value, "refresh-1", "tokens are encrypted")
+		require.Contains(t, jar, bffCSRFCookie)
+		assert.False(t, jar[bffCSRFCookie].HttpOnly)
+	})
+
+	t.Run("session authenticates API requests", func(t *testing.T) {
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "alice@example.com", w.Body.String())
+
+		w = jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/session", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var session map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+		assert.Equal(t, true, session["authenticated"])
+		assert.Equal(t, "corp", session["identityProvider"])
+		assert.Equal(t, jar[bffCSRFCookie].Value, session["csrfToken"])
+	})
+
+	t.Run("unsafe methods require the CSRF token", func(t *testing.T) {
+		w := jar.do(r, httptest.NewRequest(http.MethodPost, "/whoami", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), sessionRejectCSRF)
+
+		req := httptest.NewRequest(http.MethodPost, "/whoami", nil)
+		req.Header.Set(CSRFHeader, jar[bffCSRFCookie].Value)
+		w = jar.do(r, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("expiring access token is refreshed", func(t *testing.T) {
+		now = now.Add(5 * time.Minute)
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "refreshed@example.com", w.Body.String())
+		assert.Equal(t, int32(1), refreshes.Load())
+
+		w = jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), refreshes.Load(), "refreshed token is stored in the cookie")
+	})
+
+	t.Run("logout clears the session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+		req.Header.Set(CSRFHeader, jar[bffCSRFCookie].Value)
+		w := jar.do(r, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		logoutURL, err := url.Parse(resp["logoutURL"])
+		require.NoError(t, err)
+		assert.Equal(t, "/logout", logoutURL.Path)
+		assert.Equal(t, "https://breakglass.example.com/", logoutURL.Query().Get("post_logout_redirect_uri"))
+		assert.NotContains(t, jar, bffSessionCookie)
+		assert.NotContains(t, jar, bffCSRFCookie)
+
+		w = jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "no bearer token and no session")
+	})
+
+	t.Run("failed refresh ends the session", func(t *testing.T) {
+		state := login().Query().Get("state")
+		require.Equal(t, http.StatusFound, jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/callback?state="+state+"&code=code-1", nil)).Code)
+		failRefreshes.Store(true)
+		now = now.Add(5 * time.Minute)
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), sessionRejectExpired)
+		assert.NotContains(t, jar, bffSessionCookie)
+	})
+
+	t.Run("session lifetime is bounded", func(t *testing.T) {
+		failRefreshes.Store(false)
+		state := login().Query().Get("state")
+		require.Equal(t, http.StatusFound, jar.do(r, httptest.NewRequest(http.MethodGet, "/api/auth/callback?state="+state+"&code=code-1", nil)).Code)
+		now = now.Add(time.Hour)
+		w := jar.do(r, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), sessionRejectExpired)
+	})
+}
+
+func TestNewBFFConfig(t *testing.T) {
+	log := zaptest.NewLogger(t).Sugar()
+	bff, err := NewBFF(log, config.Config{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, bff)
+
+	_, err = NewBFF(log, config.Config{BFF: config.BFF{Enabled: true, CookieKeyFile: "/key"}}, nil)
+	assert.ErrorContains(t, err, "frontend.baseURL")
+	_, err = NewBFF(log, config.Config{Frontend: config.Frontend{BaseURL: "https://bg"}, BFF: config.BFF{Enabled: true}}, nil)
+	assert.ErrorContains(t, err, "cookieKeyFile")
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, append(testCookieKey, '\n'), 0o600))
+	cfg := config.Config{Frontend: config.Frontend{BaseURL: "https://bg"}, BFF: config.BFF{Enabled: true, CookieKeyFile: keyFile}}
+	bff, err = NewBFF(log, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultBFFSessionTTL, bff.ttl)
+	assert.True(t, bff.secure)
+
+	cfg.BFF.SessionTTL = "forever"
+	_, err = NewBFF(log, cfg, nil)
+	assert.ErrorContains(t, err, "sessionTTL")
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"strings"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+)
+
+// Default claim paths used when an IdentityProvider does not configure claimMappings
+var (
+	defaultUsernameClaims = []string{"preferred_username"}
+	defaultEmailClaims    = []string{"email"}
+	defaultGroupsClaims   = []string{"groups", "realm_access.roles"}
+
+	defaultClaimMapper = newClaimMapper(nil)
+)
+
+// claimMapper extracts username, email and groups from verified token claims
+// according to the claimMappings of the issuing IdentityProvider
+type claimMapper struct {
+	username  [][]string
+	email     [][]string
+	groups    [][]string
+	transform breakglassv1alpha1.GroupTransform
+}
+
+// newClaimMapper builds a mapper from the IdentityProvider claim mappings, falling back to the
+// defaults for unset fields. Invalid paths are skipped; they are rejected by the admission webhook.
+func newClaimMapper(m *breakglassv1alpha1.ClaimMappings) *claimMapper {
+	if m == nil {
+		m = &breakglassv1alpha1.ClaimMappings{}
+	}
+	mapper := &claimMapper{
+		username: parseClaimPaths(m.Username, defaultUsernameClaims),
+		email:    parseClaimPaths(m.Email, defaultEmailClaims),
+		groups:   parseClaimPaths(m.Groups, defaultGroupsClaims),
+	}
+	if m.GroupTransform != nil {
+		mapper.transform = *m.GroupTransform
+	}
+	return mapper
+}
+
+func parseClaimPaths(paths, defaults []string) [][]string {
+	if len(paths) == 0 {
+		paths = defaults
+	}
+	parsed := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		if segments, err := breakglassv1alpha1.ParseClaimPath(p); err == nil {
+			parsed = append(parsed, segments)
+		}
+	}
+	return parsed
+}
+
+// Username returns the first non-empty username claim
+func (m *claimMapper) Username(claims map[string]interface{}) string {
+	return firstStringClaim(claims, m.username)
+}
+
+// Email returns the first non-empty email claim
+func (m *claimMapper) Email(claims map[string]interface{}) string {
+	return firstStringClaim(claims, m.email)
+}
+
+// Groups returns the normalized groups of the first groups claim that holds any
+func (m *claimMapper) Groups(claims map[string]interface{}) []string {
+	for _, path := range m.groups {
+		if groups := stringsClaim(lookupClaim(claims, path)); len(groups) > 0 {
+			return m.normalizeGroups(groups)
+		}
+	}
+	return nil
+}
+
+// normalizeGroups strips configured prefixes, normalizes path-style groups (Keycloak: /team/role),
+// applies the configured prefix and case and removes duplicates
+func (m *claimMapper) normalizeGroups(groups []string) []string {
+	seen := make(map[string]struct{}, len(groups))
+	normalized := make([]string, 0, len(groups))
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		for _, prefix := range m.transform.StripPrefixes {
+			if strings.HasPrefix(g, prefix) {
+				g = strings.TrimPrefix(g, prefix)
+				break
+			}
+		}
+		// Remove leading slash (Keycloak group path style: /team/role)
+		g = strings.TrimLeft(g, "/")
+		if m.transform.PathMode != breakglassv1alpha1.GroupPathModeFullPath {
+			if idx := strings.LastIndex(g, "/"); idx != -1 && idx < len(g)-1 { // keep only final path element
+				g = g[idx+1:]
+			}
+		}
+		if g == "" {
+			continue
+		}
+		if m.transform.Lowercase {
+			g = strings.ToLower(g)
+		}
+		g = m.transform.Prefix + g
+		if _, exists := seen[g]; exists {
+			continue
+		}
+		seen[g] = struct{}{}
+		normalized = append(normalized, g)
+	}
+	return normalized
+}
+
+func firstStringClaim(claims map[string]interface{}, paths [][]string) string {
+	for _, path := range paths {
+		if s, ok := lookupClaim(claims, path).(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// lookupClaim walks nested claim objects along path
+func lookupClaim(claims map[string]interface{}, path []string) interface{} {
+	var current interface{} = claims
+	for _, segment := range path {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if current, ok = obj[segment]; !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// stringsClaim converts a list or single string claim into a string slice
+func stringsClaim(v interface{}) []string {
+	switch val := v.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return val
+	case string:
+		if val != "" {
+			return []string{val}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+func TestClaimMapperDefaults(t *testing.T) {
+	mapper := newClaimMapper(nil)
+
+	t.Run("groups claim", func(t *testing.T) {
+		claims := map[string]interface{}{
+			"preferred_username": "alice",
+			"email":              "alice@example.com",
+			"groups":             []interface{}{"/platform/admins", "viewers", "viewers", ""},
+		}
+		assert.Equal(t, "alice", mapper.Username(claims))
+		assert.Equal(t, "alice@example.com", mapper.Email(claims))
+		assert.Equal(t, []string{"admins", "viewers"}, mapper.Groups(claims))
+	})
+
+	t.Run("keycloak realm roles fallback", func(t *testing.T) {
+		claims := map[string]interface{}{
+			"realm_access": map[string]interface{}{"roles": []interface{}{"ops"}},
+		}
+		assert.Equal(t, []string{"ops"}, mapper.Groups(claims))
+	})
+
+	t.Run("missing claims", func(t *testing.T) {
+		claims := map[string]interface{}{"sub": "123"}
+		assert.Empty(t, mapper.Username(claims))
+		assert.Empty(t, mapper.Email(claims))
+		assert.Nil(t, mapper.Groups(claims))
+	})
+}
+
+func TestClaimMapperCustomMappings(t *testing.T) {
+	mapper := newClaimMapper(&breakglassv1alpha1.ClaimMappings{
+		Username: []string{"upn", "preferred_username"},
+		Email:    []string{"email", "upn"},
+		Groups:   []string{`["https://example.com/claims"].groups`, "roles"},
+		GroupTransform: &breakglassv1alpha1.GroupTransform{
+			StripPrefixes: []string{"oidc:", "corp-"},
+			Prefix:        "idp2:",
+			PathMode:      breakglassv1alpha1.GroupPathModeFullPath,
+			Lowercase:     true,
+		},
+	})
+
+	t.Run("fallback chain and namespaced groups claim", func(t *testing.T) {
+		claims := map[string]interface{}{
+			"upn": "bob@corp.example.com",
+			"https://example.com/claims": map[string]interface{}{
+				"groups": []interface{}{"oidc:/Team/Admins", "corp-Viewers"},
+			},
+			"roles": []interface{}{"ignored"},
+		}
+		assert.Equal(t, "bob@corp.example.com", mapper.Username(claims))
+		assert.Equal(t, "bob@corp.example.com", mapper.Email(claims), "email falls back to upn")
+		assert.Equal(t, []string{"idp2:team/admins", "idp2:viewers"}, mapper.Groups(claims))
+	})
+
+	t.Run("second groups path when first is absent", func(t *testing.T) {
+		claims := map[string]interface{}{"roles": "Operator"}
+		assert.Equal(t, []string{"idp2:operator"}, mapper.Groups(claims))
+	})
+}
+
+// newMultiIDPTestRouter serves GET /whoami behind the auth middleware in multi-IDP mode with idp
+// as the only IdentityProvider, and returns a function that signs tokens for its issuer
+func newMultiIDPTestRouter(t *testing.T, idp *breakglassv1alpha1.IdentityProvider, handler gin.HandlerFunc) (*gin.Engine, func(jwt.MapClaims) string) {
+	t.Helper()
+	auth, _, sign := newMultiIDPTestAuth(t, idp)
+	r := gin.New()
+	r.GET("/whoami", auth.Middleware(), handler)
+	return r, sign
+}
+
+// newMultiIDPTestAuth returns an AuthHandler trusting idp with a pre-loaded JWKS, the fake
+// client holding idp and objs, and a function signing claims as idp
+func newMultiIDPTestAuth(t *testing.T, idp *breakglassv1alpha1.IdentityProvider, objs ...client.Object) (*AuthHandler, client.Client, func(jwt.MapClaims) string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwksBytes, err := json.Marshal(map[string]interface{}{
+		"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA", "kid": "test-kid", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(priv.PublicKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(priv.PublicKey.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+	jwks, err := keyfunc.NewJSON(jwksBytes)
+	require.NoError(t, err)
+
+	cli := fake.NewClientBuilder().WithScheme(config.Scheme).WithObjects(append(objs, idp)...).
+		WithStatusSubresource(&breakglassv1alpha1.IdentityProvider{}).Build()
+	auth := NewAuth(zaptest.NewLogger(t).Sugar(), config.Config{})
+	auth.WithIdentityProviderLoader(config.NewIdentityProviderLoader(cli))
+	auth.jwksCache[idp.Spec.Issuer] = jwks
+
+	sign := func(claims jwt.MapClaims) string {
+		claims["iss"] = idp.Spec.Issuer
+		if _, ok := claims["exp"]; !ok {
+			claims["exp"] = time.Now().Add(time.Hour).Unix()
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = "test-kid"
+		signed, err := tok.SignedString(priv)
+		require.NoError(t, err)
+		return signed
+	}
+	return auth, cli, sign
+}
+
+func serveWithToken(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set(AuthHeaderKey, "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareUsesIssuerClaimMappings(t *testing.T) {
+	const issuer = "https://login.microsoftonline.com/tenant/v2.0"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "azure"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC:   breakglassv1alpha1.OIDCConfig{Authority: issuer, ClientID: "breakglass"},
+			Issuer: issuer,
+			ClaimMappings: &breakglassv1alpha1.ClaimMappings{
+				Username: []string{"upn"},
+				Email:    []string{"email", "upn"},
+				Groups:   []string{"groups"},
+				GroupTransform: &breakglassv1alpha1.GroupTransform{
+					Prefix: "aad:",
+				},
+			},
+		},
+	}
+
+	var gotEmail, gotUsername, gotIDP string
+	var gotGroups []string
+	r, sign := newMultiIDPTestRouter(t, idp, func(c *gin.Context) {
+		gotEmail = c.GetString("email")
+		gotUsername = c.GetString("username")
+		gotIDP = c.GetString("identity_provider_name")
+		gotGroups = c.GetStringSlice("groups")
+		c.Status(http.StatusOK)
+	})
+
+	w := serveWithToken(r, sign(jwt.MapClaims{
+		"sub":    "object-id",
+		"aud":    "breakglass",
+		"upn":    "carol@corp.example.com",
+		"groups": []string{"5f1c0b3e-1111-2222-3333-444455556666"},
+	}))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "azure", gotIDP)
+	assert.Equal(t, "carol@corp.example.com", gotEmail)
+	assert.Equal(t, "carol@corp.example.com", gotUsername)
+	assert.Equal(t, []string{"aad:5f1c0b3e-1111-2222-3333-444455556666"}, gotGroups)
+}
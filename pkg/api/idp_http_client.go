@@ -0,0 +1,49 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+// idpHTTPClients caches HTTP clients using the TLS settings of identity providers. It is safe
+// for concurrent use.
+type idpHTTPClients struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+func newIDPHTTPClients() *idpHTTPClients {
+	return &idpHTTPClients{clients: map[string]*http.Client{}}
+}
+
+// get returns a client trusting the CA of the identity provider. Redirects are not followed
+// because they could send tokens and client credentials elsewhere.
+func (h *idpHTTPClients) get(idp *config.IdentityProviderConfig) (*http.Client, error) {
+	key := fmt.Sprintf("%s/%t/%s", idp.Name, idp.InsecureSkipVerify, idp.CertificateAuthority)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.clients[key]; ok {
+		return c, nil
+	}
+	// InsecureSkipVerify is an explicit opt-in for test environments
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: idp.InsecureSkipVerify}
+	if idp.CertificateAuthority != "" {
+		pool, err := buildCertPoolFromPEM(idp.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse CA certificate for IDP %s: %w", idp.Name, err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c := &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	h.clients[key] = c
+	return c, nil
+}
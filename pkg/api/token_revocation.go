@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+)
+
+// Token revocation failure reasons, used as the reason label of JWTValidationFailure
+const (
+	tokenRejectRevoked             = "token_revoked"
+	tokenRejectInactive            = "token_inactive"
+	tokenRejectIntrospectionFailed = "introspection_failed"
+)
+
+const (
+	defaultIntrospectionCacheTTL = 30 * time.Second
+	defaultIntrospectionTimeout  = 5 * time.Second
+	// maxIntrospectionCacheEntries bounds the cache; expired entries are pruned first
+	maxIntrospectionCacheEntries = 10000
+	// maxIntrospectionResponseBytes caps the size of an introspection response
+	maxIntrospectionResponseBytes = 1 << 20
+)
+
+// SessionTerminator ends the breakglass sessions a user requested through an identity provider.
+// It is called when a user logs out or their token is reported inactive and the provider has
+// tokenRevocation.terminateSessions enabled.
+type SessionTerminator func(ctx context.Context, idpName, subject, reason string)
+
+// tokenRevocationError describes why a token was rejected by the revocation checks
+type tokenRevocationError struct {
+	status  int
+	reason  string
+	message string
+}
+
+// introspectionResult is a cached introspection answer
+type introspectionResult struct {
+	active  bool
+	expires time.Time
+}
+
+// tokenIntrospector calls RFC 7662 introspection endpoints and caches the results per token.
+// It is safe for concurrent use.
+type tokenIntrospector struct {
+	mu      sync.Mutex
+	cache   map[string]introspectionResult
+	clients *idpHTTPClients
+	now     func() time.Time
+}
+
+func newTokenIntrospector() *tokenIntrospector {
+	return &tokenIntrospector{
+		cache:   map[string]introspectionResult{},
+		clients: newIDPHTTPClients(),
+		now:     time.Now,
+	}
+}
+
+// checkTokenRevocation rejects tokens revoked by a back-channel logout or reported inactive by
+// the introspection endpoint. fresh reports whether an inactive result was just fetched rather
+// than served from the cache.
+func (a *AuthHandler) checkTokenRevocation(ctx context.Context, idp *config.IdentityProviderConfig, bearer string, claims jwt.MapClaims) (rerr *tokenRevocationError, fresh bool) {
+	rev := idp.TokenRevocation
+	if revokedByLogout(rev, claims) {
+		return &tokenRevocationError{
+			status:  http.StatusUnauthorized,
+			reason:  tokenRejectRevoked,
+			message: "token was revoked by a logout at the identity provider; please log in again",
+		}, false
+	}
+	if rev.Introspection == nil {
+		return nil, false
+	}
+
+	active, fresh, err := a.introspector.introspect(ctx, idp, bearer, claimTime(claims["exp"]))
+	if err != nil {
+		if rev.Introspection.FailOpen {
+			a.log.Warnw("token introspection failed, accepting token (failOpen)", "idp", idp.Name, "error", err)
+			return nil, false
+		}
+		a.log.Warnw("token introspection failed", "idp", idp.Name, "error", err)
+		return &tokenRevocationError{
+			status:  http.StatusServiceUnavailable,
+			reason:  tokenRejectIntrospectionFailed,
+			message: "unable to check the token with the identity provider; please retry later",
+		}, false
+	}
+	if !active {
+		return &tokenRevocationError{
+			status:  http.StatusUnauthorized,
+			reason:  tokenRejectInactive,
+			message: "token is no longer active at the identity provider; please log in again",
+		}, fresh
+	}
+	return nil, false
+}
+
+// tokenRecheck returns the check stored under breakglass.TokenRecheckKey. The identity provider is loaded
+// again on every call, so logouts recorded after the request started and providers that were
+// disabled since are taken into account.
+func (a *AuthHandler) tokenRecheck(issuer, bearer string, claims jwt.MapClaims) func(context.Context) error {
+	return func(ctx context.Context) error {
+		idp, err := a.idpLoader.LoadIdentityProviderByIssuer(ctx, issuer)
+		if err != nil {
+			return fmt.Errorf("token issuer '%s' is not an enabled identity provider: %w", issuer, err)
+		}
+		if idp.TokenRevocation == nil {
+			return nil
+		}
+		if rerr, _ := a.checkTokenRevocation(ctx, idp, bearer, claims); rerr != nil {
+			metrics.JWTValidationFailure.WithLabelValues(issuer, rerr.reason).Inc()
+			return errors.New(rerr.message)
+		}
+		return nil
+	}
+}
+
+// revokedByLogout matches the token against the recorded logout events
+func revokedByLogout(rev *config.TokenRevocationRuntimeConfig, claims jwt.MapClaims) bool {
+	if len(rev.LogoutRevocations) == 0 {
+		return false
+	}
+	sub, _ := claims["sub"].(string)
+	sid, _ := claims["sid"].(string)
+	iat := claimTime(claims["iat"])
+	for _, r := range rev.LogoutRevocations {
+		if r.SessionID != "" && r.SessionID == sid {
+			return true
+		}
+		// Tokens without iat cannot prove they were issued after the logout
+		if r.Subject != "" && r.Subject == sub && (iat.IsZero() || !iat.After(r.RevokedAt.Time)) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimTime converts a NumericDate claim; zero if absent or malformed
+func claimTime(v interface{}) time.Time {
+	switch n := v.(type) {
+	case float64:
+		return time.Unix(int64(n), 0)
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return time.Unix(i, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// introspect returns whether the token is active. Results are cached for the configured TTL,
+// but never past the token expiry.
+func (t *tokenIntrospector) introspect(ctx context.Context, idp *config.IdentityProviderConfig, token string, exp time.Time) (active, fresh bool, err error) {
+	cfg := idp.TokenRevocation.Introspection
+	sum := sha256.Sum256([]byte(token))
+	key := idp.Name + "/" + hex.EncodeToString(sum[:])
+
+	now := t.now()
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.active, false, nil
+	}
+
+	client, err := t.clients.get(idp)
+	if err != nil {
+		return false, false, err
+	}
+	timeout := parseDurationOr(cfg.RequestTimeout, defaultIntrospectionTimeout)
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic requires the form-encoded credentials (RFC 6749 section 2.3.1)
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, false, fmt.Errorf("introspection request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return false, false, fmt.Errorf("introspection endpoint returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Active bool `json:"active"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIntrospectionResponseBytes)).Decode(&body); err != nil {
+		return false, false, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+
+	expires := now.Add(parseDurationOr(cfg.CacheTTL, defaultIntrospectionCacheTTL))
+	if !exp.IsZero() && exp.Before(expires) {
+		expires = exp
+	}
+	t.store(key, introspectionResult{active: body.Active, expires: expires}, now)
+	return body.Active, true, nil
+}
+
+func (t *tokenIntrospector) store(key string, result introspectionResult, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.cache) >= maxIntrospectionCacheEntries {
+		for k, v := range t.cache {
+			if !now.Before(v.expires) {
+				delete(t.cache, k)
+			}
+		}
+		if len(t.cache) >= maxIntrospectionCacheEntries {
+			t.cache = map[string]introspectionResult{}
+		}
+	}
+	t.cache[key] = result
+}
+
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+func TestRevokedByLogout(t *testing.T) {
+	logoutAt := time.Unix(1700000000, 0)
+	rev := &config.TokenRevocationRuntimeConfig{LogoutRevocations: []breakglassv1alpha1.LogoutRevocation{
+		{Subject: "alice", RevokedAt: metav1.NewTime(logoutAt)},
+		{SessionID: "sid-1", RevokedAt: metav1.NewTime(logoutAt)},
+	}}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{"issued before logout", jwt.MapClaims{"sub": "alice", "iat": float64(logoutAt.Unix() - 60)}, true},
+		{"issued at logout", jwt.MapClaims{"sub": "alice", "iat": json.Number("1700000000")}, true},
+		{"issued after logout", jwt.MapClaims{"sub": "alice", "iat": float64(logoutAt.Unix() + 60)}, false},
+		{"missing iat", jwt.MapClaims{"sub": "alice"}, true},
+		{"other subject", jwt.MapClaims{"sub": "bob", "iat": float64(logoutAt.Unix() - 60)}, false},
+		{"logged out session", jwt.MapClaims{"sub": "bob", "sid": "sid-1", "iat": float64(logoutAt.Unix() + 60)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, revokedByLogout(rev, tt.claims))
+		})
+	}
+	assert.False(t, revokedByLogout(&config.TokenRevocationRuntimeConfig{}, jwt.MapClaims{"sub": "alice"}))
+}
+
+func TestMiddlewareTokenIntrospection(t *testing.T) {
+	var requests atomic.Int32
+	var fail atomic.Bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "breakglass", user)
+		assert.Equal(t, "s3cr%3Aet", pass, "credentials must be form-encoded")
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "access_token", r.PostForm.Get("token_type_hint"))
+		claims := jwt.MapClaims{}
+		_, _, err := jwt.NewParser().ParseUnverified(r.PostForm.Get("token"), claims)
+		require.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(map[string]any{"active": claims["sub"] != "disabled"})
+	}))
+	defer srv.Close()
+
+	const issuer = "https://keycloak.example.com/realms/corp"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC: breakglassv1alpha1.OIDCConfig{
+				Authority:            issuer,
+				ClientID:             "breakglass-ui",
+				CertificateAuthority: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})),
+			},
+			Issuer: issuer,
+			TokenRevocation: &breakglassv1alpha1.TokenRevocation{
+				Introspection: &breakglassv1alpha1.TokenIntrospection{
+					Endpoint:        srv.URL,
+					ClientID:        "breakglass",
+					ClientSecretRef: breakglassv1alpha1.SecretKeyReference{Name: "introspection", Namespace: "breakglass", Key: "secret"},
+				},
+				TerminateSessions: true,
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "introspection", Namespace: "breakglass"},
+		Data:       map[string][]byte{"secret": []byte("s3cr:et")},
+	}
+	auth, cli, sign := newMultiIDPTestAuth(t, idp, secret)
+	terminated := make(chan string, 1)
+	auth.WithSessionTerminator(func(_ context.Context, idpName, subject, _ string) {
+		terminated <- idpName + "/" + subject
+	})
+	r := gin.New()
+	r.GET("/whoami", auth.Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("active token is cached", func(t *testing.T) {
+		token := sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui"})
+		before := requests.Load()
+		assert.Equal(t, http.StatusOK, serveWithToken(r, token).Code)
+		assert.Equal(t, http.StatusOK, serveWithToken(r, token).Code)
+		assert.Equal(t, before+1, requests.Load())
+	})
+
+	t.Run("inactive token ends sessions", func(t *testing.T) {
+		w := serveWithToken(r, sign(jwt.MapClaims{"sub": "disabled", "aud": "breakglass-ui"}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), tokenRejectInactive)
+		select {
+		case got := <-terminated:
+			assert.Equal(t, "corp/disabled", got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sessions of the disabled user were not ended")
+		}
+	})
+
+	t.Run("endpoint failure", func(t *testing.T) {
+		fail.Store(true)
+		defer fail.Store(false)
+		w := serveWithToken(r, sign(jwt.MapClaims{"sub": "bob", "aud": "breakglass-ui"}))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), tokenRejectIntrospectionFailed)
+
+		current := &breakglassv1alpha1.IdentityProvider{}
+		require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(idp), current))
+		current.Spec.TokenRevocation.Introspection.FailOpen = true
+		require.NoError(t, cli.Update(context.Background(), current))
+		w = serveWithToken(r, sign(jwt.MapClaims{"sub": "carol", "aud": "breakglass-ui"}))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+func TestTokenIntrospectorCacheBoundedByExpiry(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"active": true})
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1700000000, 0)
+	ti := newTokenIntrospector()
+	ti.now = func() time.Time { return now }
+	idp := &config.IdentityProviderConfig{Name: "corp", TokenRevocation: &config.TokenRevocationRuntimeConfig{
+		Introspection: &config.TokenIntrospectionRuntimeConfig{Endpoint: srv.URL, CacheTTL: "1m"},
+	}}
+
+	active, fresh, err := ti.introspect(context.Background(), idp, "token", now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.True(t, active)
+	assert.True(t, fresh)
+
+	now = now.Add(5 * time.Second)
+	_, fresh, err = ti.introspect(context.Background(), idp, "token", now.Add(5*time.Second))
+	require.NoError(t, err)
+	assert.False(t, fresh)
+
+	now = now.Add(10 * time.Second)
+	_, fresh, err = ti.introspect(context.Background(), idp, "token", time.Time{})
+	require.NoError(t, err)
+	assert.True(t, fresh, "result must not be reused past the token expiry")
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestMiddlewareTokenRecheckSeesLaterLogout(t *testing.T) {
+	const issuer = "https://keycloak.example.com/realms/corp"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC:            breakglassv1alpha1.OIDCConfig{Authority: issuer, ClientID: "breakglass-ui"},
+			Issuer:          issuer,
+			TokenRevocation: &breakglassv1alpha1.TokenRevocation{BackChannelLogout: true},
+		},
+	}
+	auth, cli, sign := newMultiIDPTestAuth(t, idp)
+	var recheck func(context.Context) error
+	r := gin.New()
+	r.GET("/whoami", auth.Middleware(), func(c *gin.Context) {
+		v, _ := c.Get(breakglass.TokenRecheckKey)
+		recheck, _ = v.(func(context.Context) error)
+		c.Status(http.StatusOK)
+	})
+
+	iat := time.Now().Add(-time.Minute)
+	require.Equal(t, http.StatusOK, serveWithToken(r, sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui", "iat": iat.Unix()})).Code)
+	require.NotNil(t, recheck)
+	require.NoError(t, recheck(context.Background()))
+
+	current := &breakglassv1alpha1.IdentityProvider{}
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(idp), current))
+	current.Status.LogoutRevocations = []breakglassv1alpha1.LogoutRevocation{{Subject: "alice", RevokedAt: metav1.Now()}}
+	require.NoError(t, cli.Status().Update(context.Background(), current))
+
+	err := recheck(context.Background())
+	require.Error(t, err, "a logout recorded after the request started revokes the token")
+	assert.Contains(t, err.Error(), "logout")
+}
+
+func TestMiddlewareRejectsTokenOfRemovedIdentityProvider(t *testing.T) {
+	const issuer = "https://keycloak.example.com/realms/corp"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC:   breakglassv1alpha1.OIDCConfig{Authority: issuer, ClientID: "breakglass-ui"},
+			Issuer: issuer,
+		},
+	}
+	auth, cli, sign := newMultiIDPTestAuth(t, idp)
+	var recheck func(context.Context) error
+	r := gin.New()
+	r.GET("/whoami", auth.Middleware(), func(c *gin.Context) {
+		v, _ := c.Get(breakglass.TokenRecheckKey)
+		recheck, _ = v.(func(context.Context) error)
+		c.Status(http.StatusOK)
+	})
+
+	token := sign(jwt.MapClaims{"sub": "alice", "aud": "breakglass-ui"})
+	require.Equal(t, http.StatusOK, serveWithToken(r, token).Code)
+	require.NotNil(t, recheck)
+	require.NoError(t, recheck(context.Background()))
+
+	// The signing keys of the issuer stay cached after the IdentityProvider is gone
+	require.NoError(t, cli.Delete(context.Background(), idp))
+
+	w := serveWithToken(r, token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown_identity_provider")
+	assert.Error(t, recheck(context.Background()), "open watch streams of the token must be closed")
+}
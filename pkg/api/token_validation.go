@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+// Token validation failure reasons, used as the reason label of JWTValidationFailure
+const (
+	tokenRejectInvalidAudience        = "invalid_audience"
+	tokenRejectInvalidAuthorizedParty = "invalid_authorized_party"
+	tokenRejectMissingRequiredClaim   = "missing_required_claim"
+	tokenRejectRequiredClaimMismatch  = "required_claim_mismatch"
+)
+
+// tokenValidationError describes why a verified token was rejected
+type tokenValidationError struct {
+	reason  string
+	message string
+}
+
+func (e *tokenValidationError) Error() string {
+	return e.message
+}
+
+// validateTokenClaims checks the aud/azp claims and the required claims of a verified token
+// against the configuration of the issuing IdentityProvider
+func validateTokenClaims(idp *config.IdentityProviderConfig, claims map[string]interface{}) *tokenValidationError {
+	rules := idp.TokenValidation
+	if rules == nil {
+		rules = &breakglassv1alpha1.TokenValidation{}
+	}
+
+	if rules.AudienceValidation != breakglassv1alpha1.AudienceValidationDisabled {
+		if err := validateAudience(idp.ClientID, rules, claims); err != nil {
+			return err
+		}
+	}
+
+	for _, rc := range rules.RequiredClaims {
+		if err := validateRequiredClaim(rc, claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAudience(clientID string, rules *breakglassv1alpha1.TokenValidation, claims map[string]interface{}) *tokenValidationError {
+	audiences := rules.Audiences
+	if len(audiences) == 0 && clientID != "" {
+		audiences = []string{clientID}
+	}
+	parties := rules.AuthorizedParties
+	if len(parties) == 0 && clientID != "" {
+		parties = []string{clientID}
+	}
+
+	// A present azp must always name an authorized party
+	azp, hasAZP := claims["azp"].(string)
+	if hasAZP && azp != "" && !slices.Contains(parties, azp) {
+		return &tokenValidationError{
+			reason:  tokenRejectInvalidAuthorizedParty,
+			message: "token was issued to a client that is not authorized for this application",
+		}
+	}
+
+	for _, aud := range stringsClaim(claims["aud"]) {
+		if slices.Contains(audiences, aud) {
+			return nil
+		}
+	}
+	if rules.AudienceValidation != breakglassv1alpha1.AudienceValidationAudience && hasAZP && azp != "" {
+		// Keycloak access tokens name the requesting client in azp rather than aud
+		return nil
+	}
+	return &tokenValidationError{
+		reason:  tokenRejectInvalidAudience,
+		message: "token audience does not match this application",
+	}
+}
+
+func validateRequiredClaim(rc breakglassv1alpha1.RequiredClaim, claims map[string]interface{}) *tokenValidationError {
+	path, err := breakglassv1alpha1.ParseClaimPath(rc.Claim)
+	if err != nil {
+		// Rejected by the admission webhook; fail closed if it slipped through
+		return &tokenValidationError{reason: tokenRejectMissingRequiredClaim, message: fmt.Sprintf("required claim %q is misconfigured", rc.Claim)}
+	}
+	values := claimValues(lookupClaim(claims, path))
+	if len(values) == 0 {
+		return &tokenValidationError{
+			reason:  tokenRejectMissingRequiredClaim,
+			message: fmt.Sprintf("token is missing required claim %q", rc.Claim),
+		}
+	}
+	if len(rc.Values) == 0 {
+		return nil
+	}
+	for _, v := range values {
+		if slices.Contains(rc.Values, v) {
+			return nil
+		}
+	}
+	return &tokenValidationError{
+		reason:  tokenRejectRequiredClaimMismatch,
+		message: fmt.Sprintf("token claim %q does not have an accepted value", rc.Claim),
+	}
+}
+
+// claimValues renders scalar or list claims as strings; booleans and numbers use their JSON form
+func claimValues(v interface{}) []string {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if val == "" {
+			return nil
+		}
+		return []string{val}
+	case bool:
+		return []string{strconv.FormatBool(val)}
+	case float64:
+		return []string{strconv.FormatFloat(val, 'f', -1, 64)}
+	case json.Number:
+		return []string{val.String()}
+	case []interface{}:
+		var out []string
+		for _, item := range val {
+			out = append(out, claimValues(item)...)
+		}
+		return out
+	case []string:
+		var out []string
+		for _, s := range val {
+			if s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
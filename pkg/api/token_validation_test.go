@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+)
+
+func TestValidateTokenClaims(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation *breakglassv1alpha1.TokenValidation
+		claims     map[string]interface{}
+		wantReason string
+	}{
+		{name: "aud matches client", claims: map[string]interface{}{"aud": "breakglass-ui"}},
+		{name: "aud list contains client", claims: map[string]interface{}{"aud": []interface{}{"account", "breakglass-ui"}}},
+		{name: "keycloak azp without aud", claims: map[string]interface{}{"aud": "account", "azp": "breakglass-ui"}},
+		{name: "token for another client", claims: map[string]interface{}{"aud": "account", "azp": "grafana"}, wantReason: tokenRejectInvalidAuthorizedParty},
+		{name: "no aud and no azp", claims: map[string]interface{}{"sub": "x"}, wantReason: tokenRejectInvalidAudience},
+		{name: "foreign aud without azp", claims: map[string]interface{}{"aud": "grafana"}, wantReason: tokenRejectInvalidAudience},
+		{
+			name:       "strict audience ignores azp",
+			validation: &breakglassv1alpha1.TokenValidation{AudienceValidation: breakglassv1alpha1.AudienceValidationAudience},
+			claims:     map[string]interface{}{"aud": "account", "azp": "breakglass-ui"},
+			wantReason: tokenRejectInvalidAudience,
+		},
+		{
+			name:       "additional audiences and parties",
+			validation: &breakglassv1alpha1.TokenValidation{Audiences: []string{"breakglass-api"}, AuthorizedParties: []string{"breakglass-cli"}},
+			claims:     map[string]interface{}{"aud": "breakglass-api", "azp": "breakglass-cli"},
+		},
+		{
+			name:       "disabled audience validation",
+			validation: &breakglassv1alpha1.TokenValidation{AudienceValidation: breakglassv1alpha1.AudienceValidationDisabled},
+			claims:     map[string]interface{}{"aud": "grafana", "azp": "grafana"},
+		},
+		{
+			name: "required boolean claim",
+			validation: &breakglassv1alpha1.TokenValidation{RequiredClaims: []breakglassv1alpha1.RequiredClaim{
+				{Claim: "email_verified", Values: []string{"true"}},
+			}},
+			claims: map[string]interface{}{"aud": "breakglass-ui", "email_verified": true},
+		},
+		{
+			name: "required boolean claim false",
+			validation: &breakglassv1alpha1.TokenValidation{RequiredClaims: []breakglassv1alpha1.RequiredClaim{
+				{Claim: "email_verified", Values: []string{"true"}},
+			}},
+			claims:     map[string]interface{}{"aud": "breakglass-ui", "email_verified": false},
+			wantReason: tokenRejectRequiredClaimMismatch,
+		},
+		{
+			name: "required claim missing",
+			validation: &breakglassv1alpha1.TokenValidation{RequiredClaims: []breakglassv1alpha1.RequiredClaim{
+				{Claim: "acr"},
+			}},
+			claims:     map[string]interface{}{"aud": "breakglass-ui"},
+			wantReason: tokenRejectMissingRequiredClaim,
+		},
+		{
+			name: "required list claim contains value",
+			validation: &breakglassv1alpha1.TokenValidation{RequiredClaims: []breakglassv1alpha1.RequiredClaim{
+				{Claim: "amr", Values: []string{"mfa", "hwk"}},
+			}},
+			claims: map[string]interface{}{"aud": "breakglass-ui", "amr": []interface{}{"pwd", "mfa"}},
+		},
+		{
+			name: "required numeric claim",
+			validation: &breakglassv1alpha1.TokenValidation{RequiredClaims: []breakglassv1alpha1.RequiredClaim{
+				{Claim: "ext.level", Values: []string{"2"}},
+			}},
+			claims: map[string]interface{}{"aud": "breakglass-ui", "ext": map[string]interface{}{"level": float64(2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := &config.IdentityProviderConfig{Name: "idp", ClientID: "breakglass-ui", TokenValidation: tt.validation}
+			err := validateTokenClaims(idp, tt.claims)
+			if tt.wantReason == "" {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Equal(t, tt.wantReason, err.reason)
+		})
+	}
+}
+
+func TestMiddlewareRejectsTokensForOtherClients(t *testing.T) {
+	const issuer = "https://keycloak.example.com/realms/corp"
+	idp := &breakglassv1alpha1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: breakglassv1alpha1.IdentityProviderSpec{
+			OIDC:   breakglassv1alpha1.OIDCConfig{Authority: issuer, ClientID: "breakglass-ui"},
+			Issuer: issuer,
+			TokenValidation: &breakglassv1alpha1.TokenValidation{
+				RequiredClaims: []breakglassv1alpha1.RequiredClaim{{Claim: "email_verified", Values: []string{"true"}}},
+			},
+		},
+	}
+	r, sign := newMultiIDPTestRouter(t, idp, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("accepted", func(t *testing.T) {
+		w := serveWithToken(r, sign(jwt.MapClaims{"sub": "u", "aud": "account", "azp": "breakglass-ui", "email_verified": true}))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("other client", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.JWTValidationFailure.WithLabelValues(issuer, tokenRejectInvalidAuthorizedParty))
+		w := serveWithToken(r, sign(jwt.MapClaims{"sub": "u", "aud": "account", "azp": "grafana", "email_verified": true}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), tokenRejectInvalidAuthorizedParty)
+		after := testutil.ToFloat64(metrics.JWTValidationFailure.WithLabelValues(issuer, tokenRejectInvalidAuthorizedParty))
+		assert.Equal(t, before+1, after)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		w := serveWithToken(r, sign(jwt.MapClaims{"sub": "u", "aud": "breakglass-ui", "email_verified": false}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), tokenRejectRequiredClaimMismatch)
+	})
+}
@@ -0,0 +1,451 @@
+package breakglass
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"github.com/telekom/k8s-breakglass/pkg/system"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Actions carried by approval links
+const (
+	ApprovalLinkActionApprove = "approve"
+	ApprovalLinkActionReject  = "reject"
+)
+
+const (
+	// DefaultApprovalLinkTTL is used when the configuration does not specify a link validity
+	DefaultApprovalLinkTTL = 30 * time.Minute
+	// MinApprovalLinkKeyLength is the minimum length of the HMAC signing key in bytes
+	MinApprovalLinkKeyLength = 32
+
+	approvalLinkAudience  = "breakglass-approval-link"
+	approvalLinkClaimsKey = "approvalLinkClaims"
+)
+
+var (
+	ErrApprovalLinkInvalid = errors.New("approval link is invalid")
+	ErrApprovalLinkExpired = errors.New("approval link has expired")
+	ErrApprovalLinkUsed    = errors.New("approval link has already been used")
+	ErrApprovalLinkRevoked = errors.New("approval link has been revoked")
+	ErrApprovalLinkDecided = errors.New("session is no longer pending approval")
+)
+
+// ApprovalLinkClaims are the claims of a signed approval link token.
+// The subject is the approver email the link was sent to, the ID identifies the single-use token.
+type ApprovalLinkClaims struct {
+	Session string `json:"session"`
+	Action  string `json:"action"`
+	jwt.RegisteredClaims
+}
+
+// ApprovalLinkSigner issues and verifies HMAC-signed (HS256 JWS) approval link tokens
+type ApprovalLinkSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewApprovalLinkSigner creates a signer for the given HMAC key. A non-positive ttl selects DefaultApprovalLinkTTL.
+func NewApprovalLinkSigner(key []byte, ttl time.Duration) (*ApprovalLinkSigner, error) {
+	if len(key) < MinApprovalLinkKeyLength {
+		return nil, fmt.Errorf("approval link signing key must be at least %d bytes", MinApprovalLinkKeyLength)
+	}
+	if ttl <= 0 {
+		ttl = DefaultApprovalLinkTTL
+	}
+	return &ApprovalLinkSigner{key: key, ttl: ttl, now: time.Now}, nil
+}
+
+// LoadApprovalLinkSigner creates the signer described by the configuration.
+// It returns nil without error when approval links are disabled.
+func LoadApprovalLinkSigner(cfg config.ApprovalLinks) (*ApprovalLinkSigner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.SigningKeyFile == "" {
+		return nil, errors.New("approvalLinks.signingKeyFile is required when approval links are enabled")
+	}
+	key, err := os.ReadFile(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading approval link signing key: %w", err)
+	}
+	var ttl time.Duration
+	if cfg.TTL != "" {
+		if ttl, err = time.ParseDuration(cfg.TTL); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid approvalLinks.ttl %q", cfg.TTL)
+		}
+	}
+	return NewApprovalLinkSigner(bytes.TrimSpace(key), ttl)
+}
+
+// TTL returns how long issued links stay valid
+func (s *ApprovalLinkSigner) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue signs a new single-use link token for the approver to apply action to the session
+func (s *ApprovalLinkSigner) Issue(session, action, approver string) (string, *ApprovalLinkClaims, error) {
+	now := s.now()
+	claims := &ApprovalLinkClaims{
+		Session: session,
+		Action:  action,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   approver,
+			Audience:  jwt.ClaimStrings{approvalLinkAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// Verify checks the signature, audience and expiry of a link token and returns its claims
+func (s *ApprovalLinkSigner) Verify(token string) (*ApprovalLinkClaims, error) {
+	claims := &ApprovalLinkClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		var vErr *jwt.ValidationError
+		if errors.As(err, &vErr) && vErr.Errors == jwt.ValidationErrorExpired {
+			return nil, ErrApprovalLinkExpired
+		}
+		return nil, ErrApprovalLinkInvalid
+	}
+	if !claims.VerifyAudience(approvalLinkAudience, true) || claims.ID == "" || claims.Subject == "" || claims.IssuedAt == nil {
+		return nil, ErrApprovalLinkInvalid
+	}
+	if claims.Action != ApprovalLinkActionApprove && claims.Action != ApprovalLinkActionReject {
+		return nil, ErrApprovalLinkInvalid
+	}
+	return claims, nil
+}
+
+// checkApprovalLinkUsable verifies that the session still accepts the link: it must be pending,
+// and the link must neither have been used nor revoked (individually or together with all links of the session).
+func checkApprovalLinkUsable(bs v1alpha1.BreakglassSession, claims *ApprovalLinkClaims) error {
+	if links := bs.Status.ApprovalLinks; links != nil {
+		for _, l := range links.Links {
+			if l.ID != claims.ID {
+				continue
+			}
+			if !l.UsedAt.IsZero() {
+				return ErrApprovalLinkUsed
+			}
+			if !l.RevokedAt.IsZero() {
+				return ErrApprovalLinkRevoked
+			}
+		}
+		if !links.RevokedAt.IsZero() && !claims.IssuedAt.After(links.RevokedAt.Time) {
+			return ErrApprovalLinkRevoked
+		}
+	}
+	if !IsSessionPendingApproval(bs) {
+		return ErrApprovalLinkDecided
+	}
+	return nil
+}
+
+// approvalLinkRecord returns the status record for the link, adding it if necessary
+func approvalLinkRecord(bs *v1alpha1.BreakglassSession, claims *ApprovalLinkClaims) *v1alpha1.ApprovalLinkRecord {
+	if bs.Status.ApprovalLinks == nil {
+		bs.Status.ApprovalLinks = &v1alpha1.ApprovalLinksStatus{}
+	}
+	links := bs.Status.ApprovalLinks
+	for i := range links.Links {
+		if links.Links[i].ID == claims.ID {
+			return &links.Links[i]
+		}
+	}
+	links.Links = append(links.Links, v1alpha1.ApprovalLinkRecord{ID: claims.ID, Action: claims.Action, Approver: claims.Subject})
+	return &links.Links[len(links.Links)-1]
+}
+
+// approvalLinkErrorStatus maps approval link errors to HTTP status codes and metric results
+func approvalLinkErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrApprovalLinkExpired):
+		return http.StatusGone, "expired"
+	case errors.Is(err, ErrApprovalLinkUsed):
+		return http.StatusGone, "used"
+	case errors.Is(err, ErrApprovalLinkRevoked):
+		return http.StatusGone, "revoked"
+	case errors.Is(err, ErrApprovalLinkDecided):
+		return http.StatusConflict, "decided"
+	case errors.Is(err, ErrSessionNotFound):
+		return http.StatusNotFound, "invalid"
+	default:
+		return http.StatusBadRequest, "invalid"
+	}
+}
+
+// approvalLinkCondition returns the session condition applied by a link action
+func approvalLinkCondition(action string) v1alpha1.BreakglassSessionConditionType {
+	if action == ApprovalLinkActionReject {
+		return v1alpha1.SessionConditionTypeRejected
+	}
+	return v1alpha1.SessionConditionTypeApproved
+}
+
+// WithApprovalLinks enables signed one-click approve/reject links in request emails
+func (b *BreakglassSessionController) WithApprovalLinks(signer *ApprovalLinkSigner) *BreakglassSessionController {
+	b.approvalLinks = signer
+	return b
+}
+
+// approvalLinkURLs issues approve and reject links for a single approver of the session.
+// The token is passed in the URL fragment so it never reaches server or proxy access logs.
+func (wc BreakglassSessionController) approvalLinkURLs(bs v1alpha1.BreakglassSession, approver string) (approveURL, rejectURL string, expiresAt time.Time, err error) {
+	urls := map[string]string{}
+	for _, action := range []string{ApprovalLinkActionApprove, ApprovalLinkActionReject} {
+		token, claims, err := wc.approvalLinks.Issue(bs.Name, action, approver)
+		if err != nil {
+			return "", "", time.Time{}, err
+		}
+		urls[action] = fmt.Sprintf("%s/approvals/link#token=%s", wc.config.Frontend.BaseURL, token)
+		expiresAt = claims.ExpiresAt.Time
+	}
+	for action := range urls {
+		metrics.ApprovalLinksIssued.WithLabelValues(action).Inc()
+	}
+	return urls[ApprovalLinkActionApprove], urls[ApprovalLinkActionReject], expiresAt, nil
+}
+
+// verifyApprovalLink checks a link token against the current state of its session
+func (wc BreakglassSessionController) verifyApprovalLink(c *gin.Context, token string) (*ApprovalLinkClaims, v1alpha1.BreakglassSession, error) {
+	if wc.approvalLinks == nil {
+		return nil, v1alpha1.BreakglassSession{}, ErrApprovalLinkInvalid
+	}
+	claims, err := wc.approvalLinks.Verify(token)
+	if err != nil {
+		return nil, v1alpha1.BreakglassSession{}, err
+	}
+	bs, err := wc.sessionManager.GetBreakglassSessionByName(c.Request.Context(), claims.Session)
+	if err != nil {
+		return claims, bs, ErrSessionNotFound
+	}
+	return claims, bs, checkApprovalLinkUsable(bs, claims)
+}
+
+// handleConfirmApprovalLink applies the decision of an approval link after the approver
+// re-authenticated. The decision goes through the regular approve/reject path, so the caller
+// must still be an approver of the session and must be the approver the link was issued to.
+func (wc BreakglassSessionController) handleConfirmApprovalLink(c *gin.Context) {
+	reqLog := system.GetReqLogger(c, wc.log)
+	reqLog = system.EnrichReqLoggerWithAuth(c, reqLog)
+
+	if wc.approvalLinks == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "approval links are disabled"})
+		return
+	}
+
+	var payload struct {
+		Token  string `json:"token"`
+		Reason string `json:"reason,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	claims, bs, err := wc.verifyApprovalLink(c, payload.Token)
+	action := "unknown"
+	if claims != nil {
+		action = claims.Action
+	}
+	if err == nil && bs.Name != c.Param("name") {
+		err = ErrApprovalLinkInvalid
+	}
+	if err != nil {
+		status, result := approvalLinkErrorStatus(err)
+		metrics.ApprovalLinkRedemptions.WithLabelValues(action, result).Inc()
+		reqLog.Infow("Rejected approval link confirmation", "session", c.Param("name"), "result", result)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, err := wc.identityProvider.GetEmail(c)
+	if err != nil || !strings.EqualFold(email, claims.Subject) {
+		metrics.ApprovalLinkRedemptions.WithLabelValues(action, "forbidden").Inc()
+		reqLog.Warnw("Approval link used by a different identity", "session", bs.Name, "linkApprover", claims.Subject, "caller", email)
+		c.JSON(http.StatusForbidden, gin.H{"error": "approval link was issued to a different approver"})
+		return
+	}
+
+	// Hand the optional reason to the regular approval path, which records the link use on the session
+	body, _ := json.Marshal(map[string]string{"reason": payload.Reason})
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Set(approvalLinkClaimsKey, claims)
+	wc.setSessionStatus(c, approvalLinkCondition(claims.Action))
+
+	result := "redeemed"
+	if c.Writer.Status() != http.StatusOK {
+		result = "failed"
+	}
+	metrics.ApprovalLinkRedemptions.WithLabelValues(action, result).Inc()
+	reqLog.Infow("Processed approval link confirmation", "session", bs.Name, "action", action, "result", result)
+}
+
+// recordApprovalLinkUse marks the approval link from the request context (if any) as used
+func recordApprovalLinkUse(c *gin.Context, bs *v1alpha1.BreakglassSession, usedBy string) {
+	v, ok := c.Get(approvalLinkClaimsKey)
+	if !ok {
+		return
+	}
+	claims, ok := v.(*ApprovalLinkClaims)
+	if !ok {
+		return
+	}
+	rec := approvalLinkRecord(bs, claims)
+	rec.UsedAt = metav1.Now()
+	rec.UsedBy = usedBy
+}
+
+// handleRevokeApprovalLinks invalidates all approval links issued so far for the session.
+// The requester and the approvers of the session may revoke links, e.g. when a request email was forwarded.
+func (wc BreakglassSessionController) handleRevokeApprovalLinks(c *gin.Context) {
+	reqLog := system.GetReqLogger(c, wc.log)
+	reqLog = system.EnrichReqLoggerWithAuth(c, reqLog)
+
+	bs, err := wc.sessionManager.GetBreakglassSessionByName(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		reqLog.Errorw("error while getting breakglass session", "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+
+	email, _ := wc.identityProvider.GetEmail(c)
+	if email == "" || (!strings.EqualFold(email, bs.Spec.User) && !wc.isSessionApprover(c, bs)) {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	if bs.Status.ApprovalLinks == nil {
+		bs.Status.ApprovalLinks = &v1alpha1.ApprovalLinksStatus{}
+	}
+	bs.Status.ApprovalLinks.RevokedAt = metav1.Now()
+	bs.Status.ApprovalLinks.RevokedBy = email
+	if err := wc.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
+		reqLog.Errorw("error while updating breakglass session", "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	reqLog.Infow("Revoked approval links of session", "session", bs.Name, "revokedBy", email)
+	c.JSON(http.StatusOK, bs)
+}
+
+// ApprovalLinkPreview is the session summary shown on the approval link confirmation page
+type ApprovalLinkPreview struct {
+	Session            string       `json:"session"`
+	Action             string       `json:"action"`
+	Approver           string       `json:"approver"`
+	ExpiresAt          time.Time    `json:"expiresAt"`
+	Requester          string       `json:"requester"`
+	Cluster            string       `json:"cluster"`
+	Group              string       `json:"group"`
+	Reason             string       `json:"reason,omitempty"`
+	ScheduledStartTime *metav1.Time `json:"scheduledStartTime,omitempty"`
+	State              string       `json:"state"`
+}
+
+// ApprovalLinkController serves the unauthenticated approval link endpoints. Possession of a valid
+// link only allows looking at the request it was issued for and revoking the link; applying the
+// decision requires an authenticated call to the session controller.
+type ApprovalLinkController struct {
+	sessions *BreakglassSessionController
+}
+
+// NewApprovalLinkController creates the public approval link endpoints for the session controller
+func NewApprovalLinkController(sessions *BreakglassSessionController) *ApprovalLinkController {
+	return &ApprovalLinkController{sessions: sessions}
+}
+
+func (ApprovalLinkController) BasePath() string {
+	return "approvalLinks"
+}
+
+// Handlers returns no middleware: the link token itself authorizes these endpoints
+func (ApprovalLinkController) Handlers() []gin.HandlerFunc {
+	return []gin.HandlerFunc{}
+}
+
+func (lc *ApprovalLinkController) Register(rg *gin.RouterGroup) error {
+	rg.POST("preview", instrumentedHandler("handlePreviewApprovalLink", lc.handlePreview)) // Show the request behind a link
+	rg.POST("revoke", instrumentedHandler("handleRevokeApprovalLink", lc.handleRevoke))    // Revoke a single link
+	return nil
+}
+
+type approvalLinkTokenRequest struct {
+	Token string `json:"token"`
+}
+
+func (lc *ApprovalLinkController) handlePreview(c *gin.Context) {
+	var req approvalLinkTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+	claims, bs, err := lc.sessions.verifyApprovalLink(c, req.Token)
+	if err != nil {
+		status, _ := approvalLinkErrorStatus(err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ApprovalLinkPreview{
+		Session:            bs.Name,
+		Action:             claims.Action,
+		Approver:           claims.Subject,
+		ExpiresAt:          claims.ExpiresAt.Time,
+		Requester:          bs.Spec.User,
+		Cluster:            bs.Spec.Cluster,
+		Group:              bs.Spec.GrantedGroup,
+		Reason:             bs.Spec.RequestReason,
+		ScheduledStartTime: bs.Spec.ScheduledStartTime,
+		State:              string(bs.Status.State),
+	})
+}
+
+func (lc *ApprovalLinkController) handleRevoke(c *gin.Context) {
+	reqLog := system.GetReqLogger(c, lc.sessions.log)
+
+	var req approvalLinkTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+	claims, bs, err := lc.sessions.verifyApprovalLink(c, req.Token)
+	if err != nil && !errors.Is(err, ErrApprovalLinkDecided) {
+		status, _ := approvalLinkErrorStatus(err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	approvalLinkRecord(&bs, claims).RevokedAt = metav1.Now()
+	if err := lc.sessions.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
+		reqLog.Errorw("error while updating breakglass session", "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	reqLog.Infow("Revoked approval link", "session", bs.Name, "action", claims.Action, "approver", claims.Subject)
+	c.JSON(http.StatusOK, gin.H{"revoked": true})
+}
@@ -0,0 +1,316 @@
+package breakglass
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testApprovalLinkKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestApprovalLinkSigner(t *testing.T) *ApprovalLinkSigner {
+	t.Helper()
+	signer, err := NewApprovalLinkSigner(testApprovalLinkKey, time.Hour)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestApprovalLinkSigner_IssueAndVerify(t *testing.T) {
+	signer := newTestApprovalLinkSigner(t)
+
+	token, issued, err := signer.Issue("session-1", ApprovalLinkActionApprove, "approver@example.com")
+	require.NoError(t, err)
+
+	claims, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", claims.Session)
+	assert.Equal(t, ApprovalLinkActionApprove, claims.Action)
+	assert.Equal(t, "approver@example.com", claims.Subject)
+	assert.Equal(t, issued.ID, claims.ID)
+
+	// Every link gets an own identifier
+	_, other, err := signer.Issue("session-1", ApprovalLinkActionApprove, "approver@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, issued.ID, other.ID)
+}
+
+func TestApprovalLinkSigner_RejectsInvalidTokens(t *testing.T) {
+	signer := newTestApprovalLinkSigner(t)
+	token, _, err := signer.Issue("session-1", ApprovalLinkActionReject, "approver@example.com")
+	require.NoError(t, err)
+
+	otherSigner, err := NewApprovalLinkSigner([]byte(strings.Repeat("x", MinApprovalLinkKeyLength)), time.Hour)
+	require.NoError(t, err)
+	_, err = otherSigner.Verify(token)
+	assert.ErrorIs(t, err, ErrApprovalLinkInvalid)
+
+	_, err = signer.Verify(token + "x")
+	assert.ErrorIs(t, err, ErrApprovalLinkInvalid)
+
+	_, err = signer.Verify("not-a-token")
+	assert.ErrorIs(t, err, ErrApprovalLinkInvalid)
+
+	signer.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expired, _, err := signer.Issue("session-1", ApprovalLinkActionApprove, "approver@example.com")
+	require.NoError(t, err)
+	_, err = signer.Verify(expired)
+	assert.ErrorIs(t, err, ErrApprovalLinkExpired)
+}
+
+func TestNewApprovalLinkSigner_KeyAndTTL(t *testing.T) {
+	_, err := NewApprovalLinkSigner([]byte("short"), time.Hour)
+	assert.Error(t, err)
+
+	signer, err := NewApprovalLinkSigner(testApprovalLinkKey, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultApprovalLinkTTL, signer.TTL())
+}
+
+func TestLoadApprovalLinkSigner(t *testing.T) {
+	signer, err := LoadApprovalLinkSigner(config.ApprovalLinks{})
+	assert.NoError(t, err)
+	assert.Nil(t, signer)
+
+	_, err = LoadApprovalLinkSigner(config.ApprovalLinks{Enabled: true})
+	assert.Error(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, append(testApprovalLinkKey, '\n'), 0o600))
+
+	signer, err = LoadApprovalLinkSigner(config.ApprovalLinks{Enabled: true, SigningKeyFile: keyFile, TTL: "10m"})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, signer.TTL())
+
+	_, err = LoadApprovalLinkSigner(config.ApprovalLinks{Enabled: true, SigningKeyFile: keyFile, TTL: "soon"})
+	assert.Error(t, err)
+}
+
+func TestCheckApprovalLinkUsable(t *testing.T) {
+	signer := newTestApprovalLinkSigner(t)
+	_, claims, err := signer.Issue("session-1", ApprovalLinkActionApprove, "approver@example.com")
+	require.NoError(t, err)
+
+	bs := v1alpha1.BreakglassSession{ObjectMeta: metav1.ObjectMeta{Name: "session-1"}}
+	bs.Status.State = v1alpha1.SessionStatePending
+	assert.NoError(t, checkApprovalLinkUsable(bs, claims))
+
+	used := bs.DeepCopy()
+	approvalLinkRecord(used, claims).UsedAt = metav1.Now()
+	assert.ErrorIs(t, checkApprovalLinkUsable(*used, claims), ErrApprovalLinkUsed)
+
+	revoked := bs.DeepCopy()
+	approvalLinkRecord(revoked, claims).RevokedAt = metav1.Now()
+	assert.ErrorIs(t, checkApprovalLinkUsable(*revoked, claims), ErrApprovalLinkRevoked)
+
+	// Revoking all links only affects links issued before the revocation
+	allRevoked := bs.DeepCopy()
+	allRevoked.Status.ApprovalLinks = &v1alpha1.ApprovalLinksStatus{RevokedAt: metav1.NewTime(time.Now().Add(time.Minute))}
+	assert.ErrorIs(t, checkApprovalLinkUsable(*allRevoked, claims), ErrApprovalLinkRevoked)
+	allRevoked.Status.ApprovalLinks.RevokedAt = metav1.NewTime(time.Now().Add(-time.Minute))
+	assert.NoError(t, checkApprovalLinkUsable(*allRevoked, claims))
+
+	decided := bs.DeepCopy()
+	decided.Status.State = v1alpha1.SessionStateApproved
+	assert.ErrorIs(t, checkApprovalLinkUsable(*decided, claims), ErrApprovalLinkDecided)
+}
+
+type approvalLinkTestEnv struct {
+	ctrl    *BreakglassSessionController
+	signer  *ApprovalLinkSigner
+	engine  *gin.Engine
+	manager *SessionManager
+	email   string
+}
+
+func newApprovalLinkTestEnv(t *testing.T) *approvalLinkTestEnv {
+	t.Helper()
+	builder := fake.NewClientBuilder().WithScheme(Scheme)
+	for index, fn := range sessionIndexFunctions {
+		builder.WithIndex(&v1alpha1.BreakglassSession{}, index, fn)
+	}
+	builder.WithObjects(&v1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "esc-links"},
+		Spec: v1alpha1.BreakglassEscalationSpec{
+			Allowed:        v1alpha1.BreakglassEscalationAllowed{Clusters: []string{"prod"}, Groups: []string{"system:authenticated"}},
+			EscalatedGroup: "admin",
+			Approvers:      v1alpha1.BreakglassEscalationApprovers{Users: []string{"approver@example.com", "other@example.com"}},
+		},
+	})
+	builder.WithObjects(&v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "link-session"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+		Status: v1alpha1.BreakglassSessionStatus{
+			State:     v1alpha1.SessionStatePending,
+			TimeoutAt: metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	})
+	cli := builder.WithStatusSubresource(&v1alpha1.BreakglassSession{}).Build()
+	sesmanager := SessionManager{Client: cli}
+	escmanager := EscalationManager{Client: cli}
+
+	env := &approvalLinkTestEnv{signer: newTestApprovalLinkSigner(t), manager: &sesmanager, email: "approver@example.com"}
+	env.ctrl = NewBreakglassSessionController(zap.NewNop().Sugar(), config.Config{}, &sesmanager, &escmanager, func(c *gin.Context) {
+		c.Set("email", env.email)
+		c.Set("username", env.email)
+		c.Next()
+	}, "/config/config.yaml", nil, cli).WithApprovalLinks(env.signer)
+	env.ctrl.getUserGroupsFn = func(ctx context.Context, cug ClusterUserGroup) ([]string, error) {
+		return []string{"system:authenticated"}, nil
+	}
+
+	env.engine = gin.New()
+	require.NoError(t, env.ctrl.Register(env.engine.Group("/breakglassSessions", env.ctrl.Handlers()...)))
+	links := NewApprovalLinkController(env.ctrl)
+	require.NoError(t, links.Register(env.engine.Group("/approvalLinks", links.Handlers()...)))
+	return env
+}
+
+func (env *approvalLinkTestEnv) post(t *testing.T, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	w := httptest.NewRecorder()
+	env.engine.ServeHTTP(w, req)
+	return w
+}
+
+func (env *approvalLinkTestEnv) session(t *testing.T) v1alpha1.BreakglassSession {
+	t.Helper()
+	bs, err := env.manager.GetBreakglassSessionByName(context.Background(), "link-session")
+	require.NoError(t, err)
+	return bs
+}
+
+func TestApprovalLink_ConfirmApprovesOnce(t *testing.T) {
+	env := newApprovalLinkTestEnv(t)
+	token, claims, err := env.signer.Issue("link-session", ApprovalLinkActionApprove, "Approver@example.com")
+	require.NoError(t, err)
+
+	// The confirmation page can look at the request before signing in
+	w := env.post(t, "/approvalLinks/preview", gin.H{"token": token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview ApprovalLinkPreview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, "link-session", preview.Session)
+	assert.Equal(t, ApprovalLinkActionApprove, preview.Action)
+	assert.Equal(t, "user@example.com", preview.Requester)
+
+	path := "/breakglassSessions/link-session/approvalLink"
+	w = env.post(t, path, gin.H{"token": token, "reason": "looks good"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	bs := env.session(t)
+	assert.Equal(t, v1alpha1.SessionStateApproved, bs.Status.State)
+	assert.Equal(t, "approver@example.com", bs.Status.Approver)
+	assert.Equal(t, "looks good", bs.Status.ApprovalReason)
+	require.NotNil(t, bs.Status.ApprovalLinks)
+	require.Len(t, bs.Status.ApprovalLinks.Links, 1)
+	assert.Equal(t, claims.ID, bs.Status.ApprovalLinks.Links[0].ID)
+	assert.Equal(t, "approver@example.com", bs.Status.ApprovalLinks.Links[0].UsedBy)
+	assert.False(t, bs.Status.ApprovalLinks.Links[0].UsedAt.IsZero())
+
+	// Links are single-use
+	w = env.post(t, path, gin.H{"token": token})
+	assert.Equal(t, http.StatusGone, w.Code)
+	w = env.post(t, "/approvalLinks/preview", gin.H{"token": token})
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
+func TestApprovalLink_ConfirmRequiresLinkApprover(t *testing.T) {
+	env := newApprovalLinkTestEnv(t)
+	token, _, err := env.signer.Issue("link-session", ApprovalLinkActionReject, "approver@example.com")
+	require.NoError(t, err)
+
+	// A different approver cannot use a forwarded link
+	env.email = "other@example.com"
+	w := env.post(t, "/breakglassSessions/link-session/approvalLink", gin.H{"token": token})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// The link must belong to the session in the path
+	env.email = "approver@example.com"
+	w = env.post(t, "/breakglassSessions/another-session/approvalLink", gin.H{"token": token})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = env.post(t, "/breakglassSessions/link-session/approvalLink", gin.H{"token": token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, v1alpha1.SessionStateRejected, env.session(t).Status.State)
+}
+
+func TestApprovalLink_Revocation(t *testing.T) {
+	env := newApprovalLinkTestEnv(t)
+	first, _, err := env.signer.Issue("link-session", ApprovalLinkActionApprove, "approver@example.com")
+	require.NoError(t, err)
+	second, _, err := env.signer.Issue("link-session", ApprovalLinkActionApprove, "other@example.com")
+	require.NoError(t, err)
+
+	// Possession of a link is enough to revoke it
+	w := env.post(t, "/approvalLinks/revoke", gin.H{"token": first})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.post(t, "/breakglassSessions/link-session/approvalLink", gin.H{"token": first})
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	// The requester revokes all remaining links of the session
+	env.email = "user@example.com"
+	w = env.post(t, "/breakglassSessions/link-session/revokeApprovalLinks", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	env.email = "other@example.com"
+	w = env.post(t, "/breakglassSessions/link-session/approvalLink", gin.H{"token": second})
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	bs := env.session(t)
+	assert.Equal(t, v1alpha1.SessionStatePending, bs.Status.State)
+	require.NotNil(t, bs.Status.ApprovalLinks)
+	assert.Equal(t, "user@example.com", bs.Status.ApprovalLinks.RevokedBy)
+	require.Len(t, bs.Status.ApprovalLinks.Links, 1)
+	assert.False(t, bs.Status.ApprovalLinks.Links[0].RevokedAt.IsZero())
+
+	// Unrelated users cannot revoke the links of a session
+	env.email = "stranger@example.com"
+	w = env.post(t, "/breakglassSessions/link-session/revokeApprovalLinks", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSendOnRequestEmail_IncludesApprovalLinksPerApprover(t *testing.T) {
+	ctrl, sender := newCalendarTestController(t)
+	ctrl.approvalLinks = newTestApprovalLinkSigner(t)
+
+	bs := v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "mail-session"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+	}
+	require.NoError(t, ctrl.sendOnRequestEmail(bs, "user@example.com", "User", []string{"a@example.com", "b@example.com"}, nil, nil))
+
+	msgs := sender.waitFor(t, 2)
+	require.Len(t, msgs, 2)
+	for _, msg := range msgs {
+		require.Len(t, msg.Receivers, 1)
+		prefix := fmt.Sprintf("%s/approvals/link#token=", ctrl.config.Frontend.BaseURL)
+		require.Contains(t, msg.HTML, prefix)
+
+		token := msg.HTML[strings.Index(msg.HTML, prefix)+len(prefix):]
+		token = token[:strings.IndexByte(token, '"')]
+		claims, err := ctrl.approvalLinks.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, "mail-session", claims.Session)
+		assert.Equal(t, msg.Receivers[0], claims.Subject)
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"time"
 
 	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
 	"github.com/telekom/k8s-breakglass/pkg/metrics"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/discovery"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
@@ -28,6 +29,8 @@ type ClusterConfigChecker struct {
 	Interval      time.Duration
 	Recorder      record.EventRecorder
 	LeaderElected <-chan struct{} // Optional: signal when leadership acquired (nil = start immediately for backward compatibility)
+	// CertificateExpiryWarning is how long before expiry certificates are reported (0 = DefaultCertificateExpiryWarning)
+	CertificateExpiryWarning time.Duration
 }
 
 const ClusterConfigCheckInterval = 10 * time.Minute
@@ -83,88 +86,253 @@ func (ccc ClusterConfigChecker) runOnce(ctx context.Context, lg *zap.SugaredLogg
 		cc := item
 		// metric: one check attempted (label by cluster name)
 		metrics.ClusterConfigsChecked.WithLabelValues(cc.Name).Inc()
-		// if no kubeconfigSecretRef is set, log a warning
-		ref := cc.Spec.KubeconfigSecretRef
-		if ref.Name == "" || ref.Namespace == "" {
-			lg.Warnw("ClusterConfig has no kubeconfigSecretRef configured",
+		var restCfg *rest.Config
+		successMsg := "Kubeconfig validated and cluster reachable"
+		switch {
+		case cc.Spec.Auth != nil:
+			// build the client from the referenced credential; secret lookups are part of this
+			cfg, _, err := cluster.RESTConfigFromAuth(ctx, ccc.Client, cc.Spec.Auth)
+			if err != nil {
+				msg := "Cluster credentials invalid: " + err.Error()
+				lg.Warnw(msg, "cluster", cc.Name)
+				if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
+					lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+				}
+				metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
+				continue
+			}
+			restCfg = cfg
+			successMsg = "Cluster credentials validated and cluster reachable"
+		case cc.Spec.KubeconfigSecretRef != nil && cc.Spec.KubeconfigSecretRef.Name != "" && cc.Spec.KubeconfigSecretRef.Namespace != "":
+			cfg, ok := ccc.kubeconfigRESTConfig(ctx, &cc, lg)
+			if !ok {
+				continue
+			}
+			restCfg = cfg
+		default:
+			// neither kubeconfigSecretRef nor auth is set, log a warning
+			lg.Warnw("ClusterConfig has neither kubeconfigSecretRef nor auth configured",
 				"cluster", cc.Name,
 				"namespace", cc.Namespace)
 			continue
 		}
-		// fetch secret
-		key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
-		sec := corev1.Secret{}
-		if err := ccc.Client.Get(ctx, key, &sec); err != nil {
-			msg := "Referenced kubeconfig secret missing or unreadable"
-			lg.Warnw(msg,
-				"cluster", cc.Name,
-				"secret", ref.Name,
-				"secretNamespace", ref.Namespace,
-				"error", err)
-			// update status and emit event
-			if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg+": "+err.Error(), corev1.EventTypeWarning, lg); err2 != nil {
+		cc.Status.APIEndpoint = restCfg.Host
+		ccc.setCertificatesCondition(&cc, restCfg, lg)
+
+		// discovery client to attempt server version call
+		probeStart := time.Now()
+		serverVersion, err := CheckClusterReachable(restCfg)
+		if err != nil {
+			msg := "cluster unreachable: " + err.Error()
+			lg.Warnw(msg, "cluster", cc.Name)
+			if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
 				lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
 			}
 			metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
 			continue
 		}
-		// check for kubeconfig key (cluster-api provides key 'value')
-		keyName := "value"
-		if ref.Key != "" {
-			keyName = ref.Key
+		latency := time.Since(probeStart)
+		now := metav1.Now()
+		cc.Status.LastProbeTime = &now
+		cc.Status.LastProbeLatency = &metav1.Duration{Duration: latency}
+		metrics.ClusterConfigProbeLatency.WithLabelValues(cc.Name).Set(latency.Seconds())
+		if serverVersion != nil {
+			cc.Status.KubernetesVersion = serverVersion.GitVersion
 		}
-		if _, ok := sec.Data[keyName]; !ok {
-			// If secret exists but missing key, warn with metadata
-			msg := "Referenced kubeconfig secret missing key: " + keyName
-			lg.Warnw(msg,
-				"cluster", cc.Name,
-				"secret", ref.Name,
-				"secretNamespace", ref.Namespace,
-				"secretCreation", sec.CreationTimestamp.Time.Format(time.RFC3339))
+
+		// permission self-test: the credentials must hold the rights breakglass needs, and should hold no more
+		missing, excess, permErr := CheckSpokePermissions(ctx, restCfg, cc.Spec.AccessReviewMode)
+		if permErr == nil && len(missing) > 0 {
+			msg := "cluster credentials missing permissions: " + strings.Join(missing, ", ")
+			lg.Warnw(msg, "cluster", cc.Name, "accessReviewMode", cc.Spec.AccessReviewMode)
 			if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
 				lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
 			}
 			metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
 			continue
 		}
-		// Good: secret exists and has key
-		lg.Debugw("ClusterConfig kubeconfig validated",
-			"cluster", cc.Name,
-			"secret", ref.Name,
-			"secretNamespace", ref.Namespace)
+		ccc.setLeastPrivilegeCondition(&cc, excess, permErr, lg)
 
-		// Try to parse kubeconfig and attempt a simple discovery to verify reachability
-		kubecfgBytes := sec.Data[keyName]
-		// Build rest.Config from kubeconfig bytes via overridable function for testing
-		restCfg, err := RestConfigFromKubeConfig(kubecfgBytes)
-		if err != nil {
-			msg := "kubeconfig parse failed: " + err.Error()
-			lg.Warnw(msg, "cluster", cc.Name)
-			if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
-				lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+		// webhook probe: a cluster that does not consult breakglass never sees granted sessions
+		if configured, err := ProbeAuthorizationWebhook(ctx, restCfg); err != nil {
+			lg.Warnw("Authorization webhook probe failed", "cluster", cc.Name, "error", err)
+			cc.Status.AuthorizationWebhookConfigured = nil
+			metrics.ClusterConfigAuthorizationWebhookConfigured.DeleteLabelValues(cc.Name)
+		} else {
+			if !configured {
+				lg.Warnw("Cluster does not consult the breakglass authorization webhook", "cluster", cc.Name)
 			}
-			metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
-			continue
-		}
-		// discovery client to attempt server version call
-		if err := CheckClusterReachable(restCfg); err != nil {
-			msg := "cluster unreachable: " + err.Error()
-			lg.Warnw(msg, "cluster", cc.Name)
-			if err2 := ccc.setStatusAndEvent(ctx, &cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
-				lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+			cc.Status.AuthorizationWebhookConfigured = &configured
+			gauge := 0.0
+			if configured {
+				gauge = 1
 			}
-			metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
-			continue
+			metrics.ClusterConfigAuthorizationWebhookConfigured.WithLabelValues(cc.Name).Set(gauge)
 		}
 
 		// Success: update status Ready and emit Normal event
-		if err2 := ccc.setStatusAndEvent(ctx, &cc, "Ready", "Kubeconfig validated and cluster reachable", corev1.EventTypeNormal, lg); err2 != nil {
+		if err2 := ccc.setStatusAndEvent(ctx, &cc, "Ready", successMsg, corev1.EventTypeNormal, lg); err2 != nil {
 			lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
 		}
 	}
 	lg.Debug("ClusterConfig validation check completed")
 }
 
+// kubeconfigRESTConfig fetches and parses the kubeconfig secret of the ClusterConfig. On failure the
+// status is updated and false is returned.
+func (ccc ClusterConfigChecker) kubeconfigRESTConfig(ctx context.Context, cc *telekomv1alpha1.ClusterConfig, lg *zap.SugaredLogger) (*rest.Config, bool) {
+	ref := cc.Spec.KubeconfigSecretRef
+	// fetch secret
+	key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+	sec := corev1.Secret{}
+	if err := ccc.Client.Get(ctx, key, &sec); err != nil {
+		msg := "Referenced kubeconfig secret missing or unreadable"
+		lg.Warnw(msg,
+			"cluster", cc.Name,
+			"secret", ref.Name,
+			"secretNamespace", ref.Namespace,
+			"error", err)
+		// update status and emit event
+		if err2 := ccc.setStatusAndEvent(ctx, cc, "Failed", msg+": "+err.Error(), corev1.EventTypeWarning, lg); err2 != nil {
+			lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+		}
+		metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
+		return nil, false
+	}
+	// check for kubeconfig key (cluster-api provides key 'value')
+	keyName := "value"
+	if ref.Key != "" {
+		keyName = ref.Key
+	}
+	if _, ok := sec.Data[keyName]; !ok {
+		// If secret exists but missing key, warn with metadata
+		msg := "Referenced kubeconfig secret missing key: " + keyName
+		lg.Warnw(msg,
+			"cluster", cc.Name,
+			"secret", ref.Name,
+			"secretNamespace", ref.Namespace,
+			"secretCreation", sec.CreationTimestamp.Time.Format(time.RFC3339))
+		if err2 := ccc.setStatusAndEvent(ctx, cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
+			lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+		}
+		metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
+		return nil, false
+	}
+	// Good: secret exists and has key
+	lg.Debugw("ClusterConfig kubeconfig validated",
+		"cluster", cc.Name,
+		"secret", ref.Name,
+		"secretNamespace", ref.Namespace)
+
+	// Build rest.Config from kubeconfig bytes via overridable function for testing
+	restCfg, err := RestConfigFromKubeConfig(sec.Data[keyName])
+	if err != nil {
+		msg := "kubeconfig parse failed: " + err.Error()
+		lg.Warnw(msg, "cluster", cc.Name)
+		if err2 := ccc.setStatusAndEvent(ctx, cc, "Failed", msg, corev1.EventTypeWarning, lg); err2 != nil {
+			lg.Warnw("failed to persist status/event for ClusterConfig", "cluster", cc.Name, "error", err2)
+		}
+		metrics.ClusterConfigsFailed.WithLabelValues(cc.Name).Inc()
+		return nil, false
+	}
+	return restCfg, true
+}
+
+// setLeastPrivilegeCondition records the outcome of the permission self-test on the ClusterConfig.
+// Excess privileges are reported as a warning; they do not make the ClusterConfig unusable.
+func (ccc ClusterConfigChecker) setLeastPrivilegeCondition(cc *telekomv1alpha1.ClusterConfig, excess []string, selfTestErr error, lg *zap.SugaredLogger) {
+	condition := metav1.Condition{
+		Type:               string(telekomv1alpha1.ClusterConfigConditionLeastPrivilege),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cc.Generation,
+		Reason:             "LeastPrivilege",
+		Message:            "Cluster credentials hold only the permissions breakglass needs",
+	}
+	switch {
+	case selfTestErr != nil:
+		lg.Warnw("ClusterConfig permission self-test failed", "cluster", cc.Name, "error", selfTestErr)
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "SelfTestFailed"
+		condition.Message = "Permission self-test failed: " + selfTestErr.Error()
+	case len(excess) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ExcessPrivileges"
+		condition.Message = "Cluster credentials hold permissions breakglass does not need: " + strings.Join(excess, ", ")
+		lg.Warnw(condition.Message, "cluster", cc.Name)
+	}
+	// only emit the warning when the finding changes to avoid an event every check interval
+	previous := apimeta.FindStatusCondition(cc.Status.Conditions, condition.Type)
+	changed := previous == nil || previous.Status != condition.Status || previous.Message != condition.Message
+	apimeta.SetStatusCondition(&cc.Status.Conditions, condition)
+	if changed && condition.Reason == "ExcessPrivileges" && ccc.Recorder != nil {
+		ccc.Recorder.Event(cc, corev1.EventTypeWarning, "ClusterConfigExcessPrivileges", condition.Message)
+	}
+}
+
+// setCertificatesCondition records the expiry of the certificates in the cluster credentials and warns
+// when one expires within the warning threshold. Like LeastPrivilege it does not affect Ready.
+func (ccc ClusterConfigChecker) setCertificatesCondition(cc *telekomv1alpha1.ClusterConfig, cfg *rest.Config, lg *zap.SugaredLogger) {
+	condition := metav1.Condition{
+		Type:               string(telekomv1alpha1.ClusterConfigConditionCertificatesValid),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cc.Generation,
+		Reason:             "CertificatesValid",
+	}
+	clientExpiry, caExpiry, err := certificateExpiries(cfg)
+	if err != nil {
+		lg.Warnw("Failed to read certificates of cluster credentials", "cluster", cc.Name, "error", err)
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "CertificateParseFailed"
+		condition.Message = "Failed to read certificates: " + err.Error()
+		apimeta.SetStatusCondition(&cc.Status.Conditions, condition)
+		return
+	}
+	threshold := ccc.CertificateExpiryWarning
+	if threshold == 0 {
+		threshold = DefaultCertificateExpiryWarning
+	}
+	now := time.Now()
+	var expiring []string
+	record := func(name string, expiry time.Time) *metav1.Time {
+		if expiry.IsZero() {
+			metrics.ClusterConfigCertificateExpiry.DeleteLabelValues(cc.Name, name)
+			return nil
+		}
+		metrics.ClusterConfigCertificateExpiry.WithLabelValues(cc.Name, name).Set(float64(expiry.Unix()))
+		switch {
+		case !expiry.After(now):
+			condition.Reason = "CertificateExpired"
+			expiring = append(expiring, fmt.Sprintf("%s expired at %s", name, expiry.UTC().Format(time.RFC3339)))
+		case expiry.Sub(now) < threshold:
+			if condition.Reason != "CertificateExpired" {
+				condition.Reason = "CertificateExpiring"
+			}
+			expiring = append(expiring, fmt.Sprintf("%s expires at %s", name, expiry.UTC().Format(time.RFC3339)))
+		}
+		t := metav1.NewTime(expiry)
+		return &t
+	}
+	cc.Status.ClientCertificateExpiry = record("client", clientExpiry)
+	cc.Status.CertificateAuthorityExpiry = record("ca", caExpiry)
+	switch {
+	case len(expiring) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Message = "Cluster credential certificates need renewal: " + strings.Join(expiring, ", ")
+		lg.Warnw(condition.Message, "cluster", cc.Name)
+	case clientExpiry.IsZero() && caExpiry.IsZero():
+		condition.Message = "Cluster credentials contain no certificates"
+	default:
+		condition.Message = fmt.Sprintf("No certificate expires within %s", threshold)
+	}
+	// only emit the warning when the finding changes to avoid an event every check interval
+	previous := apimeta.FindStatusCondition(cc.Status.Conditions, condition.Type)
+	changed := previous == nil || previous.Status != condition.Status || previous.Message != condition.Message
+	apimeta.SetStatusCondition(&cc.Status.Conditions, condition)
+	if changed && condition.Status == metav1.ConditionFalse && ccc.Recorder != nil {
+		ccc.Recorder.Event(cc, corev1.EventTypeWarning, "ClusterConfigCertificateExpiring", condition.Message)
+	}
+}
+
 func (ccc ClusterConfigChecker) setStatusAndEvent(ctx context.Context, cc *telekomv1alpha1.ClusterConfig, phase, message, eventType string, lg *zap.SugaredLogger) error {
 	// update status with conditions
 	now := metav1.Now()
@@ -192,6 +360,8 @@ func (ccc ClusterConfigChecker) setStatusAndEvent(ctx context.Context, cc *telek
 			conditionReason = "KubeconfigParseFailed"
 		case "connection":
 			conditionReason = "ClusterUnreachable"
+		case "permissions":
+			conditionReason = "InsufficientPermissions"
 		default:
 			conditionReason = "ValidationFailed"
 		}
@@ -238,13 +408,12 @@ func (ccc ClusterConfigChecker) setStatusAndEvent(ctx context.Context, cc *telek
 }
 
 // checkClusterReachable tries to perform a simple discovery (server version) to ensure the cluster is reachable
-func checkClusterReachable(cfg *rest.Config) error {
-	d, err := discovery.NewDiscoveryClientForConfig(cfg)
+func checkClusterReachable(cfg *rest.Config) (*version.Info, error) {
+	client, err := cluster.DefaultClientPool.Clientset(cfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = d.ServerVersion()
-	return err
+	return client.Discovery().ServerVersion()
 }
 
 // StatusUpdateHelper provides methods to update ClusterConfig status with complete state exposure
@@ -274,8 +443,10 @@ func DescribeFailure(failureType, message string) (failureCategory, advice strin
 		return "secret_not_found", "Referenced kubeconfig secret doesn't exist or is inaccessible. Check secret name and namespace."
 	case "secret_key_missing":
 		return "secret_key_missing", "Kubeconfig secret exists but is missing the required key. Check secret data keys."
+	case "permissions":
+		return "insufficient_permissions", fmt.Sprintf("Cluster credentials lack permissions breakglass needs for the configured accessReviewMode. %s", message)
 	case "not_configured":
-		return "not_configured", "ClusterConfig.spec.kubeconfigSecretRef is not configured. Configure the secret reference."
+		return "not_configured", "Neither ClusterConfig.spec.kubeconfigSecretRef nor ClusterConfig.spec.auth is configured. Configure one of them."
 	default:
 		return "validation_failed", fmt.Sprintf("Configuration validation failed: %s", message)
 	}
@@ -292,6 +463,8 @@ func determineClusterConfigFailureType(message string) string {
 		return "parse"
 	case strings.Contains(message, "unreachable") || strings.Contains(message, "dial"):
 		return "connection"
+	case strings.Contains(message, "missing permissions"):
+		return "permissions"
 	default:
 		return "validation"
 	}
@@ -299,6 +472,8 @@ func determineClusterConfigFailureType(message string) string {
 
 // overridable function variables for unit testing
 var RestConfigFromKubeConfig = clientcmd.RESTConfigFromKubeConfig
-var CheckClusterReachable = func(cfg *rest.Config) error { return checkClusterReachable(cfg) }
+var CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return checkClusterReachable(cfg) }
+var CheckSpokePermissions = checkSpokePermissions
+var ProbeAuthorizationWebhook = probeAuthorizationWebhook
 
 // Fallback: attempt to build rest.Config via clientcmd
@@ -2,7 +2,15 @@ package breakglass
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +19,7 @@ import (
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,7 +30,7 @@ func TestClusterConfigChecker_MissingSecret(t *testing.T) {
 	// Setup: ClusterConfig referencing a secret that doesn't exist
 	cc := &telekomv1alpha1.ClusterConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: "cluster-a", Namespace: "default"},
-		Spec:       telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: telekomv1alpha1.SecretKeyReference{Name: "missing", Namespace: "default"}},
+		Spec:       telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "missing", Namespace: "default"}},
 	}
 	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc).Build()
 	fakeRecorder := record.NewFakeRecorder(10)
@@ -40,7 +49,7 @@ func TestClusterConfigChecker_MissingSecret(t *testing.T) {
 func TestClusterConfigChecker_MissingKey(t *testing.T) {
 	// secret exists but missing key 'value'
 	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "default"}, Data: map[string][]byte{"other": []byte("x")}}
-	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-b", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: telekomv1alpha1.SecretKeyReference{Name: "s1", Namespace: "default"}}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-b", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "s1", Namespace: "default"}}}
 	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
 	fakeRecorder := record.NewFakeRecorder(10)
 	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: fakeRecorder, Interval: time.Minute}
@@ -56,7 +65,7 @@ func TestClusterConfigChecker_MissingKey(t *testing.T) {
 func TestClusterConfigChecker_ParseFail(t *testing.T) {
 	// secret contains key but invalid kubeconfig
 	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s2", Namespace: "default"}, Data: map[string][]byte{"value": []byte("not-a-kubeconfig")}}
-	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-c", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: telekomv1alpha1.SecretKeyReference{Name: "s2", Namespace: "default"}}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-c", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "s2", Namespace: "default"}}}
 	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
 	fakeRecorder := record.NewFakeRecorder(10)
 	// stub RestConfigFromKubeConfig to return error
@@ -76,7 +85,7 @@ func TestClusterConfigChecker_ParseFail(t *testing.T) {
 func TestClusterConfigChecker_Unreachable(t *testing.T) {
 	// secret contains key and parse OK, but cluster unreachable
 	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "default"}, Data: map[string][]byte{"value": []byte("fake-kubeconfig")}}
-	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-d", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: telekomv1alpha1.SecretKeyReference{Name: "s3", Namespace: "default"}}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-d", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "s3", Namespace: "default"}}}
 	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
 	fakeRecorder := record.NewFakeRecorder(10)
 	// stub RestConfigFromKubeConfig to return non-nil config
@@ -85,7 +94,7 @@ func TestClusterConfigChecker_Unreachable(t *testing.T) {
 	defer func() { RestConfigFromKubeConfig = old }()
 	// stub CheckClusterReachable to return error
 	oldCheck := CheckClusterReachable
-	CheckClusterReachable = func(cfg *rest.Config) error { return errors.New("timeout") }
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return nil, errors.New("timeout") }
 	defer func() { CheckClusterReachable = oldCheck }()
 	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: fakeRecorder, Interval: time.Minute}
 	checker.runOnce(context.Background(), checker.Log)
@@ -111,3 +120,225 @@ func getCondition(cc *telekomv1alpha1.ClusterConfig, condType string) *metav1.Co
 	}
 	return nil
 }
+
+func TestClusterConfigChecker_AuthToken(t *testing.T) {
+	// auth mode: the token secret is validated and used to reach the cluster
+	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sa-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("abc")}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-auth", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{
+		Auth: &telekomv1alpha1.ClusterAuth{Server: "https://api.example.com", TokenSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "sa-token", Namespace: "default"}},
+	}}
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	var usedToken string
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { usedToken = cfg.BearerToken; return nil, nil }
+	defer func() { CheckClusterReachable = oldCheck }()
+	stubSpokePermissions(t, nil, nil, nil)
+	stubWebhookProbe(t, true, nil)
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: record.NewFakeRecorder(10), Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	readyCondition := getCondition(got, "Ready")
+	require.NotNil(t, readyCondition)
+	require.Equal(t, metav1.ConditionTrue, readyCondition.Status)
+	require.Equal(t, "abc", usedToken)
+
+	// the token secret disappears
+	require.NoError(t, cl.Delete(context.Background(), sec))
+	checker.runOnce(context.Background(), checker.Log)
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	readyCondition = getCondition(got, "Ready")
+	require.NotNil(t, readyCondition)
+	require.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+	require.Equal(t, "SecretMissing", readyCondition.Reason)
+}
+
+// stubSpokePermissions replaces the permission self-test for the duration of the test
+func stubSpokePermissions(t *testing.T, missing, excess []string, err error) {
+	t.Helper()
+	old := CheckSpokePermissions
+	CheckSpokePermissions = func(context.Context, *rest.Config, telekomv1alpha1.AccessReviewMode) ([]string, []string, error) {
+		return missing, excess, err
+	}
+	t.Cleanup(func() { CheckSpokePermissions = old })
+}
+
+// stubWebhookProbe replaces the authorization webhook probe for the duration of the test
+func stubWebhookProbe(t *testing.T, configured bool, err error) {
+	t.Helper()
+	old := ProbeAuthorizationWebhook
+	ProbeAuthorizationWebhook = func(context.Context, *rest.Config) (bool, error) { return configured, err }
+	t.Cleanup(func() { ProbeAuthorizationWebhook = old })
+}
+
+func newReachableClusterConfig(name string) (*telekomv1alpha1.ClusterConfig, *corev1.Secret) {
+	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name + "-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("abc")}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{
+		Auth:             &telekomv1alpha1.ClusterAuth{Server: "https://api.example.com", TokenSecretRef: &telekomv1alpha1.SecretKeyReference{Name: sec.Name, Namespace: "default"}},
+		AccessReviewMode: telekomv1alpha1.AccessReviewModeSubjectAccessReview,
+	}}
+	return cc, sec
+}
+
+func TestClusterConfigChecker_MissingPermissions(t *testing.T) {
+	cc, sec := newReachableClusterConfig("cluster-missing-perms")
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return nil, nil }
+	defer func() { CheckClusterReachable = oldCheck }()
+	stubSpokePermissions(t, []string{"create subjectaccessreviews"}, nil, nil)
+
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: record.NewFakeRecorder(10), Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	readyCondition := getCondition(got, "Ready")
+	require.NotNil(t, readyCondition)
+	require.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+	require.Equal(t, "InsufficientPermissions", readyCondition.Reason)
+	require.Contains(t, readyCondition.Message, "create subjectaccessreviews")
+}
+
+func TestClusterConfigChecker_ExcessPrivileges(t *testing.T) {
+	cc, sec := newReachableClusterConfig("cluster-excess-perms")
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return nil, nil }
+	defer func() { CheckClusterReachable = oldCheck }()
+	stubSpokePermissions(t, nil, []string{"list secrets", "impersonate users"}, nil)
+	stubWebhookProbe(t, true, nil)
+
+	recorder := record.NewFakeRecorder(10)
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: recorder, Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Equal(t, metav1.ConditionTrue, getCondition(got, "Ready").Status)
+	lp := getCondition(got, string(telekomv1alpha1.ClusterConfigConditionLeastPrivilege))
+	require.NotNil(t, lp)
+	require.Equal(t, metav1.ConditionFalse, lp.Status)
+	require.Equal(t, "ExcessPrivileges", lp.Reason)
+	require.Contains(t, lp.Message, "list secrets, impersonate users")
+
+	excessEvents := 0
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, "ClusterConfigExcessPrivileges") {
+			excessEvents++
+		}
+	}
+	require.Equal(t, 1, excessEvents, "unchanged findings should not emit repeated events")
+}
+
+func TestClusterConfigChecker_PermissionSelfTestError(t *testing.T) {
+	cc, sec := newReachableClusterConfig("cluster-selftest-error")
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return nil, nil }
+	defer func() { CheckClusterReachable = oldCheck }()
+	stubSpokePermissions(t, nil, nil, errors.New("forbidden"))
+	stubWebhookProbe(t, true, nil)
+
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: record.NewFakeRecorder(10), Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Equal(t, metav1.ConditionTrue, getCondition(got, "Ready").Status)
+	require.Equal(t, metav1.ConditionUnknown, getCondition(got, string(telekomv1alpha1.ClusterConfigConditionLeastPrivilege)).Status)
+}
+
+func TestClusterConfigChecker_HealthStatus(t *testing.T) {
+	cc, sec := newReachableClusterConfig("cluster-health")
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return &version.Info{GitVersion: "v1.31.2"}, nil }
+	defer func() { CheckClusterReachable = oldCheck }()
+	stubSpokePermissions(t, nil, nil, nil)
+	stubWebhookProbe(t, false, nil)
+
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: record.NewFakeRecorder(10), Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Equal(t, metav1.ConditionTrue, getCondition(got, "Ready").Status)
+	require.Equal(t, "v1.31.2", got.Status.KubernetesVersion)
+	require.Equal(t, "https://api.example.com", got.Status.APIEndpoint)
+	require.NotNil(t, got.Status.LastProbeTime)
+	require.NotNil(t, got.Status.LastProbeLatency)
+	require.NotNil(t, got.Status.AuthorizationWebhookConfigured)
+	require.False(t, *got.Status.AuthorizationWebhookConfigured)
+	require.Nil(t, got.Status.ClientCertificateExpiry)
+	require.Equal(t, metav1.ConditionTrue, getCondition(got, string(telekomv1alpha1.ClusterConfigConditionCertificatesValid)).Status)
+
+	// a failing probe leaves the webhook state unknown
+	stubWebhookProbe(t, false, errors.New("forbidden"))
+	checker.runOnce(context.Background(), checker.Log)
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Nil(t, got.Status.AuthorizationWebhookConfigured)
+}
+
+// selfSignedCertPEM returns a PEM encoded self-signed certificate expiring at notAfter
+func selfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestClusterConfigChecker_CertificateExpiry(t *testing.T) {
+	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s-cert", Namespace: "default"}, Data: map[string][]byte{"value": []byte("kubeconfig")}}
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "cluster-cert", Namespace: "default"}, Spec: telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "s-cert", Namespace: "default"}}}
+	cl := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(cc, sec).Build()
+	clientExpiry := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	caExpiry := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+	// the CA bundle holds two certificates, the earliest expiry is reported
+	caData := append(selfSignedCertPEM(t, caExpiry), selfSignedCertPEM(t, caExpiry.Add(24*time.Hour))...)
+	old := RestConfigFromKubeConfig
+	RestConfigFromKubeConfig = func(b []byte) (*rest.Config, error) {
+		return &rest.Config{Host: "https://10.0.0.1:6443", TLSClientConfig: rest.TLSClientConfig{CertData: selfSignedCertPEM(t, clientExpiry), CAData: caData}}, nil
+	}
+	defer func() { RestConfigFromKubeConfig = old }()
+	oldCheck := CheckClusterReachable
+	CheckClusterReachable = func(cfg *rest.Config) (*version.Info, error) { return nil, errors.New("timeout") }
+	defer func() { CheckClusterReachable = oldCheck }()
+
+	recorder := record.NewFakeRecorder(10)
+	checker := ClusterConfigChecker{Log: zap.NewNop().Sugar(), Client: cl, Recorder: recorder, Interval: time.Minute}
+	checker.runOnce(context.Background(), checker.Log)
+	checker.runOnce(context.Background(), checker.Log)
+	got := &telekomv1alpha1.ClusterConfig{}
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Equal(t, "https://10.0.0.1:6443", got.Status.APIEndpoint)
+	require.NotNil(t, got.Status.ClientCertificateExpiry)
+	require.True(t, clientExpiry.Equal(got.Status.ClientCertificateExpiry.Time))
+	require.NotNil(t, got.Status.CertificateAuthorityExpiry)
+	require.True(t, caExpiry.Equal(got.Status.CertificateAuthorityExpiry.Time))
+	certs := getCondition(got, string(telekomv1alpha1.ClusterConfigConditionCertificatesValid))
+	require.NotNil(t, certs)
+	require.Equal(t, metav1.ConditionFalse, certs.Status)
+	require.Equal(t, "CertificateExpiring", certs.Reason)
+	require.Contains(t, certs.Message, "client expires at")
+	require.NotContains(t, certs.Message, "ca expires")
+
+	expiringEvents := 0
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, "ClusterConfigCertificateExpiring") {
+			expiringEvents++
+		}
+	}
+	require.Equal(t, 1, expiringEvents, "unchanged findings should not emit repeated events")
+
+	// a shorter warning threshold accepts the certificate
+	checker.CertificateExpiryWarning = 24 * time.Hour
+	checker.runOnce(context.Background(), checker.Log)
+	require.NoError(t, cl.Get(context.Background(), clientKey(cc), got))
+	require.Equal(t, metav1.ConditionTrue, getCondition(got, string(telekomv1alpha1.ClusterConfigConditionCertificatesValid)).Status)
+}
@@ -0,0 +1,99 @@
+package breakglass
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// WebhookProbeUser is the user of the SubjectAccessReview the ClusterConfig checker sends through a
+	// cluster's API server to find out whether the breakglass authorization webhook is configured there
+	WebhookProbeUser = "system:breakglass:webhook-probe"
+	// WebhookProbeReason is the reason the authorization webhook answers probe reviews with
+	WebhookProbeReason = "breakglass authorization webhook probe"
+
+	// DefaultCertificateExpiryWarning is how long before expiry cluster credential certificates are reported
+	DefaultCertificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// probeAuthorizationWebhook asks the cluster's API server to review a request of WebhookProbeUser. Only the
+// breakglass authorization webhook answers with WebhookProbeReason, so the reason tells whether it is consulted.
+func probeAuthorizationWebhook(ctx context.Context, cfg *rest.Config) (bool, error) {
+	client, err := cluster.DefaultClientPool.Clientset(cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to create client: %w", err)
+	}
+	review := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User: WebhookProbeUser,
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Verb:     "get",
+			Group:    telekomv1alpha1.GroupVersion.Group,
+			Resource: "webhookprobes",
+		},
+	}}
+	resp, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("webhook probe: %w", err)
+	}
+	return strings.Contains(resp.Status.Reason, WebhookProbeReason), nil
+}
+
+// certificateExpiries returns the earliest expiry of the client certificates and of the CA certificates
+// of the rest config. Zero times mean the config carries no such certificate.
+func certificateExpiries(cfg *rest.Config) (client, ca time.Time, err error) {
+	certData, err := tlsData(cfg.TLSClientConfig.CertData, cfg.TLSClientConfig.CertFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("client certificate: %w", err)
+	}
+	if client, err = earliestExpiry(certData); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("client certificate: %w", err)
+	}
+	caData, err := tlsData(cfg.TLSClientConfig.CAData, cfg.TLSClientConfig.CAFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("certificate authority: %w", err)
+	}
+	if ca, err = earliestExpiry(caData); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("certificate authority: %w", err)
+	}
+	return client, ca, nil
+}
+
+func tlsData(data []byte, file string) ([]byte, error) {
+	if len(data) > 0 || file == "" {
+		return data, nil
+	}
+	return os.ReadFile(file)
+}
+
+// earliestExpiry returns the earliest NotAfter of the PEM encoded certificates
+func earliestExpiry(pemData []byte) (time.Time, error) {
+	var earliest time.Time
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			return earliest, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+}
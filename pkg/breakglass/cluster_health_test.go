@@ -0,0 +1,37 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestProbeAuthorizationWebhook(t *testing.T) {
+	var reason string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review authorizationv1.SubjectAccessReview
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+		assert.Equal(t, WebhookProbeUser, review.Spec.User)
+		review.Status.Reason = reason
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer srv.Close()
+	rc := &rest.Config{Host: srv.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}}
+
+	configured, err := probeAuthorizationWebhook(context.Background(), rc)
+	require.NoError(t, err)
+	assert.False(t, configured, "RBAC alone gives no probe reason")
+
+	reason = WebhookProbeReason
+	configured, err = probeAuthorizationWebhook(context.Background(), rc)
+	require.NoError(t, err)
+	assert.True(t, configured)
+}
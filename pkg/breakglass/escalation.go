@@ -47,8 +47,9 @@ func (ef EscalationFiltering) FilterForUserPossibleEscalations(ctx context.Conte
 	}
 
 	isEscalationForUser := func(esc telekomv1alpha1.BreakglassEscalation) bool {
-		clusterMatch := slices.Contains(esc.Spec.Allowed.Clusters, ef.FilterUserData.Clustername)
-		ef.Log.Debugw("Checking cluster match for escalation", "escalation", esc.Name, "requiredClusters", esc.Spec.Allowed.Clusters, "userCluster", ef.FilterUserData.Clustername, "clusterMatch", clusterMatch)
+		clusterMatch := slices.Contains(esc.Spec.Allowed.Clusters, ef.FilterUserData.Clustername) ||
+			slices.Contains(esc.Status.ResolvedClusters, ef.FilterUserData.Clustername)
+		ef.Log.Debugw("Checking cluster match for escalation", "escalation", esc.Name, "requiredClusters", esc.Spec.Allowed.Clusters, "resolvedClusters", esc.Status.ResolvedClusters, "userCluster", ef.FilterUserData.Clustername, "clusterMatch", clusterMatch)
 		return clusterMatch
 	}
 
@@ -112,7 +113,7 @@ func (ef EscalationFiltering) FilterSessionsForUserApprovable(ctx context.Contex
 
 		for _, esc := range escalations {
 			if ses.Spec.GrantedGroup != esc.Spec.EscalatedGroup ||
-				!slices.Contains(esc.Spec.Allowed.Clusters, ses.Spec.Cluster) {
+				!(slices.Contains(esc.Spec.Allowed.Clusters, ses.Spec.Cluster) || slices.Contains(esc.Status.ResolvedClusters, ses.Spec.Cluster)) {
 				ef.Log.Debugw("Session-escalation mismatch", "session", ses.Name, "escalation", esc.Name, "sessionGroup", ses.Spec.GrantedGroup, "escalationGroup", esc.Spec.EscalatedGroup)
 				continue
 			}
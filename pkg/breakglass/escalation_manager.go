@@ -161,15 +161,8 @@ func (em EscalationManager) GetClusterBreakglassEscalations(ctx context.Context,
 		// filter results to ensure cluster actually matches (fake client may ignore MatchingFields)
 		out := make([]telekomv1alpha1.BreakglassEscalation, 0)
 		for _, be := range list.Items {
-			if slices.Contains(be.Spec.Allowed.Clusters, cluster) {
+			if escalationAppliesToCluster(be, cluster) {
 				out = append(out, be)
-				continue
-			}
-			for _, ref := range be.Spec.ClusterConfigRefs {
-				if ref == cluster || strings.Contains(ref, cluster) {
-					out = append(out, be)
-					break
-				}
 			}
 		}
 		if len(out) > 0 {
@@ -179,16 +172,22 @@ func (em EscalationManager) GetClusterBreakglassEscalations(ctx context.Context,
 
 	// Fallback to filter-based scan
 	return em.GetBreakglassEscalationsWithFilter(ctx, func(be telekomv1alpha1.BreakglassEscalation) bool {
-		if slices.Contains(be.Spec.Allowed.Clusters, cluster) {
+		return escalationAppliesToCluster(be, cluster)
+	})
+}
+
+// escalationAppliesToCluster reports whether the escalation targets the cluster through allowed.clusters,
+// clusterConfigRefs or the clusters resolved from its clusterSelector
+func escalationAppliesToCluster(be telekomv1alpha1.BreakglassEscalation, cluster string) bool {
+	if slices.Contains(be.Spec.Allowed.Clusters, cluster) || slices.Contains(be.Status.ResolvedClusters, cluster) {
+		return true
+	}
+	for _, ref := range be.Spec.ClusterConfigRefs {
+		if ref == cluster || strings.Contains(ref, cluster) {
 			return true
 		}
-		for _, ref := range be.Spec.ClusterConfigRefs {
-			if ref == cluster || strings.Contains(ref, cluster) {
-				return true
-			}
-		}
-		return false
-	})
+	}
+	return false
 }
 
 // GetClusterGroupBreakglassEscalations returns escalations for specific cluster and user groups
@@ -218,16 +217,7 @@ func (em EscalationManager) GetClusterGroupBreakglassEscalations(ctx context.Con
 	out := make([]telekomv1alpha1.BreakglassEscalation, 0)
 	for _, be := range collected {
 		// ensure escalation applies to the requested cluster
-		clusterMatch := slices.Contains(be.Spec.Allowed.Clusters, cluster)
-		if !clusterMatch {
-			for _, ref := range be.Spec.ClusterConfigRefs {
-				if ref == cluster || strings.Contains(ref, cluster) {
-					clusterMatch = true
-					break
-				}
-			}
-		}
-		if !clusterMatch {
+		if !escalationAppliesToCluster(be, cluster) {
 			continue
 		}
 		allowedGroups := be.Spec.Allowed.Groups
@@ -277,16 +267,7 @@ func (em EscalationManager) GetClusterGroupTargetBreakglassEscalation(ctx contex
 		if be.Spec.EscalatedGroup != targetGroup {
 			continue
 		}
-		clusterMatch := slices.Contains(be.Spec.Allowed.Clusters, cluster)
-		if !clusterMatch {
-			for _, ref := range be.Spec.ClusterConfigRefs {
-				if ref == cluster || strings.Contains(ref, cluster) {
-					clusterMatch = true
-					break
-				}
-			}
-		}
-		if !clusterMatch {
+		if !escalationAppliesToCluster(be, cluster) {
 			continue
 		}
 		allowedGroups := be.Spec.Allowed.Groups
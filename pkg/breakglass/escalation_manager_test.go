@@ -742,3 +742,39 @@ func TestEscalationManager_GetClusterGroupTargetBreakglassEscalation(t *testing.
 		})
 	}
 }
+
+func TestEscalationManager_ClusterSelectorResolvedClusters(t *testing.T) {
+	selected := &telekomv1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-admin", Namespace: "default"},
+		Spec: telekomv1alpha1.BreakglassEscalationSpec{
+			EscalatedGroup:  "prod-admins",
+			Allowed:         telekomv1alpha1.BreakglassEscalationAllowed{Groups: []string{"admin"}},
+			ClusterSelector: &telekomv1alpha1.ClusterSelector{Environment: "prod"},
+		},
+		Status: telekomv1alpha1.BreakglassEscalationStatus{ResolvedClusters: []string{"prod-1", "prod-2"}},
+	}
+	cli := fake.NewClientBuilder().WithScheme(breakglass.Scheme).WithObjects(selected).Build()
+	manager := breakglass.EscalationManager{Client: cli}
+
+	got, err := manager.GetClusterBreakglassEscalations(context.Background(), "prod-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected the selector escalation for a resolved cluster, got %d", len(got))
+	}
+	got, err = manager.GetClusterGroupTargetBreakglassEscalation(context.Background(), "prod-1", []string{"admin"}, "prod-admins")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected the selector escalation for a resolved cluster and group, got %d", len(got))
+	}
+	got, err = manager.GetClusterBreakglassEscalations(context.Background(), "dev-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no escalation for an unselected cluster, got %d", len(got))
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/Nerzal/gocloak/v13"
 	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
 	"github.com/telekom/k8s-breakglass/pkg/config"
 	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
@@ -21,23 +20,12 @@ const (
 	DefaultClusterConfigCheckInterval     = 10 * time.Minute
 )
 
-// GroupMemberResolver abstracts IdP (Keycloak) group membership queries.
+// GroupMemberResolver abstracts IdP (Keycloak, LDAP) group membership queries.
 // Implementations should return slice of user identifiers (emails/usernames) for provided group.
 type GroupMemberResolver interface {
 	Members(ctx context.Context, group string) ([]string, error)
 }
 
-// KeycloakGroupMemberResolver uses GoCloak client to fetch group members from Keycloak admin API.
-type KeycloakGroupMemberResolver struct {
-	log       *zap.SugaredLogger
-	cfg       cfgpkg.KeycloakRuntimeConfig
-	gocloak   *gocloak.GoCloak
-	cache     *kcCache
-	token     string
-	tokenTime time.Time
-	tokenLock sync.RWMutex
-}
-
 type kcCache struct {
 	mu    sync.RWMutex
 	items map[string]kcEntry
@@ -64,363 +52,6 @@ func (c *kcCache) set(k string, v []string) {
 	c.items[k] = kcEntry{members: append([]string(nil), v...), expires: time.Now().Add(c.ttl)}
 }
 
-func NewKeycloakGroupMemberResolver(log *zap.SugaredLogger, cfg cfgpkg.KeycloakRuntimeConfig) *KeycloakGroupMemberResolver {
-	ttl := 10 * time.Minute
-	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil && d > 0 {
-		ttl = d
-	}
-	gc := gocloak.NewClient(cfg.BaseURL)
-	return &KeycloakGroupMemberResolver{log: log, cfg: cfg, gocloak: gc, cache: newKCCache(ttl)}
-}
-
-func (k *KeycloakGroupMemberResolver) getToken(ctx context.Context) (string, error) {
-	// Use configured service account token if available
-	if k.cfg.ServiceAccountToken != "" {
-		if k.log != nil {
-			// Log a sanitized version of the token for debugging
-			tokenPreview := k.cfg.ServiceAccountToken
-			if len(tokenPreview) > 20 {
-				tokenPreview = tokenPreview[:20] + "..."
-			}
-			k.log.Debugw("Using pre-configured service account token", "tokenPreview", tokenPreview)
-		}
-		return k.cfg.ServiceAccountToken, nil
-	}
-
-	// Check cached token
-	k.tokenLock.RLock()
-	if k.token != "" && time.Now().Before(k.tokenTime.Add(5*time.Minute)) {
-		defer k.tokenLock.RUnlock()
-		if k.log != nil {
-			k.log.Debugw("Using cached token", "expiresIn", time.Until(k.tokenTime.Add(5*time.Minute)).Seconds())
-		}
-		return k.token, nil
-	}
-	k.tokenLock.RUnlock()
-
-	// Acquire new token using client credentials
-	tokenURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", k.cfg.BaseURL, k.cfg.Realm)
-	if k.log != nil {
-		k.log.Debugw("Acquiring token via client credentials",
-			"clientID", k.cfg.ClientID,
-			"baseURL", k.cfg.BaseURL,
-			"realm", k.cfg.Realm,
-			"endpoint", tokenURL,
-			"grantType", "client_credentials",
-			"clientSecretProvided", k.cfg.ClientSecret != "")
-	}
-	token, err := k.gocloak.GetToken(ctx, k.cfg.Realm, gocloak.TokenOptions{
-		ClientID:     &k.cfg.ClientID,
-		ClientSecret: &k.cfg.ClientSecret,
-		GrantType:    gocloak.StringP("client_credentials"),
-	})
-	if err != nil {
-		if k.log != nil {
-			k.log.Errorw("Failed to acquire token",
-				"clientID", k.cfg.ClientID,
-				"error", err,
-				"endpoint", tokenURL,
-				"grantType", "client_credentials")
-		}
-		return "", err
-	}
-
-	// Cache token
-	k.tokenLock.Lock()
-	k.token = token.AccessToken
-	k.tokenTime = time.Now()
-	k.tokenLock.Unlock()
-
-	if k.log != nil {
-		tokenPreview := k.token
-		if len(tokenPreview) > 20 {
-			tokenPreview = tokenPreview[:20] + "..."
-		}
-		k.log.Debugw("Token acquired successfully",
-			"clientID", k.cfg.ClientID,
-			"tokenPreview", tokenPreview,
-			"expiresIn", token.ExpiresIn,
-			"tokenType", token.TokenType)
-	}
-	return token.AccessToken, nil
-}
-
-func (k *KeycloakGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
-	if k == nil {
-		return nil, nil
-	}
-	log := k.log
-	if k.cfg.BaseURL == "" || k.cfg.Realm == "" || k.cfg.ClientID == "" {
-		if log != nil {
-			log.Errorw("Keycloak resolver has incomplete configuration; cannot resolve groups",
-				"group", group,
-				"baseURL", k.cfg.BaseURL,
-				"realm", k.cfg.Realm,
-				"clientID", k.cfg.ClientID)
-		}
-		return nil, fmt.Errorf("keycloak resolver incomplete config: baseURL=%s, realm=%s, clientID=%s",
-			k.cfg.BaseURL, k.cfg.Realm, k.cfg.ClientID)
-	}
-	if v, ok := k.cache.get(group); ok {
-		if log != nil {
-			log.Debugw("Keycloak cache hit for group", "group", group, "membersCount", len(v), "members", v)
-		}
-		return v, nil
-	}
-	if log != nil {
-		log.Debugw("Keycloak cache miss for group; will perform lookup", "group", group)
-	}
-
-	// Get token
-	token, err := k.getToken(ctx)
-	if err != nil {
-		if log != nil {
-			log.Errorw("Failed to get Keycloak token", "group", group, "error", err)
-		}
-		return nil, err
-	}
-
-	// 1. Search for group by name
-	if log != nil {
-		log.Debugw("Starting group search step", "group", group)
-		tokenPreview := token
-		if len(tokenPreview) > 20 {
-			tokenPreview = tokenPreview[:20] + "..."
-		}
-		log.Debugw("GetGroups API call details",
-			"baseURL", k.cfg.BaseURL,
-			"realm", k.cfg.Realm,
-			"searchParam", group,
-			"tokenPreview", tokenPreview,
-			"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups", k.cfg.BaseURL, k.cfg.Realm))
-	}
-	params := gocloak.GetGroupsParams{Search: gocloak.StringP(group)}
-	groups, err := k.gocloak.GetGroups(ctx, token, k.cfg.Realm, params)
-	if err != nil {
-		if log != nil {
-			tokenPreview := token
-			if len(tokenPreview) > 20 {
-				tokenPreview = tokenPreview[:20] + "..."
-			}
-			log.Errorw("Keycloak groups search failed",
-				"group", group,
-				"error", err,
-				"errorType", fmt.Sprintf("%T", err),
-				"tokenPreview", tokenPreview,
-				"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups", k.cfg.BaseURL, k.cfg.Realm),
-				"params", fmt.Sprintf("search=%s", group))
-		}
-		return nil, err
-	}
-	if log != nil {
-		log.Debugw("Keycloak groups search completed", "group", group, "returnedGroupCount", len(groups))
-		if len(groups) > 0 {
-			for i, g := range groups {
-				log.Debugw("Group search result",
-					"index", i,
-					"groupID", g.ID,
-					"groupName", g.Name,
-					"hasSubgroups", g.SubGroups != nil && len(*g.SubGroups) > 0)
-			}
-		}
-	}
-
-	// Find matching group by name
-	var groupID *string
-	for _, g := range groups {
-		if g.Name != nil && strings.EqualFold(*g.Name, group) {
-			groupID = g.ID
-			if log != nil {
-				log.Debugw("Found matching group by name", "group", group, "groupID", *groupID, "matchedName", *g.Name)
-			}
-			break
-		}
-	}
-	if groupID == nil {
-		if log != nil {
-			log.Warnw("Group not found in search results", "group", group)
-		}
-		k.cache.set(group, []string{})
-		return []string{}, nil
-	}
-
-	// 2. Get direct group members
-	if log != nil {
-		log.Debugw("Starting direct members fetch step", "group", group, "groupID", *groupID)
-		tokenPreview := token
-		if len(tokenPreview) > 20 {
-			tokenPreview = tokenPreview[:20] + "..."
-		}
-		log.Debugw("GetGroupMembers API call details",
-			"baseURL", k.cfg.BaseURL,
-			"realm", k.cfg.Realm,
-			"groupID", *groupID,
-			"tokenPreview", tokenPreview,
-			"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s/members", k.cfg.BaseURL, k.cfg.Realm, *groupID))
-	}
-	params2 := gocloak.GetGroupsParams{}
-	members, err := k.gocloak.GetGroupMembers(ctx, token, k.cfg.Realm, *groupID, params2)
-	if err != nil {
-		if log != nil {
-			tokenPreview := token
-			if len(tokenPreview) > 20 {
-				tokenPreview = tokenPreview[:20] + "..."
-			}
-			log.Errorw("Keycloak members fetch failed",
-				"group", group,
-				"groupID", *groupID,
-				"error", err,
-				"errorType", fmt.Sprintf("%T", err),
-				"tokenPreview", tokenPreview,
-				"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s/members", k.cfg.BaseURL, k.cfg.Realm, *groupID))
-		}
-		return nil, err
-	}
-	if log != nil {
-		log.Debugw("Direct members fetch completed", "group", group, "directMemberCount", len(members))
-		for i, m := range members {
-			log.Debugw("Direct group member",
-				"index", i,
-				"userID", m.ID,
-				"username", m.Username,
-				"email", m.Email)
-		}
-	}
-
-	// Collect member identifiers
-	out := make([]string, 0, len(members))
-	for i, m := range members {
-		identifier := ""
-		if m.Email != nil && *m.Email != "" {
-			identifier = *m.Email
-			if log != nil {
-				log.Debugw("Added direct member by email", "group", group, "index", i, "email", identifier)
-			}
-		} else if m.Username != nil && *m.Username != "" {
-			identifier = *m.Username
-			if log != nil {
-				log.Debugw("Added direct member by username", "group", group, "index", i, "username", identifier)
-			}
-		}
-		if identifier != "" {
-			out = append(out, identifier)
-		}
-	}
-
-	// 3. Get group detail to retrieve subgroups
-	if log != nil {
-		log.Debugw("Starting subgroups fetch step", "group", group, "groupID", *groupID, "currentMemberCount", len(out))
-		tokenPreview := token
-		if len(tokenPreview) > 20 {
-			tokenPreview = tokenPreview[:20] + "..."
-		}
-		log.Debugw("GetGroup API call details",
-			"baseURL", k.cfg.BaseURL,
-			"realm", k.cfg.Realm,
-			"groupID", *groupID,
-			"tokenPreview", tokenPreview,
-			"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s", k.cfg.BaseURL, k.cfg.Realm, *groupID))
-	}
-	groupDetail, err := k.gocloak.GetGroup(ctx, token, k.cfg.Realm, *groupID)
-	if err != nil {
-		if log != nil {
-			tokenPreview := token
-			if len(tokenPreview) > 20 {
-				tokenPreview = tokenPreview[:20] + "..."
-			}
-			log.Warnw("Keycloak group detail fetch failed",
-				"group", group,
-				"error", err,
-				"errorType", fmt.Sprintf("%T", err),
-				"tokenPreview", tokenPreview,
-				"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s", k.cfg.BaseURL, k.cfg.Realm, *groupID))
-		}
-		// Continue with just direct members
-	} else if groupDetail != nil && groupDetail.SubGroups != nil {
-		if log != nil {
-			log.Debugw("Subgroups fetch completed", "group", group, "subgroupCount", len(*groupDetail.SubGroups))
-		}
-		for sgIdx, sg := range *groupDetail.SubGroups {
-			if sg.ID == nil {
-				continue
-			}
-			if log != nil {
-				log.Debugw("Processing subgroup", "group", group, "parentGroupID", *groupID, "subgroupIndex", sgIdx, "subgroupID", *sg.ID, "subgroupName", sg.Name)
-			}
-
-			// Fetch members of each subgroup
-			if log != nil {
-				tokenPreview := token
-				if len(tokenPreview) > 20 {
-					tokenPreview = tokenPreview[:20] + "..."
-				}
-				log.Debugw("GetGroupMembers API call for subgroup",
-					"baseURL", k.cfg.BaseURL,
-					"realm", k.cfg.Realm,
-					"subgroupID", *sg.ID,
-					"tokenPreview", tokenPreview,
-					"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s/members", k.cfg.BaseURL, k.cfg.Realm, *sg.ID))
-			}
-			params3 := gocloak.GetGroupsParams{}
-			sgMembers, err := k.gocloak.GetGroupMembers(ctx, token, k.cfg.Realm, *sg.ID, params3)
-			if err != nil {
-				if log != nil {
-					tokenPreview := token
-					if len(tokenPreview) > 20 {
-						tokenPreview = tokenPreview[:20] + "..."
-					}
-					log.Warnw("Subgroup members fetch failed",
-						"group", group,
-						"subgroupID", *sg.ID,
-						"error", err,
-						"errorType", fmt.Sprintf("%T", err),
-						"tokenPreview", tokenPreview,
-						"endpoint", fmt.Sprintf("%s/admin/realms/%s/groups/%s/members", k.cfg.BaseURL, k.cfg.Realm, *sg.ID))
-				}
-				continue
-			}
-
-			if log != nil {
-				log.Debugw("Subgroup members fetch completed", "subgroupID", *sg.ID, "memberCount", len(sgMembers))
-			}
-
-			for sgmIdx, m := range sgMembers {
-				identifier := ""
-				if m.Email != nil && *m.Email != "" {
-					identifier = *m.Email
-					if log != nil {
-						log.Debugw("Added subgroup member by email", "group", group, "subgroupID", *sg.ID, "memberIndex", sgmIdx, "email", identifier)
-					}
-				} else if m.Username != nil && *m.Username != "" {
-					identifier = *m.Username
-					if log != nil {
-						log.Debugw("Added subgroup member by username", "group", group, "subgroupID", *sg.ID, "memberIndex", sgmIdx, "username", identifier)
-					}
-				}
-				if identifier != "" {
-					out = append(out, identifier)
-				}
-			}
-		}
-	}
-
-	// 4. Normalize and deduplicate members
-	if log != nil {
-		log.Debugw("Starting member list normalization", "group", group, "beforeNormalizationCount", len(out))
-	}
-	out = normalizeMembers(out)
-	if log != nil {
-		log.Infow("Keycloak group member resolution completed successfully", "group", group, "finalResolvedCount", len(out), "members", out)
-	}
-
-	// 5. Cache and return results
-	k.cache.set(group, out)
-	if log != nil {
-		log.Debugw("Group member resolution returning successfully", "group", group, "memberCount", len(out))
-	}
-	return out, nil
-}
-
 // EscalationStatusUpdater periodically expands approver groups into member lists and stores in status.
 type EscalationStatusUpdater struct {
 	Log           *zap.SugaredLogger
@@ -430,6 +61,8 @@ type EscalationStatusUpdater struct {
 	LeaderElected <-chan struct{} // Optional: signal when leadership acquired (nil = start immediately for backward compatibility)
 	EventRecorder record.EventRecorder
 	IDPLoader     *cfgpkg.IdentityProviderLoader // For multi-IDP group fetching
+	// Resolvers keeps the per-IDP resolvers between runs (nil = new resolvers every run)
+	Resolvers *ResolverRegistry
 }
 
 func (u EscalationStatusUpdater) Start(ctx context.Context) {
@@ -477,6 +110,17 @@ func (u EscalationStatusUpdater) runOnce(ctx context.Context, log *zap.SugaredLo
 	}
 	log.Debugw("Fetched escalations for status update", "count", len(escList.Items))
 
+	// Drop resolvers of identity providers that were deleted or disabled
+	if u.Resolvers != nil && u.IDPLoader != nil {
+		if idps, err := u.IDPLoader.LoadAllIdentityProviders(ctx); err == nil {
+			names := make([]string, 0, len(idps))
+			for name := range idps {
+				names = append(names, name)
+			}
+			u.Resolvers.Retain(names)
+		}
+	}
+
 	for _, esc := range escList.Items {
 		// Collect approver groups
 		groups := esc.Spec.Approvers.Groups
@@ -751,18 +395,34 @@ func (u EscalationStatusUpdater) fetchGroupMembersFromMultipleIDPs(
 	return hierarchy, syncStatus, syncErrors
 }
 
-// createResolverForIDP creates an appropriate resolver for the given IDP config
+// createResolverForIDP returns the resolver for the given IDP config, reusing the resolver kept
+// in the registry while the configuration is unchanged
 func (u EscalationStatusUpdater) createResolverForIDP(idpConfig *cfgpkg.IdentityProviderConfig, log *zap.SugaredLogger) GroupMemberResolver {
 	if idpConfig == nil {
 		return nil
 	}
+	if u.Resolvers != nil {
+		return u.Resolvers.Get(idpConfig, func() GroupMemberResolver { return u.newResolverForIDP(idpConfig, log) })
+	}
+	return u.newResolverForIDP(idpConfig, log)
+}
 
-	// Currently only Keycloak is supported
-	if idpConfig.Keycloak == nil {
+// newResolverForIDP creates an appropriate resolver for the given IDP config
+func (u EscalationStatusUpdater) newResolverForIDP(idpConfig *cfgpkg.IdentityProviderConfig, log *zap.SugaredLogger) GroupMemberResolver {
+	switch {
+	case idpConfig.Keycloak != nil:
+		return NewKeycloakGroupMemberResolver(log, *idpConfig.Keycloak)
+	case idpConfig.LDAP != nil:
+		return NewLDAPGroupMemberResolver(log, *idpConfig.LDAP)
+	case idpConfig.SCIM != nil:
+		return NewSCIMGroupMemberResolver(log, u.K8sClient, idpConfig.Name)
+	case idpConfig.MicrosoftGraph != nil:
+		return NewMicrosoftGraphGroupMemberResolver(log, *idpConfig.MicrosoftGraph)
+	case idpConfig.HTTPJSON != nil:
+		return NewHTTPJSONGroupMemberResolver(log, *idpConfig.HTTPJSON)
+	default:
 		return nil
 	}
-
-	return NewKeycloakGroupMemberResolver(log, *idpConfig.Keycloak)
 }
 
 func normalizeMembers(in []string) []string {
@@ -865,9 +525,18 @@ func SetupResolver(idpConfig *config.IdentityProviderConfig, log *zap.SugaredLog
 	if idpConfig != nil && idpConfig.Keycloak != nil && idpConfig.Keycloak.BaseURL != "" && idpConfig.Keycloak.Realm != "" {
 		resolver = NewKeycloakGroupMemberResolver(log, *idpConfig.Keycloak)
 		log.Infow("Keycloak group sync enabled", "baseURL", idpConfig.Keycloak.BaseURL, "realm", idpConfig.Keycloak.Realm)
+	} else if idpConfig != nil && idpConfig.LDAP != nil && idpConfig.LDAP.URL != "" {
+		resolver = NewLDAPGroupMemberResolver(log, *idpConfig.LDAP)
+		log.Infow("LDAP group sync enabled", "url", idpConfig.LDAP.URL, "nestedGroups", idpConfig.LDAP.NestedGroups)
+	} else if idpConfig != nil && idpConfig.MicrosoftGraph != nil && idpConfig.MicrosoftGraph.TenantID != "" {
+		resolver = NewMicrosoftGraphGroupMemberResolver(log, *idpConfig.MicrosoftGraph)
+		log.Infow("Microsoft Graph group sync enabled", "tenantID", idpConfig.MicrosoftGraph.TenantID, "clientID", idpConfig.MicrosoftGraph.ClientID)
+	} else if idpConfig != nil && idpConfig.HTTPJSON != nil && idpConfig.HTTPJSON.MembersURL != "" {
+		resolver = NewHTTPJSONGroupMemberResolver(log, *idpConfig.HTTPJSON)
+		log.Infow("HTTP JSON group sync enabled", "membersURL", idpConfig.HTTPJSON.MembersURL)
 	} else {
 		resolver = &KeycloakGroupMemberResolver{} // no-op
-		log.Infow("Keycloak group sync disabled or not fully configured; using no-op resolver")
+		log.Infow("Group sync disabled or not fully configured; using no-op resolver")
 	}
 	return resolver
 }
@@ -18,6 +18,7 @@ import (
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
 	pkgconfig "github.com/telekom/k8s-breakglass/pkg/config"
 )
 
@@ -46,7 +47,12 @@ func getConfigForClusterName(name string) (*rest.Config, error) {
 	return nil, err
 }
 
+// AuthCheckerUser is the user name of the reviewer identity used for group authorization checks
+const AuthCheckerUser = "system:auth-checker"
+
 // CanGroupsDo impersonates given groups against provided rest.Config (target cluster kubeconfig), not the hub.
+// rc must be long-lived, like the cached config of the ClientProvider, as the client pool keys configs with
+// exec plugins or transport wrappers by identity.
 func CanGroupsDo(ctx context.Context,
 	rc *rest.Config,
 	groups []string,
@@ -57,14 +63,17 @@ func CanGroupsDo(ctx context.Context,
 		return false, errors.New("rest config is nil")
 	}
 	zap.S().Debugw("Checking if groups can perform SAR operation", "groups", groups, "cluster", clustername)
-	// Copy to avoid mutating shared config
-	cfg := rest.CopyConfig(rc)
-	cfg.Impersonate = rest.ImpersonationConfig{UserName: "system:auth-checker", Groups: groups}
-	client, err := kubernetes.NewForConfig(cfg)
+	// Impersonation is applied on top of the pooled client, so the shared config is not mutated
+	client, err := cluster.DefaultClientPool.SharedClientset(rc, rest.ImpersonationConfig{UserName: AuthCheckerUser, Groups: groups})
 	if err != nil {
 		zap.S().Errorw("Failed to create client for CanGroupsDo", "error", err.Error())
 		return false, errors.Wrap(err, "failed to create client")
 	}
+	return selfSubjectAccessReview(ctx, client, sar)
+}
+
+// selfSubjectAccessReview asks the cluster whether the identity of the client may perform the operation
+func selfSubjectAccessReview(ctx context.Context, client kubernetes.Interface, sar authorizationv1.SubjectAccessReview) (bool, error) {
 	if sar.Spec.ResourceAttributes == nil {
 		return false, errors.New("sar spec.resourceAttributes is nil")
 	}
@@ -78,13 +87,62 @@ func CanGroupsDo(ctx context.Context,
 	return response.Status.Allowed, nil
 }
 
+// CanGroupsDoViaSubjectAccessReview asks the target cluster whether given groups may perform the operation by
+// creating a SubjectAccessReview for the reviewer identity. Unlike CanGroupsDo it needs no impersonation rights,
+// only create on subjectaccessreviews.
+func CanGroupsDoViaSubjectAccessReview(ctx context.Context,
+	rc *rest.Config,
+	groups []string,
+	sar authorizationv1.SubjectAccessReview,
+	clustername string,
+) (bool, error) {
+	if rc == nil {
+		return false, errors.New("rest config is nil")
+	}
+	if sar.Spec.ResourceAttributes == nil {
+		return false, errors.New("sar spec.resourceAttributes is nil")
+	}
+	zap.S().Debugw("Checking if groups can perform SAR operation via SubjectAccessReview", "groups", groups, "cluster", clustername)
+	client, err := cluster.DefaultClientPool.SharedClientset(rc, rest.ImpersonationConfig{})
+	if err != nil {
+		zap.S().Errorw("Failed to create client for CanGroupsDoViaSubjectAccessReview", "error", err.Error())
+		return false, errors.Wrap(err, "failed to create client")
+	}
+	ra := sar.Spec.ResourceAttributes
+	review := authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User:   AuthCheckerUser,
+		Groups: groups,
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace:   ra.Namespace,
+			Verb:        ra.Verb,
+			Group:       ra.Group,
+			Resource:    ra.Resource,
+			Subresource: ra.Subresource,
+			Name:        ra.Name,
+		},
+	}}
+	response, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &review, metav1.CreateOptions{})
+	if err != nil {
+		zap.S().Errorw("Failed to create SubjectAccessReview", "error", err.Error())
+		return false, err
+	}
+	zap.S().Infow("SubjectAccessReview result", "allowed", response.Status.Allowed)
+	return response.Status.Allowed, nil
+}
+
 // Legacy wrapper kept for compatibility (uses local context); prefer CanGroupsDo with explicit rest.Config.
 func CanGroupsDoLegacy(ctx context.Context, groups []string, sar authorizationv1.SubjectAccessReview, clustername string) (bool, error) {
 	rc, err := getConfigForClusterName(clustername)
 	if err != nil {
 		return false, err
 	}
-	return CanGroupsDo(ctx, rc, groups, sar, clustername)
+	// the config is loaded per call, so it goes through Clientset, which does not pool it by identity
+	rc.Impersonate = rest.ImpersonationConfig{UserName: AuthCheckerUser, Groups: groups}
+	client, err := cluster.DefaultClientPool.Clientset(rc)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create client")
+	}
+	return selfSubjectAccessReview(ctx, client, sar)
 }
 
 // stripOIDCPrefixes removes configured OIDC prefixes from user groups to allow matching with cluster groups
@@ -141,7 +199,7 @@ func getUserGroupsInternal(ctx context.Context, cug ClusterUserGroup, configPath
 		UserName: cug.Username,
 	}
 
-	client, err := kubernetes.NewForConfig(kubeCfg)
+	client, err := cluster.DefaultClientPool.Clientset(kubeCfg)
 	if err != nil {
 		zap.S().Errorw("GetUserGroups: client construction failed", "cluster", cug.Clustername, "error", err.Error())
 		return nil, errors.Wrap(err, "failed to create client")
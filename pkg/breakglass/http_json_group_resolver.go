@@ -0,0 +1,88 @@
+package breakglass
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/httpjson"
+	"go.uber.org/zap"
+)
+
+// DefaultHTTPJSONRequestTimeout bounds the requests made to resolve one group
+const DefaultHTTPJSONRequestTimeout = 10 * time.Second
+
+// HTTPJSONGroupMemberResolver resolves group members from a homegrown directory endpoint. The
+// group name is substituted into a URL template and the members are extracted from the JSON
+// response with a JSONPath expression, following next page links when configured.
+type HTTPJSONGroupMemberResolver struct {
+	log     *zap.SugaredLogger
+	client  *httpjson.Client
+	err     error
+	cache   *kcCache
+	timeout time.Duration
+}
+
+func NewHTTPJSONGroupMemberResolver(log *zap.SugaredLogger, cfg cfgpkg.HTTPJSONRuntimeConfig) *HTTPJSONGroupMemberResolver {
+	ttl := 10 * time.Minute
+	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil && d > 0 {
+		ttl = d
+	}
+	timeout := DefaultHTTPJSONRequestTimeout
+	if d, err := time.ParseDuration(cfg.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	// A configuration error is reported by Members so the resolver can be created unconditionally
+	client, err := httpjson.NewClient(httpjson.Options{
+		MembersURL:           cfg.MembersURL,
+		MembersPath:          cfg.MembersPath,
+		NextPagePath:         cfg.NextPagePath,
+		MaxPages:             cfg.MaxPages,
+		Token:                cfg.Token,
+		TokenHeader:          cfg.TokenHeader,
+		CertificateAuthority: cfg.CertificateAuthority,
+		InsecureSkipVerify:   cfg.InsecureSkipVerify,
+	})
+	return &HTTPJSONGroupMemberResolver{log: log, client: client, err: err, cache: newKCCache(ttl), timeout: timeout}
+}
+
+func (h *HTTPJSONGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
+	if h == nil {
+		return nil, nil
+	}
+	log := h.log
+	if h.err != nil {
+		return nil, h.err
+	}
+	if v, ok := h.cache.get(group); ok {
+		if log != nil {
+			log.Debugw("HTTP JSON cache hit for group", "group", group, "membersCount", len(v))
+		}
+		return v, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	members, err := h.client.Members(ctx, group)
+	if errors.Is(err, httpjson.ErrGroupNotFound) {
+		if log != nil {
+			log.Warnw("Group not found at HTTP JSON endpoint", "group", group, "url", h.client.MembersURL(group))
+		}
+		h.cache.set(group, []string{})
+		return []string{}, nil
+	}
+	if err != nil {
+		if log != nil {
+			log.Errorw("Failed to resolve HTTP JSON group members", "group", group, "error", err)
+		}
+		return nil, err
+	}
+
+	members = normalizeMembers(members)
+	if log != nil {
+		log.Debugw("Resolved HTTP JSON group members", "group", group, "membersCount", len(members))
+	}
+	h.cache.set(group, members)
+	return members, nil
+}
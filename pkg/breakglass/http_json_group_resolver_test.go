@@ -0,0 +1,59 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestHTTPJSONGroupMemberResolver(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer directory-token", r.Header.Get("Authorization"))
+		if r.URL.Path != "/groups/ops/members" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": []map[string]string{{"mail": "Alice@Example.com"}, {"mail": "bob@example.com"}},
+				"next": "/groups/ops/members?cursor=2",
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]string{{"mail": "alice@example.com"}}})
+	}))
+	defer srv.Close()
+
+	resolver := NewHTTPJSONGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.HTTPJSONRuntimeConfig{
+		MembersURL:           srv.URL + "/groups/{group}/members",
+		MembersPath:          "$.data[*].mail",
+		NextPagePath:         "$.next",
+		Token:                "directory-token",
+		CertificateAuthority: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})),
+	})
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, members)
+
+	members, err = resolver.Members(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestHTTPJSONGroupMemberResolverInvalidConfig(t *testing.T) {
+	resolver := NewHTTPJSONGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.HTTPJSONRuntimeConfig{
+		MembersURL:  "https://directory.example.com/{group}",
+		MembersPath: "$.members[",
+	})
+	_, err := resolver.Members(context.Background(), "ops")
+	assert.ErrorContains(t, err, "membersPath")
+}
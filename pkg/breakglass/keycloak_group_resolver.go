@@ -0,0 +1,430 @@
+package breakglass
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultKeycloakRequestTimeout bounds a single Keycloak API request
+	DefaultKeycloakRequestTimeout = 10 * time.Second
+	// DefaultKeycloakRequestsPerSecond is the default client-side rate limit of a resolver
+	DefaultKeycloakRequestsPerSecond = 10
+	// DefaultKeycloakBurst is the default number of requests allowed above the rate limit
+	DefaultKeycloakBurst = 20
+
+	// keycloakPageSize is the page size of member and subgroup listings
+	keycloakPageSize = 500
+	// keycloakLookupTimeouts bounds a shared group lookup, which issues several requests, to this many
+	// request timeouts
+	keycloakLookupTimeouts = 6
+	// keycloakTokenLeeway renews admin tokens shortly before they expire
+	keycloakTokenLeeway = 30 * time.Second
+	// keycloakMaxGroupDepth bounds the subgroup levels expanded below a group
+	keycloakMaxGroupDepth = 10
+)
+
+// KeycloakGroupMemberResolver uses GoCloak client to fetch group members from Keycloak admin API.
+// Members of all subgroups are included. Resolvers are safe for concurrent use: concurrent
+// lookups of the same group share one set of requests, all lookups share one admin token and
+// requests are rate limited on the client.
+type KeycloakGroupMemberResolver struct {
+	log      *zap.SugaredLogger
+	cfg      cfgpkg.KeycloakRuntimeConfig
+	gocloak  *gocloak.GoCloak
+	cache    *kcCache
+	timeout  time.Duration
+	limiter  *rate.Limiter
+	pageSize int
+	lookups  singleflight.Group
+
+	// tokenLock serialises token refreshes so concurrent lookups wait for and share one token
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewKeycloakGroupMemberResolver(log *zap.SugaredLogger, cfg cfgpkg.KeycloakRuntimeConfig) *KeycloakGroupMemberResolver {
+	ttl := 10 * time.Minute
+	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil && d > 0 {
+		ttl = d
+	}
+	timeout := DefaultKeycloakRequestTimeout
+	if d, err := time.ParseDuration(cfg.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	rps := DefaultKeycloakRequestsPerSecond
+	if cfg.RequestsPerSecond > 0 {
+		rps = cfg.RequestsPerSecond
+	}
+	burst := DefaultKeycloakBurst
+	if cfg.Burst > 0 {
+		burst = cfg.Burst
+	}
+
+	gc := gocloak.NewClient(strings.TrimRight(cfg.BaseURL, "/"))
+	// InsecureSkipVerify is an explicit opt-in for test environments
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CertificateAuthority != "" {
+		pool := x509.NewCertPool()
+		if pool.AppendCertsFromPEM([]byte(cfg.CertificateAuthority)) {
+			tlsConfig.RootCAs = pool
+		} else if log != nil {
+			log.Warnw("Could not parse Keycloak CA certificate; using system trust store", "baseURL", cfg.BaseURL)
+		}
+	}
+	gc.RestyClient().SetTLSClientConfig(tlsConfig)
+
+	return &KeycloakGroupMemberResolver{
+		log:      log,
+		cfg:      cfg,
+		gocloak:  gc,
+		cache:    newKCCache(ttl),
+		timeout:  timeout,
+		limiter:  rate.NewLimiter(rate.Limit(rps), burst),
+		pageSize: keycloakPageSize,
+	}
+}
+
+func (k *KeycloakGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
+	if k == nil {
+		return nil, nil
+	}
+	log := k.log
+	if k.cfg.BaseURL == "" || k.cfg.Realm == "" || k.cfg.ClientID == "" {
+		if log != nil {
+			log.Errorw("Keycloak resolver has incomplete configuration; cannot resolve groups",
+				"group", group,
+				"baseURL", k.cfg.BaseURL,
+				"realm", k.cfg.Realm,
+				"clientID", k.cfg.ClientID)
+		}
+		return nil, fmt.Errorf("keycloak resolver incomplete config: baseURL=%s, realm=%s, clientID=%s",
+			k.cfg.BaseURL, k.cfg.Realm, k.cfg.ClientID)
+	}
+	if v, ok := k.cache.get(group); ok {
+		if log != nil {
+			log.Debugw("Keycloak cache hit for group", "group", group, "membersCount", len(v))
+		}
+		return v, nil
+	}
+
+	// The lookup is shared by all callers asking for the group, so it must not end with the first
+	// caller's context. Each caller waits on its own context instead.
+	ch := k.lookups.DoChan(group, func() (any, error) {
+		// A lookup that finished while this one was being scheduled may have filled the cache
+		if v, ok := k.cache.get(group); ok {
+			return v, nil
+		}
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), keycloakLookupTimeouts*k.timeout)
+		defer cancel()
+		members, err := k.lookup(lookupCtx, group)
+		if err != nil {
+			return nil, err
+		}
+		k.cache.set(group, members)
+		return members, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Shared {
+			metrics.KeycloakGroupLookupsShared.WithLabelValues(k.cfg.Realm).Inc()
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return append([]string(nil), res.Val.([]string)...), nil
+	}
+}
+
+// lookup resolves the members of the group and all its subgroups from Keycloak
+func (k *KeycloakGroupMemberResolver) lookup(ctx context.Context, group string) ([]string, error) {
+	log := k.log
+	var groups []*gocloak.Group
+	err := k.call(ctx, "search_groups", func(ctx context.Context, token string) error {
+		var err error
+		groups, err = k.gocloak.GetGroups(ctx, token, k.cfg.Realm, gocloak.GetGroupsParams{Search: gocloak.StringP(group)})
+		return err
+	})
+	if err != nil {
+		if log != nil {
+			log.Errorw("Keycloak groups search failed", "group", group, "error", err)
+		}
+		return nil, err
+	}
+
+	groupID := ""
+	for _, g := range groups {
+		if g.ID != nil && g.Name != nil && strings.EqualFold(*g.Name, group) {
+			groupID = *g.ID
+			break
+		}
+	}
+	if groupID == "" {
+		if log != nil {
+			log.Warnw("Group not found in search results", "group", group)
+		}
+		return []string{}, nil
+	}
+
+	var out []string
+	if err := k.collectMembers(ctx, group, groupID, 0, map[string]struct{}{}, &out); err != nil {
+		if log != nil {
+			log.Errorw("Keycloak members fetch failed", "group", group, "groupID", groupID, "error", err)
+		}
+		return nil, err
+	}
+	out = normalizeMembers(out)
+	if log != nil {
+		log.Infow("Keycloak group member resolution completed successfully", "group", group, "finalResolvedCount", len(out))
+	}
+	return out, nil
+}
+
+// collectMembers appends the members of the group and its subgroups to out. Failures below the
+// requested group are logged and skipped, so one broken subgroup does not hide all approvers.
+func (k *KeycloakGroupMemberResolver) collectMembers(ctx context.Context, group, groupID string, depth int, visited map[string]struct{}, out *[]string) error {
+	visited[groupID] = struct{}{}
+	members, err := k.groupMembers(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	*out = append(*out, members...)
+
+	if depth >= keycloakMaxGroupDepth {
+		if k.log != nil {
+			k.log.Warnw("Keycloak subgroups nested too deeply; ignoring deeper levels", "group", group, "maxDepth", keycloakMaxGroupDepth)
+		}
+		return nil
+	}
+	children, err := k.childGroups(ctx, groupID)
+	if err != nil {
+		if k.log != nil {
+			k.log.Warnw("Keycloak subgroups fetch failed; continuing without them", "group", group, "groupID", groupID, "error", err)
+		}
+		return nil
+	}
+	for _, id := range children {
+		if _, seen := visited[id]; seen {
+			continue
+		}
+		if err := k.collectMembers(ctx, group, id, depth+1, visited, out); err != nil && k.log != nil {
+			k.log.Warnw("Subgroup members fetch failed", "group", group, "subgroupID", id, "error", err)
+		}
+	}
+	return nil
+}
+
+// groupMembers returns the identifiers of the direct members of a group, fetched page by page
+func (k *KeycloakGroupMemberResolver) groupMembers(ctx context.Context, groupID string) ([]string, error) {
+	var out []string
+	for first := 0; ; first += k.pageSize {
+		var page []*gocloak.User
+		err := k.call(ctx, "group_members", func(ctx context.Context, token string) error {
+			var err error
+			page, err = k.gocloak.GetGroupMembers(ctx, token, k.cfg.Realm, groupID, gocloak.GetGroupsParams{
+				First:               gocloak.IntP(first),
+				Max:                 gocloak.IntP(k.pageSize),
+				BriefRepresentation: gocloak.BoolP(true),
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page {
+			if m.Email != nil && *m.Email != "" {
+				out = append(out, *m.Email)
+			} else if m.Username != nil && *m.Username != "" {
+				out = append(out, *m.Username)
+			}
+		}
+		if len(page) < k.pageSize {
+			return out, nil
+		}
+	}
+}
+
+// childGroups returns the IDs of the direct subgroups of a group. Keycloak 23 and later list
+// them through the children endpoint; older versions embed them in the group representation.
+func (k *KeycloakGroupMemberResolver) childGroups(ctx context.Context, groupID string) ([]string, error) {
+	var ids []string
+	for first := 0; ; first += k.pageSize {
+		var page []*gocloak.Group
+		err := k.call(ctx, "child_groups", func(ctx context.Context, token string) error {
+			resp, err := k.gocloak.GetRequestWithBearerAuth(ctx, token).
+				SetResult(&page).
+				SetQueryParams(map[string]string{
+					"first":               strconv.Itoa(first),
+					"max":                 strconv.Itoa(k.pageSize),
+					"briefRepresentation": "true",
+				}).
+				Get(k.adminURL("groups", groupID, "children"))
+			// Error responses are checked first, their body may not be a group list
+			if resp != nil && resp.IsError() {
+				return &gocloak.APIError{Code: resp.StatusCode(), Message: resp.Status(), Type: gocloak.APIErrTypeUnknown}
+			}
+			return err
+		})
+		if first == 0 && keycloakErrorCode(err) == http.StatusNotFound {
+			return k.embeddedSubGroups(ctx, groupID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range page {
+			if g.ID != nil {
+				ids = append(ids, *g.ID)
+			}
+		}
+		if len(page) < k.pageSize {
+			return ids, nil
+		}
+	}
+}
+
+func (k *KeycloakGroupMemberResolver) embeddedSubGroups(ctx context.Context, groupID string) ([]string, error) {
+	var detail *gocloak.Group
+	err := k.call(ctx, "group", func(ctx context.Context, token string) error {
+		var err error
+		detail, err = k.gocloak.GetGroup(ctx, token, k.cfg.Realm, groupID)
+		return err
+	})
+	if err != nil || detail == nil || detail.SubGroups == nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(*detail.SubGroups))
+	for _, sg := range *detail.SubGroups {
+		if sg.ID != nil {
+			ids = append(ids, *sg.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (k *KeycloakGroupMemberResolver) adminURL(path ...string) string {
+	for i, p := range path {
+		path[i] = url.PathEscape(p)
+	}
+	return fmt.Sprintf("%s/admin/realms/%s/%s", strings.TrimRight(k.cfg.BaseURL, "/"), url.PathEscape(k.cfg.Realm), strings.Join(path, "/"))
+}
+
+// call runs one admin API request with the shared token. A request rejected with 401 is
+// retried once with a new token, e.g. after the token was revoked in Keycloak.
+func (k *KeycloakGroupMemberResolver) call(ctx context.Context, op string, fn func(ctx context.Context, token string) error) error {
+	token, err := k.getToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = k.do(ctx, op, func(ctx context.Context) error { return fn(ctx, token) })
+	if keycloakErrorCode(err) == http.StatusUnauthorized && k.cfg.ServiceAccountToken == "" {
+		k.invalidateToken(token)
+		if token, err = k.getToken(ctx); err != nil {
+			return err
+		}
+		err = k.do(ctx, op, func(ctx context.Context) error { return fn(ctx, token) })
+	}
+	return err
+}
+
+// do applies the rate limit and request timeout to one Keycloak request and records its metrics
+func (k *KeycloakGroupMemberResolver) do(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	realm := k.cfg.Realm
+	waitStart := time.Now()
+	if err := k.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	metrics.KeycloakRateLimitWait.WithLabelValues(realm).Observe(time.Since(waitStart).Seconds())
+
+	reqCtx, cancel := context.WithTimeout(ctx, k.timeout)
+	defer cancel()
+	start := time.Now()
+	err := fn(reqCtx)
+	metrics.KeycloakAPIRequestDuration.WithLabelValues(realm, op).Observe(time.Since(start).Seconds())
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.KeycloakAPIRequests.WithLabelValues(realm, op, result).Inc()
+	return err
+}
+
+// getToken returns the admin token, acquiring a new one via client credentials when the cached
+// token is about to expire
+func (k *KeycloakGroupMemberResolver) getToken(ctx context.Context) (string, error) {
+	// Use configured service account token if available
+	if k.cfg.ServiceAccountToken != "" {
+		return k.cfg.ServiceAccountToken, nil
+	}
+
+	k.tokenLock.Lock()
+	defer k.tokenLock.Unlock()
+	if k.token != "" && time.Now().Before(k.tokenExpiry) {
+		return k.token, nil
+	}
+
+	var token *gocloak.JWT
+	err := k.do(ctx, "token", func(ctx context.Context) error {
+		var err error
+		token, err = k.gocloak.GetToken(ctx, k.cfg.Realm, gocloak.TokenOptions{
+			ClientID:     &k.cfg.ClientID,
+			ClientSecret: &k.cfg.ClientSecret,
+			GrantType:    gocloak.StringP("client_credentials"),
+		})
+		return err
+	})
+	if err != nil {
+		if k.log != nil {
+			k.log.Errorw("Failed to acquire token", "clientID", k.cfg.ClientID, "realm", k.cfg.Realm, "error", err)
+		}
+		return "", err
+	}
+
+	lifetime := time.Duration(token.ExpiresIn)*time.Second - keycloakTokenLeeway
+	if lifetime <= 0 {
+		// Tokens living shorter than the leeway are reused for half their lifetime
+		lifetime = time.Duration(token.ExpiresIn) * time.Second / 2
+	}
+	k.token = token.AccessToken
+	k.tokenExpiry = time.Now().Add(lifetime)
+	if k.log != nil {
+		k.log.Debugw("Token acquired successfully", "clientID", k.cfg.ClientID, "expiresIn", token.ExpiresIn)
+	}
+	return k.token, nil
+}
+
+// invalidateToken drops the cached token unless another lookup already replaced it
+func (k *KeycloakGroupMemberResolver) invalidateToken(token string) {
+	k.tokenLock.Lock()
+	defer k.tokenLock.Unlock()
+	if k.token == token {
+		k.token = ""
+	}
+}
+
+// keycloakErrorCode returns the HTTP status of a Keycloak API error, or 0
+func keycloakErrorCode(err error) int {
+	var apiErr *gocloak.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
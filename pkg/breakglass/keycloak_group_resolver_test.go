@@ -0,0 +1,224 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+)
+
+// fakeKeycloak serves the subset of the Keycloak token and admin API used by the resolver
+type fakeKeycloak struct {
+	members       map[string][]string // group ID -> usernames
+	children      map[string][]string // group ID -> subgroup IDs
+	noChildrenAPI bool                // answer 404 on the children endpoint like Keycloak < 23
+	searchDelay   time.Duration
+	rejectTokens  atomic.Int32 // number of admin requests to answer with 401
+
+	tokens   atomic.Int32
+	searches atomic.Int32
+	pages    atomic.Int32
+}
+
+func (f *fakeKeycloak) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/realms/test/protocol/openid-connect/token" {
+			n := f.tokens.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token-" + strconv.Itoa(int(n)), "expires_in": 300})
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-") {
+			http.Error(w, `{"error":"HTTP 401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		if f.rejectTokens.Load() > 0 {
+			f.rejectTokens.Add(-1)
+			http.Error(w, `{"error":"HTTP 401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/realms/test/groups"), "/")
+		q := r.URL.Query()
+		switch {
+		case len(parts) == 1:
+			f.searches.Add(1)
+			time.Sleep(f.searchDelay)
+			var groups []map[string]any
+			if strings.EqualFold(q.Get("search"), "ops") {
+				groups = append(groups, map[string]any{"id": "g-ops", "name": "OPS"})
+			}
+			_ = json.NewEncoder(w).Encode(groups)
+		case len(parts) == 3 && parts[2] == "members":
+			f.pages.Add(1)
+			first, _ := strconv.Atoi(q.Get("first"))
+			limit, _ := strconv.Atoi(q.Get("max"))
+			users := []map[string]any{}
+			all := f.members[parts[1]]
+			for i := first; i < len(all) && i < first+limit; i++ {
+				users = append(users, map[string]any{"username": all[i], "email": all[i] + "@example.com"})
+			}
+			_ = json.NewEncoder(w).Encode(users)
+		case len(parts) == 3 && parts[2] == "children":
+			if f.noChildrenAPI {
+				http.Error(w, `{"error":"HTTP 404 Not Found"}`, http.StatusNotFound)
+				return
+			}
+			first, _ := strconv.Atoi(q.Get("first"))
+			limit, _ := strconv.Atoi(q.Get("max"))
+			groups := []map[string]any{}
+			all := f.children[parts[1]]
+			for i := first; i < len(all) && i < first+limit; i++ {
+				groups = append(groups, map[string]any{"id": all[i], "name": all[i]})
+			}
+			_ = json.NewEncoder(w).Encode(groups)
+		case len(parts) == 2:
+			subGroups := []map[string]any{}
+			for _, id := range f.children[parts[1]] {
+				subGroups = append(subGroups, map[string]any{"id": id, "name": id})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": parts[1], "name": parts[1], "subGroups": subGroups})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func newTestKeycloakResolver(t *testing.T, fake *fakeKeycloak) *KeycloakGroupMemberResolver {
+	srv := httptest.NewServer(fake.handler(t))
+	t.Cleanup(srv.Close)
+	return NewKeycloakGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.KeycloakRuntimeConfig{
+		BaseURL:           srv.URL,
+		Realm:             "test",
+		ClientID:          "breakglass",
+		ClientSecret:      "secret",
+		RequestsPerSecond: 1000,
+		Burst:             1000,
+	})
+}
+
+func TestKeycloakGroupMemberResolver_PaginationAndSubgroups(t *testing.T) {
+	fake := &fakeKeycloak{
+		members: map[string][]string{
+			"g-ops":   {"alice", "bob", "carol", "dave", "erin"},
+			"g-night": {"frank", "alice"},
+			"g-deep":  {"grace"},
+		},
+		children: map[string][]string{
+			"g-ops":   {"g-night"},
+			"g-night": {"g-deep", "g-ops"}, // cycle back to the parent is ignored
+		},
+	}
+	resolver := newTestKeycloakResolver(t, fake)
+	resolver.pageSize = 2
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com",
+		"erin@example.com", "frank@example.com", "grace@example.com",
+	}, members)
+	// g-ops needs 3 pages, g-night 2 (the second one empty) and g-deep 1
+	assert.Equal(t, int32(6), fake.pages.Load())
+}
+
+func TestKeycloakGroupMemberResolver_EmbeddedSubgroupsFallback(t *testing.T) {
+	fake := &fakeKeycloak{
+		members:       map[string][]string{"g-ops": {"alice"}, "g-night": {"bob"}},
+		children:      map[string][]string{"g-ops": {"g-night"}},
+		noChildrenAPI: true,
+	}
+	resolver := newTestKeycloakResolver(t, fake)
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, members)
+}
+
+func TestKeycloakGroupMemberResolver_UnknownGroup(t *testing.T) {
+	resolver := newTestKeycloakResolver(t, &fakeKeycloak{})
+
+	members, err := resolver.Members(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestKeycloakGroupMemberResolver_SharesTokenAndCache(t *testing.T) {
+	fake := &fakeKeycloak{members: map[string][]string{"g-ops": {"alice"}}}
+	resolver := newTestKeycloakResolver(t, fake)
+
+	for _, group := range []string{"ops", "other", "ops"} {
+		_, err := resolver.Members(context.Background(), group)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fake.tokens.Load(), "token should be reused across lookups")
+	assert.Equal(t, int32(2), fake.searches.Load(), "second lookup of ops should be served from the cache")
+}
+
+func TestKeycloakGroupMemberResolver_ConcurrentLookupsShareRequests(t *testing.T) {
+	fake := &fakeKeycloak{members: map[string][]string{"g-ops": {"alice"}}, searchDelay: 100 * time.Millisecond}
+	resolver := newTestKeycloakResolver(t, fake)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			members, err := resolver.Members(context.Background(), "ops")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"alice@example.com"}, members)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), fake.tokens.Load())
+	assert.Equal(t, int32(1), fake.searches.Load())
+}
+
+func TestKeycloakGroupMemberResolver_CanceledCallerDoesNotFailSharedLookup(t *testing.T) {
+	fake := &fakeKeycloak{members: map[string][]string{"g-ops": {"alice"}}, searchDelay: 200 * time.Millisecond}
+	resolver := newTestKeycloakResolver(t, fake)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	first := make(chan error, 1)
+	go func() {
+		_, err := resolver.Members(ctx, "ops")
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err, "a waiter must not inherit the first caller's deadline")
+	assert.Equal(t, []string{"alice@example.com"}, members)
+	assert.ErrorIs(t, <-first, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), fake.searches.Load())
+}
+
+func TestKeycloakGroupMemberResolver_RetriesWithNewTokenOn401(t *testing.T) {
+	fake := &fakeKeycloak{members: map[string][]string{"g-ops": {"alice"}}}
+	fake.rejectTokens.Store(1)
+	resolver := newTestKeycloakResolver(t, fake)
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com"}, members)
+	assert.Equal(t, int32(2), fake.tokens.Load())
+}
+
+func TestKeycloakGroupMemberResolver_IncompleteConfig(t *testing.T) {
+	resolver := NewKeycloakGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.KeycloakRuntimeConfig{BaseURL: "https://kc.example.com"})
+	_, err := resolver.Members(context.Background(), "ops")
+	assert.Error(t, err)
+}
@@ -0,0 +1,239 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/ldap"
+	"go.uber.org/zap"
+)
+
+// Defaults for LDAP group sync settings left empty in the IdentityProvider
+const (
+	DefaultLDAPUserFilter         = "(objectClass=person)"
+	DefaultLDAPGroupFilter        = "(|(objectClass=group)(objectClass=groupOfNames)(objectClass=groupOfUniqueNames))"
+	DefaultLDAPMemberOfAttribute  = "memberOf"
+	DefaultLDAPGroupNameAttribute = "cn"
+	DefaultLDAPMaxNestingDepth    = 10
+	DefaultLDAPPageSize           = 500
+	DefaultLDAPRequestTimeout     = 10 * time.Second
+)
+
+// DefaultLDAPUserIdentifierAttributes are tried in order to identify a user
+var DefaultLDAPUserIdentifierAttributes = []string{"mail", "userPrincipalName", "uid"}
+
+// LDAPGroupMemberResolver resolves group members with LDAP searches. The group is looked up by
+// name under the group search base; users are the entries whose memberOf attribute names the
+// group or, depending on the nesting mode, one of its subgroups.
+type LDAPGroupMemberResolver struct {
+	log     *zap.SugaredLogger
+	cfg     cfgpkg.LDAPRuntimeConfig
+	cache   *kcCache
+	timeout time.Duration
+}
+
+func NewLDAPGroupMemberResolver(log *zap.SugaredLogger, cfg cfgpkg.LDAPRuntimeConfig) *LDAPGroupMemberResolver {
+	ttl := 10 * time.Minute
+	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil && d > 0 {
+		ttl = d
+	}
+	timeout := DefaultLDAPRequestTimeout
+	if d, err := time.ParseDuration(cfg.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = DefaultLDAPUserFilter
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = DefaultLDAPGroupFilter
+	}
+	if cfg.MemberOfAttribute == "" {
+		cfg.MemberOfAttribute = DefaultLDAPMemberOfAttribute
+	}
+	if cfg.GroupNameAttribute == "" {
+		cfg.GroupNameAttribute = DefaultLDAPGroupNameAttribute
+	}
+	if len(cfg.UserIdentifierAttributes) == 0 {
+		cfg.UserIdentifierAttributes = DefaultLDAPUserIdentifierAttributes
+	}
+	if cfg.NestedGroups == "" {
+		cfg.NestedGroups = string(telekomv1alpha1.LDAPNestedGroupsRecursive)
+	}
+	if cfg.MaxNestingDepth <= 0 {
+		cfg.MaxNestingDepth = DefaultLDAPMaxNestingDepth
+	}
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = DefaultLDAPPageSize
+	}
+	return &LDAPGroupMemberResolver{log: log, cfg: cfg, cache: newKCCache(ttl), timeout: timeout}
+}
+
+// ConnectOptions returns the connection settings of the resolver
+func (l *LDAPGroupMemberResolver) ConnectOptions() ldap.ConnectOptions {
+	return ldap.ConnectOptions{
+		URL:                  l.cfg.URL,
+		StartTLS:             l.cfg.StartTLS,
+		BindDN:               l.cfg.BindDN,
+		Password:             l.cfg.BindPassword,
+		CertificateAuthority: l.cfg.CertificateAuthority,
+		InsecureSkipVerify:   l.cfg.InsecureSkipVerify,
+	}
+}
+
+func (l *LDAPGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
+	if l == nil {
+		return nil, nil
+	}
+	log := l.log
+	if l.cfg.URL == "" || l.cfg.UserSearchBase == "" || l.cfg.GroupSearchBase == "" {
+		return nil, fmt.Errorf("ldap resolver incomplete config: url=%s, userSearchBase=%s, groupSearchBase=%s",
+			l.cfg.URL, l.cfg.UserSearchBase, l.cfg.GroupSearchBase)
+	}
+	if v, ok := l.cache.get(group); ok {
+		if log != nil {
+			log.Debugw("LDAP cache hit for group", "group", group, "membersCount", len(v))
+		}
+		return v, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	conn, err := ldap.Connect(ctx, l.ConnectOptions())
+	if err != nil {
+		if log != nil {
+			log.Errorw("Failed to connect to LDAP server", "url", l.cfg.URL, "bindDN", l.cfg.BindDN, "error", err)
+		}
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// 1. Find the group entries by name
+	groupDNs, err := l.searchDNs(ctx, conn, l.cfg.GroupSearchBase,
+		fmt.Sprintf("(&%s(%s=%s))", l.cfg.GroupFilter, l.cfg.GroupNameAttribute, ldap.EscapeFilter(group)))
+	if err != nil {
+		if log != nil {
+			log.Errorw("LDAP group search failed", "group", group, "error", err)
+		}
+		return nil, err
+	}
+	if len(groupDNs) == 0 {
+		if log != nil {
+			log.Warnw("Group not found in LDAP", "group", group, "groupSearchBase", l.cfg.GroupSearchBase)
+		}
+		l.cache.set(group, []string{})
+		return []string{}, nil
+	}
+
+	// 2. Expand subgroups unless the server resolves nesting itself
+	memberFilter := fmt.Sprintf("(%s=%%s)", l.cfg.MemberOfAttribute)
+	switch telekomv1alpha1.LDAPNestedGroupMode(l.cfg.NestedGroups) {
+	case telekomv1alpha1.LDAPNestedGroupsInChain:
+		memberFilter = fmt.Sprintf("(%s:%s:=%%s)", l.cfg.MemberOfAttribute, ldap.MatchingRuleInChain)
+	case telekomv1alpha1.LDAPNestedGroupsRecursive:
+		groupDNs, err = l.expandSubgroups(ctx, conn, group, groupDNs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Collect the users of every group
+	var members []string
+	for _, dn := range groupDNs {
+		entries, err := conn.Search(ctx, &ldap.SearchRequest{
+			BaseDN:     l.cfg.UserSearchBase,
+			Scope:      ldap.ScopeWholeSubtree,
+			Filter:     fmt.Sprintf("(&%s%s)", l.cfg.UserFilter, fmt.Sprintf(memberFilter, ldap.EscapeFilter(dn))),
+			Attributes: l.cfg.UserIdentifierAttributes,
+			PageSize:   l.cfg.PageSize,
+		})
+		if err != nil {
+			if log != nil {
+				log.Errorw("LDAP member search failed", "group", group, "groupDN", dn, "error", err)
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if id := l.identifier(e); id != "" {
+				members = append(members, id)
+			} else if log != nil {
+				log.Debugw("Skipping LDAP user without identifier attribute", "dn", e.DN, "attributes", l.cfg.UserIdentifierAttributes)
+			}
+		}
+	}
+
+	members = normalizeMembers(members)
+	if log != nil {
+		log.Debugw("Resolved LDAP group members", "group", group, "groupDNs", len(groupDNs), "membersCount", len(members))
+	}
+	l.cache.set(group, members)
+	return members, nil
+}
+
+// expandSubgroups walks groups whose memberOf names an already known group, breadth first,
+// up to MaxNestingDepth levels. Cycles are cut by tracking visited DNs.
+func (l *LDAPGroupMemberResolver) expandSubgroups(ctx context.Context, conn *ldap.Conn, group string, roots []string) ([]string, error) {
+	visited := make(map[string]bool, len(roots))
+	for _, dn := range roots {
+		visited[dn] = true
+	}
+	all := append([]string(nil), roots...)
+	level := roots
+	for depth := 0; depth < l.cfg.MaxNestingDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, dn := range level {
+			subgroups, err := l.searchDNs(ctx, conn, l.cfg.GroupSearchBase,
+				fmt.Sprintf("(&%s(%s=%s))", l.cfg.GroupFilter, l.cfg.MemberOfAttribute, ldap.EscapeFilter(dn)))
+			if err != nil {
+				if l.log != nil {
+					l.log.Errorw("LDAP subgroup search failed", "group", group, "groupDN", dn, "error", err)
+				}
+				return nil, err
+			}
+			for _, sub := range subgroups {
+				if !visited[sub] {
+					visited[sub] = true
+					next = append(next, sub)
+				}
+			}
+		}
+		all = append(all, next...)
+		level = next
+	}
+	if len(level) > 0 && l.log != nil {
+		l.log.Warnw("LDAP group nesting exceeds maxNestingDepth; deeper subgroups are ignored",
+			"group", group, "maxNestingDepth", l.cfg.MaxNestingDepth)
+	}
+	return all, nil
+}
+
+func (l *LDAPGroupMemberResolver) searchDNs(ctx context.Context, conn *ldap.Conn, base, filter string) ([]string, error) {
+	entries, err := conn.Search(ctx, &ldap.SearchRequest{
+		BaseDN: base,
+		Scope:  ldap.ScopeWholeSubtree,
+		Filter: filter,
+		// "1.1" requests no attributes (RFC 4511, section 4.5.1.8)
+		Attributes: []string{"1.1"},
+		PageSize:   l.cfg.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	dns := make([]string, 0, len(entries))
+	for _, e := range entries {
+		dns = append(dns, e.DN)
+	}
+	return dns, nil
+}
+
+// identifier returns the first non-empty value of the configured identifier attributes
+func (l *LDAPGroupMemberResolver) identifier(e *ldap.Entry) string {
+	for _, attr := range l.cfg.UserIdentifierAttributes {
+		if v := e.Value(attr); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package breakglass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/ldap"
+	"github.com/telekom/k8s-breakglass/pkg/ldap/ldaptest"
+	"go.uber.org/zap"
+)
+
+const (
+	testLDAPBindDN   = "cn=svc-breakglass,ou=services,dc=example,dc=com"
+	testLDAPPassword = "bind-secret"
+	testOpsDN        = "cn=ops,ou=groups,dc=example,dc=com"
+	testOncallDN     = "cn=ops-oncall,ou=groups,dc=example,dc=com"
+	testOncallEUDN   = "cn=ops-oncall-eu,ou=groups,dc=example,dc=com"
+)
+
+// newTestDirectory builds a directory with the nesting ops <- ops-oncall <- ops-oncall-eu (<- ops, a cycle)
+func newTestDirectory(t *testing.T) *ldaptest.Server {
+	t.Helper()
+	srv := ldaptest.NewServer(testLDAPBindDN, testLDAPPassword)
+	t.Cleanup(srv.Close)
+
+	srv.AddEntry(testOpsDN, map[string][]string{"objectClass": {"group"}, "cn": {"ops"}, "memberOf": {testOncallEUDN}})
+	srv.AddEntry(testOncallDN, map[string][]string{"objectClass": {"group"}, "cn": {"ops-oncall"}, "memberOf": {testOpsDN}})
+	srv.AddEntry(testOncallEUDN, map[string][]string{"objectClass": {"groupOfNames"}, "cn": {"ops-oncall-eu"}, "memberOf": {testOncallDN}})
+	srv.AddEntry("cn=dev,ou=groups,dc=example,dc=com", map[string][]string{"objectClass": {"group"}, "cn": {"dev"}})
+
+	srv.AddEntry("cn=Alice,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "mail": {"Alice@example.com"}, "memberOf": {testOpsDN},
+	})
+	srv.AddEntry("cn=Bob,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "userPrincipalName": {"bob@corp.example.com"}, "memberOf": {testOncallDN},
+	})
+	srv.AddEntry("cn=Carol,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "mail": {"carol@example.com"}, "memberOf": {testOncallEUDN, testOpsDN},
+	})
+	srv.AddEntry("cn=Dave,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "mail": {"dave@example.com"}, "memberOf": {"cn=dev,ou=groups,dc=example,dc=com"},
+	})
+	// Service entries without an identifier attribute are skipped
+	srv.AddEntry("cn=robot,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "memberOf": {testOpsDN},
+	})
+	return srv
+}
+
+func testLDAPConfig(url string) cfgpkg.LDAPRuntimeConfig {
+	return cfgpkg.LDAPRuntimeConfig{
+		URL:             url,
+		BindDN:          testLDAPBindDN,
+		BindPassword:    testLDAPPassword,
+		UserSearchBase:  "ou=people,dc=example,dc=com",
+		GroupSearchBase: "ou=groups,dc=example,dc=com",
+	}
+}
+
+func TestLDAPGroupMemberResolverNestedGroups(t *testing.T) {
+	srv := newTestDirectory(t)
+
+	tests := []struct {
+		name string
+		mode string
+		want []string
+	}{
+		{name: "direct members only", mode: "None", want: []string{"alice@example.com", "carol@example.com"}},
+		{name: "recursive", mode: "Recursive", want: []string{"alice@example.com", "carol@example.com", "bob@corp.example.com"}},
+		{name: "recursive is the default", want: []string{"alice@example.com", "carol@example.com", "bob@corp.example.com"}},
+		{name: "in chain", mode: "InChain", want: []string{"alice@example.com", "bob@corp.example.com", "carol@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testLDAPConfig(srv.URL)
+			cfg.NestedGroups = tt.mode
+			resolver := NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg)
+
+			members, err := resolver.Members(context.Background(), "ops")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, members)
+		})
+	}
+}
+
+func TestLDAPGroupMemberResolverDepthLimit(t *testing.T) {
+	srv := newTestDirectory(t)
+	// Bob is one level below ops (ops-oncall), Erin two levels (ops-oncall-eu)
+	srv.AddEntry("cn=Erin,ou=people,dc=example,dc=com", map[string][]string{
+		"objectClass": {"person"}, "mail": {"erin@example.com"}, "memberOf": {testOncallEUDN},
+	})
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.MaxNestingDepth = 1
+	members, err := NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg).Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Contains(t, members, "bob@corp.example.com")
+	assert.NotContains(t, members, "erin@example.com")
+
+	cfg.MaxNestingDepth = 2
+	members, err = NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg).Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Contains(t, members, "erin@example.com")
+}
+
+func TestLDAPGroupMemberResolverPagingAndCache(t *testing.T) {
+	srv := newTestDirectory(t)
+	cfg := testLDAPConfig(srv.URL)
+	cfg.NestedGroups = "None"
+	cfg.PageSize = 1
+	resolver := NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg)
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice@example.com", "carol@example.com"}, members)
+	// 1 group search + 3 member pages (alice, carol, robot)
+	searches := len(srv.Searches())
+	assert.Equal(t, 4, searches)
+
+	_, err = resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Len(t, srv.Searches(), searches, "second lookup is served from the cache")
+}
+
+func TestLDAPGroupMemberResolverUnknownGroupAndEscaping(t *testing.T) {
+	srv := newTestDirectory(t)
+	resolver := NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), testLDAPConfig(srv.URL))
+
+	members, err := resolver.Members(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	// Filter metacharacters in group names must not widen the search
+	members, err = resolver.Members(context.Background(), "*")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+	assert.Contains(t, srv.Searches(), `(&(|(objectClass=group)(objectClass=groupOfNames)(objectClass=groupOfUniqueNames))(cn=\2a))`)
+}
+
+func TestLDAPGroupMemberResolverErrors(t *testing.T) {
+	srv := newTestDirectory(t)
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.BindPassword = "wrong"
+	_, err := NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg).Members(context.Background(), "ops")
+	require.Error(t, err)
+	assert.True(t, ldap.IsBindError(err))
+
+	cfg = testLDAPConfig(srv.URL)
+	cfg.GroupSearchBase = ""
+	_, err = NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg).Members(context.Background(), "ops")
+	assert.Error(t, err)
+
+	cfg = testLDAPConfig(srv.URL)
+	cfg.UserFilter = "(objectClass=person"
+	_, err = NewLDAPGroupMemberResolver(zap.NewNop().Sugar(), cfg).Members(context.Background(), "ops")
+	assert.Error(t, err)
+}
+
+func TestCreateResolverForIDP_LDAP(t *testing.T) {
+	updater := &EscalationStatusUpdater{}
+	cfg := testLDAPConfig("ldap://ldap.example.com")
+	resolver := updater.createResolverForIDP(&cfgpkg.IdentityProviderConfig{LDAP: &cfg}, zap.NewNop().Sugar())
+	assert.IsType(t, &LDAPGroupMemberResolver{}, resolver)
+
+	assert.IsType(t, &LDAPGroupMemberResolver{}, SetupResolver(&cfgpkg.IdentityProviderConfig{LDAP: &cfg}, zap.NewNop().Sugar()))
+}
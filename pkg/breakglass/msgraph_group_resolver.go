@@ -0,0 +1,87 @@
+package breakglass
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/msgraph"
+	"go.uber.org/zap"
+)
+
+// DefaultMicrosoftGraphRequestTimeout bounds the requests made to resolve one group
+const DefaultMicrosoftGraphRequestTimeout = 10 * time.Second
+
+// MicrosoftGraphGroupMemberResolver resolves the transitive user members of Entra ID groups
+// through Microsoft Graph. Groups are referenced by display name or object ID.
+type MicrosoftGraphGroupMemberResolver struct {
+	log     *zap.SugaredLogger
+	client  *msgraph.Client
+	err     error
+	cache   *kcCache
+	timeout time.Duration
+}
+
+func NewMicrosoftGraphGroupMemberResolver(log *zap.SugaredLogger, cfg cfgpkg.MicrosoftGraphRuntimeConfig) *MicrosoftGraphGroupMemberResolver {
+	ttl := 10 * time.Minute
+	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil && d > 0 {
+		ttl = d
+	}
+	timeout := DefaultMicrosoftGraphRequestTimeout
+	if d, err := time.ParseDuration(cfg.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	// A configuration error is reported by Members so the resolver can be created unconditionally
+	client, err := msgraph.NewClient(msgraph.Options{
+		TenantID:                 cfg.TenantID,
+		ClientID:                 cfg.ClientID,
+		ClientSecret:             cfg.ClientSecret,
+		AuthorityURL:             cfg.AuthorityURL,
+		GraphURL:                 cfg.GraphURL,
+		UserIdentifierAttributes: cfg.UserIdentifierAttributes,
+		CertificateAuthority:     cfg.CertificateAuthority,
+		InsecureSkipVerify:       cfg.InsecureSkipVerify,
+	})
+	return &MicrosoftGraphGroupMemberResolver{log: log, client: client, err: err, cache: newKCCache(ttl), timeout: timeout}
+}
+
+func (m *MicrosoftGraphGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	log := m.log
+	if m.err != nil {
+		return nil, m.err
+	}
+	if v, ok := m.cache.get(group); ok {
+		if log != nil {
+			log.Debugw("Microsoft Graph cache hit for group", "group", group, "membersCount", len(v))
+		}
+		return v, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	members, err := m.client.Members(ctx, group)
+	if errors.Is(err, msgraph.ErrGroupNotFound) {
+		if log != nil {
+			log.Warnw("Group not found in Microsoft Graph", "group", group)
+		}
+		m.cache.set(group, []string{})
+		return []string{}, nil
+	}
+	if err != nil {
+		if log != nil {
+			log.Errorw("Failed to resolve Microsoft Graph group members", "group", group, "error", err)
+		}
+		return nil, err
+	}
+
+	members = normalizeMembers(members)
+	if log != nil {
+		log.Debugw("Resolved Microsoft Graph group members", "group", group, "membersCount", len(members))
+	}
+	m.cache.set(group, members)
+	return members, nil
+}
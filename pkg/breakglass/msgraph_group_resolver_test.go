@@ -0,0 +1,82 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestMicrosoftGraphGroupMemberResolver(t *testing.T) {
+	var memberRequests atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+		case r.URL.Path == "/v1.0/groups":
+			var value []map[string]string
+			if r.URL.Query().Get("$filter") == "displayName eq 'ops'" {
+				value = []map[string]string{{"id": "11111111-2222-3333-4444-555555555555"}}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"value": value})
+		default:
+			memberRequests.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"value": []map[string]any{
+				{"mail": "Alice@Example.com", "accountEnabled": true},
+				{"mail": "alice@example.com", "accountEnabled": true},
+				{"userPrincipalName": "bob@example.com"},
+			}})
+		}
+	}))
+	defer srv.Close()
+
+	resolver := NewMicrosoftGraphGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.MicrosoftGraphRuntimeConfig{
+		TenantID:             "tenant",
+		ClientID:             "client",
+		ClientSecret:         "secret",
+		AuthorityURL:         srv.URL,
+		GraphURL:             srv.URL,
+		CertificateAuthority: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})),
+	})
+
+	members, err := resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, members)
+
+	_, err = resolver.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), memberRequests.Load(), "members should be cached")
+
+	members, err = resolver.Members(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestMicrosoftGraphGroupMemberResolverInvalidConfig(t *testing.T) {
+	resolver := NewMicrosoftGraphGroupMemberResolver(zap.NewNop().Sugar(), cfgpkg.MicrosoftGraphRuntimeConfig{TenantID: "tenant"})
+	_, err := resolver.Members(context.Background(), "ops")
+	assert.Error(t, err)
+}
+
+func TestCreateResolverForIDP_MicrosoftGraphAndHTTPJSON(t *testing.T) {
+	updater := &EscalationStatusUpdater{}
+	log := zap.NewNop().Sugar()
+
+	resolver := updater.createResolverForIDP(&cfgpkg.IdentityProviderConfig{
+		MicrosoftGraph: &cfgpkg.MicrosoftGraphRuntimeConfig{TenantID: "t", ClientID: "c", ClientSecret: "s"},
+	}, log)
+	assert.IsType(t, &MicrosoftGraphGroupMemberResolver{}, resolver)
+
+	resolver = updater.createResolverForIDP(&cfgpkg.IdentityProviderConfig{
+		HTTPJSON: &cfgpkg.HTTPJSONRuntimeConfig{MembersURL: "https://directory.example.com/{group}", MembersPath: "$.members[*]"},
+	}, log)
+	assert.IsType(t, &HTTPJSONGroupMemberResolver{}, resolver)
+}
@@ -0,0 +1,391 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/mail"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultNotificationDigestInterval is used when neither the escalation, the approver preference
+	// nor the global configuration specify a digest interval
+	DefaultNotificationDigestInterval = 15 * time.Minute
+	// NotificationDigestTickInterval is how often due digests are checked and sent
+	NotificationDigestTickInterval = 30 * time.Second
+	// NotificationDigestRetryBackoff is the delay before a digest that failed to send is retried. It
+	// doubles with every failed attempt up to the digest interval.
+	NotificationDigestRetryBackoff = time.Minute
+)
+
+// Reasons reported when a request bypasses the digest
+const (
+	urgentReasonKeyword         = "keyword"
+	urgentReasonApprovalTimeout = "approval_timeout"
+	urgentReasonScheduledStart  = "scheduled_start"
+)
+
+// digestEntry is a pending request waiting to be included in an approver's next digest
+type digestEntry struct {
+	SessionName string
+	Request     mail.DigestRequest
+}
+
+type approverDigest struct {
+	due      time.Time
+	interval time.Duration
+	entries  []digestEntry
+	// failures counts the failed attempts to send the entries
+	failures int
+}
+
+// NotificationDigest buffers session request notifications per approver until their digest is due.
+// The buffer is kept in memory of the replica that handled the request; pending entries are lost when
+// the replica crashes. Failed sends are retried, see flushNotificationDigests.
+type NotificationDigest struct {
+	mu      sync.Mutex
+	pending map[string]*approverDigest // keyed by lower-cased approver email
+	now     func() time.Time
+}
+
+// NewNotificationDigest creates an empty digest buffer
+func NewNotificationDigest() *NotificationDigest {
+	return &NotificationDigest{pending: map[string]*approverDigest{}, now: time.Now}
+}
+
+// NextFlush returns when the digest for the approver will be sent if an entry is added now
+func (d *NotificationDigest) NextFlush(approver string, interval time.Duration) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if p, ok := d.pending[strings.ToLower(approver)]; ok {
+		return p.due
+	}
+	return d.now().Add(interval)
+}
+
+// Add buffers a request for the approver. The first entry of an empty digest schedules it
+// one interval from now; later entries join the already scheduled digest.
+func (d *NotificationDigest) Add(approver string, interval time.Duration, entry digestEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := strings.ToLower(approver)
+	p, ok := d.pending[key]
+	if !ok {
+		p = &approverDigest{due: d.now().Add(interval), interval: interval}
+		d.pending[key] = p
+	}
+	for _, e := range p.entries {
+		if e.SessionName == entry.SessionName {
+			return
+		}
+	}
+	p.entries = append(p.entries, entry)
+}
+
+// retry puts the entries of a digest that failed to send back into the buffer, merged with entries
+// added in the meantime, and schedules the next attempt after an exponential backoff
+func (d *NotificationDigest) retry(approver string, failed *approverDigest, now time.Time) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := strings.ToLower(approver)
+	failures := failed.failures + 1
+	backoff := NotificationDigestRetryBackoff
+	for i := 1; i < failures && backoff < failed.interval; i++ {
+		backoff *= 2
+	}
+	if backoff > failed.interval {
+		backoff = failed.interval
+	}
+	due := now.Add(backoff)
+
+	p, ok := d.pending[key]
+	if !ok {
+		d.pending[key] = &approverDigest{due: due, interval: failed.interval, entries: failed.entries, failures: failures}
+		return due
+	}
+	if due.Before(p.due) {
+		p.due = due
+	}
+	p.failures = failures
+	merged := append([]digestEntry(nil), failed.entries...)
+	for _, e := range p.entries {
+		known := false
+		for _, f := range failed.entries {
+			if f.SessionName == e.SessionName {
+				known = true
+				break
+			}
+		}
+		if !known {
+			merged = append(merged, e)
+		}
+	}
+	p.entries = merged
+	return p.due
+}
+
+// takeDue removes and returns all digests that are due at the given time, keyed by approver
+func (d *NotificationDigest) takeDue(now time.Time) map[string]*approverDigest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	due := map[string]*approverDigest{}
+	for approver, p := range d.pending {
+		if !now.Before(p.due) {
+			due[approver] = p
+			delete(d.pending, approver)
+		}
+	}
+	return due
+}
+
+// Len returns the number of approvers with a pending digest
+func (d *NotificationDigest) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.pending)
+}
+
+// notificationDigestInterval resolves whether the approver receives request notifications as a digest
+// and at which interval. Approver preferences take precedence over the escalation setting.
+func (wc BreakglassSessionController) notificationDigestInterval(approver string, escalation *v1alpha1.BreakglassEscalation) (time.Duration, bool) {
+	if wc.notificationDigest == nil {
+		return 0, false
+	}
+
+	interval := parseDigestInterval(wc.config.Notifications.DigestInterval, DefaultNotificationDigestInterval)
+	enabled := false
+	if escalation != nil && escalation.Spec.NotificationDigest != nil {
+		enabled = escalation.Spec.NotificationDigest.Enabled
+		interval = parseDigestInterval(escalation.Spec.NotificationDigest.Interval, interval)
+	}
+
+	if pref := findNotificationPreference(wc.config.Notifications.Preferences, approver); pref != nil {
+		switch strings.ToLower(pref.Mode) {
+		case config.NotificationModeImmediate:
+			enabled = false
+		case config.NotificationModeDigest:
+			enabled = true
+		}
+		interval = parseDigestInterval(pref.DigestInterval, interval)
+	}
+	return interval, enabled
+}
+
+func findNotificationPreference(prefs []config.NotificationPreference, approver string) *config.NotificationPreference {
+	for i := range prefs {
+		if strings.EqualFold(prefs[i].User, approver) {
+			return &prefs[i]
+		}
+	}
+	return nil
+}
+
+// parseDigestInterval parses a digest interval, clamping it to the allowed bounds and
+// falling back to def for empty or invalid values
+func parseDigestInterval(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+	if d < v1alpha1.MinNotificationDigestInterval {
+		return v1alpha1.MinNotificationDigestInterval
+	}
+	if d > v1alpha1.MaxNotificationDigestInterval {
+		return v1alpha1.MaxNotificationDigestInterval
+	}
+	return d
+}
+
+// urgentRequestReason returns why a request must bypass the digest, or "" if it can wait until flushAt.
+// Requests are urgent when their reason contains a configured keyword, or when they would time out
+// or reach their scheduled start before the digest is sent.
+func urgentRequestReason(bs v1alpha1.BreakglassSession, escalation *v1alpha1.BreakglassEscalation, flushAt time.Time) string {
+	if escalation != nil && escalation.Spec.NotificationDigest != nil && bs.Spec.RequestReason != "" {
+		reason := strings.ToLower(bs.Spec.RequestReason)
+		for _, kw := range escalation.Spec.NotificationDigest.UrgentReasonKeywords {
+			if kw != "" && strings.Contains(reason, strings.ToLower(kw)) {
+				return urgentReasonKeyword
+			}
+		}
+	}
+	if !bs.Status.TimeoutAt.IsZero() && bs.Status.TimeoutAt.Time.Before(flushAt) {
+		return urgentReasonApprovalTimeout
+	}
+	if bs.Spec.ScheduledStartTime != nil && !bs.Spec.ScheduledStartTime.IsZero() && bs.Spec.ScheduledStartTime.Time.Before(flushAt) {
+		return urgentReasonScheduledStart
+	}
+	return ""
+}
+
+// deferToDigest buffers the request notification for approvers in digest mode and returns the
+// approvers that still need an immediate email
+func (wc BreakglassSessionController) deferToDigest(
+	log *zap.SugaredLogger,
+	bs v1alpha1.BreakglassSession,
+	requestUsername string,
+	approvers []string,
+	escalation *v1alpha1.BreakglassEscalation,
+) []string {
+	if wc.notificationDigest == nil {
+		return approvers
+	}
+
+	escalationName := ""
+	if escalation != nil {
+		escalationName = escalation.Name
+	}
+
+	immediate := make([]string, 0, len(approvers))
+	for _, approver := range approvers {
+		interval, enabled := wc.notificationDigestInterval(approver, escalation)
+		if !enabled {
+			immediate = append(immediate, approver)
+			continue
+		}
+		if reason := urgentRequestReason(bs, escalation, wc.notificationDigest.NextFlush(approver, interval)); reason != "" {
+			log.Infow("Urgent request bypasses notification digest",
+				"session", bs.Name, "approver", approver, "reason", reason)
+			metrics.NotificationDigestUrgentBypass.WithLabelValues(escalationName, reason).Inc()
+			immediate = append(immediate, approver)
+			continue
+		}
+
+		wc.notificationDigest.Add(approver, interval, digestEntry{
+			SessionName: bs.Name,
+			Request:     wc.digestRequest(bs, requestUsername),
+		})
+		metrics.NotificationDigestDeferred.WithLabelValues(escalationName).Inc()
+		log.Debugw("Request notification deferred to digest",
+			"session", bs.Name, "approver", approver, "interval", interval.String())
+	}
+	return immediate
+}
+
+func (wc BreakglassSessionController) digestRequest(bs v1alpha1.BreakglassSession, requestUsername string) mail.DigestRequest {
+	requester := bs.Spec.User
+	if requestUsername != "" && requestUsername != bs.Spec.User {
+		requester = fmt.Sprintf("%s (%s)", requestUsername, bs.Spec.User)
+	}
+	requestedAt := bs.CreationTimestamp.Time
+	if requestedAt.IsZero() {
+		requestedAt = time.Now()
+	}
+	scheduled := ""
+	if bs.Spec.ScheduledStartTime != nil && !bs.Spec.ScheduledStartTime.IsZero() {
+		scheduled = bs.Spec.ScheduledStartTime.Format("2006-01-02 15:04:05 MST")
+	}
+	return mail.DigestRequest{
+		SessionName:        bs.Name,
+		Requester:          requester,
+		Cluster:            bs.Spec.Cluster,
+		Group:              bs.Spec.GrantedGroup,
+		Reason:             bs.Spec.RequestReason,
+		RequestedAt:        requestedAt.Format("2006-01-02 15:04:05 MST"),
+		ScheduledStartTime: scheduled,
+		URL:                fmt.Sprintf("%s/review?name=%s", wc.config.Frontend.BaseURL, bs.Name),
+	}
+}
+
+// RunNotificationDigest periodically sends due notification digests until ctx is cancelled.
+// It runs on every replica because digests are buffered by the replica that handled the request.
+func (wc *BreakglassSessionController) RunNotificationDigest(ctx context.Context) {
+	if wc.notificationDigest == nil {
+		return
+	}
+	ticker := time.NewTicker(NotificationDigestTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Deliver whatever is buffered so pending requests are not silently dropped on shutdown
+			wc.flushNotificationDigests(context.Background(), time.Now().Add(v1alpha1.MaxNotificationDigestInterval))
+			return
+		case now := <-ticker.C:
+			wc.flushNotificationDigests(ctx, now)
+		}
+	}
+}
+
+// flushNotificationDigests sends every digest that is due at now. Sessions that are no longer
+// pending approval are left out; digests without remaining entries are skipped. Digests that fail
+// to send are put back and retried with backoff for as long as their sessions stay pending.
+func (wc *BreakglassSessionController) flushNotificationDigests(ctx context.Context, now time.Time) {
+	for approver, digest := range wc.notificationDigest.takeDue(now) {
+		requests := make([]mail.DigestRequest, 0, len(digest.entries))
+		remaining := make([]digestEntry, 0, len(digest.entries))
+		for _, e := range digest.entries {
+			if wc.sessionManager != nil {
+				bs, err := wc.sessionManager.GetBreakglassSessionByName(ctx, e.SessionName)
+				if err != nil || !IsSessionPendingApproval(bs) {
+					continue
+				}
+			}
+			requests = append(requests, e.Request)
+			remaining = append(remaining, e)
+		}
+		if len(requests) == 0 {
+			metrics.NotificationDigestSent.WithLabelValues("empty").Inc()
+			continue
+		}
+		sort.Slice(requests, func(i, j int) bool { return requests[i].RequestedAt < requests[j].RequestedAt })
+
+		if err := wc.sendNotificationDigest(approver, digest.interval, requests); err != nil {
+			digest.entries = remaining
+			retryAt := wc.notificationDigest.retry(approver, digest, now)
+			wc.log.Warnw("Failed to send notification digest, retrying", "approver", approver, "requests", len(requests),
+				"attempt", digest.failures+1, "retryAt", retryAt, "error", err)
+			metrics.NotificationDigestSent.WithLabelValues("failed").Inc()
+			continue
+		}
+		metrics.NotificationDigestSent.WithLabelValues("sent").Inc()
+	}
+}
+
+func (wc *BreakglassSessionController) sendNotificationDigest(approver string, interval time.Duration, requests []mail.DigestRequest) error {
+	if wc.disableEmail {
+		return nil
+	}
+	brandingName := "Breakglass"
+	if wc.config.Frontend.BrandingName != "" {
+		brandingName = wc.config.Frontend.BrandingName
+	}
+
+	subject := fmt.Sprintf("%d breakglass request(s) awaiting your approval", len(requests))
+	rendered, err := wc.mailTemplates.Render(mail.TemplateRequestDigest, subject, mail.RequestDigestMailParams{
+		ApproverEmail: approver,
+		Requests:      requests,
+		Interval:      formatDuration(interval),
+		URL:           wc.config.Frontend.BaseURL + "/approvals/pending",
+		BrandingName:  brandingName,
+	})
+	if err != nil {
+		return fmt.Errorf("rendering digest: %w", err)
+	}
+
+	msg := mail.Message{Receivers: []string{approver}, Subject: rendered.Subject, HTML: rendered.HTML, Text: rendered.Text}
+	if wc.mailQueue != nil {
+		return wc.mailQueue.EnqueueMessage(fmt.Sprintf("digest-%s-%d", approver, time.Now().Unix()), msg)
+	}
+	if wc.mail != nil {
+		return mail.Deliver(wc.mail, msg)
+	}
+	return fmt.Errorf("no mail sender configured")
+}
+
+// WithNotificationDigest enables digest delivery of request notifications for approvers that opt in
+// (via the escalation or their preference). RunNotificationDigest must be started to send digests.
+func (b *BreakglassSessionController) WithNotificationDigest(digest *NotificationDigest) *BreakglassSessionController {
+	b.notificationDigest = digest
+	return b
+}
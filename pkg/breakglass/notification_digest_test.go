@@ -0,0 +1,235 @@
+package breakglass
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func digestEscalation(enabled bool, interval string, keywords ...string) *v1alpha1.BreakglassEscalation {
+	return &v1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "esc-digest"},
+		Spec: v1alpha1.BreakglassEscalationSpec{
+			NotificationDigest: &v1alpha1.NotificationDigestConfig{
+				Enabled:              enabled,
+				Interval:             interval,
+				UrgentReasonKeywords: keywords,
+			},
+		},
+	}
+}
+
+func TestNotificationDigest_AddAndTakeDue(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	d := NewNotificationDigest()
+	d.now = func() time.Time { return now }
+
+	assert.Equal(t, now.Add(15*time.Minute), d.NextFlush("a@example.com", 15*time.Minute))
+
+	d.Add("A@example.com", 15*time.Minute, digestEntry{SessionName: "s1"})
+	now = now.Add(5 * time.Minute)
+	// Later entries join the already scheduled digest, duplicates are ignored
+	d.Add("a@example.com", 15*time.Minute, digestEntry{SessionName: "s2"})
+	d.Add("a@example.com", 15*time.Minute, digestEntry{SessionName: "s2"})
+	assert.Equal(t, now.Add(10*time.Minute), d.NextFlush("a@example.com", 15*time.Minute))
+	assert.Equal(t, 1, d.Len())
+
+	assert.Empty(t, d.takeDue(now))
+
+	due := d.takeDue(now.Add(10 * time.Minute))
+	require.Contains(t, due, "a@example.com")
+	assert.Len(t, due["a@example.com"].entries, 2)
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestNotificationDigestInterval_Precedence(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Notifications.DigestInterval = "20m"
+	cfg.Notifications.Preferences = []config.NotificationPreference{
+		{User: "quiet@example.com", Mode: "digest", DigestInterval: "1h"},
+		{User: "Loud@example.com", Mode: "immediate"},
+	}
+	ctrl := BreakglassSessionController{config: cfg, notificationDigest: NewNotificationDigest()}
+
+	// Escalation enables digests with its own interval
+	interval, enabled := ctrl.notificationDigestInterval("other@example.com", digestEscalation(true, "30m"))
+	assert.True(t, enabled)
+	assert.Equal(t, 30*time.Minute, interval)
+
+	// Global default interval applies when the escalation does not set one
+	interval, enabled = ctrl.notificationDigestInterval("other@example.com", digestEscalation(true, ""))
+	assert.True(t, enabled)
+	assert.Equal(t, 20*time.Minute, interval)
+
+	// Without escalation digest settings approvers get immediate emails
+	_, enabled = ctrl.notificationDigestInterval("other@example.com", &v1alpha1.BreakglassEscalation{})
+	assert.False(t, enabled)
+
+	// User preferences win over the escalation in both directions
+	interval, enabled = ctrl.notificationDigestInterval("quiet@example.com", &v1alpha1.BreakglassEscalation{})
+	assert.True(t, enabled)
+	assert.Equal(t, time.Hour, interval)
+	_, enabled = ctrl.notificationDigestInterval("loud@example.com", digestEscalation(true, "30m"))
+	assert.False(t, enabled)
+
+	// Digests are disabled entirely without a digest buffer
+	ctrl.notificationDigest = nil
+	_, enabled = ctrl.notificationDigestInterval("quiet@example.com", digestEscalation(true, "30m"))
+	assert.False(t, enabled)
+}
+
+func TestParseDigestInterval(t *testing.T) {
+	assert.Equal(t, 15*time.Minute, parseDigestInterval("", 15*time.Minute))
+	assert.Equal(t, 15*time.Minute, parseDigestInterval("bogus", 15*time.Minute))
+	assert.Equal(t, 45*time.Minute, parseDigestInterval("45m", 15*time.Minute))
+	assert.Equal(t, v1alpha1.MinNotificationDigestInterval, parseDigestInterval("5s", 15*time.Minute))
+	assert.Equal(t, v1alpha1.MaxNotificationDigestInterval, parseDigestInterval("72h", 15*time.Minute))
+}
+
+func TestUrgentRequestReason(t *testing.T) {
+	flushAt := time.Now().Add(15 * time.Minute)
+	esc := digestEscalation(true, "15m", "sev1")
+
+	bs := v1alpha1.BreakglassSession{}
+	bs.Status.TimeoutAt = metav1.NewTime(time.Now().Add(time.Hour))
+	assert.Equal(t, "", urgentRequestReason(bs, esc, flushAt))
+
+	bs.Spec.RequestReason = "SEV1 outage in prod"
+	assert.Equal(t, urgentReasonKeyword, urgentRequestReason(bs, esc, flushAt))
+
+	bs.Spec.RequestReason = "routine maintenance"
+	bs.Status.TimeoutAt = metav1.NewTime(time.Now().Add(5 * time.Minute))
+	assert.Equal(t, urgentReasonApprovalTimeout, urgentRequestReason(bs, esc, flushAt))
+
+	bs.Status.TimeoutAt = metav1.Time{}
+	bs.Spec.ScheduledStartTime = &metav1.Time{Time: time.Now().Add(10 * time.Minute)}
+	assert.Equal(t, urgentReasonScheduledStart, urgentRequestReason(bs, esc, flushAt))
+}
+
+func TestDeferToDigest(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Notifications.Preferences = []config.NotificationPreference{{User: "loud@example.com", Mode: "immediate"}}
+	ctrl := BreakglassSessionController{config: cfg, notificationDigest: NewNotificationDigest()}
+	log := zap.NewNop().Sugar()
+
+	bs := v1alpha1.BreakglassSession{ObjectMeta: metav1.ObjectMeta{Name: "s1"}}
+	bs.Spec.User = "user@example.com"
+	bs.Status.TimeoutAt = metav1.NewTime(time.Now().Add(time.Hour))
+
+	immediate := ctrl.deferToDigest(log, bs, "User", []string{"oncall@example.com", "loud@example.com"}, digestEscalation(true, "15m", "sev1"))
+	assert.Equal(t, []string{"loud@example.com"}, immediate)
+	assert.Equal(t, 1, ctrl.notificationDigest.Len())
+
+	// Urgent requests are not deferred
+	bs.Name = "s2"
+	bs.Spec.RequestReason = "sev1"
+	immediate = ctrl.deferToDigest(log, bs, "User", []string{"oncall@example.com"}, digestEscalation(true, "15m", "sev1"))
+	assert.Equal(t, []string{"oncall@example.com"}, immediate)
+}
+
+func TestFlushNotificationDigests_SkipsDecidedSessions(t *testing.T) {
+	pending := &v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-1"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: v1alpha1.SessionStatePending},
+	}
+	approved := &v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "approved-1"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: v1alpha1.SessionStateApproved},
+	}
+	cli := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(pending, approved).Build()
+	sesmanager := SessionManager{Client: cli}
+
+	ctrl, sender := newCalendarTestController(t)
+	ctrl.sessionManager = &sesmanager
+	ctrl.notificationDigest = NewNotificationDigest()
+	ctrl.config.Frontend.BaseURL = "https://breakglass.example.com"
+
+	for _, bs := range []*v1alpha1.BreakglassSession{pending, approved} {
+		ctrl.notificationDigest.Add("oncall@example.com", time.Minute, digestEntry{
+			SessionName: bs.Name,
+			Request:     ctrl.digestRequest(*bs, ""),
+		})
+	}
+	// An approver whose requests were all decided receives no digest
+	ctrl.notificationDigest.Add("other@example.com", time.Minute, digestEntry{
+		SessionName: approved.Name,
+		Request:     ctrl.digestRequest(*approved, ""),
+	})
+
+	ctrl.flushNotificationDigests(context.Background(), time.Now().Add(2*time.Minute))
+
+	msgs := sender.waitFor(t, 1)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []string{"oncall@example.com"}, msgs[0].Receivers)
+	assert.Contains(t, msgs[0].Subject, "1 breakglass request")
+	assert.Contains(t, msgs[0].HTML, "https://breakglass.example.com/review?name=pending-1")
+	assert.False(t, strings.Contains(msgs[0].HTML, "approved-1"))
+	assert.Equal(t, 0, ctrl.notificationDigest.Len())
+}
+
+func TestFlushNotificationDigests_RetriesFailedSends(t *testing.T) {
+	pending := &v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-1"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: v1alpha1.SessionStatePending},
+	}
+	cli := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(pending).Build()
+
+	sender := &recordingMessageSender{}
+	ctrl := &BreakglassSessionController{
+		log:                zap.NewNop().Sugar(),
+		sessionManager:     &SessionManager{Client: cli},
+		notificationDigest: NewNotificationDigest(),
+	}
+	ctrl.notificationDigest.Add("oncall@example.com", 10*time.Minute, digestEntry{
+		SessionName: pending.Name,
+		Request:     ctrl.digestRequest(*pending, ""),
+	})
+
+	// without a mail sender every attempt fails and the entry is kept with a growing backoff
+	now := time.Now().Add(10 * time.Minute)
+	ctrl.flushNotificationDigests(context.Background(), now)
+	require.Equal(t, 1, ctrl.notificationDigest.Len())
+	assert.Equal(t, now.Add(NotificationDigestRetryBackoff), ctrl.notificationDigest.NextFlush("oncall@example.com", 10*time.Minute))
+
+	// a request added meanwhile joins the retry
+	ctrl.notificationDigest.Add("oncall@example.com", 10*time.Minute, digestEntry{SessionName: "deleted-1"})
+	now = now.Add(NotificationDigestRetryBackoff)
+	ctrl.flushNotificationDigests(context.Background(), now)
+	assert.Equal(t, now.Add(2*NotificationDigestRetryBackoff), ctrl.notificationDigest.NextFlush("oncall@example.com", 10*time.Minute))
+
+	ctrl.mail = sender
+	ctrl.flushNotificationDigests(context.Background(), now.Add(2*NotificationDigestRetryBackoff))
+	msgs := sender.waitFor(t, 1)
+	require.Len(t, msgs, 1)
+	assert.Contains(t, msgs[0].HTML, "pending-1")
+	assert.Equal(t, 0, ctrl.notificationDigest.Len())
+}
+
+func TestNotificationDigest_RetryBackoffIsCappedByInterval(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	d := NewNotificationDigest()
+	failed := &approverDigest{interval: 5 * time.Minute, entries: []digestEntry{{SessionName: "s1"}}, failures: 6}
+	assert.Equal(t, now.Add(5*time.Minute), d.retry("a@example.com", failed, now))
+
+	// retried entries merge into a digest scheduled in the meantime and keep the earlier due time
+	d = NewNotificationDigest()
+	d.now = func() time.Time { return now }
+	d.Add("a@example.com", time.Hour, digestEntry{SessionName: "s2"})
+	d.Add("a@example.com", time.Hour, digestEntry{SessionName: "s1"})
+	assert.Equal(t, now.Add(NotificationDigestRetryBackoff), d.retry("A@example.com", &approverDigest{interval: time.Hour, entries: []digestEntry{{SessionName: "s1"}}}, now))
+	due := d.takeDue(now.Add(time.Minute))
+	require.Contains(t, due, "a@example.com")
+	assert.Len(t, due["a@example.com"].entries, 2)
+}
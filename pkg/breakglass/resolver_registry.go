@@ -0,0 +1,69 @@
+package breakglass
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+)
+
+// ResolverRegistry keeps one long-lived group member resolver per identity provider, so caches,
+// admin tokens and rate limits survive between escalation status update runs. A resolver is
+// replaced when the group sync configuration of its identity provider changes.
+// It is safe for concurrent use.
+type ResolverRegistry struct {
+	mu        sync.Mutex
+	resolvers map[string]registeredResolver
+}
+
+type registeredResolver struct {
+	fingerprint string
+	resolver    GroupMemberResolver
+}
+
+func NewResolverRegistry() *ResolverRegistry {
+	return &ResolverRegistry{resolvers: map[string]registeredResolver{}}
+}
+
+// Get returns the resolver of the identity provider, calling build to create it on first use
+// or after a configuration change. Nil resolvers are not kept.
+func (r *ResolverRegistry) Get(idpConfig *cfgpkg.IdentityProviderConfig, build func() GroupMemberResolver) GroupMemberResolver {
+	fingerprint := resolverFingerprint(idpConfig)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.resolvers[idpConfig.Name]; ok && existing.fingerprint == fingerprint {
+		return existing.resolver
+	}
+	resolver := build()
+	if resolver == nil {
+		delete(r.resolvers, idpConfig.Name)
+		return nil
+	}
+	r.resolvers[idpConfig.Name] = registeredResolver{fingerprint: fingerprint, resolver: resolver}
+	return resolver
+}
+
+// Retain drops the resolvers of identity providers not in names, e.g. deleted providers
+func (r *ResolverRegistry) Retain(names []string) {
+	keep := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		keep[n] = struct{}{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n := range r.resolvers {
+		if _, ok := keep[n]; !ok {
+			delete(r.resolvers, n)
+		}
+	}
+}
+
+// resolverFingerprint hashes the group sync configuration, including credentials, of an
+// identity provider
+func resolverFingerprint(idpConfig *cfgpkg.IdentityProviderConfig) string {
+	raw, _ := json.Marshal([]any{idpConfig.Keycloak, idpConfig.LDAP, idpConfig.SCIM, idpConfig.MicrosoftGraph, idpConfig.HTTPJSON})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,38 @@
+package breakglass
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestResolverRegistry(t *testing.T) {
+	registry := NewResolverRegistry()
+	log := zap.NewNop().Sugar()
+	idp := &cfgpkg.IdentityProviderConfig{
+		Name:     "kc",
+		Keycloak: &cfgpkg.KeycloakRuntimeConfig{BaseURL: "https://kc.example.com", Realm: "r", ClientID: "c"},
+	}
+	builds := 0
+	build := func() GroupMemberResolver {
+		builds++
+		return NewKeycloakGroupMemberResolver(log, *idp.Keycloak)
+	}
+
+	first := registry.Get(idp, build)
+	assert.Same(t, first, registry.Get(idp, build), "unchanged config should reuse the resolver")
+	assert.Equal(t, 1, builds)
+
+	idp.Keycloak.ClientSecret = "rotated"
+	second := registry.Get(idp, build)
+	assert.NotSame(t, first, second, "changed config should replace the resolver")
+	assert.Equal(t, 2, builds)
+
+	registry.Retain([]string{"other"})
+	assert.NotSame(t, second, registry.Get(idp, build), "retain should drop unknown providers")
+	assert.Equal(t, 3, builds)
+
+	assert.Nil(t, registry.Get(&cfgpkg.IdentityProviderConfig{Name: "none"}, func() GroupMemberResolver { return nil }))
+}
@@ -0,0 +1,366 @@
+package breakglass
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeSCIMIdentityProviders struct {
+	idps  map[string]*config.IdentityProviderConfig
+	err   error
+	loads int
+}
+
+func (f *fakeSCIMIdentityProviders) LoadAllIdentityProviders(context.Context) (map[string]*config.IdentityProviderConfig, error) {
+	f.loads++
+	return f.idps, f.err
+}
+
+func newSCIMTestIDPs() *fakeSCIMIdentityProviders {
+	return &fakeSCIMIdentityProviders{idps: map[string]*config.IdentityProviderConfig{
+		"okta":     {Name: "okta", SCIM: &config.SCIMRuntimeConfig{BearerToken: "okta-token"}},
+		"entra":    {Name: "entra", SCIM: &config.SCIMRuntimeConfig{BearerToken: "entra-token"}},
+		"keycloak": {Name: "keycloak", Keycloak: &config.KeycloakRuntimeConfig{BaseURL: "https://kc.example.com"}},
+	}}
+}
+
+type scimTestServer struct {
+	engine *gin.Engine
+	client client.Client
+	ctrl   *SCIMController
+}
+
+func newSCIMTestServer(t *testing.T, idps SCIMIdentityProviderSource) *scimTestServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cli := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	ctrl := NewSCIMController(zap.NewNop().Sugar(), cli, idps)
+	engine := gin.New()
+	require.NoError(t, ctrl.Register(engine.Group("/api/"+ctrl.BasePath(), ctrl.Handlers()...)))
+	return &scimTestServer{engine: engine, client: cli, ctrl: ctrl}
+}
+
+func (s *scimTestServer) do(t *testing.T, token, method, path string, body any) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, "/api/scim/v2/"+path, reader)
+	req.Header.Set("Content-Type", scimContentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	var out map[string]any
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out), w.Body.String())
+	}
+	return w, out
+}
+
+func (s *scimTestServer) createUser(t *testing.T, token, userName, email string) string {
+	t.Helper()
+	w, out := s.do(t, token, http.MethodPost, "Users", map[string]any{
+		"schemas":  []string{scimUserSchema},
+		"userName": userName,
+		"emails":   []map[string]any{{"value": email, "type": "work", "primary": true}},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return out["id"].(string)
+}
+
+func (s *scimTestServer) createGroup(t *testing.T, token, name string, members ...map[string]any) string {
+	t.Helper()
+	w, out := s.do(t, token, http.MethodPost, "Groups", map[string]any{
+		"schemas":     []string{scimGroupSchema},
+		"displayName": name,
+		"members":     members,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return out["id"].(string)
+}
+
+func TestSCIMController_Authentication(t *testing.T) {
+	srv := newSCIMTestServer(t, newSCIMTestIDPs())
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "missing token", want: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic b2t0YS10b2tlbg==", want: http.StatusUnauthorized},
+		{name: "unknown token", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "valid token", header: "Bearer okta-token", want: http.StatusOK},
+		{name: "scheme is case-insensitive", header: "bearer okta-token", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/scim/v2/Users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, scimContentType, w.Header().Get("Content-Type"))
+			if tt.want == http.StatusUnauthorized {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, []any{scimErrorSchema}, body["schemas"])
+				assert.Equal(t, "401", body["status"])
+			}
+		})
+	}
+}
+
+func TestSCIMController_TokenCache(t *testing.T) {
+	idps := newSCIMTestIDPs()
+	srv := newSCIMTestServer(t, idps)
+	now := time.Now()
+	srv.ctrl.now = func() time.Time { return now }
+
+	w, _ := srv.do(t, "okta-token", http.MethodGet, "Users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w, _ = srv.do(t, "okta-token", http.MethodGet, "Users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, idps.loads, "tokens are cached")
+
+	// Rotated tokens take effect after the refresh interval
+	idps.idps["okta"].SCIM.BearerToken = "rotated"
+	now = now.Add(SCIMTokenRefreshInterval)
+	w, _ = srv.do(t, "okta-token", http.MethodGet, "Users", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w, _ = srv.do(t, "rotated", http.MethodGet, "Users", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A failed reload keeps the cached tokens
+	idps.err = errors.New("api server unavailable")
+	now = now.Add(SCIMTokenRefreshInterval)
+	w, _ = srv.do(t, "rotated", http.MethodGet, "Users", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSCIMController_SharedTokenIsRejected(t *testing.T) {
+	idps := newSCIMTestIDPs()
+	idps.idps["entra"].SCIM.BearerToken = "okta-token"
+	srv := newSCIMTestServer(t, idps)
+
+	w, _ := srv.do(t, "okta-token", http.MethodGet, "Users", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSCIMController_UserLifecycle(t *testing.T) {
+	srv := newSCIMTestServer(t, newSCIMTestIDPs())
+
+	id := srv.createUser(t, "okta-token", "alice", "Alice@example.com")
+
+	u := &v1alpha1.SCIMUser{}
+	require.NoError(t, srv.client.Get(context.Background(), client.ObjectKey{Name: id}, u))
+	assert.Equal(t, "okta", u.Labels[v1alpha1.SCIMIdentityProviderLabel])
+	assert.Equal(t, "okta", u.Spec.IdentityProvider)
+	assert.True(t, u.Spec.Active, "active defaults to true")
+
+	// userName is unique per identity provider, case-insensitively
+	w, out := srv.do(t, "okta-token", http.MethodPost, "Users", map[string]any{"userName": "ALICE"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, scimTypeUniqueness, out["scimType"])
+	// ...but another identity provider may use the same name
+	srv.createUser(t, "entra-token", "alice", "alice@entra.example.com")
+
+	w, out = srv.do(t, "okta-token", http.MethodGet, "Users?filter="+`userName%20eq%20%22Alice%22`, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 1, out["totalResults"])
+	assert.Equal(t, id, out["Resources"].([]any)[0].(map[string]any)["id"])
+
+	// Deactivation the way Okta sends it (no path) and the way Entra ID sends it (string value)
+	w, out = srv.do(t, "okta-token", http.MethodPatch, "Users/"+id, map[string]any{
+		"Operations": []map[string]any{{"op": "replace", "value": map[string]any{"active": false}}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, false, out["active"])
+	w, out = srv.do(t, "okta-token", http.MethodPatch, "Users/"+id, map[string]any{
+		"Operations": []map[string]any{
+			{"op": "Replace", "path": "active", "value": "True"},
+			{"op": "Replace", "path": `emails[type eq "work"].value`, "value": "alice.new@example.com"},
+			{"op": "Add", "path": "name.givenName", "value": "Alice"},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, true, out["active"])
+	assert.Equal(t, "alice.new@example.com", out["emails"].([]any)[0].(map[string]any)["value"])
+
+	w, out = srv.do(t, "okta-token", http.MethodPut, "Users/"+id, map[string]any{"userName": "alice2", "active": false})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "alice2", out["userName"])
+	assert.Nil(t, out["emails"])
+
+	// Resources of other identity providers are invisible
+	w, _ = srv.do(t, "entra-token", http.MethodGet, "Users/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = srv.do(t, "entra-token", http.MethodDelete, "Users/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = srv.do(t, "okta-token", http.MethodDelete, "Users/"+id, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w, out = srv.do(t, "okta-token", http.MethodGet, "Users/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404", out["status"])
+}
+
+func TestSCIMController_ListPaginationAndFilters(t *testing.T) {
+	srv := newSCIMTestServer(t, newSCIMTestIDPs())
+	for _, name := range []string{"a", "b", "c"} {
+		srv.createUser(t, "okta-token", name, name+"@example.com")
+	}
+
+	w, out := srv.do(t, "okta-token", http.MethodGet, "Users?startIndex=2&count=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []any{scimListResponseSchema}, out["schemas"])
+	assert.EqualValues(t, 3, out["totalResults"])
+	assert.EqualValues(t, 2, out["startIndex"])
+	assert.EqualValues(t, 1, out["itemsPerPage"])
+	assert.Len(t, out["Resources"], 1)
+
+	w, out = srv.do(t, "okta-token", http.MethodGet, "Users?startIndex=10", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, out["Resources"])
+
+	for _, filter := range []string{`userName%20co%20%22a%22`, `emails.value%20eq%20%22a%22`, `userName%20eq%20a`} {
+		w, out = srv.do(t, "okta-token", http.MethodGet, "Users?filter="+filter, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, filter)
+		assert.Equal(t, scimTypeInvalidFilter, out["scimType"], filter)
+	}
+	w, _ = srv.do(t, "okta-token", http.MethodGet, "Users?count=x", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSCIMController_GroupLifecycle(t *testing.T) {
+	srv := newSCIMTestServer(t, newSCIMTestIDPs())
+	alice := srv.createUser(t, "okta-token", "alice", "alice@example.com")
+	bob := srv.createUser(t, "okta-token", "bob", "bob@example.com")
+	entraUser := srv.createUser(t, "entra-token", "eve", "eve@example.com")
+
+	oncall := srv.createGroup(t, "okta-token", "ops-oncall", map[string]any{"value": bob})
+	ops := srv.createGroup(t, "okta-token", "ops", map[string]any{"value": alice}, map[string]any{"value": oncall})
+
+	g := &v1alpha1.SCIMGroup{}
+	require.NoError(t, srv.client.Get(context.Background(), client.ObjectKey{Name: ops}, g))
+	assert.Equal(t, []v1alpha1.SCIMMember{
+		{Value: alice, Type: v1alpha1.SCIMMemberTypeUser},
+		{Value: oncall, Type: v1alpha1.SCIMMemberTypeGroup},
+	}, g.Spec.Members, "member types are resolved")
+
+	// Names are unique; members must exist within the identity provider
+	w, out := srv.do(t, "okta-token", http.MethodPost, "Groups", map[string]any{"displayName": "OPS"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, scimTypeUniqueness, out["scimType"])
+	w, out = srv.do(t, "okta-token", http.MethodPost, "Groups", map[string]any{"displayName": "x", "members": []map[string]any{{"value": entraUser}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, scimTypeInvalidValue, out["scimType"])
+
+	// Entra ID style membership changes
+	w, _ = srv.do(t, "okta-token", http.MethodPatch, "Groups/"+ops, map[string]any{
+		"Operations": []map[string]any{
+			{"op": "Add", "path": "members", "value": []map[string]any{{"value": bob}, {"value": alice}}},
+			{"op": "Remove", "path": `members[value eq "` + alice + `"]`},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, srv.client.Get(context.Background(), client.ObjectKey{Name: ops}, g))
+	assert.ElementsMatch(t, []string{oncall, bob}, scimMemberValues(g.Spec.Members))
+
+	// Okta style rename and membership replacement
+	w, out = srv.do(t, "okta-token", http.MethodPatch, "Groups/"+ops, map[string]any{
+		"Operations": []map[string]any{
+			{"op": "replace", "value": map[string]any{"id": ops, "displayName": "operations"}},
+			{"op": "replace", "path": "members", "value": []map[string]any{{"value": alice}}},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "operations", out["displayName"])
+	require.NoError(t, srv.client.Get(context.Background(), client.ObjectKey{Name: ops}, g))
+	assert.Equal(t, []string{alice}, scimMemberValues(g.Spec.Members))
+
+	w, out = srv.do(t, "okta-token", http.MethodGet, "Groups?filter="+`displayName%20eq%20%22Operations%22`+"&excludedAttributes=members", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.EqualValues(t, 1, out["totalResults"])
+	assert.Nil(t, out["Resources"].([]any)[0].(map[string]any)["members"])
+
+	// A group cannot contain itself
+	w, _ = srv.do(t, "okta-token", http.MethodPatch, "Groups/"+ops, map[string]any{
+		"Operations": []map[string]any{{"op": "add", "path": "members", "value": []map[string]any{{"value": ops}}}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, out = srv.do(t, "okta-token", http.MethodPatch, "Groups/"+ops, map[string]any{
+		"Operations": []map[string]any{{"op": "remove"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, scimTypeNoTarget, out["scimType"])
+
+	// Deleting a user or group removes it from all groups
+	w, _ = srv.do(t, "okta-token", http.MethodPatch, "Groups/"+ops, map[string]any{
+		"Operations": []map[string]any{{"op": "add", "path": "members", "value": []map[string]any{{"value": oncall, "type": "Group"}}}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	w, _ = srv.do(t, "okta-token", http.MethodDelete, "Users/"+alice, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w, _ = srv.do(t, "okta-token", http.MethodDelete, "Groups/"+oncall, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NoError(t, srv.client.Get(context.Background(), client.ObjectKey{Name: ops}, g))
+	assert.Empty(t, g.Spec.Members)
+}
+
+func TestSCIMController_ServiceProviderConfig(t *testing.T) {
+	srv := newSCIMTestServer(t, newSCIMTestIDPs())
+	w, out := srv.do(t, "okta-token", http.MethodGet, "ServiceProviderConfig", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, out["patch"].(map[string]any)["supported"])
+	assert.Equal(t, false, out["bulk"].(map[string]any)["supported"])
+	assert.EqualValues(t, SCIMMaxResults, out["filter"].(map[string]any)["maxResults"])
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	spec := v1alpha1.SCIMUserSpec{UserName: "alice", DisplayName: "Alice", Active: true,
+		Emails: []v1alpha1.SCIMEmail{{Value: "alice@example.com", Type: "work", Primary: true}}}
+
+	require.NoError(t, applyUserPatch(&spec, scimPatchOperation{Op: "add", Path: "emails", Value: json.RawMessage(`[{"value":"a@home.example.com","type":"home"}]`)}))
+	assert.Len(t, spec.Emails, 2)
+	require.NoError(t, applyUserPatch(&spec, scimPatchOperation{Op: "remove", Path: "displayName"}))
+	assert.Empty(t, spec.DisplayName)
+	require.NoError(t, applyUserPatch(&spec, scimPatchOperation{Op: "replace", Path: "externalId", Value: json.RawMessage(`"00u1"`)}))
+	assert.Equal(t, "00u1", spec.ExternalID)
+
+	assert.Error(t, applyUserPatch(&spec, scimPatchOperation{Op: "move", Path: "userName"}))
+	assert.Error(t, applyUserPatch(&spec, scimPatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`"maybe"`)}))
+	assert.Error(t, applyUserPatch(&spec, scimPatchOperation{Op: "replace", Value: json.RawMessage(`"alice"`)}))
+}
+
+func scimMemberValues(members []v1alpha1.SCIMMember) []string {
+	values := make([]string, 0, len(members))
+	for _, m := range members {
+		values = append(values, m.Value)
+	}
+	return values
+}
@@ -0,0 +1,105 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SCIMGroupMemberResolver resolves group members from the SCIMUser and SCIMGroup resources an
+// identity provider pushed through the SCIM endpoints. No outbound calls are made.
+type SCIMGroupMemberResolver struct {
+	log     *zap.SugaredLogger
+	client  client.Client
+	idpName string
+}
+
+func NewSCIMGroupMemberResolver(log *zap.SugaredLogger, cli client.Client, idpName string) *SCIMGroupMemberResolver {
+	return &SCIMGroupMemberResolver{log: log, client: cli, idpName: idpName}
+}
+
+// Members returns the active users of the group with the given display name, including the
+// users of nested groups
+func (s *SCIMGroupMemberResolver) Members(ctx context.Context, group string) ([]string, error) {
+	if s == nil || s.client == nil {
+		return nil, nil
+	}
+	selector := client.MatchingLabels{telekomv1alpha1.SCIMIdentityProviderLabel: s.idpName}
+
+	groups := &telekomv1alpha1.SCIMGroupList{}
+	if err := s.client.List(ctx, groups, selector); err != nil {
+		return nil, fmt.Errorf("failed to list SCIM groups of %s: %w", s.idpName, err)
+	}
+	groupsByID := make(map[string]*telekomv1alpha1.SCIMGroup, len(groups.Items))
+	var root *telekomv1alpha1.SCIMGroup
+	for i := range groups.Items {
+		g := &groups.Items[i]
+		groupsByID[g.Name] = g
+		if strings.EqualFold(g.Spec.DisplayName, group) {
+			root = g
+		}
+	}
+	if root == nil {
+		if s.log != nil {
+			s.log.Warnw("Group not provisioned via SCIM", "group", group, "idp", s.idpName)
+		}
+		return []string{}, nil
+	}
+
+	users := &telekomv1alpha1.SCIMUserList{}
+	if err := s.client.List(ctx, users, selector); err != nil {
+		return nil, fmt.Errorf("failed to list SCIM users of %s: %w", s.idpName, err)
+	}
+	usersByID := make(map[string]*telekomv1alpha1.SCIMUser, len(users.Items))
+	for i := range users.Items {
+		usersByID[users.Items[i].Name] = &users.Items[i]
+	}
+
+	// Walk nested groups; the visited set cuts membership cycles
+	var members []string
+	visited := map[string]bool{root.Name: true}
+	queue := []*telekomv1alpha1.SCIMGroup{root}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		for _, m := range g.Spec.Members {
+			if m.Type == telekomv1alpha1.SCIMMemberTypeGroup {
+				if sub, ok := groupsByID[m.Value]; ok && !visited[sub.Name] {
+					visited[sub.Name] = true
+					queue = append(queue, sub)
+				}
+				continue
+			}
+			u, ok := usersByID[m.Value]
+			if !ok || !u.Spec.Active {
+				continue
+			}
+			members = append(members, scimUserIdentifier(u))
+		}
+	}
+
+	members = normalizeMembers(members)
+	if s.log != nil {
+		s.log.Debugw("Resolved SCIM group members", "group", group, "idp", s.idpName, "groups", len(visited), "membersCount", len(members))
+	}
+	return members, nil
+}
+
+// scimUserIdentifier identifies a user by the primary email, the first email or the user name
+func scimUserIdentifier(u *telekomv1alpha1.SCIMUser) string {
+	for _, e := range u.Spec.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	for _, e := range u.Spec.Emails {
+		if e.Value != "" {
+			return e.Value
+		}
+	}
+	return u.Spec.UserName
+}
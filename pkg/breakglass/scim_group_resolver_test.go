@@ -0,0 +1,83 @@
+package breakglass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	cfgpkg "github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func scimTestUser(idp, id, userName string, active bool, emails ...v1alpha1.SCIMEmail) *v1alpha1.SCIMUser {
+	return &v1alpha1.SCIMUser{
+		ObjectMeta: metav1.ObjectMeta{Name: id, Labels: map[string]string{v1alpha1.SCIMIdentityProviderLabel: idp}},
+		Spec:       v1alpha1.SCIMUserSpec{IdentityProvider: idp, UserName: userName, Active: active, Emails: emails},
+	}
+}
+
+func scimTestGroup(idp, id, name string, members ...v1alpha1.SCIMMember) *v1alpha1.SCIMGroup {
+	return &v1alpha1.SCIMGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: id, Labels: map[string]string{v1alpha1.SCIMIdentityProviderLabel: idp}},
+		Spec:       v1alpha1.SCIMGroupSpec{IdentityProvider: idp, DisplayName: name, Members: members},
+	}
+}
+
+func TestSCIMGroupMemberResolver(t *testing.T) {
+	user := func(id string) v1alpha1.SCIMMember {
+		return v1alpha1.SCIMMember{Value: id, Type: v1alpha1.SCIMMemberTypeUser}
+	}
+	group := func(id string) v1alpha1.SCIMMember {
+		return v1alpha1.SCIMMember{Value: id, Type: v1alpha1.SCIMMemberTypeGroup}
+	}
+
+	objs := []client.Object{
+		scimTestUser("okta", "u1", "alice", true,
+			v1alpha1.SCIMEmail{Value: "alice@home.example.com", Type: "home"},
+			v1alpha1.SCIMEmail{Value: "Alice@example.com", Type: "work", Primary: true}),
+		scimTestUser("okta", "u2", "bob", true, v1alpha1.SCIMEmail{Value: "bob@example.com"}),
+		scimTestUser("okta", "u3", "carol@example.com", true),
+		scimTestUser("okta", "u4", "dave", false, v1alpha1.SCIMEmail{Value: "dave@example.com"}),
+		scimTestUser("entra", "u5", "eve", true, v1alpha1.SCIMEmail{Value: "eve@example.com"}),
+		// ops <- oncall <- oncall-eu <- ops (cycle)
+		scimTestGroup("okta", "g1", "ops", user("u1"), group("g2"), user("u4"), user("missing")),
+		scimTestGroup("okta", "g2", "ops-oncall", user("u2"), group("g3")),
+		scimTestGroup("okta", "g3", "ops-oncall-eu", user("u3"), group("g1"), user("u5")),
+		scimTestGroup("entra", "g4", "ops", user("u5")),
+	}
+	cli := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(objs...).Build()
+
+	tests := []struct {
+		name  string
+		idp   string
+		group string
+		want  []string
+	}{
+		{name: "nested groups and identifiers", idp: "okta", group: "OPS",
+			want: []string{"alice@example.com", "bob@example.com", "carol@example.com"}},
+		{name: "subgroup", idp: "okta", group: "ops-oncall-eu",
+			want: []string{"carol@example.com", "alice@example.com", "bob@example.com"}},
+		{name: "identity providers are isolated", idp: "entra", group: "ops", want: []string{"eve@example.com"}},
+		{name: "unknown group", idp: "okta", group: "dev", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, err := NewSCIMGroupMemberResolver(zap.NewNop().Sugar(), cli, tt.idp).Members(context.Background(), tt.group)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, members)
+		})
+	}
+}
+
+func TestCreateResolverForIDP_SCIM(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	updater := &EscalationStatusUpdater{K8sClient: cli}
+	resolver := updater.createResolverForIDP(&cfgpkg.IdentityProviderConfig{Name: "okta", SCIM: &cfgpkg.SCIMRuntimeConfig{}}, zap.NewNop().Sugar())
+	require.IsType(t, &SCIMGroupMemberResolver{}, resolver)
+	assert.Equal(t, "okta", resolver.(*SCIMGroupMemberResolver).idpName)
+}
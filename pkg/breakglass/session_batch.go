@@ -0,0 +1,334 @@
+package breakglass
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/mail"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"github.com/telekom/k8s-breakglass/pkg/system"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// MaxSessionBatchSize caps the number of sessions of a single batch request
+	MaxSessionBatchSize = 100
+
+	SessionBatchApprove = "approve"
+	SessionBatchReject  = "reject"
+	SessionBatchCancel  = "cancel"
+)
+
+// SessionBatchRequest applies one action with a shared reason to several sessions
+type SessionBatchRequest struct {
+	Sessions []string `json:"sessions"`
+	Action   string   `json:"action"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// SessionBatchResult is the outcome for a single session. Status is the HTTP status the single-session
+// endpoint would have answered with.
+type SessionBatchResult struct {
+	Session string               `json:"session"`
+	Status  int                  `json:"status"`
+	State   string               `json:"state,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	StepUp  *StepUpRequiredError `json:"stepUp,omitempty"`
+}
+
+// SessionBatchResponse lists the per-session results in request order
+type SessionBatchResponse struct {
+	Action    string               `json:"action"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []SessionBatchResult `json:"results"`
+}
+
+// SessionBatchController serves POST /api/breakglassSessions:batch. The custom method is part of the
+// path segment of the session collection, so it gets its own route group next to the session controller.
+type SessionBatchController struct {
+	sessions *BreakglassSessionController
+}
+
+// NewSessionBatchController creates the batch endpoint for the session controller
+func NewSessionBatchController(sessions *BreakglassSessionController) *SessionBatchController {
+	return &SessionBatchController{sessions: sessions}
+}
+
+// BasePath captures the custom method after the collection name, e.g. ":batch"
+func (SessionBatchController) BasePath() string {
+	return "breakglassSessions:method"
+}
+
+// Handlers returns the session controller's authentication middleware
+func (bc *SessionBatchController) Handlers() []gin.HandlerFunc {
+	return bc.sessions.Handlers()
+}
+
+func (bc *SessionBatchController) Register(rg *gin.RouterGroup) error {
+	rg.POST("", instrumentedHandler("handleBatchBreakglassSessions", bc.handleBatch)) // Approve, reject or cancel several sessions
+	return nil
+}
+
+// pendingDecision is a decision taken in a batch whose requester is notified once the batch is done
+type pendingDecision struct {
+	session   v1alpha1.BreakglassSession
+	decision  string
+	condition v1alpha1.BreakglassSessionConditionType
+	// start and end of the access window; for a cancel, the window before it was cut short
+	start, end time.Time
+}
+
+func (bc *SessionBatchController) handleBatch(c *gin.Context) {
+	if c.Param("method") != ":batch" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown method"})
+		return
+	}
+	wc := bc.sessions
+	reqLog := system.GetReqLogger(c, wc.log)
+	reqLog = system.EnrichReqLoggerWithAuth(c, reqLog)
+
+	var req SessionBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Action != SessionBatchApprove && req.Action != SessionBatchReject && req.Action != SessionBatchCancel {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("action must be one of %s, %s, %s", SessionBatchApprove, SessionBatchReject, SessionBatchCancel)})
+		return
+	}
+	names := make([]string, 0, len(req.Sessions))
+	seen := map[string]bool{}
+	for _, name := range req.Sessions {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sessions must name at least one session"})
+		return
+	}
+	if len(names) > MaxSessionBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d sessions can be changed at once", MaxSessionBatchSize)})
+		return
+	}
+	reqLog.Infow("Handling session batch", "action", req.Action, "sessions", len(names))
+
+	resp := SessionBatchResponse{Action: req.Action, Results: make([]SessionBatchResult, 0, len(names))}
+	byRequester := map[string][]pendingDecision{}
+	for _, name := range names {
+		result, decided := bc.apply(c, reqLog, name, req)
+		if result.Error == "" {
+			resp.Succeeded++
+			metrics.SessionBatchItems.WithLabelValues(req.Action, "success").Inc()
+			if decided != nil && decided.session.Spec.User != "" {
+				byRequester[decided.session.Spec.User] = append(byRequester[decided.session.Spec.User], *decided)
+			}
+		} else {
+			resp.Failed++
+			metrics.SessionBatchItems.WithLabelValues(req.Action, "failed").Inc()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	approverEmail, _ := wc.identityProvider.GetEmail(c)
+	requesters := make([]string, 0, len(byRequester))
+	for requester := range byRequester {
+		requesters = append(requesters, requester)
+	}
+	sort.Strings(requesters)
+	for _, requester := range requesters {
+		wc.sendSessionDecisionSummaryEmail(reqLog, requester, approverEmail, req.Reason, byRequester[requester])
+	}
+
+	reqLog.Infow("Session batch done", "action", req.Action, "succeeded", resp.Succeeded, "failed", resp.Failed)
+	c.JSON(http.StatusOK, resp)
+}
+
+// apply runs one session of a batch through the checks and status changes of the single-session endpoints
+func (bc *SessionBatchController) apply(c *gin.Context, reqLog *zap.SugaredLogger, name string, req SessionBatchRequest) (SessionBatchResult, *pendingDecision) {
+	wc := bc.sessions
+	ctx := c.Request.Context()
+	result := SessionBatchResult{Session: name}
+	fail := func(status int, message string) (SessionBatchResult, *pendingDecision) {
+		result.Status = status
+		result.Error = message
+		return result, nil
+	}
+
+	bs, err := wc.sessionManager.GetBreakglassSessionByName(ctx, name)
+	if err != nil {
+		// like GET /breakglassSessions/:name, a failed lookup is reported as not found
+		reqLog.Debugw("Batch: session not found", "session", name, "error", err)
+		return fail(http.StatusNotFound, "session not found")
+	}
+
+	decided := &pendingDecision{}
+	switch req.Action {
+	case SessionBatchApprove, SessionBatchReject:
+		condition := v1alpha1.SessionConditionTypeApproved
+		decided.decision = "approved"
+		if req.Action == SessionBatchReject {
+			condition = v1alpha1.SessionConditionTypeRejected
+			decided.decision = "rejected"
+		}
+		decided.condition = condition
+		if derr := wc.checkSessionDecision(c, bs, condition); derr != nil {
+			result.State = string(bs.Status.State)
+			return fail(derr.status, derr.message)
+		}
+		if condition == v1alpha1.SessionConditionTypeApproved {
+			stepUp, err := wc.approvalStepUp(c, bs)
+			if err != nil {
+				reqLog.Errorw("Failed to resolve escalation for approval auth requirements", "session", bs.Name, "error", err)
+				return fail(http.StatusInternalServerError, "failed to resolve escalation for session")
+			}
+			if stepUp != nil {
+				result.StepUp = stepUp
+				return fail(http.StatusUnauthorized, stepUp.Message)
+			}
+		}
+		if err := wc.applySessionDecision(c, &bs, condition, req.Reason); err != nil {
+			reqLog.Errorw("error applying session decision", "session", bs.Name, "error", err)
+			return fail(http.StatusInternalServerError, "failed to apply decision")
+		}
+	case SessionBatchCancel:
+		decided.decision = "canceled"
+		if derr := wc.checkApproverCancel(c, bs); derr != nil {
+			result.State = string(bs.Status.State)
+			return fail(derr.status, derr.message)
+		}
+		decided.start, decided.end = sessionAccessWindow(bs)
+		wc.applyApproverCancel(c, &bs, req.Reason)
+	}
+	if decided.start.IsZero() && decided.end.IsZero() {
+		decided.start, decided.end = sessionAccessWindow(bs)
+	}
+
+	if err := wc.sessionManager.UpdateBreakglassSessionStatus(ctx, bs); err != nil {
+		if apierrors.IsConflict(err) {
+			return fail(http.StatusConflict, "session was modified concurrently, retry")
+		}
+		reqLog.Errorw("error while updating breakglass session", "session", bs.Name, "error", err)
+		return fail(http.StatusInternalServerError, "failed to update session")
+	}
+	if decided.condition != "" {
+		// the consolidated email replaces the approval email of the single-session endpoint
+		wc.recordSessionDecision(reqLog, bs, decided.condition, false)
+	}
+
+	decided.session = bs
+	result.Status = http.StatusOK
+	result.State = string(bs.Status.State)
+	return result, decided
+}
+
+// sendSessionDecisionSummaryEmail notifies a requester once about all their sessions decided in a batch.
+// An iTIP message carries a single event (RFC 5546), so a summary of one session carries its calendar
+// invite or cancellation, while a summary of several sessions is followed by one invite per session.
+func (wc BreakglassSessionController) sendSessionDecisionSummaryEmail(log *zap.SugaredLogger, requester, decidedBy, reason string, decisions []pendingDecision) {
+	if wc.disableEmail || wc.mailQueue == nil || len(decisions) == 0 {
+		return
+	}
+
+	brandingName := "Breakglass"
+	if wc.config.Frontend.BrandingName != "" {
+		brandingName = wc.config.Frontend.BrandingName
+	}
+	params := mail.SessionDecisionSummaryMailParams{
+		SubjectEmail: requester,
+		DecidedBy:    decidedBy,
+		Reason:       strings.TrimSpace(reason),
+		URL:          wc.config.Frontend.BaseURL,
+		BrandingName: brandingName,
+	}
+	for _, d := range decisions {
+		params.Decisions = append(params.Decisions, decisionSummaryEntry(d))
+	}
+
+	subject := fmt.Sprintf("Breakglass Access Decisions - %d session(s)", len(decisions))
+	rendered, err := wc.mailTemplates.Render(mail.TemplateSessionDecisionSummary, subject, params)
+	if err != nil {
+		log.Errorw("failed to render session decision summary template", "error", err, "to", requester)
+		return
+	}
+
+	msg := mail.Message{
+		Receivers: []string{requester},
+		Subject:   rendered.Subject,
+		HTML:      rendered.HTML,
+		Text:      rendered.Text,
+	}
+	if len(decisions) == 1 {
+		msg.Calendar = wc.decisionCalendarEvent(decisions[0])
+	}
+	if err := wc.mailQueue.EnqueueMessage("session-decisions-"+requester, msg); err != nil {
+		log.Errorw("failed to enqueue session decision summary", "error", err, "to", requester)
+		return
+	}
+	log.Infow("session decision summary enqueued for sending", "to", requester, "sessions", len(decisions))
+
+	if len(decisions) > 1 {
+		for _, d := range decisions {
+			wc.sendSessionDecisionInvite(log, requester, params, d)
+		}
+	}
+}
+
+// sendSessionDecisionInvite sends the calendar invite or cancellation of one session decided in a batch,
+// with the summary of that session as body
+func (wc BreakglassSessionController) sendSessionDecisionInvite(log *zap.SugaredLogger, requester string, params mail.SessionDecisionSummaryMailParams, d pendingDecision) {
+	event := wc.decisionCalendarEvent(d)
+	if event == nil {
+		return
+	}
+	params.Decisions = []mail.SessionDecision{decisionSummaryEntry(d)}
+	rendered, err := wc.mailTemplates.Render(mail.TemplateSessionDecisionSummary, event.Summary, params)
+	if err != nil {
+		log.Errorw("failed to render session decision invite", "error", err, "to", requester, "session", d.session.Name)
+		return
+	}
+	msg := mail.Message{
+		Receivers: []string{requester},
+		Subject:   rendered.Subject,
+		HTML:      rendered.HTML,
+		Text:      rendered.Text,
+		Calendar:  event,
+	}
+	if err := wc.mailQueue.EnqueueMessage("session-decision-invite-"+d.session.Name, msg); err != nil {
+		log.Errorw("failed to enqueue session decision invite", "error", err, "to", requester, "session", d.session.Name)
+	}
+}
+
+// decisionSummaryEntry lists a decided session in the summary email
+func decisionSummaryEntry(d pendingDecision) mail.SessionDecision {
+	entry := mail.SessionDecision{
+		SessionName: d.session.Name,
+		Cluster:     d.session.Spec.Cluster,
+		Group:       d.session.Spec.GrantedGroup,
+		Decision:    d.decision,
+	}
+	if d.decision != "rejected" {
+		entry.StartTime = d.start.Format("2006-01-02 15:04:05")
+		entry.EndTime = d.end.Format("2006-01-02 15:04:05")
+	}
+	return entry
+}
+
+// decisionCalendarEvent returns the invite of an approved session or the cancellation of a canceled one
+func (wc BreakglassSessionController) decisionCalendarEvent(d pendingDecision) *mail.CalendarEvent {
+	switch d.decision {
+	case "approved":
+		return wc.sessionCalendarEvent(d.session, mail.CalendarMethodRequest, mail.CalendarSequenceApproved, d.start, d.end)
+	case "canceled":
+		return wc.sessionCalendarEvent(d.session, mail.CalendarMethodCancel, mail.CalendarSequenceCancelled, d.start, d.end)
+	}
+	return nil
+}
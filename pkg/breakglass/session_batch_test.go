@@ -0,0 +1,213 @@
+package breakglass
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/mail"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func batchSession(name, user string, state v1alpha1.BreakglassSessionState) *v1alpha1.BreakglassSession {
+	return &v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1alpha1.BreakglassSessionSpec{Cluster: "c", User: user, GrantedGroup: "g"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: state},
+	}
+}
+
+func newBatchTestEngine(t *testing.T) (*gin.Engine, *recordingMessageSender) {
+	t.Helper()
+	blockSelf := true
+	builder := fake.NewClientBuilder().WithScheme(Scheme)
+	for index, fn := range sessionIndexFunctions {
+		builder.WithIndex(&v1alpha1.BreakglassSession{}, index, fn)
+	}
+	builder.WithObjects(
+		&v1alpha1.BreakglassEscalation{
+			ObjectMeta: metav1.ObjectMeta{Name: "esc"},
+			Spec: v1alpha1.BreakglassEscalationSpec{
+				Allowed:           v1alpha1.BreakglassEscalationAllowed{Clusters: []string{"c"}, Groups: []string{"system:authenticated"}},
+				EscalatedGroup:    "g",
+				Approvers:         v1alpha1.BreakglassEscalationApprovers{Users: []string{"approver@e.com"}},
+				BlockSelfApproval: &blockSelf,
+			},
+		},
+		batchSession("a1", "alice@e.com", v1alpha1.SessionStatePending),
+		batchSession("a2", "alice@e.com", v1alpha1.SessionStatePending),
+		batchSession("b1", "bob@e.com", v1alpha1.SessionStatePending),
+		batchSession("self", "approver@e.com", v1alpha1.SessionStatePending),
+		batchSession("done", "bob@e.com", v1alpha1.SessionStateRejected),
+	)
+	cli := builder.WithStatusSubresource(&v1alpha1.BreakglassSession{}).Build()
+
+	sender := &recordingMessageSender{}
+	queue := mail.NewQueue(sender, zap.NewNop().Sugar(), 1, 10, 10)
+	queue.Start()
+	t.Cleanup(func() { _ = queue.Stop(context.Background()) })
+
+	ctrl := NewBreakglassSessionController(zap.NewNop().Sugar(), config.Config{},
+		&SessionManager{Client: cli}, &EscalationManager{Client: cli},
+		func(c *gin.Context) {
+			c.Set("email", c.GetHeader("X-Test-Email"))
+			c.Next()
+		}, "/config/config.yaml", nil, cli)
+	ctrl.mailQueue = queue
+	ctrl.getUserGroupsFn = func(ctx context.Context, cug ClusterUserGroup) ([]string, error) {
+		return []string{"system:authenticated"}, nil
+	}
+
+	engine := gin.New()
+	batch := NewSessionBatchController(ctrl)
+	_ = batch.Register(engine.Group("/"+batch.BasePath(), batch.Handlers()...))
+	return engine, sender
+}
+
+func postBatch(t *testing.T, engine *gin.Engine, email, path string, body any) (*httptest.ResponseRecorder, SessionBatchResponse) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set("X-Test-Email", email)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var resp SessionBatchResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestSessionBatchValidation(t *testing.T) {
+	engine, _ := newBatchTestEngine(t)
+
+	w, _ := postBatch(t, engine, "approver@e.com", "/breakglassSessions:purge", SessionBatchRequest{Sessions: []string{"a1"}, Action: SessionBatchApprove})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	tooMany := make([]string, MaxSessionBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("s", i+1)
+	}
+	for _, body := range []SessionBatchRequest{
+		{Sessions: []string{"a1"}, Action: "drop"},
+		{Sessions: []string{" ", ""}, Action: SessionBatchApprove},
+		{Sessions: tooMany, Action: SessionBatchReject},
+	} {
+		w, _ := postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestSessionBatchApprovePartialSuccess(t *testing.T) {
+	engine, sender := newBatchTestEngine(t)
+
+	w, resp := postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{
+		Sessions: []string{"a1", "b1", "a2", "a1", "self", "done", "missing"},
+		Action:   SessionBatchApprove,
+		Reason:   "incident 42",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 3, resp.Succeeded)
+	assert.Equal(t, 3, resp.Failed)
+
+	statuses := map[string]int{}
+	for _, r := range resp.Results {
+		statuses[r.Session] = r.Status
+	}
+	assert.Equal(t, map[string]int{
+		"a1":      http.StatusOK,
+		"b1":      http.StatusOK,
+		"a2":      http.StatusOK,
+		"self":    http.StatusUnauthorized, // self-approval is blocked by the escalation
+		"done":    http.StatusBadRequest,
+		"missing": http.StatusNotFound,
+	}, statuses)
+	assert.Equal(t, "a1", resp.Results[0].Session, "results keep the request order without duplicates")
+	assert.Equal(t, string(v1alpha1.SessionStateApproved), resp.Results[0].State)
+
+	// one summary per requester, sorted by requester, and one invite per session of a multi-session summary
+	msgs := sender.waitFor(t, 4)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, []string{"alice@e.com"}, msgs[0].Receivers)
+	assert.Contains(t, msgs[0].HTML, "a1")
+	assert.Contains(t, msgs[0].HTML, "a2")
+	assert.Contains(t, msgs[0].HTML, "incident 42")
+	assert.Nil(t, msgs[0].Calendar, "a summary of several sessions carries no calendar invite")
+	assert.Equal(t, []string{"bob@e.com"}, msgs[3].Receivers)
+	assert.NotNil(t, msgs[3].Calendar)
+
+	// the same decision again conflicts per item
+	_, resp = postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a1"}, Action: SessionBatchApprove})
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, http.StatusConflict, resp.Results[0].Status)
+}
+
+func TestSessionBatchRejectAndCancelRequireApprover(t *testing.T) {
+	engine, _ := newBatchTestEngine(t)
+
+	// a requester may reject their own pending sessions, but not anyone else's
+	_, resp := postBatch(t, engine, "alice@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a1", "b1"}, Action: SessionBatchReject})
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, http.StatusOK, resp.Results[0].Status)
+	assert.Equal(t, string(v1alpha1.SessionStateRejected), resp.Results[0].State)
+	assert.Equal(t, http.StatusUnauthorized, resp.Results[1].Status)
+
+	_, resp = postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a2"}, Action: SessionBatchApprove})
+	require.Equal(t, 1, resp.Succeeded)
+
+	_, resp = postBatch(t, engine, "alice@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a2"}, Action: SessionBatchCancel})
+	assert.Equal(t, http.StatusUnauthorized, resp.Results[0].Status, "only approvers cancel through the batch")
+
+	_, resp = postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a2", "a1"}, Action: SessionBatchCancel, Reason: "done"})
+	assert.Equal(t, http.StatusOK, resp.Results[0].Status)
+	assert.Equal(t, string(v1alpha1.SessionStateExpired), resp.Results[0].State)
+	assert.Equal(t, http.StatusBadRequest, resp.Results[1].Status, "rejected sessions cannot be canceled")
+}
+
+func TestSessionBatchSendsInvitePerSession(t *testing.T) {
+	engine, sender := newBatchTestEngine(t)
+
+	_, resp := postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a1", "a2"}, Action: SessionBatchApprove})
+	require.Equal(t, 2, resp.Succeeded)
+
+	msgs := sender.waitFor(t, 3)
+	require.Len(t, msgs, 3)
+	assert.Nil(t, msgs[0].Calendar)
+	invites := map[string]*mail.CalendarEvent{}
+	for _, m := range msgs[1:] {
+		assert.Equal(t, []string{"alice@e.com"}, m.Receivers)
+		require.NotNil(t, m.Calendar)
+		assert.Equal(t, mail.CalendarMethodRequest, m.Calendar.Method)
+		assert.Equal(t, mail.CalendarSequenceApproved, m.Calendar.Sequence)
+		invites[m.Calendar.UID] = m.Calendar
+	}
+	require.Contains(t, invites, mail.SessionEventUID("a1"))
+	require.Contains(t, invites, mail.SessionEventUID("a2"))
+
+	_, resp = postBatch(t, engine, "approver@e.com", "/breakglassSessions:batch", SessionBatchRequest{Sessions: []string{"a1", "a2"}, Action: SessionBatchCancel})
+	require.Equal(t, 2, resp.Succeeded)
+
+	msgs = sender.waitFor(t, 6)
+	require.Len(t, msgs, 6)
+	assert.Nil(t, msgs[3].Calendar)
+	for _, m := range msgs[4:] {
+		require.NotNil(t, m.Calendar)
+		assert.Equal(t, mail.CalendarMethodCancel, m.Calendar.Method)
+		approved := invites[m.Calendar.UID]
+		require.NotNil(t, approved, m.Calendar.UID)
+		// the cancellation names the window that was approved, not the one cut short by the cancel
+		assert.True(t, m.Calendar.End.Equal(approved.End.Truncate(time.Second)), "cancel end %s, approved end %s", m.Calendar.End, approved.End)
+	}
+}
@@ -0,0 +1,172 @@
+package breakglass
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/mail"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingMessageSender captures multipart messages delivered by the mail queue
+type recordingMessageSender struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (s *recordingMessageSender) Send(receivers []string, subject, body string) error {
+	return s.SendMessage(mail.Message{Receivers: receivers, Subject: subject, HTML: body})
+}
+
+func (s *recordingMessageSender) SendMessage(msg mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *recordingMessageSender) GetHost() string { return "recording-host" }
+
+func (s *recordingMessageSender) GetPort() int { return 25 }
+
+func (s *recordingMessageSender) waitFor(t *testing.T, n int) []mail.Message {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.messages) >= n
+	}, 2*time.Second, 10*time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mail.Message(nil), s.messages...)
+}
+
+func newCalendarTestController(t *testing.T) (*BreakglassSessionController, *recordingMessageSender) {
+	t.Helper()
+	sender := &recordingMessageSender{}
+	queue := mail.NewQueue(sender, zap.NewNop().Sugar(), 1, 10, 10)
+	queue.Start()
+	t.Cleanup(func() { _ = queue.Stop(context.Background()) })
+
+	cfg := config.Config{}
+	cfg.Frontend.BaseURL = "https://breakglass.example.com"
+	return &BreakglassSessionController{
+		log:       zap.NewNop().Sugar(),
+		config:    cfg,
+		mail:      sender,
+		mailQueue: queue,
+	}, sender
+}
+
+func TestSendSessionApprovalEmail_AttachesInvite(t *testing.T) {
+	ctrl, sender := newCalendarTestController(t)
+
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	session := v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "sched-1"},
+		Spec: v1alpha1.BreakglassSessionSpec{
+			User:               "user@example.com",
+			Cluster:            "prod",
+			GrantedGroup:       "admin",
+			ScheduledStartTime: &metav1.Time{Time: start},
+		},
+		Status: v1alpha1.BreakglassSessionStatus{
+			State:      v1alpha1.SessionStateWaitingForScheduledTime,
+			ApprovedAt: metav1.Now(),
+			ExpiresAt:  metav1.NewTime(start.Add(time.Hour)),
+			Approver:   "approver@example.com",
+		},
+	}
+
+	ctrl.sendSessionApprovalEmail(ctrl.log, session)
+
+	msgs := sender.waitFor(t, 1)
+	require.Len(t, msgs, 1)
+	ev := msgs[0].Calendar
+	require.NotNil(t, ev)
+	assert.Equal(t, mail.SessionEventUID("sched-1"), ev.UID)
+	assert.Equal(t, mail.CalendarMethodRequest, ev.Method)
+	assert.Equal(t, mail.CalendarSequenceApproved, ev.Sequence)
+	assert.True(t, ev.Start.Equal(start))
+	assert.True(t, ev.End.Equal(start.Add(time.Hour)))
+	assert.Equal(t, []string{"user@example.com"}, ev.Attendees)
+	assert.Equal(t, "prod", ev.Location)
+}
+
+func TestSendSessionCancelledEmail_CancelsInvite(t *testing.T) {
+	ctrl, sender := newCalendarTestController(t)
+
+	approvedAt := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	session := v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "active-1"},
+		Spec: v1alpha1.BreakglassSessionSpec{
+			User:         "user@example.com",
+			Cluster:      "prod",
+			GrantedGroup: "admin",
+		},
+		Status: v1alpha1.BreakglassSessionStatus{
+			State:      v1alpha1.SessionStateApproved,
+			ApprovedAt: metav1.NewTime(approvedAt),
+			ExpiresAt:  metav1.NewTime(approvedAt.Add(time.Hour)),
+		},
+	}
+	start, end := sessionAccessWindow(session)
+	assert.True(t, start.Equal(approvedAt))
+	assert.True(t, end.Equal(approvedAt.Add(time.Hour)))
+
+	ctrl.sendSessionCancelledEmail(ctrl.log, session, start, end, "canceled", "approver@example.com")
+
+	msgs := sender.waitFor(t, 1)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []string{"user@example.com"}, msgs[0].Receivers)
+	assert.Contains(t, msgs[0].Subject, "Cancelled")
+	assert.Contains(t, msgs[0].HTML, "approver@example.com")
+	ev := msgs[0].Calendar
+	require.NotNil(t, ev)
+	assert.Equal(t, mail.SessionEventUID("active-1"), ev.UID)
+	assert.Equal(t, mail.CalendarMethodCancel, ev.Method)
+	assert.Equal(t, mail.CalendarSequenceCancelled, ev.Sequence)
+	assert.True(t, ev.Start.Equal(start))
+}
+
+func TestSendSessionCancelledEmail_DisabledEmail(t *testing.T) {
+	ctrl, sender := newCalendarTestController(t)
+	ctrl.disableEmail = true
+
+	session := v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "quiet"},
+		Spec:       v1alpha1.BreakglassSessionSpec{User: "user@example.com"},
+	}
+	ctrl.sendSessionCancelledEmail(ctrl.log, session, time.Now(), time.Now().Add(time.Hour), "dropped", "")
+
+	time.Sleep(50 * time.Millisecond)
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	assert.Empty(t, sender.messages)
+}
+
+func TestSessionAccessWindow_PrefersActualStart(t *testing.T) {
+	scheduled := time.Now().Add(-time.Hour).Truncate(time.Second)
+	actual := scheduled.Add(time.Minute)
+	session := v1alpha1.BreakglassSession{
+		Spec: v1alpha1.BreakglassSessionSpec{ScheduledStartTime: &metav1.Time{Time: scheduled}},
+		Status: v1alpha1.BreakglassSessionStatus{
+			ApprovedAt:      metav1.NewTime(scheduled.Add(-time.Hour)),
+			ActualStartTime: metav1.NewTime(actual),
+			ExpiresAt:       metav1.NewTime(scheduled.Add(time.Hour)),
+		},
+	}
+	start, _ := sessionAccessWindow(session)
+	assert.True(t, start.Equal(actual))
+
+	session.Status.ActualStartTime = metav1.Time{}
+	start, _ = sessionAccessWindow(session)
+	assert.True(t, start.Equal(scheduled))
+}
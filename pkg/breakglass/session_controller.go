@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
 	"github.com/telekom/k8s-breakglass/pkg/config"
 	"github.com/telekom/k8s-breakglass/pkg/mail"
 	"github.com/telekom/k8s-breakglass/pkg/metrics"
@@ -21,7 +22,6 @@ import (
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -48,9 +48,16 @@ type BreakglassSessionController struct {
 	identityProvider  IdentityProvider
 	mail              mail.Sender
 	mailQueue         *mail.Queue
-	getUserGroupsFn   GetUserGroupsFunction
-	disableEmail      bool
-	ccProvider        interface {
+	mailTemplates     *mail.Templates
+	// notificationDigest buffers request notifications for approvers in digest mode (nil disables digests)
+	notificationDigest *NotificationDigest
+	// approvalLinks signs one-click approve/reject links for request emails (nil disables links)
+	approvalLinks *ApprovalLinkSigner
+	// sessionWatch streams session changes to watch clients (nil disables the watch endpoint)
+	sessionWatch    *SessionWatchHub
+	getUserGroupsFn GetUserGroupsFunction
+	disableEmail    bool
+	ccProvider      interface {
 		GetRESTConfig(ctx context.Context, name string) (*rest.Config, error)
 	}
 	clusterConfigManager *ClusterConfigManager
@@ -88,14 +95,17 @@ func (BreakglassSessionController) BasePath() string {
 
 func (wc *BreakglassSessionController) Register(rg *gin.RouterGroup) error {
 	// RESTful endpoints for breakglass sessions (no leading slash)
-	rg.GET("", instrumentedHandler("handleGetBreakglassSessionStatus", wc.handleGetBreakglassSessionStatus))           // List/filter sessions
-	rg.GET(":name", instrumentedHandler("handleGetBreakglassSessionByName", wc.handleGetBreakglassSessionByName))      // Get single session by name
-	rg.POST("", instrumentedHandler("handleRequestBreakglassSession", wc.handleRequestBreakglassSession))              // Create session
-	rg.POST(":name/approve", instrumentedHandler("handleApproveBreakglassSession", wc.handleApproveBreakglassSession)) // Approve session
-	rg.POST(":name/reject", instrumentedHandler("handleRejectBreakglassSession", wc.handleRejectBreakglassSession))    // Reject session
-	rg.POST(":name/withdraw", instrumentedHandler("handleWithdrawMyRequest", wc.handleWithdrawMyRequest))              // Withdraw session (by requester)
-	rg.POST(":name/drop", instrumentedHandler("handleDropMySession", wc.handleDropMySession))                          // Drop session (owner can drop active or pending)
-	rg.POST(":name/cancel", instrumentedHandler("handleApproverCancel", wc.handleApproverCancel))                      // Approver cancels a running/approved session
+	rg.GET("", instrumentedHandler("handleGetBreakglassSessionStatus", wc.handleGetBreakglassSessionStatus))             // List/filter sessions
+	rg.GET("watch", wc.handleWatchBreakglassSessions)                                                                    // Stream session changes (SSE), not instrumented as streams stay open
+	rg.GET(":name", instrumentedHandler("handleGetBreakglassSessionByName", wc.handleGetBreakglassSessionByName))        // Get single session by name
+	rg.POST("", instrumentedHandler("handleRequestBreakglassSession", wc.handleRequestBreakglassSession))                // Create session
+	rg.POST(":name/approve", instrumentedHandler("handleApproveBreakglassSession", wc.handleApproveBreakglassSession))   // Approve session
+	rg.POST(":name/reject", instrumentedHandler("handleRejectBreakglassSession", wc.handleRejectBreakglassSession))      // Reject session
+	rg.POST(":name/withdraw", instrumentedHandler("handleWithdrawMyRequest", wc.handleWithdrawMyRequest))                // Withdraw session (by requester)
+	rg.POST(":name/drop", instrumentedHandler("handleDropMySession", wc.handleDropMySession))                            // Drop session (owner can drop active or pending)
+	rg.POST(":name/cancel", instrumentedHandler("handleApproverCancel", wc.handleApproverCancel))                        // Approver cancels a running/approved session
+	rg.POST(":name/approvalLink", instrumentedHandler("handleConfirmApprovalLink", wc.handleConfirmApprovalLink))        // Apply a decision from an email approval link
+	rg.POST(":name/revokeApprovalLinks", instrumentedHandler("handleRevokeApprovalLinks", wc.handleRevokeApprovalLinks)) // Revoke all approval links of a session
 	return nil
 }
 
@@ -246,6 +256,27 @@ func toRFC1123Label(s string) string {
 	return out
 }
 
+// clusterGroupMapping returns the group mapping of the cluster's ClusterConfig, or nil if the
+// cluster has none or its ClusterConfig cannot be loaded
+func (wc BreakglassSessionController) clusterGroupMapping(ctx context.Context, clusterName string) *v1alpha1.GroupMapping {
+	if cc := wc.lookupClusterConfig(ctx, clusterName); cc != nil {
+		return cc.Spec.GroupMapping
+	}
+	return nil
+}
+
+// lookupClusterConfig returns the ClusterConfig of the cluster, or nil when it cannot be read
+func (wc BreakglassSessionController) lookupClusterConfig(ctx context.Context, clusterName string) *v1alpha1.ClusterConfig {
+	if wc.clusterConfigManager == nil {
+		return nil
+	}
+	cc, err := wc.clusterConfigManager.GetClusterConfigByName(ctx, clusterName)
+	if err != nil {
+		return nil
+	}
+	return cc
+}
+
 func (wc BreakglassSessionController) handleRequestBreakglassSession(c *gin.Context) {
 	// Get correlation ID for consistent logging
 	// request-scoped logger (includes cid, method, path)
@@ -306,8 +337,11 @@ func (wc BreakglassSessionController) handleRequestBreakglassSession(c *gin.Cont
 			return
 		}
 	}
-	// Strip OIDC prefixes if configured (cluster retrieval might include them; token groups usually not)
-	if cfg, cerr := config.Load(wc.configPath); cerr == nil && len(cfg.Kubernetes.OIDCPrefixes) > 0 {
+	// Strip OIDC prefixes if configured (cluster retrieval might include them; token groups usually not).
+	// Clusters with a group mapping already translated cluster groups in getUserGroupsFn.
+	if wc.clusterGroupMapping(ctx, cug.Clustername) != nil {
+		reqLog.Debug("Cluster has a group mapping; skipping global OIDC prefix stripping")
+	} else if cfg, cerr := config.Load(wc.configPath); cerr == nil && len(cfg.Kubernetes.OIDCPrefixes) > 0 {
 		userGroups = stripOIDCPrefixes(userGroups, cfg.Kubernetes.OIDCPrefixes)
 	} else if cerr != nil {
 		reqLog.With("error", errors.Wrap(cerr, "config load failed for OIDC prefix stripping")).Debug("Continuing without OIDC prefix stripping")
@@ -527,6 +561,11 @@ func (wc BreakglassSessionController) handleRequestBreakglassSession(c *gin.Cont
 			spec.IdentityProviderIssuer = iss
 		}
 	}
+	if userID, exists := c.Get("user_id"); exists {
+		if sub, ok := userID.(string); ok && sub != "" {
+			spec.UserID = sub
+		}
+	}
 
 	if matchedEsc != nil {
 		// copy relevant duration-related fields from escalation spec to session spec
@@ -831,6 +870,51 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 		_ = json.NewDecoder(c.Request.Body).Decode(&approverPayload)
 	}
 
+	if derr := wc.checkSessionDecision(c, bs, sesCondition); derr != nil {
+		if derr.status == http.StatusUnauthorized {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.JSON(derr.status, gin.H{"error": derr.message, "session": bs})
+		return
+	}
+
+	// Escalations may require approvers to have authenticated recently and/or with MFA (step-up)
+	if sesCondition == v1alpha1.SessionConditionTypeApproved && !wc.enforceApprovalAuthRequirements(c, bs) {
+		return
+	}
+
+	if err := wc.applySessionDecision(c, &bs, sesCondition, approverPayload.Reason); err != nil {
+		reqLog.Error("error applying session decision", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	if err := wc.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
+		reqLog.Error("error while updating breakglass session", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	wc.recordSessionDecision(reqLog, bs, sesCondition, true)
+
+	c.JSON(http.StatusOK, bs)
+}
+
+// sessionDecisionError is a failed precondition or authorization check of a session decision
+type sessionDecisionError struct {
+	status  int
+	message string
+}
+
+func (e *sessionDecisionError) Error() string {
+	return e.message
+}
+
+// checkSessionDecision verifies that the session is in a state the decision applies to and that the
+// caller may take it: approvers, including the self-approval rules of isSessionApprover, and the
+// requester for rejecting their own pending request. Approval step-up is checked separately.
+func (wc BreakglassSessionController) checkSessionDecision(c *gin.Context, bs v1alpha1.BreakglassSession, sesCondition v1alpha1.BreakglassSessionConditionType) *sessionDecisionError {
 	var lastCondition metav1.Condition
 	if l := len(bs.Status.Conditions); l > 0 {
 		lastCondition = bs.Status.Conditions[l-1]
@@ -838,8 +922,7 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 
 	// If the session already has the same last condition, return conflict to avoid repeated transitions.
 	if lastCondition.Type == string(sesCondition) {
-		c.JSON(http.StatusConflict, gin.H{"error": "session already in requested state", "session": bs})
-		return
+		return &sessionDecisionError{status: http.StatusConflict, message: "session already in requested state"}
 	}
 
 	// Different actions have different preconditions:
@@ -850,13 +933,11 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 	currState := bs.Status.State
 	if sesCondition == v1alpha1.SessionConditionTypeApproved || sesCondition == v1alpha1.SessionConditionTypeRejected {
 		if currState != v1alpha1.SessionStatePending {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("session must be pending to perform %s; current state: %s", sesCondition, currState), "session": bs})
-			return
+			return &sessionDecisionError{status: http.StatusBadRequest, message: fmt.Sprintf("session must be pending to perform %s; current state: %s", sesCondition, currState)}
 		}
 	} else {
 		if currState == v1alpha1.SessionStateRejected || currState == v1alpha1.SessionStateWithdrawn || currState == v1alpha1.SessionStateExpired || currState == v1alpha1.SessionStateTimeout {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("session is in terminal state %s and cannot be modified", currState), "session": bs})
-			return
+			return &sessionDecisionError{status: http.StatusBadRequest, message: fmt.Sprintf("session is in terminal state %s and cannot be modified", currState)}
 		}
 	}
 
@@ -874,10 +955,15 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 
 	if !allowOwnerReject {
 		if !wc.isSessionApprover(c, bs) {
-			c.Status(http.StatusUnauthorized)
-			return
+			return &sessionDecisionError{status: http.StatusUnauthorized, message: "not an approver of this session"}
 		}
 	}
+	return nil
+}
+
+// applySessionDecision sets the status of an approved or rejected session; the caller persists it
+func (wc BreakglassSessionController) applySessionDecision(c *gin.Context, bs *v1alpha1.BreakglassSession, sesCondition v1alpha1.BreakglassSessionConditionType, reason string) error {
+	reqLog := system.GetReqLogger(c, wc.log)
 
 	switch sesCondition {
 	case v1alpha1.SessionConditionTypeApproved:
@@ -943,8 +1029,8 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 			bs.Status.Approvers = addIfNotPresent(bs.Status.Approvers, approverEmail)
 		}
 		// store approver reason if provided
-		if strings.TrimSpace(approverPayload.Reason) != "" {
-			bs.Status.ApprovalReason = approverPayload.Reason
+		if strings.TrimSpace(reason) != "" {
+			bs.Status.ApprovalReason = reason
 		}
 	case v1alpha1.SessionConditionTypeRejected:
 		// IMPORTANT: Do NOT clear existing timestamps. We want to preserve history.
@@ -971,20 +1057,17 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 			bs.Status.Approvers = addIfNotPresent(bs.Status.Approvers, rejectorEmail)
 		}
 		// store approver reason if provided
-		if strings.TrimSpace(approverPayload.Reason) != "" {
-			bs.Status.ApprovalReason = approverPayload.Reason
+		if strings.TrimSpace(reason) != "" {
+			bs.Status.ApprovalReason = reason
 		}
 	case v1alpha1.SessionConditionTypeIdle:
-		reqLog.Error("error setting session status to idle which should be only initial state")
-		c.Status(http.StatusInternalServerError)
-		return
+		return fmt.Errorf("session status cannot be set to idle, which is only the initial state")
 	default:
-		reqLog.Error("unknown session condition type", zap.String("type", string(sesCondition)))
-		c.Status(http.StatusInternalServerError)
-		return
+		return fmt.Errorf("unknown session condition type %q", sesCondition)
 	}
 
 	username, _ := wc.identityProvider.GetEmail(c)
+	recordApprovalLinkUse(c, bs, username)
 	bs.Status.Conditions = append(bs.Status.Conditions, metav1.Condition{
 		Type:               string(sesCondition),
 		Status:             metav1.ConditionTrue,
@@ -992,14 +1075,11 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 		Reason:             string(v1alpha1.SessionConditionReasonEditedByApprover),
 		Message:            fmt.Sprintf("User %q set session to %s", username, sesCondition),
 	})
+	return nil
+}
 
-	if err := wc.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
-		reqLog.Error("error while updating breakglass session", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
-		return
-	}
-
-	// Track metrics for session lifecycle events
+// recordSessionDecision tracks a persisted decision and, with notify, sends the approval email
+func (wc BreakglassSessionController) recordSessionDecision(reqLog *zap.SugaredLogger, bs v1alpha1.BreakglassSession, sesCondition v1alpha1.BreakglassSessionConditionType, notify bool) {
 	switch sesCondition {
 	case v1alpha1.SessionConditionTypeApproved:
 		metrics.SessionApproved.WithLabelValues(bs.Spec.Cluster).Inc()
@@ -1013,14 +1093,12 @@ func (wc BreakglassSessionController) setSessionStatus(c *gin.Context, sesCondit
 		}
 
 		// Send approval notification email to requester
-		if !wc.disableEmail && wc.mailQueue != nil && bs.Spec.User != "" {
+		if notify && !wc.disableEmail && wc.mailQueue != nil && bs.Spec.User != "" {
 			wc.sendSessionApprovalEmail(reqLog, bs)
 		}
 	case v1alpha1.SessionConditionTypeRejected:
 		metrics.SessionRejected.WithLabelValues(bs.Spec.Cluster).Inc()
 	}
-
-	c.JSON(http.StatusOK, bs)
 }
 
 func (wc BreakglassSessionController) getActiveBreakglassSession(ctx context.Context,
@@ -1112,20 +1190,41 @@ func (wc *BreakglassSessionController) handleGetBreakglassSessionStatus(c *gin.C
 		return
 	}
 
+	listOpts, err := parseSessionListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stateFilters := normalizeStateFilters(c)
+	statePredicates := buildStateFilterPredicates(stateFilters)
+	visibility, err := wc.parseSessionVisibility(c)
+	if err != nil {
+		reqLog.Error("Error getting user identity email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, "failed to extract email from token")
+		return
+	}
+
+	// Narrow the list call through the field indexes where the query allows it
+	fs := fields.Set{}
+	if clusterQ != "" {
+		fs["spec.cluster"] = clusterQ
+	}
+	if userQ != "" {
+		fs["spec.user"] = userQ
+	} else if visibility.mine && !visibility.approver && !visibility.approvedByMe {
+		// only own sessions can match
+		fs["spec.user"] = visibility.email
+	}
+	if groupQ != "" {
+		fs["spec.grantedGroup"] = groupQ
+	}
+	if state, ok := indexedSessionState(stateFilters); ok {
+		fs["status.state"] = string(state)
+	}
+
 	var sessions []v1alpha1.BreakglassSession
-	var err error
-	if clusterQ != "" || userQ != "" || groupQ != "" {
-		// Build field selector from provided params
-		fs := fields.Set{}
-		if clusterQ != "" {
-			fs["spec.cluster"] = clusterQ
-		}
-		if userQ != "" {
-			fs["spec.user"] = userQ
-		}
-		if groupQ != "" {
-			fs["spec.grantedGroup"] = groupQ
-		}
+	if len(fs) > 0 {
 		selector := fields.SelectorFromSet(fs)
 		reqLog.Debugw("Using field selector for sessions query", "selector", selector.String())
 		sessions, err = wc.sessionManager.GetBreakglassSessionsWithSelector(ctx, selector)
@@ -1138,70 +1237,40 @@ func (wc *BreakglassSessionController) handleGetBreakglassSessionStatus(c *gin.C
 		return
 	}
 
-	// Ownership filters
-	includeMine := parseBoolQuery(c.Query("mine"), false)
-	includeApprover := parseBoolQuery(c.Query("approver"), true)
-	includeApprovedByMe := parseBoolQuery(c.Query("approvedByMe"), false)
-	stateFilters := normalizeStateFilters(c)
-	statePredicates := buildStateFilterPredicates(stateFilters)
-
-	var userEmail string
-	if includeMine || includeApprovedByMe {
-		userEmail, err = wc.identityProvider.GetEmail(c)
-		if err != nil {
-			reqLog.Error("Error getting user identity email", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, "failed to extract email from token")
-			return
-		}
-	}
-
 	filtered := make([]v1alpha1.BreakglassSession, 0, len(sessions))
 	for _, ses := range sessions {
-		isMine := userEmail != "" && ses.Spec.User == userEmail
-		var isApprover bool
-		if includeApprover {
-			isApprover = wc.isSessionApprover(c, ses)
-		}
-		hasApproved := false
-		if includeApprovedByMe {
-			hasApproved = userHasApprovedSession(ses, userEmail)
-		}
-
-		include := false
-		if includeMine && isMine {
-			include = true
-		}
-		if includeApprover && isApprover {
-			include = true
-		}
-		if includeApprovedByMe && hasApproved {
-			include = true
-		}
-		if !(includeMine || includeApprover || includeApprovedByMe) {
-			include = isMine || isApprover || hasApproved
+		// cheap filters first, the approver check resolves escalations
+		if !listOpts.matchesTimeRange(ses) {
+			continue
 		}
-		if !include {
+		if len(statePredicates) > 0 && !slices.ContainsFunc(statePredicates, func(p sessionStatePredicate) bool { return p(ses) }) {
 			continue
 		}
-
-		if len(statePredicates) > 0 {
-			matched := false
-			for _, predicate := range statePredicates {
-				if predicate(ses) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
+		if !wc.isSessionVisible(c, visibility, ses) {
+			continue
 		}
-
 		filtered = append(filtered, ses)
 	}
 
-	reqLog.Infow("Returning filtered breakglass sessions", "count", len(filtered))
-	c.JSON(http.StatusOK, dropK8sInternalFieldsSessionList(filtered))
+	total := len(filtered)
+	page, next := listOpts.page(filtered)
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	if next != "" {
+		c.Header(ContinueHeader, next)
+	}
+	reqLog.Infow("Returning filtered breakglass sessions", "count", len(page), "total", total)
+	page = dropK8sInternalFieldsSessionList(page)
+	if len(listOpts.Fields) == 0 {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+	projected, err := projectSessions(page, listOpts.Fields)
+	if err != nil {
+		reqLog.Error("Error projecting breakglass sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, "failed to render breakglass sessions")
+		return
+	}
+	c.JSON(http.StatusOK, projected)
 }
 
 // handleGetBreakglassSessionByName handles GET /breakglassSessions/:name and returns a single session
@@ -1317,6 +1386,11 @@ func (wc *BreakglassSessionController) handleDropMySession(c *gin.Context) {
 	}
 
 	// If approved -> mark as Expired and set RetainedUntil appropriately (owner requested termination)
+	// Sessions that were approved have a calendar invite that must be cancelled
+	hadInvite := (bs.Status.State == v1alpha1.SessionStateApproved || bs.Status.State == v1alpha1.SessionStateWaitingForScheduledTime) &&
+		!bs.Status.ApprovedAt.IsZero()
+	windowStart, windowEnd := sessionAccessWindow(bs)
+
 	if bs.Status.State == v1alpha1.SessionStateApproved && !bs.Status.ApprovedAt.IsZero() {
 		// Approved session dropped - transition to Expired
 		// IMPORTANT: Do NOT clear existing timestamps. We want to preserve history.
@@ -1376,6 +1450,10 @@ func (wc *BreakglassSessionController) handleDropMySession(c *gin.Context) {
 		return
 	}
 
+	if hadInvite {
+		wc.sendSessionCancelledEmail(reqLog, bs, windowStart, windowEnd, "dropped", "")
+	}
+
 	c.JSON(http.StatusOK, bs)
 }
 
@@ -1393,17 +1471,47 @@ func (wc *BreakglassSessionController) handleApproverCancel(c *gin.Context) {
 		return
 	}
 
+	if derr := wc.checkApproverCancel(c, bs); derr != nil {
+		if derr.status == http.StatusUnauthorized {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.JSON(derr.status, derr.message)
+		return
+	}
+
+	windowStart, windowEnd := sessionAccessWindow(bs)
+	approverEmail := wc.applyApproverCancel(c, &bs, "")
+
+	if err := wc.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
+		reqLog.Error("error while updating breakglass session", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	wc.sendSessionCancelledEmail(reqLog, bs, windowStart, windowEnd, "canceled", approverEmail)
+
+	c.JSON(http.StatusOK, bs)
+}
+
+// checkApproverCancel verifies that the caller approves the session and that it is active
+func (wc BreakglassSessionController) checkApproverCancel(c *gin.Context, bs v1alpha1.BreakglassSession) *sessionDecisionError {
 	// Only approvers can cancel via this endpoint
 	if !wc.isSessionApprover(c, bs) {
-		c.Status(http.StatusUnauthorized)
-		return
+		return &sessionDecisionError{status: http.StatusUnauthorized, message: "not an approver of this session"}
 	}
 
 	// Only allow cancellation of active/approved sessions
 	if bs.Status.State != v1alpha1.SessionStateApproved || bs.Status.ApprovedAt.IsZero() {
-		c.JSON(http.StatusBadRequest, "Session is not active/approved and cannot be canceled by approver")
-		return
+		return &sessionDecisionError{status: http.StatusBadRequest, message: "Session is not active/approved and cannot be canceled by approver"}
 	}
+	return nil
+}
+
+// applyApproverCancel expires an active session on behalf of the calling approver and returns the
+// approver's email; the caller persists the session
+func (wc BreakglassSessionController) applyApproverCancel(c *gin.Context, bs *v1alpha1.BreakglassSession, reason string) string {
+	reqLog := system.GetReqLogger(c, wc.log)
 
 	// Transition to expired immediately
 	// IMPORTANT: Do NOT clear existing timestamps. We want to preserve history.
@@ -1429,22 +1537,19 @@ func (wc *BreakglassSessionController) handleApproverCancel(c *gin.Context) {
 		bs.Status.Approvers = addIfNotPresent(bs.Status.Approvers, approverEmail)
 	}
 
+	message := "Session canceled by approver"
+	if reason = strings.TrimSpace(reason); reason != "" {
+		message += ": " + reason
+	}
 	bs.Status.Conditions = append(bs.Status.Conditions, metav1.Condition{
 		Type:               string(v1alpha1.SessionConditionTypeExpired),
 		Status:             metav1.ConditionTrue,
 		LastTransitionTime: metav1.Now(),
 		Reason:             string(v1alpha1.SessionConditionReasonEditedByApprover),
-		Message:            "Session canceled by approver",
+		Message:            message,
 	})
 	bs.Status.ReasonEnded = "canceled"
-
-	if err := wc.sessionManager.UpdateBreakglassSessionStatus(c.Request.Context(), bs); err != nil {
-		reqLog.Error("error while updating breakglass session", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
-		return
-	}
-
-	c.JSON(http.StatusOK, bs)
+	return approverEmail
 }
 
 // formatDuration converts a time.Duration to a human-readable string (e.g., "2 hours", "30 minutes")
@@ -1546,6 +1651,17 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 		return fmt.Errorf("cannot send email: no approvers available")
 	}
 
+	// Approval links are bound to a single approver, so every approver gets an own email
+	if wc.approvalLinks != nil && len(approvers) > 1 {
+		var lastErr error
+		for _, approver := range approvers {
+			if err := wc.sendOnRequestEmail(bs, requestEmail, requestUsername, []string{approver}, approverGroupsToShow, matchedEscalation); err != nil {
+				lastErr = err
+			}
+		}
+		return lastErr
+	}
+
 	subject := fmt.Sprintf("Cluster %q user %q is requesting breakglass group assignment %q", bs.Spec.Cluster, bs.Spec.User, bs.Spec.GrantedGroup)
 
 	wc.log.Debugw("Rendering breakglass session request email",
@@ -1637,7 +1753,19 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 		}
 	}
 
-	body, err := mail.RenderBreakglassSessionRequest(mail.RequestBreakglassSessionMailParams{
+	approveURL, rejectURL, approvalLinkExpiresAt := "", "", ""
+	if wc.approvalLinks != nil && len(approvers) == 1 {
+		approve, reject, expiresAt, err := wc.approvalLinkURLs(bs, approvers[0])
+		if err != nil {
+			wc.log.Warnw("Failed to issue approval links; sending request email without them",
+				"session", bs.Name, "error", err)
+		} else {
+			approveURL, rejectURL = approve, reject
+			approvalLinkExpiresAt = expiresAt.Format("2006-01-02 15:04:05 MST")
+		}
+	}
+
+	rendered, err := wc.mailTemplates.Render(mail.TemplateBreakglassSessionRequest, subject, mail.RequestBreakglassSessionMailParams{
 		SubjectEmail:            requestEmail,
 		SubjectFullName:         requestUsername,
 		RequestingUsername:      requestUsername,
@@ -1653,6 +1781,9 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 		RequestedApprovalGroups: requestedApprovalGroupsStr,
 		TimeRemaining:           timeRemaining,
 		URL:                     fmt.Sprintf("%s/review?name=%s", wc.config.Frontend.BaseURL, bs.Name),
+		ApproveURL:              approveURL,
+		RejectURL:               rejectURL,
+		ApprovalLinkExpiresAt:   approvalLinkExpiresAt,
 		BrandingName: func() string {
 			if wc.config.Frontend.BrandingName != "" {
 				return wc.config.Frontend.BrandingName
@@ -1668,6 +1799,14 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 			"subject", subject)
 		return err
 	}
+	subject, body := rendered.Subject, rendered.HTML
+	msg := mail.Message{Receivers: approvers, Subject: subject, HTML: body, Text: rendered.Text}
+
+	// Scheduled requests carry a tentative, informational event so approvers can see the requested window
+	if bs.Spec.ScheduledStartTime != nil && !bs.Spec.ScheduledStartTime.IsZero() {
+		msg.Calendar = wc.sessionCalendarEvent(bs, mail.CalendarMethodPublish, mail.CalendarSequenceRequested,
+			bs.Spec.ScheduledStartTime.Time, expiryTime)
+	}
 
 	wc.log.Debugw("Email template rendered successfully",
 		"session", bs.Name,
@@ -1679,7 +1818,7 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 	// Use mail queue for non-blocking async sending
 	if wc.mailQueue != nil {
 		sessionID := fmt.Sprintf("session-%s", bs.Name)
-		if err := wc.mailQueue.Enqueue(sessionID, approvers, subject, body); err != nil {
+		if err := wc.mailQueue.EnqueueMessage(sessionID, msg); err != nil {
 			wc.log.Warnw("Failed to enqueue session request email (will not retry)",
 				"session", bs.Name,
 				"recipientCount", len(approvers),
@@ -1687,7 +1826,7 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 				"subject", subject,
 				"error", err)
 			// Try fallback to synchronous send if queue fails
-			if err := wc.mail.Send(approvers, subject, body); err != nil {
+			if err := mail.Deliver(wc.mail, msg); err != nil {
 				wc.log.Errorw("fallback: failed to send request email",
 					"session", bs.Name,
 					"recipientCount", len(approvers),
@@ -1712,7 +1851,7 @@ func (wc BreakglassSessionController) sendOnRequestEmail(bs v1alpha1.BreakglassS
 	}
 
 	// Fallback to synchronous send if no queue is available
-	if err := wc.mail.Send(approvers, subject, body); err != nil {
+	if err := mail.Deliver(wc.mail, msg); err != nil {
 		wc.log.Errorw("failed to send request email",
 			"session", bs.Name,
 			"recipientCount", len(approvers),
@@ -1771,8 +1910,12 @@ func (wc BreakglassSessionController) sendOnRequestEmailsByGroup(
 		}
 	}
 
-	// Send one email to each approver, showing all groups they belong to
+	// Send one email to each approver, showing all groups they belong to.
+	// Approvers in digest mode get the request in their next digest instead (unless it is urgent).
 	for approver, groups := range approverToGroups {
+		if len(wc.deferToDigest(log, bs, requestUsername, []string{approver}, matchedEscalation)) == 0 {
+			continue
+		}
 		log.Debugw("Sending email for approver",
 			"session", bs.Name,
 			"approver", approver,
@@ -1805,6 +1948,8 @@ func (wc BreakglassSessionController) sendOnRequestEmailsByGroup(
 			}
 		}
 
+		approversForExplicit = wc.deferToDigest(log, bs, requestUsername, approversForExplicit, matchedEscalation)
+
 		if len(approversForExplicit) > 0 {
 			log.Debugw("Sending email for explicit users",
 				"session", bs.Name,
@@ -2351,11 +2496,15 @@ func NewBreakglassSessionController(log *zap.SugaredLogger,
 	}
 
 	ctrl.getUserGroupsFn = func(ctx context.Context, cug ClusterUserGroup) ([]string, error) {
+		if cc := ctrl.lookupClusterConfig(ctx, cug.Clustername); cc != nil && cc.Spec.AccessReviewMode == v1alpha1.AccessReviewModeSubjectAccessReview {
+			// Resolving groups on the cluster requires impersonating the user, which this mode does not grant
+			return nil, fmt.Errorf("cluster %s uses accessReviewMode %s; user groups must be provided by the identity token",
+				cug.Clustername, v1alpha1.AccessReviewModeSubjectAccessReview)
+		}
 		if ctrl.ccProvider != nil {
 			if rc, err := ctrl.ccProvider.GetRESTConfig(ctx, cug.Clustername); err == nil && rc != nil {
-				remote := rest.CopyConfig(rc)
-				remote.Impersonate = rest.ImpersonationConfig{UserName: cug.Username}
-				client, cerr := kubernetes.NewForConfig(remote)
+				// rc is the provider's cached config, so the pool reuses one client per cluster
+				client, cerr := cluster.DefaultClientPool.SharedClientset(rc, rest.ImpersonationConfig{UserName: cug.Username})
 				if cerr != nil {
 					return nil, errors.Wrap(cerr, "remote client construction failed")
 				}
@@ -2365,8 +2514,9 @@ func NewBreakglassSessionController(log *zap.SugaredLogger,
 				}
 				ui := res.Status.UserInfo
 				groups := ui.Groups
-				cfgLoaded, lerr := config.Load(ctrl.configPath)
-				if lerr == nil && len(cfgLoaded.Kubernetes.OIDCPrefixes) > 0 {
+				if mapping := ctrl.clusterGroupMapping(ctx, cug.Clustername); mapping != nil {
+					groups = mapping.GroupsFromCluster(groups)
+				} else if cfgLoaded, lerr := config.Load(ctrl.configPath); lerr == nil && len(cfgLoaded.Kubernetes.OIDCPrefixes) > 0 {
 					groups = stripOIDCPrefixes(groups, cfgLoaded.Kubernetes.OIDCPrefixes)
 				}
 				log.Debugw("Resolved user groups via spoke cluster rest.Config", "cluster", cug.Clustername, "user", cug.Username, "groups", groups)
@@ -2431,21 +2581,31 @@ func (wc BreakglassSessionController) sendSessionApprovalEmail(log *zap.SugaredL
 		IDPIssuer: session.Spec.IdentityProviderIssuer,
 	}
 
-	// Render the approval email body using the enhanced template
-	body, err := mail.RenderApproved(params)
+	// Render the approval email using the enhanced template (or an operator-supplied override)
+	subject := fmt.Sprintf("Breakglass Access Approved - %s on %s", session.Spec.GrantedGroup, session.Spec.Cluster)
+	rendered, err := wc.mailTemplates.Render(mail.TemplateApproved, subject, params)
 	if err != nil {
 		log.Errorw("failed to render approval email template", "error", err, "session", session.Name)
 		return
 	}
 
+	// The invite covers the approved access window; later cancellations reuse its UID
+	start := time.Now()
+	if isScheduled {
+		start = session.Spec.ScheduledStartTime.Time
+	} else if !session.Status.ApprovedAt.IsZero() {
+		start = session.Status.ApprovedAt.Time
+	}
+
 	// Enqueue the email for sending
-	subject := fmt.Sprintf("Breakglass Access Approved - %s on %s", session.Spec.GrantedGroup, session.Spec.Cluster)
-	err = wc.mailQueue.Enqueue(
-		"session-approval-"+session.Name,
-		[]string{session.Spec.User},
-		subject,
-		body,
-	)
+	err = wc.mailQueue.EnqueueMessage("session-approval-"+session.Name, mail.Message{
+		Receivers: []string{session.Spec.User},
+		Subject:   rendered.Subject,
+		HTML:      rendered.HTML,
+		Text:      rendered.Text,
+		Calendar: wc.sessionCalendarEvent(session, mail.CalendarMethodRequest, mail.CalendarSequenceApproved,
+			start, session.Status.ExpiresAt.Time),
+	})
 	if err != nil {
 		log.Errorw("failed to enqueue approval email", "error", err, "session", session.Name, "to", session.Spec.User)
 		return
@@ -2454,12 +2614,101 @@ func (wc BreakglassSessionController) sendSessionApprovalEmail(log *zap.SugaredL
 	log.Infow("approval email enqueued for sending", "session", session.Name, "to", session.Spec.User)
 }
 
+// sendSessionCancelledEmail notifies the requester that an approved access window ended early
+// and cancels the calendar event sent with the approval email
+func (wc BreakglassSessionController) sendSessionCancelledEmail(log *zap.SugaredLogger, session v1alpha1.BreakglassSession,
+	start, end time.Time, reason, cancelledBy string,
+) {
+	if wc.disableEmail || wc.mailQueue == nil || session.Spec.User == "" {
+		return
+	}
+
+	brandingName := "Breakglass"
+	if wc.config.Frontend.BrandingName != "" {
+		brandingName = wc.config.Frontend.BrandingName
+	}
+
+	subject := fmt.Sprintf("Breakglass Access Cancelled - %s on %s", session.Spec.GrantedGroup, session.Spec.Cluster)
+	rendered, err := wc.mailTemplates.Render(mail.TemplateSessionCancelled, subject, mail.SessionCancelledMailParams{
+		SubjectEmail:  session.Spec.User,
+		RequestedRole: session.Spec.GrantedGroup,
+		Cluster:       session.Spec.Cluster,
+		SessionID:     session.Name,
+		StartTime:     start.Format("2006-01-02 15:04:05"),
+		EndTime:       end.Format("2006-01-02 15:04:05"),
+		Reason:        reason,
+		CancelledBy:   cancelledBy,
+		URL:           wc.config.Frontend.BaseURL,
+		BrandingName:  brandingName,
+	})
+	if err != nil {
+		log.Errorw("failed to render cancellation email template", "error", err, "session", session.Name)
+		return
+	}
+
+	err = wc.mailQueue.EnqueueMessage("session-cancelled-"+session.Name, mail.Message{
+		Receivers: []string{session.Spec.User},
+		Subject:   rendered.Subject,
+		HTML:      rendered.HTML,
+		Text:      rendered.Text,
+		Calendar:  wc.sessionCalendarEvent(session, mail.CalendarMethodCancel, mail.CalendarSequenceCancelled, start, end),
+	})
+	if err != nil {
+		log.Errorw("failed to enqueue cancellation email", "error", err, "session", session.Name, "to", session.Spec.User)
+		return
+	}
+
+	log.Infow("cancellation email enqueued for sending", "session", session.Name, "to", session.Spec.User)
+}
+
+// sessionCalendarEvent builds the calendar event describing the access window of a session.
+// All events of a session share the same UID so clients update or cancel the existing entry.
+func (wc BreakglassSessionController) sessionCalendarEvent(session v1alpha1.BreakglassSession, method string, sequence int,
+	start, end time.Time,
+) *mail.CalendarEvent {
+	event := &mail.CalendarEvent{
+		UID:      mail.SessionEventUID(session.Name),
+		Sequence: sequence,
+		Method:   method,
+		Summary:  fmt.Sprintf("Breakglass access: %s on %s", session.Spec.GrantedGroup, session.Spec.Cluster),
+		Description: fmt.Sprintf("Breakglass session %s for %s (group %s on cluster %s).",
+			session.Name, session.Spec.User, session.Spec.GrantedGroup, session.Spec.Cluster),
+		Location: session.Spec.Cluster,
+		URL:      wc.config.Frontend.BaseURL,
+		Start:    start,
+		End:      end,
+	}
+	if method != mail.CalendarMethodPublish && session.Spec.User != "" {
+		event.Attendees = []string{session.Spec.User}
+	}
+	return event
+}
+
+// sessionAccessWindow returns the start and end of the access window granted to an approved session
+func sessionAccessWindow(session v1alpha1.BreakglassSession) (time.Time, time.Time) {
+	start := session.Status.ApprovedAt.Time
+	switch {
+	case !session.Status.ActualStartTime.IsZero():
+		start = session.Status.ActualStartTime.Time
+	case session.Spec.ScheduledStartTime != nil && !session.Spec.ScheduledStartTime.IsZero():
+		start = session.Spec.ScheduledStartTime.Time
+	}
+	return start, session.Status.ExpiresAt.Time
+}
+
 // WithQueue sets the mail queue for asynchronous email sending
 func (b *BreakglassSessionController) WithQueue(mailQueue *mail.Queue) *BreakglassSessionController {
 	b.mailQueue = mailQueue
 	return b
 }
 
+// WithMailTemplates sets the templates used to render notification emails.
+// Without templates, the embedded defaults are used.
+func (b *BreakglassSessionController) WithMailTemplates(templates *mail.Templates) *BreakglassSessionController {
+	b.mailTemplates = templates
+	return b
+}
+
 // Handlers returns the middleware(s) for this controller (required by APIController interface)
 func (b *BreakglassSessionController) Handlers() []gin.HandlerFunc {
 	return []gin.HandlerFunc{b.middleware}
@@ -2528,6 +2777,25 @@ func normalizeStateToken(value string) string {
 	return replacer.Replace(trimmed)
 }
 
+// indexedSessionState returns the session state to select through the status.state index when the
+// state filter is a single token that maps to exactly one stored state
+func indexedSessionState(tokens []string) (v1alpha1.BreakglassSessionState, bool) {
+	if len(tokens) != 1 {
+		return "", false
+	}
+	switch tokens[0] {
+	case "pending":
+		return v1alpha1.SessionStatePending, true
+	case "approved":
+		return v1alpha1.SessionStateApproved, true
+	case "timeout", "approvaltimeout":
+		return v1alpha1.SessionStateTimeout, true
+	case "waitingforscheduledtime", "waiting", "scheduled":
+		return v1alpha1.SessionStateWaitingForScheduledTime, true
+	}
+	return "", false
+}
+
 func buildStateFilterPredicates(tokens []string) []sessionStatePredicate {
 	if len(tokens) == 0 {
 		return nil
@@ -2576,6 +2844,44 @@ func buildStateFilterPredicates(tokens []string) []sessionStatePredicate {
 	return predicates
 }
 
+// sessionVisibility holds the mine, approver and approvedByMe query flags of a session query together
+// with the caller's email
+type sessionVisibility struct {
+	email        string
+	mine         bool
+	approver     bool
+	approvedByMe bool
+}
+
+// parseSessionVisibility reads the ownership flags; the email is only resolved when a flag needs it
+func (wc *BreakglassSessionController) parseSessionVisibility(c *gin.Context) (sessionVisibility, error) {
+	v := sessionVisibility{
+		mine:         parseBoolQuery(c.Query("mine"), false),
+		approver:     parseBoolQuery(c.Query("approver"), true),
+		approvedByMe: parseBoolQuery(c.Query("approvedByMe"), false),
+	}
+	if v.mine || v.approvedByMe {
+		email, err := wc.identityProvider.GetEmail(c)
+		if err != nil {
+			return v, err
+		}
+		v.email = email
+	}
+	return v, nil
+}
+
+// isSessionVisible reports whether the caller may see the session under the ownership flags: own
+// sessions with mine, sessions they can approve with approver and sessions they approved with approvedByMe
+func (wc *BreakglassSessionController) isSessionVisible(c *gin.Context, v sessionVisibility, ses v1alpha1.BreakglassSession) bool {
+	isMine := v.email != "" && ses.Spec.User == v.email
+	isApprover := v.approver && wc.isSessionApprover(c, ses)
+	hasApproved := v.approvedByMe && userHasApprovedSession(ses, v.email)
+	if !(v.mine || v.approver || v.approvedByMe) {
+		return isMine || isApprover || hasApproved
+	}
+	return (v.mine && isMine) || isApprover || hasApproved
+}
+
 func userHasApprovedSession(session v1alpha1.BreakglassSession, email string) bool {
 	if email == "" {
 		return false
@@ -55,6 +55,9 @@ var sessionIndexFunctions = map[string]client.IndexerFunc{
 	"spec.grantedGroup": func(o client.Object) []string {
 		return []string{o.(*v1alpha1.BreakglassSession).Spec.GrantedGroup}
 	},
+	"status.state": func(o client.Object) []string {
+		return []string{string(o.(*v1alpha1.BreakglassSession).Status.State)}
+	},
 }
 
 func TestDropK8sInternalFieldsSessionStripsMetadata(t *testing.T) {
@@ -162,6 +165,7 @@ func TestRequestApproveRejectGetSession(t *testing.T) {
 				if url == "/breakglassSessions" {
 					c.Set("email", "tester@telekom.de")
 					c.Set("username", "Tester")
+					c.Set("user_id", "tester-subject")
 				} else if strings.Contains(url, "/approve") || strings.Contains(url, "/reject") {
 					c.Set("email", "approver@telekom.de")
 					c.Set("username", "Approver")
@@ -217,6 +221,9 @@ func TestRequestApproveRejectGetSession(t *testing.T) {
 	if stat := ses.Status.RetainedUntil; stat.Day() != time.Now().Add(MonthDuration).Day() {
 		t.Fatalf("Incorrect session store until date day status %#v", stat)
 	}
+	if ses.Spec.UserID != "tester-subject" {
+		t.Fatalf("Expected session userID to be the token subject, got %q", ses.Spec.UserID)
+	}
 
 	// approve session
 	req, _ = http.NewRequest(http.MethodPost,
@@ -3825,3 +3832,23 @@ func (m *MockGroupResolver) Members(ctx context.Context, group string) ([]string
 	}
 	return nil, fmt.Errorf("group not found: %s", group)
 }
+
+func TestClusterGroupMapping(t *testing.T) {
+	mapping := &v1alpha1.GroupMapping{OIDCPrefix: "oidc:"}
+	cli := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(
+		&v1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "mapped", Namespace: "default"}, Spec: v1alpha1.ClusterConfigSpec{GroupMapping: mapping}},
+		&v1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"}},
+	).Build()
+	logger, _ := zap.NewDevelopment()
+	ctrl := NewBreakglassSessionController(logger.Sugar(), config.Config{}, &SessionManager{Client: cli}, &EscalationManager{Client: cli}, nil, "/config/config.yaml", nil, cli)
+
+	if got := ctrl.clusterGroupMapping(context.Background(), "mapped"); got == nil || got.OIDCPrefix != "oidc:" {
+		t.Fatalf("expected group mapping of cluster mapped, got %#v", got)
+	}
+	if got := ctrl.clusterGroupMapping(context.Background(), "plain"); got != nil {
+		t.Fatalf("expected no group mapping for cluster plain, got %#v", got)
+	}
+	if got := ctrl.clusterGroupMapping(context.Background(), "missing"); got != nil {
+		t.Fatalf("expected no group mapping for unknown cluster, got %#v", got)
+	}
+}
@@ -0,0 +1,290 @@
+package breakglass
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+)
+
+const (
+	// TotalCountHeader carries the number of sessions matching a list query before pagination
+	TotalCountHeader = "X-Total-Count"
+	// ContinueHeader carries the token for the next page of a list query; absent on the last page
+	ContinueHeader = "X-Continue"
+
+	// MaxSessionListLimit caps the page size of session list queries
+	MaxSessionListLimit = 1000
+
+	sessionSortCreated   = "created"
+	sessionSortExpiresAt = "expiresAt"
+	sessionSortState     = "state"
+)
+
+// sessionListOptions holds the pagination, sorting, time-range and projection parameters of a session list query
+type sessionListOptions struct {
+	Limit         int
+	Continue      *sessionListCursor
+	SortBy        string
+	Descending    bool
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+	Fields        []string
+	// paginate is set when any of limit, continue or sort was requested; otherwise sessions keep
+	// the order of the list call, as before pagination was supported
+	paginate bool
+}
+
+// sessionListCursor points behind the last session of a page. It records the sort so a token cannot
+// be replayed against a differently ordered query.
+type sessionListCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k"`
+	Name       string `json:"n"`
+}
+
+func (c sessionListCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSessionListCursor(token string) (*sessionListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continue token")
+	}
+	cursor := &sessionListCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.Name == "" {
+		return nil, fmt.Errorf("invalid continue token")
+	}
+	return cursor, nil
+}
+
+// parseSessionListOptions reads limit, continue, sort, order, createdAfter, createdBefore, expiresAfter,
+// expiresBefore and fields from the query
+func parseSessionListOptions(c *gin.Context) (sessionListOptions, error) {
+	opts := sessionListOptions{SortBy: sessionSortCreated, Descending: true}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = min(limit, MaxSessionListLimit)
+		opts.paginate = true
+	}
+	if v := c.Query("sort"); v != "" {
+		switch v {
+		case sessionSortCreated, sessionSortExpiresAt, sessionSortState:
+			opts.SortBy = v
+		default:
+			return opts, fmt.Errorf("sort must be one of %s, %s, %s", sessionSortCreated, sessionSortExpiresAt, sessionSortState)
+		}
+		// expiry and state read naturally in ascending order
+		opts.Descending = v == sessionSortCreated
+		opts.paginate = true
+	}
+	if v := c.Query("order"); v != "" {
+		switch strings.ToLower(v) {
+		case "asc":
+			opts.Descending = false
+		case "desc":
+			opts.Descending = true
+		default:
+			return opts, fmt.Errorf("order must be asc or desc")
+		}
+		opts.paginate = true
+	}
+	if v := c.Query("continue"); v != "" {
+		cursor, err := decodeSessionListCursor(v)
+		if err != nil {
+			return opts, err
+		}
+		if cursor.SortBy != opts.SortBy || cursor.Descending != opts.Descending {
+			return opts, fmt.Errorf("continue token belongs to a query with a different sort order")
+		}
+		opts.Continue = cursor
+		opts.paginate = true
+	}
+
+	for _, tf := range []struct {
+		param string
+		into  *time.Time
+	}{
+		{"createdAfter", &opts.CreatedAfter},
+		{"createdBefore", &opts.CreatedBefore},
+		{"expiresAfter", &opts.ExpiresAfter},
+		{"expiresBefore", &opts.ExpiresBefore},
+	} {
+		if v := c.Query(tf.param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be an RFC 3339 timestamp", tf.param)
+			}
+			*tf.into = t
+		}
+	}
+
+	if v := c.Query("fields"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			root := strings.SplitN(f, ".", 2)[0]
+			if root != "metadata" && root != "spec" && root != "status" {
+				return opts, fmt.Errorf("field %q must start with metadata, spec or status", f)
+			}
+			opts.Fields = append(opts.Fields, f)
+		}
+	}
+	return opts, nil
+}
+
+// matchesTimeRange reports whether the session lies within the requested creation and expiry bounds.
+// Sessions without an expiry never match an expiry bound.
+func (o sessionListOptions) matchesTimeRange(ses v1alpha1.BreakglassSession) bool {
+	created := ses.CreationTimestamp.Time
+	if !o.CreatedAfter.IsZero() && !created.After(o.CreatedAfter) {
+		return false
+	}
+	if !o.CreatedBefore.IsZero() && !created.Before(o.CreatedBefore) {
+		return false
+	}
+	if o.ExpiresAfter.IsZero() && o.ExpiresBefore.IsZero() {
+		return true
+	}
+	expires := ses.Status.ExpiresAt.Time
+	if expires.IsZero() {
+		return false
+	}
+	if !o.ExpiresAfter.IsZero() && !expires.After(o.ExpiresAfter) {
+		return false
+	}
+	if !o.ExpiresBefore.IsZero() && !expires.Before(o.ExpiresBefore) {
+		return false
+	}
+	return true
+}
+
+// sortKey returns a string that orders sessions by the sort field
+func (o sessionListOptions) sortKey(ses v1alpha1.BreakglassSession) string {
+	timeKey := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("%020d", t.UnixNano())
+	}
+	switch o.SortBy {
+	case sessionSortExpiresAt:
+		return timeKey(ses.Status.ExpiresAt.Time)
+	case sessionSortState:
+		return string(ses.Status.State)
+	default:
+		return timeKey(ses.CreationTimestamp.Time)
+	}
+}
+
+// less orders by sort key and then by name, so the order is total and cursors are unambiguous
+func (o sessionListOptions) less(aKey, aName, bKey, bName string) bool {
+	if aKey != bKey {
+		if o.Descending {
+			return aKey > bKey
+		}
+		return aKey < bKey
+	}
+	return aName < bName
+}
+
+// page sorts the sessions, skips those up to the cursor and cuts the page. It returns the next
+// continue token, or "" when the page is the last one.
+func (o sessionListOptions) page(sessions []v1alpha1.BreakglassSession) ([]v1alpha1.BreakglassSession, string) {
+	if !o.paginate {
+		return sessions, ""
+	}
+	type keyed struct {
+		key     string
+		session v1alpha1.BreakglassSession
+	}
+	items := make([]keyed, 0, len(sessions))
+	for _, ses := range sessions {
+		items = append(items, keyed{key: o.sortKey(ses), session: ses})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return o.less(items[i].key, items[i].session.Name, items[j].key, items[j].session.Name)
+	})
+
+	start := 0
+	if o.Continue != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return o.less(o.Continue.Key, o.Continue.Name, items[i].key, items[i].session.Name)
+		})
+	}
+	items = items[start:]
+	next := ""
+	if o.Limit > 0 && len(items) > o.Limit {
+		items = items[:o.Limit]
+		last := items[o.Limit-1]
+		next = sessionListCursor{SortBy: o.SortBy, Descending: o.Descending, Key: last.key, Name: last.session.Name}.encode()
+	}
+	out := make([]v1alpha1.BreakglassSession, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.session)
+	}
+	return out, next
+}
+
+// projectSessions reduces the sessions to the requested dotted field paths. apiVersion and kind are
+// always kept so clients can tell what they received.
+func projectSessions(sessions []v1alpha1.BreakglassSession, paths []string) ([]map[string]interface{}, error) {
+	out := make([]map[string]interface{}, 0, len(sessions))
+	for i := range sessions {
+		raw, err := json.Marshal(sessions[i])
+		if err != nil {
+			return nil, err
+		}
+		full := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &full); err != nil {
+			return nil, err
+		}
+		projected := map[string]interface{}{"apiVersion": full["apiVersion"], "kind": full["kind"]}
+		for _, path := range paths {
+			copyPath(full, projected, strings.Split(path, "."))
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
+
+// copyPath copies the value at path from src into dst, creating intermediate objects
+func copyPath(src, dst map[string]interface{}, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	target, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+	}
+	copyPath(child, target, path[1:])
+	if len(target) > 0 {
+		dst[path[0]] = target
+	}
+}
@@ -0,0 +1,206 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func parseListQuery(t *testing.T, query string) (sessionListOptions, error) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/breakglassSessions?"+query, nil)
+	return parseSessionListOptions(c)
+}
+
+func TestParseSessionListOptions(t *testing.T) {
+	opts, err := parseListQuery(t, "")
+	require.NoError(t, err)
+	assert.False(t, opts.paginate)
+	assert.Equal(t, sessionSortCreated, opts.SortBy)
+	assert.True(t, opts.Descending)
+
+	opts, err = parseListQuery(t, "limit=5000&sort=expiresAt")
+	require.NoError(t, err)
+	assert.True(t, opts.paginate)
+	assert.Equal(t, MaxSessionListLimit, opts.Limit)
+	assert.Equal(t, sessionSortExpiresAt, opts.SortBy)
+	assert.False(t, opts.Descending, "expiresAt defaults to ascending")
+
+	opts, err = parseListQuery(t, "sort=state&order=desc&createdAfter=2024-01-01T00:00:00Z&fields=metadata.name,%20spec.user")
+	require.NoError(t, err)
+	assert.True(t, opts.Descending)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), opts.CreatedAfter.UTC())
+	assert.Equal(t, []string{"metadata.name", "spec.user"}, opts.Fields)
+
+	for _, bad := range []string{
+		"limit=0",
+		"limit=abc",
+		"sort=name",
+		"order=up",
+		"createdBefore=yesterday",
+		"fields=apiVersion",
+		"continue=not-a-token",
+		"continue=" + sessionListCursor{SortBy: sessionSortState, Key: "Pending", Name: "a"}.encode(),
+	} {
+		_, err := parseListQuery(t, bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSessionListMatchesTimeRange(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ses := v1alpha1.BreakglassSession{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(base)}}
+
+	assert.True(t, sessionListOptions{}.matchesTimeRange(ses))
+	assert.True(t, sessionListOptions{CreatedAfter: base.Add(-time.Hour), CreatedBefore: base.Add(time.Hour)}.matchesTimeRange(ses))
+	assert.False(t, sessionListOptions{CreatedAfter: base}.matchesTimeRange(ses), "bounds are exclusive")
+	assert.False(t, sessionListOptions{ExpiresAfter: base}.matchesTimeRange(ses), "sessions without expiry never match an expiry bound")
+
+	ses.Status.ExpiresAt = metav1.NewTime(base.Add(2 * time.Hour))
+	assert.True(t, sessionListOptions{ExpiresAfter: base}.matchesTimeRange(ses))
+	assert.False(t, sessionListOptions{ExpiresBefore: base.Add(time.Hour)}.matchesTimeRange(ses))
+}
+
+func TestSessionListPageWalksAllSessionsOnce(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sessions []v1alpha1.BreakglassSession
+	for i := 0; i < 7; i++ {
+		sessions = append(sessions, v1alpha1.BreakglassSession{ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("s%d", i),
+			// pairs share a timestamp so the name tie-break is exercised
+			CreationTimestamp: metav1.NewTime(base.Add(time.Duration(i/2) * time.Minute)),
+		}})
+	}
+
+	opts := sessionListOptions{SortBy: sessionSortCreated, Descending: true, Limit: 3, paginate: true}
+	var seen []string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination does not terminate")
+		page, next := opts.page(sessions)
+		for _, s := range page {
+			seen = append(seen, s.Name)
+		}
+		if next == "" {
+			break
+		}
+		cursor, err := decodeSessionListCursor(next)
+		require.NoError(t, err)
+		opts.Continue = cursor
+	}
+	assert.Equal(t, []string{"s6", "s4", "s5", "s2", "s3", "s0", "s1"}, seen)
+}
+
+func TestSessionListPageKeepsOrderWithoutPagination(t *testing.T) {
+	sessions := []v1alpha1.BreakglassSession{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+	}
+	page, next := sessionListOptions{SortBy: sessionSortCreated}.page(sessions)
+	assert.Equal(t, sessions, page)
+	assert.Empty(t, next)
+}
+
+func TestProjectSessions(t *testing.T) {
+	sessions := []v1alpha1.BreakglassSession{{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "breakglass.t-caas.telekom.com/v1alpha1", Kind: "BreakglassSession"},
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns"},
+		Spec:       v1alpha1.BreakglassSessionSpec{Cluster: "c1", User: "u@example.com"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: v1alpha1.SessionStatePending},
+	}}
+	out, err := projectSessions(sessions, []string{"metadata.name", "spec.user", "status.state", "spec.missing.path"})
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "breakglass.t-caas.telekom.com/v1alpha1",
+		"kind":       "BreakglassSession",
+		"metadata":   map[string]interface{}{"name": "s1"},
+		"spec":       map[string]interface{}{"user": "u@example.com"},
+		"status":     map[string]interface{}{"state": "Pending"},
+	}, out[0])
+}
+
+func TestListSessionsPaginationHeadersAndProjection(t *testing.T) {
+	builder := fake.NewClientBuilder().WithScheme(Scheme)
+	for index, fn := range sessionIndexFunctions {
+		builder.WithIndex(&v1alpha1.BreakglassSession{}, index, fn)
+	}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		builder.WithObjects(&v1alpha1.BreakglassSession{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("s%d", i),
+				CreationTimestamp: metav1.NewTime(base.Add(time.Duration(i) * time.Minute)),
+			},
+			Spec: v1alpha1.BreakglassSessionSpec{Cluster: "c1", User: "owner@example.com", GrantedGroup: "g1"},
+		})
+	}
+	cli := builder.Build()
+	logger, _ := zap.NewDevelopment()
+	ctrl := NewBreakglassSessionController(logger.Sugar(), config.Config{},
+		&SessionManager{Client: cli}, &EscalationManager{Client: cli},
+		func(c *gin.Context) {
+			c.Set("email", "owner@example.com")
+			c.Set("username", "owner")
+			c.Next()
+		}, "/config/config.yaml", nil, cli)
+	ctrl.getUserGroupsFn = func(ctx context.Context, cug ClusterUserGroup) ([]string, error) {
+		return []string{"system:authenticated"}, nil
+	}
+	engine := gin.New()
+	_ = ctrl.Register(engine.Group("/breakglassSessions", ctrl.Handlers()...))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/breakglassSessions?mine=true&approver=false&"+query, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("limit=2&sort=created&order=asc")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "5", w.Header().Get(TotalCountHeader))
+	var page []v1alpha1.BreakglassSession
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page, 2)
+	assert.Equal(t, "s0", page[0].Name)
+	assert.Equal(t, "s1", page[1].Name)
+	next := w.Header().Get(ContinueHeader)
+	require.NotEmpty(t, next)
+
+	w = get("limit=3&sort=created&order=asc&continue=" + next)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page, 3)
+	assert.Equal(t, "s2", page[0].Name)
+	assert.Empty(t, w.Header().Get(ContinueHeader), "last page carries no continue token")
+
+	w = get("continue=" + next)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "token from an ascending query must not continue a descending one")
+
+	w = get("createdAfter=" + base.Add(2*time.Minute).UTC().Format(time.RFC3339) + "&fields=metadata.name")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, strconv.Itoa(2), w.Header().Get(TotalCountHeader))
+	var projected []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &projected))
+	require.Len(t, projected, 2)
+	for _, p := range projected {
+		assert.NotContains(t, p, "spec")
+		assert.Contains(t, p["metadata"], "name")
+	}
+}
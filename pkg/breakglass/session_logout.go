@@ -0,0 +1,87 @@
+package breakglass
+
+import (
+	"context"
+	"time"
+
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReasonEndedLoggedOut is the status.reasonEnded of sessions ended because their requester
+// logged out or was disabled at the identity provider
+const ReasonEndedLoggedOut = "loggedOut"
+
+// EndSessionsOfUser ends the pending, scheduled and active sessions the user with the given
+// subject requested through the identity provider. Active sessions expire immediately, all
+// others are withdrawn. It is used as the api.SessionTerminator of identity providers with
+// tokenRevocation.terminateSessions enabled.
+func (wc *BreakglassSessionController) EndSessionsOfUser(ctx context.Context, idpName, subject, reason string) {
+	log := wc.log.With("identityProvider", idpName, "subject", subject)
+	sessions, err := wc.sessionManager.GetAllBreakglassSessions(ctx)
+	if err != nil {
+		log.Errorw("failed to list sessions of logged out user", "error", err)
+		return
+	}
+
+	ended := 0
+	for _, bs := range sessions {
+		if bs.Spec.IdentityProviderName != idpName || bs.Spec.UserID != subject {
+			continue
+		}
+		state := bs.Status.State
+		if state != v1alpha1.SessionStatePending && state != v1alpha1.SessionStateApproved &&
+			state != v1alpha1.SessionStateWaitingForScheduledTime {
+			continue
+		}
+
+		hadInvite := state != v1alpha1.SessionStatePending && !bs.Status.ApprovedAt.IsZero()
+		windowStart, windowEnd := sessionAccessWindow(bs)
+		now := time.Now()
+		if state == v1alpha1.SessionStateApproved && !bs.Status.ApprovedAt.IsZero() {
+			// IMPORTANT: Do NOT clear existing timestamps. We want to preserve history.
+			bs.Status.ExpiresAt = metav1.NewTime(now)
+			bs.Status.State = v1alpha1.SessionStateExpired
+			bs.Status.Conditions = append(bs.Status.Conditions, metav1.Condition{
+				Type:               string(v1alpha1.SessionConditionTypeExpired),
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now),
+				Reason:             "LoggedOut",
+				Message:            "Session ended: " + reason,
+			})
+		} else {
+			bs.Status.WithdrawnAt = metav1.NewTime(now)
+			bs.Status.State = v1alpha1.SessionStateWithdrawn
+			bs.Status.Approver = ""
+			bs.Status.Approvers = nil
+			bs.Status.Conditions = append(bs.Status.Conditions, metav1.Condition{
+				Type:               string(v1alpha1.SessionConditionTypeCanceled),
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now),
+				Reason:             "LoggedOut",
+				Message:            "Session ended: " + reason,
+			})
+		}
+		bs.Status.ReasonEnded = ReasonEndedLoggedOut
+
+		retainFor := DefaultRetainForDuration
+		if bs.Spec.RetainFor != "" {
+			if d, err := time.ParseDuration(bs.Spec.RetainFor); err == nil && d > 0 {
+				retainFor = d
+			}
+		}
+		bs.Status.RetainedUntil = metav1.NewTime(now.Add(retainFor))
+
+		if err := wc.sessionManager.UpdateBreakglassSessionStatus(ctx, bs); err != nil {
+			log.Errorw("failed to end session of logged out user", "session", bs.Name, "error", err)
+			continue
+		}
+		ended++
+		if hadInvite {
+			wc.sendSessionCancelledEmail(log, bs, windowStart, windowEnd, reason, "")
+		}
+	}
+	if ended > 0 {
+		log.Infow("ended sessions of logged out user", "count", ended, "reason", reason)
+	}
+}
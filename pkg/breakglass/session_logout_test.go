@@ -0,0 +1,71 @@
+package breakglass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEndSessionsOfUser(t *testing.T) {
+	session := func(name, idp, userID string, state v1alpha1.BreakglassSessionState) *v1alpha1.BreakglassSession {
+		bs := &v1alpha1.BreakglassSession{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1alpha1.BreakglassSessionSpec{
+				User:                 "alice@example.com",
+				Cluster:              "prod",
+				GrantedGroup:         "admin",
+				IdentityProviderName: idp,
+				UserID:               userID,
+				RetainFor:            "24h",
+			},
+			Status: v1alpha1.BreakglassSessionStatus{State: state},
+		}
+		if state == v1alpha1.SessionStateApproved {
+			bs.Status.ApprovedAt = metav1.NewTime(time.Now().Add(-time.Minute))
+			bs.Status.ExpiresAt = metav1.NewTime(time.Now().Add(time.Hour))
+		}
+		return bs
+	}
+	cli := fake.NewClientBuilder().WithScheme(Scheme).
+		WithObjects(
+			session("active", "corp", "alice", v1alpha1.SessionStateApproved),
+			session("pending", "corp", "alice", v1alpha1.SessionStatePending),
+			session("rejected", "corp", "alice", v1alpha1.SessionStateRejected),
+			session("other-idp", "partner", "alice", v1alpha1.SessionStateApproved),
+			session("other-user", "corp", "bob", v1alpha1.SessionStatePending),
+		).
+		WithStatusSubresource(&v1alpha1.BreakglassSession{}).Build()
+	sesmanager := NewSessionManagerWithClient(cli)
+	ctrl := &BreakglassSessionController{log: zap.NewNop().Sugar(), sessionManager: &sesmanager, disableEmail: true}
+
+	ctrl.EndSessionsOfUser(context.Background(), "corp", "alice", "user logged out at the identity provider")
+
+	get := func(name string) v1alpha1.BreakglassSession {
+		bs := v1alpha1.BreakglassSession{}
+		require.NoError(t, cli.Get(context.Background(), client.ObjectKey{Name: name}, &bs))
+		return bs
+	}
+
+	active := get("active")
+	assert.Equal(t, v1alpha1.SessionStateExpired, active.Status.State)
+	assert.Equal(t, ReasonEndedLoggedOut, active.Status.ReasonEnded)
+	assert.WithinDuration(t, time.Now(), active.Status.ExpiresAt.Time, 5*time.Second)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), active.Status.RetainedUntil.Time, 5*time.Second)
+
+	pending := get("pending")
+	assert.Equal(t, v1alpha1.SessionStateWithdrawn, pending.Status.State)
+	assert.Equal(t, ReasonEndedLoggedOut, pending.Status.ReasonEnded)
+	assert.False(t, pending.Status.WithdrawnAt.IsZero())
+
+	assert.Equal(t, v1alpha1.SessionStateRejected, get("rejected").Status.State)
+	assert.Equal(t, v1alpha1.SessionStateApproved, get("other-idp").Status.State)
+	assert.Equal(t, v1alpha1.SessionStatePending, get("other-user").Status.State)
+}
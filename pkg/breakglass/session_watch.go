@@ -0,0 +1,393 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"github.com/telekom/k8s-breakglass/pkg/system"
+	"go.uber.org/zap"
+	clientcache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// SessionWatchAdded is sent for sessions that became visible to the caller
+	SessionWatchAdded = "added"
+	// SessionWatchModified is sent for visible sessions that changed
+	SessionWatchModified = "modified"
+	// SessionWatchRemoved is sent for sessions that were deleted or are no longer visible to the caller
+	SessionWatchRemoved = "removed"
+	// SessionWatchReset is sent when a stream cannot resume from the requested resourceVersion; the
+	// client drops its sessions and rebuilds them from the added events that follow
+	SessionWatchReset = "reset"
+	// SessionWatchBookmark carries the resourceVersion to resume from after the initial sessions
+	SessionWatchBookmark = "bookmark"
+	// SessionWatchUnauthorized is sent before a stream is closed because the caller's token expired or
+	// was revoked; the client has to log in again before reconnecting
+	SessionWatchUnauthorized = "unauthorized"
+
+	// TokenRecheckKey is the gin context key of a func(context.Context) error set by the auth
+	// middleware that checks the request's token against the current revocation state of its
+	// identity provider. Streams call it on every heartbeat.
+	TokenRecheckKey = "token_recheck"
+
+	// DefaultSessionWatchHistory is the number of recent changes kept to resume streams from
+	DefaultSessionWatchHistory = 1000
+	// DefaultSessionWatchHeartbeat is the interval of keep-alive comments on idle streams
+	DefaultSessionWatchHeartbeat = 30 * time.Second
+
+	// sessionWatchBuffer is the number of changes queued per stream before a slow client is dropped
+	sessionWatchBuffer = 256
+)
+
+// sessionChange is one informer notification. Old is nil for creations and New is nil for deletions.
+type sessionChange struct {
+	ResourceVersion string
+	Old             *v1alpha1.BreakglassSession
+	New             *v1alpha1.BreakglassSession
+}
+
+// SessionWatchHub fans BreakglassSession informer notifications out to watch streams and keeps a
+// bounded history of recent changes so disconnected clients can resume where they left off.
+type SessionWatchHub struct {
+	log       *zap.SugaredLogger
+	heartbeat time.Duration
+	size      int
+
+	mu          sync.Mutex
+	history     []sessionChange
+	subscribers map[*sessionSubscriber]struct{}
+}
+
+type sessionSubscriber struct {
+	changes chan sessionChange
+}
+
+// NewSessionWatchHub returns a hub keeping DefaultSessionWatchHistory changes
+func NewSessionWatchHub(log *zap.SugaredLogger) *SessionWatchHub {
+	return &SessionWatchHub{
+		log:         log,
+		heartbeat:   DefaultSessionWatchHeartbeat,
+		size:        DefaultSessionWatchHistory,
+		subscribers: map[*sessionSubscriber]struct{}{},
+	}
+}
+
+// Register attaches the hub to the manager's BreakglassSession informer
+func (h *SessionWatchHub) Register(ctx context.Context, mgr ctrl.Manager) error {
+	informer, err := mgr.GetCache().GetInformer(ctx, &v1alpha1.BreakglassSession{})
+	if err != nil {
+		return fmt.Errorf("get BreakglassSession informer: %w", err)
+	}
+	if _, err := informer.AddEventHandler(h); err != nil {
+		return fmt.Errorf("register session watch handler: %w", err)
+	}
+	return nil
+}
+
+// OnAdd implements cache.ResourceEventHandler. Sessions of the initial list are part of the snapshot
+// every new stream starts with, so they are not recorded.
+func (h *SessionWatchHub) OnAdd(obj interface{}, isInInitialList bool) {
+	if ses := extractSession(obj); ses != nil && !isInInitialList {
+		h.publish(sessionChange{ResourceVersion: ses.ResourceVersion, New: ses})
+	}
+}
+
+// OnUpdate implements cache.ResourceEventHandler
+func (h *SessionWatchHub) OnUpdate(oldObj, newObj interface{}) {
+	oldSes, newSes := extractSession(oldObj), extractSession(newObj)
+	if newSes == nil || (oldSes != nil && oldSes.ResourceVersion == newSes.ResourceVersion) {
+		// periodic resyncs carry no change
+		return
+	}
+	h.publish(sessionChange{ResourceVersion: newSes.ResourceVersion, Old: oldSes, New: newSes})
+}
+
+// OnDelete implements cache.ResourceEventHandler
+func (h *SessionWatchHub) OnDelete(obj interface{}) {
+	if ses := extractSession(obj); ses != nil {
+		h.publish(sessionChange{ResourceVersion: ses.ResourceVersion, Old: ses})
+	}
+}
+
+func (h *SessionWatchHub) publish(change sessionChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = append(h.history, change)
+	if len(h.history) > h.size {
+		h.history = slices.Delete(h.history, 0, len(h.history)-h.size)
+	}
+	for sub := range h.subscribers {
+		select {
+		case sub.changes <- change:
+		default:
+			// the stream is closed and the client resumes from its last event after reconnecting
+			delete(h.subscribers, sub)
+			close(sub.changes)
+			metrics.SessionWatchLagged.Inc()
+		}
+	}
+}
+
+// subscribe registers a stream. With a resourceVersion it returns the changes recorded after it and
+// resumed=true; an unknown or empty resourceVersion returns resumed=false and the stream has to start
+// from a snapshot. head is the resourceVersion of the latest recorded change.
+func (h *SessionWatchHub) subscribe(resourceVersion string) (sub *sessionSubscriber, replay []sessionChange, resumed bool, head string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub = &sessionSubscriber{changes: make(chan sessionChange, sessionWatchBuffer)}
+	h.subscribers[sub] = struct{}{}
+	if len(h.history) > 0 {
+		head = h.history[len(h.history)-1].ResourceVersion
+	}
+	if resourceVersion == "" {
+		return sub, nil, false, head
+	}
+	// a deletion can share the resourceVersion of the preceding update, so resume after the first
+	// match and rather send that update twice than miss the deletion
+	i := slices.IndexFunc(h.history, func(c sessionChange) bool { return c.ResourceVersion == resourceVersion })
+	if i < 0 {
+		return sub, nil, false, head
+	}
+	return sub, slices.Clone(h.history[i+1:]), true, head
+}
+
+func (h *SessionWatchHub) unsubscribe(sub *sessionSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.changes)
+	}
+}
+
+func extractSession(obj interface{}) *v1alpha1.BreakglassSession {
+	switch t := obj.(type) {
+	case *v1alpha1.BreakglassSession:
+		return t
+	case clientcache.DeletedFinalStateUnknown:
+		if ses, ok := t.Obj.(*v1alpha1.BreakglassSession); ok {
+			return ses
+		}
+	}
+	return nil
+}
+
+// sessionWatchFilter decides which changes a stream sees, using the query semantics of the list endpoint
+type sessionWatchFilter struct {
+	cluster, user, group string
+	states               []sessionStatePredicate
+	visibility           sessionVisibility
+}
+
+func (f sessionWatchFilter) matches(wc *BreakglassSessionController, c *gin.Context, ses *v1alpha1.BreakglassSession) bool {
+	if ses == nil {
+		return false
+	}
+	if (f.cluster != "" && ses.Spec.Cluster != f.cluster) ||
+		(f.user != "" && ses.Spec.User != f.user) ||
+		(f.group != "" && ses.Spec.GrantedGroup != f.group) {
+		return false
+	}
+	if len(f.states) > 0 && !slices.ContainsFunc(f.states, func(p sessionStatePredicate) bool { return p(*ses) }) {
+		return false
+	}
+	return wc.isSessionVisible(c, f.visibility, *ses)
+}
+
+// eventType maps a change to the event the caller sees. A session that starts or stops matching the
+// filter is added or removed even if it was modified, so clients only ever hold sessions they may see.
+func (f sessionWatchFilter) eventType(wc *BreakglassSessionController, c *gin.Context, change sessionChange) (string, *v1alpha1.BreakglassSession) {
+	newOK := f.matches(wc, c, change.New)
+	oldOK := f.matches(wc, c, change.Old)
+	switch {
+	case newOK && oldOK:
+		return SessionWatchModified, change.New
+	case newOK:
+		return SessionWatchAdded, change.New
+	case oldOK:
+		return SessionWatchRemoved, change.Old
+	}
+	return "", nil
+}
+
+// handleWatchBreakglassSessions handles GET /breakglassSessions/watch. It streams Server-Sent Events
+// for the sessions the caller may see, filtered by the cluster, user, group, state, mine, approver
+// and approvedByMe parameters of the list endpoint. A new stream starts with an added event per
+// session followed by a bookmark; streams reconnecting with the resourceVersion query parameter or the
+// Last-Event-ID header replay the changes they missed instead.
+func (wc *BreakglassSessionController) handleWatchBreakglassSessions(c *gin.Context) {
+	reqLog := system.GetReqLogger(c, wc.log)
+	reqLog = system.EnrichReqLoggerWithAuth(c, reqLog)
+	if wc.sessionWatch == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session watch is not available"})
+		return
+	}
+
+	visibility, err := wc.parseSessionVisibility(c)
+	if err != nil {
+		reqLog.Error("Error getting user identity email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, "failed to extract email from token")
+		return
+	}
+	filter := sessionWatchFilter{
+		cluster:    c.Query("cluster"),
+		user:       c.Query("user"),
+		group:      c.Query("group"),
+		states:     buildStateFilterPredicates(normalizeStateFilters(c)),
+		visibility: visibility,
+	}
+
+	resourceVersion := c.Query("resourceVersion")
+	if resourceVersion == "" {
+		resourceVersion = c.GetHeader("Last-Event-ID")
+	}
+	sub, replay, resumed, head := wc.sessionWatch.subscribe(resourceVersion)
+	defer wc.sessionWatch.unsubscribe(sub)
+	metrics.SessionWatchStreams.Inc()
+	defer metrics.SessionWatchStreams.Dec()
+
+	var snapshot []v1alpha1.BreakglassSession
+	if !resumed {
+		// subscribed first so no change between the list and the stream is lost
+		snapshot, err = wc.sessionManager.GetAllBreakglassSessions(c.Request.Context())
+		if err != nil {
+			reqLog.Error("Error getting breakglass sessions", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, "failed to extract breakglass session information")
+			return
+		}
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// keep reverse proxies from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event, id string, data interface{}) bool {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			reqLog.Errorw("Failed to encode session watch event", "error", err)
+			return true
+		}
+		if id != "" {
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+				return false
+			}
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, raw); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		metrics.SessionWatchEvents.WithLabelValues(event).Inc()
+		return true
+	}
+	deliver := func(change sessionChange) bool {
+		event, ses := filter.eventType(wc, c, change)
+		if event == "" {
+			return true
+		}
+		out := ses.DeepCopy()
+		dropK8sInternalFieldsSession(out)
+		return send(event, change.ResourceVersion, out)
+	}
+
+	if resumed {
+		reqLog.Debugw("Resuming session watch", "resourceVersion", resourceVersion, "changes", len(replay))
+		for _, change := range replay {
+			if !deliver(change) {
+				return
+			}
+		}
+	} else {
+		if resourceVersion != "" && !send(SessionWatchReset, "", gin.H{"reason": "resourceVersion is too old to resume from"}) {
+			return
+		}
+		for i := range snapshot {
+			if !filter.matches(wc, c, &snapshot[i]) {
+				continue
+			}
+			dropK8sInternalFieldsSession(&snapshot[i])
+			if !send(SessionWatchAdded, "", snapshot[i]) {
+				return
+			}
+		}
+		if !send(SessionWatchBookmark, head, gin.H{"resourceVersion": head}) {
+			return
+		}
+	}
+
+	// the token was valid when the stream started, but the stream must not outlive it
+	var expired <-chan time.Time
+	if exp, ok := tokenExpiry(c); ok {
+		timer := time.NewTimer(time.Until(exp))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var recheck func(context.Context) error
+	if v, ok := c.Get(TokenRecheckKey); ok {
+		recheck, _ = v.(func(context.Context) error)
+	}
+	unauthorized := func(reason string) {
+		reqLog.Infow("Closing session watch", "reason", reason)
+		send(SessionWatchUnauthorized, "", gin.H{"reason": reason})
+	}
+
+	heartbeat := time.NewTicker(wc.sessionWatch.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired:
+			unauthorized("token expired")
+			return
+		case <-heartbeat.C:
+			if recheck != nil {
+				if err := recheck(c.Request.Context()); err != nil {
+					unauthorized(err.Error())
+					return
+				}
+			}
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case change, ok := <-sub.changes:
+			if !ok {
+				reqLog.Infow("Closing session watch of a client that fell behind")
+				return
+			}
+			if !deliver(change) {
+				return
+			}
+		}
+	}
+}
+
+// tokenExpiry returns the exp claim of the caller's validated token
+func tokenExpiry(c *gin.Context) (time.Time, bool) {
+	raw, ok := c.Get("raw_claims")
+	if !ok {
+		return time.Time{}, false
+	}
+	claims, _ := raw.(jwt.MapClaims)
+	return numericClaimTime(claims["exp"])
+}
+
+// WithSessionWatch enables the session watch stream
+func (b *BreakglassSessionController) WithSessionWatch(hub *SessionWatchHub) *BreakglassSessionController {
+	b.sessionWatch = hub
+	return b
+}
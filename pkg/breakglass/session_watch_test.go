@@ -0,0 +1,261 @@
+package breakglass
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func watchSession(name, user, rv string, state v1alpha1.BreakglassSessionState) *v1alpha1.BreakglassSession {
+	return &v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: rv},
+		Spec:       v1alpha1.BreakglassSessionSpec{Cluster: "c1", User: user, GrantedGroup: "g1"},
+		Status:     v1alpha1.BreakglassSessionStatus{State: state},
+	}
+}
+
+func TestSessionWatchHubResume(t *testing.T) {
+	hub := NewSessionWatchHub(zap.NewNop().Sugar())
+	hub.OnAdd(watchSession("initial", "u", "1", v1alpha1.SessionStatePending), true)
+	hub.OnAdd(watchSession("s1", "u", "2", v1alpha1.SessionStatePending), false)
+	hub.OnUpdate(watchSession("s1", "u", "2", v1alpha1.SessionStatePending), watchSession("s1", "u", "2", v1alpha1.SessionStatePending))
+	hub.OnUpdate(watchSession("s1", "u", "2", v1alpha1.SessionStatePending), watchSession("s1", "u", "3", v1alpha1.SessionStateApproved))
+	hub.OnDelete(clientcache.DeletedFinalStateUnknown{Key: "s1", Obj: watchSession("s1", "u", "3", v1alpha1.SessionStateApproved)})
+
+	require.Len(t, hub.history, 3, "initial list and resyncs are not recorded")
+
+	sub, replay, resumed, head := hub.subscribe("2")
+	defer hub.unsubscribe(sub)
+	assert.True(t, resumed)
+	assert.Equal(t, "3", head)
+	require.Len(t, replay, 2)
+	assert.NotNil(t, replay[0].Old)
+	assert.Nil(t, replay[1].New, "deletion carries the last known session")
+
+	// a deletion sharing the update's resourceVersion is replayed together with the update
+	sub2, replay, resumed, _ := hub.subscribe("3")
+	defer hub.unsubscribe(sub2)
+	assert.True(t, resumed)
+	assert.Len(t, replay, 1)
+
+	sub3, _, resumed, _ := hub.subscribe("999")
+	defer hub.unsubscribe(sub3)
+	assert.False(t, resumed)
+}
+
+func TestSessionWatchHubTrimsHistoryAndDropsSlowSubscribers(t *testing.T) {
+	hub := NewSessionWatchHub(zap.NewNop().Sugar())
+	hub.size = 5
+	sub, _, _, _ := hub.subscribe("")
+	for i := 0; i < sessionWatchBuffer+1; i++ {
+		hub.OnAdd(watchSession(fmt.Sprintf("s%d", i), "u", fmt.Sprint(i+1), v1alpha1.SessionStatePending), false)
+	}
+	assert.Len(t, hub.history, 5)
+	assert.Equal(t, fmt.Sprint(sessionWatchBuffer+1), hub.history[4].ResourceVersion)
+
+	received := 0
+	for range sub.changes {
+		received++
+	}
+	assert.Equal(t, sessionWatchBuffer, received, "the channel is closed once the buffer overflows")
+	assert.Empty(t, hub.subscribers)
+	// unsubscribing a dropped subscriber is a no-op
+	hub.unsubscribe(sub)
+}
+
+type sseEvent struct {
+	id, event, data string
+}
+
+func readSSE(t *testing.T, scanner *bufio.Scanner) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if ev.event != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("stream ended: %v", scanner.Err())
+	return ev
+}
+
+func sessionName(t *testing.T, ev sseEvent) string {
+	t.Helper()
+	var ses v1alpha1.BreakglassSession
+	require.NoError(t, json.Unmarshal([]byte(ev.data), &ses))
+	return ses.Name
+}
+
+func TestWatchBreakglassSessionsStream(t *testing.T) {
+	mine := watchSession("mine", "owner@example.com", "", v1alpha1.SessionStatePending)
+	other := watchSession("other", "someone@example.com", "", v1alpha1.SessionStatePending)
+	cli := fake.NewClientBuilder().WithScheme(Scheme).WithObjects(mine, other).Build()
+	logger := zap.NewNop().Sugar()
+	ctrl := NewBreakglassSessionController(logger, config.Config{},
+		&SessionManager{Client: cli}, &EscalationManager{Client: cli},
+		func(c *gin.Context) {
+			c.Set("email", "owner@example.com")
+			c.Set("username", "owner")
+			c.Next()
+		}, "/config/config.yaml", nil, cli)
+	hub := NewSessionWatchHub(logger)
+	hub.heartbeat = 20 * time.Millisecond
+	ctrl.WithSessionWatch(hub)
+	engine := gin.New()
+	_ = ctrl.Register(engine.Group("/breakglassSessions", ctrl.Handlers()...))
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	open := func(query string) (*bufio.Scanner, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/breakglassSessions/watch?mine=true&approver=false"+query, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return bufio.NewScanner(resp.Body), cancel
+	}
+
+	stream, cancel := open("")
+	ev := readSSE(t, stream)
+	assert.Equal(t, SessionWatchAdded, ev.event)
+	assert.Equal(t, "mine", sessionName(t, ev), "only the caller's own session is in the snapshot")
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchBookmark, ev.event)
+
+	// wait until the stream is subscribed before publishing
+	require.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subscribers) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	approved := watchSession("mine", "owner@example.com", "10", v1alpha1.SessionStateApproved)
+	hub.OnUpdate(mine, approved)
+	hub.OnUpdate(other, watchSession("other", "someone@example.com", "11", v1alpha1.SessionStateApproved))
+	hub.OnDelete(approved)
+
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchModified, ev.event)
+	assert.Equal(t, "10", ev.id)
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchRemoved, ev.event, "the other user's change is not visible")
+	assert.Equal(t, "mine", sessionName(t, ev))
+	cancel()
+
+	// resuming replays the changes after the given resourceVersion
+	stream, cancel = open("&resourceVersion=10")
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchRemoved, ev.event)
+	cancel()
+
+	// an unknown resourceVersion resets the client and sends the snapshot again
+	stream, cancel = open("&state=pending&resourceVersion=unknown")
+	defer cancel()
+	assert.Equal(t, SessionWatchReset, readSSE(t, stream).event)
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchAdded, ev.event)
+	assert.Equal(t, "mine", sessionName(t, ev))
+	ev = readSSE(t, stream)
+	assert.Equal(t, SessionWatchBookmark, ev.event)
+	assert.Equal(t, "10", ev.id)
+}
+
+func TestWatchBreakglassSessionsUnavailableWithoutHub(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	ctrl := NewBreakglassSessionController(zap.NewNop().Sugar(), config.Config{},
+		&SessionManager{Client: cli}, &EscalationManager{Client: cli},
+		func(c *gin.Context) { c.Next() }, "/config/config.yaml", nil, cli)
+	engine := gin.New()
+	_ = ctrl.Register(engine.Group("/breakglassSessions", ctrl.Handlers()...))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/breakglassSessions/watch", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestWatchBreakglassSessionsClosesForExpiredOrRevokedToken(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	logger := zap.NewNop().Sugar()
+	var revoked atomic.Bool
+	ctrl := NewBreakglassSessionController(logger, config.Config{},
+		&SessionManager{Client: cli}, &EscalationManager{Client: cli},
+		func(c *gin.Context) {
+			c.Set("email", "owner@example.com")
+			exp := time.Now().Add(time.Hour)
+			if c.Query("shortLived") != "" {
+				exp = time.Now().Add(time.Second)
+			}
+			c.Set("raw_claims", jwt.MapClaims{"exp": float64(exp.Unix())})
+			c.Set(TokenRecheckKey, func(context.Context) error {
+				if revoked.Load() {
+					return errors.New("token was revoked by a logout at the identity provider")
+				}
+				return nil
+			})
+			c.Next()
+		}, "/config/config.yaml", nil, cli)
+	hub := NewSessionWatchHub(logger)
+	hub.heartbeat = 20 * time.Millisecond
+	ctrl.WithSessionWatch(hub)
+	engine := gin.New()
+	_ = ctrl.Register(engine.Group("/breakglassSessions", ctrl.Handlers()...))
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	open := func(query string) *bufio.Scanner {
+		resp, err := http.Get(srv.URL + "/breakglassSessions/watch?mine=true&approver=false" + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		stream := bufio.NewScanner(resp.Body)
+		require.Equal(t, SessionWatchBookmark, readSSE(t, stream).event)
+		return stream
+	}
+	assertClosed := func(stream *bufio.Scanner, reason string) {
+		ev := readSSE(t, stream)
+		assert.Equal(t, SessionWatchUnauthorized, ev.event)
+		assert.Contains(t, ev.data, reason)
+		for stream.Scan() {
+		}
+		assert.NoError(t, stream.Err(), "the server ends the stream")
+	}
+
+	start := time.Now()
+	assertClosed(open("&shortLived=true"), "token expired")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	stream := open("")
+	revoked.Store(true)
+	assertClosed(stream, "revoked")
+}
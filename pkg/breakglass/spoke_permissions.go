@@ -0,0 +1,102 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// spokePermission is a right of the cluster credentials checked by the permission self-test
+type spokePermission struct {
+	description string
+	attributes  authorizationv1.ResourceAttributes
+}
+
+var (
+	permCreateSubjectAccessReviews = spokePermission{"create subjectaccessreviews",
+		authorizationv1.ResourceAttributes{Verb: "create", Group: "authorization.k8s.io", Resource: "subjectaccessreviews"}}
+	permImpersonateGroups = spokePermission{"impersonate groups",
+		authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "groups"}}
+	permImpersonateUsers = spokePermission{"impersonate users",
+		authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "users"}}
+)
+
+// excessSpokePermissions are rights breakglass never uses in any access review mode
+var excessSpokePermissions = []spokePermission{
+	{"all verbs on all resources", authorizationv1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"}},
+	{"list secrets", authorizationv1.ResourceAttributes{Verb: "list", Resource: "secrets"}},
+	{"create clusterrolebindings", authorizationv1.ResourceAttributes{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}},
+	{"impersonate serviceaccounts", authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "serviceaccounts"}},
+}
+
+// requiredSpokePermissions returns the rights breakglass needs on a cluster and the rights that are
+// excess for the access review mode
+func requiredSpokePermissions(mode telekomv1alpha1.AccessReviewMode) (required, excess []spokePermission) {
+	excess = excessSpokePermissions
+	if mode == telekomv1alpha1.AccessReviewModeSubjectAccessReview {
+		required = []spokePermission{permCreateSubjectAccessReviews}
+		excess = append(append([]spokePermission{}, excess...), permImpersonateGroups, permImpersonateUsers)
+		return required, excess
+	}
+	// Session checks create SubjectAccessReviews in every mode
+	return []spokePermission{permCreateSubjectAccessReviews, permImpersonateGroups, permImpersonateUsers}, excess
+}
+
+// SpokePolicyRules returns RBAC rules granting exactly the rights breakglass needs on a cluster for the
+// access review mode
+func SpokePolicyRules(mode telekomv1alpha1.AccessReviewMode) []rbacv1.PolicyRule {
+	required, _ := requiredSpokePermissions(mode)
+	rules := make([]rbacv1.PolicyRule, 0, len(required))
+	for _, p := range required {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{p.attributes.Group},
+			Resources: []string{p.attributes.Resource},
+			Verbs:     []string{p.attributes.Verb},
+		})
+	}
+	return rules
+}
+
+// checkSpokePermissions runs SelfSubjectAccessReviews with the cluster credentials and returns the
+// required rights they lack and the excess rights they hold
+func checkSpokePermissions(ctx context.Context, cfg *rest.Config, mode telekomv1alpha1.AccessReviewMode) (missing, excess []string, err error) {
+	client, err := cluster.DefaultClientPool.Clientset(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	allowed := func(p spokePermission) (bool, error) {
+		attrs := p.attributes
+		review := &authorizationv1.SelfSubjectAccessReview{Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs}}
+		resp, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("self-test %q: %w", p.description, err)
+		}
+		return resp.Status.Allowed, nil
+	}
+	required, notNeeded := requiredSpokePermissions(mode)
+	for _, p := range required {
+		ok, err := allowed(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			missing = append(missing, p.description)
+		}
+	}
+	for _, p := range notNeeded {
+		ok, err := allowed(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			excess = append(excess, p.description)
+		}
+	}
+	return missing, excess, nil
+}
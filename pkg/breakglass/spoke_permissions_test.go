@@ -0,0 +1,160 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+// fakeAuthorizationServer answers SelfSubjectAccessReviews from the granted rights and records
+// the SubjectAccessReviews it receives
+type fakeAuthorizationServer struct {
+	granted map[string]bool // "verb group/resource"
+	reviews []authorizationv1.SubjectAccessReview
+}
+
+func (f *fakeAuthorizationServer) restConfig(t *testing.T) *rest.Config {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews":
+			var review authorizationv1.SelfSubjectAccessReview
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+			ra := review.Spec.ResourceAttributes
+			review.Status.Allowed = f.granted[ra.Verb+" "+ra.Group+"/"+ra.Resource]
+			_ = json.NewEncoder(w).Encode(review)
+		case "/apis/authorization.k8s.io/v1/subjectaccessreviews":
+			var review authorizationv1.SubjectAccessReview
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+			f.reviews = append(f.reviews, review)
+			review.Status.Allowed = len(review.Spec.Groups) > 0 && review.Spec.Groups[0] == "admins"
+			_ = json.NewEncoder(w).Encode(review)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &rest.Config{Host: srv.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}}
+}
+
+func TestCheckSpokePermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        telekomv1alpha1.AccessReviewMode
+		granted     []string
+		wantMissing []string
+		wantExcess  []string
+	}{
+		{
+			name:    "subject access review mode with least privilege",
+			mode:    telekomv1alpha1.AccessReviewModeSubjectAccessReview,
+			granted: []string{"create authorization.k8s.io/subjectaccessreviews"},
+		},
+		{
+			name:        "subject access review mode with impersonation rights",
+			mode:        telekomv1alpha1.AccessReviewModeSubjectAccessReview,
+			granted:     []string{"impersonate /users", "impersonate /groups"},
+			wantMissing: []string{"create subjectaccessreviews"},
+			wantExcess:  []string{"impersonate groups", "impersonate users"},
+		},
+		{
+			name:        "impersonation mode",
+			mode:        telekomv1alpha1.AccessReviewModeImpersonation,
+			granted:     []string{"create authorization.k8s.io/subjectaccessreviews", "impersonate /groups", "list /secrets"},
+			wantMissing: []string{"impersonate users"},
+			wantExcess:  []string{"list secrets"},
+		},
+		{
+			name:       "cluster admin",
+			granted:    []string{"create authorization.k8s.io/subjectaccessreviews", "impersonate /groups", "impersonate /users", "* */*"},
+			wantExcess: []string{"all verbs on all resources"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAuthorizationServer{granted: map[string]bool{}}
+			for _, g := range tt.granted {
+				f.granted[g] = true
+			}
+			missing, excess, err := checkSpokePermissions(context.Background(), f.restConfig(t), tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMissing, missing)
+			assert.Equal(t, tt.wantExcess, excess)
+		})
+	}
+}
+
+func TestCanGroupsDoViaSubjectAccessReview(t *testing.T) {
+	f := &fakeAuthorizationServer{}
+	rc := f.restConfig(t)
+	sar := authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User: "alice",
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: "default", Verb: "get", Resource: "pods", Name: "web-0",
+		},
+	}}
+
+	allowed, err := CanGroupsDoViaSubjectAccessReview(context.Background(), rc, []string{"admins"}, sar, "spoke")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = CanGroupsDoViaSubjectAccessReview(context.Background(), rc, []string{"viewers"}, sar, "spoke")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	require.Len(t, f.reviews, 2)
+	review := f.reviews[0]
+	assert.Equal(t, AuthCheckerUser, review.Spec.User, "the requesting user's own rights must not be reviewed")
+	assert.Equal(t, []string{"admins"}, review.Spec.Groups)
+	assert.Equal(t, "web-0", review.Spec.ResourceAttributes.Name)
+
+	_, err = CanGroupsDoViaSubjectAccessReview(context.Background(), nil, []string{"admins"}, sar, "spoke")
+	assert.EqualError(t, err, "rest config is nil")
+}
+
+func TestCanGroupsDoReusesPooledClientForWrappedConfig(t *testing.T) {
+	pool := cluster.NewClientPool(cluster.ClientPoolOptions{})
+	previous := cluster.DefaultClientPool
+	cluster.DefaultClientPool = pool
+	t.Cleanup(func() { cluster.DefaultClientPool = previous })
+
+	f := &fakeAuthorizationServer{granted: map[string]bool{"get /pods": true}}
+	rc := f.restConfig(t)
+	// workload identity and exec kubeconfigs carry a transport wrapper, so the pool keys them by identity
+	rc.WrapTransport = func(rt http.RoundTripper) http.RoundTripper { return rt }
+	sar := authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		ResourceAttributes: &authorizationv1.ResourceAttributes{Namespace: "default", Verb: "get", Resource: "pods"},
+	}}
+
+	for _, groups := range [][]string{{"admins"}, {"viewers"}, {"admins", "viewers"}, {"admins"}} {
+		allowed, err := CanGroupsDo(context.Background(), rc, groups, sar, "spoke")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, 1, pool.Len())
+	assert.Nil(t, rc.Impersonate.Groups, "the shared config is not mutated")
+}
+
+func TestSpokePolicyRules(t *testing.T) {
+	rules := SpokePolicyRules(telekomv1alpha1.AccessReviewModeSubjectAccessReview)
+	require.Len(t, rules, 1)
+	assert.Equal(t, []string{"authorization.k8s.io"}, rules[0].APIGroups)
+	assert.Equal(t, []string{"subjectaccessreviews"}, rules[0].Resources)
+	assert.Equal(t, []string{"create"}, rules[0].Verbs)
+
+	rules = SpokePolicyRules(telekomv1alpha1.AccessReviewModeImpersonation)
+	var resources []string
+	for _, r := range rules {
+		resources = append(resources, r.Resources...)
+	}
+	assert.ElementsMatch(t, []string{"subjectaccessreviews", "groups", "users"}, resources)
+}
@@ -0,0 +1,231 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"github.com/telekom/k8s-breakglass/pkg/system"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// StepUpErrorCode is returned in the code field of step-up errors. It matches the
+// OAuth 2.0 Step-Up Authentication Challenge error (RFC 9470).
+const StepUpErrorCode = "insufficient_user_authentication"
+
+// Reasons for refusing an approval because of the approver's authentication
+const (
+	StepUpReasonACR             = "acr_not_satisfied"
+	StepUpReasonAMR             = "amr_not_satisfied"
+	StepUpReasonAuthTimeMissing = "auth_time_missing"
+	StepUpReasonAuthTimeTooOld  = "auth_time_too_old"
+)
+
+// authTimeClockSkew tolerates approver tokens whose auth_time is slightly in the future
+const authTimeClockSkew = time.Minute
+
+// StepUpRequiredError describes the authentication an approver has to perform before approving.
+// It is serialized as the body of the 401 response so the frontend can start an OIDC login with
+// matching acr_values and max_age parameters.
+type StepUpRequiredError struct {
+	Message    string   `json:"error"`
+	Code       string   `json:"code"`
+	Reason     string   `json:"reason"`
+	Escalation string   `json:"escalation,omitempty"`
+	ACRValues  []string `json:"acrValues,omitempty"`
+	AMRValues  []string `json:"amrValues,omitempty"`
+	// MaxAge is the maximum authentication age in seconds, 0 if not restricted
+	MaxAge int64 `json:"maxAge,omitempty"`
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return e.Message
+}
+
+// wwwAuthenticate renders the RFC 9470 challenge for the WWW-Authenticate header
+func (e *StepUpRequiredError) wwwAuthenticate() string {
+	parts := []string{
+		fmt.Sprintf("error=%q", StepUpErrorCode),
+		fmt.Sprintf("error_description=%q", e.Message),
+	}
+	if len(e.ACRValues) > 0 {
+		parts = append(parts, fmt.Sprintf("acr_values=%q", strings.Join(e.ACRValues, " ")))
+	}
+	if e.MaxAge > 0 {
+		parts = append(parts, fmt.Sprintf("max_age=%d", e.MaxAge))
+	}
+	return "Bearer " + strings.Join(parts, ", ")
+}
+
+// checkApprovalAuthRequirements verifies the acr, amr and auth_time claims of the approver's
+// token against the requirements. It returns nil when all configured requirements are met.
+func checkApprovalAuthRequirements(req *v1alpha1.ApprovalAuthRequirements, claims jwt.MapClaims, now time.Time) *StepUpRequiredError {
+	if req == nil {
+		return nil
+	}
+	stepUp := func(reason, message string) *StepUpRequiredError {
+		e := &StepUpRequiredError{
+			Message:   message,
+			Code:      StepUpErrorCode,
+			Reason:    reason,
+			ACRValues: req.ACRValues,
+			AMRValues: req.AMRValues,
+		}
+		if d, err := time.ParseDuration(req.MaxAuthAge); err == nil && d > 0 {
+			e.MaxAge = int64(d / time.Second)
+		}
+		return e
+	}
+
+	if len(req.ACRValues) > 0 {
+		acr, _ := claims["acr"].(string)
+		if !slices.Contains(req.ACRValues, acr) {
+			return stepUp(StepUpReasonACR, "approval requires a stronger authentication level")
+		}
+	}
+
+	if len(req.AMRValues) > 0 {
+		methods := amrClaim(claims["amr"])
+		if !slices.ContainsFunc(req.AMRValues, func(m string) bool { return slices.Contains(methods, m) }) {
+			return stepUp(StepUpReasonAMR, "approval requires authentication with one of the methods "+strings.Join(req.AMRValues, ", "))
+		}
+	}
+
+	if req.MaxAuthAge != "" {
+		maxAge, err := time.ParseDuration(req.MaxAuthAge)
+		if err != nil {
+			// Rejected by the admission webhook; fail closed if it slipped through
+			return stepUp(StepUpReasonAuthTimeTooOld, "approval requires a recent authentication")
+		}
+		authTime, ok := numericClaimTime(claims["auth_time"])
+		if !ok {
+			return stepUp(StepUpReasonAuthTimeMissing, "approval requires a recent authentication but the token has no auth_time")
+		}
+		if now.Sub(authTime) > maxAge || authTime.After(now.Add(authTimeClockSkew)) {
+			return stepUp(StepUpReasonAuthTimeTooOld, fmt.Sprintf("approval requires an authentication within the last %s", maxAge))
+		}
+	}
+	return nil
+}
+
+// amrClaim returns the authentication methods of the amr claim (a list, or a single string for some providers)
+func amrClaim(v interface{}) []string {
+	switch val := v.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return val
+	case string:
+		return strings.Fields(val)
+	}
+	return nil
+}
+
+// numericClaimTime converts a NumericDate claim into a time
+func numericClaimTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case float64:
+		return time.Unix(int64(val), 0), true
+	case int64:
+		return time.Unix(val, 0), true
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return time.Unix(n, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// approvalAuthEscalations returns the escalations whose approvalAuthRequirements apply to approving the
+// session: the owning escalation, or for sessions without an owner reference every escalation granting
+// the session's group on its cluster.
+func (wc BreakglassSessionController) approvalAuthEscalations(ctx context.Context, bs v1alpha1.BreakglassSession) ([]v1alpha1.BreakglassEscalation, error) {
+	if wc.escalationManager == nil {
+		return nil, nil
+	}
+	for _, or := range bs.OwnerReferences {
+		if or.Kind != "BreakglassEscalation" {
+			continue
+		}
+		esc, err := wc.escalationManager.GetBreakglassEscalation(ctx, bs.Namespace, or.Name)
+		if err == nil {
+			return []v1alpha1.BreakglassEscalation{*esc}, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	escalations, err := wc.escalationManager.GetClusterBreakglassEscalations(ctx, bs.Spec.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]v1alpha1.BreakglassEscalation, 0, len(escalations))
+	for _, esc := range escalations {
+		if esc.Spec.EscalatedGroup == bs.Spec.GrantedGroup {
+			matching = append(matching, esc)
+		}
+	}
+	return matching, nil
+}
+
+// approvalStepUp checks the approver's token against the approvalAuthRequirements of the session's
+// escalation and returns the step-up challenge when they are not met
+func (wc BreakglassSessionController) approvalStepUp(c *gin.Context, bs v1alpha1.BreakglassSession) (*StepUpRequiredError, error) {
+	reqLog := system.GetReqLogger(c, wc.log)
+
+	escalations, err := wc.approvalAuthEscalations(c.Request.Context(), bs)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.MapClaims
+	if raw, ok := c.Get("raw_claims"); ok {
+		claims, _ = raw.(jwt.MapClaims)
+	}
+	now := time.Now()
+	for _, esc := range escalations {
+		stepUp := checkApprovalAuthRequirements(esc.Spec.ApprovalAuthRequirements, claims, now)
+		if stepUp == nil {
+			continue
+		}
+		stepUp.Escalation = esc.Name
+		metrics.SessionApprovalStepUpRequired.WithLabelValues(bs.Spec.Cluster, stepUp.Reason).Inc()
+		reqLog.Infow("Approval requires step-up authentication",
+			"session", bs.Name, "escalation", esc.Name, "reason", stepUp.Reason,
+			"acr", claims["acr"], "amr", claims["amr"])
+		return stepUp, nil
+	}
+	return nil, nil
+}
+
+// enforceApprovalAuthRequirements checks the approver's token against the approvalAuthRequirements of the
+// session's escalation. When they are not met it writes a 401 step-up challenge and returns false.
+func (wc BreakglassSessionController) enforceApprovalAuthRequirements(c *gin.Context, bs v1alpha1.BreakglassSession) bool {
+	stepUp, err := wc.approvalStepUp(c, bs)
+	if err != nil {
+		system.GetReqLogger(c, wc.log).Errorw("Failed to resolve escalation for approval auth requirements", "session", bs.Name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve escalation for session"})
+		return false
+	}
+	if stepUp != nil {
+		c.Header("WWW-Authenticate", stepUp.wwwAuthenticate())
+		c.JSON(http.StatusUnauthorized, stepUp)
+		return false
+	}
+	return true
+}
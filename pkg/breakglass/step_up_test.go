@@ -0,0 +1,159 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckApprovalAuthRequirements(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	req := &v1alpha1.ApprovalAuthRequirements{
+		ACRValues:  []string{"gold", "urn:mace:incommon:iap:silver"},
+		AMRValues:  []string{"mfa", "hwk"},
+		MaxAuthAge: "15m",
+	}
+	satisfied := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"acr":       "gold",
+			"amr":       []interface{}{"pwd", "otp", "mfa"},
+			"auth_time": float64(now.Add(-5 * time.Minute).Unix()),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		req    *v1alpha1.ApprovalAuthRequirements
+		mutate func(jwt.MapClaims)
+		reason string
+	}{
+		{name: "no requirements", req: nil, mutate: func(c jwt.MapClaims) { delete(c, "acr") }},
+		{name: "all satisfied", req: req},
+		{name: "amr as space separated string", req: req, mutate: func(c jwt.MapClaims) { c["amr"] = "pwd hwk" }},
+		{name: "auth_time as json.Number", req: req, mutate: func(c jwt.MapClaims) { c["auth_time"] = json.Number("1699999900") }},
+		{name: "wrong acr", req: req, mutate: func(c jwt.MapClaims) { c["acr"] = "1" }, reason: StepUpReasonACR},
+		{name: "missing acr", req: req, mutate: func(c jwt.MapClaims) { delete(c, "acr") }, reason: StepUpReasonACR},
+		{name: "no accepted amr", req: req, mutate: func(c jwt.MapClaims) { c["amr"] = []interface{}{"pwd"} }, reason: StepUpReasonAMR},
+		{name: "missing auth_time", req: req, mutate: func(c jwt.MapClaims) { delete(c, "auth_time") }, reason: StepUpReasonAuthTimeMissing},
+		{name: "stale auth_time", req: req, mutate: func(c jwt.MapClaims) { c["auth_time"] = float64(now.Add(-time.Hour).Unix()) }, reason: StepUpReasonAuthTimeTooOld},
+		{name: "future auth_time", req: req, mutate: func(c jwt.MapClaims) { c["auth_time"] = float64(now.Add(time.Hour).Unix()) }, reason: StepUpReasonAuthTimeTooOld},
+		{name: "only maxAuthAge configured", req: &v1alpha1.ApprovalAuthRequirements{MaxAuthAge: "1h"}, mutate: func(c jwt.MapClaims) {
+			delete(c, "acr")
+			delete(c, "amr")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := satisfied()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+			err := checkApprovalAuthRequirements(tt.req, claims, now)
+			if tt.reason == "" {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Equal(t, tt.reason, err.Reason)
+			assert.Equal(t, StepUpErrorCode, err.Code)
+			assert.Equal(t, req.ACRValues, err.ACRValues)
+			assert.Equal(t, int64(900), err.MaxAge)
+		})
+	}
+}
+
+func TestSetSessionStatusEnforcesApprovalAuthRequirements(t *testing.T) {
+	builder := fake.NewClientBuilder().WithScheme(Scheme)
+	for index, fn := range sessionIndexFunctions {
+		builder.WithIndex(&v1alpha1.BreakglassSession{}, index, fn)
+	}
+	builder.WithObjects(&v1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "esc-prod"},
+		Spec: v1alpha1.BreakglassEscalationSpec{
+			Allowed:        v1alpha1.BreakglassEscalationAllowed{Clusters: []string{"prod"}, Groups: []string{"system:authenticated"}},
+			EscalatedGroup: "admin",
+			Approvers:      v1alpha1.BreakglassEscalationApprovers{Users: []string{"approver@example.com"}},
+			ApprovalAuthRequirements: &v1alpha1.ApprovalAuthRequirements{
+				ACRValues:  []string{"mfa"},
+				MaxAuthAge: "10m",
+			},
+		},
+	})
+	builder.WithObjects(&v1alpha1.BreakglassSession{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "prod-session",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: v1alpha1.GroupVersion.String(), Kind: "BreakglassEscalation", Name: "esc-prod",
+			}},
+		},
+		Spec: v1alpha1.BreakglassSessionSpec{User: "user@example.com", Cluster: "prod", GrantedGroup: "admin"},
+		Status: v1alpha1.BreakglassSessionStatus{
+			State:     v1alpha1.SessionStatePending,
+			TimeoutAt: metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	})
+	cli := builder.WithStatusSubresource(&v1alpha1.BreakglassSession{}).Build()
+	sesmanager := SessionManager{Client: cli}
+	escmanager := EscalationManager{Client: cli}
+
+	var claims jwt.MapClaims
+	ctrl := NewBreakglassSessionController(zap.NewNop().Sugar(), config.Config{}, &sesmanager, &escmanager, func(c *gin.Context) {
+		c.Set("email", "approver@example.com")
+		c.Set("username", "approver@example.com")
+		c.Set("raw_claims", claims)
+		c.Next()
+	}, "/config/config.yaml", nil, cli)
+	ctrl.getUserGroupsFn = func(ctx context.Context, cug ClusterUserGroup) ([]string, error) {
+		return []string{"system:authenticated"}, nil
+	}
+	engine := gin.New()
+	require.NoError(t, ctrl.Register(engine.Group("/breakglassSessions", ctrl.Handlers()...)))
+
+	approve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/breakglassSessions/prod-session/approve", nil)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// Password-only login from an hour ago
+	claims = jwt.MapClaims{"acr": "1", "auth_time": float64(time.Now().Add(-time.Hour).Unix())}
+	w := approve()
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `acr_values="mfa"`)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `max_age=600`)
+	var stepUp StepUpRequiredError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stepUp))
+	assert.Equal(t, StepUpErrorCode, stepUp.Code)
+	assert.Equal(t, StepUpReasonACR, stepUp.Reason)
+	assert.Equal(t, "esc-prod", stepUp.Escalation)
+	assert.Equal(t, []string{"mfa"}, stepUp.ACRValues)
+	assert.Equal(t, int64(600), stepUp.MaxAge)
+
+	bs, err := sesmanager.GetBreakglassSessionByName(context.Background(), "prod-session")
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.SessionStatePending, bs.Status.State)
+
+	// After re-authenticating with MFA the approval goes through
+	claims = jwt.MapClaims{"acr": "mfa", "auth_time": float64(time.Now().Add(-time.Minute).Unix())}
+	w = approve()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	bs, err = sesmanager.GetBreakglassSessionByName(context.Background(), "prod-session")
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.SessionStateApproved, bs.Status.State)
+}
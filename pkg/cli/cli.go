@@ -10,6 +10,8 @@ import (
 
 	"github.com/telekom/k8s-breakglass/pkg/breakglass"
 	"github.com/telekom/k8s-breakglass/pkg/cert"
+	"github.com/telekom/k8s-breakglass/pkg/cluster"
+	"github.com/telekom/k8s-breakglass/pkg/config"
 	"go.uber.org/zap"
 )
 
@@ -235,6 +237,40 @@ func ParseClusterConfigCheckInterval(interval string, log *zap.SugaredLogger) ti
 	return checkInterval
 }
 
+func ParseCertificateExpiryWarning(value string, log *zap.SugaredLogger) time.Duration {
+	threshold, err := parseDuration("certificateExpiryWarning", value, breakglass.DefaultCertificateExpiryWarning)
+	if err != nil {
+		log.Warn(err)
+	}
+	return threshold
+}
+
+// ParseSpokeClientPoolOptions turns the spokeClients configuration into client pool options, falling
+// back to the defaults for unset or invalid values
+func ParseSpokeClientPoolOptions(c config.SpokeClients, log *zap.SugaredLogger) cluster.ClientPoolOptions {
+	opts := cluster.DefaultClientPoolOptions()
+	if c.MaxIdleConnsPerHost > 0 {
+		opts.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"spokeClients.idleConnTimeout", c.IdleConnTimeout, &opts.IdleConnTimeout},
+		{"spokeClients.keepAlive", c.KeepAlive, &opts.KeepAlive},
+		{"spokeClients.healthCheckInterval", c.HealthCheckInterval, &opts.HealthCheckInterval},
+		{"spokeClients.idleClientTTL", c.IdleClientTTL, &opts.IdleClientTTL},
+	} {
+		parsed, err := parseDuration(d.name, d.value, *d.into)
+		if err != nil {
+			log.Warn(err)
+		}
+		*d.into = parsed
+	}
+	return opts
+}
+
 func parseDuration(name, value string, def time.Duration) (time.Duration, error) {
 	duration := def
 	if value != "" {
@@ -3,6 +3,10 @@ package cli
 import (
 	"crypto/tls"
 	"testing"
+	"time"
+
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
 )
 
 func TestGetEnvString(t *testing.T) {
@@ -51,3 +55,24 @@ func TestDisableHTTP2(t *testing.T) {
 		t.Fatalf("expected HTTP/1.1 only, got %v", cfg.NextProtos)
 	}
 }
+
+func TestParseSpokeClientPoolOptions(t *testing.T) {
+	opts := ParseSpokeClientPoolOptions(config.SpokeClients{
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     "2m",
+		KeepAlive:           "not-a-duration",
+	}, zap.NewNop().Sugar())
+
+	if opts.MaxIdleConnsPerHost != 4 {
+		t.Fatalf("expected 4 idle connections per host, got %d", opts.MaxIdleConnsPerHost)
+	}
+	if opts.IdleConnTimeout != 2*time.Minute {
+		t.Fatalf("expected idle connection timeout 2m, got %s", opts.IdleConnTimeout)
+	}
+	if opts.KeepAlive != 30*time.Second {
+		t.Fatalf("expected default keep-alive for invalid value, got %s", opts.KeepAlive)
+	}
+	if opts.IdleClientTTL != time.Hour {
+		t.Fatalf("expected default idle client TTL, got %s", opts.IdleClientTTL)
+	}
+}
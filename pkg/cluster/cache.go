@@ -8,11 +8,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,6 +23,9 @@ import (
 // ClientProvider resolves ClusterConfig objects and caches lightweight metadata.
 var ErrClusterConfigNotFound = errors.New("clusterconfig not found")
 
+// warmTimeout bounds the connection attempt of Warm
+const warmTimeout = 10 * time.Second
+
 type ClientProvider struct {
 	k8s  ctrlclient.Client
 	log  *zap.SugaredLogger
@@ -31,6 +36,8 @@ type ClientProvider struct {
 	clusterToSecret map[string]string
 	// secretToClusters tracks all clusters backed by a given secret (keyed by namespace/name)
 	secretToClusters map[string]map[string]struct{}
+	// pool holds the clientsets built from the cached rest configs
+	pool *ClientPool
 }
 
 func NewClientProvider(c ctrlclient.Client, log *zap.SugaredLogger) *ClientProvider {
@@ -41,6 +48,7 @@ func NewClientProvider(c ctrlclient.Client, log *zap.SugaredLogger) *ClientProvi
 		rest:             map[string]*rest.Config{},
 		clusterToSecret:  map[string]string{},
 		secretToClusters: map[string]map[string]struct{}{},
+		pool:             DefaultClientPool,
 	}
 }
 
@@ -107,7 +115,8 @@ func (p *ClientProvider) GetInNamespace(ctx context.Context, namespace, name str
 	return &cp, nil
 }
 
-// GetRESTConfig returns a rest.Config built from the referenced kubeconfig secret, caching it.
+// GetRESTConfig returns a rest.Config built from the referenced kubeconfig secret or the auth
+// settings of the ClusterConfig, caching it.
 func (p *ClientProvider) GetRESTConfig(ctx context.Context, name string) (*rest.Config, error) {
 	p.mu.RLock()
 	rc, ok := p.rest[name]
@@ -120,22 +129,81 @@ func (p *ClientProvider) GetRESTConfig(ctx context.Context, name string) (*rest.
 	if err != nil {
 		return nil, err
 	}
-	secretDataKey := cc.Spec.KubeconfigSecretRef.Key
+	var cfg *rest.Config
+	var secretRefKey string
+	switch {
+	case cc.Spec.Auth != nil:
+		cfg, secretRefKey, err = RESTConfigFromAuth(ctx, p.k8s, cc.Spec.Auth)
+	case cc.Spec.KubeconfigSecretRef != nil:
+		cfg, secretRefKey, err = p.restConfigFromKubeconfig(ctx, cc.Spec.KubeconfigSecretRef)
+	default:
+		err = fmt.Errorf("clusterconfig %s has neither kubeconfigSecretRef nor auth", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cc.Spec.QPS != nil {
+		cfg.QPS = float32(*cc.Spec.QPS)
+	}
+	if cc.Spec.Burst != nil {
+		cfg.Burst = int(*cc.Spec.Burst)
+	}
+	p.mu.Lock()
+	p.rest[name] = cfg
+	// Workload identity reads no secret, so there is nothing to invalidate the config on
+	if secretRefKey != "" {
+		p.clusterToSecret[name] = secretRefKey
+		if _, ok := p.secretToClusters[secretRefKey]; !ok {
+			p.secretToClusters[secretRefKey] = map[string]struct{}{}
+		}
+		p.secretToClusters[secretRefKey][name] = struct{}{}
+	}
+	p.mu.Unlock()
+	return cfg, nil
+}
+
+// GetClientset returns the pooled clientset for the cluster, sharing connections with every other
+// caller of the client pool using the same credentials.
+func (p *ClientProvider) GetClientset(ctx context.Context, name string) (kubernetes.Interface, error) {
+	cfg, err := p.GetRESTConfig(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.pool.SharedClientset(cfg, rest.ImpersonationConfig{})
+}
+
+// Warm builds the pooled client of the cluster and opens a connection to its API server, so the
+// first authorization request for the cluster does not pay for the TLS handshake.
+func (p *ClientProvider) Warm(ctx context.Context, name string) error {
+	cs, err := p.GetClientset(ctx, name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+	defer cancel()
+	_, err = cs.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+	return err
+}
+
+// restConfigFromKubeconfig parses the kubeconfig stored in the referenced secret and returns it
+// together with the cache key of the secret.
+func (p *ClientProvider) restConfigFromKubeconfig(ctx context.Context, ref *telekomv1alpha1.SecretKeyReference) (*rest.Config, string, error) {
+	secretDataKey := ref.Key
 	if secretDataKey == "" {
 		// default to 'value' for Cluster API compatibility
 		secretDataKey = "value"
 	}
 	secret := corev1.Secret{}
-	if err := p.k8s.Get(ctx, types.NamespacedName{Name: cc.Spec.KubeconfigSecretRef.Name, Namespace: cc.Spec.KubeconfigSecretRef.Namespace}, &secret); err != nil {
-		return nil, fmt.Errorf("fetch kubeconfig secret: %w", err)
+	if err := p.k8s.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, &secret); err != nil {
+		return nil, "", fmt.Errorf("fetch kubeconfig secret: %w", err)
 	}
 	raw, ok := secret.Data[secretDataKey]
 	if !ok {
-		return nil, fmt.Errorf("secret %s/%s missing key %s", cc.Spec.KubeconfigSecretRef.Namespace, cc.Spec.KubeconfigSecretRef.Name, secretDataKey)
+		return nil, "", fmt.Errorf("secret %s/%s missing key %s", ref.Namespace, ref.Name, secretDataKey)
 	}
 	cfg, err := clientcmd.RESTConfigFromKubeConfig(raw)
 	if err != nil {
-		return nil, fmt.Errorf("parse kubeconfig: %w", err)
+		return nil, "", fmt.Errorf("parse kubeconfig: %w", err)
 	}
 	// If the kubeconfig references a loopback endpoint (kind default), rewrite to in-cluster service DNS
 	if strings.Contains(cfg.Host, "127.0.0.1") || strings.Contains(cfg.Host, "localhost") {
@@ -146,22 +214,7 @@ func (p *ClientProvider) GetRESTConfig(ctx context.Context, name string) (*rest.
 			cfg.Host = "https://kubernetes.default.svc"
 		}
 	}
-	if cc.Spec.QPS != nil {
-		cfg.QPS = float32(*cc.Spec.QPS)
-	}
-	if cc.Spec.Burst != nil {
-		cfg.Burst = int(*cc.Spec.Burst)
-	}
-	p.mu.Lock()
-	p.rest[name] = cfg
-	secretRefKey := secretCacheKey(cc.Spec.KubeconfigSecretRef.Namespace, cc.Spec.KubeconfigSecretRef.Name)
-	p.clusterToSecret[name] = secretRefKey
-	if _, ok := p.secretToClusters[secretRefKey]; !ok {
-		p.secretToClusters[secretRefKey] = map[string]struct{}{}
-	}
-	p.secretToClusters[secretRefKey][name] = struct{}{}
-	p.mu.Unlock()
-	return cfg, nil
+	return cfg, secretCacheKey(ref.Namespace, ref.Name), nil
 }
 
 // Invalidate removes an entry (called by informer/controller update hooks later).
@@ -171,6 +224,18 @@ func (p *ClientProvider) Invalidate(name string) {
 	p.mu.Unlock()
 }
 
+// Refresh drops the cached ClusterConfig only. It is used for changes that cannot affect the
+// credentials, such as status updates, so the rest config and pooled client stay in place.
+func (p *ClientProvider) Refresh(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k := range p.data {
+		if strings.HasSuffix(k, "/"+name) || k == name {
+			delete(p.data, k)
+		}
+	}
+}
+
 // InvalidateSecret removes all cached entries (ClusterConfig + rest.Config) that rely on a specific secret.
 func (p *ClientProvider) InvalidateSecret(namespace, name string) {
 	key := secretCacheKey(namespace, name)
@@ -201,7 +266,10 @@ func (p *ClientProvider) evictClusterLocked(name string) {
 			delete(p.data, k)
 		}
 	}
-	delete(p.rest, name)
+	if cfg, ok := p.rest[name]; ok {
+		p.pool.Evict(cfg)
+		delete(p.rest, name)
+	}
 	if secretKey, ok := p.clusterToSecret[name]; ok {
 		if clusters, found := p.secretToClusters[secretKey]; found {
 			delete(clusters, name)
@@ -0,0 +1,314 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"golang.org/x/net/http2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// ClientPoolOptions tunes the HTTP transports of pooled spoke clients
+type ClientPoolOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept open to a cluster's API server
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections that were idle this long
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period of connections
+	KeepAlive time.Duration
+	// HealthCheckInterval sends an HTTP/2 ping on connections that received no frame this long, so
+	// broken connections are detected before a request is multiplexed onto them
+	HealthCheckInterval time.Duration
+	// IdleClientTTL drops pooled clients not used this long
+	IdleClientTTL time.Duration
+}
+
+// DefaultClientPoolOptions returns the transport tuning used unless configured otherwise
+func DefaultClientPoolOptions() ClientPoolOptions {
+	return ClientPoolOptions{
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		KeepAlive:           30 * time.Second,
+		HealthCheckInterval: 30 * time.Second,
+		IdleClientTTL:       time.Hour,
+	}
+}
+
+// DefaultClientPool is shared by the webhook, the ClusterConfig checker and the session controller so
+// that all of them reuse the connections to a cluster
+var DefaultClientPool = NewClientPool(DefaultClientPoolOptions())
+
+// ClientPool shares clientsets and HTTP transports between callers talking to the same cluster with
+// the same credentials. Clients are keyed by the credential-relevant content of the rest.Config, so
+// a config rebuilt from unchanged credentials reuses the pooled client while changed credentials get
+// a new one. Configs carrying functions (transport wrappers, custom dialers) are keyed by identity and
+// only pooled through SharedClientset.
+type ClientPool struct {
+	opts      ClientPoolOptions
+	mu        sync.Mutex
+	entries   map[string]*pooledClient
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type pooledClient struct {
+	// source is the config the entry was requested with, kept so identity keys stay unique
+	source *rest.Config
+	// config is the impersonation-free config the clientset was built from, sharing its rate limiter
+	config     *rest.Config
+	httpClient *http.Client
+	transport  *http.Transport
+	clientset  *kubernetes.Clientset
+	lastUsed   time.Time
+}
+
+// NewClientPool returns an empty pool; zero options fall back to DefaultClientPoolOptions
+func NewClientPool(opts ClientPoolOptions) *ClientPool {
+	def := DefaultClientPoolOptions()
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = def.IdleConnTimeout
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = def.KeepAlive
+	}
+	if opts.HealthCheckInterval <= 0 {
+		opts.HealthCheckInterval = def.HealthCheckInterval
+	}
+	if opts.IdleClientTTL <= 0 {
+		opts.IdleClientTTL = def.IdleClientTTL
+	}
+	return &ClientPool{opts: opts, entries: map[string]*pooledClient{}, now: time.Now}
+}
+
+// Clientset returns a pooled clientset for the config. Impersonating configs share the transport of
+// the config without impersonation, so impersonated requests multiplex onto the same connections.
+// Configs carrying functions can only be pooled by identity, and the pool cannot tell whether the
+// caller keeps using the same config, so they get an unpooled clientset; callers holding a long-lived
+// config use SharedClientset instead.
+func (p *ClientPool) Clientset(cfg *rest.Config) (kubernetes.Interface, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("rest config is nil")
+	}
+	if keyedByIdentity(cfg) {
+		metrics.SpokeClientPoolLookups.WithLabelValues("unpooled").Inc()
+		return kubernetes.NewForConfig(cfg)
+	}
+	return p.SharedClientset(cfg, cfg.Impersonate)
+}
+
+// SharedClientset returns the pooled clientset for base with the impersonation applied on top of its
+// transport; the impersonation of base itself is ignored. base must be long-lived, like the configs
+// cached by ClientProvider.GetRESTConfig, as configs carrying functions are pooled by identity and a
+// fresh copy per call would create a pooled client per call.
+func (p *ClientPool) SharedClientset(base *rest.Config, impersonate rest.ImpersonationConfig) (kubernetes.Interface, error) {
+	entry, err := p.get(base)
+	if err != nil {
+		return nil, err
+	}
+	if !impersonates(impersonate) {
+		return entry.clientset, nil
+	}
+	httpClient := &http.Client{
+		Transport: transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+			UserName: impersonate.UserName,
+			UID:      impersonate.UID,
+			Groups:   impersonate.Groups,
+			Extra:    impersonate.Extra,
+		}, entry.httpClient.Transport),
+		Timeout: entry.httpClient.Timeout,
+	}
+	return kubernetes.NewForConfigAndClient(entry.config, httpClient)
+}
+
+// HTTPClient returns the pooled HTTP client for the config without impersonation. Like Clientset it
+// does not pool configs carrying functions.
+func (p *ClientPool) HTTPClient(cfg *rest.Config) (*http.Client, error) {
+	if cfg != nil && keyedByIdentity(cfg) {
+		metrics.SpokeClientPoolLookups.WithLabelValues("unpooled").Inc()
+		base := rest.CopyConfig(cfg)
+		base.Impersonate = rest.ImpersonationConfig{}
+		return rest.HTTPClientFor(base)
+	}
+	entry, err := p.get(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return entry.httpClient, nil
+}
+
+// Evict drops the client pooled for the config and closes its idle connections
+func (p *ClientPool) Evict(cfg *rest.Config) {
+	if cfg == nil {
+		return
+	}
+	key := poolKey(cfg)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.removeLocked(key) {
+		metrics.SpokeClientPoolEvictions.WithLabelValues("invalidated").Inc()
+	}
+}
+
+// Len returns the number of pooled clients
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+func (p *ClientPool) get(cfg *rest.Config) (*pooledClient, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("rest config is nil")
+	}
+	key := poolKey(cfg)
+	now := p.now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sweepLocked(now)
+	if entry, ok := p.entries[key]; ok {
+		entry.lastUsed = now
+		metrics.SpokeClientPoolLookups.WithLabelValues("hit").Inc()
+		return entry, nil
+	}
+	metrics.SpokeClientPoolLookups.WithLabelValues("miss").Inc()
+	entry, err := p.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	entry.lastUsed = now
+	p.entries[key] = entry
+	metrics.SpokeClientPoolSize.Set(float64(len(p.entries)))
+	return entry, nil
+}
+
+// build creates the transport with the pool's tuning and wraps it the way client-go would
+func (p *ClientPool) build(cfg *rest.Config) (*pooledClient, error) {
+	base := rest.CopyConfig(cfg)
+	base.Impersonate = rest.ImpersonationConfig{}
+	if base.RateLimiter == nil && base.QPS >= 0 {
+		qps, burst := base.QPS, base.Burst
+		if qps == 0 {
+			qps = rest.DefaultQPS
+		}
+		if burst == 0 {
+			burst = rest.DefaultBurst
+		}
+		// one rate limiter per cluster and credential, shared by all clientsets built from the entry
+		base.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+
+	tc, err := base.TransportConfig()
+	if err != nil {
+		return nil, fmt.Errorf("transport config: %w", err)
+	}
+	tlsConfig, err := transport.TLSConfigFor(tc)
+	if err != nil {
+		return nil, fmt.Errorf("tls config: %w", err)
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: p.opts.KeepAlive}
+	dial := dialer.DialContext
+	if tc.DialHolder != nil && tc.DialHolder.Dial != nil {
+		dial = tc.DialHolder.Dial
+	}
+	proxy := http.ProxyFromEnvironment
+	if tc.Proxy != nil {
+		proxy = tc.Proxy
+	}
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   10 * time.Second,
+		MaxIdleConnsPerHost:   p.opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       p.opts.IdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	h2, err := http2.ConfigureTransports(t)
+	if err != nil {
+		return nil, fmt.Errorf("configure http2: %w", err)
+	}
+	h2.ReadIdleTimeout = p.opts.HealthCheckInterval
+	h2.PingTimeout = 15 * time.Second
+
+	rt, err := transport.HTTPWrappersForConfig(tc, t)
+	if err != nil {
+		return nil, fmt.Errorf("transport wrappers: %w", err)
+	}
+	httpClient := &http.Client{Transport: rt, Timeout: base.Timeout}
+	clientset, err := kubernetes.NewForConfigAndClient(base, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledClient{source: cfg, config: base, httpClient: httpClient, transport: t, clientset: clientset}, nil
+}
+
+// sweepLocked drops clients idle for longer than IdleClientTTL, at most once a minute
+func (p *ClientPool) sweepLocked(now time.Time) {
+	if now.Sub(p.lastSweep) < time.Minute {
+		return
+	}
+	p.lastSweep = now
+	for key, entry := range p.entries {
+		if now.Sub(entry.lastUsed) > p.opts.IdleClientTTL && p.removeLocked(key) {
+			metrics.SpokeClientPoolEvictions.WithLabelValues("idle").Inc()
+		}
+	}
+}
+
+func (p *ClientPool) removeLocked(key string) bool {
+	entry, ok := p.entries[key]
+	if !ok {
+		return false
+	}
+	delete(p.entries, key)
+	entry.transport.CloseIdleConnections()
+	metrics.SpokeClientPoolSize.Set(float64(len(p.entries)))
+	return true
+}
+
+// keyedByIdentity reports whether the config carries settings that cannot be compared by content
+func keyedByIdentity(cfg *rest.Config) bool {
+	return cfg.WrapTransport != nil || cfg.Dial != nil || cfg.Proxy != nil || cfg.Transport != nil ||
+		cfg.ExecProvider != nil || cfg.AuthProvider != nil || cfg.RateLimiter != nil
+}
+
+func impersonates(i rest.ImpersonationConfig) bool {
+	return i.UserName != "" || i.UID != "" || len(i.Groups) > 0 || len(i.Extra) > 0
+}
+
+// poolKey identifies the connection and credential settings of a config. Impersonation is not part
+// of the key as it is applied on top of the pooled transport.
+func poolKey(cfg *rest.Config) string {
+	if keyedByIdentity(cfg) {
+		// functions cannot be compared, so such configs only share clients with themselves; the
+		// pooled entry keeps the config alive so its address is not reused
+		return fmt.Sprintf("ptr:%p", cfg)
+	}
+	h := sha256.New()
+	tls := cfg.TLSClientConfig
+	for _, field := range []string{
+		cfg.Host, cfg.APIPath, cfg.UserAgent,
+		cfg.BearerToken, cfg.BearerTokenFile, cfg.Username, cfg.Password,
+		tls.ServerName, tls.CertFile, tls.KeyFile, tls.CAFile,
+		string(tls.CertData), string(tls.KeyData), string(tls.CAData),
+		fmt.Sprint(tls.Insecure, tls.NextProtos, cfg.Timeout, cfg.QPS, cfg.Burst, cfg.DisableCompression),
+		cfg.ContentType, cfg.AcceptContentTypes,
+	} {
+		// length prefix so adjacent fields cannot run into each other
+		fmt.Fprintf(h, "%d:%s|", len(field), field)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap/zaptest"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// recordingAPIServer answers SelfSubjectAccessReviews and /version and records the impersonated
+// user and the remote address of every request
+type recordingAPIServer struct {
+	mu           sync.Mutex
+	impersonated []string
+	remoteAddrs  map[string]struct{}
+}
+
+func (s *recordingAPIServer) start(t *testing.T) *httptest.Server {
+	s.remoteAddrs = map[string]struct{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.impersonated = append(s.impersonated, r.Header.Get("Impersonate-User"))
+		s.remoteAddrs[r.RemoteAddr] = struct{}{}
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			_, _ = w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.0"}`))
+		case "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews":
+			var review authorizationv1.SelfSubjectAccessReview
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+			review.Status.Allowed = true
+			_ = json.NewEncoder(w).Encode(review)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testRESTConfig(host, token string) *rest.Config {
+	return &rest.Config{Host: host, BearerToken: token, ContentConfig: rest.ContentConfig{ContentType: "application/json"}}
+}
+
+func TestClientPool_SharesClientsByContent(t *testing.T) {
+	pool := NewClientPool(ClientPoolOptions{})
+
+	first, err := pool.Clientset(testRESTConfig("https://api.example.com", "token-a"))
+	require.NoError(t, err)
+	// a config rebuilt from the same credentials reuses the client
+	second, err := pool.Clientset(testRESTConfig("https://api.example.com", "token-a"))
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// changed credentials get their own client
+	rotated, err := pool.Clientset(testRESTConfig("https://api.example.com", "token-b"))
+	require.NoError(t, err)
+	assert.NotSame(t, first, rotated)
+	assert.Equal(t, 2, pool.Len())
+}
+
+func TestClientPool_IdentityKeyForTransportWrappers(t *testing.T) {
+	pool := NewClientPool(ClientPoolOptions{})
+	wrap := func(rt http.RoundTripper) http.RoundTripper { return rt }
+
+	a := testRESTConfig("https://api.example.com", "")
+	a.WrapTransport = wrap
+	b := testRESTConfig("https://api.example.com", "")
+	b.WrapTransport = wrap
+
+	ca, err := pool.SharedClientset(a, rest.ImpersonationConfig{})
+	require.NoError(t, err)
+	cb, err := pool.SharedClientset(b, rest.ImpersonationConfig{})
+	require.NoError(t, err)
+	assert.NotSame(t, ca, cb, "wrapped transports may carry different credentials")
+	again, err := pool.SharedClientset(a, rest.ImpersonationConfig{})
+	require.NoError(t, err)
+	assert.Same(t, ca, again)
+	_, err = pool.SharedClientset(a, rest.ImpersonationConfig{UserName: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Len())
+
+	// copies of a wrapped config handed to Clientset are not pooled, so they cannot pile up
+	for i := 0; i < 3; i++ {
+		cfg := rest.CopyConfig(a)
+		cfg.Impersonate = rest.ImpersonationConfig{UserName: "bob"}
+		_, err := pool.Clientset(cfg)
+		require.NoError(t, err)
+		_, err = pool.HTTPClient(cfg)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, pool.Len())
+}
+
+func TestClientPool_ImpersonationSharesConnections(t *testing.T) {
+	api := &recordingAPIServer{}
+	srv := api.start(t)
+	pool := NewClientPool(ClientPoolOptions{})
+	ctx := context.Background()
+
+	base := testRESTConfig(srv.URL, "token")
+	for _, user := range []string{"", "alice", "bob"} {
+		cfg := rest.CopyConfig(base)
+		cfg.Impersonate = rest.ImpersonationConfig{UserName: user}
+		cs, err := pool.Clientset(cfg)
+		require.NoError(t, err)
+		_, err = cs.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "pods"}},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, pool.Len())
+	assert.Equal(t, []string{"", "alice", "bob"}, api.impersonated)
+	assert.Len(t, api.remoteAddrs, 1, "sequential requests should reuse one connection")
+}
+
+func TestClientPool_EvictAndIdleSweep(t *testing.T) {
+	pool := NewClientPool(ClientPoolOptions{IdleClientTTL: 10 * time.Minute})
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	cfg := testRESTConfig("https://api.example.com", "token")
+	first, err := pool.Clientset(cfg)
+	require.NoError(t, err)
+	pool.Evict(cfg)
+	assert.Equal(t, 0, pool.Len())
+	second, err := pool.Clientset(cfg)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	// another cluster keeps being used while the first one goes idle
+	other := testRESTConfig("https://other.example.com", "token")
+	now = now.Add(11 * time.Minute)
+	_, err = pool.Clientset(other)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Len(), "the idle client should have been dropped")
+}
+
+func TestClientPool_NilConfig(t *testing.T) {
+	_, err := NewClientPool(ClientPoolOptions{}).Clientset(nil)
+	assert.Error(t, err)
+}
+
+func TestClientProvider_PooledClientset(t *testing.T) {
+	api := &recordingAPIServer{}
+	srv := api.start(t)
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = telekomv1alpha1.AddToScheme(scheme)
+	cc := telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "pooled", Namespace: "default"},
+		Spec: telekomv1alpha1.ClusterConfigSpec{Auth: &telekomv1alpha1.ClusterAuth{
+			Server:         srv.URL,
+			TokenSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "pooled-token", Namespace: "default"},
+		}},
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pooled-token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("abc")},
+	}
+	provider := NewClientProvider(fake.NewClientBuilder().WithScheme(scheme).WithObjects(&cc, &secret).Build(), zaptest.NewLogger(t).Sugar())
+	provider.pool = NewClientPool(ClientPoolOptions{})
+	ctx := context.Background()
+
+	require.NoError(t, provider.Warm(ctx, "pooled"))
+	first, err := provider.GetClientset(ctx, "pooled")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.pool.Len())
+
+	// status updates keep the pooled client
+	provider.Refresh("pooled")
+	second, err := provider.GetClientset(ctx, "pooled")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// secret changes drop it
+	provider.InvalidateSecret("default", "pooled-token")
+	assert.Equal(t, 0, provider.pool.Len())
+	third, err := provider.GetClientset(ctx, "pooled")
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+}
@@ -0,0 +1,227 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultWorkloadIdentityTokenFile is the default mount path of the projected ServiceAccount token
+// exchanged by ClusterConfigs using workload identity
+const DefaultWorkloadIdentityTokenFile = "/var/run/secrets/breakglass/workload-identity/token"
+
+// WorkloadIdentityTokenFile is the projected ServiceAccount token exchanged by ClusterConfigs using
+// workload identity. It is set on the controller rather than per ClusterConfig, so ClusterConfig
+// authors cannot send other files of the controller pod to a token endpoint.
+var WorkloadIdentityTokenFile = DefaultWorkloadIdentityTokenFile
+
+// WorkloadIdentityTokenURLs are the token endpoints ClusterConfigs may exchange the projected token at.
+// The token is sent to the tokenURL of the ClusterConfig, so without this list any ClusterConfig author
+// could receive the controller's ServiceAccount token. Workload identity is refused while it is empty.
+var WorkloadIdentityTokenURLs []string
+
+const (
+	// defaultTokenSecretKey is the data key of token Secrets, as used by ServiceAccount token Secrets
+	defaultTokenSecretKey = "token"
+	// defaultWorkloadIdentityTokenLifetime is assumed when the token endpoint omits expires_in
+	defaultWorkloadIdentityTokenLifetime = 5 * time.Minute
+
+	tokenExchangeGrantType    = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT              = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeAccessToken      = "urn:ietf:params:oauth:token-type:access_token"
+	maxTokenExchangeErrorBody = 512
+)
+
+// tokenExchangeHTTPClient performs workload identity token exchanges; overridable in tests
+var tokenExchangeHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// RESTConfigFromAuth builds a rest.Config from the auth settings of a ClusterConfig. It also returns
+// the cache key (namespace/name) of the Secret the credential was read from, or "" for workload identity.
+func RESTConfigFromAuth(ctx context.Context, reader ctrlclient.Reader, auth *telekomv1alpha1.ClusterAuth) (*rest.Config, string, error) {
+	if auth == nil {
+		return nil, "", errors.New("auth is not configured")
+	}
+	cfg := &rest.Config{
+		Host:            auth.Server,
+		TLSClientConfig: rest.TLSClientConfig{ServerName: auth.TLSServerName},
+	}
+	if auth.CertificateAuthority != "" {
+		cfg.CAData = []byte(auth.CertificateAuthority)
+	}
+
+	switch {
+	case auth.TokenSecretRef != nil:
+		ref := auth.TokenSecretRef
+		key := ref.Key
+		if key == "" {
+			key = defaultTokenSecretKey
+		}
+		secret := corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, "", fmt.Errorf("fetch token secret: %w", err)
+		}
+		token := strings.TrimSpace(string(secret.Data[key]))
+		if token == "" {
+			return nil, "", fmt.Errorf("secret %s/%s missing key %s", ref.Namespace, ref.Name, key)
+		}
+		cfg.BearerToken = token
+		return cfg, secretCacheKey(ref.Namespace, ref.Name), nil
+
+	case auth.ClientCertificateSecretRef != nil:
+		ref := auth.ClientCertificateSecretRef
+		secret := corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, "", fmt.Errorf("fetch client certificate secret: %w", err)
+		}
+		for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+			if len(secret.Data[key]) == 0 {
+				return nil, "", fmt.Errorf("secret %s/%s missing key %s", ref.Namespace, ref.Name, key)
+			}
+		}
+		if _, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); err != nil {
+			return nil, "", fmt.Errorf("invalid client certificate in secret %s/%s: %w", ref.Namespace, ref.Name, err)
+		}
+		cfg.CertData = secret.Data[corev1.TLSCertKey]
+		cfg.KeyData = secret.Data[corev1.TLSPrivateKeyKey]
+		return cfg, secretCacheKey(ref.Namespace, ref.Name), nil
+
+	case auth.WorkloadIdentity != nil:
+		if !slices.Contains(WorkloadIdentityTokenURLs, auth.WorkloadIdentity.TokenURL) {
+			return nil, "", fmt.Errorf("workload identity tokenURL %q is not allowed by kubernetes.workloadIdentityTokenURLs", auth.WorkloadIdentity.TokenURL)
+		}
+		src := &tokenExchangeSource{cfg: *auth.WorkloadIdentity.DeepCopy(), tokenFile: WorkloadIdentityTokenFile}
+		cfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			return &tokenExchangeTransport{source: src, base: rt}
+		}
+		return cfg, "", nil
+	}
+	return nil, "", errors.New("auth has no credential configured")
+}
+
+// tokenExchangeSource exchanges the projected ServiceAccount token for an access token of the
+// cluster (RFC 8693). The token file is re-read on every exchange since the kubelet rotates it.
+// The exchanged token is reused until shortly before it expires or until reset.
+type tokenExchangeSource struct {
+	cfg       telekomv1alpha1.WorkloadIdentityAuth
+	tokenFile string
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns the cached token or exchanges a new one with the context of the request that needs it
+func (s *tokenExchangeSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	token, err := s.exchange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// reset drops the cached token, e.g. after the cluster rejected it
+func (s *tokenExchangeSource) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+}
+
+func (s *tokenExchangeSource) exchange(ctx context.Context) (*oauth2.Token, error) {
+	subject, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("read workload identity token: %w", err)
+	}
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {strings.TrimSpace(string(subject))},
+		"subject_token_type":   {tokenTypeJWT},
+		"requested_token_type": {tokenTypeAccessToken},
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.ClientID != "" {
+		form.Set("client_id", s.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("workload identity token exchange: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := tokenExchangeHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("workload identity token exchange: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxTokenExchangeErrorBody))
+		return nil, fmt.Errorf("workload identity token exchange failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode workload identity token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("workload identity token response contains no access_token")
+	}
+	lifetime := time.Duration(result.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultWorkloadIdentityTokenLifetime
+	}
+	// The token is always sent as a bearer token, whatever token_type the service reports
+	return &oauth2.Token{AccessToken: result.AccessToken, TokenType: "Bearer", Expiry: time.Now().Add(lifetime)}, nil
+}
+
+// tokenExchangeTransport authenticates requests to the cluster with the exchanged token and drops the
+// token when the cluster answers 401, so the next request exchanges a new one
+type tokenExchangeTransport struct {
+	source *tokenExchangeSource
+	base   http.RoundTripper
+}
+
+func (t *tokenExchangeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	token.SetAuthHeader(req)
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.source.reset()
+	}
+	return resp, err
+}
@@ -0,0 +1,275 @@
+package cluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func credentialsScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = telekomv1alpha1.AddToScheme(scheme)
+	return scheme
+}
+
+func mustClientCertificate(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "breakglass"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestRESTConfigFromAuth_Token(t *testing.T) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sa-token", Namespace: "breakglass"},
+		Data:       map[string][]byte{"token": []byte("abc\n")},
+	}
+	c := fake.NewClientBuilder().WithScheme(credentialsScheme()).WithObjects(&secret).Build()
+	auth := &telekomv1alpha1.ClusterAuth{
+		Server:               "https://api.example.com:6443",
+		CertificateAuthority: "ca-pem",
+		TLSServerName:        "api.internal",
+		TokenSecretRef:       &telekomv1alpha1.SecretKeyReference{Name: "sa-token", Namespace: "breakglass"},
+	}
+
+	cfg, secretKey, err := RESTConfigFromAuth(context.Background(), c, auth)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com:6443", cfg.Host)
+	assert.Equal(t, "abc", cfg.BearerToken)
+	assert.Equal(t, []byte("ca-pem"), cfg.CAData)
+	assert.Equal(t, "api.internal", cfg.ServerName)
+	assert.Equal(t, "breakglass/sa-token", secretKey)
+
+	auth.TokenSecretRef.Key = "other"
+	_, _, err = RESTConfigFromAuth(context.Background(), c, auth)
+	assert.ErrorContains(t, err, "missing key other")
+
+	auth.TokenSecretRef.Name = "absent"
+	_, _, err = RESTConfigFromAuth(context.Background(), c, auth)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestRESTConfigFromAuth_ClientCertificate(t *testing.T) {
+	certPEM, keyPEM := mustClientCertificate(t)
+	valid := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-cert", Namespace: "breakglass"},
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+	}
+	broken := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken-cert", Namespace: "breakglass"},
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: []byte("garbage")},
+	}
+	c := fake.NewClientBuilder().WithScheme(credentialsScheme()).WithObjects(&valid, &broken).Build()
+
+	auth := &telekomv1alpha1.ClusterAuth{
+		Server:                     "https://api.example.com:6443",
+		ClientCertificateSecretRef: &telekomv1alpha1.SecretReference{Name: "client-cert", Namespace: "breakglass"},
+	}
+	cfg, secretKey, err := RESTConfigFromAuth(context.Background(), c, auth)
+	require.NoError(t, err)
+	assert.Equal(t, certPEM, cfg.CertData)
+	assert.Equal(t, keyPEM, cfg.KeyData)
+	assert.Nil(t, cfg.CAData)
+	assert.Equal(t, "breakglass/client-cert", secretKey)
+
+	auth.ClientCertificateSecretRef.Name = "broken-cert"
+	_, _, err = RESTConfigFromAuth(context.Background(), c, auth)
+	assert.ErrorContains(t, err, "invalid client certificate")
+}
+
+func TestRESTConfigFromAuth_WorkloadIdentity(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("projected-sa-token\n"), 0o600))
+	oldFile := WorkloadIdentityTokenFile
+	WorkloadIdentityTokenFile = tokenFile
+	t.Cleanup(func() { WorkloadIdentityTokenFile = oldFile })
+
+	var exchanges atomic.Int32
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges.Add(1)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, tokenExchangeGrantType, r.PostForm.Get("grant_type"))
+		assert.Equal(t, "projected-sa-token", r.PostForm.Get("subject_token"))
+		assert.Equal(t, tokenTypeJWT, r.PostForm.Get("subject_token_type"))
+		assert.Equal(t, "spoke-cluster", r.PostForm.Get("audience"))
+		assert.Equal(t, "openid k8s", r.PostForm.Get("scope"))
+		assert.Equal(t, "breakglass", r.PostForm.Get("client_id"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "exchanged-token", "token_type": "N_A", "expires_in": 3600})
+	}))
+	t.Cleanup(sts.Close)
+	oldClient := tokenExchangeHTTPClient
+	tokenExchangeHTTPClient = sts.Client()
+	t.Cleanup(func() { tokenExchangeHTTPClient = oldClient })
+
+	var authHeaders []string
+	var reject atomic.Bool
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		if reject.CompareAndSwap(true, false) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"34"}`))
+	}))
+	t.Cleanup(apiServer.Close)
+
+	auth := &telekomv1alpha1.ClusterAuth{
+		Server: apiServer.URL,
+		WorkloadIdentity: &telekomv1alpha1.WorkloadIdentityAuth{
+			TokenURL: sts.URL,
+			Audience: "spoke-cluster",
+			Scopes:   []string{"openid", "k8s"},
+			ClientID: "breakglass",
+		},
+	}
+	_, _, err := RESTConfigFromAuth(context.Background(), fake.NewClientBuilder().Build(), auth)
+	assert.ErrorContains(t, err, "not allowed", "the token must only be sent to token endpoints the operator allowed")
+
+	oldURLs := WorkloadIdentityTokenURLs
+	WorkloadIdentityTokenURLs = []string{sts.URL}
+	t.Cleanup(func() { WorkloadIdentityTokenURLs = oldURLs })
+	cfg, secretKey, err := RESTConfigFromAuth(context.Background(), fake.NewClientBuilder().Build(), auth)
+	require.NoError(t, err)
+	assert.Empty(t, secretKey)
+
+	httpClient, err := rest.HTTPClientFor(cfg)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient.Get(apiServer.URL + "/version")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, []string{"Bearer exchanged-token", "Bearer exchanged-token"}, authHeaders)
+	assert.Equal(t, int32(1), exchanges.Load(), "exchanged token should be reused until it expires")
+
+	reject.Store(true)
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient.Get(apiServer.URL + "/version")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, int32(2), exchanges.Load(), "a token rejected by the cluster should be exchanged again")
+}
+
+func TestTokenExchangeSource_Errors(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("projected-sa-token"), 0o600))
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(sts.Close)
+	oldClient := tokenExchangeHTTPClient
+	tokenExchangeHTTPClient = sts.Client()
+	t.Cleanup(func() { tokenExchangeHTTPClient = oldClient })
+
+	src := &tokenExchangeSource{cfg: telekomv1alpha1.WorkloadIdentityAuth{TokenURL: sts.URL}, tokenFile: tokenFile}
+	_, err := src.Token(context.Background())
+	assert.ErrorContains(t, err, "status 400")
+
+	src.tokenFile = filepath.Join(t.TempDir(), "missing")
+	_, err = src.Token(context.Background())
+	assert.ErrorContains(t, err, "read workload identity token")
+}
+
+func TestTokenExchangeSource_UsesRequestContext(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("projected-sa-token"), 0o600))
+	release := make(chan struct{})
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(sts.Close)
+	t.Cleanup(func() { close(release) })
+	oldClient := tokenExchangeHTTPClient
+	tokenExchangeHTTPClient = sts.Client()
+	t.Cleanup(func() { tokenExchangeHTTPClient = oldClient })
+
+	src := &tokenExchangeSource{cfg: telekomv1alpha1.WorkloadIdentityAuth{TokenURL: sts.URL}, tokenFile: tokenFile}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := src.Token(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second, "a slow token endpoint must not outlive the request")
+}
+
+func TestGetRESTConfig_Auth(t *testing.T) {
+	cc := telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "token-cluster", Namespace: "default"},
+		Spec: telekomv1alpha1.ClusterConfigSpec{
+			Auth: &telekomv1alpha1.ClusterAuth{
+				Server:         "https://api.example.com:6443",
+				TokenSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "sa-token", Namespace: "default"},
+			},
+		},
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sa-token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("abc")},
+	}
+	c := fake.NewClientBuilder().WithScheme(credentialsScheme()).WithObjects(&cc, &secret).Build()
+	provider := NewClientProvider(c, zaptest.NewLogger(t).Sugar())
+
+	cfg, err := provider.GetRESTConfig(context.Background(), "token-cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", cfg.BearerToken)
+	assert.True(t, provider.IsSecretTracked("default", "sa-token"))
+
+	// rotating the token secret evicts the cached config
+	provider.InvalidateSecret("default", "sa-token")
+	provider.mu.RLock()
+	_, cached := provider.rest["token-cluster"]
+	provider.mu.RUnlock()
+	assert.False(t, cached)
+}
+
+func TestGetRESTConfig_NoCredentials(t *testing.T) {
+	cc := telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(credentialsScheme()).WithObjects(&cc).Build()
+	provider := NewClientProvider(c, zaptest.NewLogger(t).Sugar())
+
+	_, err := provider.GetRESTConfig(context.Background(), "empty")
+	assert.ErrorContains(t, err, "neither kubeconfigSecretRef nor auth")
+}
@@ -0,0 +1,464 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"github.com/telekom/k8s-breakglass/pkg/metrics"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Labels and annotations of ClusterConfigs managed by cluster discovery
+const (
+	DiscoveryManagedByLabel   = "app.kubernetes.io/managed-by"
+	DiscoveryManagedByValue   = "breakglass-cluster-discovery"
+	DiscoverySourceAnnotation = "breakglass.t-caas.telekom.com/discovery-source"
+)
+
+const (
+	// DefaultDiscoveryInterval is the time between discovery runs when none is configured
+	DefaultDiscoveryInterval = time.Minute
+	// clusterAPIGroup is the API group of Cluster API Cluster objects
+	clusterAPIGroup = "cluster.x-k8s.io"
+	// clusterAPIClusterNameLabel names the cluster a Cluster API kubeconfig Secret belongs to
+	clusterAPIClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+	// clusterAPIKubeconfigSuffix is appended to the cluster name by Cluster API kubeconfig Secrets
+	clusterAPIKubeconfigSuffix = "-kubeconfig"
+	// defaultDiscoverySecretKey is the kubeconfig key of Cluster API kubeconfig Secrets
+	defaultDiscoverySecretKey = "value"
+)
+
+// Discoverer creates, updates and deletes ClusterConfigs for the clusters of a fleet, discovered
+// from Cluster API Cluster objects or kubeconfig Secrets. Every ClusterConfig it manages carries the
+// managed-by label; ClusterConfigs without it are never modified. It runs only on the leader, as a
+// controller triggered by changes of the source objects.
+type Discoverer struct {
+	client        ctrlclient.Client
+	log           *zap.SugaredLogger
+	cfg           config.ClusterDiscovery
+	template      telekomv1alpha1.ClusterConfigSpec
+	selector      labels.Selector
+	interval      time.Duration
+	leaderElected <-chan struct{}
+	// trigger starts the first run of the discovery controller after leadership is acquired
+	trigger chan event.GenericEvent
+}
+
+// discoveredCluster is a cluster found by a discovery source
+type discoveredCluster struct {
+	name      string
+	namespace string
+	source    string // Kind/namespace/name of the source object
+	labels    map[string]string
+	secretRef telekomv1alpha1.SecretKeyReference
+}
+
+// NewDiscoverer validates the discovery configuration. leaderElected may be nil to start immediately.
+func NewDiscoverer(c ctrlclient.Client, cfg config.ClusterDiscovery, leaderElected <-chan struct{}, log *zap.SugaredLogger) (*Discoverer, error) {
+	if cfg.Source == "" {
+		cfg.Source = config.ClusterDiscoverySourceClusterAPI
+	}
+	if cfg.Source != config.ClusterDiscoverySourceClusterAPI && cfg.Source != config.ClusterDiscoverySourceSecret {
+		return nil, fmt.Errorf("invalid clusterDiscovery.source %q: must be %s or %s", cfg.Source,
+			config.ClusterDiscoverySourceClusterAPI, config.ClusterDiscoverySourceSecret)
+	}
+	// Without a selector every Secret of the cluster would be taken for a kubeconfig
+	if cfg.Source == config.ClusterDiscoverySourceSecret && cfg.LabelSelector == "" {
+		return nil, errors.New("clusterDiscovery.labelSelector is required for the Secret source")
+	}
+	selector, err := labels.Parse(cfg.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterDiscovery.labelSelector: %w", err)
+	}
+	interval := DefaultDiscoveryInterval
+	if cfg.Interval != "" {
+		if interval, err = time.ParseDuration(cfg.Interval); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid clusterDiscovery.interval %q", cfg.Interval)
+		}
+	}
+	if cfg.ClusterAPIVersion == "" {
+		cfg.ClusterAPIVersion = "v1beta1"
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = defaultDiscoverySecretKey
+	}
+	template, err := cfg.TemplateSpec()
+	if err != nil {
+		return nil, err
+	}
+	return &Discoverer{
+		client:        c,
+		log:           log,
+		cfg:           cfg,
+		template:      template,
+		selector:      selector,
+		interval:      interval,
+		leaderElected: leaderElected,
+		trigger:       make(chan event.GenericEvent, 1),
+	}, nil
+}
+
+// discoveryRequest is the only request of the discovery controller: every event triggers a full run
+var discoveryRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "cluster-discovery"}}
+
+// SetupWithManager runs discovery as a controller. Changes of Cluster API Clusters (or kubeconfig
+// Secrets) and of managed ClusterConfigs trigger a run; each successful run is repeated after the
+// interval as a resync. If the Cluster API CRDs are not installed, discovery relies on the resync.
+// Source objects are watched through a cache of their own, restricted to the label selector and
+// namespaces, so discovery does not cache every Secret or Cluster of the cluster.
+func (d *Discoverer) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(context.Context, ctrlclient.Object) []reconcile.Request {
+		return []reconcile.Request{discoveryRequest}
+	})
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("cluster-discovery").
+		WatchesRawSource(source.Channel(d.trigger, enqueue)).
+		Watches(&telekomv1alpha1.ClusterConfig{}, enqueue, builder.WithPredicates(managedClusterConfigPredicate()))
+
+	var sourceObj ctrlclient.Object = &corev1.Secret{}
+	if d.cfg.Source != config.ClusterDiscoverySourceSecret {
+		gvk := schema.GroupVersionKind{Group: clusterAPIGroup, Version: d.cfg.ClusterAPIVersion, Kind: "Cluster"}
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			d.log.Warnw("Cluster API Clusters cannot be watched, discovery only runs every interval", "gvk", gvk.String(), "error", err)
+			sourceObj = nil
+		} else {
+			cluster := &unstructured.Unstructured{}
+			cluster.SetGroupVersionKind(gvk)
+			sourceObj = cluster
+		}
+	}
+	if sourceObj != nil {
+		opts := cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper(), DefaultLabelSelector: d.selector}
+		if len(d.cfg.Namespaces) > 0 {
+			opts.DefaultNamespaces = make(map[string]cache.Config, len(d.cfg.Namespaces))
+			for _, ns := range d.cfg.Namespaces {
+				opts.DefaultNamespaces[ns] = cache.Config{}
+			}
+		}
+		sourceCache, err := cache.New(mgr.GetConfig(), opts)
+		if err != nil {
+			return fmt.Errorf("create cluster discovery cache: %w", err)
+		}
+		if err := mgr.Add(sourceCache); err != nil {
+			return fmt.Errorf("add cluster discovery cache: %w", err)
+		}
+		b = b.WatchesRawSource(source.Kind(sourceCache, sourceObj, enqueue, d.sourcePredicate()))
+	}
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: 1}).Complete(d)
+}
+
+// Start triggers the first discovery run once this replica becomes the leader
+func (d *Discoverer) Start(ctx context.Context) {
+	if d.leaderElected != nil {
+		d.log.Info("Cluster discovery waiting for leadership signal before starting...")
+		select {
+		case <-ctx.Done():
+			d.log.Info("Cluster discovery stopping before acquiring leadership (context cancelled)")
+			return
+		case <-d.leaderElected:
+			d.log.Info("Leadership acquired - starting cluster discovery")
+		}
+	}
+	d.log.Infow("Cluster discovery started", "source", d.cfg.Source, "interval", d.interval, "labelSelector", d.cfg.LabelSelector)
+	select {
+	case d.trigger <- event.GenericEvent{Object: &telekomv1alpha1.ClusterConfig{}}:
+	default:
+	}
+}
+
+// Reconcile runs discovery on the leader and schedules the next resync
+func (d *Discoverer) Reconcile(ctx context.Context, _ reconcile.Request) (ctrl.Result, error) {
+	if !d.isLeader() {
+		// Start triggers the first run after leadership is acquired
+		return ctrl.Result{}, nil
+	}
+	if err := d.RunOnce(ctx); err != nil {
+		return ctrl.Result{}, fmt.Errorf("cluster discovery run failed: %w", err)
+	}
+	return ctrl.Result{RequeueAfter: d.interval}, nil
+}
+
+func (d *Discoverer) isLeader() bool {
+	if d.leaderElected == nil {
+		return true
+	}
+	select {
+	case <-d.leaderElected:
+		return true
+	default:
+		return false
+	}
+}
+
+// sourcePredicate passes events of source objects in the configured namespaces matching the label
+// selector before or after the change. Updates only matter when labels or the deletion state change.
+func (d *Discoverer) sourcePredicate() predicate.Funcs {
+	matches := func(obj ctrlclient.Object) bool {
+		if len(d.cfg.Namespaces) > 0 && !slices.Contains(d.cfg.Namespaces, obj.GetNamespace()) {
+			return false
+		}
+		return d.selector.Matches(labels.Set(obj.GetLabels()))
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return matches(e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return matches(e.Object) },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !matches(e.ObjectOld) && !matches(e.ObjectNew) {
+				return false
+			}
+			return !maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+				(e.ObjectOld.GetDeletionTimestamp() == nil) != (e.ObjectNew.GetDeletionTimestamp() == nil)
+		},
+	}
+}
+
+// managedClusterConfigPredicate passes deletions and spec changes of managed ClusterConfigs, so
+// manual edits are reverted without waiting for the resync
+func managedClusterConfigPredicate() predicate.Predicate {
+	managed := predicate.NewPredicateFuncs(func(obj ctrlclient.Object) bool {
+		return obj.GetLabels()[DiscoveryManagedByLabel] == DiscoveryManagedByValue
+	})
+	return predicate.And(managed, predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+				!maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+		},
+	})
+}
+
+// RunOnce reconciles the managed ClusterConfigs with the discovered clusters. ClusterConfigs are only
+// deleted after the source was listed successfully, so an unavailable source never empties the fleet.
+func (d *Discoverer) RunOnce(ctx context.Context) error {
+	found, err := d.discover(ctx)
+	if err != nil {
+		metrics.ClusterDiscoveryRuns.WithLabelValues("error").Inc()
+		return err
+	}
+
+	managed := telekomv1alpha1.ClusterConfigList{}
+	if err := d.client.List(ctx, &managed, ctrlclient.MatchingLabels{DiscoveryManagedByLabel: DiscoveryManagedByValue}); err != nil {
+		metrics.ClusterDiscoveryRuns.WithLabelValues("error").Inc()
+		return fmt.Errorf("list managed clusterconfigs: %w", err)
+	}
+
+	desired := make(map[types.NamespacedName]struct{}, len(found))
+	var errs []error
+	for _, dc := range found {
+		cc := d.clusterConfigFor(dc)
+		key := types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name}
+		if _, dup := desired[key]; dup {
+			d.log.Warnw("Skipping discovered cluster mapping to an already discovered ClusterConfig", "clusterConfig", key.String(), "source", dc.source)
+			continue
+		}
+		desired[key] = struct{}{}
+		if err := d.apply(ctx, cc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for i := range managed.Items {
+		cc := &managed.Items[i]
+		if _, ok := desired[types.NamespacedName{Namespace: cc.Namespace, Name: cc.Name}]; ok {
+			continue
+		}
+		if err := d.client.Delete(ctx, cc); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("delete clusterconfig %s/%s: %w", cc.Namespace, cc.Name, err))
+			continue
+		}
+		d.log.Infow("Deleted ClusterConfig of vanished cluster", "clusterConfig", cc.Namespace+"/"+cc.Name, "source", cc.Annotations[DiscoverySourceAnnotation])
+		metrics.ClusterDiscoveryChanges.WithLabelValues("deleted").Inc()
+	}
+
+	metrics.ClusterDiscoveryManaged.Set(float64(len(desired)))
+	if err := errors.Join(errs...); err != nil {
+		metrics.ClusterDiscoveryRuns.WithLabelValues("partial").Inc()
+		return err
+	}
+	metrics.ClusterDiscoveryRuns.WithLabelValues("success").Inc()
+	return nil
+}
+
+// apply creates the ClusterConfig or updates a managed one that drifted from the desired state
+func (d *Discoverer) apply(ctx context.Context, desired *telekomv1alpha1.ClusterConfig) error {
+	existing := telekomv1alpha1.ClusterConfig{}
+	err := d.client.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := d.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("create clusterconfig %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		d.log.Infow("Created ClusterConfig for discovered cluster", "clusterConfig", desired.Namespace+"/"+desired.Name, "source", desired.Annotations[DiscoverySourceAnnotation])
+		metrics.ClusterDiscoveryChanges.WithLabelValues("created").Inc()
+		return nil
+	case err != nil:
+		return fmt.Errorf("get clusterconfig %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	if existing.Labels[DiscoveryManagedByLabel] != DiscoveryManagedByValue {
+		d.log.Warnw("ClusterConfig of discovered cluster exists and is not managed by discovery; leaving it unchanged",
+			"clusterConfig", desired.Namespace+"/"+desired.Name, "source", desired.Annotations[DiscoverySourceAnnotation])
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) &&
+		existing.Annotations[DiscoverySourceAnnotation] == desired.Annotations[DiscoverySourceAnnotation] {
+		return nil
+	}
+	existing.Spec = desired.Spec
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	existing.Annotations[DiscoverySourceAnnotation] = desired.Annotations[DiscoverySourceAnnotation]
+	if err := d.client.Update(ctx, &existing); err != nil {
+		return fmt.Errorf("update clusterconfig %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	d.log.Infow("Updated ClusterConfig of discovered cluster", "clusterConfig", desired.Namespace+"/"+desired.Name)
+	metrics.ClusterDiscoveryChanges.WithLabelValues("updated").Inc()
+	return nil
+}
+
+// clusterConfigFor renders the template for a discovered cluster
+func (d *Discoverer) clusterConfigFor(dc discoveredCluster) *telekomv1alpha1.ClusterConfig {
+	spec := *d.template.DeepCopy()
+	ref := dc.secretRef
+	spec.KubeconfigSecretRef = &ref
+	// Match the value defaulted by the API server so unchanged clusters are not updated every run
+	if spec.AccessReviewMode == "" {
+		spec.AccessReviewMode = telekomv1alpha1.AccessReviewModeImpersonation
+	}
+	mapping := d.cfg.LabelMapping
+	for _, m := range []struct {
+		label string
+		field *string
+	}{
+		{mapping.Tenant, &spec.Tenant},
+		{mapping.Environment, &spec.Environment},
+		{mapping.Site, &spec.Site},
+		{mapping.Location, &spec.Location},
+	} {
+		if v := dc.labels[m.label]; m.label != "" && v != "" {
+			*m.field = v
+		}
+	}
+
+	namespace := d.cfg.TargetNamespace
+	if namespace == "" {
+		namespace = dc.namespace
+	}
+	return &telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        d.cfg.NamePrefix + dc.name,
+			Namespace:   namespace,
+			Labels:      map[string]string{DiscoveryManagedByLabel: DiscoveryManagedByValue},
+			Annotations: map[string]string{DiscoverySourceAnnotation: dc.source},
+		},
+		Spec: spec,
+	}
+}
+
+// discover lists the clusters of the configured source, sorted by source for stable results
+func (d *Discoverer) discover(ctx context.Context) ([]discoveredCluster, error) {
+	namespaces := d.cfg.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	var found []discoveredCluster
+	for _, ns := range namespaces {
+		opts := []ctrlclient.ListOption{ctrlclient.InNamespace(ns), ctrlclient.MatchingLabelsSelector{Selector: d.selector}}
+		var (
+			batch []discoveredCluster
+			err   error
+		)
+		if d.cfg.Source == config.ClusterDiscoverySourceSecret {
+			batch, err = d.discoverSecrets(ctx, opts)
+		} else {
+			batch, err = d.discoverClusterAPI(ctx, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, batch...)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].source < found[j].source })
+	return found, nil
+}
+
+func (d *Discoverer) discoverClusterAPI(ctx context.Context, opts []ctrlclient.ListOption) ([]discoveredCluster, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: clusterAPIGroup, Version: d.cfg.ClusterAPIVersion, Kind: "ClusterList"})
+	if err := d.client.List(ctx, list, opts...); err != nil {
+		return nil, fmt.Errorf("list Cluster API clusters: %w", err)
+	}
+	var found []discoveredCluster
+	for _, item := range list.Items {
+		if item.GetDeletionTimestamp() != nil {
+			continue
+		}
+		found = append(found, discoveredCluster{
+			name:      item.GetName(),
+			namespace: item.GetNamespace(),
+			source:    "Cluster/" + item.GetNamespace() + "/" + item.GetName(),
+			labels:    item.GetLabels(),
+			secretRef: telekomv1alpha1.SecretKeyReference{
+				Name:      item.GetName() + clusterAPIKubeconfigSuffix,
+				Namespace: item.GetNamespace(),
+				Key:       d.cfg.SecretKey,
+			},
+		})
+	}
+	return found, nil
+}
+
+func (d *Discoverer) discoverSecrets(ctx context.Context, opts []ctrlclient.ListOption) ([]discoveredCluster, error) {
+	list := &corev1.SecretList{}
+	if err := d.client.List(ctx, list, opts...); err != nil {
+		return nil, fmt.Errorf("list kubeconfig secrets: %w", err)
+	}
+	var found []discoveredCluster
+	for _, s := range list.Items {
+		if s.DeletionTimestamp != nil {
+			continue
+		}
+		name := s.Labels[clusterAPIClusterNameLabel]
+		if name == "" {
+			name = strings.TrimSuffix(s.Name, clusterAPIKubeconfigSuffix)
+		}
+		found = append(found, discoveredCluster{
+			name:      name,
+			namespace: s.Namespace,
+			source:    "Secret/" + s.Namespace + "/" + s.Name,
+			labels:    s.Labels,
+			secretRef: telekomv1alpha1.SecretKeyReference{Name: s.Name, Namespace: s.Namespace, Key: d.cfg.SecretKey},
+		})
+	}
+	return found, nil
+}
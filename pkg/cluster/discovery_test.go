@@ -0,0 +1,260 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func discoveryScheme() *runtime.Scheme {
+	scheme := credentialsScheme()
+	gv := schema.GroupVersion{Group: clusterAPIGroup, Version: "v1beta1"}
+	scheme.AddKnownTypeWithName(gv.WithKind("Cluster"), &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gv.WithKind("ClusterList"), &unstructured.UnstructuredList{})
+	return scheme
+}
+
+func capiCluster(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: clusterAPIGroup, Version: "v1beta1", Kind: "Cluster"})
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+func newTestDiscoverer(t *testing.T, c ctrlclient.Client, cfg config.ClusterDiscovery) *Discoverer {
+	t.Helper()
+	d, err := NewDiscoverer(c, cfg, nil, zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err)
+	return d
+}
+
+func getClusterConfig(t *testing.T, c ctrlclient.Client, namespace, name string) (*telekomv1alpha1.ClusterConfig, error) {
+	t.Helper()
+	cc := &telekomv1alpha1.ClusterConfig{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, cc)
+	return cc, err
+}
+
+func TestDiscoverer_ClusterAPILifecycle(t *testing.T) {
+	alpha := capiCluster("fleet", "alpha", map[string]string{"tenant": "team-a", "env": "prod", "region": "eu-1"})
+	beta := capiCluster("fleet", "beta", map[string]string{"tenant": "team-b"})
+	c := fake.NewClientBuilder().WithScheme(discoveryScheme()).WithObjects(alpha, beta).Build()
+	d := newTestDiscoverer(t, c, config.ClusterDiscovery{
+		TargetNamespace: "breakglass",
+		LabelMapping:    config.ClusterDiscoveryLabelMapping{Tenant: "tenant", Environment: "env", Location: "region"},
+		Template: map[string]interface{}{
+			"site":             "default-site",
+			"environment":      "dev",
+			"accessReviewMode": "SubjectAccessReview",
+			"groupMapping":     map[interface{}]interface{}{"oidcPrefix": "oidc:"},
+		},
+	})
+
+	require.NoError(t, d.RunOnce(context.Background()))
+	cc, err := getClusterConfig(t, c, "breakglass", "alpha")
+	require.NoError(t, err)
+	assert.Equal(t, DiscoveryManagedByValue, cc.Labels[DiscoveryManagedByLabel])
+	assert.Equal(t, "Cluster/fleet/alpha", cc.Annotations[DiscoverySourceAnnotation])
+	assert.Equal(t, &telekomv1alpha1.SecretKeyReference{Name: "alpha-kubeconfig", Namespace: "fleet", Key: "value"}, cc.Spec.KubeconfigSecretRef)
+	assert.Equal(t, "team-a", cc.Spec.Tenant)
+	assert.Equal(t, "prod", cc.Spec.Environment)
+	assert.Equal(t, "eu-1", cc.Spec.Location)
+	assert.Equal(t, "default-site", cc.Spec.Site)
+	assert.Equal(t, telekomv1alpha1.AccessReviewModeSubjectAccessReview, cc.Spec.AccessReviewMode)
+	require.NotNil(t, cc.Spec.GroupMapping)
+	assert.Equal(t, "oidc:", cc.Spec.GroupMapping.OIDCPrefix)
+
+	cc, err = getClusterConfig(t, c, "breakglass", "beta")
+	require.NoError(t, err)
+	assert.Equal(t, "team-b", cc.Spec.Tenant)
+	assert.Equal(t, "dev", cc.Spec.Environment, "template value is kept when the label is missing")
+
+	// label changes are applied, removed clusters are garbage collected
+	alpha.SetLabels(map[string]string{"tenant": "team-c"})
+	require.NoError(t, c.Update(context.Background(), alpha))
+	require.NoError(t, c.Delete(context.Background(), beta))
+	require.NoError(t, d.RunOnce(context.Background()))
+
+	cc, err = getClusterConfig(t, c, "breakglass", "alpha")
+	require.NoError(t, err)
+	assert.Equal(t, "team-c", cc.Spec.Tenant)
+	_, err = getClusterConfig(t, c, "breakglass", "beta")
+	assert.True(t, apierrors.IsNotFound(err), "ClusterConfig of deleted cluster should be removed")
+}
+
+func TestDiscoverer_LeavesUnmanagedClusterConfigsAlone(t *testing.T) {
+	manual := &telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "alpha", Namespace: "fleet"},
+		Spec: telekomv1alpha1.ClusterConfigSpec{
+			Tenant:              "hand-written",
+			KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "other", Namespace: "fleet"},
+		},
+	}
+	other := &telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "fleet"},
+		Spec:       telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "other", Namespace: "fleet"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(discoveryScheme()).WithObjects(capiCluster("fleet", "alpha", nil), manual, other).Build()
+	d := newTestDiscoverer(t, c, config.ClusterDiscovery{})
+
+	require.NoError(t, d.RunOnce(context.Background()))
+	cc, err := getClusterConfig(t, c, "fleet", "alpha")
+	require.NoError(t, err)
+	assert.Equal(t, "hand-written", cc.Spec.Tenant)
+	_, err = getClusterConfig(t, c, "fleet", "other")
+	assert.NoError(t, err, "unmanaged ClusterConfigs must never be deleted")
+}
+
+func TestDiscoverer_SourceErrorKeepsClusterConfigs(t *testing.T) {
+	managed := &telekomv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "alpha", Namespace: "fleet", Labels: map[string]string{DiscoveryManagedByLabel: DiscoveryManagedByValue}},
+		Spec:       telekomv1alpha1.ClusterConfigSpec{KubeconfigSecretRef: &telekomv1alpha1.SecretKeyReference{Name: "alpha-kubeconfig", Namespace: "fleet"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(discoveryScheme()).WithObjects(managed).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, client ctrlclient.WithWatch, list ctrlclient.ObjectList, opts ...ctrlclient.ListOption) error {
+			if _, ok := list.(*unstructured.UnstructuredList); ok {
+				return errors.New("no matches for kind Cluster")
+			}
+			return client.List(ctx, list, opts...)
+		},
+	}).Build()
+	d := newTestDiscoverer(t, c, config.ClusterDiscovery{})
+
+	assert.Error(t, d.RunOnce(context.Background()))
+	_, err := getClusterConfig(t, c, "fleet", "alpha")
+	assert.NoError(t, err)
+}
+
+func TestDiscoverer_Secrets(t *testing.T) {
+	selected := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "gamma-kubeconfig", Namespace: "fleet",
+		Labels: map[string]string{"breakglass": "true", "site": "bonn"}}}
+	named := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "delta-admin", Namespace: "fleet",
+		Labels: map[string]string{"breakglass": "true", clusterAPIClusterNameLabel: "delta"}}}
+	ignored := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "fleet"}}
+	c := fake.NewClientBuilder().WithScheme(discoveryScheme()).WithObjects(selected, named, ignored).Build()
+	d := newTestDiscoverer(t, c, config.ClusterDiscovery{
+		Source:        config.ClusterDiscoverySourceSecret,
+		LabelSelector: "breakglass=true",
+		SecretKey:     "kubeconfig",
+		NamePrefix:    "fleet-",
+		LabelMapping:  config.ClusterDiscoveryLabelMapping{Site: "site"},
+	})
+
+	require.NoError(t, d.RunOnce(context.Background()))
+	cc, err := getClusterConfig(t, c, "fleet", "fleet-gamma")
+	require.NoError(t, err)
+	assert.Equal(t, &telekomv1alpha1.SecretKeyReference{Name: "gamma-kubeconfig", Namespace: "fleet", Key: "kubeconfig"}, cc.Spec.KubeconfigSecretRef)
+	assert.Equal(t, "bonn", cc.Spec.Site)
+	_, err = getClusterConfig(t, c, "fleet", "fleet-delta")
+	assert.NoError(t, err)
+
+	list := telekomv1alpha1.ClusterConfigList{}
+	require.NoError(t, c.List(context.Background(), &list))
+	assert.Len(t, list.Items, 2)
+}
+
+func TestNewDiscoverer_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ClusterDiscovery
+	}{
+		{name: "unknown source", cfg: config.ClusterDiscovery{Source: "Fleet"}},
+		{name: "secret source without selector", cfg: config.ClusterDiscovery{Source: config.ClusterDiscoverySourceSecret}},
+		{name: "invalid selector", cfg: config.ClusterDiscovery{LabelSelector: "a in (b"}},
+		{name: "invalid interval", cfg: config.ClusterDiscovery{Interval: "soon"}},
+		{name: "template with credentials", cfg: config.ClusterDiscovery{Template: map[string]interface{}{
+			"kubeconfigSecretRef": map[interface{}]interface{}{"name": "x", "namespace": "y"},
+		}}},
+		{name: "template with unknown field", cfg: config.ClusterDiscovery{Template: map[string]interface{}{"tenantt": "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDiscoverer(fake.NewClientBuilder().Build(), tt.cfg, nil, zaptest.NewLogger(t).Sugar())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDiscoverer_ReconcileRunsOnLeaderAndResyncs(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(discoveryScheme()).WithObjects(capiCluster("fleet", "alpha", nil)).Build()
+	leader := make(chan struct{})
+	d, err := NewDiscoverer(c, config.ClusterDiscovery{Interval: "5m"}, leader, zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err)
+
+	res, err := d.Reconcile(context.Background(), discoveryRequest)
+	require.NoError(t, err)
+	assert.Zero(t, res.RequeueAfter, "followers do not schedule resyncs")
+	_, err = getClusterConfig(t, c, "fleet", "alpha")
+	assert.True(t, apierrors.IsNotFound(err), "followers do not discover")
+
+	close(leader)
+	d.Start(context.Background())
+	select {
+	case <-d.trigger:
+	default:
+		t.Fatal("acquiring leadership triggers the first run")
+	}
+
+	res, err = d.Reconcile(context.Background(), discoveryRequest)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, res.RequeueAfter)
+	_, err = getClusterConfig(t, c, "fleet", "alpha")
+	assert.NoError(t, err)
+}
+
+func TestDiscoverer_SourcePredicate(t *testing.T) {
+	d := newTestDiscoverer(t, fake.NewClientBuilder().WithScheme(discoveryScheme()).Build(), config.ClusterDiscovery{
+		Namespaces:    []string{"fleet"},
+		LabelSelector: "breakglass=true",
+	})
+	p := d.sourcePredicate()
+	selected := capiCluster("fleet", "alpha", map[string]string{"breakglass": "true"})
+	other := capiCluster("other", "alpha", map[string]string{"breakglass": "true"})
+	unselected := capiCluster("fleet", "alpha", map[string]string{"breakglass": "false"})
+
+	assert.True(t, p.Create(event.CreateEvent{Object: selected}))
+	assert.False(t, p.Create(event.CreateEvent{Object: other}), "namespace not discovered")
+	assert.False(t, p.Create(event.CreateEvent{Object: unselected}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: selected}))
+
+	changed := selected.DeepCopy()
+	changed.SetLabels(map[string]string{"breakglass": "true", "tenant": "team-a"})
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: selected, ObjectNew: changed}), "label change")
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: selected, ObjectNew: unselected}), "object leaves the selector")
+	status := selected.DeepCopy()
+	status.SetGeneration(2)
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: selected, ObjectNew: status}), "changes other than labels do not matter")
+	deleting := selected.DeepCopy()
+	now := metav1.Now()
+	deleting.SetDeletionTimestamp(&now)
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: selected, ObjectNew: deleting}))
+
+	managed := managedClusterConfigPredicate()
+	cc := &telekomv1alpha1.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "alpha", Labels: map[string]string{DiscoveryManagedByLabel: DiscoveryManagedByValue}, Generation: 1}}
+	edited := cc.DeepCopy()
+	edited.Generation = 2
+	assert.True(t, managed.Update(event.UpdateEvent{ObjectOld: cc, ObjectNew: edited}))
+	assert.False(t, managed.Update(event.UpdateEvent{ObjectOld: cc, ObjectNew: cc.DeepCopy()}), "status updates are ignored")
+	assert.True(t, managed.Delete(event.DeleteEvent{Object: cc}))
+	assert.False(t, managed.Delete(event.DeleteEvent{Object: &telekomv1alpha1.ClusterConfig{}}), "unmanaged ClusterConfigs are ignored")
+}
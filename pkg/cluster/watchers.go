@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
 	"go.uber.org/zap"
@@ -11,9 +12,59 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// maxConcurrentWarmups bounds the clusters pre-warmed at once, so a cold start with many ClusterConfigs
+// does not fire all secret reads, token exchanges and connections at the same time
+const maxConcurrentWarmups = 4
+
+// clientWarmer pre-warms cluster clients in the background with bounded concurrency. A cluster queued
+// again before its warm-up started is warmed only once.
+type clientWarmer struct {
+	warm    func(ctx context.Context, name string) error
+	slots   chan struct{}
+	log     *zap.SugaredLogger
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+func newClientWarmer(warm func(ctx context.Context, name string) error, concurrency int, log *zap.SugaredLogger) *clientWarmer {
+	return &clientWarmer{warm: warm, slots: make(chan struct{}, concurrency), log: log, pending: map[string]struct{}{}}
+}
+
+func (w *clientWarmer) enqueue(ctx context.Context, name string) {
+	w.mu.Lock()
+	if _, ok := w.pending[name]; ok {
+		w.mu.Unlock()
+		return
+	}
+	w.pending[name] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case w.slots <- struct{}{}:
+		case <-ctx.Done():
+			w.started(name)
+			return
+		}
+		defer func() { <-w.slots }()
+		// a change arriving from now on warms the cluster again with the new credentials
+		w.started(name)
+		if err := w.warm(ctx, name); err != nil && w.log != nil {
+			w.log.Debugw("Failed to pre-warm cluster client", "cluster", name, "error", err)
+		}
+	}()
+}
+
+func (w *clientWarmer) started(name string) {
+	w.mu.Lock()
+	delete(w.pending, name)
+	w.mu.Unlock()
+}
+
 // RegisterInvalidationHandlers wires controller-runtime cache event handlers to keep the ClientProvider caches fresh.
 // It watches ClusterConfig and Secret events to invalidate cached ClusterConfigs and REST configs whenever
-// a relevant object changes or is deleted.
+// a relevant object changes or is deleted. Pooled clients are dropped together with the REST configs and
+// pre-warmed again, at most maxConcurrentWarmups at a time, when a ClusterConfig is added or its spec changes.
 func RegisterInvalidationHandlers(ctx context.Context, mgr ctrl.Manager, provider *ClientProvider, log *zap.SugaredLogger) error {
 	if provider == nil {
 		return fmt.Errorf("client provider is nil")
@@ -30,7 +81,14 @@ func RegisterInvalidationHandlers(ctx context.Context, mgr ctrl.Manager, provide
 	if err != nil {
 		return fmt.Errorf("get ClusterConfig informer: %w", err)
 	}
+	warmer := newClientWarmer(provider.Warm, maxConcurrentWarmups, log)
+	warm := func(name string) { warmer.enqueue(ctx, name) }
 	if _, err := ccInformer.AddEventHandler(clientcache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cfg := extractClusterConfig(obj); cfg != nil {
+				warm(cfg.Name)
+			}
+		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			oldCfg := extractClusterConfig(oldObj)
 			newCfg := extractClusterConfig(newObj)
@@ -40,10 +98,16 @@ func RegisterInvalidationHandlers(ctx context.Context, mgr ctrl.Manager, provide
 			if oldCfg != nil && oldCfg.ResourceVersion == newCfg.ResourceVersion {
 				return
 			}
+			if oldCfg != nil && oldCfg.Generation == newCfg.Generation {
+				// status and metadata updates leave the credentials alone
+				provider.Refresh(newCfg.Name)
+				return
+			}
 			provider.Invalidate(newCfg.Name)
 			if log != nil {
 				log.Debugw("ClusterConfig cache invalidated", "cluster", newCfg.Name)
 			}
+			warm(newCfg.Name)
 		},
 		DeleteFunc: func(obj interface{}) {
 			cfg := extractClusterConfig(obj)
@@ -1,7 +1,12 @@
 package cluster
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,3 +37,52 @@ func TestExtractSecretHandlesDeletedFinalState(t *testing.T) {
 func TestExtractSecretReturnsNilForUnknownTypes(t *testing.T) {
 	assert.Nil(t, extractSecret(v1.Now()))
 }
+
+func TestClientWarmerBoundsConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	var running, maxRunning int32
+	var mu sync.Mutex
+	warmed := map[string]int{}
+	w := newClientWarmer(func(ctx context.Context, name string) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		mu.Lock()
+		warmed[name]++
+		mu.Unlock()
+		return nil
+	}, 3, nil)
+	total := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, c := range warmed {
+			n += c
+		}
+		return n
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		w.enqueue(ctx, fmt.Sprintf("cluster-%d", i))
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 3 }, time.Second, time.Millisecond)
+	for i := 3; i < 20; i++ {
+		w.enqueue(ctx, fmt.Sprintf("cluster-%d", i))
+	}
+	// queued again while all slots are busy
+	w.enqueue(ctx, "cluster-19")
+
+	close(release)
+	assert.Eventually(t, func() bool { return total() == 20 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, warmed["cluster-19"])
+}
@@ -3,8 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
+	k8syaml "sigs.k8s.io/yaml"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
 )
 
 // IdentityProviderConfig represents the runtime identity provider configuration
@@ -27,7 +31,8 @@ type IdentityProviderConfig struct {
 	// ClientID for OIDC and Keycloak
 	ClientID string
 
-	// ClientSecret for Keycloak (loaded from secret reference)
+	// ClientSecret is the OIDC client secret (loaded from spec.oidc.clientSecretRef), used by the
+	// server-side login with confidential clients
 	ClientSecret string
 
 	// CertificateAuthority contains a PEM encoded CA certificate for TLS validation
@@ -40,6 +45,30 @@ type IdentityProviderConfig struct {
 	// Other provider-specific fields (BaseURL for Keycloak, etc.)
 	Keycloak *KeycloakRuntimeConfig
 
+	// LDAP holds the LDAP group sync configuration when GroupSyncProvider is LDAP
+	LDAP *LDAPRuntimeConfig
+
+	// SCIM holds the SCIM provisioning configuration when GroupSyncProvider is SCIM
+	SCIM *SCIMRuntimeConfig
+
+	// MicrosoftGraph holds the Graph configuration when GroupSyncProvider is MicrosoftGraph
+	MicrosoftGraph *MicrosoftGraphRuntimeConfig
+
+	// HTTPJSON holds the HTTP JSON configuration when GroupSyncProvider is HTTPJSON
+	HTTPJSON *HTTPJSONRuntimeConfig
+
+	// ClaimMappings selects the username, email and groups claims of tokens issued by this IDP
+	// (nil means the defaults)
+	ClaimMappings *breakglassv1alpha1.ClaimMappings
+
+	// TokenValidation holds audience and required claim rules for tokens issued by this IDP
+	// (nil means the defaults: aud or azp must name ClientID)
+	TokenValidation *breakglassv1alpha1.TokenValidation
+
+	// TokenRevocation holds introspection and back-channel logout settings together with the
+	// logout events still within the retention (nil means tokens are trusted until they expire)
+	TokenRevocation *TokenRevocationRuntimeConfig
+
 	// Raw provider config for extensibility
 	RawConfig interface{}
 }
@@ -55,6 +84,88 @@ type KeycloakRuntimeConfig struct {
 	RequestTimeout       string
 	InsecureSkipVerify   bool
 	CertificateAuthority string
+	RequestsPerSecond    int
+	Burst                int
+}
+
+// LDAPRuntimeConfig is LDAP-specific runtime configuration
+type LDAPRuntimeConfig struct {
+	URL                      string
+	StartTLS                 bool
+	BindDN                   string
+	BindPassword             string
+	UserSearchBase           string
+	UserFilter               string
+	UserIdentifierAttributes []string
+	MemberOfAttribute        string
+	GroupSearchBase          string
+	GroupFilter              string
+	GroupNameAttribute       string
+	NestedGroups             string
+	MaxNestingDepth          int
+	PageSize                 int
+	CacheTTL                 string
+	RequestTimeout           string
+	InsecureSkipVerify       bool
+	CertificateAuthority     string
+}
+
+// SCIMRuntimeConfig is SCIM-specific runtime configuration
+type SCIMRuntimeConfig struct {
+	// BearerToken authenticates the identity provider on the SCIM endpoints
+	BearerToken string
+}
+
+// MicrosoftGraphRuntimeConfig is Microsoft Graph-specific runtime configuration
+type MicrosoftGraphRuntimeConfig struct {
+	TenantID                 string
+	ClientID                 string
+	ClientSecret             string
+	AuthorityURL             string
+	GraphURL                 string
+	UserIdentifierAttributes []string
+	CacheTTL                 string
+	RequestTimeout           string
+	InsecureSkipVerify       bool
+	CertificateAuthority     string
+}
+
+// DefaultLogoutRetention is how long back-channel logout events are remembered by default
+const DefaultLogoutRetention = time.Hour
+
+// TokenRevocationRuntimeConfig is runtime configuration of token revocation checks
+type TokenRevocationRuntimeConfig struct {
+	// Introspection is set when tokens are checked at the introspection endpoint
+	Introspection     *TokenIntrospectionRuntimeConfig
+	BackChannelLogout bool
+	LogoutRetention   time.Duration
+	TerminateSessions bool
+	// LogoutRevocations are the logout events recorded in the IdentityProvider status
+	LogoutRevocations []breakglassv1alpha1.LogoutRevocation
+}
+
+// TokenIntrospectionRuntimeConfig is RFC 7662 introspection runtime configuration
+type TokenIntrospectionRuntimeConfig struct {
+	Endpoint       string
+	ClientID       string
+	ClientSecret   string
+	CacheTTL       string
+	RequestTimeout string
+	FailOpen       bool
+}
+
+// HTTPJSONRuntimeConfig is runtime configuration of the generic HTTP JSON group sync provider
+type HTTPJSONRuntimeConfig struct {
+	MembersURL           string
+	MembersPath          string
+	NextPagePath         string
+	MaxPages             int
+	Token                string
+	TokenHeader          string
+	CacheTTL             string
+	RequestTimeout       string
+	InsecureSkipVerify   bool
+	CertificateAuthority string
 }
 
 type Frontend struct {
@@ -83,12 +194,178 @@ type Kubernetes struct {
 	// ClusterConfigCheckInterval controls how often ClusterConfig resources are validated (e.g. "10m").
 	// +optional
 	ClusterConfigCheckInterval string `yaml:"clusterConfigCheckInterval"`
+	// WorkloadIdentityTokenFile is the projected ServiceAccount token exchanged by ClusterConfigs
+	// using auth.workloadIdentity. Defaults to /var/run/secrets/breakglass/workload-identity/token.
+	// +optional
+	WorkloadIdentityTokenFile string `yaml:"workloadIdentityTokenFile"`
+	// WorkloadIdentityTokenURLs lists the token endpoints ClusterConfigs may send the projected
+	// ServiceAccount token to. Workload identity is refused for other tokenURLs, and entirely while empty.
+	// +optional
+	WorkloadIdentityTokenURLs []string `yaml:"workloadIdentityTokenURLs"`
+	// CertificateExpiryWarning is how long before expiry the certificates of cluster credentials are
+	// reported on the ClusterConfig (e.g. "720h"). Defaults to 30 days.
+	// +optional
+	CertificateExpiryWarning string `yaml:"certificateExpiryWarning"`
+	// SpokeClients tunes the connections the hub keeps open to managed clusters
+	// +optional
+	SpokeClients SpokeClients `yaml:"spokeClients"`
+}
+
+// SpokeClients tunes the shared pool of clients for managed clusters. Durations use Go syntax (e.g. "90s").
+type SpokeClients struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept per cluster. Defaults to 10.
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost"`
+	// IdleConnTimeout closes connections idle this long. Defaults to 90s.
+	IdleConnTimeout string `yaml:"idleConnTimeout"`
+	// KeepAlive is the TCP keep-alive period. Defaults to 30s.
+	KeepAlive string `yaml:"keepAlive"`
+	// HealthCheckInterval pings HTTP/2 connections that were silent this long. Defaults to 30s.
+	HealthCheckInterval string `yaml:"healthCheckInterval"`
+	// IdleClientTTL drops clients of clusters not contacted this long. Defaults to 1h.
+	IdleClientTTL string `yaml:"idleClientTTL"`
+}
+
+// Mail holds global email notification settings
+type Mail struct {
+	// TemplatesConfigMap optionally references a ConfigMap with email template overrides.
+	// It is used when the default MailProvider does not reference its own overrides.
+	TemplatesConfigMap ConfigMapRef `yaml:"templatesConfigMap"`
+	// Locale selects the locale variant of the template overrides (e.g. "de").
+	Locale string `yaml:"locale"`
+}
+
+// Notification delivery modes for approver preferences
+const (
+	NotificationModeImmediate = "immediate"
+	NotificationModeDigest    = "digest"
+)
+
+// Notifications holds approver notification settings
+type Notifications struct {
+	// DigestInterval is the default interval between notification digests (e.g. "15m").
+	// Escalations and user preferences may override it. Defaults to 15m.
+	DigestInterval string `yaml:"digestInterval"`
+	// Preferences holds per-approver delivery preferences. They take precedence over
+	// the notificationDigest setting of escalations.
+	Preferences []NotificationPreference `yaml:"preferences"`
+}
+
+// NotificationPreference is the notification delivery preference of a single approver
+type NotificationPreference struct {
+	// User is the approver email (matched case-insensitively)
+	User string `yaml:"user"`
+	// Mode is either "immediate" or "digest"
+	Mode string `yaml:"mode"`
+	// DigestInterval optionally overrides the digest interval for this approver
+	DigestInterval string `yaml:"digestInterval"`
+}
+
+// ApprovalLinks configures signed one-click approve/reject links in request emails
+type ApprovalLinks struct {
+	// Enabled adds approve/reject links to request emails
+	Enabled bool `yaml:"enabled"`
+	// SigningKeyFile is a file holding the HMAC key used to sign the links (at least 32 bytes)
+	SigningKeyFile string `yaml:"signingKeyFile"`
+	// TTL is how long a link stays valid (e.g. "30m"). Defaults to 30m.
+	TTL string `yaml:"ttl"`
+}
+
+// BFF configures the server-side OIDC login (backend-for-frontend). The server runs the
+// authorization code flow with PKCE and keeps the tokens in encrypted HttpOnly cookies, so
+// browser JavaScript never sees them.
+type BFF struct {
+	// Enabled adds the /api/auth login endpoints and accepts cookie sessions on the API
+	Enabled bool `yaml:"enabled"`
+	// CookieKeyFile is a file holding the key encrypting the session cookies (at least 32 bytes).
+	// All replicas must use the same key.
+	CookieKeyFile string `yaml:"cookieKeyFile"`
+	// SessionTTL is the maximum lifetime of a login session (e.g. "12h"). Defaults to 12h.
+	SessionTTL string `yaml:"sessionTTL"`
+	// Scopes requested at login. Defaults to openid, profile and email.
+	Scopes []string `yaml:"scopes"`
+	// InsecureCookies omits the Secure cookie attribute (local development over plain HTTP only)
+	InsecureCookies bool `yaml:"insecureCookies"`
+}
+
+// Cluster discovery sources
+const (
+	ClusterDiscoverySourceClusterAPI = "ClusterAPI"
+	ClusterDiscoverySourceSecret     = "Secret"
+)
+
+// ClusterDiscovery configures automatic management of ClusterConfigs for a fleet of clusters,
+// discovered from Cluster API Cluster objects or from kubeconfig Secrets.
+type ClusterDiscovery struct {
+	// Enabled starts the discovery loop on the leader replica
+	Enabled bool `yaml:"enabled"`
+	// Source is either "ClusterAPI" (default) or "Secret"
+	Source string `yaml:"source"`
+	// Interval between discovery runs (e.g. "1m"). Defaults to 1m.
+	Interval string `yaml:"interval"`
+	// Namespaces limits discovery to these namespaces. Empty means all namespaces.
+	Namespaces []string `yaml:"namespaces"`
+	// LabelSelector selects the Cluster objects or Secrets to manage ClusterConfigs for.
+	// Required for the Secret source.
+	LabelSelector string `yaml:"labelSelector"`
+	// ClusterAPIVersion is the API version of Cluster API Cluster objects. Defaults to v1beta1.
+	ClusterAPIVersion string `yaml:"clusterAPIVersion"`
+	// SecretKey is the kubeconfig key of discovered Secrets. Defaults to "value" (Cluster API).
+	SecretKey string `yaml:"secretKey"`
+	// TargetNamespace receives the ClusterConfigs. Defaults to the namespace of the source object.
+	TargetNamespace string `yaml:"targetNamespace"`
+	// NamePrefix is prepended to the names of discovered ClusterConfigs
+	NamePrefix string `yaml:"namePrefix"`
+	// LabelMapping names the labels of the source object copied into ClusterConfig fields
+	LabelMapping ClusterDiscoveryLabelMapping `yaml:"labelMapping"`
+	// Template is the ClusterConfig spec (in ClusterConfig field names) applied to every discovered
+	// cluster. kubeconfigSecretRef and auth are set by discovery and must not be part of it.
+	Template map[string]interface{} `yaml:"template"`
+}
+
+// ClusterDiscoveryLabelMapping holds the label keys mapped to ClusterConfig fields
+type ClusterDiscoveryLabelMapping struct {
+	Tenant      string `yaml:"tenant"`
+	Environment string `yaml:"environment"`
+	Site        string `yaml:"site"`
+	Location    string `yaml:"location"`
+}
+
+// TemplateSpec decodes the template into a ClusterConfig spec
+func (d ClusterDiscovery) TemplateSpec() (breakglassv1alpha1.ClusterConfigSpec, error) {
+	var spec breakglassv1alpha1.ClusterConfigSpec
+	if len(d.Template) == 0 {
+		return spec, nil
+	}
+	raw, err := yaml.Marshal(d.Template)
+	if err != nil {
+		return spec, fmt.Errorf("encode clusterDiscovery.template: %w", err)
+	}
+	// sigs.k8s.io/yaml honours the json field names of the API types
+	if err := k8syaml.UnmarshalStrict(raw, &spec); err != nil {
+		return spec, fmt.Errorf("invalid clusterDiscovery.template: %w", err)
+	}
+	if spec.KubeconfigSecretRef != nil || spec.Auth != nil {
+		return spec, fmt.Errorf("invalid clusterDiscovery.template: kubeconfigSecretRef and auth are set by discovery")
+	}
+	return spec, nil
+}
+
+// ConfigMapRef is a namespaced ConfigMap reference in the config file
+type ConfigMapRef struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
 }
 
 type Config struct {
-	Server     Server
-	Frontend   Frontend
-	Kubernetes Kubernetes
+	Server        Server
+	Frontend      Frontend
+	Kubernetes    Kubernetes
+	Mail          Mail
+	Notifications Notifications
+	ApprovalLinks ApprovalLinks `yaml:"approvalLinks"`
+	BFF           BFF           `yaml:"bff"`
+	// ClusterDiscovery manages ClusterConfigs for discovered clusters
+	ClusterDiscovery ClusterDiscovery `yaml:"clusterDiscovery"`
 }
 
 // Load loads the breakglass configuration from a file path.
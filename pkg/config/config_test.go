@@ -160,3 +160,44 @@ func TestLoadDefaultPath(t *testing.T) {
 		t.Errorf("Load() with default path expected error but got none")
 	}
 }
+
+func TestClusterDiscoveryTemplateSpec(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-config-*.yaml")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer func() { _ = os.Remove(tempFile.Name()) }()
+	content := `
+clusterDiscovery:
+  enabled: true
+  labelSelector: breakglass=true
+  template:
+    environment: prod
+    blockSelfApproval: true
+    qps: 50
+    groupMapping:
+      oidcPrefix: "oidc:"
+      rules:
+        - idpGroup: admins
+          clusterGroup: cluster-admins
+`
+	if _, err := tempFile.WriteString(content); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	_ = tempFile.Close()
+
+	cfg, err := config.Load(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	spec, err := cfg.ClusterDiscovery.TemplateSpec()
+	if err != nil {
+		t.Fatalf("TemplateSpec() error = %v", err)
+	}
+	if spec.Environment != "prod" || !spec.BlockSelfApproval || spec.QPS == nil || *spec.QPS != 50 {
+		t.Fatalf("unexpected template spec: %+v", spec)
+	}
+	if spec.GroupMapping == nil || len(spec.GroupMapping.Rules) != 1 || spec.GroupMapping.Rules[0].ClusterGroup != "cluster-admins" {
+		t.Fatalf("unexpected group mapping: %+v", spec.GroupMapping)
+	}
+}
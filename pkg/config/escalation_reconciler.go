@@ -3,19 +3,23 @@ package config
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -91,6 +95,7 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 
 	// Validate escalation configuration and references
 	now := metav1.Now()
+	previous := escalation.Status.DeepCopy()
 
 	// Track validation errors to report all at once
 	var validationErrors []struct {
@@ -115,6 +120,21 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 		})
 	}
 
+	// Resolve the cluster selector into status so the spec.allowed.cluster index covers the matched clusters
+	if selectorErr := r.resolveClusterSelector(ctx, escalation); selectorErr != nil {
+		validationErrors = append(validationErrors, struct {
+			conditionType string
+			reason        string
+			message       string
+			error         error
+		}{
+			conditionType: string(breakglassv1alpha1.BreakglassEscalationConditionClusterRefsValid),
+			reason:        "ClusterSelectorResolutionFailed",
+			message:       selectorErr.Error(),
+			error:         selectorErr,
+		})
+	}
+
 	// Validate cluster reference
 	if clusterErr := r.validateClusterRef(ctx, escalation); clusterErr != nil {
 		validationErrors = append(validationErrors, struct {
@@ -189,7 +209,8 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 			}
 			apimeta.SetStatusCondition(&escalation.Status.Conditions, condition)
 
-			if r.recorder != nil {
+			// Repeated reconciles of an unchanged failure do not emit further events
+			if r.recorder != nil && conditionChanged(previous.Conditions, condition) {
 				r.recorder.Event(escalation, "Warning", ve.reason, ve.message)
 			}
 			r.logger.Warnw("Escalation validation failed",
@@ -198,7 +219,7 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 				"error", ve.error)
 		}
 
-		if err := r.client.Status().Update(ctx, escalation); err != nil {
+		if err := r.updateStatusIfChanged(ctx, escalation, previous); err != nil {
 			r.logger.Warnw("Failed to update escalation status with validation errors",
 				"escalation", escalation.Name,
 				"error", err)
@@ -207,6 +228,7 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 	}
 
 	// All validations passed - set all conditions to true
+	succeeded := false
 	for _, condType := range []breakglassv1alpha1.BreakglassEscalationConditionType{
 		breakglassv1alpha1.BreakglassEscalationConditionConfigValidated,
 		breakglassv1alpha1.BreakglassEscalationConditionClusterRefsValid,
@@ -222,14 +244,16 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 			Message:            "Validation passed",
 			LastTransitionTime: now,
 		}
+		succeeded = succeeded || !apimeta.IsStatusConditionTrue(previous.Conditions, condition.Type)
 		apimeta.SetStatusCondition(&escalation.Status.Conditions, condition)
 	}
 
-	if r.recorder != nil {
+	// Only the transition to a valid escalation is reported, not every successful reconcile
+	if r.recorder != nil && succeeded {
 		r.recorder.Event(escalation, "Normal", "ValidationSucceeded", "All escalation validations passed successfully")
 	}
 
-	if err := r.client.Status().Update(ctx, escalation); err != nil {
+	if err := r.updateStatusIfChanged(ctx, escalation, previous); err != nil {
 		r.logger.Warnw("Failed to update escalation status with success state",
 			"escalation", escalation.Name,
 			"error", err)
@@ -264,6 +288,21 @@ func (r *EscalationReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 	return reconcile.Result{}, nil
 }
 
+// updateStatusIfChanged writes the status only if the reconcile changed it
+func (r *EscalationReconciler) updateStatusIfChanged(ctx context.Context, esc *breakglassv1alpha1.BreakglassEscalation, previous *breakglassv1alpha1.BreakglassEscalationStatus) error {
+	if apiequality.Semantic.DeepEqual(previous, &esc.Status) {
+		return nil
+	}
+	return r.client.Status().Update(ctx, esc)
+}
+
+// conditionChanged reports whether setting the condition changes its status, reason or message
+func conditionChanged(conditions []metav1.Condition, condition metav1.Condition) bool {
+	existing := apimeta.FindStatusCondition(conditions, condition.Type)
+	return existing == nil || existing.Status != condition.Status ||
+		existing.Reason != condition.Reason || existing.Message != condition.Message
+}
+
 // updateEscalationIDPMapping fetches all BreakglassEscalation CRs and builds the escalation→IDP mapping cache.
 func (r *EscalationReconciler) updateEscalationIDPMapping(ctx context.Context) error {
 	escalations := &breakglassv1alpha1.BreakglassEscalationList{}
@@ -333,21 +372,98 @@ func (r *EscalationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			newEsc := e.ObjectNew.(*breakglassv1alpha1.BreakglassEscalation)
 			// Only trigger reconcile if spec changed
 			return !slicesEqual(oldEsc.Spec.AllowedIdentityProviders, newEsc.Spec.AllowedIdentityProviders) ||
+				!apiequality.Semantic.DeepEqual(oldEsc.Spec.ClusterSelector, newEsc.Spec.ClusterSelector) ||
 				oldEsc.DeletionTimestamp != newEsc.DeletionTimestamp
 		},
 		CreateFunc: func(e event.CreateEvent) bool { return true },
 		DeleteFunc: func(e event.DeleteEvent) bool { return true },
 	}
 
+	// ClusterConfig changes only matter for escalations with a clusterSelector, and only when the
+	// selected fields change (the ClusterConfig checker updates status every interval)
+	clusterConfigPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCC := e.ObjectOld.(*breakglassv1alpha1.ClusterConfig)
+			newCC := e.ObjectNew.(*breakglassv1alpha1.ClusterConfig)
+			return !apiequality.Semantic.DeepEqual(oldCC.Labels, newCC.Labels) ||
+				oldCC.Spec.Tenant != newCC.Spec.Tenant ||
+				oldCC.Spec.Environment != newCC.Spec.Environment ||
+				oldCC.Spec.Site != newCC.Spec.Site
+		},
+		CreateFunc: func(e event.CreateEvent) bool { return true },
+		DeleteFunc: func(e event.DeleteEvent) bool { return true },
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&breakglassv1alpha1.BreakglassEscalation{}).
+		For(&breakglassv1alpha1.BreakglassEscalation{}, builder.WithPredicates(specChangePredicate)).
+		Watches(&breakglassv1alpha1.ClusterConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.escalationsWithClusterSelector),
+			builder.WithPredicates(clusterConfigPredicate)).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 1, // Process one escalation at a time
 		}).
-		WithEventFilter(specChangePredicate).
 		Complete(r)
 }
 
+// resolveClusterSelector stores the names of the ClusterConfigs matched by spec.clusterSelector in
+// status.resolvedClusters. On error the previously resolved clusters are kept.
+func (r *EscalationReconciler) resolveClusterSelector(ctx context.Context, esc *breakglassv1alpha1.BreakglassEscalation) error {
+	if esc.Spec.ClusterSelector == nil {
+		esc.Status.ResolvedClusters = nil
+		return nil
+	}
+	clusters := &breakglassv1alpha1.ClusterConfigList{}
+	if err := r.client.List(ctx, clusters); err != nil {
+		return fmt.Errorf("failed to list ClusterConfigs for clusterSelector: %w", err)
+	}
+	var resolved []string
+	for i := range clusters.Items {
+		matches, err := esc.Spec.ClusterSelector.Matches(&clusters.Items[i])
+		if err != nil {
+			return fmt.Errorf("invalid clusterSelector: %w", err)
+		}
+		if matches {
+			resolved = append(resolved, clusters.Items[i].Name)
+		}
+	}
+	slices.Sort(resolved)
+	resolved = slices.Compact(resolved)
+	if !slices.Equal(resolved, esc.Status.ResolvedClusters) {
+		r.logger.Infow("Escalation clusterSelector resolved",
+			"escalation", esc.Name,
+			"clusters", resolved)
+	}
+	esc.Status.ResolvedClusters = resolved
+	return nil
+}
+
+// escalationsWithClusterSelector maps a ClusterConfig event to the escalations whose clusterSelector
+// selects it. Updates are mapped for the old and the new ClusterConfig, so escalations it stops
+// matching are requeued as well.
+func (r *EscalationReconciler) escalationsWithClusterSelector(ctx context.Context, obj client.Object) []reconcile.Request {
+	cc, ok := obj.(*breakglassv1alpha1.ClusterConfig)
+	if !ok {
+		return nil
+	}
+	escalations := &breakglassv1alpha1.BreakglassEscalationList{}
+	if err := r.client.List(ctx, escalations); err != nil {
+		r.logger.Warnw("Failed to list BreakglassEscalations for ClusterConfig change", "error", err)
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range escalations.Items {
+		selector := escalations.Items[i].Spec.ClusterSelector
+		if selector == nil {
+			continue
+		}
+		// invalid selectors are requeued so the reconcile reports them
+		if matches, err := selector.Matches(cc); matches || err != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&escalations.Items[i])})
+		}
+	}
+	return requests
+}
+
 // validateEscalationConfig validates the escalation's configuration
 func (r *EscalationReconciler) validateEscalationConfig(esc *breakglassv1alpha1.BreakglassEscalation) error {
 	// IdentityProviders are optional - escalation will use cluster defaults if not specified
@@ -6,9 +6,13 @@ import (
 
 	"github.com/stretchr/testify/require"
 	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestValidateMailProviderRef(t *testing.T) {
@@ -61,3 +65,85 @@ func TestValidateMailProviderRef(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveClusterSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, breakglassv1alpha1.AddToScheme(scheme))
+
+	prod := &breakglassv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-b", Namespace: "tenant-a", Labels: map[string]string{"tier": "prod"}},
+		Spec:       breakglassv1alpha1.ClusterConfigSpec{Environment: "prod"},
+	}
+	prod2 := &breakglassv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-a", Namespace: "tenant-b", Labels: map[string]string{"tier": "prod"}},
+		Spec:       breakglassv1alpha1.ClusterConfigSpec{Environment: "prod"},
+	}
+	dev := &breakglassv1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-a", Namespace: "tenant-a", Labels: map[string]string{"tier": "dev"}},
+		Spec:       breakglassv1alpha1.ClusterConfigSpec{Environment: "dev"},
+	}
+	escalation := &breakglassv1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-admin", Namespace: "tenant-a"},
+		Spec: breakglassv1alpha1.BreakglassEscalationSpec{
+			ClusterSelector: &breakglassv1alpha1.ClusterSelector{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}},
+			},
+		},
+	}
+	plain := &breakglassv1alpha1.BreakglassEscalation{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "tenant-a"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(prod, prod2, dev, escalation, plain).Build()
+	reconciler := &EscalationReconciler{client: fakeClient, logger: zap.NewNop().Sugar()}
+
+	require.NoError(t, reconciler.resolveClusterSelector(context.Background(), escalation))
+	require.Equal(t, []string{"prod-a", "prod-b"}, escalation.Status.ResolvedClusters)
+
+	escalation.Spec.ClusterSelector = nil
+	require.NoError(t, reconciler.resolveClusterSelector(context.Background(), escalation))
+	require.Nil(t, escalation.Status.ResolvedClusters)
+
+	// ClusterConfig changes only requeue escalations whose selector matches the ClusterConfig
+	require.Empty(t, reconciler.escalationsWithClusterSelector(context.Background(), dev))
+	requests := reconciler.escalationsWithClusterSelector(context.Background(), prod)
+	require.Len(t, requests, 1)
+	require.Equal(t, "prod-admin", requests[0].Name)
+}
+
+func TestEscalationReconcilerRecordsEventsOnlyOnChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, breakglassv1alpha1.AddToScheme(scheme))
+
+	escalation := &breakglassv1alpha1.BreakglassEscalation{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "tenant-a"},
+		Spec:       breakglassv1alpha1.BreakglassEscalationSpec{EscalatedGroup: "admins"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(escalation).
+		WithStatusSubresource(&breakglassv1alpha1.BreakglassEscalation{}).Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := NewEscalationReconciler(fakeClient, zap.NewNop().Sugar(), recorder, nil, nil, 0)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(escalation)}
+
+	for range 3 {
+		_, err := reconciler.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+	}
+	require.Len(t, recorder.Events, 1, "only the first successful validation is reported")
+	require.Contains(t, <-recorder.Events, "ValidationSucceeded")
+
+	current := &breakglassv1alpha1.BreakglassEscalation{}
+	require.NoError(t, fakeClient.Get(context.Background(), req.NamespacedName, current))
+	resourceVersion := current.ResourceVersion
+	_, err := reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(context.Background(), req.NamespacedName, current))
+	require.Equal(t, resourceVersion, current.ResourceVersion, "an unchanged status is not written")
+
+	// a new failure is reported once
+	current.Spec.MailProvider = "missing"
+	require.NoError(t, fakeClient.Update(context.Background(), current))
+	for range 2 {
+		_, err := reconciler.Reconcile(context.Background(), req)
+		require.Error(t, err)
+	}
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "MailProviderValidationFailed")
+}
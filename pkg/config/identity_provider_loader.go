@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -162,6 +163,8 @@ func (l *IdentityProviderLoader) convertToRuntimeConfig(ctx context.Context, idp
 		ClientID:             idp.Spec.OIDC.ClientID,
 		CertificateAuthority: idp.Spec.OIDC.CertificateAuthority,
 		InsecureSkipVerify:   idp.Spec.OIDC.InsecureSkipVerify,
+		ClaimMappings:        idp.Spec.ClaimMappings.DeepCopy(),
+		TokenValidation:      idp.Spec.TokenValidation.DeepCopy(),
 	}
 
 	l.logger.Debugw("OIDC config loaded",
@@ -169,6 +172,23 @@ func (l *IdentityProviderLoader) convertToRuntimeConfig(ctx context.Context, idp
 		"clientID", idp.Spec.OIDC.ClientID,
 		"issuer", idp.Spec.Issuer)
 
+	if idp.Spec.OIDC.ClientSecretRef != nil {
+		secret, err := l.getSecretValue(ctx, idp.Spec.OIDC.ClientSecretRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load OIDC client secret", "error", err)
+			return nil, fmt.Errorf("failed to load OIDC client secret: %w", err)
+		}
+		runtimeConfig.ClientSecret = secret
+	}
+
+	if idp.Spec.TokenRevocation != nil {
+		revocation, err := l.convertTokenRevocation(ctx, idp)
+		if err != nil {
+			return nil, err
+		}
+		runtimeConfig.TokenRevocation = revocation
+	}
+
 	// Setup group sync if configured
 	if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderKeycloak && idp.Spec.Keycloak != nil {
 		l.logger.Debugw("Setting up Keycloak group sync",
@@ -184,6 +204,8 @@ func (l *IdentityProviderLoader) convertToRuntimeConfig(ctx context.Context, idp
 			RequestTimeout:       idp.Spec.Keycloak.RequestTimeout,
 			InsecureSkipVerify:   idp.Spec.Keycloak.InsecureSkipVerify,
 			CertificateAuthority: idp.Spec.Keycloak.CertificateAuthority,
+			RequestsPerSecond:    int(idp.Spec.Keycloak.RequestsPerSecond),
+			Burst:                int(idp.Spec.Keycloak.Burst),
 		}
 
 		// Load client secret from secret reference
@@ -204,6 +226,97 @@ func (l *IdentityProviderLoader) convertToRuntimeConfig(ctx context.Context, idp
 		}
 
 		runtimeConfig.Keycloak = keycloakConfig
+	} else if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderLDAP && idp.Spec.LDAP != nil {
+		spec := idp.Spec.LDAP
+		l.logger.Debugw("Setting up LDAP group sync",
+			"url", spec.URL,
+			"bindDN", spec.BindDN,
+			"nestedGroups", spec.NestedGroups)
+
+		password, err := l.getSecretValue(ctx, &spec.BindPasswordRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load LDAP bind password", "error", err)
+			return nil, fmt.Errorf("failed to load LDAP bind password: %w", err)
+		}
+
+		runtimeConfig.LDAP = &LDAPRuntimeConfig{
+			URL:                      spec.URL,
+			StartTLS:                 spec.StartTLS,
+			BindDN:                   spec.BindDN,
+			BindPassword:             password,
+			UserSearchBase:           spec.UserSearchBase,
+			UserFilter:               spec.UserFilter,
+			UserIdentifierAttributes: append([]string(nil), spec.UserIdentifierAttributes...),
+			MemberOfAttribute:        spec.MemberOfAttribute,
+			GroupSearchBase:          spec.GroupSearchBase,
+			GroupFilter:              spec.GroupFilter,
+			GroupNameAttribute:       spec.GroupNameAttribute,
+			NestedGroups:             string(spec.NestedGroups),
+			MaxNestingDepth:          int(spec.MaxNestingDepth),
+			PageSize:                 int(spec.PageSize),
+			CacheTTL:                 spec.CacheTTL,
+			RequestTimeout:           spec.RequestTimeout,
+			InsecureSkipVerify:       spec.InsecureSkipVerify,
+			CertificateAuthority:     spec.CertificateAuthority,
+		}
+	} else if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderSCIM && idp.Spec.SCIM != nil {
+		l.logger.Debugw("Setting up SCIM group sync",
+			"secretName", idp.Spec.SCIM.BearerTokenRef.Name,
+			"secretNamespace", idp.Spec.SCIM.BearerTokenRef.Namespace)
+
+		token, err := l.getSecretValue(ctx, &idp.Spec.SCIM.BearerTokenRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load SCIM bearer token", "error", err)
+			return nil, fmt.Errorf("failed to load SCIM bearer token: %w", err)
+		}
+		runtimeConfig.SCIM = &SCIMRuntimeConfig{BearerToken: token}
+	} else if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderMicrosoftGraph && idp.Spec.MicrosoftGraph != nil {
+		spec := idp.Spec.MicrosoftGraph
+		l.logger.Debugw("Setting up Microsoft Graph group sync",
+			"tenantID", spec.TenantID,
+			"clientID", spec.ClientID)
+
+		secret, err := l.getSecretValue(ctx, &spec.ClientSecretRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load Microsoft Graph client secret", "error", err)
+			return nil, fmt.Errorf("failed to load Microsoft Graph client secret: %w", err)
+		}
+		runtimeConfig.MicrosoftGraph = &MicrosoftGraphRuntimeConfig{
+			TenantID:                 spec.TenantID,
+			ClientID:                 spec.ClientID,
+			ClientSecret:             secret,
+			AuthorityURL:             spec.AuthorityURL,
+			GraphURL:                 spec.GraphURL,
+			UserIdentifierAttributes: append([]string(nil), spec.UserIdentifierAttributes...),
+			CacheTTL:                 spec.CacheTTL,
+			RequestTimeout:           spec.RequestTimeout,
+			InsecureSkipVerify:       spec.InsecureSkipVerify,
+			CertificateAuthority:     spec.CertificateAuthority,
+		}
+	} else if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderHTTPJSON && idp.Spec.HTTPJSON != nil {
+		spec := idp.Spec.HTTPJSON
+		l.logger.Debugw("Setting up HTTP JSON group sync", "membersURL", spec.MembersURL)
+
+		var token string
+		if spec.TokenRef != nil {
+			var err error
+			if token, err = l.getSecretValue(ctx, spec.TokenRef); err != nil {
+				l.logger.Errorw("Failed to load HTTP JSON token", "error", err)
+				return nil, fmt.Errorf("failed to load HTTP JSON token: %w", err)
+			}
+		}
+		runtimeConfig.HTTPJSON = &HTTPJSONRuntimeConfig{
+			MembersURL:           spec.MembersURL,
+			MembersPath:          spec.MembersPath,
+			NextPagePath:         spec.NextPagePath,
+			MaxPages:             int(spec.MaxPages),
+			Token:                token,
+			TokenHeader:          spec.TokenHeader,
+			CacheTTL:             spec.CacheTTL,
+			RequestTimeout:       spec.RequestTimeout,
+			InsecureSkipVerify:   spec.InsecureSkipVerify,
+			CertificateAuthority: spec.CertificateAuthority,
+		}
 	} else if idp.Spec.GroupSyncProvider != "" {
 		l.logger.Warnw("Unknown group sync provider configured", "provider", idp.Spec.GroupSyncProvider)
 	} else {
@@ -213,6 +326,46 @@ func (l *IdentityProviderLoader) convertToRuntimeConfig(ctx context.Context, idp
 	return runtimeConfig, nil
 }
 
+// convertTokenRevocation loads the introspection client secret and the logout events that are
+// still within the retention
+func (l *IdentityProviderLoader) convertTokenRevocation(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider) (*TokenRevocationRuntimeConfig, error) {
+	spec := idp.Spec.TokenRevocation
+	revocation := &TokenRevocationRuntimeConfig{
+		BackChannelLogout: spec.BackChannelLogout,
+		LogoutRetention:   DefaultLogoutRetention,
+		TerminateSessions: spec.TerminateSessions,
+	}
+	if d, err := time.ParseDuration(spec.LogoutRetention); err == nil && d > 0 {
+		revocation.LogoutRetention = d
+	}
+	if spec.BackChannelLogout {
+		cutoff := time.Now().Add(-revocation.LogoutRetention)
+		for _, r := range idp.Status.LogoutRevocations {
+			if r.RevokedAt.Time.After(cutoff) {
+				revocation.LogoutRevocations = append(revocation.LogoutRevocations, *r.DeepCopy())
+			}
+		}
+	}
+
+	if spec.Introspection != nil {
+		l.logger.Debugw("Setting up token introspection", "endpoint", spec.Introspection.Endpoint)
+		secret, err := l.getSecretValue(ctx, &spec.Introspection.ClientSecretRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load token introspection client secret", "error", err)
+			return nil, fmt.Errorf("failed to load token introspection client secret: %w", err)
+		}
+		revocation.Introspection = &TokenIntrospectionRuntimeConfig{
+			Endpoint:       spec.Introspection.Endpoint,
+			ClientID:       spec.Introspection.ClientID,
+			ClientSecret:   secret,
+			CacheTTL:       spec.Introspection.CacheTTL,
+			RequestTimeout: spec.Introspection.RequestTimeout,
+			FailOpen:       spec.Introspection.FailOpen,
+		}
+	}
+	return revocation, nil
+}
+
 // getSecretValue retrieves a specific key from a Secret
 func (l *IdentityProviderLoader) getSecretValue(ctx context.Context, secretRef *breakglassv1alpha1.SecretKeyReference) (string, error) {
 	if secretRef == nil || secretRef.Name == "" {
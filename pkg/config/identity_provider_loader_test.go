@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -64,10 +65,12 @@ func TestIdentityProviderLoader_LoadIdentityProvider(t *testing.T) {
 						},
 						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderKeycloak,
 						Keycloak: &breakglassv1alpha1.KeycloakGroupSync{
-							BaseURL:  "https://keycloak.example.com",
-							Realm:    "master",
-							ClientID: "keycloak-admin",
-							CacheTTL: "10m",
+							BaseURL:           "https://keycloak.example.com",
+							Realm:             "master",
+							ClientID:          "keycloak-admin",
+							CacheTTL:          "10m",
+							RequestsPerSecond: 5,
+							Burst:             15,
 							ClientSecretRef: breakglassv1alpha1.SecretKeyReference{
 								Name:      "keycloak-secret",
 								Namespace: "default",
@@ -94,9 +97,416 @@ func TestIdentityProviderLoader_LoadIdentityProvider(t *testing.T) {
 					cfg.Authority == "https://auth.example.com" &&
 					cfg.Keycloak != nil &&
 					cfg.Keycloak.BaseURL == "https://keycloak.example.com" &&
-					cfg.Keycloak.ClientSecret == "super-secret"
+					cfg.Keycloak.ClientSecret == "super-secret" &&
+					cfg.Keycloak.RequestsPerSecond == 5 &&
+					cfg.Keycloak.Burst == 15
+			},
+		},
+		{
+			name: "OIDC with LDAP group sync",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-ldap",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://dex.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderLDAP,
+						LDAP: &breakglassv1alpha1.LDAPGroupSync{
+							URL:             "ldap://ad.example.com",
+							StartTLS:        true,
+							BindDN:          "cn=svc-breakglass,dc=example,dc=com",
+							UserSearchBase:  "ou=people,dc=example,dc=com",
+							GroupSearchBase: "ou=groups,dc=example,dc=com",
+							NestedGroups:    breakglassv1alpha1.LDAPNestedGroupsInChain,
+							PageSize:        200,
+							BindPasswordRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "ldap-bind",
+								Namespace: "default",
+								Key:       "password",
+							},
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ldap-bind",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"password": []byte("bind-secret"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				return cfg.Keycloak == nil &&
+					cfg.LDAP != nil &&
+					cfg.LDAP.URL == "ldap://ad.example.com" &&
+					cfg.LDAP.StartTLS &&
+					cfg.LDAP.BindPassword == "bind-secret" &&
+					cfg.LDAP.NestedGroups == "InChain" &&
+					cfg.LDAP.PageSize == 200
+			},
+		},
+		{
+			name: "LDAP group sync with missing bind password secret",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "broken-ldap",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://dex.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderLDAP,
+						LDAP: &breakglassv1alpha1.LDAPGroupSync{
+							URL:             "ldaps://ad.example.com",
+							BindDN:          "cn=svc-breakglass,dc=example,dc=com",
+							UserSearchBase:  "ou=people,dc=example,dc=com",
+							GroupSearchBase: "ou=groups,dc=example,dc=com",
+							BindPasswordRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "missing-secret",
+								Namespace: "default",
+							},
+						},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "OIDC with SCIM group sync",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-scim",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderSCIM,
+						SCIM: &breakglassv1alpha1.SCIMGroupSync{
+							BearerTokenRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "scim-token",
+								Namespace: "default",
+								Key:       "token",
+							},
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "scim-token",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"token": []byte("scim-secret"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				return cfg.Keycloak == nil &&
+					cfg.LDAP == nil &&
+					cfg.SCIM != nil &&
+					cfg.SCIM.BearerToken == "scim-secret"
+			},
+		},
+		{
+			name: "SCIM group sync with missing bearer token secret",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "broken-scim",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderSCIM,
+						SCIM: &breakglassv1alpha1.SCIMGroupSync{
+							BearerTokenRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "missing-secret",
+								Namespace: "default",
+							},
+						},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "OIDC with Microsoft Graph group sync",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-entra",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderMicrosoftGraph,
+						MicrosoftGraph: &breakglassv1alpha1.MicrosoftGraphGroupSync{
+							TenantID: "tenant",
+							ClientID: "graph-client",
+							ClientSecretRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "graph-secret",
+								Namespace: "default",
+							},
+							UserIdentifierAttributes: []string{"userPrincipalName"},
+							CacheTTL:                 "5m",
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "graph-secret",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"value": []byte("graph-secret-value"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				return cfg.Keycloak == nil &&
+					cfg.MicrosoftGraph != nil &&
+					cfg.MicrosoftGraph.TenantID == "tenant" &&
+					cfg.MicrosoftGraph.ClientID == "graph-client" &&
+					cfg.MicrosoftGraph.ClientSecret == "graph-secret-value" &&
+					len(cfg.MicrosoftGraph.UserIdentifierAttributes) == 1 &&
+					cfg.MicrosoftGraph.CacheTTL == "5m"
+			},
+		},
+		{
+			name: "Microsoft Graph group sync with missing client secret",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "broken-entra",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderMicrosoftGraph,
+						MicrosoftGraph: &breakglassv1alpha1.MicrosoftGraphGroupSync{
+							TenantID: "tenant",
+							ClientID: "graph-client",
+							ClientSecretRef: breakglassv1alpha1.SecretKeyReference{
+								Name:      "missing-secret",
+								Namespace: "default",
+							},
+						},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "OIDC with HTTP JSON group sync",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-httpjson",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						GroupSyncProvider: breakglassv1alpha1.GroupSyncProviderHTTPJSON,
+						HTTPJSON: &breakglassv1alpha1.HTTPJSONGroupSync{
+							MembersURL:   "https://directory.example.com/groups/{group}/members",
+							MembersPath:  "$.members[*].email",
+							NextPagePath: "$.next",
+							MaxPages:     10,
+							TokenRef: &breakglassv1alpha1.SecretKeyReference{
+								Name:      "directory-token",
+								Namespace: "default",
+								Key:       "token",
+							},
+							TokenHeader: "X-API-Key",
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "directory-token",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"token": []byte("api-key"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				return cfg.HTTPJSON != nil &&
+					cfg.HTTPJSON.MembersURL == "https://directory.example.com/groups/{group}/members" &&
+					cfg.HTTPJSON.MembersPath == "$.members[*].email" &&
+					cfg.HTTPJSON.NextPagePath == "$.next" &&
+					cfg.HTTPJSON.MaxPages == 10 &&
+					cfg.HTTPJSON.Token == "api-key" &&
+					cfg.HTTPJSON.TokenHeader == "X-API-Key"
+			},
+		},
+		{
+			name: "OIDC with token revocation",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-revocation",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						TokenRevocation: &breakglassv1alpha1.TokenRevocation{
+							Introspection: &breakglassv1alpha1.TokenIntrospection{
+								Endpoint: "https://login.example.com/introspect",
+								ClientID: "breakglass",
+								ClientSecretRef: breakglassv1alpha1.SecretKeyReference{
+									Name:      "introspection",
+									Namespace: "default",
+									Key:       "secret",
+								},
+								CacheTTL: "10s",
+								FailOpen: true,
+							},
+							BackChannelLogout: true,
+							LogoutRetention:   "30m",
+							TerminateSessions: true,
+						},
+					},
+					Status: breakglassv1alpha1.IdentityProviderStatus{
+						LogoutRevocations: []breakglassv1alpha1.LogoutRevocation{
+							{Subject: "stale", RevokedAt: metav1.NewTime(time.Now().Add(-time.Hour))},
+							{Subject: "recent", RevokedAt: metav1.NewTime(time.Now().Add(-time.Minute))},
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "introspection",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"secret": []byte("introspection-secret"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				r := cfg.TokenRevocation
+				return r != nil &&
+					r.Introspection != nil &&
+					r.Introspection.ClientSecret == "introspection-secret" &&
+					r.Introspection.CacheTTL == "10s" &&
+					r.Introspection.FailOpen &&
+					r.BackChannelLogout &&
+					r.TerminateSessions &&
+					r.LogoutRetention == 30*time.Minute &&
+					len(r.LogoutRevocations) == 1 &&
+					r.LogoutRevocations[0].Subject == "recent"
+			},
+		},
+		{
+			name: "OIDC confidential client",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "oidc-confidential",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "breakglass",
+							ClientSecretRef: &breakglassv1alpha1.SecretKeyReference{
+								Name:      "oidc-client",
+								Namespace: "default",
+							},
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "oidc-client",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"value": []byte("client-secret"),
+					},
+				},
+			},
+			wantError: false,
+			check: func(cfg *IdentityProviderConfig) bool {
+				return cfg.ClientSecret == "client-secret"
 			},
 		},
+		{
+			name: "token introspection with missing client secret",
+			idps: []breakglassv1alpha1.IdentityProvider{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "broken-introspection",
+					},
+					Spec: breakglassv1alpha1.IdentityProviderSpec{
+						Primary: true,
+						OIDC: breakglassv1alpha1.OIDCConfig{
+							Authority: "https://login.example.com",
+							ClientID:  "test-client",
+						},
+						TokenRevocation: &breakglassv1alpha1.TokenRevocation{
+							Introspection: &breakglassv1alpha1.TokenIntrospection{
+								Endpoint: "https://login.example.com/introspect",
+								ClientID: "breakglass",
+								ClientSecretRef: breakglassv1alpha1.SecretKeyReference{
+									Name:      "missing-secret",
+									Namespace: "default",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantError: true,
+		},
 		{
 			name: "disabled provider skipped",
 			idps: []breakglassv1alpha1.IdentityProvider{
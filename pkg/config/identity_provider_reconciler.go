@@ -19,6 +19,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/httpjson"
+	"github.com/telekom/k8s-breakglass/pkg/ldap"
+	"github.com/telekom/k8s-breakglass/pkg/msgraph"
 )
 
 // IdentityProviderReconciler implements controller-runtime's Reconciler interface
@@ -311,6 +314,23 @@ func (r *IdentityProviderReconciler) updateGroupSyncHealth(ctx context.Context,
 		return
 	}
 
+	if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderLDAP {
+		r.updateLDAPGroupSyncHealth(ctx, idp, oldCondition)
+		return
+	}
+	if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderSCIM {
+		r.updateSCIMGroupSyncHealth(ctx, idp, oldCondition)
+		return
+	}
+	if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderMicrosoftGraph {
+		r.updateMicrosoftGraphGroupSyncHealth(ctx, idp, oldCondition)
+		return
+	}
+	if idp.Spec.GroupSyncProvider == breakglassv1alpha1.GroupSyncProviderHTTPJSON {
+		r.updateHTTPJSONGroupSyncHealth(ctx, idp, oldCondition)
+		return
+	}
+
 	if idp.Spec.GroupSyncProvider != breakglassv1alpha1.GroupSyncProviderKeycloak {
 		// Unknown provider
 		idp.SetCondition(metav1.Condition{
@@ -473,6 +493,214 @@ func (r *IdentityProviderReconciler) updateGroupSyncHealth(ctx context.Context,
 	r.logger.Debugw("group sync provider health check passed", "name", idp.Name, "provider", idp.Spec.GroupSyncProvider)
 }
 
+// updateLDAPGroupSyncHealth checks the LDAP configuration, reads the bind password and verifies
+// that the directory is reachable and accepts the bind credentials
+func (r *IdentityProviderReconciler) updateLDAPGroupSyncHealth(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition) {
+	spec := idp.Spec.LDAP
+	if spec == nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "LDAPMissing", "GroupSyncLDAPMissing",
+			"LDAP configuration is required when groupSyncProvider is LDAP")
+		return
+	}
+	if spec.URL == "" || spec.BindDN == "" || spec.UserSearchBase == "" || spec.GroupSearchBase == "" {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "LDAPIncomplete", "GroupSyncLDAPConfigIncomplete",
+			"LDAP configuration incomplete: missing url, bindDN, userSearchBase, or groupSearchBase")
+		return
+	}
+
+	secretRef := spec.BindPasswordRef
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "SecretNotFound", "GroupSyncSecretNotFound",
+			fmt.Sprintf("Failed to read LDAP bind password secret '%s' in namespace '%s': %v", secretRef.Name, secretRef.Namespace, err))
+		return
+	}
+	secretDataKey := secretRef.Key
+	if secretDataKey == "" {
+		secretDataKey = "value"
+	}
+	password, exists := secret.Data[secretDataKey]
+	if !exists {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "SecretKeyNotFound", "GroupSyncSecretKeyMissing",
+			fmt.Sprintf("LDAP bind password key '%s' not found in secret '%s'", secretDataKey, secretRef.Name))
+		return
+	}
+
+	timeout := 10 * time.Second
+	if d, err := time.ParseDuration(spec.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := ldap.Connect(probeCtx, ldap.ConnectOptions{
+		URL:                  spec.URL,
+		StartTLS:             spec.StartTLS,
+		BindDN:               spec.BindDN,
+		Password:             string(password),
+		CertificateAuthority: spec.CertificateAuthority,
+		InsecureSkipVerify:   spec.InsecureSkipVerify,
+	})
+	if err != nil {
+		if ldap.IsBindError(err) {
+			r.setGroupSyncUnhealthy(idp, oldCondition, "LDAPBindFailed", "GroupSyncLDAPBindFailed",
+				fmt.Sprintf("LDAP bind as '%s' failed: %v", spec.BindDN, err))
+		} else {
+			r.setGroupSyncUnhealthy(idp, oldCondition, "LDAPConnectionFailed", "GroupSyncLDAPConnectionFailed",
+				fmt.Sprintf("Failed to connect to LDAP server '%s': %v", spec.URL, err))
+		}
+		return
+	}
+	_ = conn.Close()
+	r.setGroupSyncHealthy(idp, oldCondition)
+}
+
+// updateSCIMGroupSyncHealth checks that the bearer token the identity provider pushes with is
+// readable. SCIM is inbound, so there is no remote endpoint to probe.
+func (r *IdentityProviderReconciler) updateSCIMGroupSyncHealth(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition) {
+	if idp.Spec.SCIM == nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "SCIMMissing", "GroupSyncSCIMMissing",
+			"SCIM configuration is required when groupSyncProvider is SCIM")
+		return
+	}
+	if _, ok := r.readGroupSyncSecret(ctx, idp, oldCondition, idp.Spec.SCIM.BearerTokenRef, "SCIM bearer token"); !ok {
+		return
+	}
+	r.setGroupSyncHealthy(idp, oldCondition)
+}
+
+// updateMicrosoftGraphGroupSyncHealth reads the client secret and verifies that an access token
+// can be acquired with the client credentials grant
+func (r *IdentityProviderReconciler) updateMicrosoftGraphGroupSyncHealth(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition) {
+	spec := idp.Spec.MicrosoftGraph
+	if spec == nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "MicrosoftGraphMissing", "GroupSyncMicrosoftGraphMissing",
+			"Microsoft Graph configuration is required when groupSyncProvider is MicrosoftGraph")
+		return
+	}
+	secret, ok := r.readGroupSyncSecret(ctx, idp, oldCondition, spec.ClientSecretRef, "Microsoft Graph client secret")
+	if !ok {
+		return
+	}
+	client, err := msgraph.NewClient(msgraph.Options{
+		TenantID:             spec.TenantID,
+		ClientID:             spec.ClientID,
+		ClientSecret:         secret,
+		AuthorityURL:         spec.AuthorityURL,
+		GraphURL:             spec.GraphURL,
+		CertificateAuthority: spec.CertificateAuthority,
+		InsecureSkipVerify:   spec.InsecureSkipVerify,
+	})
+	if err != nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "MicrosoftGraphInvalid", "GroupSyncMicrosoftGraphInvalid",
+			fmt.Sprintf("Invalid Microsoft Graph configuration: %v", err))
+		return
+	}
+
+	timeout := 10 * time.Second
+	if d, err := time.ParseDuration(spec.RequestTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := client.Token(probeCtx); err != nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "MicrosoftGraphTokenFailed", "GroupSyncMicrosoftGraphTokenFailed",
+			fmt.Sprintf("Failed to acquire a Microsoft Graph token for client '%s': %v", spec.ClientID, err))
+		return
+	}
+	r.setGroupSyncHealthy(idp, oldCondition)
+}
+
+// updateHTTPJSONGroupSyncHealth checks the URL template and JSONPath expressions and reads the
+// token. No request is sent because there is no group known to exist.
+func (r *IdentityProviderReconciler) updateHTTPJSONGroupSyncHealth(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition) {
+	spec := idp.Spec.HTTPJSON
+	if spec == nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "HTTPJSONMissing", "GroupSyncHTTPJSONMissing",
+			"HTTP JSON configuration is required when groupSyncProvider is HTTPJSON")
+		return
+	}
+	if _, err := httpjson.NewClient(httpjson.Options{
+		MembersURL:           spec.MembersURL,
+		MembersPath:          spec.MembersPath,
+		NextPagePath:         spec.NextPagePath,
+		CertificateAuthority: spec.CertificateAuthority,
+	}); err != nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "HTTPJSONInvalid", "GroupSyncHTTPJSONInvalid",
+			fmt.Sprintf("Invalid HTTP JSON configuration: %v", err))
+		return
+	}
+	if spec.TokenRef != nil {
+		if _, ok := r.readGroupSyncSecret(ctx, idp, oldCondition, *spec.TokenRef, "HTTP JSON token"); !ok {
+			return
+		}
+	}
+	r.setGroupSyncHealthy(idp, oldCondition)
+}
+
+// readGroupSyncSecret returns the value of a secret key used by a group sync provider. When the
+// secret or key is missing the condition is set to unhealthy and false is returned.
+func (r *IdentityProviderReconciler) readGroupSyncSecret(ctx context.Context, idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition, secretRef breakglassv1alpha1.SecretKeyReference, what string) (string, bool) {
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "SecretNotFound", "GroupSyncSecretNotFound",
+			fmt.Sprintf("Failed to read %s secret '%s' in namespace '%s': %v", what, secretRef.Name, secretRef.Namespace, err))
+		return "", false
+	}
+	secretDataKey := secretRef.Key
+	if secretDataKey == "" {
+		secretDataKey = "value"
+	}
+	if len(secret.Data[secretDataKey]) == 0 {
+		r.setGroupSyncUnhealthy(idp, oldCondition, "SecretKeyNotFound", "GroupSyncSecretKeyMissing",
+			fmt.Sprintf("%s key '%s' not found or empty in secret '%s'", what, secretDataKey, secretRef.Name))
+		return "", false
+	}
+	return string(secret.Data[secretDataKey]), true
+}
+
+// setGroupSyncHealthy sets the GroupSyncHealthy condition to true and emits an event when the
+// provider recovers
+func (r *IdentityProviderReconciler) setGroupSyncHealthy(idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition) {
+	idp.SetCondition(metav1.Condition{
+		Type:               string(breakglassv1alpha1.IdentityProviderConditionGroupSyncHealthy),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: idp.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "GroupSyncOperational",
+		Message:            "Group sync provider is operational",
+	})
+	if oldCondition != nil && oldCondition.Status == metav1.ConditionFalse {
+		if r.recorder != nil {
+			eventIdp := idp.DeepCopy()
+			eventIdp.SetNamespace("")
+			r.recorder.Event(eventIdp, "Normal", "GroupSyncHealthy",
+				"Group sync provider is now healthy and reachable")
+		}
+		r.logger.Infow("group sync provider recovered to healthy state",
+			"name", idp.Name, "provider", idp.Spec.GroupSyncProvider)
+	}
+	r.logger.Debugw("group sync provider health check passed", "name", idp.Name, "provider", idp.Spec.GroupSyncProvider)
+}
+
+// setGroupSyncUnhealthy sets the GroupSyncHealthy condition to false and emits a warning event
+// when the provider was healthy (or unchecked) before
+func (r *IdentityProviderReconciler) setGroupSyncUnhealthy(idp *breakglassv1alpha1.IdentityProvider, oldCondition *metav1.Condition, reason, eventReason, message string) {
+	idp.SetCondition(metav1.Condition{
+		Type:               string(breakglassv1alpha1.IdentityProviderConditionGroupSyncHealthy),
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: idp.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	if r.recorder != nil && (oldCondition == nil || oldCondition.Status == metav1.ConditionTrue) {
+		eventIdp := idp.DeepCopy()
+		eventIdp.SetNamespace("")
+		r.recorder.Event(eventIdp, "Warning", eventReason, message)
+	}
+	r.logger.Debugw("group sync provider health check failed", "name", idp.Name, "provider", idp.Spec.GroupSyncProvider, "reason", reason)
+}
+
 // SetupWithManager sets up the controller with the manager (required for controller-runtime)
 // This is called during manager initialization and registers the reconciler
 func (r *IdentityProviderReconciler) SetupWithManager(mgr ctrl.Manager) error {
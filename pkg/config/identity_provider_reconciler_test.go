@@ -3,18 +3,23 @@ package config
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/ldap/ldaptest"
 	ctrltest "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -263,3 +268,227 @@ func TestIdentityProviderReconciler_NilLogger(t *testing.T) {
 	require.NotNil(t, reconciler)
 	assert.NotNil(t, reconciler.logger)
 }
+
+// TestIdentityProviderReconciler_LDAPGroupSyncHealth verifies the LDAP health check binds against the directory
+func TestIdentityProviderReconciler_LDAPGroupSyncHealth(t *testing.T) {
+	srv := ldaptest.NewServer("cn=svc,dc=example,dc=com", "bind-secret")
+	defer srv.Close()
+
+	newIDP := func(url, secretName string) *v1alpha1.IdentityProvider {
+		return &v1alpha1.IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "dex-ad", Generation: 1},
+			Spec: v1alpha1.IdentityProviderSpec{
+				OIDC:              v1alpha1.OIDCConfig{Authority: "https://dex.example.com", ClientID: "breakglass"},
+				GroupSyncProvider: v1alpha1.GroupSyncProviderLDAP,
+				LDAP: &v1alpha1.LDAPGroupSync{
+					URL:             url,
+					BindDN:          "cn=svc,dc=example,dc=com",
+					BindPasswordRef: v1alpha1.SecretKeyReference{Name: secretName, Namespace: "breakglass", Key: "password"},
+					UserSearchBase:  "ou=people,dc=example,dc=com",
+					GroupSearchBase: "ou=groups,dc=example,dc=com",
+					RequestTimeout:  "2s",
+				},
+			},
+		}
+	}
+	secret := func(name, password string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "breakglass"},
+			Data:       map[string][]byte{"password": []byte(password)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		idp        *v1alpha1.IdentityProvider
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{name: "healthy", idp: newIDP(srv.URL, "good"), wantStatus: metav1.ConditionTrue, wantReason: "GroupSyncOperational"},
+		{name: "wrong password", idp: newIDP(srv.URL, "bad"), wantStatus: metav1.ConditionFalse, wantReason: "LDAPBindFailed"},
+		{name: "missing secret", idp: newIDP(srv.URL, "missing"), wantStatus: metav1.ConditionFalse, wantReason: "SecretNotFound"},
+		{name: "unreachable", idp: newIDP("ldap://127.0.0.1:1", "good"), wantStatus: metav1.ConditionFalse, wantReason: "LDAPConnectionFailed"},
+		{name: "missing config", idp: func() *v1alpha1.IdentityProvider {
+			idp := newIDP(srv.URL, "good")
+			idp.Spec.LDAP = nil
+			return idp
+		}(), wantStatus: metav1.ConditionFalse, wantReason: "LDAPMissing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrltest.NewClientBuilder().WithScheme(Scheme).
+				WithObjects(secret("good", "bind-secret"), secret("bad", "wrong")).Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := NewIdentityProviderReconciler(cli, zap.NewNop().Sugar(), nil).WithEventRecorder(recorder)
+
+			reconciler.updateGroupSyncHealth(context.Background(), tt.idp)
+
+			cond := tt.idp.GetCondition(string(v1alpha1.IdentityProviderConditionGroupSyncHealthy))
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status, cond.Message)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+			if tt.wantStatus == metav1.ConditionFalse {
+				assert.Len(t, recorder.Events, 1, "warning event on first failure")
+			}
+		})
+	}
+}
+
+func TestIdentityProviderReconciler_SCIMGroupSyncHealth(t *testing.T) {
+	newIDP := func(secretName, key string) *v1alpha1.IdentityProvider {
+		return &v1alpha1.IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "entra", Generation: 1},
+			Spec: v1alpha1.IdentityProviderSpec{
+				OIDC:              v1alpha1.OIDCConfig{Authority: "https://login.example.com", ClientID: "breakglass"},
+				GroupSyncProvider: v1alpha1.GroupSyncProviderSCIM,
+				SCIM: &v1alpha1.SCIMGroupSync{
+					BearerTokenRef: v1alpha1.SecretKeyReference{Name: secretName, Namespace: "breakglass", Key: key},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		idp        *v1alpha1.IdentityProvider
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{name: "healthy", idp: newIDP("scim", "token"), wantStatus: metav1.ConditionTrue, wantReason: "GroupSyncOperational"},
+		{name: "missing secret", idp: newIDP("missing", "token"), wantStatus: metav1.ConditionFalse, wantReason: "SecretNotFound"},
+		{name: "missing key", idp: newIDP("scim", "other"), wantStatus: metav1.ConditionFalse, wantReason: "SecretKeyNotFound"},
+		{name: "missing config", idp: func() *v1alpha1.IdentityProvider {
+			idp := newIDP("scim", "token")
+			idp.Spec.SCIM = nil
+			return idp
+		}(), wantStatus: metav1.ConditionFalse, wantReason: "SCIMMissing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrltest.NewClientBuilder().WithScheme(Scheme).WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "scim", Namespace: "breakglass"},
+				Data:       map[string][]byte{"token": []byte("scim-secret")},
+			}).Build()
+			reconciler := NewIdentityProviderReconciler(cli, zap.NewNop().Sugar(), nil)
+
+			reconciler.updateGroupSyncHealth(context.Background(), tt.idp)
+
+			cond := tt.idp.GetCondition(string(v1alpha1.IdentityProviderConditionGroupSyncHealthy))
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status, cond.Message)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
+
+func TestIdentityProviderReconciler_MicrosoftGraphGroupSyncHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("client_secret") != "graph-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad secret"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	newIDP := func(key string) *v1alpha1.IdentityProvider {
+		return &v1alpha1.IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "entra", Generation: 1},
+			Spec: v1alpha1.IdentityProviderSpec{
+				OIDC:              v1alpha1.OIDCConfig{Authority: "https://login.example.com", ClientID: "breakglass"},
+				GroupSyncProvider: v1alpha1.GroupSyncProviderMicrosoftGraph,
+				MicrosoftGraph: &v1alpha1.MicrosoftGraphGroupSync{
+					TenantID:        "tenant",
+					ClientID:        "graph-client",
+					ClientSecretRef: v1alpha1.SecretKeyReference{Name: "graph", Namespace: "breakglass", Key: key},
+					AuthorityURL:    srv.URL,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		idp        *v1alpha1.IdentityProvider
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{name: "healthy", idp: newIDP("secret"), wantStatus: metav1.ConditionTrue, wantReason: "GroupSyncOperational"},
+		{name: "wrong secret", idp: newIDP("wrong"), wantStatus: metav1.ConditionFalse, wantReason: "MicrosoftGraphTokenFailed"},
+		{name: "missing key", idp: newIDP("other"), wantStatus: metav1.ConditionFalse, wantReason: "SecretKeyNotFound"},
+		{name: "missing config", idp: func() *v1alpha1.IdentityProvider {
+			idp := newIDP("secret")
+			idp.Spec.MicrosoftGraph = nil
+			return idp
+		}(), wantStatus: metav1.ConditionFalse, wantReason: "MicrosoftGraphMissing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrltest.NewClientBuilder().WithScheme(Scheme).WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "graph", Namespace: "breakglass"},
+				Data:       map[string][]byte{"secret": []byte("graph-secret"), "wrong": []byte("nope")},
+			}).Build()
+			reconciler := NewIdentityProviderReconciler(cli, zap.NewNop().Sugar(), nil)
+
+			reconciler.updateGroupSyncHealth(context.Background(), tt.idp)
+
+			cond := tt.idp.GetCondition(string(v1alpha1.IdentityProviderConditionGroupSyncHealthy))
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status, cond.Message)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
+
+func TestIdentityProviderReconciler_HTTPJSONGroupSyncHealth(t *testing.T) {
+	newIDP := func(membersPath string, tokenRef *v1alpha1.SecretKeyReference) *v1alpha1.IdentityProvider {
+		return &v1alpha1.IdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "directory", Generation: 1},
+			Spec: v1alpha1.IdentityProviderSpec{
+				OIDC:              v1alpha1.OIDCConfig{Authority: "https://login.example.com", ClientID: "breakglass"},
+				GroupSyncProvider: v1alpha1.GroupSyncProviderHTTPJSON,
+				HTTPJSON: &v1alpha1.HTTPJSONGroupSync{
+					MembersURL:  "https://directory.example.com/groups/{group}",
+					MembersPath: membersPath,
+					TokenRef:    tokenRef,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		idp        *v1alpha1.IdentityProvider
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{name: "healthy without token", idp: newIDP("$.members[*]", nil), wantStatus: metav1.ConditionTrue, wantReason: "GroupSyncOperational"},
+		{name: "healthy with token", idp: newIDP("$.members[*]", &v1alpha1.SecretKeyReference{Name: "directory", Namespace: "breakglass"}),
+			wantStatus: metav1.ConditionTrue, wantReason: "GroupSyncOperational"},
+		{name: "missing secret", idp: newIDP("$.members[*]", &v1alpha1.SecretKeyReference{Name: "missing", Namespace: "breakglass"}),
+			wantStatus: metav1.ConditionFalse, wantReason: "SecretNotFound"},
+		{name: "invalid path", idp: newIDP("$.members[", nil), wantStatus: metav1.ConditionFalse, wantReason: "HTTPJSONInvalid"},
+		{name: "missing config", idp: func() *v1alpha1.IdentityProvider {
+			idp := newIDP("$.members[*]", nil)
+			idp.Spec.HTTPJSON = nil
+			return idp
+		}(), wantStatus: metav1.ConditionFalse, wantReason: "HTTPJSONMissing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrltest.NewClientBuilder().WithScheme(Scheme).WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "directory", Namespace: "breakglass"},
+				Data:       map[string][]byte{"value": []byte("api-key")},
+			}).Build()
+			reconciler := NewIdentityProviderReconciler(cli, zap.NewNop().Sugar(), nil)
+
+			reconciler.updateGroupSyncHealth(context.Background(), tt.idp)
+
+			cond := tt.idp.GetCondition(string(v1alpha1.IdentityProviderConditionGroupSyncHealthy))
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status, cond.Message)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
@@ -35,6 +35,15 @@ type MailProviderConfig struct {
 	Password             string
 	InsecureSkipVerify   bool
 	CertificateAuthority string
+	TLSMode              breakglassv1alpha1.SMTPTLSMode
+	AuthMechanism        breakglassv1alpha1.SMTPAuthMechanism
+	ClientCertificate    *tls.Certificate
+
+	// OAuth2 client-credentials configuration for XOAUTH2
+	OAuth2TokenURL     string
+	OAuth2ClientID     string
+	OAuth2ClientSecret string
+	OAuth2Scopes       []string
 
 	// Sender configuration
 	SenderAddress string
@@ -44,6 +53,11 @@ type MailProviderConfig struct {
 	RetryCount     int
 	RetryBackoffMs int
 	QueueSize      int
+
+	// Template override configuration
+	TemplatesConfigMapName      string
+	TemplatesConfigMapNamespace string
+	TemplatesLocale             string
 }
 
 // MailProviderLoader loads and caches MailProvider configurations from Kubernetes
@@ -242,6 +256,8 @@ func (l *MailProviderLoader) convertToRuntimeConfig(ctx context.Context, mp *bre
 		Username:             mp.Spec.SMTP.Username,
 		InsecureSkipVerify:   mp.Spec.SMTP.InsecureSkipVerify,
 		CertificateAuthority: mp.Spec.SMTP.CertificateAuthority,
+		TLSMode:              mp.Spec.SMTP.TLSMode,
+		AuthMechanism:        mp.Spec.SMTP.AuthMechanism,
 		SenderAddress:        mp.Spec.Sender.Address,
 		SenderName:           mp.Spec.Sender.Name,
 		RetryCount:           mp.Spec.Retry.Count,
@@ -262,17 +278,45 @@ func (l *MailProviderLoader) convertToRuntimeConfig(ctx context.Context, mp *bre
 	if config.DisplayName == "" {
 		config.DisplayName = mp.Name
 	}
+	if mp.Spec.Templates != nil {
+		config.TemplatesConfigMapName = mp.Spec.Templates.ConfigMapRef.Name
+		config.TemplatesConfigMapNamespace = mp.Spec.Templates.ConfigMapRef.Namespace
+		config.TemplatesLocale = mp.Spec.Templates.Locale
+	}
 
 	// Load password from secret if referenced
 	if mp.Spec.SMTP.PasswordRef != nil {
 		password, err := l.getSecretValue(ctx, mp.Spec.SMTP.PasswordRef)
 		if err != nil {
 			l.logger.Errorw("Failed to load SMTP password from secret", "name", mp.Name, "error", err)
-			return nil, fmt.Errorf("failed to load SMTP password: %w", err)
+			return nil, &CredentialLoadError{Reason: "password_load_failed", Err: fmt.Errorf("failed to load SMTP password: %w", err)}
 		}
 		config.Password = password
 	}
 
+	// Load the OAuth2 client secret for XOAUTH2
+	if oauth := mp.Spec.SMTP.OAuth2; oauth != nil {
+		clientSecret, err := l.getSecretValue(ctx, &oauth.ClientSecretRef)
+		if err != nil {
+			l.logger.Errorw("Failed to load SMTP OAuth2 client secret", "name", mp.Name, "error", err)
+			return nil, &CredentialLoadError{Reason: "oauth2_secret_load_failed", Err: fmt.Errorf("failed to load OAuth2 client secret: %w", err)}
+		}
+		config.OAuth2TokenURL = oauth.TokenURL
+		config.OAuth2ClientID = oauth.ClientID
+		config.OAuth2ClientSecret = clientSecret
+		config.OAuth2Scopes = oauth.Scopes
+	}
+
+	// Load the client certificate for TLS client authentication
+	if ref := mp.Spec.SMTP.ClientCertificateSecretRef; ref != nil {
+		cert, err := l.getClientCertificate(ctx, ref)
+		if err != nil {
+			l.logger.Errorw("Failed to load SMTP client certificate", "name", mp.Name, "error", err)
+			return nil, &CredentialLoadError{Reason: "client_certificate_load_failed", Err: fmt.Errorf("failed to load client certificate: %w", err)}
+		}
+		config.ClientCertificate = cert
+	}
+
 	l.logger.Debugw("Converted MailProvider to runtime config",
 		"name", mp.Name,
 		"host", config.Host,
@@ -319,6 +363,55 @@ func (l *MailProviderLoader) getSecretValue(ctx context.Context, ref *breakglass
 	return string(value), nil
 }
 
+// getClientCertificate loads a certificate and key pair from a kubernetes.io/tls Secret
+func (l *MailProviderLoader) getClientCertificate(ctx context.Context, ref *breakglassv1alpha1.SecretReference) (*tls.Certificate, error) {
+	if ref.Namespace == "" || ref.Name == "" {
+		return nil, fmt.Errorf("secret reference name and namespace are required")
+	}
+
+	var secret corev1.Secret
+	if err := l.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate in secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	return &cert, nil
+}
+
+// CredentialLoadError reports that a credential referenced by a MailProvider could not be loaded.
+// Reason is a short machine-readable label such as password_load_failed.
+type CredentialLoadError struct {
+	Reason string
+	Err    error
+}
+
+func (e *CredentialLoadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CredentialLoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfigMapData retrieves the data of a Kubernetes ConfigMap
+func LoadConfigMapData(ctx context.Context, reader client.Reader, namespace, name string) (map[string]string, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("configmap reference namespace is empty")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("configmap reference name is empty")
+	}
+
+	var cm corev1.ConfigMap
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &cm); err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+	return cm.Data, nil
+}
+
 // InvalidateCache clears the cache, forcing a reload on next access
 func (l *MailProviderLoader) InvalidateCache(providerName string) {
 	l.mu.Lock()
@@ -355,5 +448,9 @@ func (c *MailProviderConfig) GetTLSConfig() *tls.Config {
 		// If parsing fails, we'll fall back to system certificates
 	}
 
+	if c.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*c.ClientCertificate}
+	}
+
 	return tlsConfig
 }
@@ -2,20 +2,20 @@ package config
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
+	"errors"
 	"fmt"
-	"net"
-	"net/smtp"
 	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
@@ -31,10 +31,30 @@ type MailProviderReconciler struct {
 
 	// Callbacks for notifying other components of changes
 	OnMailProviderChange func(providerName string)
+
+	// OnTemplatesChange is called on every reconcile, including changes of the referenced template
+	// ConfigMaps, so the shared mail templates are reloaded independently of the SMTP health check
+	OnTemplatesChange func()
+
+	// GlobalTemplatesConfigMap is the mail.templatesConfigMap of config.yaml. Its changes reload the
+	// templates like changes of a ConfigMap referenced by a MailProvider.
+	GlobalTemplatesConfigMap types.NamespacedName
+
+	// TemplateValidator test-renders template overrides loaded from the ConfigMap referenced
+	// in spec.templates and returns the validated override keys. Template rendering lives in
+	// the mail package, which depends on this package, so it is injected by the caller.
+	TemplateValidator func(data map[string]string, locale string) ([]string, error)
 }
 
 // Reconcile handles MailProvider create/update/delete events
 func (r *MailProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// MailProviders are cluster-scoped, so a namespaced request stands for the global templates ConfigMap
+	if req.Namespace != "" {
+		r.Log.Debugw("Mail templates ConfigMap changed, reloading templates", "configMap", req.String())
+		r.reloadTemplates()
+		return reconcile.Result{}, nil
+	}
+
 	log := r.Log.With("mailprovider", req.Name)
 	log.Debug("Reconciling MailProvider")
 
@@ -48,6 +68,7 @@ func (r *MailProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			if r.OnMailProviderChange != nil {
 				r.OnMailProviderChange(req.Name)
 			}
+			r.reloadTemplates()
 			return reconcile.Result{}, nil
 		}
 		log.Errorw("Failed to get MailProvider", "error", err)
@@ -63,9 +84,15 @@ func (r *MailProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	// If disabled, just mark as not ready and return
 	if mp.Spec.Disabled {
+		r.reloadTemplates()
 		return r.updateStatusDisabled(ctx, &mp)
 	}
 
+	// Validate template overrides (reported alongside the health status) and reload the templates,
+	// whether or not the SMTP server is reachable
+	r.validateTemplates(ctx, &mp)
+	r.reloadTemplates()
+
 	// Perform health check
 	healthy, healthErr := r.performHealthCheck(ctx, &mp)
 
@@ -77,27 +104,25 @@ func (r *MailProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 }
 
-// performHealthCheck checks if the mail provider is reachable and functional
+// performHealthCheck checks if the mail provider is reachable and functional.
+// It loads the referenced credentials and opens a session with the configured TLS mode and
+// authentication mechanism, acquiring an OAuth2 access token when XOAUTH2 is used.
 func (r *MailProviderReconciler) performHealthCheck(ctx context.Context, mp *breakglassv1alpha1.MailProvider) (bool, error) {
 	log := r.Log.With("mailprovider", mp.Name)
 
-	// Load password if needed
-	var password string
-	if mp.Spec.SMTP.PasswordRef != nil {
-		var err error
-		password, err = r.getSecretValue(ctx, mp.Spec.SMTP.PasswordRef)
-		if err != nil {
-			log.Warnw("Failed to load SMTP password", "error", err)
-			metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, "password_load_failed").Inc()
-			return false, fmt.Errorf("failed to load password: %w", err)
+	// Load credentials the same way the mail sender does
+	cfg, err := NewMailProviderLoader(r.Client).WithLogger(log).convertToRuntimeConfig(ctx, mp)
+	if err != nil {
+		reason := "credentials_load_failed"
+		var loadErr *CredentialLoadError
+		if errors.As(err, &loadErr) {
+			reason = loadErr.Reason
 		}
+		log.Warnw("Failed to load SMTP credentials", "error", err)
+		metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, reason).Inc()
+		return false, err
 	}
 
-	// Try to connect to SMTP server
-	addr := fmt.Sprintf("%s:%d", mp.Spec.SMTP.Host, mp.Spec.SMTP.Port)
-
-	log.Debugw("Performing SMTP health check", "addr", addr)
-
 	// Use a short timeout for health checks
 	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -110,7 +135,7 @@ func (r *MailProviderReconciler) performHealthCheck(ctx context.Context, mp *bre
 	resultCh := make(chan healthCheckResult, 1)
 
 	go func() {
-		healthy, err := r.performHealthCheckSync(checkCtx, mp, password, log)
+		healthy, err := r.performHealthCheckSync(checkCtx, mp, cfg, log)
 		resultCh <- healthCheckResult{healthy: healthy, err: err}
 	}()
 
@@ -125,76 +150,32 @@ func (r *MailProviderReconciler) performHealthCheck(ctx context.Context, mp *bre
 }
 
 // performHealthCheckSync performs the actual SMTP health check synchronously
-func (r *MailProviderReconciler) performHealthCheckSync(ctx context.Context, mp *breakglassv1alpha1.MailProvider, password string, log *zap.SugaredLogger) (bool, error) {
-	// Try to connect to SMTP server
-	addr := fmt.Sprintf("%s:%d", mp.Spec.SMTP.Host, mp.Spec.SMTP.Port)
-
-	log.Debugw("Performing SMTP health check", "addr", addr)
-
-	// Create dialer with timeout
-	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
-	}
-
-	// Connect with timeout
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+func (r *MailProviderReconciler) performHealthCheckSync(ctx context.Context, mp *breakglassv1alpha1.MailProvider, cfg *MailProviderConfig, log *zap.SugaredLogger) (bool, error) {
+	log.Debugw("Performing SMTP health check",
+		"host", cfg.Host,
+		"port", cfg.Port,
+		"tlsMode", cfg.EffectiveTLSMode(),
+		"authMechanism", cfg.AuthMechanism)
+
+	client, err := NewSMTPDialer(cfg).Dial(ctx)
 	if err != nil {
-		log.Warnw("SMTP connection failed", "error", err)
-		metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, "connection_failed").Inc()
-		return false, fmt.Errorf("connection failed: %w", err)
-	}
-	defer conn.Close()
-
-	// Create SMTP client from connection
-	client, err := smtp.NewClient(conn, mp.Spec.SMTP.Host)
-	if err != nil {
-		log.Warnw("SMTP client creation failed", "error", err)
-		metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, "connection_failed").Inc()
-		return false, fmt.Errorf("client creation failed: %w", err)
-	}
-	defer client.Close()
-
-	// Try STARTTLS with proper TLS config
-	if !mp.Spec.SMTP.InsecureSkipVerify {
-		tlsConfig := &tls.Config{
-			ServerName:         mp.Spec.SMTP.Host,
-			InsecureSkipVerify: false,
-		}
-
-		// Add custom CA certificate if provided
-		if mp.Spec.SMTP.CertificateAuthority != "" {
-			certPool := x509.NewCertPool()
-			if ok := certPool.AppendCertsFromPEM([]byte(mp.Spec.SMTP.CertificateAuthority)); !ok {
-				log.Warnw("Failed to parse CA certificate")
-			} else {
-				tlsConfig.RootCAs = certPool
+		reason := "connection_failed"
+		var smtpErr *SMTPError
+		if errors.As(err, &smtpErr) {
+			switch smtpErr.Stage {
+			case SMTPStageTLS:
+				reason = "tls_failed"
+			case SMTPStageToken:
+				reason = "token_failed"
+			case SMTPStageAuth:
+				reason = "auth_failed"
 			}
 		}
-
-		if err := client.StartTLS(tlsConfig); err != nil {
-			// STARTTLS might not be supported, log but don't fail
-			log.Debugw("STARTTLS not available or failed", "error", err)
-		}
-	} else {
-		// Only use insecure TLS if explicitly configured
-		tlsConfig := &tls.Config{
-			ServerName:         mp.Spec.SMTP.Host,
-			InsecureSkipVerify: true,
-		}
-		if err := client.StartTLS(tlsConfig); err != nil {
-			log.Debugw("STARTTLS not available or failed (insecure mode)", "error", err)
-		}
-	}
-
-	// Try authentication if username is provided
-	if mp.Spec.SMTP.Username != "" && password != "" {
-		auth := smtp.PlainAuth("", mp.Spec.SMTP.Username, password, mp.Spec.SMTP.Host)
-		if err := client.Auth(auth); err != nil {
-			log.Warnw("SMTP authentication failed", "error", err)
-			metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, "auth_failed").Inc()
-			return false, fmt.Errorf("authentication failed: %w", err)
-		}
+		log.Warnw("SMTP health check failed", "reason", reason, "error", err)
+		metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, reason).Inc()
+		return false, err
 	}
+	_ = client.Quit()
 
 	log.Info("Health check passed")
 	metrics.MailProviderHealthCheck.WithLabelValues(mp.Name, "success").Inc()
@@ -212,6 +193,88 @@ func (r *MailProviderReconciler) performHealthCheckSync(ctx context.Context, mp
 	return true, nil
 }
 
+// validateTemplates loads and test-renders the template overrides referenced by the MailProvider
+// and records the result in the TemplatesValid condition. Invalid overrides never prevent sending:
+// the mail package keeps the embedded defaults (or the last valid overrides) in that case.
+func (r *MailProviderReconciler) validateTemplates(ctx context.Context, mp *breakglassv1alpha1.MailProvider) {
+	if mp.Spec.Templates == nil {
+		mp.Status.TemplateOverrides = nil
+		meta.RemoveStatusCondition(&mp.Status.Conditions, string(breakglassv1alpha1.MailProviderConditionTemplatesValid))
+		return
+	}
+
+	log := r.Log.With("mailprovider", mp.Name)
+	ref := mp.Spec.Templates.ConfigMapRef
+
+	keys, err := func() ([]string, error) {
+		data, err := LoadConfigMapData(ctx, r.Client, ref.Namespace, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if r.TemplateValidator == nil {
+			return nil, nil
+		}
+		return r.TemplateValidator(data, mp.Spec.Templates.Locale)
+	}()
+	if err != nil {
+		log.Warnw("Mail template overrides are invalid, falling back to embedded templates", "configMap", ref.Namespace+"/"+ref.Name, "error", err)
+		metrics.MailProviderTemplateValidation.WithLabelValues(mp.Name, "invalid").Inc()
+		mp.Status.TemplateOverrides = nil
+		mp.Status.Conditions = r.updateCondition(mp.Status.Conditions,
+			metav1.Condition{
+				Type:               string(breakglassv1alpha1.MailProviderConditionTemplatesValid),
+				Status:             metav1.ConditionFalse,
+				Reason:             "TemplateValidationFailed",
+				Message:            fmt.Sprintf("Using embedded templates: %v", err),
+				LastTransitionTime: metav1.Now(),
+			})
+		return
+	}
+
+	metrics.MailProviderTemplateValidation.WithLabelValues(mp.Name, "valid").Inc()
+	mp.Status.TemplateOverrides = keys
+	mp.Status.Conditions = r.updateCondition(mp.Status.Conditions,
+		metav1.Condition{
+			Type:               string(breakglassv1alpha1.MailProviderConditionTemplatesValid),
+			Status:             metav1.ConditionTrue,
+			Reason:             "TemplatesRendered",
+			Message:            fmt.Sprintf("%d template override(s) loaded from ConfigMap %s/%s", len(keys), ref.Namespace, ref.Name),
+			LastTransitionTime: metav1.Now(),
+		})
+}
+
+func (r *MailProviderReconciler) reloadTemplates() {
+	if r.OnTemplatesChange != nil {
+		r.OnTemplatesChange()
+	}
+}
+
+// templateConfigMapRequests maps a ConfigMap event to the MailProviders referencing the ConfigMap in
+// spec.templates, and to a namespaced request when it is the global templates ConfigMap
+func (r *MailProviderReconciler) templateConfigMapRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if r.GlobalTemplatesConfigMap.Name != "" && key == r.GlobalTemplatesConfigMap {
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+
+	providers := &breakglassv1alpha1.MailProviderList{}
+	if err := r.List(ctx, providers); err != nil {
+		r.Log.Errorw("Failed to list MailProviders for template ConfigMap change", "configMap", key.String(), "error", err)
+		return requests
+	}
+	for _, mp := range providers.Items {
+		if mp.Spec.Templates == nil {
+			continue
+		}
+		ref := mp.Spec.Templates.ConfigMapRef
+		if ref.Namespace == key.Namespace && ref.Name == key.Name {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: mp.Name}})
+		}
+	}
+	return requests
+}
+
 // updateStatusHealthy updates the status to indicate the provider is healthy
 func (r *MailProviderReconciler) updateStatusHealthy(ctx context.Context, mp *breakglassv1alpha1.MailProvider) (ctrl.Result, error) {
 	now := metav1.Now()
@@ -328,38 +391,10 @@ func (r *MailProviderReconciler) updateCondition(conditions []metav1.Condition,
 	return append(conditions, newCondition)
 }
 
-// getSecretValue retrieves a value from a Kubernetes Secret
-func (r *MailProviderReconciler) getSecretValue(ctx context.Context, ref *breakglassv1alpha1.SecretKeyReference) (string, error) {
-	if ref == nil {
-		return "", fmt.Errorf("secret reference is nil")
-	}
-
-	var secret corev1.Secret
-	secretKey := client.ObjectKey{
-		Namespace: ref.Namespace,
-		Name:      ref.Name,
-	}
-
-	if err := r.Get(ctx, secretKey, &secret); err != nil {
-		return "", fmt.Errorf("failed to get secret %s/%s: %w", ref.Namespace, ref.Name, err)
-	}
-
-	key := ref.Key
-	if key == "" {
-		key = "password" // Default key
-	}
-
-	value, exists := secret.Data[key]
-	if !exists {
-		return "", fmt.Errorf("key %s not found in secret %s/%s", key, ref.Namespace, ref.Name)
-	}
-
-	return string(value), nil
-}
-
 // SetupWithManager sets up the controller with the Manager
 func (r *MailProviderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&breakglassv1alpha1.MailProvider{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.templateConfigMapRequests)).
 		Complete(r)
 }
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMailProviderReconcilerValidateTemplates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = breakglassv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mail-templates", Namespace: "breakglass"},
+		Data:       map[string]string{"approved.subject": "Approved"},
+	}
+	newProvider := func() *breakglassv1alpha1.MailProvider {
+		return &breakglassv1alpha1.MailProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "provider"},
+			Spec: breakglassv1alpha1.MailProviderSpec{
+				Templates: &breakglassv1alpha1.MailTemplatesConfig{
+					ConfigMapRef: breakglassv1alpha1.ConfigMapReference{Name: "mail-templates", Namespace: "breakglass"},
+					Locale:       "de",
+				},
+			},
+		}
+	}
+	templatesCondition := string(breakglassv1alpha1.MailProviderConditionTemplatesValid)
+
+	t.Run("valid overrides", func(t *testing.T) {
+		var gotLocale string
+		r := &MailProviderReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(cm).Build(),
+			Log:    zap.NewNop().Sugar(),
+			TemplateValidator: func(data map[string]string, locale string) ([]string, error) {
+				gotLocale = locale
+				return []string{"approved.subject"}, nil
+			},
+		}
+		mp := newProvider()
+		r.validateTemplates(context.Background(), mp)
+
+		if gotLocale != "de" {
+			t.Fatalf("expected locale to be passed to validator, got %q", gotLocale)
+		}
+		if !meta.IsStatusConditionTrue(mp.Status.Conditions, templatesCondition) {
+			t.Fatalf("expected %s condition to be true, got %+v", templatesCondition, mp.Status.Conditions)
+		}
+		if len(mp.Status.TemplateOverrides) != 1 || mp.Status.TemplateOverrides[0] != "approved.subject" {
+			t.Fatalf("unexpected template overrides in status: %v", mp.Status.TemplateOverrides)
+		}
+	})
+
+	t.Run("invalid overrides", func(t *testing.T) {
+		r := &MailProviderReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(cm).Build(),
+			Log:    zap.NewNop().Sugar(),
+			TemplateValidator: func(map[string]string, string) ([]string, error) {
+				return nil, errors.New("approved.subject: bad template")
+			},
+		}
+		mp := newProvider()
+		r.validateTemplates(context.Background(), mp)
+
+		cond := meta.FindStatusCondition(mp.Status.Conditions, templatesCondition)
+		if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != "TemplateValidationFailed" {
+			t.Fatalf("expected failed %s condition, got %+v", templatesCondition, cond)
+		}
+		if mp.Status.TemplateOverrides != nil {
+			t.Fatalf("expected no template overrides in status, got %v", mp.Status.TemplateOverrides)
+		}
+	})
+
+	t.Run("missing configmap", func(t *testing.T) {
+		r := &MailProviderReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+			Log:    zap.NewNop().Sugar(),
+		}
+		mp := newProvider()
+		r.validateTemplates(context.Background(), mp)
+
+		if meta.IsStatusConditionTrue(mp.Status.Conditions, templatesCondition) {
+			t.Fatalf("expected %s condition to be false for missing configmap", templatesCondition)
+		}
+	})
+
+	t.Run("templates removed", func(t *testing.T) {
+		r := &MailProviderReconciler{Log: zap.NewNop().Sugar()}
+		mp := newProvider()
+		mp.Spec.Templates = nil
+		mp.Status.TemplateOverrides = []string{"approved.subject"}
+		mp.Status.Conditions = []metav1.Condition{{Type: templatesCondition, Status: metav1.ConditionTrue}}
+		r.validateTemplates(context.Background(), mp)
+
+		if meta.FindStatusCondition(mp.Status.Conditions, templatesCondition) != nil || mp.Status.TemplateOverrides != nil {
+			t.Fatalf("expected template status to be cleared, got %+v", mp.Status)
+		}
+	})
+}
+
+func TestMailProviderReconcilerHealthCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = breakglassv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	serverCert, serverCertPEM, _ := newTestCertificate(t, "smtp")
+	_, clientCertPEM, clientKeyPEM := newTestCertificate(t, "breakglass-client")
+	srv := startSMTPTestServer(t, testSMTPServerOptions{
+		tlsConfig:      &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientAuth: tls.RequireAnyClientCert},
+		authMechanisms: "XOAUTH2",
+		username:       "breakglass@example.com",
+		token:          "access-token",
+	})
+	tokenSrv, calls := startTokenServer(t, "client", "s3cret", "access-token")
+
+	oauthSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "smtp-oauth", Namespace: "breakglass"},
+		Data:       map[string][]byte{"client-secret": []byte("s3cret")},
+	}
+	certSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "smtp-client-cert", Namespace: "breakglass"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: clientCertPEM, corev1.TLSPrivateKeyKey: clientKeyPEM},
+	}
+	newProvider := func() *breakglassv1alpha1.MailProvider {
+		return &breakglassv1alpha1.MailProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "exchange"},
+			Spec: breakglassv1alpha1.MailProviderSpec{
+				SMTP: breakglassv1alpha1.SMTPConfig{
+					Host:                 "127.0.0.1",
+					Port:                 srv.port(),
+					Username:             "breakglass@example.com",
+					CertificateAuthority: string(serverCertPEM),
+					TLSMode:              breakglassv1alpha1.SMTPTLSModeStartTLS,
+					AuthMechanism:        breakglassv1alpha1.SMTPAuthMechanismXOAUTH2,
+					OAuth2: &breakglassv1alpha1.SMTPOAuth2Config{
+						TokenURL:        tokenSrv.URL,
+						ClientID:        "client",
+						ClientSecretRef: breakglassv1alpha1.SecretKeyReference{Name: "smtp-oauth", Namespace: "breakglass", Key: "client-secret"},
+					},
+					ClientCertificateSecretRef: &breakglassv1alpha1.SecretReference{Name: "smtp-client-cert", Namespace: "breakglass"},
+				},
+			},
+		}
+	}
+
+	t.Run("exercises XOAUTH2 and client certificate", func(t *testing.T) {
+		var changed string
+		r := &MailProviderReconciler{
+			Client:               fake.NewClientBuilder().WithScheme(scheme).WithObjects(oauthSecret, certSecret).Build(),
+			Log:                  zap.NewNop().Sugar(),
+			OnMailProviderChange: func(name string) { changed = name },
+		}
+		healthy, err := r.performHealthCheck(context.Background(), newProvider())
+		if !healthy || err != nil {
+			t.Fatalf("expected healthy provider, got healthy=%v err=%v", healthy, err)
+		}
+		if calls.Load() == 0 {
+			t.Fatalf("expected the health check to acquire an access token")
+		}
+		if !srv.sawClientCert() {
+			t.Fatalf("expected the client certificate to be presented")
+		}
+		if got := srv.mechanismsUsed(); len(got) == 0 || got[len(got)-1] != "XOAUTH2" {
+			t.Fatalf("expected XOAUTH2 authentication, got %v", got)
+		}
+		if changed != "exchange" {
+			t.Fatalf("expected change notification for provider, got %q", changed)
+		}
+	})
+
+	t.Run("missing client secret", func(t *testing.T) {
+		r := &MailProviderReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(certSecret).Build(),
+			Log:    zap.NewNop().Sugar(),
+		}
+		healthy, err := r.performHealthCheck(context.Background(), newProvider())
+		if healthy {
+			t.Fatalf("expected unhealthy provider")
+		}
+		var loadErr *CredentialLoadError
+		if !errors.As(err, &loadErr) || loadErr.Reason != "oauth2_secret_load_failed" {
+			t.Fatalf("expected oauth2_secret_load_failed, got %v", err)
+		}
+	})
+
+	t.Run("authentication failure", func(t *testing.T) {
+		badSecret := oauthSecret.DeepCopy()
+		badSecret.Data["client-secret"] = []byte("wrong")
+		r := &MailProviderReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(badSecret, certSecret).Build(),
+			Log:    zap.NewNop().Sugar(),
+		}
+		healthy, err := r.performHealthCheck(context.Background(), newProvider())
+		if healthy {
+			t.Fatalf("expected unhealthy provider")
+		}
+		var smtpErr *SMTPError
+		if !errors.As(err, &smtpErr) || smtpErr.Stage != SMTPStageToken {
+			t.Fatalf("expected token stage failure, got %v", err)
+		}
+	})
+}
+
+func TestMailProviderReconcilerReloadsTemplates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = breakglassv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	withTemplates := func(name, cmName string) *breakglassv1alpha1.MailProvider {
+		mp := &breakglassv1alpha1.MailProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			// nothing listens on port 1, so the health check fails
+			Spec: breakglassv1alpha1.MailProviderSpec{SMTP: breakglassv1alpha1.SMTPConfig{Host: "127.0.0.1", Port: 1}},
+		}
+		if cmName != "" {
+			mp.Spec.Templates = &breakglassv1alpha1.MailTemplatesConfig{
+				ConfigMapRef: breakglassv1alpha1.ConfigMapReference{Name: cmName, Namespace: "breakglass"},
+			}
+		}
+		return mp
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mail-templates", Namespace: "breakglass"}}
+	global := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "global-templates", Namespace: "breakglass"}}
+
+	reloads := 0
+	r := &MailProviderReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(withTemplates("a", "mail-templates"), withTemplates("b", "other"), withTemplates("c", ""), cm).
+			WithStatusSubresource(&breakglassv1alpha1.MailProvider{}).Build(),
+		Log:                      zap.NewNop().Sugar(),
+		OnTemplatesChange:        func() { reloads++ },
+		GlobalTemplatesConfigMap: types.NamespacedName{Namespace: "breakglass", Name: "global-templates"},
+	}
+	ctx := context.Background()
+
+	requests := r.templateConfigMapRequests(ctx, cm)
+	if len(requests) != 1 || requests[0].Name != "a" || requests[0].Namespace != "" {
+		t.Fatalf("expected the referencing MailProvider to be enqueued, got %v", requests)
+	}
+	requests = r.templateConfigMapRequests(ctx, global)
+	if len(requests) != 1 || requests[0].NamespacedName != r.GlobalTemplatesConfigMap {
+		t.Fatalf("expected the global templates ConfigMap to be enqueued, got %v", requests)
+	}
+	if requests := r.templateConfigMapRequests(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "breakglass"}}); len(requests) != 0 {
+		t.Fatalf("expected no requests for an unrelated ConfigMap, got %v", requests)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: r.GlobalTemplatesConfigMap}); err != nil {
+		t.Fatalf("reconcile of the global templates ConfigMap failed: %v", err)
+	}
+	if reloads != 1 {
+		t.Fatalf("expected templates to be reloaded for the global ConfigMap, got %d reloads", reloads)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "a"}}); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if reloads != 2 {
+		t.Fatalf("expected templates to be reloaded although the SMTP health check failed, got %d reloads", reloads)
+	}
+	var mp breakglassv1alpha1.MailProvider
+	if err := r.Get(ctx, types.NamespacedName{Name: "a"}, &mp); err != nil {
+		t.Fatalf("get MailProvider: %v", err)
+	}
+	if meta.IsStatusConditionTrue(mp.Status.Conditions, string(breakglassv1alpha1.MailProviderConditionHealthy)) {
+		t.Fatalf("expected the provider to be unhealthy, got %+v", mp.Status.Conditions)
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+)
+
+// DefaultSMTPDialTimeout bounds establishing the TCP connection to the SMTP server
+const DefaultSMTPDialTimeout = 10 * time.Second
+
+// SMTPStage identifies the step of an SMTP session setup that failed
+type SMTPStage string
+
+const (
+	SMTPStageConnect SMTPStage = "connect"
+	SMTPStageTLS     SMTPStage = "tls"
+	SMTPStageToken   SMTPStage = "token"
+	SMTPStageAuth    SMTPStage = "auth"
+)
+
+// SMTPError reports the stage at which an SMTP session could not be established
+type SMTPError struct {
+	Stage SMTPStage
+	Err   error
+}
+
+func (e *SMTPError) Error() string {
+	return fmt.Sprintf("smtp %s failed: %v", e.Stage, e.Err)
+}
+
+func (e *SMTPError) Unwrap() error {
+	return e.Err
+}
+
+// SMTPDialer opens authenticated SMTP sessions using the TLS mode and authentication
+// mechanism of a MailProvider. XOAUTH2 access tokens are cached and refreshed by the
+// dialer, so a dialer should be reused for the lifetime of the provider configuration.
+type SMTPDialer struct {
+	cfg         *MailProviderConfig
+	dialTimeout time.Duration
+
+	tokenOnce   sync.Once
+	tokenSource oauth2.TokenSource
+}
+
+// NewSMTPDialer creates an SMTPDialer for the given runtime configuration
+func NewSMTPDialer(cfg *MailProviderConfig) *SMTPDialer {
+	return &SMTPDialer{cfg: cfg, dialTimeout: DefaultSMTPDialTimeout}
+}
+
+// Dial connects to the SMTP server, negotiates TLS and authenticates.
+// The returned client is ready for MAIL FROM; the caller must Quit or Close it.
+// When ctx carries a deadline it applies to the whole session.
+func (d *SMTPDialer) Dial(ctx context.Context) (*smtp.Client, error) {
+	cfg := d.cfg
+	mode := cfg.EffectiveTLSMode()
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+
+	netDialer := &net.Dialer{Timeout: d.dialTimeout}
+	conn, err := netDialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, &SMTPError{Stage: SMTPStageConnect, Err: err}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if mode == breakglassv1alpha1.SMTPTLSModeImplicit {
+		tlsConn := tls.Client(conn, cfg.GetTLSConfig())
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, &SMTPError{Stage: SMTPStageTLS, Err: err}
+		}
+		conn = tlsConn
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, &SMTPError{Stage: SMTPStageConnect, Err: err}
+	}
+
+	if err := d.negotiateTLS(c, mode); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	if err := d.authenticate(c); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// negotiateTLS upgrades the connection with STARTTLS as required by the TLS mode
+func (d *SMTPDialer) negotiateTLS(c *smtp.Client, mode breakglassv1alpha1.SMTPTLSMode) error {
+	if mode == breakglassv1alpha1.SMTPTLSModeImplicit || mode == breakglassv1alpha1.SMTPTLSModeNone {
+		return nil
+	}
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		if mode == breakglassv1alpha1.SMTPTLSModeStartTLS {
+			return &SMTPError{Stage: SMTPStageTLS, Err: errors.New("server does not support STARTTLS")}
+		}
+		return nil
+	}
+	if err := c.StartTLS(d.cfg.GetTLSConfig()); err != nil {
+		return &SMTPError{Stage: SMTPStageTLS, Err: err}
+	}
+	return nil
+}
+
+// authenticate runs the configured SMTP AUTH mechanism
+func (d *SMTPDialer) authenticate(c *smtp.Client) error {
+	cfg := d.cfg
+	var auth smtp.Auth
+
+	switch cfg.AuthMechanism {
+	case breakglassv1alpha1.SMTPAuthMechanismNone:
+		return nil
+	case breakglassv1alpha1.SMTPAuthMechanismPlain:
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case breakglassv1alpha1.SMTPAuthMechanismLogin:
+		auth = &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}
+	case breakglassv1alpha1.SMTPAuthMechanismXOAUTH2:
+		token, err := d.accessToken()
+		if err != nil {
+			return &SMTPError{Stage: SMTPStageToken, Err: err}
+		}
+		auth = &xoauth2Auth{username: cfg.Username, token: token}
+	default:
+		// Unset: negotiate based on the advertised mechanisms when credentials are configured
+		if cfg.Username == "" || cfg.Password == "" {
+			return nil
+		}
+		ok, mechanisms := c.Extension("AUTH")
+		if !ok {
+			return nil
+		}
+		switch {
+		case strings.Contains(mechanisms, "CRAM-MD5"):
+			auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+		case strings.Contains(mechanisms, "LOGIN") && !strings.Contains(mechanisms, "PLAIN"):
+			auth = &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}
+		default:
+			auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+		}
+	}
+
+	if err := c.Auth(auth); err != nil {
+		return &SMTPError{Stage: SMTPStageAuth, Err: err}
+	}
+	return nil
+}
+
+// accessToken returns a cached or freshly acquired OAuth2 access token
+func (d *SMTPDialer) accessToken() (string, error) {
+	cfg := d.cfg
+	if cfg.OAuth2TokenURL == "" || cfg.OAuth2ClientID == "" {
+		return "", errors.New("oauth2 token endpoint and client ID are required for XOAUTH2")
+	}
+	d.tokenOnce.Do(func() {
+		cc := &clientcredentials.Config{
+			ClientID:     cfg.OAuth2ClientID,
+			ClientSecret: cfg.OAuth2ClientSecret,
+			TokenURL:     cfg.OAuth2TokenURL,
+			Scopes:       cfg.OAuth2Scopes,
+		}
+		d.tokenSource = cc.TokenSource(context.Background())
+	})
+	token, err := d.tokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// EffectiveTLSMode returns the configured TLS mode, defaulting to Implicit on port 465
+// and Opportunistic otherwise
+func (c *MailProviderConfig) EffectiveTLSMode() breakglassv1alpha1.SMTPTLSMode {
+	if c.TLSMode != "" {
+		return c.TLSMode
+	}
+	if c.Port == 465 {
+		return breakglassv1alpha1.SMTPTLSModeImplicit
+	}
+	return breakglassv1alpha1.SMTPTLSModeOpportunistic
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism used by Exchange Online and Gmail
+type xoauth2Auth struct {
+	username string
+	token    string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		// The server sent an error challenge; an empty response makes it report the final status
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
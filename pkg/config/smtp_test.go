@@ -0,0 +1,445 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+)
+
+// testSMTPServerOptions configures the behaviour of the in-process SMTP server
+type testSMTPServerOptions struct {
+	// tlsConfig enables STARTTLS (or implicit TLS when implicit is set)
+	tlsConfig *tls.Config
+	implicit  bool
+	// authMechanisms is advertised in the EHLO response, e.g. "PLAIN LOGIN XOAUTH2"
+	authMechanisms string
+	username       string
+	password       string
+	token          string
+}
+
+// testSMTPServer is a minimal SMTP server that implements EHLO, STARTTLS and AUTH
+// (PLAIN, LOGIN, XOAUTH2) for exercising SMTPDialer
+type testSMTPServer struct {
+	opts testSMTPServerOptions
+	ln   net.Listener
+	wg   sync.WaitGroup
+
+	mu         sync.Mutex
+	authedWith []string
+	clientCert bool
+}
+
+func startSMTPTestServer(t *testing.T, opts testSMTPServerOptions) *testSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &testSMTPServer{opts: opts, ln: ln}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+func (s *testSMTPServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testSMTPServer) mechanismsUsed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.authedWith...)
+}
+
+func (s *testSMTPServer) sawClientCert() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientCert
+}
+
+func (s *testSMTPServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	isTLS := false
+	upgrade := func() bool {
+		tlsConn := tls.Server(conn, s.opts.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		if len(tlsConn.ConnectionState().PeerCertificates) > 0 {
+			s.mu.Lock()
+			s.clientCert = true
+			s.mu.Unlock()
+		}
+		conn = tlsConn
+		isTLS = true
+		return true
+	}
+	if s.opts.implicit && !upgrade() {
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	write := func(format string, args ...any) { _, _ = fmt.Fprintf(conn, format+"\r\n", args...) }
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+	succeed := func(mechanism string) {
+		s.mu.Lock()
+		s.authedWith = append(s.authedWith, mechanism)
+		s.mu.Unlock()
+		write("235 2.7.0 Authentication successful")
+	}
+
+	write("220 localhost ESMTP test")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			write("250-localhost")
+			if s.opts.tlsConfig != nil && !isTLS {
+				write("250-STARTTLS")
+			}
+			if s.opts.authMechanisms != "" {
+				write("250-AUTH %s", s.opts.authMechanisms)
+			}
+			write("250 OK")
+		case cmd == "STARTTLS":
+			write("220 Ready to start TLS")
+			if !upgrade() {
+				return
+			}
+			r = bufio.NewReader(conn)
+		case strings.HasPrefix(cmd, "AUTH PLAIN "):
+			decoded, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+			if string(decoded) == "\x00"+s.opts.username+"\x00"+s.opts.password {
+				succeed("PLAIN")
+			} else {
+				write("535 5.7.8 Authentication credentials invalid")
+			}
+		case cmd == "AUTH LOGIN":
+			write("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+			userLine, _ := readLine()
+			write("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+			passLine, _ := readLine()
+			user, _ := base64.StdEncoding.DecodeString(userLine)
+			pass, _ := base64.StdEncoding.DecodeString(passLine)
+			if string(user) == s.opts.username && string(pass) == s.opts.password {
+				succeed("LOGIN")
+			} else {
+				write("535 5.7.8 Authentication credentials invalid")
+			}
+		case strings.HasPrefix(cmd, "AUTH XOAUTH2 "):
+			decoded, _ := base64.StdEncoding.DecodeString(line[len("AUTH XOAUTH2 "):])
+			expected := "user=" + s.opts.username + "\x01auth=Bearer " + s.opts.token + "\x01\x01"
+			if string(decoded) == expected {
+				succeed("XOAUTH2")
+			} else {
+				write("334 %s", base64.StdEncoding.EncodeToString([]byte(`{"status":"401","schemes":"bearer"}`)))
+				_, _ = readLine()
+				write("535 5.7.3 Authentication unsuccessful")
+			}
+		case cmd == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+// newTestCertificate creates a self-signed certificate valid for 127.0.0.1
+func newTestCertificate(t *testing.T, commonName string) (tls.Certificate, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	return cert, certPEM, keyPEM
+}
+
+// startTokenServer serves the OAuth2 client-credentials grant and counts token requests
+func startTokenServer(t *testing.T, clientID, clientSecret, token string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = r.ParseForm()
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if r.PostForm.Get("grant_type") != "client_credentials" || id != clientID || secret != clientSecret {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, token)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestSMTPDialer_XOAUTH2OverSTARTTLS(t *testing.T) {
+	serverCert, serverCertPEM, _ := newTestCertificate(t, "smtp")
+	srv := startSMTPTestServer(t, testSMTPServerOptions{
+		tlsConfig:      &tls.Config{Certificates: []tls.Certificate{serverCert}},
+		authMechanisms: "LOGIN XOAUTH2",
+		username:       "breakglass@example.com",
+		token:          "access-token-1",
+	})
+	tokenSrv, calls := startTokenServer(t, "client", "s3cret", "access-token-1")
+
+	cfg := &MailProviderConfig{
+		Host:                 "127.0.0.1",
+		Port:                 srv.port(),
+		Username:             "breakglass@example.com",
+		CertificateAuthority: string(serverCertPEM),
+		TLSMode:              breakglassv1alpha1.SMTPTLSModeStartTLS,
+		AuthMechanism:        breakglassv1alpha1.SMTPAuthMechanismXOAUTH2,
+		OAuth2TokenURL:       tokenSrv.URL,
+		OAuth2ClientID:       "client",
+		OAuth2ClientSecret:   "s3cret",
+		OAuth2Scopes:         []string{"https://outlook.office365.com/.default"},
+	}
+	dialer := NewSMTPDialer(cfg)
+
+	for i := 0; i < 2; i++ {
+		c, err := dialer.Dial(context.Background())
+		if err != nil {
+			t.Fatalf("dial %d failed: %v", i, err)
+		}
+		if _, ok := c.TLSConnectionState(); !ok {
+			t.Fatalf("expected STARTTLS to be negotiated")
+		}
+		_ = c.Quit()
+	}
+
+	if got := srv.mechanismsUsed(); len(got) != 2 || got[0] != "XOAUTH2" {
+		t.Fatalf("expected two XOAUTH2 authentications, got %v", got)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected the access token to be cached, got %d token requests", calls.Load())
+	}
+}
+
+func TestSMTPDialer_XOAUTH2Failures(t *testing.T) {
+	serverCert, serverCertPEM, _ := newTestCertificate(t, "smtp")
+	srv := startSMTPTestServer(t, testSMTPServerOptions{
+		tlsConfig:      &tls.Config{Certificates: []tls.Certificate{serverCert}},
+		authMechanisms: "XOAUTH2",
+		username:       "breakglass@example.com",
+		token:          "expected-token",
+	})
+
+	newConfig := func(tokenURL, secret string) *MailProviderConfig {
+		return &MailProviderConfig{
+			Host:                 "127.0.0.1",
+			Port:                 srv.port(),
+			Username:             "breakglass@example.com",
+			CertificateAuthority: string(serverCertPEM),
+			AuthMechanism:        breakglassv1alpha1.SMTPAuthMechanismXOAUTH2,
+			OAuth2TokenURL:       tokenURL,
+			OAuth2ClientID:       "client",
+			OAuth2ClientSecret:   secret,
+		}
+	}
+
+	t.Run("token rejected by server", func(t *testing.T) {
+		tokenSrv, _ := startTokenServer(t, "client", "s3cret", "other-token")
+		_, err := NewSMTPDialer(newConfig(tokenSrv.URL, "s3cret")).Dial(context.Background())
+		assertSMTPStage(t, err, SMTPStageAuth)
+	})
+
+	t.Run("token endpoint rejects client", func(t *testing.T) {
+		tokenSrv, _ := startTokenServer(t, "client", "s3cret", "expected-token")
+		_, err := NewSMTPDialer(newConfig(tokenSrv.URL, "wrong")).Dial(context.Background())
+		assertSMTPStage(t, err, SMTPStageToken)
+	})
+}
+
+func TestSMTPDialer_TLSModes(t *testing.T) {
+	serverCert, serverCertPEM, _ := newTestCertificate(t, "smtp")
+
+	t.Run("StartTLS required but not offered", func(t *testing.T) {
+		srv := startSMTPTestServer(t, testSMTPServerOptions{})
+		cfg := &MailProviderConfig{Host: "127.0.0.1", Port: srv.port(), TLSMode: breakglassv1alpha1.SMTPTLSModeStartTLS}
+		_, err := NewSMTPDialer(cfg).Dial(context.Background())
+		assertSMTPStage(t, err, SMTPStageTLS)
+	})
+
+	t.Run("Opportunistic continues without STARTTLS", func(t *testing.T) {
+		srv := startSMTPTestServer(t, testSMTPServerOptions{})
+		cfg := &MailProviderConfig{Host: "127.0.0.1", Port: srv.port()}
+		c, err := NewSMTPDialer(cfg).Dial(context.Background())
+		if err != nil {
+			t.Fatalf("expected plaintext session, got %v", err)
+		}
+		_ = c.Quit()
+	})
+
+	t.Run("Opportunistic fails on untrusted certificate", func(t *testing.T) {
+		srv := startSMTPTestServer(t, testSMTPServerOptions{tlsConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}}})
+		cfg := &MailProviderConfig{Host: "127.0.0.1", Port: srv.port()}
+		_, err := NewSMTPDialer(cfg).Dial(context.Background())
+		assertSMTPStage(t, err, SMTPStageTLS)
+	})
+
+	t.Run("Implicit TLS with client certificate", func(t *testing.T) {
+		clientCert, _, _ := newTestCertificate(t, "breakglass-client")
+		srv := startSMTPTestServer(t, testSMTPServerOptions{
+			tlsConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientAuth: tls.RequireAnyClientCert},
+			implicit:  true,
+		})
+		cfg := &MailProviderConfig{
+			Host:                 "127.0.0.1",
+			Port:                 srv.port(),
+			TLSMode:              breakglassv1alpha1.SMTPTLSModeImplicit,
+			CertificateAuthority: string(serverCertPEM),
+			ClientCertificate:    &clientCert,
+		}
+		c, err := NewSMTPDialer(cfg).Dial(context.Background())
+		if err != nil {
+			t.Fatalf("implicit TLS dial failed: %v", err)
+		}
+		_ = c.Quit()
+		if !srv.sawClientCert() {
+			t.Fatalf("expected the server to receive the client certificate")
+		}
+	})
+}
+
+func TestSMTPDialer_PasswordMechanisms(t *testing.T) {
+	tests := []struct {
+		name       string
+		advertised string
+		mechanism  breakglassv1alpha1.SMTPAuthMechanism
+		password   string
+		want       string
+		wantStage  SMTPStage
+	}{
+		{name: "explicit LOGIN", advertised: "PLAIN LOGIN", mechanism: breakglassv1alpha1.SMTPAuthMechanismLogin, password: "pw", want: "LOGIN"},
+		{name: "explicit PLAIN", advertised: "PLAIN LOGIN", mechanism: breakglassv1alpha1.SMTPAuthMechanismPlain, password: "pw", want: "PLAIN"},
+		{name: "negotiated LOGIN-only server", advertised: "LOGIN", password: "pw", want: "LOGIN"},
+		{name: "negotiated PLAIN", advertised: "PLAIN LOGIN", password: "pw", want: "PLAIN"},
+		{name: "wrong password", advertised: "PLAIN", mechanism: breakglassv1alpha1.SMTPAuthMechanismPlain, password: "nope", wantStage: SMTPStageAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startSMTPTestServer(t, testSMTPServerOptions{authMechanisms: tt.advertised, username: "user", password: "pw"})
+			cfg := &MailProviderConfig{
+				Host:          "127.0.0.1",
+				Port:          srv.port(),
+				Username:      "user",
+				Password:      tt.password,
+				AuthMechanism: tt.mechanism,
+			}
+			c, err := NewSMTPDialer(cfg).Dial(context.Background())
+			if tt.wantStage != "" {
+				assertSMTPStage(t, err, tt.wantStage)
+				return
+			}
+			if err != nil {
+				t.Fatalf("dial failed: %v", err)
+			}
+			_ = c.Quit()
+			if got := srv.mechanismsUsed(); len(got) != 1 || got[0] != tt.want {
+				t.Fatalf("expected %s authentication, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMailProviderConfig_EffectiveTLSMode(t *testing.T) {
+	if got := (&MailProviderConfig{Port: 465}).EffectiveTLSMode(); got != breakglassv1alpha1.SMTPTLSModeImplicit {
+		t.Fatalf("expected Implicit on port 465, got %s", got)
+	}
+	if got := (&MailProviderConfig{Port: 587}).EffectiveTLSMode(); got != breakglassv1alpha1.SMTPTLSModeOpportunistic {
+		t.Fatalf("expected Opportunistic on port 587, got %s", got)
+	}
+	if got := (&MailProviderConfig{Port: 465, TLSMode: breakglassv1alpha1.SMTPTLSModeStartTLS}).EffectiveTLSMode(); got != breakglassv1alpha1.SMTPTLSModeStartTLS {
+		t.Fatalf("expected explicit mode to win, got %s", got)
+	}
+}
+
+func assertSMTPStage(t *testing.T, err error, stage SMTPStage) {
+	t.Helper()
+	var smtpErr *SMTPError
+	if !errors.As(err, &smtpErr) {
+		t.Fatalf("expected SMTPError at stage %s, got %v", stage, err)
+	}
+	if smtpErr.Stage != stage {
+		t.Fatalf("expected stage %s, got %s (%v)", stage, smtpErr.Stage, smtpErr.Err)
+	}
+}
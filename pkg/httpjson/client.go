@@ -0,0 +1,262 @@
+// Package httpjson resolves group members from HTTP endpoints returning JSON. The member
+// identifiers and the next page link are extracted with JSONPath expressions.
+package httpjson
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	// GroupPlaceholder is replaced by the escaped group name in the members URL
+	GroupPlaceholder = "{group}"
+	// DefaultMaxPages limits the pages fetched for one group
+	DefaultMaxPages = 50
+	// DefaultTokenHeader carries the token as a bearer token
+	DefaultTokenHeader = "Authorization"
+
+	// maxResponseBytes caps the size of a single response body
+	maxResponseBytes = 16 << 20
+)
+
+// ErrGroupNotFound is returned when the members URL of a group answers 404
+var ErrGroupNotFound = errors.New("httpjson: group not found")
+
+// Options describes the endpoint and how to read its responses
+type Options struct {
+	// MembersURL contains GroupPlaceholder
+	MembersURL string
+	// MembersPath selects the member identifiers, e.g. $.members[*].email
+	MembersPath string
+	// NextPagePath selects the next page URL; empty disables paging
+	NextPagePath string
+	// MaxPages defaults to DefaultMaxPages
+	MaxPages int
+	// Token is sent in TokenHeader ("Bearer <token>" for the Authorization header)
+	Token       string
+	TokenHeader string
+	// CertificateAuthority is a PEM encoded CA bundle; the system roots are used when empty
+	CertificateAuthority string
+	// InsecureSkipVerify disables server certificate verification (testing only)
+	InsecureSkipVerify bool
+}
+
+// Client fetches group members. A Client is safe for concurrent use.
+type Client struct {
+	opts        Options
+	http        *http.Client
+	membersPath string
+	nextPath    string
+}
+
+// NewClient validates the options, including both JSONPath expressions
+func NewClient(opts Options) (*Client, error) {
+	if !strings.Contains(opts.MembersURL, GroupPlaceholder) {
+		return nil, fmt.Errorf("httpjson: membersURL must contain %s", GroupPlaceholder)
+	}
+	if _, err := url.Parse(strings.ReplaceAll(opts.MembersURL, GroupPlaceholder, "group")); err != nil {
+		return nil, fmt.Errorf("httpjson: invalid membersURL: %w", err)
+	}
+	membersPath, err := ParsePath(opts.MembersPath)
+	if err != nil {
+		return nil, fmt.Errorf("httpjson: invalid membersPath: %w", err)
+	}
+	var nextPath string
+	if opts.NextPagePath != "" {
+		if nextPath, err = ParsePath(opts.NextPagePath); err != nil {
+			return nil, fmt.Errorf("httpjson: invalid nextPagePath: %w", err)
+		}
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	if opts.TokenHeader == "" {
+		opts.TokenHeader = DefaultTokenHeader
+	}
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{
+		opts: opts,
+		// Redirects could leak the token to other hosts
+		http: &http.Client{
+			Transport:     transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		membersPath: membersPath,
+		nextPath:    nextPath,
+	}, nil
+}
+
+// ParsePath checks a JSONPath expression and returns it in the braced template form
+// expected by k8s.io/client-go/util/jsonpath ("$.a[*].b" becomes "{$.a[*].b}")
+func ParsePath(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return "", fmt.Errorf("empty JSONPath expression")
+	}
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	if err := jsonpath.New("path").Parse(expr); err != nil {
+		return "", err
+	}
+	return expr, nil
+}
+
+func (opts Options) tlsConfig() (*tls.Config, error) {
+	// InsecureSkipVerify is an explicit opt-in for test environments
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CertificateAuthority != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CertificateAuthority)) {
+			return nil, fmt.Errorf("httpjson: certificateAuthority contains no valid PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// MembersURL returns the first page URL of a group
+func (c *Client) MembersURL(group string) string {
+	// QueryEscape also covers path segments once spaces are percent-encoded
+	escaped := strings.ReplaceAll(url.QueryEscape(group), "+", "%20")
+	return strings.ReplaceAll(c.opts.MembersURL, GroupPlaceholder, escaped)
+}
+
+// Members fetches all pages of a group and returns the extracted identifiers
+func (c *Client) Members(ctx context.Context, group string) ([]string, error) {
+	first, err := url.Parse(c.MembersURL(group))
+	if err != nil {
+		return nil, fmt.Errorf("httpjson: invalid members URL: %w", err)
+	}
+	var members []string
+	visited := map[string]bool{}
+	for next := first; next != nil; {
+		if len(visited) == c.opts.MaxPages {
+			return nil, fmt.Errorf("httpjson: members of group %q exceed %d pages", group, c.opts.MaxPages)
+		}
+		visited[next.String()] = true
+
+		doc, err := c.get(ctx, next.String())
+		if err != nil {
+			return nil, err
+		}
+		values, err := find(c.membersPath, doc)
+		if err != nil {
+			return nil, fmt.Errorf("httpjson: membersPath: %w", err)
+		}
+		members = append(members, values...)
+
+		next = nil
+		if c.nextPath == "" {
+			break
+		}
+		links, err := find(c.nextPath, doc)
+		if err != nil {
+			return nil, fmt.Errorf("httpjson: nextPagePath: %w", err)
+		}
+		if len(links) == 0 || links[0] == "" {
+			break
+		}
+		ref, err := url.Parse(links[0])
+		if err != nil {
+			return nil, fmt.Errorf("httpjson: invalid next page URL %q: %w", links[0], err)
+		}
+		next = first.ResolveReference(ref)
+		if next.Scheme != first.Scheme || next.Host != first.Host {
+			return nil, fmt.Errorf("httpjson: refusing to follow next page URL to another host: %s", next.Redacted())
+		}
+		if visited[next.String()] {
+			break
+		}
+	}
+	return members, nil
+}
+
+func (c *Client) get(ctx context.Context, target string) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.opts.Token != "" {
+		if http.CanonicalHeaderKey(c.opts.TokenHeader) == DefaultTokenHeader {
+			req.Header.Set(DefaultTokenHeader, "Bearer "+c.opts.Token)
+		} else {
+			req.Header.Set(c.opts.TokenHeader, c.opts.Token)
+		}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("httpjson: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrGroupNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("httpjson: %s returned %d", req.URL.Redacted(), resp.StatusCode)
+	}
+	var doc any
+	dec := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("httpjson: failed to decode response: %w", err)
+	}
+	return doc, nil
+}
+
+// find evaluates a parsed path and returns the non-empty scalar results as strings
+func find(path string, doc any) ([]string, error) {
+	jp := jsonpath.New("path").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, err
+	}
+	results, err := jp.FindResults(doc)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, set := range results {
+		for _, v := range set {
+			if s := scalar(v); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, nil
+}
+
+func scalar(v reflect.Value) string {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	default:
+		// Objects and arrays are not identifiers
+		return ""
+	}
+}
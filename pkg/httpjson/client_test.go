@@ -0,0 +1,97 @@
+package httpjson
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, srv *httptest.Server, opts Options) *Client {
+	t.Helper()
+	opts.CertificateAuthority = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	if opts.MembersURL == "" {
+		opts.MembersURL = srv.URL + "/groups/{group}/members"
+	}
+	c, err := NewClient(opts)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClientMembersPaging(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.URL.Path != "/groups/platform ops/members" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"members": []map[string]any{{"email": "alice@example.com"}, {"email": "bob@example.com"}, {"name": "no-email"}},
+				"links":   map[string]string{"next": "?page=2"},
+			})
+		case "2":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"members": []map[string]any{{"email": "carol@example.com"}, {"email": 42}},
+				"links":   map[string]string{"next": ""},
+			})
+		}
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv, Options{MembersPath: "$.members[*].email", NextPagePath: "$.links.next", Token: "secret"})
+
+	assert.Equal(t, srv.URL+"/groups/platform%20ops%2Fadmins/members", c.MembersURL("platform ops/admins"))
+	members, err := c.Members(context.Background(), "platform ops")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com", "carol@example.com", "42"}, members)
+
+	_, err = c.Members(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+}
+
+func TestClientMembersLimits(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		next := map[string]string{
+			"/groups/endless/members":  "/groups/endless/members?n=" + r.URL.Query().Get("n") + "1",
+			"/groups/cycle/members":    "/groups/cycle/members",
+			"/groups/external/members": "https://attacker.example.com/members",
+		}[r.URL.Path]
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": []string{"alice"}, "next": next})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv, Options{MembersPath: "{.users[*]}", NextPagePath: ".next", MaxPages: 3, Token: "key", TokenHeader: "X-API-Key"})
+
+	_, err := c.Members(context.Background(), "endless")
+	assert.ErrorContains(t, err, "exceed 3 pages")
+
+	members, err := c.Members(context.Background(), "cycle")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, members)
+
+	_, err = c.Members(context.Background(), "external")
+	assert.ErrorContains(t, err, "another host")
+}
+
+func TestNewClientValidation(t *testing.T) {
+	_, err := NewClient(Options{MembersURL: "https://example.com/members", MembersPath: "$.a"})
+	assert.ErrorContains(t, err, "{group}")
+
+	_, err = NewClient(Options{MembersURL: "https://example.com/{group}", MembersPath: "$.a[?("})
+	assert.ErrorContains(t, err, "membersPath")
+
+	_, err = NewClient(Options{MembersURL: "https://example.com/{group}", MembersPath: "$.a", NextPagePath: "{.b"})
+	assert.ErrorContains(t, err, "nextPagePath")
+
+	c, err := NewClient(Options{MembersURL: "https://example.com/{group}", MembersPath: "$.a"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxPages, c.opts.MaxPages)
+	assert.Equal(t, "{$.a}", c.membersPath)
+}
@@ -64,6 +64,17 @@ func RegisterCommonFieldIndexes(ctx context.Context, idx client.FieldIndexer, lo
 		return err
 	}
 
+	if err := register("BreakglassSession.status.state", func() error {
+		return idx.IndexField(ctx, &v1alpha1.BreakglassSession{}, "status.state", func(rawObj client.Object) []string {
+			if bs, ok := rawObj.(*v1alpha1.BreakglassSession); ok && bs.Status.State != "" {
+				return []string{string(bs.Status.State)}
+			}
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
 	if err := register("BreakglassSession.metadata.name", func() error {
 		return idx.IndexField(ctx, &v1alpha1.BreakglassSession{}, "metadata.name", func(rawObj client.Object) []string {
 			if bs, ok := rawObj.(*v1alpha1.BreakglassSession); ok && bs.Name != "" {
@@ -81,9 +92,11 @@ func RegisterCommonFieldIndexes(ctx context.Context, idx client.FieldIndexer, lo
 			if !ok || be == nil {
 				return nil
 			}
-			out := make([]string, 0, len(be.Spec.Allowed.Clusters)+len(be.Spec.ClusterConfigRefs))
+			// clusters matched by spec.clusterSelector are resolved into status by the escalation reconciler
+			out := make([]string, 0, len(be.Spec.Allowed.Clusters)+len(be.Spec.ClusterConfigRefs)+len(be.Status.ResolvedClusters))
 			out = append(out, be.Spec.Allowed.Clusters...)
 			out = append(out, be.Spec.ClusterConfigRefs...)
+			out = append(out, be.Status.ResolvedClusters...)
 			return out
 		})
 	}); err != nil {
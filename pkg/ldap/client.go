@@ -0,0 +1,315 @@
+// Package ldap wraps github.com/go-ldap/ldap/v3 with what group synchronization needs: simple
+// bind, StartTLS and paged search, with every operation bounded by a context.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// Object identifiers
+const (
+	// OIDStartTLS is the StartTLS extended operation (RFC 4511, section 4.14)
+	OIDStartTLS = "1.3.6.1.4.1.1466.20037"
+	// OIDPagedResults is the simple paged results control (RFC 2696)
+	OIDPagedResults = goldap.ControlTypePaging
+)
+
+// Result codes (RFC 4511, appendix A)
+const (
+	ResultSuccess                 = goldap.LDAPResultSuccess
+	ResultOperationsError         = goldap.LDAPResultOperationsError
+	ResultProtocolError           = goldap.LDAPResultProtocolError
+	ResultSizeLimitExceeded       = goldap.LDAPResultSizeLimitExceeded
+	ResultUnavailableCritical     = goldap.LDAPResultUnavailableCriticalExtension
+	ResultConfidentialityRequired = goldap.LDAPResultConfidentialityRequired
+	ResultNoSuchObject            = goldap.LDAPResultNoSuchObject
+	ResultInvalidDNSyntax         = goldap.LDAPResultInvalidDNSyntax
+	ResultInvalidCredentials      = goldap.LDAPResultInvalidCredentials
+	ResultInsufficientAccess      = goldap.LDAPResultInsufficientAccessRights
+	ResultUnavailable             = goldap.LDAPResultUnavailable
+	ResultUnwillingToPerform      = goldap.LDAPResultUnwillingToPerform
+)
+
+// DefaultPort and DefaultTLSPort are used when the URL does not specify a port
+const (
+	DefaultPort    = goldap.DefaultLdapPort
+	DefaultTLSPort = goldap.DefaultLdapsPort
+)
+
+// Scope of a search request
+type Scope int
+
+const (
+	ScopeBaseObject   Scope = goldap.ScopeBaseObject
+	ScopeSingleLevel  Scope = goldap.ScopeSingleLevel
+	ScopeWholeSubtree Scope = goldap.ScopeWholeSubtree
+)
+
+// IsResultCode reports whether err is an LDAP error with the given result code
+func IsResultCode(err error, code uint16) bool {
+	return goldap.IsErrorWithCode(err, code)
+}
+
+// SearchRequest describes a search operation
+type SearchRequest struct {
+	BaseDN     string
+	Scope      Scope
+	Filter     string
+	Attributes []string
+	// SizeLimit is the maximum number of entries the server should return (0: no limit)
+	SizeLimit int
+	// PageSize requests the results in pages of this size using the paged results control (0: no paging)
+	PageSize int
+}
+
+// Entry is a search result entry
+type Entry struct {
+	DN         string
+	Attributes []*EntryAttribute
+}
+
+// EntryAttribute is an attribute of an entry with its values
+type EntryAttribute struct {
+	Name   string
+	Values []string
+}
+
+// Values returns the values of the named attribute (case-insensitive), or nil
+func (e *Entry) Values(name string) []string {
+	for _, a := range e.Attributes {
+		if strings.EqualFold(a.Name, name) {
+			return a.Values
+		}
+	}
+	return nil
+}
+
+// Value returns the first value of the named attribute, or an empty string
+func (e *Entry) Value(name string) string {
+	if v := e.Values(name); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Conn is a connection to an LDAP server. Operations are executed one at a time.
+type Conn struct {
+	mu     sync.Mutex
+	conn   *goldap.Conn
+	host   string
+	closed bool
+}
+
+// Dial connects to the server at rawURL (ldap://host[:port] or ldaps://host[:port]).
+// tlsConfig is used for ldaps:// and may be nil; its ServerName defaults to the URL host.
+func Dial(ctx context.Context, rawURL string, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid URL %q: %w", rawURL, err)
+	}
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		return nil, fmt.Errorf("ldap: URL %q has no host", rawURL)
+	}
+
+	var dialer net.Dialer
+	var conn net.Conn
+	switch strings.ToLower(u.Scheme) {
+	case "ldap":
+		if port == "" {
+			port = DefaultPort
+		}
+		if conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port)); err != nil {
+			return nil, err
+		}
+	case "ldaps":
+		if port == "" {
+			port = DefaultTLSPort
+		}
+		raw, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(raw, clientTLSConfig(tlsConfig, host))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = raw.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	default:
+		return nil, fmt.Errorf("ldap: unsupported URL scheme %q (expected ldap or ldaps)", u.Scheme)
+	}
+
+	lc := goldap.NewConn(conn, strings.EqualFold(u.Scheme, "ldaps"))
+	lc.Start()
+	return &Conn{conn: lc, host: host}, nil
+}
+
+func clientTLSConfig(cfg *tls.Config, host string) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		cfg = cfg.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	return cfg
+}
+
+// TLS reports whether the connection is encrypted
+func (c *Conn) TLS() bool {
+	_, ok := c.conn.TLSConnectionState()
+	return ok
+}
+
+// Close sends an unbind request and closes the connection
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if err := c.conn.Unbind(); err != nil {
+		// the connection is broken already; release it without the unbind
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// StartTLS upgrades the connection with the StartTLS extended operation
+func (c *Conn) StartTLS(ctx context.Context, tlsConfig *tls.Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.do(ctx, func() error {
+		return c.conn.StartTLS(clientTLSConfig(tlsConfig, c.host))
+	})
+}
+
+// Bind authenticates with a simple bind. An empty dn and password performs an anonymous bind;
+// a dn with an empty password is rejected because servers treat it as an unauthenticated bind.
+func (c *Conn) Bind(ctx context.Context, dn, password string) error {
+	if dn != "" && password == "" {
+		return errors.New("ldap: refusing unauthenticated bind with empty password")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.do(ctx, func() error {
+		_, err := c.conn.SimpleBind(&goldap.SimpleBindRequest{Username: dn, Password: password, AllowEmptyPassword: dn == ""})
+		return err
+	})
+}
+
+// Search runs a search and returns all entries. When PageSize is set the results are
+// requested page by page with the paged results control.
+func (c *Conn) Search(ctx context.Context, req *SearchRequest) ([]*Entry, error) {
+	filter := normalizeFilter(req.Filter)
+	if err := checkFilterNesting(filter); err != nil {
+		return nil, err
+	}
+	if _, err := goldap.CompileFilter(filter); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	search := goldap.NewSearchRequest(req.BaseDN, int(req.Scope), goldap.NeverDerefAliases,
+		req.SizeLimit, 0, false, filter, req.Attributes, nil) // no time limit; bounded by the context
+	var paging *goldap.ControlPaging
+	if req.PageSize > 0 {
+		paging = goldap.NewControlPaging(uint32(req.PageSize))
+		search.Controls = []goldap.Control{paging}
+	}
+
+	var entries []*Entry
+	for {
+		var result *goldap.SearchResult
+		err := c.do(ctx, func() (err error) {
+			result, err = c.conn.Search(search)
+			return err
+		})
+		if result != nil {
+			for _, e := range result.Entries {
+				entries = append(entries, convertEntry(e))
+			}
+		}
+		if err != nil {
+			return entries, err
+		}
+		if paging == nil {
+			return entries, nil
+		}
+		control, ok := goldap.FindControl(result.Controls, goldap.ControlTypePaging).(*goldap.ControlPaging)
+		if !ok || len(control.Cookie) == 0 {
+			return entries, nil
+		}
+		if req.SizeLimit > 0 && len(entries) >= req.SizeLimit {
+			return entries, goldap.NewError(ResultSizeLimitExceeded, errors.New("size limit exceeded"))
+		}
+		paging.SetCookie(control.Cookie)
+	}
+}
+
+func convertEntry(e *goldap.Entry) *Entry {
+	entry := &Entry{DN: e.DN}
+	for _, a := range e.Attributes {
+		entry.Attributes = append(entry.Attributes, &EntryAttribute{Name: a.Name, Values: a.Values})
+	}
+	return entry
+}
+
+// do runs an operation, closing the connection when the context is done first. go-ldap operations
+// do not take a context, and a connection with an abandoned request can no longer be used.
+// The caller must hold c.mu.
+func (c *Conn) do(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.closed {
+		return errors.New("ldap: connection closed")
+	}
+	done := make(chan struct{})
+	abandoned := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-done:
+				// the operation completed at the same time
+				abandoned <- false
+				return
+			default:
+			}
+			_ = c.conn.Close()
+			abandoned <- true
+		case <-done:
+			abandoned <- false
+		}
+	}()
+	err := op()
+	close(done)
+	if <-abandoned {
+		c.closed = true
+		if err == nil {
+			// the operation completed before the connection was closed; its result is valid
+			return nil
+		}
+		return ctx.Err()
+	}
+	if err != nil && goldap.IsErrorWithCode(err, goldap.ErrorNetwork) {
+		// responses can no longer be matched to requests
+		_ = c.conn.Close()
+		c.closed = true
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+package ldap_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/telekom/k8s-breakglass/pkg/ldap"
+	"github.com/telekom/k8s-breakglass/pkg/ldap/ldaptest"
+)
+
+const (
+	bindDN   = "cn=svc-breakglass,ou=services,dc=example,dc=com"
+	password = "s3cret"
+)
+
+func newDirectory(t *testing.T) *ldaptest.Server {
+	t.Helper()
+	srv := ldaptest.NewServer(bindDN, password)
+	t.Cleanup(srv.Close)
+	srv.AddEntry("dc=example,dc=com", map[string][]string{"objectClass": {"domain"}})
+	srv.AddEntry("cn=ops,ou=groups,dc=example,dc=com", map[string][]string{"objectClass": {"group"}, "cn": {"ops"}})
+	for i := 0; i < 7; i++ {
+		srv.AddEntry(fmt.Sprintf("uid=user%d,ou=people,dc=example,dc=com", i), map[string][]string{
+			"objectClass": {"person"},
+			"uid":         {fmt.Sprintf("user%d", i)},
+			"mail":        {fmt.Sprintf("user%d@example.com", i)},
+			"memberOf":    {"cn=ops,ou=groups,dc=example,dc=com"},
+		})
+	}
+	return srv
+}
+
+func dial(t *testing.T, url string) *ldap.Conn {
+	t.Helper()
+	conn, err := ldap.Dial(context.Background(), url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestBind(t *testing.T) {
+	srv := newDirectory(t)
+	ctx := context.Background()
+
+	conn := dial(t, srv.URL)
+	err := conn.Bind(ctx, bindDN, "wrong")
+	assert.True(t, ldap.IsResultCode(err, ldap.ResultInvalidCredentials), "unexpected error: %v", err)
+	require.NoError(t, conn.Bind(ctx, bindDN, password))
+
+	// An empty password would be an unauthenticated bind and is refused client side
+	assert.Error(t, conn.Bind(ctx, bindDN, ""))
+}
+
+func TestSearchPaged(t *testing.T) {
+	srv := newDirectory(t)
+	ctx := context.Background()
+	conn := dial(t, srv.URL)
+	require.NoError(t, conn.Bind(ctx, bindDN, password))
+
+	entries, err := conn.Search(ctx, &ldap.SearchRequest{
+		BaseDN:     "ou=people,dc=example,dc=com",
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     "(&(objectClass=person)(memberOf=cn=ops,ou=groups,dc=example,dc=com))",
+		Attributes: []string{"mail"},
+		PageSize:   3,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+	assert.Equal(t, "user0@example.com", entries[0].Value("MAIL"))
+	assert.Empty(t, entries[0].Value("uid"), "only requested attributes are returned")
+	assert.Len(t, srv.Searches(), 3, "7 entries in pages of 3")
+
+	// The connection stays usable after a paged search
+	entries, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(cn=ops)"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "cn=ops,ou=groups,dc=example,dc=com", entries[0].DN)
+}
+
+func TestSearchErrors(t *testing.T) {
+	srv := newDirectory(t)
+	ctx := context.Background()
+	conn := dial(t, srv.URL)
+
+	_, err := conn.Search(ctx, &ldap.SearchRequest{BaseDN: "dc=example,dc=com", Filter: "(uid=*)"})
+	assert.True(t, ldap.IsResultCode(err, ldap.ResultInsufficientAccess), "search before bind: %v", err)
+
+	require.NoError(t, conn.Bind(ctx, bindDN, password))
+	_, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "ou=missing,dc=example,dc=com", Filter: "(uid=*)"})
+	assert.True(t, ldap.IsResultCode(err, ldap.ResultNoSuchObject), "unknown base DN: %v", err)
+
+	_, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "dc=example,dc=com", Filter: "(uid=*"})
+	assert.Error(t, err)
+
+	entries, err := conn.Search(ctx, &ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=*)", SizeLimit: 2})
+	assert.True(t, ldap.IsResultCode(err, ldap.ResultSizeLimitExceeded), "size limit: %v", err)
+	assert.Len(t, entries, 2)
+}
+
+func TestStartTLS(t *testing.T) {
+	cert, pool := selfSignedCertificate(t)
+	srv := ldaptest.NewUnstartedServer()
+	srv.Credentials[bindDN] = password
+	srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	srv.RequireTLS = true
+	srv.Start()
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	plain := dial(t, srv.URL)
+	err := plain.Bind(ctx, bindDN, password)
+	assert.True(t, ldap.IsResultCode(err, ldap.ResultConfidentialityRequired), "bind without TLS: %v", err)
+
+	untrusted := dial(t, srv.URL)
+	assert.Error(t, untrusted.StartTLS(ctx, nil), "server certificate is not trusted")
+
+	conn := dial(t, srv.URL)
+	require.NoError(t, conn.StartTLS(ctx, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}))
+	assert.True(t, conn.TLS())
+	require.NoError(t, conn.Bind(ctx, bindDN, password))
+	assert.Error(t, conn.StartTLS(ctx, nil), "already encrypted")
+}
+
+func TestDialErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, url := range []string{"http://localhost", "ldap://", "://bad"} {
+		_, err := ldap.Dial(ctx, url, nil)
+		assert.Error(t, err, url)
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	// A server that accepts connections but never answers
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = c.Close() })
+		}
+	}()
+
+	conn := dial(t, "ldap://"+l.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = conn.Bind(ctx, bindDN, password)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Error(t, conn.Bind(context.Background(), bindDN, password), "connection is closed after a timeout")
+}
+
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
@@ -0,0 +1,74 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ConnectOptions describes how to reach and authenticate to a directory
+type ConnectOptions struct {
+	URL      string
+	StartTLS bool
+	BindDN   string
+	Password string
+	// CertificateAuthority is a PEM encoded CA bundle; the system roots are used when empty
+	CertificateAuthority string
+	// InsecureSkipVerify disables server certificate verification (testing only)
+	InsecureSkipVerify bool
+}
+
+// BindError wraps a failed bind so callers can tell authentication failures from connection failures
+type BindError struct {
+	Err error
+}
+
+func (e *BindError) Error() string { return "ldap: bind failed: " + e.Err.Error() }
+func (e *BindError) Unwrap() error { return e.Err }
+
+// IsBindError reports whether err is a failed bind
+func IsBindError(err error) bool {
+	var bindErr *BindError
+	return errors.As(err, &bindErr)
+}
+
+// Connect dials the server, negotiates StartTLS if requested and binds
+func Connect(ctx context.Context, opts ConnectOptions) (*Conn, error) {
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := Dial(ctx, opts.URL, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if opts.StartTLS {
+		if err := conn.StartTLS(ctx, tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if err := conn.Bind(ctx, opts.BindDN, opts.Password); err != nil {
+		_ = conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &BindError{Err: err}
+	}
+	return conn, nil
+}
+
+func (opts ConnectOptions) tlsConfig() (*tls.Config, error) {
+	// InsecureSkipVerify is an explicit opt-in for test environments
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CertificateAuthority != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CertificateAuthority)) {
+			return nil, fmt.Errorf("ldap: certificateAuthority contains no valid PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
@@ -0,0 +1,55 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// MatchingRuleInChain is the Active Directory matching rule (LDAP_MATCHING_RULE_IN_CHAIN) that
+// walks nested group memberships on the server, e.g. (memberOf:1.2.840.113556.1.4.1941:=<group DN>)
+const MatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// EscapeFilter escapes a value for use in a string filter (RFC 4515, section 3)
+func EscapeFilter(s string) string {
+	return goldap.EscapeFilter(s)
+}
+
+// normalizeFilter accepts a filter without surrounding parentheses, e.g. "objectClass=group"
+func normalizeFilter(s string) string {
+	s = strings.TrimSpace(s)
+	if s != "" && s[0] != '(' {
+		s = "(" + s + ")"
+	}
+	return s
+}
+
+// checkFilterNesting rejects filters whose parentheses do not nest like RFC 4515 requires.
+// go-ldap's compiler takes an unescaped parenthesis inside a value as part of the value, so
+// "(&(objectClass=person(cn=a))" would otherwise silently become a different filter.
+func checkFilterNesting(s string) error {
+	// composite[i] reports whether the i-th open parenthesis starts an and, or or not filter
+	var composite []bool
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			if n := len(composite); n > 0 && !composite[n-1] {
+				return fmt.Errorf("ldap: unescaped '(' in filter value at position %d", i)
+			}
+			composite = append(composite, i+1 < len(s) && strings.IndexByte("&|!", s[i+1]) >= 0)
+		case ')':
+			if len(composite) == 0 {
+				return fmt.Errorf("ldap: unbalanced ')' in filter at position %d", i)
+			}
+			composite = composite[:len(composite)-1]
+			if len(composite) == 0 && i != len(s)-1 {
+				return fmt.Errorf("ldap: unexpected %q after filter at position %d", s[i+1:], i+1)
+			}
+		}
+	}
+	if len(composite) > 0 {
+		return fmt.Errorf("ldap: unterminated filter")
+	}
+	return nil
+}
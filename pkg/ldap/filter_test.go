@@ -0,0 +1,44 @@
+package ldap
+
+import (
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeFilter(t *testing.T) {
+	assert.Equal(t, "(objectClass=group)", normalizeFilter("objectClass=group"))
+	assert.Equal(t, "(objectClass=group)", normalizeFilter(" (objectClass=group) "))
+	assert.Equal(t, "", normalizeFilter(""))
+}
+
+func TestEscapeFilter(t *testing.T) {
+	assert.Equal(t, `admins`, EscapeFilter("admins"))
+	assert.Equal(t, `a\2ab\28c\29d\5ce\00`, EscapeFilter("a*b(c)d\\e\x00"))
+
+	p, err := goldap.CompileFilter("(cn=" + EscapeFilter("ops*(prod)") + ")")
+	require.NoError(t, err)
+	assert.EqualValues(t, goldap.FilterEqualityMatch, p.Tag)
+	assert.Equal(t, "ops*(prod)", p.Children[1].Data.String())
+}
+
+func TestCheckFilterNesting(t *testing.T) {
+	for _, in := range []string{
+		"(cn=admins)",
+		"(&(objectClass=group)(!(cn=a\\28b\\29)))",
+		"(|(cn=a)(&(cn=b)(cn=c)))",
+	} {
+		assert.NoError(t, checkFilterNesting(in), in)
+	}
+	for _, in := range []string{
+		"(cn=admins",
+		"(cn=admins))",
+		"(cn=a)(cn=b)",
+		"(&(objectClass=person(cn=a)))",
+		"(cn=a(b))",
+	} {
+		assert.Error(t, checkFilterNesting(in), in)
+	}
+}
@@ -0,0 +1,601 @@
+// Package ldaptest provides an in-process LDAP server for tests. It keeps its directory in memory
+// and supports simple bind, StartTLS, search with paging and the Active Directory in-chain matching rule.
+package ldaptest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/telekom/k8s-breakglass/pkg/ldap"
+)
+
+// Server is an in-memory LDAP server listening on a loopback address
+type Server struct {
+	// URL of the server, e.g. ldap://127.0.0.1:38261
+	URL string
+
+	// Credentials maps bind DNs to passwords. Anonymous binds are always rejected.
+	Credentials map[string]string
+	// TLSConfig enables the StartTLS extended operation when set
+	TLSConfig *tls.Config
+	// RequireTLS rejects binds and searches on connections that did not negotiate StartTLS
+	RequireTLS bool
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	entries  []*ldap.Entry
+	searches []string
+	conns    map[net.Conn]struct{}
+}
+
+// NewUnstartedServer returns a server that is not listening yet; configure it and call Start
+func NewUnstartedServer() *Server {
+	return &Server{Credentials: map[string]string{}, conns: map[net.Conn]struct{}{}}
+}
+
+// NewServer starts a server with the given bind credentials
+func NewServer(bindDN, password string) *Server {
+	s := NewUnstartedServer()
+	s.Credentials[bindDN] = password
+	s.Start()
+	return s
+}
+
+// Start starts listening on a random loopback port
+func (s *Server) Start() {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("ldaptest: failed to listen: %v", err))
+	}
+	s.listener = l
+	s.URL = "ldap://" + l.Addr().String()
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// Close stops the server and closes all connections
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// AddEntry adds an entry to the directory
+func (s *Server) AddEntry(dn string, attrs map[string][]string) {
+	entry := &ldap.Entry{DN: dn}
+	for name, values := range attrs {
+		entry.Attributes = append(entry.Attributes, &ldap.EntryAttribute{Name: name, Values: values})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+// Searches returns the filters of all search requests received so far, one per page
+func (s *Server) Searches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.searches...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+type session struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	isTLS  bool
+	bound  bool
+	cursor map[string]int // paged search cookie -> offset
+}
+
+// LDAP message protocol operations (RFC 4511, section 4.2 ff.)
+const (
+	opBindRequest     = 0
+	opBindResponse    = 1
+	opUnbindRequest   = 2
+	opSearchRequest   = 3
+	opSearchEntry     = 4
+	opSearchDone      = 5
+	opExtendedRequest = 23
+	opExtendedResp    = 24
+)
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{conn: conn, r: bufio.NewReader(conn), cursor: map[string]int{}}
+	for {
+		p, err := ber.ReadPacket(sess.r)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id, ok := p.Children[0].Value.(int64)
+		op := p.Children[1]
+		if !ok || op.ClassType != ber.ClassApplication {
+			return
+		}
+		switch op.Tag {
+		case opBindRequest:
+			s.bind(sess, id, op)
+		case opUnbindRequest:
+			return
+		case opSearchRequest:
+			var controls *ber.Packet
+			if len(p.Children) > 2 {
+				controls = p.Children[2]
+			}
+			s.search(sess, id, op, controls)
+		case opExtendedRequest:
+			if !s.extended(sess, id, op) {
+				return
+			}
+		default:
+			s.reply(sess, id, result(opExtendedResp, ldap.ResultProtocolError, "unsupported operation"))
+			return
+		}
+	}
+}
+
+func (s *Server) reply(sess *session, id int64, op *ber.Packet, controls ...*ber.Packet) {
+	msg := ber.NewSequence("LDAP Message")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	msg.AppendChild(op)
+	if len(controls) > 0 {
+		list := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+		for _, c := range controls {
+			list.AppendChild(c)
+		}
+		msg.AppendChild(list)
+	}
+	_, _ = sess.conn.Write(msg.Bytes())
+}
+
+func octetString(s string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, s, "")
+}
+
+// str returns the contents of a primitive packet, which the decoder only types for universal tags
+func str(p *ber.Packet) string {
+	if p == nil {
+		return ""
+	}
+	if s, ok := p.Value.(string); ok {
+		return s
+	}
+	return p.Data.String()
+}
+
+func integer(p *ber.Packet) int64 {
+	if p == nil {
+		return 0
+	}
+	n, _ := p.Value.(int64)
+	return n
+}
+
+func child(p *ber.Packet, i int) *ber.Packet {
+	if p == nil || i >= len(p.Children) {
+		return nil
+	}
+	return p.Children[i]
+}
+
+func result(tag ber.Tag, code uint16, message string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	p.AppendChild(octetString(""))
+	p.AppendChild(octetString(message))
+	return p
+}
+
+func (s *Server) bind(sess *session, id int64, op *ber.Packet) {
+	if s.RequireTLS && !sess.isTLS {
+		s.reply(sess, id, result(opBindResponse, ldap.ResultConfidentialityRequired, "TLS required"))
+		return
+	}
+	dn, password := str(child(op, 1)), str(child(op, 2))
+	s.mu.Lock()
+	expected, ok := s.Credentials[dn]
+	s.mu.Unlock()
+	if dn == "" || !ok || expected != password {
+		sess.bound = false
+		s.reply(sess, id, result(opBindResponse, ldap.ResultInvalidCredentials, "invalid credentials"))
+		return
+	}
+	sess.bound = true
+	s.reply(sess, id, result(opBindResponse, ldap.ResultSuccess, ""))
+}
+
+func (s *Server) extended(sess *session, id int64, op *ber.Packet) bool {
+	if str(child(op, 0)) != ldap.OIDStartTLS {
+		s.reply(sess, id, result(opExtendedResp, ldap.ResultProtocolError, "unsupported extended operation"))
+		return true
+	}
+	if s.TLSConfig == nil || sess.isTLS {
+		s.reply(sess, id, result(opExtendedResp, ldap.ResultUnavailable, "StartTLS not available"))
+		return true
+	}
+	s.reply(sess, id, result(opExtendedResp, ldap.ResultSuccess, ""))
+	tlsConn := tls.Server(sess.conn, s.TLSConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return false
+	}
+	s.mu.Lock()
+	delete(s.conns, sess.conn)
+	s.conns[tlsConn] = struct{}{}
+	s.mu.Unlock()
+	sess.conn = tlsConn
+	sess.r = bufio.NewReader(tlsConn)
+	sess.isTLS = true
+	return true
+}
+
+func (s *Server) search(sess *session, id int64, op, controls *ber.Packet) {
+	if !sess.bound || (s.RequireTLS && !sess.isTLS) {
+		s.reply(sess, id, result(opSearchDone, ldap.ResultInsufficientAccess, "bind required"))
+		return
+	}
+	baseDN := str(child(op, 0))
+	scope := integer(child(op, 1))
+	sizeLimit := integer(child(op, 3))
+	f, err := decodeFilter(child(op, 6))
+	if err != nil {
+		s.reply(sess, id, result(opSearchDone, ldap.ResultProtocolError, err.Error()))
+		return
+	}
+	filterString, err := goldap.DecompileFilter(child(op, 6))
+	if err != nil {
+		s.reply(sess, id, result(opSearchDone, ldap.ResultProtocolError, err.Error()))
+		return
+	}
+	var attrs []string
+	if list := child(op, 7); list != nil {
+		for _, a := range list.Children {
+			attrs = append(attrs, str(a))
+		}
+	}
+
+	s.mu.Lock()
+	s.searches = append(s.searches, filterString)
+	if !s.exists(baseDN) {
+		s.mu.Unlock()
+		s.reply(sess, id, result(opSearchDone, ldap.ResultNoSuchObject, "no such object"))
+		return
+	}
+	var matches []*ldap.Entry
+	for _, e := range s.entries {
+		if inScope(e.DN, baseDN, ldap.Scope(scope)) && s.match(e, f) {
+			matches = append(matches, e)
+		}
+	}
+	s.mu.Unlock()
+
+	// Paged results control
+	pageSize, cookie := 0, ""
+	if controls != nil {
+		for _, c := range controls.Children {
+			if str(child(c, 0)) != ldap.OIDPagedResults || len(c.Children) < 2 {
+				continue
+			}
+			value, err := ber.DecodePacketErr(c.Children[len(c.Children)-1].Data.Bytes())
+			if err == nil {
+				pageSize, cookie = int(integer(child(value, 0))), str(child(value, 1))
+			}
+		}
+	}
+	offset := 0
+	if cookie != "" {
+		o, ok := sess.cursor[cookie]
+		if !ok {
+			s.reply(sess, id, result(opSearchDone, ldap.ResultUnwillingToPerform, "invalid cookie"))
+			return
+		}
+		delete(sess.cursor, cookie)
+		offset = o
+	}
+	page := matches[min(offset, len(matches)):]
+	if pageSize > 0 && len(page) > pageSize {
+		page = page[:pageSize]
+	}
+	if sizeLimit > 0 && len(page) > int(sizeLimit) {
+		page = page[:sizeLimit]
+		for _, e := range page {
+			s.reply(sess, id, encodeEntry(e, attrs))
+		}
+		s.reply(sess, id, result(opSearchDone, ldap.ResultSizeLimitExceeded, "size limit exceeded"))
+		return
+	}
+	for _, e := range page {
+		s.reply(sess, id, encodeEntry(e, attrs))
+	}
+	if pageSize <= 0 {
+		s.reply(sess, id, result(opSearchDone, ldap.ResultSuccess, ""))
+		return
+	}
+	next := ""
+	if offset+len(page) < len(matches) {
+		next = fmt.Sprintf("%d-%d", id, offset+len(page))
+		sess.cursor[next] = offset + len(page)
+	}
+	value := ber.NewSequence("Paging")
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(len(matches)), "Size"))
+	value.AppendChild(octetString(next))
+	control := ber.NewSequence("Control")
+	control.AppendChild(octetString(ldap.OIDPagedResults))
+	control.AppendChild(octetString(string(value.Bytes())))
+	s.reply(sess, id, result(opSearchDone, ldap.ResultSuccess, ""), control)
+}
+
+func encodeEntry(e *ldap.Entry, attrs []string) *ber.Packet {
+	list := ber.NewSequence("Attributes")
+	for _, a := range e.Attributes {
+		if !wanted(a.Name, attrs) {
+			continue
+		}
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range a.Values {
+			vals.AppendChild(octetString(v))
+		}
+		attr := ber.NewSequence("Attribute")
+		attr.AppendChild(octetString(a.Name))
+		attr.AppendChild(vals)
+		list.AppendChild(attr)
+	}
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, opSearchEntry, nil, "Search Result Entry")
+	p.AppendChild(octetString(e.DN))
+	p.AppendChild(list)
+	return p
+}
+
+func wanted(name string, attrs []string) bool {
+	if len(attrs) == 0 {
+		return true
+	}
+	for _, a := range attrs {
+		if a == "*" || strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ",")
+}
+
+func inScope(dn, base string, scope ldap.Scope) bool {
+	dn, base = normalizeDN(dn), normalizeDN(base)
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return dn == base
+	case ldap.ScopeSingleLevel:
+		i := strings.Index(dn, ",")
+		return i >= 0 && dn[i+1:] == base
+	default:
+		return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+	}
+}
+
+// find returns the entry with the given DN; the caller must hold s.mu
+func (s *Server) find(dn string) *ldap.Entry {
+	if dn == "" {
+		return &ldap.Entry{}
+	}
+	n := normalizeDN(dn)
+	for _, e := range s.entries {
+		if normalizeDN(e.DN) == n {
+			return e
+		}
+	}
+	return nil
+}
+
+// exists reports whether the DN is an entry or the parent of one, so tests need not add
+// every organizational unit; the caller must hold s.mu
+func (s *Server) exists(dn string) bool {
+	for _, e := range s.entries {
+		if inScope(e.DN, dn, ldap.ScopeWholeSubtree) {
+			return true
+		}
+	}
+	return dn == ""
+}
+
+// filter is a decoded search filter (RFC 4511, section 4.5.1.7)
+type filter struct {
+	tag      ber.Tag
+	children []*filter // and, or, not
+
+	attribute string
+	value     string
+
+	// substrings
+	initial string
+	any     []string
+	final   string
+
+	// extensible match
+	matchingRule string
+}
+
+func decodeFilter(p *ber.Packet) (*filter, error) {
+	if p == nil || p.ClassType != ber.ClassContext {
+		return nil, fmt.Errorf("ldaptest: invalid filter")
+	}
+	f := &filter{tag: p.Tag}
+	switch p.Tag {
+	case goldap.FilterAnd, goldap.FilterOr, goldap.FilterNot:
+		for _, c := range p.Children {
+			cf, err := decodeFilter(c)
+			if err != nil {
+				return nil, err
+			}
+			f.children = append(f.children, cf)
+		}
+		if p.Tag == goldap.FilterNot && len(f.children) != 1 {
+			return nil, fmt.Errorf("ldaptest: invalid not filter")
+		}
+	case goldap.FilterEqualityMatch, goldap.FilterGreaterOrEqual, goldap.FilterLessOrEqual, goldap.FilterApproxMatch:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("ldaptest: invalid attribute value assertion")
+		}
+		f.attribute, f.value = str(p.Children[0]), str(p.Children[1])
+	case goldap.FilterSubstrings:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("ldaptest: invalid substrings filter")
+		}
+		f.attribute = str(p.Children[0])
+		for _, c := range p.Children[1].Children {
+			switch c.Tag {
+			case goldap.FilterSubstringsInitial:
+				f.initial = str(c)
+			case goldap.FilterSubstringsAny:
+				f.any = append(f.any, str(c))
+			case goldap.FilterSubstringsFinal:
+				f.final = str(c)
+			}
+		}
+	case goldap.FilterPresent:
+		f.attribute = str(p)
+	case goldap.FilterExtensibleMatch:
+		for _, c := range p.Children {
+			switch c.Tag {
+			case 1:
+				f.matchingRule = str(c)
+			case 2:
+				f.attribute = str(c)
+			case 3:
+				f.value = str(c)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("ldaptest: unsupported filter tag %d", p.Tag)
+	}
+	return f, nil
+}
+
+// match evaluates a filter with case-insensitive matching; the caller must hold s.mu
+func (s *Server) match(e *ldap.Entry, f *filter) bool {
+	switch f.tag {
+	case goldap.FilterAnd:
+		for _, c := range f.children {
+			if !s.match(e, c) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, c := range f.children {
+			if s.match(e, c) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !s.match(e, f.children[0])
+	case goldap.FilterPresent:
+		return strings.EqualFold(f.attribute, "objectClass") || len(e.Values(f.attribute)) > 0
+	case goldap.FilterEqualityMatch, goldap.FilterApproxMatch:
+		return hasValue(e, f.attribute, f.value)
+	case goldap.FilterSubstrings:
+		for _, v := range e.Values(f.attribute) {
+			if matchSubstrings(strings.ToLower(v), f) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterGreaterOrEqual, goldap.FilterLessOrEqual:
+		for _, v := range e.Values(f.attribute) {
+			c := strings.Compare(strings.ToLower(v), strings.ToLower(f.value))
+			if (f.tag == goldap.FilterGreaterOrEqual && c >= 0) || (f.tag == goldap.FilterLessOrEqual && c <= 0) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterExtensibleMatch:
+		if f.matchingRule == ldap.MatchingRuleInChain {
+			return s.inChain(e, f.attribute, normalizeDN(f.value), map[string]bool{})
+		}
+		return hasValue(e, f.attribute, f.value)
+	}
+	return false
+}
+
+func hasValue(e *ldap.Entry, attr, value string) bool {
+	for _, v := range e.Values(attr) {
+		if strings.EqualFold(v, value) || (strings.Contains(v, "=") && normalizeDN(v) == normalizeDN(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSubstrings(v string, f *filter) bool {
+	if f.initial != "" {
+		if !strings.HasPrefix(v, strings.ToLower(f.initial)) {
+			return false
+		}
+		v = v[len(f.initial):]
+	}
+	for _, sub := range f.any {
+		i := strings.Index(v, strings.ToLower(sub))
+		if i < 0 {
+			return false
+		}
+		v = v[i+len(sub):]
+	}
+	return f.final == "" || strings.HasSuffix(v, strings.ToLower(f.final))
+}
+
+// inChain follows the DN-valued attribute transitively, like LDAP_MATCHING_RULE_IN_CHAIN
+func (s *Server) inChain(e *ldap.Entry, attr, target string, visited map[string]bool) bool {
+	for _, v := range e.Values(attr) {
+		dn := normalizeDN(v)
+		if dn == target {
+			return true
+		}
+		if visited[dn] {
+			continue
+		}
+		visited[dn] = true
+		if next := s.find(v); next != nil && s.inChain(next, attr, target, visited) {
+			return true
+		}
+	}
+	return false
+}
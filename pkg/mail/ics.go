@@ -0,0 +1,156 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// iCalendar methods used for breakglass access windows (RFC 5546)
+const (
+	CalendarMethodPublish = "PUBLISH"
+	CalendarMethodRequest = "REQUEST"
+	CalendarMethodCancel  = "CANCEL"
+)
+
+// Calendar event sequence numbers. Clients replace an event when they receive the same UID
+// with a higher SEQUENCE, so each lifecycle stage of a session uses a fixed, increasing value.
+const (
+	CalendarSequenceRequested = 0
+	CalendarSequenceApproved  = 1
+	CalendarSequenceCancelled = 2
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// CalendarEvent describes a breakglass access window as an iCalendar event
+type CalendarEvent struct {
+	// UID identifies the event across updates and cancellations (stable per session)
+	UID         string
+	Sequence    int
+	Method      string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Organizer   string
+	OrganizerCN string
+	Attendees   []string
+	// Stamp is the creation time of this revision of the event (defaults to now)
+	Stamp time.Time
+}
+
+// SessionEventUID returns the calendar event UID for a breakglass session
+func SessionEventUID(sessionName string) string {
+	return sessionName + "@breakglass"
+}
+
+// ContentType returns the MIME content type for the event including its method
+func (e CalendarEvent) ContentType() string {
+	return fmt.Sprintf("text/calendar; method=%s", e.method())
+}
+
+// ICS renders the event as an iCalendar (RFC 5545) object
+func (e CalendarEvent) ICS() string {
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	status := "CONFIRMED"
+	switch {
+	case e.method() == CalendarMethodCancel:
+		status = "CANCELLED"
+	case e.Sequence == CalendarSequenceRequested:
+		status = "TENTATIVE"
+	}
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICSLine(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//telekom//k8s-breakglass//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:" + e.method())
+	line("BEGIN:VEVENT")
+	line("UID:" + escapeICSText(e.UID))
+	line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	line("DTSTAMP:" + stamp.UTC().Format(icsTimeFormat))
+	line("DTSTART:" + e.Start.UTC().Format(icsTimeFormat))
+	line("DTEND:" + e.End.UTC().Format(icsTimeFormat))
+	line("SUMMARY:" + escapeICSText(e.Summary))
+	if e.Description != "" {
+		line("DESCRIPTION:" + escapeICSText(e.Description))
+	}
+	if e.Location != "" {
+		line("LOCATION:" + escapeICSText(e.Location))
+	}
+	if e.URL != "" {
+		line("URL:" + e.URL)
+	}
+	if e.Organizer != "" {
+		if e.OrganizerCN != "" {
+			line(fmt.Sprintf("ORGANIZER;CN=%s:mailto:%s", quoteICSParam(e.OrganizerCN), e.Organizer))
+		} else {
+			line("ORGANIZER:mailto:" + e.Organizer)
+		}
+	}
+	for _, attendee := range e.Attendees {
+		line("ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=FALSE:mailto:" + attendee)
+	}
+	line("STATUS:" + status)
+	line("TRANSP:TRANSPARENT")
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+
+	return b.String()
+}
+
+func (e CalendarEvent) method() string {
+	if e.Method == "" {
+		return CalendarMethodRequest
+	}
+	return e.Method
+}
+
+// escapeICSText escapes TEXT values as defined in RFC 5545 section 3.3.11
+func escapeICSText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// quoteICSParam quotes a parameter value if it contains characters that are not allowed unquoted
+func quoteICSParam(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// foldICSLine folds content lines longer than 75 octets (RFC 5545 section 3.1)
+// without splitting multi-byte UTF-8 sequences
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range s {
+		size := len(string(r))
+		if lineLen+size > limit {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += size
+	}
+	return b.String()
+}
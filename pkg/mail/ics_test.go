@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleEvent() CalendarEvent {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	return CalendarEvent{
+		UID:         SessionEventUID("session-1"),
+		Sequence:    CalendarSequenceApproved,
+		Method:      CalendarMethodRequest,
+		Summary:     "Breakglass access: admin on prod",
+		Description: "Session session-1, cluster prod; group admin",
+		Location:    "prod",
+		URL:         "https://breakglass.example.com",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Organizer:   "noreply@example.com",
+		OrganizerCN: "Breakglass",
+		Attendees:   []string{"user@example.com"},
+		Stamp:       start.Add(-time.Hour),
+	}
+}
+
+func TestCalendarEvent_ICS(t *testing.T) {
+	ics := sampleEvent().ICS()
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "METHOD:REQUEST\r\n")
+	assert.Contains(t, ics, "UID:session-1@breakglass\r\n")
+	assert.Contains(t, ics, "SEQUENCE:1\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20250101T090000Z\r\n")
+	assert.Contains(t, ics, "DTSTART:20250101T100000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20250101T110000Z\r\n")
+	assert.Contains(t, ics, `DESCRIPTION:Session session-1\, cluster prod\; group admin`)
+	assert.Contains(t, ics, "ORGANIZER;CN=Breakglass:mailto:noreply@example.com\r\n")
+	assert.Contains(t, strings.ReplaceAll(ics, "\r\n ", ""), "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=FALSE:mailto:user@example.com\r\n")
+	assert.Contains(t, ics, "STATUS:CONFIRMED\r\n")
+}
+
+func TestCalendarEvent_Status(t *testing.T) {
+	ev := sampleEvent()
+
+	ev.Method, ev.Sequence = CalendarMethodPublish, CalendarSequenceRequested
+	assert.Contains(t, ev.ICS(), "STATUS:TENTATIVE\r\n")
+	assert.Equal(t, "text/calendar; method=PUBLISH", ev.ContentType())
+
+	ev.Method, ev.Sequence = CalendarMethodCancel, CalendarSequenceCancelled
+	assert.Contains(t, ev.ICS(), "METHOD:CANCEL\r\n")
+	assert.Contains(t, ev.ICS(), "STATUS:CANCELLED\r\n")
+
+	ev.Method = ""
+	assert.Equal(t, "text/calendar; method=REQUEST", ev.ContentType())
+}
+
+func TestCalendarEvent_OrganizerCNQuoted(t *testing.T) {
+	ev := sampleEvent()
+	ev.OrganizerCN = `Ops: "Breakglass"`
+	assert.Contains(t, ev.ICS(), `ORGANIZER;CN="Ops: 'Breakglass'":mailto:noreply@example.com`)
+}
+
+func TestEscapeICSText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne`, escapeICSText("a\\b;c,d\r\ne"))
+}
+
+func TestFoldICSLine(t *testing.T) {
+	short := "SUMMARY:short"
+	assert.Equal(t, short, foldICSLine(short))
+
+	long := "DESCRIPTION:" + strings.Repeat("ä", 60)
+	folded := foldICSLine(long)
+	for _, l := range strings.Split(folded, "\r\n") {
+		assert.LessOrEqual(t, len(l), 75)
+	}
+	// Unfolding restores the original line without breaking multi-byte characters
+	assert.Equal(t, long, strings.ReplaceAll(folded, "\r\n ", ""))
+}
@@ -2,10 +2,11 @@ package mail
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"net/smtp"
 	"time"
 
 	"github.com/telekom/k8s-breakglass/pkg/config"
@@ -21,8 +22,36 @@ type Sender interface {
 	GetPort() int
 }
 
+// Message is a notification email with an HTML body, a plain-text alternative
+// and an optional calendar event for the access window
+type Message struct {
+	Receivers []string
+	Subject   string
+	HTML      string
+	// Text is the plain-text alternative. If empty, it is derived from HTML.
+	Text string
+	// Calendar is attached as an iCalendar (.ics) invite when set
+	Calendar *CalendarEvent
+}
+
+// MessageSender is implemented by senders that can deliver multipart messages.
+// Senders that only implement Sender receive the HTML body via Send.
+type MessageSender interface {
+	SendMessage(msg Message) error
+}
+
+// Deliver sends msg through s, using multipart delivery when supported
+func Deliver(s Sender, msg Message) error {
+	if ms, ok := s.(MessageSender); ok {
+		return ms.SendMessage(msg)
+	}
+	return s.Send(msg.Receivers, msg.Subject, msg.HTML)
+}
+
 type sender struct {
-	dialer         *gomail.Dialer
+	host           string
+	port           int
+	dialer         *config.SMTPDialer
 	senderAddress  string
 	senderName     string
 	retryCount     int
@@ -34,12 +63,12 @@ func NewSenderFromMailProvider(mpConfig *config.MailProviderConfig, brandingName
 	log.Printf("[mail] Initializing mail sender from MailProvider: %s (host: %s, port: %d)",
 		mpConfig.Name, mpConfig.Host, mpConfig.Port)
 
-	d := gomail.NewDialer(mpConfig.Host, mpConfig.Port, mpConfig.Username, mpConfig.Password)
+	d := config.NewSMTPDialer(mpConfig)
 
 	if mpConfig.InsecureSkipVerify {
 		log.Printf("[mail] InsecureSkipVerify is enabled for mail TLS connection")
-		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 	}
+	log.Printf("[mail] SMTP TLS mode: %s, auth mechanism: %s", mpConfig.EffectiveTLSMode(), authMechanismName(mpConfig))
 
 	// Use provider's sender configuration
 	senderAddr := mpConfig.SenderAddress
@@ -68,6 +97,8 @@ func NewSenderFromMailProvider(mpConfig *config.MailProviderConfig, brandingName
 	log.Printf("[mail] Retry configuration: count=%d, initialBackoffMs=%d", retryCount, retryBackoffMs)
 
 	return &sender{
+		host:           mpConfig.Host,
+		port:           mpConfig.Port,
 		dialer:         d,
 		senderAddress:  senderAddr,
 		senderName:     senderName,
@@ -77,6 +108,14 @@ func NewSenderFromMailProvider(mpConfig *config.MailProviderConfig, brandingName
 }
 
 func (s *sender) Send(receivers []string, subject, body string) error {
+	return s.SendMessage(Message{Receivers: receivers, Subject: subject, HTML: body})
+}
+
+// SendMessage sends a multipart/alternative message (plain text and HTML), with the
+// calendar event attached as an .ics invite when present
+func (s *sender) SendMessage(m Message) error {
+	receivers, subject := m.Receivers, m.Subject
+
 	// Validate receivers
 	if len(receivers) == 0 {
 		log.Printf("[mail] ERROR: Send called with no receivers. Subject: %s", subject)
@@ -84,17 +123,13 @@ func (s *sender) Send(receivers []string, subject, body string) error {
 	}
 
 	log.Printf("[mail] Preparing to send mail to %d receivers. Subject: %s", len(receivers), subject)
-	msg := gomail.NewMessage()
-	msg.SetAddressHeader("From", s.senderAddress, s.senderName)
-	msg.SetHeader("Bcc", receivers...)
-	msg.SetHeader("Subject", subject)
-	msg.SetBody("text/html", body)
+	msg := s.buildMessage(m)
 
 	var lastErr error
 	backoffMs := s.retryBackoffMs
 
 	for attempt := 0; attempt <= s.retryCount; attempt++ {
-		err := s.dialer.DialAndSend(msg)
+		err := s.dialAndSend(msg)
 		if err == nil {
 			log.Printf("[mail] Mail sent successfully to %d receivers on attempt %d", len(receivers), attempt+1)
 			metrics.MailSendSuccess.WithLabelValues(s.GetHost()).Inc()
@@ -116,12 +151,103 @@ func (s *sender) Send(receivers []string, subject, body string) error {
 	return lastErr
 }
 
+// buildMessage assembles the MIME message. Alternatives are ordered from least to most
+// preferred: plain text, HTML and, for invites, the text/calendar part understood by mail clients.
+func (s *sender) buildMessage(m Message) *gomail.Message {
+	text := m.Text
+	if text == "" {
+		text = HTMLToText(m.HTML)
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetAddressHeader("From", s.senderAddress, s.senderName)
+	msg.SetHeader("Bcc", m.Receivers...)
+	msg.SetHeader("Subject", m.Subject)
+	msg.SetBody("text/plain", text)
+	msg.AddAlternative("text/html", m.HTML)
+
+	if m.Calendar != nil {
+		event := s.withOrganizer(*m.Calendar)
+		ics := event.ICS()
+		msg.AddAlternative(event.ContentType(), ics)
+		msg.Attach("invite.ics",
+			gomail.SetHeader(map[string][]string{"Content-Type": {event.ContentType() + `; charset=UTF-8; name="invite.ics"`}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := io.WriteString(w, ics)
+				return err
+			}))
+	}
+	return msg
+}
+
+// withOrganizer defaults the event organizer to the configured sender
+func (s *sender) withOrganizer(event CalendarEvent) CalendarEvent {
+	if event.Organizer == "" {
+		event.Organizer, event.OrganizerCN = s.senderAddress, s.senderName
+	}
+	return event
+}
+
+// dialAndSend opens an SMTP session with the provider's TLS and authentication settings
+// and delivers msg
+func (s *sender) dialAndSend(msg *gomail.Message) error {
+	c, err := s.dialer.Dial(context.Background())
+	if err != nil {
+		return err
+	}
+	sc := &smtpSendCloser{client: c}
+	if err := gomail.Send(sc, msg); err != nil {
+		_ = c.Close()
+		return err
+	}
+	return sc.Close()
+}
+
+// smtpSendCloser adapts an authenticated net/smtp client to gomail's SendCloser
+type smtpSendCloser struct {
+	client *smtp.Client
+}
+
+func (sc *smtpSendCloser) Send(from string, to []string, msg io.WriterTo) error {
+	if err := sc.client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := sc.client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := sc.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (sc *smtpSendCloser) Close() error {
+	return sc.client.Quit()
+}
+
+func authMechanismName(mpConfig *config.MailProviderConfig) string {
+	if mpConfig.AuthMechanism != "" {
+		return string(mpConfig.AuthMechanism)
+	}
+	if mpConfig.Username == "" {
+		return "none"
+	}
+	return "negotiated"
+}
+
 func (s *sender) GetHost() string {
-	return s.dialer.Host
+	return s.host
 }
 
 func (s *sender) GetPort() int {
-	return s.dialer.Port
+	return s.port
 }
 
 func Setup(ctx context.Context, kubeClient client.Client, brandingName string, log *zap.SugaredLogger) (*Queue, error) {
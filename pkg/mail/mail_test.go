@@ -2,6 +2,7 @@ package mail
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"net"
 	"strings"
@@ -376,3 +377,67 @@ func TestSender_Send_HappyPath(t *testing.T) {
 	err := sender.Send([]string{"recipient@example.com"}, "Hello", "<p>body</p>")
 	assert.NoError(t, err, "expected Send to succeed against test SMTP server")
 }
+
+func TestSender_BuildMessage_Multipart(t *testing.T) {
+	s := NewSenderFromMailProvider(&config.MailProviderConfig{
+		Name: "multipart", Host: "localhost", Port: 25, SenderAddress: "noreply@example.com",
+	}, "Breakglass").(*sender)
+
+	var b bytes.Buffer
+	_, err := s.buildMessage(Message{
+		Receivers: []string{"user@example.com"},
+		Subject:   "Hello",
+		HTML:      "<p>Hello <b>world</b></p>",
+	}).WriteTo(&b)
+	assert.NoError(t, err)
+
+	out := b.String()
+	assert.Contains(t, out, "multipart/alternative")
+	assert.Contains(t, out, "Content-Type: text/plain; charset=UTF-8")
+	assert.Contains(t, out, "Content-Type: text/html; charset=UTF-8")
+	assert.Contains(t, out, "Hello world")
+	assert.NotContains(t, out, "text/calendar")
+	// Plain text comes first so clients prefer the HTML alternative
+	assert.Less(t, strings.Index(out, "text/plain"), strings.Index(out, "text/html"))
+}
+
+func TestSender_BuildMessage_ExplicitTextAndCalendar(t *testing.T) {
+	s := NewSenderFromMailProvider(&config.MailProviderConfig{
+		Name: "calendar", Host: "localhost", Port: 25, SenderAddress: "noreply@example.com",
+	}, "Breakglass").(*sender)
+
+	ev := sampleEvent()
+	ev.Organizer, ev.OrganizerCN = "", ""
+
+	var b bytes.Buffer
+	_, err := s.buildMessage(Message{
+		Receivers: []string{"user@example.com"},
+		Subject:   "Approved",
+		HTML:      "<p>approved</p>",
+		Text:      "custom plain text",
+		Calendar:  &ev,
+	}).WriteTo(&b)
+	assert.NoError(t, err)
+
+	out := b.String()
+	assert.Contains(t, out, "multipart/mixed")
+	assert.Contains(t, out, "custom plain text")
+	assert.Contains(t, out, "Content-Type: text/calendar; method=REQUEST")
+	assert.Contains(t, out, `filename="invite.ics"`)
+
+	// The invite is encoded in the MIME body; check the event with the defaulted organizer instead
+	assert.Contains(t, s.withOrganizer(ev).ICS(), "ORGANIZER;CN=Breakglass:mailto:noreply@example.com")
+}
+
+func TestDeliver_FallsBackToSend(t *testing.T) {
+	plain := &MockSender{host: "plain"}
+	err := Deliver(plain, Message{Receivers: []string{"a@example.com"}, Subject: "s", HTML: "<p>h</p>", Text: "t"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>h</p>", plain.lastBody)
+
+	multi := &MockMessageSender{}
+	err = Deliver(multi, Message{Receivers: []string{"a@example.com"}, Subject: "s", HTML: "<p>h</p>", Text: "t"})
+	assert.NoError(t, err)
+	assert.Equal(t, "t", multi.last.Text)
+	assert.Equal(t, 0, multi.sendCalls)
+}
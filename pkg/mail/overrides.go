@@ -0,0 +1,438 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TemplateName identifies one of the notification templates that can be overridden
+type TemplateName string
+
+const (
+	TemplateRequest                       TemplateName = "request"
+	TemplateApproved                      TemplateName = "approved"
+	TemplateBreakglassSessionRequest      TemplateName = "breakglassSessionRequest"
+	TemplateBreakglassSessionNotification TemplateName = "breakglassSessionNotification"
+	TemplateSessionCancelled              TemplateName = "sessionCancelled"
+	TemplateRequestDigest                 TemplateName = "requestDigest"
+	TemplateSessionDecisionSummary        TemplateName = "sessionDecisionSummary"
+)
+
+// Template parts that can be supplied per template (and optionally per locale)
+const (
+	templatePartSubject = "subject"
+	templatePartHTML    = "html"
+	templatePartText    = "txt"
+)
+
+// Rendered holds the output of a rendered notification template
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// templateOverride holds the parsed parts of one override key prefix (e.g. "approved.de")
+type templateOverride struct {
+	subject *texttemplate.Template
+	html    *template.Template
+	text    *texttemplate.Template
+}
+
+// Templates renders notification mails, preferring operator-supplied overrides
+// and falling back to the embedded default templates.
+// A nil *Templates is valid and always renders the embedded defaults.
+type Templates struct {
+	mu        sync.RWMutex
+	overrides map[string]*templateOverride // keyed by "<template>" or "<template>.<locale>"
+	keys      []string
+	locale    string
+}
+
+// NewTemplates returns a Templates instance without overrides
+func NewTemplates() *Templates {
+	return &Templates{overrides: map[string]*templateOverride{}}
+}
+
+// ParseTemplateOverrides parses ConfigMap data into a Templates instance.
+// Every override is test-rendered with sample parameters so that invalid
+// templates are rejected before they are used for real notifications.
+func ParseTemplateOverrides(data map[string]string, locale string) (*Templates, error) {
+	t := NewTemplates()
+	if err := t.Update(data, locale); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ValidateTemplateOverrides parses and test-renders the given override data and
+// returns the sorted list of override keys that were found.
+func ValidateTemplateOverrides(data map[string]string, locale string) ([]string, error) {
+	t, err := ParseTemplateOverrides(data, locale)
+	if err != nil {
+		return nil, err
+	}
+	return t.Keys(), nil
+}
+
+// Update replaces the overrides with the given ConfigMap data.
+// On error the previously loaded overrides are kept.
+func (t *Templates) Update(data map[string]string, locale string) error {
+	overrides := map[string]*templateOverride{}
+	keys := make([]string, 0, len(data))
+	var errs []string
+
+	for key, raw := range data {
+		name, prefix, part, err := parseOverrideKey(key)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		o := overrides[prefix]
+		if o == nil {
+			o = &templateOverride{}
+			overrides[prefix] = o
+		}
+		switch part {
+		case templatePartSubject:
+			o.subject, err = texttemplate.New(key).Parse(raw)
+		case templatePartHTML:
+			o.html, err = template.New(key).Parse(raw)
+		case templatePartText:
+			o.text, err = texttemplate.New(key).Parse(raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		if err := o.validate(name, part); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid template overrides: %s", strings.Join(errs, "; "))
+	}
+	sort.Strings(keys)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.overrides = overrides
+	t.keys = keys
+	t.locale = locale
+	return nil
+}
+
+// Keys returns the sorted list of loaded override keys
+func (t *Templates) Keys() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]string(nil), t.keys...)
+}
+
+// Render renders the named template with the given parameters.
+// Each part (subject, HTML body, plain-text body) is resolved independently,
+// so an override may replace only the subject and keep the embedded HTML body.
+func (t *Templates) Render(name TemplateName, defaultSubject string, params any) (Rendered, error) {
+	out := Rendered{Subject: defaultSubject}
+
+	var subject *texttemplate.Template
+	var html *template.Template
+	var text *texttemplate.Template
+	if t != nil {
+		t.mu.RLock()
+		for _, prefix := range lookupPrefixes(name, t.locale) {
+			o := t.overrides[prefix]
+			if o == nil {
+				continue
+			}
+			if subject == nil {
+				subject = o.subject
+			}
+			if html == nil {
+				html = o.html
+			}
+			if text == nil {
+				text = o.text
+			}
+		}
+		t.mu.RUnlock()
+	}
+
+	var err error
+	if subject != nil {
+		if out.Subject, err = renderText(subject, params); err != nil {
+			return out, err
+		}
+		out.Subject = strings.TrimSpace(out.Subject)
+	}
+	if html == nil {
+		html = defaultTemplate(name)
+	}
+	if out.HTML, err = render(html, params); err != nil {
+		return out, err
+	}
+	if text != nil {
+		if out.Text, err = renderText(text, params); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// validate test-renders the given part with sample parameters for the template
+func (o *templateOverride) validate(name TemplateName, part string) error {
+	params := sampleParams(name)
+	var err error
+	switch part {
+	case templatePartSubject:
+		_, err = renderText(o.subject, params)
+	case templatePartHTML:
+		_, err = render(o.html, params)
+	case templatePartText:
+		_, err = renderText(o.text, params)
+	}
+	return err
+}
+
+// parseOverrideKey splits a ConfigMap key of the form <template>[.<locale>].<part>
+func parseOverrideKey(key string) (TemplateName, string, string, error) {
+	segments := strings.Split(key, ".")
+	if len(segments) < 2 || len(segments) > 3 {
+		return "", "", "", fmt.Errorf("%s: key must have the form <template>[.<locale>].<part>", key)
+	}
+	name := TemplateName(segments[0])
+	if defaultTemplate(name) == nil {
+		return "", "", "", fmt.Errorf("%s: unknown template %q", key, segments[0])
+	}
+	part := segments[len(segments)-1]
+	switch part {
+	case templatePartSubject, templatePartHTML, templatePartText:
+	default:
+		return "", "", "", fmt.Errorf("%s: unknown part %q (expected subject, html or txt)", key, part)
+	}
+	prefix := string(name)
+	if len(segments) == 3 {
+		if segments[1] == "" {
+			return "", "", "", fmt.Errorf("%s: empty locale", key)
+		}
+		prefix += "." + strings.ToLower(segments[1])
+	}
+	return name, prefix, part, nil
+}
+
+// lookupPrefixes returns the override prefixes to try for a template, most specific first.
+// For locale "de-AT" this yields "<name>.de-at", "<name>.de", "<name>".
+func lookupPrefixes(name TemplateName, locale string) []string {
+	prefixes := []string{}
+	locale = strings.ToLower(locale)
+	for locale != "" {
+		prefixes = append(prefixes, string(name)+"."+locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return append(prefixes, string(name))
+}
+
+func defaultTemplate(name TemplateName) *template.Template {
+	switch name {
+	case TemplateRequest:
+		return requestTemplate
+	case TemplateApproved:
+		return approvedTempate
+	case TemplateBreakglassSessionRequest, TemplateBreakglassSessionNotification:
+		return breakglassSessionTemplate
+	case TemplateSessionCancelled:
+		return sessionCancelledTemplate
+	case TemplateRequestDigest:
+		return requestDigestTemplate
+	case TemplateSessionDecisionSummary:
+		return sessionDecisionSummaryTemplate
+	}
+	return nil
+}
+
+// sampleParams returns representative parameters used to test-render overrides
+func sampleParams(name TemplateName) any {
+	switch name {
+	case TemplateRequest:
+		return RequestMailParams{
+			SubjectFullName: "Jane Doe",
+			SubjectEmail:    "jane.doe@example.com",
+			RequestedRole:   "cluster-admin",
+			URL:             "https://breakglass.example.com/review?name=sample",
+			BrandingName:    "Breakglass",
+		}
+	case TemplateApproved:
+		return ApprovedMailParams{
+			SubjectFullName:  "Jane Doe",
+			SubjectEmail:     "jane.doe@example.com",
+			RequestedRole:    "cluster-admin",
+			ApproverFullName: "John Smith",
+			ApproverEmail:    "john.smith@example.com",
+			BrandingName:     "Breakglass",
+			ApprovedAt:       "2025-01-01 10:00:00",
+			ActivationTime:   "2025-01-01 10:00:00",
+			ExpirationTime:   "2025-01-01 11:00:00",
+			SessionID:        "sample-session",
+			Cluster:          "sample-cluster",
+			Username:         "jane.doe@example.com",
+			IDPName:          "sample-idp",
+			IDPIssuer:        "https://idp.example.com",
+		}
+	case TemplateSessionCancelled:
+		return SessionCancelledMailParams{
+			SubjectEmail:  "jane.doe@example.com",
+			RequestedRole: "cluster-admin",
+			Cluster:       "sample-cluster",
+			SessionID:     "sample-session",
+			StartTime:     "2025-01-01 10:00:00",
+			EndTime:       "2025-01-01 11:00:00",
+			Reason:        "canceled",
+			CancelledBy:   "john.smith@example.com",
+			URL:           "https://breakglass.example.com/sessions",
+			BrandingName:  "Breakglass",
+		}
+	case TemplateRequestDigest:
+		return RequestDigestMailParams{
+			ApproverEmail: "john.smith@example.com",
+			Requests: []DigestRequest{{
+				SessionName: "sample-session",
+				Requester:   "jane.doe@example.com",
+				Cluster:     "sample-cluster",
+				Group:       "cluster-admin",
+				Reason:      "Incident INC-1234",
+				RequestedAt: "2025-01-01 09:55:00 UTC",
+				URL:         "https://breakglass.example.com/review?name=sample-session",
+			}},
+			Interval:     "15 minutes",
+			URL:          "https://breakglass.example.com/approvals/pending",
+			BrandingName: "Breakglass",
+		}
+	case TemplateSessionDecisionSummary:
+		return SessionDecisionSummaryMailParams{
+			SubjectEmail: "jane.doe@example.com",
+			DecidedBy:    "john.smith@example.com",
+			Reason:       "Incident INC-1234 resolved",
+			Decisions: []SessionDecision{{
+				SessionName: "sample-session",
+				Cluster:     "sample-cluster",
+				Group:       "cluster-admin",
+				Decision:    "approved",
+				StartTime:   "2025-01-01 10:00:00",
+				EndTime:     "2025-01-01 11:00:00",
+			}},
+			URL:          "https://breakglass.example.com/sessions",
+			BrandingName: "Breakglass",
+		}
+	default:
+		return RequestBreakglassSessionMailParams{
+			SubjectEmail:            "jane.doe@example.com",
+			SubjectFullName:         "Jane Doe",
+			RequestingUsername:      "jane.doe@example.com",
+			RequestedCluster:        "sample-cluster",
+			RequestedUsername:       "jane.doe@example.com",
+			RequestedGroup:          "cluster-admin",
+			RequestReason:           "Incident INC-1234",
+			Approver:                "john.smith@example.com",
+			ApproverGroups:          []string{"approvers"},
+			ScheduledStartTime:      "2025-01-01 10:00:00 UTC",
+			CalculatedExpiresAt:     "2025-01-01 11:00:00 UTC",
+			FormattedDuration:       "1 hour",
+			RequestedAt:             "2025-01-01 09:55:00 UTC",
+			RequestedApprovalGroups: "approvers",
+			TimeRemaining:           "1 hour",
+			URL:                     "https://breakglass.example.com/review?name=sample",
+			BrandingName:            "Breakglass",
+			ApproveURL:              "https://breakglass.example.com/approvals/link#token=sample-approve",
+			RejectURL:               "https://breakglass.example.com/approvals/link#token=sample-reject",
+			ApprovalLinkExpiresAt:   "2025-01-01 10:25:00 UTC",
+		}
+	}
+}
+
+func renderText(t *texttemplate.Template, p any) (string, error) {
+	b := bytes.Buffer{}
+	err := t.Execute(&b, p)
+	return b.String(), err
+}
+
+// TemplateLoader keeps a Templates instance in sync with the override ConfigMap referenced
+// by the default MailProvider, falling back to the ConfigMap from the global mail config.
+type TemplateLoader struct {
+	client    client.Client
+	cfg       config.Mail
+	log       *zap.SugaredLogger
+	templates *Templates
+}
+
+// NewTemplateLoader creates a TemplateLoader. Call Reload to load the overrides.
+func NewTemplateLoader(kubeClient client.Client, cfg config.Mail, log *zap.SugaredLogger) *TemplateLoader {
+	return &TemplateLoader{
+		client:    kubeClient,
+		cfg:       cfg,
+		log:       log,
+		templates: NewTemplates(),
+	}
+}
+
+// Templates returns the Templates instance kept up to date by this loader
+func (l *TemplateLoader) Templates() *Templates {
+	return l.templates
+}
+
+// GlobalConfigMap returns the templates ConfigMap of the global mail config, used when the default
+// MailProvider references none
+func (l *TemplateLoader) GlobalConfigMap() types.NamespacedName {
+	return types.NamespacedName{Namespace: l.cfg.TemplatesConfigMap.Namespace, Name: l.cfg.TemplatesConfigMap.Name}
+}
+
+// Reload resolves the override ConfigMap and replaces the loaded overrides.
+// If the ConfigMap cannot be loaded or is invalid, the previous overrides are kept.
+func (l *TemplateLoader) Reload(ctx context.Context) error {
+	namespace, name, locale := l.cfg.TemplatesConfigMap.Namespace, l.cfg.TemplatesConfigMap.Name, l.cfg.Locale
+
+	provider, err := config.NewMailProviderLoader(l.client).WithLogger(l.log).GetDefaultMailProvider(ctx)
+	if err != nil {
+		l.log.Debugw("No default MailProvider available for template overrides, using global mail config", "error", err)
+	} else if provider.TemplatesConfigMapName != "" {
+		namespace, name, locale = provider.TemplatesConfigMapNamespace, provider.TemplatesConfigMapName, provider.TemplatesLocale
+	}
+
+	if name == "" {
+		return l.templates.Update(nil, locale)
+	}
+
+	data, err := config.LoadConfigMapData(ctx, l.client, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to load mail template overrides: %w", err)
+	}
+	if err := l.templates.Update(data, locale); err != nil {
+		return err
+	}
+	l.log.Infow("Loaded mail template overrides",
+		"configMap", namespace+"/"+name,
+		"locale", locale,
+		"keys", l.templates.Keys())
+	return nil
+}
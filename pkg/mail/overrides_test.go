@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	breakglassv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/config"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTemplatesRenderWithoutOverrides(t *testing.T) {
+	var templates *Templates
+	params := ApprovedMailParams{SubjectFullName: "John Doe", RequestedRole: "admin"}
+
+	rendered, err := templates.Render(TemplateApproved, "default subject", params)
+	require.NoError(t, err)
+
+	expected, err := RenderApproved(params)
+	require.NoError(t, err)
+	assert.Equal(t, "default subject", rendered.Subject)
+	assert.Equal(t, expected, rendered.HTML)
+	assert.Empty(t, rendered.Text)
+}
+
+func TestTemplatesRenderOverrides(t *testing.T) {
+	templates, err := ParseTemplateOverrides(map[string]string{
+		"approved.subject":    "Access granted: {{ .RequestedRole }}",
+		"approved.de.subject": "Zugriff erteilt: {{ .RequestedRole }}",
+		"approved.txt":        "Hello {{ .SubjectFullName }}",
+		"approved.de-at.html": "<p>Servus {{ .SubjectFullName }}</p>",
+	}, "de-AT")
+	require.NoError(t, err)
+
+	rendered, err := templates.Render(TemplateApproved, "default subject", ApprovedMailParams{SubjectFullName: "John Doe", RequestedRole: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, "Zugriff erteilt: admin", rendered.Subject, "base language subject should win over locale-independent subject")
+	assert.Equal(t, "<p>Servus John Doe</p>", rendered.HTML)
+	assert.Equal(t, "Hello John Doe", rendered.Text)
+
+	// Templates without overrides still use the embedded defaults
+	rendered, err = templates.Render(TemplateBreakglassSessionRequest, "request subject", RequestBreakglassSessionMailParams{RequestedCluster: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "request subject", rendered.Subject)
+	assert.Contains(t, rendered.HTML, "prod")
+}
+
+func TestParseTemplateOverridesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   map[string]string
+		errMsg string
+	}{
+		{name: "unknown template", data: map[string]string{"unknown.subject": "x"}, errMsg: "unknown template"},
+		{name: "unknown part", data: map[string]string{"approved.body": "x"}, errMsg: "unknown part"},
+		{name: "malformed key", data: map[string]string{"approved": "x"}, errMsg: "<template>[.<locale>].<part>"},
+		{name: "parse error", data: map[string]string{"approved.html": "{{ .SubjectFullName "}, errMsg: "approved.html"},
+		{name: "unknown field", data: map[string]string{"approved.subject": "{{ .DoesNotExist }}"}, errMsg: "DoesNotExist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateTemplateOverrides(tt.data, "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestTemplatesUpdateKeepsPreviousOnError(t *testing.T) {
+	templates, err := ParseTemplateOverrides(map[string]string{"approved.subject": "custom"}, "")
+	require.NoError(t, err)
+
+	err = templates.Update(map[string]string{"approved.subject": "{{ .Broken"}, "")
+	require.Error(t, err)
+	assert.Equal(t, []string{"approved.subject"}, templates.Keys())
+
+	rendered, err := templates.Render(TemplateApproved, "default", ApprovedMailParams{})
+	require.NoError(t, err)
+	assert.Equal(t, "custom", rendered.Subject)
+}
+
+func TestTemplateLoaderReload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = breakglassv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	providerCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "provider-templates", Namespace: "breakglass"},
+		Data:       map[string]string{"approved.subject": "from provider"},
+	}
+	globalCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "global-templates", Namespace: "breakglass"},
+		Data:       map[string]string{"approved.subject": "from global config"},
+	}
+	provider := &breakglassv1alpha1.MailProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-provider"},
+		Spec: breakglassv1alpha1.MailProviderSpec{
+			Default: true,
+			SMTP:    breakglassv1alpha1.SMTPConfig{Host: "smtp.example.com", Port: 587},
+			Sender:  breakglassv1alpha1.SenderConfig{Address: "noreply@example.com"},
+			Templates: &breakglassv1alpha1.MailTemplatesConfig{
+				ConfigMapRef: breakglassv1alpha1.ConfigMapReference{Name: "provider-templates", Namespace: "breakglass"},
+			},
+		},
+	}
+	globalCfg := config.Mail{TemplatesConfigMap: config.ConfigMapRef{Name: "global-templates", Namespace: "breakglass"}}
+
+	t.Run("provider reference takes precedence", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(providerCM, globalCM, provider).Build()
+		loader := NewTemplateLoader(c, globalCfg, zap.NewNop().Sugar())
+		require.NoError(t, loader.Reload(context.Background()))
+
+		rendered, err := loader.Templates().Render(TemplateApproved, "default", ApprovedMailParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "from provider", rendered.Subject)
+	})
+
+	t.Run("global config fallback", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(globalCM).Build()
+		loader := NewTemplateLoader(c, globalCfg, zap.NewNop().Sugar())
+		require.NoError(t, loader.Reload(context.Background()))
+
+		rendered, err := loader.Templates().Render(TemplateApproved, "default", ApprovedMailParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "from global config", rendered.Subject)
+	})
+
+	t.Run("missing configmap keeps embedded defaults", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		loader := NewTemplateLoader(c, globalCfg, zap.NewNop().Sugar())
+		require.Error(t, loader.Reload(context.Background()))
+
+		rendered, err := loader.Templates().Render(TemplateApproved, "default", ApprovedMailParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "default", rendered.Subject)
+	})
+}
@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// HTMLToText converts an HTML mail body into a readable plain-text alternative.
+// Block elements become line breaks, list items are prefixed with "- " and links
+// keep their target in parentheses. Content of head, style and script is dropped.
+func HTMLToText(body string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	var b strings.Builder
+	skipDepth := 0
+	var hrefs []string
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishText(b.String())
+
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := strings.Join(strings.Fields(string(tokenizer.Text())), " ")
+			if text == "" {
+				continue
+			}
+			if cur := b.String(); cur != "" && !strings.HasSuffix(cur, "\n") && !strings.HasSuffix(cur, " ") {
+				b.WriteByte(' ')
+			}
+			b.WriteString(text)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			a := atom.Lookup(name)
+			switch a {
+			case atom.Head, atom.Style, atom.Script, atom.Title:
+				skipDepth++
+			case atom.Br:
+				b.WriteByte('\n')
+			case atom.Li:
+				b.WriteString("\n- ")
+			case atom.A:
+				href := ""
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = tokenizer.TagAttr()
+					if string(key) == "href" {
+						href = string(val)
+					}
+				}
+				hrefs = append(hrefs, href)
+			default:
+				if isBlockElement(a) {
+					b.WriteString("\n\n")
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			a := atom.Lookup(name)
+			switch a {
+			case atom.Head, atom.Style, atom.Script, atom.Title:
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case atom.A:
+				if len(hrefs) == 0 {
+					continue
+				}
+				href := hrefs[len(hrefs)-1]
+				hrefs = hrefs[:len(hrefs)-1]
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasSuffix(b.String(), href) {
+					b.WriteString(" (" + href + ")")
+				}
+			default:
+				if isBlockElement(a) {
+					b.WriteString("\n\n")
+				}
+			}
+		}
+	}
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Table, atom.Tr, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Section, atom.Header, atom.Footer, atom.Blockquote, atom.Pre, atom.Hr:
+		return true
+	}
+	return false
+}
+
+func finishText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
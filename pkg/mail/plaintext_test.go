@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText(t *testing.T) {
+	body := `<html><head><title>T</title><style>body { color: red; }</style></head>
+<body>
+  <h1>Breakglass</h1>
+  <div class="card">
+    <p>Jane   Doe<br>jane@example.com</p>
+    <ul><li>first</li><li>second</li></ul>
+    <p><a href="https://example.com/review">Review</a></p>
+    <script>alert(1)</script>
+  </div>
+</body></html>`
+
+	text := HTMLToText(body)
+
+	assert.Equal(t, "Breakglass\n\nJane Doe\njane@example.com\n\n- first\n- second\n\nReview (https://example.com/review)\n", text)
+	assert.NotContains(t, text, "color")
+	assert.NotContains(t, text, "alert")
+}
+
+func TestHTMLToText_LinkTextIsURL(t *testing.T) {
+	text := HTMLToText(`<p><a href="https://example.com">https://example.com</a></p>`)
+	assert.Equal(t, "https://example.com\n", text)
+}
+
+func TestHTMLToText_EmbeddedTemplates(t *testing.T) {
+	for name := range map[TemplateName]struct{}{
+		TemplateRequest:                  {},
+		TemplateApproved:                 {},
+		TemplateBreakglassSessionRequest: {},
+		TemplateSessionCancelled:         {},
+		TemplateRequestDigest:            {},
+		TemplateSessionDecisionSummary:   {},
+	} {
+		rendered, err := NewTemplates().Render(name, "subject", sampleParams(name))
+		assert.NoError(t, err, name)
+		text := HTMLToText(rendered.HTML)
+		assert.NotEmpty(t, strings.TrimSpace(text), name)
+		assert.NotContains(t, text, "<", name)
+		assert.NotContains(t, text, "{", name)
+	}
+}
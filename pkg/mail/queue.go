@@ -33,6 +33,8 @@ type QueueItem struct {
 	Receivers []string
 	Subject   string
 	Body      string
+	Text      string
+	Calendar  *CalendarEvent
 	Attempt   int
 	CreatedAt time.Time
 	NextRetry time.Time
@@ -94,6 +96,12 @@ func (q *Queue) Start() {
 
 // Enqueue adds an email to the queue for sending
 func (q *Queue) Enqueue(id string, receivers []string, subject, body string) error {
+	return q.EnqueueMessage(id, Message{Receivers: receivers, Subject: subject, HTML: body})
+}
+
+// EnqueueMessage adds a multipart message (optionally with a calendar invite) to the queue for sending
+func (q *Queue) EnqueueMessage(id string, msg Message) error {
+	receivers, subject := msg.Receivers, msg.Subject
 	if len(receivers) == 0 {
 		q.log.Errorw("Cannot enqueue email: empty receivers list",
 			"id", id,
@@ -116,7 +124,9 @@ func (q *Queue) Enqueue(id string, receivers []string, subject, body string) err
 		ID:        id,
 		Receivers: receivers,
 		Subject:   subject,
-		Body:      body,
+		Body:      msg.HTML,
+		Text:      msg.Text,
+		Calendar:  msg.Calendar,
 		Attempt:   0,
 		CreatedAt: time.Now(),
 		NextRetry: time.Now(),
@@ -198,7 +208,13 @@ func (q *Queue) processItem(item *QueueItem) {
 		"maxRetries", q.maxRetries+1,
 		"receivers", len(item.Receivers))
 
-	err := q.sender.Send(item.Receivers, item.Subject, item.Body)
+	err := Deliver(q.sender, Message{
+		Receivers: item.Receivers,
+		Subject:   item.Subject,
+		HTML:      item.Body,
+		Text:      item.Text,
+		Calendar:  item.Calendar,
+	})
 	if err == nil {
 		q.log.Infow("Queued email sent successfully",
 			"id", item.ID,
@@ -19,6 +19,7 @@ package mail
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -444,3 +445,72 @@ func TestQueue_ConcurrentEnqueue(t *testing.T) {
 
 	assert.Equal(t, 10, sender.attempts)
 }
+
+// MockMessageSender records multipart messages delivered through SendMessage
+type MockMessageSender struct {
+	mu        sync.Mutex
+	last      Message
+	messages  int
+	sendCalls int
+}
+
+func (m *MockMessageSender) Send(receivers []string, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sendCalls++
+	return nil
+}
+
+func (m *MockMessageSender) SendMessage(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last = msg
+	m.messages++
+	return nil
+}
+
+func (m *MockMessageSender) GetHost() string {
+	return "multipart.example.com"
+}
+
+func (m *MockMessageSender) GetPort() int {
+	return 25
+}
+
+func TestQueue_EnqueueMessage(t *testing.T) {
+	sender := &MockMessageSender{}
+	queue := NewQueue(sender, zap.NewNop().Sugar(), 3, 100, 10)
+	queue.Start()
+	defer func() {
+		if err := queue.Stop(context.Background()); err != nil {
+			t.Errorf("failed to stop queue: %v", err)
+		}
+	}()
+
+	ev := sampleEvent()
+	err := queue.EnqueueMessage("invite-1", Message{
+		Receivers: []string{"user@example.com"},
+		Subject:   "Approved",
+		HTML:      "<p>approved</p>",
+		Text:      "approved",
+		Calendar:  &ev,
+	})
+	assert.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	assert.Equal(t, 1, sender.messages)
+	assert.Equal(t, 0, sender.sendCalls)
+	assert.Equal(t, "approved", sender.last.Text)
+	if assert.NotNil(t, sender.last.Calendar) {
+		assert.Equal(t, SessionEventUID("session-1"), sender.last.Calendar.UID)
+	}
+}
+
+func TestQueue_EnqueueMessageNoReceivers(t *testing.T) {
+	queue := NewQueue(&MockMessageSender{}, zap.NewNop().Sugar(), 3, 100, 10)
+	err := queue.EnqueueMessage("invite-2", Message{Subject: "s", HTML: "h"})
+	assert.Error(t, err)
+}
@@ -64,6 +64,67 @@ type RequestBreakglassSessionMailParams struct {
 
 	URL          string
 	BrandingName string
+
+	// One-click approval links for a single approver (empty when approval links are disabled)
+	ApproveURL            string
+	RejectURL             string
+	ApprovalLinkExpiresAt string
+}
+
+// SessionCancelledMailParams describes an approved access window that was ended before it ran out
+type SessionCancelledMailParams struct {
+	SubjectEmail  string
+	RequestedRole string
+	Cluster       string
+	SessionID     string
+	StartTime     string
+	EndTime       string
+	Reason        string // e.g. "canceled" or "dropped"
+	CancelledBy   string // Approver who canceled the session (empty if dropped by the owner)
+	URL           string
+	BrandingName  string
+}
+
+// RequestDigestMailParams summarizes the pending requests batched for a single approver
+type RequestDigestMailParams struct {
+	ApproverEmail string
+	Requests      []DigestRequest
+	Interval      string // Human-readable digest interval (e.g. "15 minutes")
+	URL           string // Link to the pending approvals overview
+	BrandingName  string
+}
+
+// DigestRequest is a single pending request listed in a digest
+type DigestRequest struct {
+	SessionName        string
+	Requester          string
+	Cluster            string
+	Group              string
+	Reason             string
+	RequestedAt        string
+	ScheduledStartTime string
+	URL                string
+}
+
+// SessionDecisionSummaryMailParams summarizes the decisions an approver took on several sessions of one
+// requester at once
+type SessionDecisionSummaryMailParams struct {
+	SubjectEmail string
+	DecidedBy    string
+	Reason       string // Reason shared by all decisions
+	Decisions    []SessionDecision
+	URL          string
+	BrandingName string
+}
+
+// SessionDecision is a single session listed in a decision summary
+type SessionDecision struct {
+	SessionName string
+	Cluster     string
+	Group       string
+	Decision    string // "approved", "rejected" or "canceled"
+	StartTime   string // Access window of approved and canceled sessions
+	EndTime     string
 }
 
 var (
@@ -71,6 +132,9 @@ var (
 	approvedTempate                = template.New("approved")
 	breakglassSessionTemplate      = template.New("breakglassSessionRequest")
 	breakglassNotificationTemplate = template.New("breakglassSessionNotification")
+	sessionCancelledTemplate       = template.New("sessionCancelled")
+	requestDigestTemplate          = template.New("requestDigest")
+	sessionDecisionSummaryTemplate = template.New("sessionDecisionSummary")
 
 	//go:embed templates/request.html
 	requestTemplateRaw string
@@ -80,6 +144,12 @@ var (
 	breakglassSessionReqTemplateRaw string
 	//go:embed templates/breakglassSessionNotification.html
 	breakglassSessionNotifiTemplateRaw string
+	//go:embed templates/sessionCancelled.html
+	sessionCancelledTemplateRaw string
+	//go:embed templates/requestDigest.html
+	requestDigestTemplateRaw string
+	//go:embed templates/sessionDecisionSummary.html
+	sessionDecisionSummaryTemplateRaw string
 )
 
 func init() {
@@ -95,6 +165,15 @@ func init() {
 	if _, err := breakglassNotificationTemplate.Parse(breakglassSessionNotifiTemplateRaw); err != nil {
 		panic(err)
 	}
+	if _, err := sessionCancelledTemplate.Parse(sessionCancelledTemplateRaw); err != nil {
+		panic(err)
+	}
+	if _, err := requestDigestTemplate.Parse(requestDigestTemplateRaw); err != nil {
+		panic(err)
+	}
+	if _, err := sessionDecisionSummaryTemplate.Parse(sessionDecisionSummaryTemplateRaw); err != nil {
+		panic(err)
+	}
 }
 
 func render(t *template.Template, p any) (string, error) {
@@ -118,3 +197,15 @@ func RenderBreakglassSessionRequest(p RequestBreakglassSessionMailParams) (strin
 func RenderBreakglassSessionNotification(p RequestBreakglassSessionMailParams) (string, error) {
 	return render(breakglassSessionTemplate, p)
 }
+
+func RenderSessionCancelled(p SessionCancelledMailParams) (string, error) {
+	return render(sessionCancelledTemplate, p)
+}
+
+func RenderRequestDigest(p RequestDigestMailParams) (string, error) {
+	return render(requestDigestTemplate, p)
+}
+
+func RenderSessionDecisionSummary(p SessionDecisionSummaryMailParams) (string, error) {
+	return render(sessionDecisionSummaryTemplate, p)
+}
@@ -16,6 +16,58 @@ var (
 		Name: "breakglass_clusterconfigs_failed_total",
 		Help: "Total number of ClusterConfig validations that failed",
 	}, []string{"cluster"})
+	// ClusterConfig health metrics
+	ClusterConfigCertificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "breakglass_clusterconfig_certificate_expiry_timestamp_seconds",
+		Help: "Unix time at which the client certificate or certificate authority of the cluster credentials expires",
+	}, []string{"cluster", "certificate"})
+	ClusterConfigProbeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "breakglass_clusterconfig_probe_latency_seconds",
+		Help: "Round trip time of the last successful reachability probe of the cluster",
+	}, []string{"cluster"})
+	ClusterConfigAuthorizationWebhookConfigured = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "breakglass_clusterconfig_authorization_webhook_configured",
+		Help: "Whether the cluster's API server consults the breakglass authorization webhook (1) or not (0)",
+	}, []string{"cluster"})
+	// Spoke client pool metrics
+	SpokeClientPoolSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "breakglass_spoke_client_pool_clients",
+		Help: "Number of spoke clients held in the shared client pool",
+	})
+	SpokeClientPoolLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_spoke_client_pool_lookups_total",
+		Help: "Spoke client pool lookups by result (hit, miss or unpooled)",
+	}, []string{"result"})
+	SpokeClientPoolEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_spoke_client_pool_evictions_total",
+		Help: "Spoke clients dropped from the pool by reason (invalidated or idle)",
+	}, []string{"reason"})
+	// Session watch stream metrics
+	SessionWatchStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "breakglass_session_watch_streams",
+		Help: "Number of open session watch streams",
+	})
+	SessionWatchEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_session_watch_events_total",
+		Help: "Events sent on session watch streams by type (added, modified, removed, reset)",
+	}, []string{"type"})
+	SessionWatchLagged = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "breakglass_session_watch_lagged_total",
+		Help: "Session watch streams closed because the client did not keep up with events",
+	})
+	// Cluster discovery metrics
+	ClusterDiscoveryRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_cluster_discovery_runs_total",
+		Help: "Total number of cluster discovery runs, by result",
+	}, []string{"result"})
+	ClusterDiscoveryChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_cluster_discovery_changes_total",
+		Help: "Total number of ClusterConfigs created, updated or deleted by cluster discovery",
+	}, []string{"action"})
+	ClusterDiscoveryManaged = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "breakglass_cluster_discovery_managed_clusterconfigs",
+		Help: "Number of ClusterConfigs managed by cluster discovery after the last successful run",
+	})
 	// Webhook SAR metrics
 	WebhookSARRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "breakglass_webhook_sar_requests_total",
@@ -91,6 +143,14 @@ var (
 		Name: "breakglass_session_rejected_total",
 		Help: "Total number of Breakglass sessions that were rejected",
 	}, []string{"cluster"})
+	SessionBatchItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_session_batch_items_total",
+		Help: "Sessions processed by batch approve/reject/cancel requests by action and result (success or failed)",
+	}, []string{"action", "result"})
+	SessionApprovalStepUpRequired = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_session_approval_step_up_required_total",
+		Help: "Total number of approvals refused because the approver's authentication did not meet the escalation's approvalAuthRequirements",
+	}, []string{"cluster", "reason"})
 
 	// Mail metrics
 	MailSendSuccess = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -122,6 +182,29 @@ var (
 		Name: "breakglass_mail_failed_total",
 		Help: "Total number of emails failed after all retries",
 	}, []string{"host"})
+	// Notification digest metrics
+	NotificationDigestDeferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_notification_digest_deferred_total",
+		Help: "Total number of approver request notifications deferred to a digest",
+	}, []string{"escalation"})
+	NotificationDigestUrgentBypass = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_notification_digest_urgent_bypass_total",
+		Help: "Total number of approver request notifications sent immediately despite digest mode because the request was urgent",
+	}, []string{"escalation", "reason"})
+	NotificationDigestSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_notification_digest_sent_total",
+		Help: "Total number of notification digests sent by result (sent, empty, failed)",
+	}, []string{"result"})
+
+	// Approval link metrics
+	ApprovalLinksIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_approval_links_issued_total",
+		Help: "Total number of signed one-click approval links issued in request emails by action",
+	}, []string{"action"})
+	ApprovalLinkRedemptions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_approval_link_redemptions_total",
+		Help: "Total number of approval link confirmations by action and result (redeemed, invalid, expired, used, revoked, decided, forbidden, failed)",
+	}, []string{"action", "result"})
 
 	// MailProvider metrics
 	MailProviderConfigured = prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -137,6 +220,10 @@ var (
 		Help:    "Duration of MailProvider health checks",
 		Buckets: []float64{.1, .5, 1, 2, 5, 10},
 	}, []string{"provider"})
+	MailProviderTemplateValidation = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_mailprovider_template_validation_total",
+		Help: "Total number of MailProvider template override validations by result (valid, invalid)",
+	}, []string{"provider", "result"})
 	MailProviderStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "breakglass_mailprovider_status",
 		Help: "Current status of MailProvider (1=Healthy, 0=Unhealthy, -1=Disabled)",
@@ -233,6 +320,26 @@ var (
 		Help: "Number of JWKS entries currently in cache",
 	}, []string{"issuer"})
 
+	// Keycloak group sync metrics
+	KeycloakAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_keycloak_api_requests_total",
+		Help: "Total Keycloak API requests made by group sync, by operation and result",
+	}, []string{"realm", "operation", "result"})
+	KeycloakAPIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "breakglass_keycloak_api_request_duration_seconds",
+		Help:    "Latency of Keycloak API requests made by group sync",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"realm", "operation"})
+	KeycloakRateLimitWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "breakglass_keycloak_rate_limit_wait_seconds",
+		Help:    "Time Keycloak API requests waited for the client-side rate limiter",
+		Buckets: []float64{.001, .01, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"realm"})
+	KeycloakGroupLookupsShared = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "breakglass_keycloak_group_lookups_shared_total",
+		Help: "Total group lookups answered by a concurrent lookup of the same group",
+	}, []string{"realm"})
+
 	// IDP Selection metrics (Multi-IDP UI flow)
 	MultiIDPConfigRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "breakglass_multi_idp_config_requests_total",
@@ -319,6 +426,18 @@ var (
 func init() {
 	prometheus.MustRegister(ClusterConfigsChecked)
 	prometheus.MustRegister(ClusterConfigsFailed)
+	prometheus.MustRegister(ClusterConfigCertificateExpiry)
+	prometheus.MustRegister(ClusterConfigProbeLatency)
+	prometheus.MustRegister(ClusterConfigAuthorizationWebhookConfigured)
+	prometheus.MustRegister(SpokeClientPoolSize)
+	prometheus.MustRegister(SpokeClientPoolLookups)
+	prometheus.MustRegister(SpokeClientPoolEvictions)
+	prometheus.MustRegister(SessionWatchStreams)
+	prometheus.MustRegister(SessionWatchEvents)
+	prometheus.MustRegister(SessionWatchLagged)
+	prometheus.MustRegister(ClusterDiscoveryRuns)
+	prometheus.MustRegister(ClusterDiscoveryChanges)
+	prometheus.MustRegister(ClusterDiscoveryManaged)
 	prometheus.MustRegister(WebhookSARRequests)
 	prometheus.MustRegister(WebhookSARRequestsByAction)
 	prometheus.MustRegister(WebhookSARAllowed)
@@ -336,6 +455,8 @@ func init() {
 	prometheus.MustRegister(SessionActivated)
 	prometheus.MustRegister(SessionApproved)
 	prometheus.MustRegister(SessionRejected)
+	prometheus.MustRegister(SessionBatchItems)
+	prometheus.MustRegister(SessionApprovalStepUpRequired)
 	prometheus.MustRegister(MailSendSuccess)
 	prometheus.MustRegister(MailSendFailure)
 	prometheus.MustRegister(MailQueued)
@@ -343,9 +464,15 @@ func init() {
 	prometheus.MustRegister(MailSent)
 	prometheus.MustRegister(MailRetryScheduled)
 	prometheus.MustRegister(MailFailed)
+	prometheus.MustRegister(NotificationDigestDeferred)
+	prometheus.MustRegister(NotificationDigestUrgentBypass)
+	prometheus.MustRegister(NotificationDigestSent)
+	prometheus.MustRegister(ApprovalLinksIssued)
+	prometheus.MustRegister(ApprovalLinkRedemptions)
 	prometheus.MustRegister(MailProviderConfigured)
 	prometheus.MustRegister(MailProviderHealthCheck)
 	prometheus.MustRegister(MailProviderHealthCheckDuration)
+	prometheus.MustRegister(MailProviderTemplateValidation)
 	prometheus.MustRegister(MailProviderStatus)
 	prometheus.MustRegister(MailProviderEmailsSent)
 	prometheus.MustRegister(MailProviderEmailsFailed)
@@ -370,6 +497,12 @@ func init() {
 	prometheus.MustRegister(JWKSFetchDuration)
 	prometheus.MustRegister(JWKSCacheSize)
 
+	// Register Keycloak group sync metrics
+	prometheus.MustRegister(KeycloakAPIRequests)
+	prometheus.MustRegister(KeycloakAPIRequestDuration)
+	prometheus.MustRegister(KeycloakRateLimitWait)
+	prometheus.MustRegister(KeycloakGroupLookupsShared)
+
 	// Register multi-IDP UI flow metrics
 	prometheus.MustRegister(MultiIDPConfigRequests)
 	prometheus.MustRegister(MultiIDPConfigSuccess)
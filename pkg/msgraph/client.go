@@ -0,0 +1,293 @@
+// Package msgraph is a minimal Microsoft Graph client for resolving the transitive user members
+// of Entra ID groups with the OAuth2 client credentials flow.
+package msgraph
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAuthorityURL is the login endpoint of the global Azure cloud
+	DefaultAuthorityURL = "https://login.microsoftonline.com"
+	// DefaultGraphURL is the Graph endpoint of the global Azure cloud
+	DefaultGraphURL = "https://graph.microsoft.com"
+
+	// pageSize is the largest $top accepted by the transitiveMembers endpoint
+	pageSize = 999
+	// maxPages guards against endless @odata.nextLink chains
+	maxPages = 1000
+	// maxResponseBytes caps the size of a single response body
+	maxResponseBytes = 16 << 20
+	// tokenExpirySlack renews tokens shortly before they expire
+	tokenExpirySlack = time.Minute
+)
+
+// DefaultUserIdentifierAttributes are tried in order to identify a user
+var DefaultUserIdentifierAttributes = []string{"mail", "userPrincipalName"}
+
+// ErrGroupNotFound is returned when no group matches the requested name or ID
+var ErrGroupNotFound = errors.New("msgraph: group not found")
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$`)
+
+// Options describes the app registration and the cloud to talk to
+type Options struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// AuthorityURL and GraphURL default to the global Azure cloud
+	AuthorityURL string
+	GraphURL     string
+	// UserIdentifierAttributes are the user properties tried in order to identify a member
+	UserIdentifierAttributes []string
+	// CertificateAuthority is a PEM encoded CA bundle; the system roots are used when empty
+	CertificateAuthority string
+	// InsecureSkipVerify disables server certificate verification (testing only)
+	InsecureSkipVerify bool
+}
+
+// Client talks to Microsoft Graph. Access tokens are cached until shortly before they expire.
+// A Client is safe for concurrent use.
+type Client struct {
+	opts Options
+	http *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewClient validates the options and applies defaults
+func NewClient(opts Options) (*Client, error) {
+	if opts.TenantID == "" || opts.ClientID == "" || opts.ClientSecret == "" {
+		return nil, fmt.Errorf("msgraph: tenantID, clientID and client secret are required")
+	}
+	if opts.AuthorityURL == "" {
+		opts.AuthorityURL = DefaultAuthorityURL
+	}
+	if opts.GraphURL == "" {
+		opts.GraphURL = DefaultGraphURL
+	}
+	opts.AuthorityURL = strings.TrimSuffix(opts.AuthorityURL, "/")
+	opts.GraphURL = strings.TrimSuffix(opts.GraphURL, "/")
+	if len(opts.UserIdentifierAttributes) == 0 {
+		opts.UserIdentifierAttributes = DefaultUserIdentifierAttributes
+	}
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{opts: opts, http: &http.Client{Transport: transport}}, nil
+}
+
+func (opts Options) tlsConfig() (*tls.Config, error) {
+	// InsecureSkipVerify is an explicit opt-in for test environments
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CertificateAuthority != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CertificateAuthority)) {
+			return nil, fmt.Errorf("msgraph: certificateAuthority contains no valid PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// Token returns a cached access token or requests a new one with the client credentials grant
+func (c *Client) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.opts.ClientID},
+		"client_secret": {c.opts.ClientSecret},
+		"scope":         {c.opts.GraphURL + "/.default"},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.opts.AuthorityURL, url.PathEscape(c.opts.TenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("msgraph: token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("msgraph: failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		if body.Error != "" {
+			return "", fmt.Errorf("msgraph: token request returned %d: %s: %s", resp.StatusCode, body.Error, body.ErrorDescription)
+		}
+		return "", fmt.Errorf("msgraph: token request returned %d", resp.StatusCode)
+	}
+
+	c.token = body.AccessToken
+	c.expiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - tokenExpirySlack)
+	return c.token, nil
+}
+
+// GroupID returns the object ID of the group with the given display name. Object IDs are
+// returned unchanged so groups can also be referenced by ID.
+func (c *Client) GroupID(ctx context.Context, group string) (string, error) {
+	if guidPattern.MatchString(group) {
+		return group, nil
+	}
+	query := url.Values{
+		"$filter": {fmt.Sprintf("displayName eq '%s'", strings.ReplaceAll(group, "'", "''"))},
+		"$select": {"id"},
+	}
+	var page struct {
+		Value []struct {
+			ID string `json:"id"`
+		} `json:"value"`
+	}
+	if err := c.get(ctx, c.opts.GraphURL+"/v1.0/groups?"+query.Encode(), &page); err != nil {
+		return "", err
+	}
+	switch len(page.Value) {
+	case 0:
+		return "", ErrGroupNotFound
+	case 1:
+		return page.Value[0].ID, nil
+	default:
+		return "", fmt.Errorf("msgraph: %d groups are named %q; reference the group by object ID", len(page.Value), group)
+	}
+}
+
+// Members returns the identifiers of the enabled users that are direct or nested members of
+// the group, following @odata.nextLink until all pages are read
+func (c *Client) Members(ctx context.Context, group string) ([]string, error) {
+	groupID, err := c.GroupID(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{
+		"$select": {strings.Join(append(append([]string{}, c.opts.UserIdentifierAttributes...), "accountEnabled"), ",")},
+		"$top":    {fmt.Sprint(pageSize)},
+		// Casting transitiveMembers to users is an advanced query
+		"$count": {"true"},
+	}
+	next := fmt.Sprintf("%s/v1.0/groups/%s/transitiveMembers/microsoft.graph.user?%s",
+		c.opts.GraphURL, url.PathEscape(groupID), query.Encode())
+
+	var members []string
+	for pages := 0; next != ""; pages++ {
+		if pages == maxPages {
+			return nil, fmt.Errorf("msgraph: members of group %q exceed %d pages", group, maxPages)
+		}
+		var page struct {
+			Value    []map[string]any `json:"value"`
+			NextLink string           `json:"@odata.nextLink"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			return nil, err
+		}
+		for _, user := range page.Value {
+			if enabled, ok := user["accountEnabled"].(bool); ok && !enabled {
+				continue
+			}
+			if id := c.identifier(user); id != "" {
+				members = append(members, id)
+			}
+		}
+		if page.NextLink != "" && !c.sameOrigin(page.NextLink) {
+			return nil, fmt.Errorf("msgraph: refusing to follow @odata.nextLink to another host: %s", page.NextLink)
+		}
+		next = page.NextLink
+	}
+	return members, nil
+}
+
+// identifier returns the first non-empty configured user property
+func (c *Client) identifier(user map[string]any) string {
+	for _, attr := range c.opts.UserIdentifierAttributes {
+		if v, ok := user[attr].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sameOrigin reports whether link points to the configured Graph endpoint; the access token
+// must never be sent elsewhere
+func (c *Client) sameOrigin(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	base, err := url.Parse(c.opts.GraphURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == base.Scheme && u.Host == base.Host
+}
+
+func (c *Client) get(ctx context.Context, target string, into any) error {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	// Required for advanced queries such as OData casts
+	req.Header.Set("ConsistencyLevel", "eventual")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("msgraph: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body := io.LimitReader(resp.Body, maxResponseBytes)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrGroupNotFound
+	case resp.StatusCode != http.StatusOK:
+		var graphErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(body).Decode(&graphErr) == nil && graphErr.Error.Code != "" {
+			return fmt.Errorf("msgraph: request returned %d: %s: %s", resp.StatusCode, graphErr.Error.Code, graphErr.Error.Message)
+		}
+		return fmt.Errorf("msgraph: request returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(body).Decode(into); err != nil {
+		return fmt.Errorf("msgraph: failed to decode response: %w", err)
+	}
+	return nil
+}
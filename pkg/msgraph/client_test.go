@@ -0,0 +1,152 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGroupID = "0b5c2f9e-6a8d-4f3b-9c1e-2d7a4b6e8f01"
+
+// newTestServer serves the token endpoint and a group "ops" whose members span two pages
+func newTestServer(t *testing.T, tokenRequests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("POST /tenant/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad secret"})
+			return
+		}
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, srv.URL+"/.default", r.PostForm.Get("scope"))
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /v1.0/groups", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var value []map[string]string
+		switch r.URL.Query().Get("$filter") {
+		case "displayName eq 'ops'":
+			value = []map[string]string{{"id": testGroupID}}
+		case "displayName eq 'o''brien'":
+			value = []map[string]string{{"id": "a"}, {"id": "b"}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"value": value})
+	})
+	mux.HandleFunc("GET /v1.0/groups/{id}/transitiveMembers/microsoft.graph.user", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != testGroupID {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": "Request_ResourceNotFound"}})
+			return
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			assert.Equal(t, "mail,userPrincipalName,accountEnabled", r.URL.Query().Get("$select"))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"value": []map[string]any{
+					{"mail": "alice@example.com", "userPrincipalName": "alice@corp.example.com", "accountEnabled": true},
+					{"mail": nil, "userPrincipalName": "bob@corp.example.com", "accountEnabled": true},
+					{"mail": "disabled@example.com", "accountEnabled": false},
+				},
+				"@odata.nextLink": srv.URL + "/v1.0/groups/" + testGroupID + "/transitiveMembers/microsoft.graph.user?$skiptoken=2",
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"value": []map[string]any{{"mail": "carol@example.com", "accountEnabled": true}},
+		})
+	})
+	srv = httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testOptions(srv *httptest.Server) Options {
+	return Options{
+		TenantID:             "tenant",
+		ClientID:             "client",
+		ClientSecret:         "secret",
+		AuthorityURL:         srv.URL,
+		GraphURL:             srv.URL + "/",
+		CertificateAuthority: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})),
+	}
+}
+
+func TestClientMembers(t *testing.T) {
+	var tokenRequests atomic.Int32
+	srv := newTestServer(t, &tokenRequests)
+	c, err := NewClient(testOptions(srv))
+	require.NoError(t, err)
+
+	members, err := c.Members(context.Background(), "ops")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@corp.example.com", "carol@example.com"}, members)
+
+	members, err = c.Members(context.Background(), testGroupID)
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+	assert.Equal(t, int32(1), tokenRequests.Load(), "token should be cached")
+
+	_, err = c.Members(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+
+	_, err = c.Members(context.Background(), "9d3c1f2e-0000-4000-8000-000000000000")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+
+	_, err = c.Members(context.Background(), "o'brien")
+	assert.ErrorContains(t, err, "2 groups are named")
+}
+
+func TestClientTokenError(t *testing.T) {
+	var tokenRequests atomic.Int32
+	srv := newTestServer(t, &tokenRequests)
+	opts := testOptions(srv)
+	opts.ClientSecret = "wrong"
+	c, err := NewClient(opts)
+	require.NoError(t, err)
+
+	_, err = c.Token(context.Background())
+	assert.ErrorContains(t, err, "invalid_client: bad secret")
+}
+
+func TestClientRejectsForeignNextLink(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"value":           []map[string]any{{"mail": "alice@example.com"}},
+			"@odata.nextLink": "https://attacker.example.com/next",
+		})
+	}))
+	defer srv.Close()
+	c, err := NewClient(testOptions(srv))
+	require.NoError(t, err)
+
+	_, err = c.Members(context.Background(), testGroupID)
+	assert.ErrorContains(t, err, "another host")
+}
+
+func TestNewClientValidation(t *testing.T) {
+	_, err := NewClient(Options{TenantID: "t", ClientID: "c"})
+	assert.Error(t, err)
+
+	_, err = NewClient(Options{TenantID: "t", ClientID: "c", ClientSecret: "s", CertificateAuthority: "not a pem"})
+	assert.ErrorContains(t, err, "no valid PEM")
+
+	c, err := NewClient(Options{TenantID: "t", ClientID: "c", ClientSecret: "s"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGraphURL, c.opts.GraphURL)
+	assert.Equal(t, DefaultUserIdentifierAttributes, c.opts.UserIdentifierAttributes)
+}
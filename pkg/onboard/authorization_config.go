@@ -0,0 +1,65 @@
+package onboard
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The AuthorizationConfiguration types mirror apiserver.config.k8s.io; k8s.io/apiserver is not a
+// dependency of this module.
+
+type authorizationConfig struct {
+	metav1.TypeMeta `json:",inline"`
+	Authorizers     []authorizerConfig `json:"authorizers"`
+}
+
+type authorizerConfig struct {
+	Type    string                   `json:"type"`
+	Name    string                   `json:"name"`
+	Webhook *webhookAuthorizerConfig `json:"webhook,omitempty"`
+}
+
+type webhookAuthorizerConfig struct {
+	Timeout                                  metav1.Duration         `json:"timeout"`
+	AuthorizedTTL                            metav1.Duration         `json:"authorizedTTL"`
+	UnauthorizedTTL                          metav1.Duration         `json:"unauthorizedTTL"`
+	SubjectAccessReviewVersion               string                  `json:"subjectAccessReviewVersion"`
+	MatchConditionSubjectAccessReviewVersion string                  `json:"matchConditionSubjectAccessReviewVersion"`
+	FailurePolicy                            string                  `json:"failurePolicy"`
+	ConnectionInfo                           webhookConnectionInfo   `json:"connectionInfo"`
+	MatchConditions                          []webhookMatchCondition `json:"matchConditions,omitempty"`
+}
+
+type webhookConnectionInfo struct {
+	Type           string `json:"type"`
+	KubeConfigFile string `json:"kubeConfigFile,omitempty"`
+}
+
+type webhookMatchCondition struct {
+	Expression string `json:"expression"`
+}
+
+// authorizationConfiguration chains the breakglass webhook after the Node and RBAC authorizers, so it
+// is only consulted for requests RBAC does not allow
+func authorizationConfiguration(o Options) authorizationConfig {
+	conditions := make([]webhookMatchCondition, 0, len(o.MatchConditions))
+	for _, mc := range o.MatchConditions {
+		conditions = append(conditions, webhookMatchCondition{Expression: mc})
+	}
+	return authorizationConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: o.APIVersion, Kind: "AuthorizationConfiguration"},
+		Authorizers: []authorizerConfig{
+			{Type: "Node", Name: "node"},
+			{Type: "RBAC", Name: "rbac"},
+			{Type: "Webhook", Name: "breakglass", Webhook: &webhookAuthorizerConfig{
+				Timeout:                                  metav1.Duration{Duration: o.Timeout},
+				AuthorizedTTL:                            metav1.Duration{Duration: o.AuthorizedTTL},
+				UnauthorizedTTL:                          metav1.Duration{Duration: o.UnauthorizedTTL},
+				SubjectAccessReviewVersion:               "v1",
+				MatchConditionSubjectAccessReviewVersion: "v1",
+				FailurePolicy:                            o.FailurePolicy,
+				ConnectionInfo:                           webhookConnectionInfo{Type: "KubeConfigFile", KubeConfigFile: o.KubeconfigPath},
+				MatchConditions:                          conditions,
+			}},
+		},
+	}
+}
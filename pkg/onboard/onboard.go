@@ -0,0 +1,200 @@
+// Package onboard generates the configuration that wires a cluster's API server to the breakglass
+// authorization webhook, and the RBAC the breakglass hub needs on that cluster.
+package onboard
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultWebhookPath is the hub path the authorization webhook is served under
+	DefaultWebhookPath = "/api/breakglass/webhook/authorize/"
+	// DefaultKubeconfigPath is where the webhook kubeconfig is expected on the control plane nodes
+	DefaultKubeconfigPath = "/etc/kubernetes/breakglass-webhook-kubeconfig.yaml"
+	// DefaultAuthorizationConfigAPIVersion is the AuthorizationConfiguration version supported since Kubernetes 1.30
+	DefaultAuthorizationConfigAPIVersion = "apiserver.config.k8s.io/v1beta1"
+	// DefaultHubNamespace and DefaultHubServiceAccount name the identity the hub uses on the cluster
+	DefaultHubNamespace      = "breakglass-system"
+	DefaultHubServiceAccount = "breakglass-hub"
+
+	FailurePolicyDeny      = "Deny"
+	FailurePolicyNoOpinion = "NoOpinion"
+)
+
+// defaultMatchCondition sends only human users to the webhook. The webhook probe user of the
+// ClusterConfig checker is let through so the hub can tell whether the webhook is configured.
+var defaultMatchCondition = fmt.Sprintf("request.user == '%s' || "+
+	"('system:authenticated' in request.groups && !request.user.startsWith('system:') && !('system:serviceaccounts' in request.groups))",
+	breakglass.WebhookProbeUser)
+
+// Options controls the generated configuration
+type Options struct {
+	// ClusterName is the ClusterConfig name, used in the webhook URL
+	ClusterName string
+	// AccessReviewMode selects the RBAC the hub needs on the cluster
+	AccessReviewMode telekomv1alpha1.AccessReviewMode
+	// HubURL is the external base URL of the breakglass hub (e.g. https://breakglass.example.com)
+	HubURL string
+	// WebhookPath is the path of the webhook below HubURL (default DefaultWebhookPath)
+	WebhookPath string
+	// HubCAData is the PEM CA bundle the API server uses to verify the hub
+	HubCAData []byte
+	// InsecureSkipTLSVerify disables verification of the hub certificate (testing only)
+	InsecureSkipTLSVerify bool
+	// WebhookToken is the bearer token the API server presents to the hub
+	WebhookToken string
+	// KubeconfigPath is where the webhook kubeconfig is placed on the control plane nodes
+	KubeconfigPath string
+	// APIVersion of the AuthorizationConfiguration (default DefaultAuthorizationConfigAPIVersion)
+	APIVersion string
+	// Timeout of a webhook call (1s to 30s, default 3s)
+	Timeout time.Duration
+	// AuthorizedTTL and UnauthorizedTTL control decision caching in the API server (default 30s)
+	AuthorizedTTL   time.Duration
+	UnauthorizedTTL time.Duration
+	// FailurePolicy is Deny or NoOpinion (default Deny)
+	FailurePolicy string
+	// MatchConditions replace the default CEL match condition when set
+	MatchConditions []string
+	// HubNamespace and HubServiceAccount name the identity created for the hub on the cluster
+	HubNamespace      string
+	HubServiceAccount string
+}
+
+// OptionsForClusterConfig returns options for onboarding the cluster of the ClusterConfig
+func OptionsForClusterConfig(cc *telekomv1alpha1.ClusterConfig) Options {
+	return Options{ClusterName: cc.Name, AccessReviewMode: cc.Spec.AccessReviewMode}
+}
+
+// Default fills unset options with their defaults
+func (o *Options) Default() {
+	if o.WebhookPath == "" {
+		o.WebhookPath = DefaultWebhookPath
+	}
+	if o.KubeconfigPath == "" {
+		o.KubeconfigPath = DefaultKubeconfigPath
+	}
+	if o.APIVersion == "" {
+		o.APIVersion = DefaultAuthorizationConfigAPIVersion
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 3 * time.Second
+	}
+	if o.AuthorizedTTL == 0 {
+		o.AuthorizedTTL = 30 * time.Second
+	}
+	if o.UnauthorizedTTL == 0 {
+		o.UnauthorizedTTL = 30 * time.Second
+	}
+	if o.FailurePolicy == "" {
+		o.FailurePolicy = FailurePolicyDeny
+	}
+	if len(o.MatchConditions) == 0 {
+		o.MatchConditions = []string{defaultMatchCondition}
+	}
+	if o.HubNamespace == "" {
+		o.HubNamespace = DefaultHubNamespace
+	}
+	if o.HubServiceAccount == "" {
+		o.HubServiceAccount = DefaultHubServiceAccount
+	}
+	if o.AccessReviewMode == "" {
+		o.AccessReviewMode = telekomv1alpha1.AccessReviewModeImpersonation
+	}
+}
+
+// Validate checks the options the way the API server would check the generated configuration
+func (o Options) Validate() error {
+	var errs []error
+	if o.ClusterName == "" {
+		errs = append(errs, errors.New("cluster name is required"))
+	}
+	if u, err := url.Parse(o.HubURL); err != nil || u.Host == "" {
+		errs = append(errs, fmt.Errorf("hub URL %q is not an absolute URL", o.HubURL))
+	} else if u.Scheme != "https" {
+		errs = append(errs, fmt.Errorf("hub URL %q must use https", o.HubURL))
+	}
+	if len(o.HubCAData) == 0 && !o.InsecureSkipTLSVerify {
+		errs = append(errs, errors.New("hub CA is required unless TLS verification is disabled"))
+	}
+	if o.Timeout < time.Second || o.Timeout > 30*time.Second {
+		errs = append(errs, fmt.Errorf("timeout %s must be between 1s and 30s", o.Timeout))
+	}
+	if o.AuthorizedTTL < 0 || o.UnauthorizedTTL < 0 {
+		errs = append(errs, errors.New("cache TTLs must not be negative"))
+	}
+	if o.FailurePolicy != FailurePolicyDeny && o.FailurePolicy != FailurePolicyNoOpinion {
+		errs = append(errs, fmt.Errorf("failure policy %q must be %s or %s", o.FailurePolicy, FailurePolicyDeny, FailurePolicyNoOpinion))
+	}
+	for i, mc := range o.MatchConditions {
+		if strings.TrimSpace(mc) == "" {
+			errs = append(errs, fmt.Errorf("match condition %d is empty", i))
+		}
+	}
+	switch o.AccessReviewMode {
+	case telekomv1alpha1.AccessReviewModeImpersonation, telekomv1alpha1.AccessReviewModeSubjectAccessReview:
+	default:
+		errs = append(errs, fmt.Errorf("unknown access review mode %q", o.AccessReviewMode))
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookURL returns the URL the API server calls for the cluster
+func (o Options) WebhookURL() string {
+	return strings.TrimSuffix(o.HubURL, "/") + "/" + strings.Trim(o.WebhookPath, "/") + "/" + url.PathEscape(o.ClusterName)
+}
+
+// Result is the generated configuration
+type Result struct {
+	// WebhookKubeconfig is placed at Options.KubeconfigPath on the control plane nodes
+	WebhookKubeconfig []byte
+	// AuthorizationConfig is passed to the API server with --authorization-config
+	AuthorizationConfig []byte
+	// RBAC grants the hub's ServiceAccount the rights it needs on the cluster
+	RBAC []client.Object
+}
+
+// Generate renders the configuration for the options. Options are defaulted and validated first.
+func Generate(o Options) (*Result, error) {
+	o.Default()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := webhookKubeconfig(o)
+	if err != nil {
+		return nil, err
+	}
+	authzConfig, err := yaml.Marshal(authorizationConfiguration(o))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render AuthorizationConfiguration: %w", err)
+	}
+	return &Result{WebhookKubeconfig: kubeconfig, AuthorizationConfig: authzConfig, RBAC: SpokeRBAC(o)}, nil
+}
+
+func webhookKubeconfig(o Options) ([]byte, error) {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["breakglass"] = &clientcmdapi.Cluster{
+		Server:                   o.WebhookURL(),
+		CertificateAuthorityData: o.HubCAData,
+		InsecureSkipTLSVerify:    o.InsecureSkipTLSVerify,
+	}
+	cfg.AuthInfos["kube-apiserver"] = &clientcmdapi.AuthInfo{Token: o.WebhookToken}
+	cfg.Contexts["webhook"] = &clientcmdapi.Context{Cluster: "breakglass", AuthInfo: "kube-apiserver"}
+	cfg.CurrentContext = "webhook"
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render webhook kubeconfig: %w", err)
+	}
+	return out, nil
+}
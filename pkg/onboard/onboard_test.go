@@ -0,0 +1,111 @@
+package onboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+func validOptions() Options {
+	return Options{
+		ClusterName:  "prod-1",
+		HubURL:       "https://breakglass.example.com/",
+		HubCAData:    []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"),
+		WebhookToken: "secret",
+	}
+}
+
+func TestWebhookURL(t *testing.T) {
+	o := validOptions()
+	o.Default()
+	assert.Equal(t, "https://breakglass.example.com/api/breakglass/webhook/authorize/prod-1", o.WebhookURL())
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Options)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(*Options) {}},
+		{name: "http hub", mutate: func(o *Options) { o.HubURL = "http://breakglass.example.com" }, wantErr: "must use https"},
+		{name: "relative hub", mutate: func(o *Options) { o.HubURL = "breakglass" }, wantErr: "not an absolute URL"},
+		{name: "missing CA", mutate: func(o *Options) { o.HubCAData = nil }, wantErr: "hub CA is required"},
+		{name: "insecure without CA", mutate: func(o *Options) { o.HubCAData = nil; o.InsecureSkipTLSVerify = true }},
+		{name: "timeout too long", mutate: func(o *Options) { o.Timeout = time.Minute }, wantErr: "between 1s and 30s"},
+		{name: "unknown failure policy", mutate: func(o *Options) { o.FailurePolicy = "Allow" }, wantErr: "failure policy"},
+		{name: "empty match condition", mutate: func(o *Options) { o.MatchConditions = []string{" "} }, wantErr: "match condition 0 is empty"},
+		{name: "unknown mode", mutate: func(o *Options) { o.AccessReviewMode = "Magic" }, wantErr: "unknown access review mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := validOptions()
+			o.Default()
+			tt.mutate(&o)
+			err := o.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	o := validOptions()
+	o.AccessReviewMode = telekomv1alpha1.AccessReviewModeSubjectAccessReview
+	o.FailurePolicy = FailurePolicyNoOpinion
+	o.Timeout = 5 * time.Second
+	res, err := Generate(o)
+	require.NoError(t, err)
+
+	kubeconfig, err := clientcmd.Load(res.WebhookKubeconfig)
+	require.NoError(t, err)
+	ctx := kubeconfig.Contexts[kubeconfig.CurrentContext]
+	require.NotNil(t, ctx)
+	assert.Equal(t, "https://breakglass.example.com/api/breakglass/webhook/authorize/prod-1", kubeconfig.Clusters[ctx.Cluster].Server)
+	assert.Equal(t, o.HubCAData, kubeconfig.Clusters[ctx.Cluster].CertificateAuthorityData)
+	assert.Equal(t, "secret", kubeconfig.AuthInfos[ctx.AuthInfo].Token)
+
+	var authz authorizationConfig
+	require.NoError(t, yaml.UnmarshalStrict(res.AuthorizationConfig, &authz))
+	assert.Equal(t, DefaultAuthorizationConfigAPIVersion, authz.APIVersion)
+	assert.Equal(t, "AuthorizationConfiguration", authz.Kind)
+	require.Len(t, authz.Authorizers, 3)
+	assert.Equal(t, []string{"Node", "RBAC", "Webhook"}, []string{authz.Authorizers[0].Type, authz.Authorizers[1].Type, authz.Authorizers[2].Type})
+	webhook := authz.Authorizers[2].Webhook
+	require.NotNil(t, webhook)
+	assert.Equal(t, 5*time.Second, webhook.Timeout.Duration)
+	assert.Equal(t, FailurePolicyNoOpinion, webhook.FailurePolicy)
+	assert.Equal(t, DefaultKubeconfigPath, webhook.ConnectionInfo.KubeConfigFile)
+	require.Len(t, webhook.MatchConditions, 1)
+	assert.Contains(t, webhook.MatchConditions[0].Expression, breakglass.WebhookProbeUser, "the checker's webhook probe must reach the webhook")
+
+	assert.Len(t, res.RBAC, 5)
+}
+
+func TestGenerateCustomMatchConditions(t *testing.T) {
+	o := validOptions()
+	o.MatchConditions = []string{"request.user == 'alice'", "'admins' in request.groups"}
+	res, err := Generate(o)
+	require.NoError(t, err)
+	var authz authorizationConfig
+	require.NoError(t, yaml.UnmarshalStrict(res.AuthorizationConfig, &authz))
+	assert.Equal(t, []webhookMatchCondition{{Expression: "request.user == 'alice'"}, {Expression: "'admins' in request.groups"}},
+		authz.Authorizers[2].Webhook.MatchConditions)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate(Options{ClusterName: "prod-1", HubURL: "http://hub"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must use https")
+	assert.Contains(t, err.Error(), "hub CA is required")
+}
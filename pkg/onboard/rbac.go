@@ -0,0 +1,239 @@
+package onboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ManagedByLabel marks the objects created by the onboarding tool
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "breakglass-onboard"
+)
+
+// SpokeRBAC returns the objects that give the hub a ServiceAccount on the cluster holding exactly the
+// rights breakglass needs for the access review mode. The token of the ServiceAccount is stored in
+// the returned Secret and is what the hub's ClusterConfig credentials should use.
+func SpokeRBAC(o Options) []client.Object {
+	o.Default()
+	labels := map[string]string{ManagedByLabel: ManagedByValue}
+	meta := func(name, namespace string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}
+	}
+	tokenSecret := meta(o.HubServiceAccount+"-token", o.HubNamespace)
+	tokenSecret.Annotations = map[string]string{corev1.ServiceAccountNameKey: o.HubServiceAccount}
+	return []client.Object{
+		&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: meta(o.HubNamespace, ""),
+		},
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta(o.HubServiceAccount, o.HubNamespace),
+		},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: tokenSecret,
+			Type:       corev1.SecretTypeServiceAccountToken,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: meta(o.HubServiceAccount, ""),
+			Rules:      breakglass.SpokePolicyRules(o.AccessReviewMode),
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: meta(o.HubServiceAccount, ""),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: o.HubServiceAccount},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: o.HubServiceAccount, Namespace: o.HubNamespace}},
+		},
+	}
+}
+
+// RenderObjects renders objects as a multi-document YAML manifest
+func RenderObjects(objs []client.Object) ([]byte, error) {
+	docs := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", describe(obj), err)
+		}
+		docs = append(docs, string(out))
+	}
+	return []byte(strings.Join(docs, "---\n")), nil
+}
+
+// ApplyRBAC creates the objects on the cluster or updates the fields the onboarding tool owns. It
+// returns one line per object describing what was done.
+func ApplyRBAC(ctx context.Context, c client.Client, objs []client.Object) ([]string, error) {
+	var actions []string
+	for _, desired := range objs {
+		live := desired.DeepCopyObject().(client.Object)
+		err := c.Get(ctx, client.ObjectKeyFromObject(desired), live)
+		if apierrors.IsNotFound(err) {
+			if err := c.Create(ctx, desired.DeepCopyObject().(client.Object)); err != nil {
+				return actions, fmt.Errorf("failed to create %s: %w", describe(desired), err)
+			}
+			actions = append(actions, "created "+describe(desired))
+			continue
+		}
+		if err != nil {
+			return actions, fmt.Errorf("failed to get %s: %w", describe(desired), err)
+		}
+		changed, err := mergeOwnedFields(live, desired)
+		if err != nil {
+			return actions, err
+		}
+		if !changed {
+			actions = append(actions, "unchanged "+describe(desired))
+			continue
+		}
+		if err := c.Update(ctx, live); err != nil {
+			return actions, fmt.Errorf("failed to update %s: %w", describe(desired), err)
+		}
+		actions = append(actions, "updated "+describe(desired))
+	}
+	return actions, nil
+}
+
+// mergeOwnedFields copies the fields the onboarding tool owns from desired into live
+func mergeOwnedFields(live, desired client.Object) (bool, error) {
+	before := live.DeepCopyObject()
+	live.SetLabels(mergeMaps(live.GetLabels(), desired.GetLabels()))
+	live.SetAnnotations(mergeMaps(live.GetAnnotations(), desired.GetAnnotations()))
+	switch l := live.(type) {
+	case *rbacv1.ClusterRole:
+		l.Rules = desired.(*rbacv1.ClusterRole).Rules
+	case *rbacv1.ClusterRoleBinding:
+		d := desired.(*rbacv1.ClusterRoleBinding)
+		if l.RoleRef != d.RoleRef {
+			return false, fmt.Errorf("%s binds %s %s, roleRef is immutable; delete the binding and onboard again", describe(desired), l.RoleRef.Kind, l.RoleRef.Name)
+		}
+		l.Subjects = d.Subjects
+	case *corev1.Secret:
+		if d := desired.(*corev1.Secret); l.Type != d.Type {
+			return false, fmt.Errorf("%s has type %s instead of %s; delete it and onboard again", describe(desired), l.Type, d.Type)
+		}
+	}
+	return !apiequality.Semantic.DeepEqual(before, live), nil
+}
+
+func mergeMaps(live, desired map[string]string) map[string]string {
+	if len(desired) == 0 {
+		return live
+	}
+	out := make(map[string]string, len(live)+len(desired))
+	for k, v := range live {
+		out[k] = v
+	}
+	for k, v := range desired {
+		out[k] = v
+	}
+	return out
+}
+
+// DiffRBAC returns a unified diff between the objects on the cluster and the generated ones. Only the
+// fields the onboarding tool owns are compared. An empty string means the cluster is up to date.
+func DiffRBAC(ctx context.Context, c client.Reader, objs []client.Object) (string, error) {
+	var diff strings.Builder
+	for _, desired := range objs {
+		var liveView []byte
+		live := desired.DeepCopyObject().(client.Object)
+		err := c.Get(ctx, client.ObjectKeyFromObject(desired), live)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return "", fmt.Errorf("failed to get %s: %w", describe(desired), err)
+		default:
+			if liveView, err = ownedView(live, desired); err != nil {
+				return "", err
+			}
+		}
+		desiredView, err := ownedView(desired, desired)
+		if err != nil {
+			return "", err
+		}
+		name := strings.ReplaceAll(describe(desired), " ", "/")
+		d, err := DiffText("live/"+name, "generated/"+name, liveView, desiredView)
+		if err != nil {
+			return "", err
+		}
+		diff.WriteString(d)
+	}
+	return diff.String(), nil
+}
+
+// ownedView renders the fields of obj the onboarding tool owns, restricting labels and annotations to
+// the keys set on desired
+func ownedView(obj, desired client.Object) ([]byte, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	view := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &view); err != nil {
+		return nil, err
+	}
+	view["apiVersion"] = desired.GetObjectKind().GroupVersionKind().GroupVersion().String()
+	view["kind"] = desired.GetObjectKind().GroupVersionKind().Kind
+	metadata := map[string]interface{}{"name": obj.GetName()}
+	if obj.GetNamespace() != "" {
+		metadata["namespace"] = obj.GetNamespace()
+	}
+	if labels := restrictKeys(obj.GetLabels(), desired.GetLabels()); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if annotations := restrictKeys(obj.GetAnnotations(), desired.GetAnnotations()); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	view["metadata"] = metadata
+	delete(view, "status")
+	delete(view, "spec")
+	delete(view, "data")
+	delete(view, "secrets")
+	return yaml.Marshal(view)
+}
+
+func restrictKeys(m, keys map[string]string) map[string]string {
+	out := map[string]string{}
+	for k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// DiffText returns a unified diff of two texts, or an empty string when they are equal
+func DiffText(fromName, toName string, from, to []byte) (string, error) {
+	if string(from) == string(to) {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(from)),
+		B:        difflib.SplitLines(string(to)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+func describe(obj client.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if obj.GetNamespace() != "" {
+		return fmt.Sprintf("%s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+	}
+	return fmt.Sprintf("%s %s", kind, obj.GetName())
+}
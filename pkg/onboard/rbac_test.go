@@ -0,0 +1,127 @@
+package onboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSpokeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestSpokeRBAC(t *testing.T) {
+	objs := SpokeRBAC(Options{AccessReviewMode: telekomv1alpha1.AccessReviewModeSubjectAccessReview})
+	require.Len(t, objs, 5)
+	role := objs[3].(*rbacv1.ClusterRole)
+	assert.Equal(t, DefaultHubServiceAccount, role.Name)
+	require.Len(t, role.Rules, 1)
+	assert.Equal(t, []string{"subjectaccessreviews"}, role.Rules[0].Resources)
+	binding := objs[4].(*rbacv1.ClusterRoleBinding)
+	assert.Equal(t, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: DefaultHubServiceAccount, Namespace: DefaultHubNamespace}, binding.Subjects[0])
+	secret := objs[2].(*corev1.Secret)
+	assert.Equal(t, corev1.SecretTypeServiceAccountToken, secret.Type)
+	assert.Equal(t, DefaultHubServiceAccount, secret.Annotations[corev1.ServiceAccountNameKey])
+
+	out, err := RenderObjects(objs)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "kind: ClusterRoleBinding")
+	assert.Contains(t, string(out), "---\n")
+}
+
+func TestApplyRBAC(t *testing.T) {
+	ctx := context.Background()
+	objs := SpokeRBAC(Options{AccessReviewMode: telekomv1alpha1.AccessReviewModeSubjectAccessReview})
+	c := newSpokeClient(t)
+
+	actions, err := ApplyRBAC(ctx, c, objs)
+	require.NoError(t, err)
+	require.Len(t, actions, 5)
+	for _, a := range actions {
+		assert.Contains(t, a, "created ")
+	}
+
+	actions, err = ApplyRBAC(ctx, c, objs)
+	require.NoError(t, err)
+	for _, a := range actions {
+		assert.Contains(t, a, "unchanged ")
+	}
+
+	// switching the mode widens the ClusterRole
+	actions, err = ApplyRBAC(ctx, c, SpokeRBAC(Options{AccessReviewMode: telekomv1alpha1.AccessReviewModeImpersonation}))
+	require.NoError(t, err)
+	assert.Contains(t, actions, "updated ClusterRole "+DefaultHubServiceAccount)
+	role := &rbacv1.ClusterRole{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: DefaultHubServiceAccount}, role))
+	assert.Len(t, role.Rules, 3)
+}
+
+func TestApplyRBAC_PreservesForeignLabels(t *testing.T) {
+	ctx := context.Background()
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: DefaultHubNamespace, Labels: map[string]string{"team": "platform"}}}
+	c := newSpokeClient(t, existing)
+
+	actions, err := ApplyRBAC(ctx, c, SpokeRBAC(Options{})[:1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"updated Namespace " + DefaultHubNamespace}, actions)
+	ns := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: DefaultHubNamespace}, ns))
+	assert.Equal(t, "platform", ns.Labels["team"])
+	assert.Equal(t, ManagedByValue, ns.Labels[ManagedByLabel])
+}
+
+func TestApplyRBAC_ImmutableRoleRef(t *testing.T) {
+	existing := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultHubServiceAccount},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+	c := newSpokeClient(t, existing)
+	_, err := ApplyRBAC(context.Background(), c, SpokeRBAC(Options{})[4:])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "roleRef is immutable")
+}
+
+func TestDiffRBAC(t *testing.T) {
+	ctx := context.Background()
+	objs := SpokeRBAC(Options{AccessReviewMode: telekomv1alpha1.AccessReviewModeSubjectAccessReview})
+	c := newSpokeClient(t)
+
+	diff, err := DiffRBAC(ctx, c, objs)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+++ generated/ClusterRole/"+DefaultHubServiceAccount)
+	assert.Contains(t, diff, "+  - subjectaccessreviews")
+
+	_, err = ApplyRBAC(ctx, c, objs)
+	require.NoError(t, err)
+	diff, err = DiffRBAC(ctx, c, objs)
+	require.NoError(t, err)
+	assert.Empty(t, diff, "applied objects must not show a diff")
+
+	diff, err = DiffRBAC(ctx, c, SpokeRBAC(Options{AccessReviewMode: telekomv1alpha1.AccessReviewModeImpersonation}))
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+  - impersonate")
+	assert.NotContains(t, diff, "Namespace")
+}
+
+func TestDiffText(t *testing.T) {
+	d, err := DiffText("a", "b", []byte("x\n"), []byte("x\n"))
+	require.NoError(t, err)
+	assert.Empty(t, d)
+	d, err = DiffText("a", "b", []byte("x\n"), []byte("y\n"))
+	require.NoError(t, err)
+	assert.Contains(t, d, "-x")
+	assert.Contains(t, d, "+y")
+}
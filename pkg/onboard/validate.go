@@ -0,0 +1,64 @@
+package onboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	telekomv1alpha1 "github.com/telekom/k8s-breakglass/api/v1alpha1"
+	"github.com/telekom/k8s-breakglass/pkg/breakglass"
+	"k8s.io/client-go/rest"
+)
+
+// Validation is the outcome of checking an onboarded cluster with the hub's credentials
+type Validation struct {
+	// MissingPermissions are rights breakglass needs that the hub's credentials lack
+	MissingPermissions []string
+	// ExcessPermissions are rights the hub's credentials hold that breakglass does not need
+	ExcessPermissions []string
+	// WebhookConfigured reports whether the API server consults the breakglass authorization webhook
+	WebhookConfigured bool
+}
+
+// OK reports whether the cluster is fully onboarded
+func (v Validation) OK() bool {
+	return len(v.MissingPermissions) == 0 && v.WebhookConfigured
+}
+
+// String summarizes the validation for humans
+func (v Validation) String() string {
+	var b strings.Builder
+	if len(v.MissingPermissions) == 0 {
+		b.WriteString("permissions: ok\n")
+	} else {
+		fmt.Fprintf(&b, "permissions: missing %s\n", strings.Join(v.MissingPermissions, ", "))
+	}
+	if len(v.ExcessPermissions) > 0 {
+		fmt.Fprintf(&b, "permissions: excess %s\n", strings.Join(v.ExcessPermissions, ", "))
+	}
+	if v.WebhookConfigured {
+		b.WriteString("authorization webhook: configured\n")
+	} else {
+		b.WriteString("authorization webhook: not configured (install the generated AuthorizationConfiguration and restart the API server)\n")
+	}
+	return b.String()
+}
+
+// Validate runs the ClusterConfig checker's permission self-test and webhook probe against the cluster
+// with the given credentials, which should be the ones the hub uses for the cluster
+func Validate(ctx context.Context, cfg *rest.Config, mode telekomv1alpha1.AccessReviewMode) (Validation, error) {
+	var v Validation
+	var err error
+	v.MissingPermissions, v.ExcessPermissions, err = breakglass.CheckSpokePermissions(ctx, cfg, mode)
+	if err != nil {
+		return v, fmt.Errorf("permission self-test failed: %w", err)
+	}
+	if len(v.MissingPermissions) > 0 {
+		// the probe needs create subjectaccessreviews
+		return v, nil
+	}
+	if v.WebhookConfigured, err = breakglass.ProbeAuthorizationWebhook(ctx, cfg); err != nil {
+		return v, err
+	}
+	return v, nil
+}
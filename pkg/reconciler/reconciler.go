@@ -10,6 +10,7 @@ import (
 	"github.com/telekom/k8s-breakglass/pkg/cli"
 	"github.com/telekom/k8s-breakglass/pkg/config"
 	"github.com/telekom/k8s-breakglass/pkg/indexer"
+	"github.com/telekom/k8s-breakglass/pkg/mail"
 	"github.com/telekom/k8s-breakglass/pkg/metrics"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -65,6 +66,7 @@ func NewManager(
 // - Metrics server configuration with secure serving
 // - Field index setup for efficient queries
 // - IdentityProvider reconciler setup
+// - MailProvider reconciler setup (health checks and template override validation)
 // - Manager startup and leader election
 // - Broadcasting leadership signal to background loops when acquired
 func Setup(
@@ -72,6 +74,7 @@ func Setup(
 	mgr ctrl.Manager,
 	idpLoader *config.IdentityProviderLoader,
 	server *api.Server,
+	mailTemplateLoader *mail.TemplateLoader,
 	log *zap.SugaredLogger,
 ) error {
 	// Register health check handlers for liveness and readiness probes
@@ -136,6 +139,31 @@ func Setup(
 	}
 	log.Infow("Successfully registered BreakglassEscalation reconciler", "resyncPeriod", "10m")
 
+	// Register MailProvider Reconciler with controller-runtime manager
+	// It reports SMTP health and validates template overrides; changes reload the shared mail templates
+	log.Debugw("Setting up MailProvider reconciler")
+	mailProviderReconciler := &config.MailProviderReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Log:               log,
+		TemplateValidator: mail.ValidateTemplateOverrides,
+		OnTemplatesChange: func() {
+			if mailTemplateLoader == nil {
+				return
+			}
+			if err := mailTemplateLoader.Reload(ctx); err != nil {
+				log.Warnw("Failed to reload mail template overrides", "error", err)
+			}
+		},
+	}
+	if mailTemplateLoader != nil {
+		mailProviderReconciler.GlobalTemplatesConfigMap = mailTemplateLoader.GlobalConfigMap()
+	}
+	if err := mailProviderReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to setup MailProvider reconciler with manager: %w", err)
+	}
+	log.Infow("Successfully registered MailProvider reconciler")
+
 	// Note: Leadership election is NOT handled by the manager at this level.
 	// Background loops (cleanup, escalation updater, cluster config checker) use the resourcelock
 	// to coordinate and run only on the leader. The signal propagation to those loops happens
@@ -16,7 +16,6 @@ import (
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	"github.com/telekom/k8s-breakglass/api/v1alpha1"
@@ -238,6 +237,7 @@ type WebhookController struct {
 	sesManager   *breakglass.SessionManager
 	escalManager *breakglass.EscalationManager
 	canDoFn      breakglass.CanGroupsDoFunction
+	sarCanDoFn   breakglass.CanGroupsDoFunction
 	ccProvider   *cluster.ClientProvider
 	denyEval     *policy.Evaluator
 }
@@ -310,6 +310,13 @@ func (wc *WebhookController) handleAuthorize(c *gin.Context) {
 	actionSummary := summarizeAction(&sar)
 
 	username := sar.Spec.User
+	// The ClusterConfig checker sends a review for this user through the cluster's API server to find out
+	// whether the cluster consults this webhook; answer it without touching sessions or escalations
+	if username == breakglass.WebhookProbeUser {
+		reqLog.Debug("Answering authorization webhook probe")
+		c.JSON(http.StatusOK, &SubjectAccessReviewResponse{ApiVersion: sar.APIVersion, Kind: sar.Kind, Status: SubjectAccessReviewResponseStatus{Allowed: false, Reason: breakglass.WebhookProbeReason}})
+		return
+	}
 	reqLog.Infow("Processing authorization", "username", username, "groupsRequested", sar.Spec.Groups)
 
 	var clusterCfg *v1alpha1.ClusterConfig
@@ -492,23 +499,30 @@ func (wc *WebhookController) handleAuthorize(c *gin.Context) {
 	// Perform standard RBAC check against target cluster (not hub) using its kubeconfig
 	var can bool
 	var rbacErr error
+	// Granted groups are hub group names; RBAC on the target cluster may know them under other names
+	var groupMapping *v1alpha1.GroupMapping
+	if clusterCfg != nil {
+		groupMapping = clusterCfg.Spec.GroupMapping
+	}
+	rbacGroups := groupMapping.GroupsToCluster(groups)
+	canDoFn := wc.canDoFnFor(clusterCfg)
 	// Log input to RBAC check for easier debugging
-	reqLog.Debugw("Invoking RBAC canDoFn", "groups", groups, "resourceAttributes", sar.Spec.ResourceAttributes, "cluster", clusterName)
+	reqLog.Debugw("Invoking RBAC canDoFn", "groups", rbacGroups, "resourceAttributes", sar.Spec.ResourceAttributes, "cluster", clusterName)
 
 	var sessionSARSkippedErr error
 	if wc.ccProvider != nil {
 		if rc, rerr := wc.ccProvider.GetRESTConfig(ctx, clusterName); rerr == nil {
-			can, rbacErr = wc.canDoFn(ctx, rc, groups, sar, clusterName)
+			can, rbacErr = canDoFn(ctx, rc, rbacGroups, sar, clusterName)
 		} else {
 			// downgrade to info; this will commonly happen if RBAC does not yet allow clusterconfig get
 			reqLog.With("error", rerr).Info("Failed to get REST config for standard RBAC check; using legacy fallback")
 			// Record that session SAR checks will be skipped because we couldn't load REST config
 			sessionSARSkippedErr = rerr
 			// Still invoke injected canDoFn with nil to allow tests to control behavior
-			can, rbacErr = wc.canDoFn(ctx, nil, groups, sar, clusterName)
+			can, rbacErr = canDoFn(ctx, nil, rbacGroups, sar, clusterName)
 		}
 	} else {
-		can, rbacErr = wc.canDoFn(ctx, nil, groups, sar, clusterName)
+		can, rbacErr = canDoFn(ctx, nil, rbacGroups, sar, clusterName)
 	}
 	if rbacErr != nil {
 		msg := rbacErr.Error()
@@ -548,7 +562,7 @@ func (wc *WebhookController) handleAuthorize(c *gin.Context) {
 				reqLog.With("error", err.Error()).Warn("Unable to load target cluster rest.Config for SAR; skipping session SAR checks")
 				// mark that we skipped session SAR checks for diagnostics
 				sessionSARSkippedErr = err
-			} else if allowedSession, grp, sesName, impersonated := wc.authorizeViaSessions(ctx, rc, sessions, sar, clusterName, reqLog); allowedSession {
+			} else if allowedSession, grp, sesName, impersonated := wc.authorizeViaSessions(ctx, rc, sessions, sar, clusterName, groupMapping, reqLog); allowedSession {
 				reqLog.With("grantedGroup", grp, "session", sesName, "impersonatedGroup", impersonated).Debug("Authorized via breakglass session group on target cluster")
 				allowed = true
 				allowSource = "session"
@@ -776,6 +790,7 @@ func NewWebhookController(log *zap.SugaredLogger,
 		sesManager:   sesManager,
 		escalManager: escalManager,
 		canDoFn:      breakglass.CanGroupsDo,
+		sarCanDoFn:   breakglass.CanGroupsDoViaSubjectAccessReview,
 		ccProvider:   ccProvider,
 		denyEval:     denyEval,
 	}
@@ -857,7 +872,8 @@ func dedupeStrings(in []string) []string {
 }
 
 // authorizeViaSessions performs per-session SubjectAccessReviews using the session's granted group.
-func (wc *WebhookController) authorizeViaSessions(ctx context.Context, rc *rest.Config, sessions []v1alpha1.BreakglassSession, incoming authorizationv1.SubjectAccessReview, clusterName string, reqLog ...*zap.SugaredLogger) (bool, string, string, string) {
+// With a group mapping the granted group is impersonated under its cluster name only.
+func (wc *WebhookController) authorizeViaSessions(ctx context.Context, rc *rest.Config, sessions []v1alpha1.BreakglassSession, incoming authorizationv1.SubjectAccessReview, clusterName string, groupMapping *v1alpha1.GroupMapping, reqLog ...*zap.SugaredLogger) (bool, string, string, string) {
 	var logger *zap.SugaredLogger
 	if len(reqLog) > 0 {
 		logger = reqLog[0]
@@ -865,7 +881,7 @@ func (wc *WebhookController) authorizeViaSessions(ctx context.Context, rc *rest.
 	if len(sessions) == 0 || incoming.Spec.ResourceAttributes == nil {
 		return false, "", "", ""
 	}
-	clientset, err := kubernetes.NewForConfig(rc)
+	clientset, err := cluster.DefaultClientPool.SharedClientset(rc, rest.ImpersonationConfig{})
 	if err != nil {
 		if logger != nil {
 			logger.With("error", err).Error("failed creating clientset for session SAR")
@@ -876,76 +892,12 @@ func (wc *WebhookController) authorizeViaSessions(ctx context.Context, rc *rest.
 	}
 	sarClient := clientset.AuthorizationV1().SubjectAccessReviews()
 	for _, s := range sessions {
-		var allowedGroupsToCheck []string
-		// Resolve escalation via OwnerReferences first
-		if len(s.OwnerReferences) > 0 && wc.escalManager != nil {
-			for _, or := range s.OwnerReferences {
-				esc, eerr := wc.escalManager.GetBreakglassEscalation(ctx, s.Namespace, or.Name)
-				if eerr != nil {
-					if logger != nil {
-						logger.With("error", eerr, "ownerRef", or, "session", s.Name).Debug("failed to lookup escalation for session ownerRef")
-					} else if wc.log != nil {
-						wc.log.With("error", eerr, "ownerRef", or, "session", s.Name).Debug("failed to lookup escalation for session ownerRef")
-					}
-					continue
-				}
-				allowedGroupsToCheck = esc.Spec.Allowed.Groups
-				break
-			}
-		}
-
-		if len(allowedGroupsToCheck) == 0 {
-			// Fallback: when no escalation allowed groups are available, try
-			// the plain granted group so sessions without explicit escalation
-			// ownerRefs still enable standard session-based SAR checks.
-			allowedGroupsToCheck = []string{s.Spec.GrantedGroup}
-			if logger != nil {
-				logger.Debugw("No escalation allowed groups found; falling back to session granted group for prefix detection", "session", s.Name, "grantedGroup", s.Spec.GrantedGroup)
-			} else if wc.log != nil {
-				wc.log.Debugw("No escalation allowed groups found; falling back to session granted group for prefix detection", "session", s.Name, "grantedGroup", s.Spec.GrantedGroup)
-			}
-		}
-
-		prefixes := wc.config.Kubernetes.OIDCPrefixes
-		// Find a primary prefix by matching incoming groups to allowed groups
-		var primaryPrefix string
-		if incoming.Spec.Groups != nil && len(prefixes) > 0 {
-			for _, ig := range incoming.Spec.Groups {
-				for _, allowed := range allowedGroupsToCheck {
-					for _, p := range prefixes {
-						if strings.HasPrefix(ig, p) && strings.HasSuffix(ig, allowed) {
-							primaryPrefix = p
-							break
-						}
-					}
-					if primaryPrefix != "" {
-						break
-					}
-				}
-				if primaryPrefix != "" {
-					break
-				}
-			}
-		}
-
-		// Build ordered list of impersonation groups to try. We include the plain
-		// granted group as a baseline. If a primary prefix is detected, try it
-		// first, then the plain group, then remaining prefixes. Otherwise try
-		// plain group first followed by configured prefixes.
-		groupsToTry := make([]string, 0, len(prefixes)+1)
-		if primaryPrefix != "" {
-			groupsToTry = append(groupsToTry, primaryPrefix+s.Spec.GrantedGroup)
-			groupsToTry = append(groupsToTry, s.Spec.GrantedGroup)
-			for _, p := range prefixes {
-				if p != primaryPrefix {
-					groupsToTry = append(groupsToTry, p+s.Spec.GrantedGroup)
-				}
-			}
+		var groupsToTry []string
+		if groupMapping != nil {
+			// The ClusterConfig declares the cluster's group names, so there is nothing to guess
+			groupsToTry = []string{groupMapping.ToCluster(s.Spec.GrantedGroup)}
 		} else {
-			groupsToTry = append(groupsToTry, s.Spec.GrantedGroup)
-			for _, p := range prefixes {
-				groupsToTry = append(groupsToTry, p+s.Spec.GrantedGroup)
-			}
+			groupsToTry = wc.guessImpersonationGroups(ctx, s, incoming, logger)
 		}
 
 		if logger != nil {
@@ -1005,6 +957,94 @@ func (wc *WebhookController) authorizeViaSessions(ctx context.Context, rc *rest.
 	return false, "", "", ""
 }
 
+// guessImpersonationGroups returns the candidate cluster names of a session's granted group for
+// clusters without a group mapping, derived from the configured OIDC prefixes and the prefix
+// found on the incoming request's groups.
+func (wc *WebhookController) guessImpersonationGroups(ctx context.Context, s v1alpha1.BreakglassSession, incoming authorizationv1.SubjectAccessReview, logger *zap.SugaredLogger) []string {
+	var allowedGroupsToCheck []string
+	// Resolve escalation via OwnerReferences first
+	if len(s.OwnerReferences) > 0 && wc.escalManager != nil {
+		for _, or := range s.OwnerReferences {
+			esc, eerr := wc.escalManager.GetBreakglassEscalation(ctx, s.Namespace, or.Name)
+			if eerr != nil {
+				if logger != nil {
+					logger.With("error", eerr, "ownerRef", or, "session", s.Name).Debug("failed to lookup escalation for session ownerRef")
+				} else if wc.log != nil {
+					wc.log.With("error", eerr, "ownerRef", or, "session", s.Name).Debug("failed to lookup escalation for session ownerRef")
+				}
+				continue
+			}
+			allowedGroupsToCheck = esc.Spec.Allowed.Groups
+			break
+		}
+	}
+
+	if len(allowedGroupsToCheck) == 0 {
+		// Fallback: when no escalation allowed groups are available, try
+		// the plain granted group so sessions without explicit escalation
+		// ownerRefs still enable standard session-based SAR checks.
+		allowedGroupsToCheck = []string{s.Spec.GrantedGroup}
+		if logger != nil {
+			logger.Debugw("No escalation allowed groups found; falling back to session granted group for prefix detection", "session", s.Name, "grantedGroup", s.Spec.GrantedGroup)
+		} else if wc.log != nil {
+			wc.log.Debugw("No escalation allowed groups found; falling back to session granted group for prefix detection", "session", s.Name, "grantedGroup", s.Spec.GrantedGroup)
+		}
+	}
+
+	prefixes := wc.config.Kubernetes.OIDCPrefixes
+	// Find a primary prefix by matching incoming groups to allowed groups
+	var primaryPrefix string
+	if incoming.Spec.Groups != nil && len(prefixes) > 0 {
+		for _, ig := range incoming.Spec.Groups {
+			for _, allowed := range allowedGroupsToCheck {
+				for _, p := range prefixes {
+					if strings.HasPrefix(ig, p) && strings.HasSuffix(ig, allowed) {
+						primaryPrefix = p
+						break
+					}
+				}
+				if primaryPrefix != "" {
+					break
+				}
+			}
+			if primaryPrefix != "" {
+				break
+			}
+		}
+	}
+
+	// Build ordered list of impersonation groups to try. We include the plain
+	// granted group as a baseline. If a primary prefix is detected, try it
+	// first, then the plain group, then remaining prefixes. Otherwise try
+	// plain group first followed by configured prefixes.
+	groupsToTry := make([]string, 0, len(prefixes)+1)
+	if primaryPrefix != "" {
+		groupsToTry = append(groupsToTry, primaryPrefix+s.Spec.GrantedGroup)
+		groupsToTry = append(groupsToTry, s.Spec.GrantedGroup)
+		for _, p := range prefixes {
+			if p != primaryPrefix {
+				groupsToTry = append(groupsToTry, p+s.Spec.GrantedGroup)
+			}
+		}
+	} else {
+		groupsToTry = append(groupsToTry, s.Spec.GrantedGroup)
+		for _, p := range prefixes {
+			groupsToTry = append(groupsToTry, p+s.Spec.GrantedGroup)
+		}
+	}
+	return groupsToTry
+}
+
+// SetCanDoFn replaces the RBAC check for clusters of every access review mode
 func (wc *WebhookController) SetCanDoFn(f func(ctx context.Context, rc *rest.Config, groups []string, sar authorizationv1.SubjectAccessReview, clustername string) (bool, error)) {
 	wc.canDoFn = f
+	wc.sarCanDoFn = f
+}
+
+// canDoFnFor returns the RBAC check matching the access review mode of the cluster
+func (wc *WebhookController) canDoFnFor(clusterCfg *v1alpha1.ClusterConfig) breakglass.CanGroupsDoFunction {
+	if clusterCfg != nil && clusterCfg.Spec.AccessReviewMode == v1alpha1.AccessReviewModeSubjectAccessReview && wc.sarCanDoFn != nil {
+		return wc.sarCanDoFn
+	}
+	return wc.canDoFn
 }
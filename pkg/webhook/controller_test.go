@@ -375,3 +375,58 @@ func TestHandleAuthorize_NoIDPRestriction_HappyPath(t *testing.T) {
 		t.Fatalf("expected Allowed=true for unrestricted escalation, got false; reason=%s", resp.Status.Reason)
 	}
 }
+
+func TestCanDoFnFor_AccessReviewMode(t *testing.T) {
+	var used string
+	wc := &WebhookController{
+		canDoFn: func(context.Context, *rest.Config, []string, authorizationv1.SubjectAccessReview, string) (bool, error) {
+			used = "impersonation"
+			return false, nil
+		},
+		sarCanDoFn: func(context.Context, *rest.Config, []string, authorizationv1.SubjectAccessReview, string) (bool, error) {
+			used = "sar"
+			return false, nil
+		},
+	}
+	for _, tc := range []struct {
+		cfg  *v1alpha1.ClusterConfig
+		want string
+	}{
+		{cfg: nil, want: "impersonation"},
+		{cfg: &v1alpha1.ClusterConfig{}, want: "impersonation"},
+		{cfg: &v1alpha1.ClusterConfig{Spec: v1alpha1.ClusterConfigSpec{AccessReviewMode: v1alpha1.AccessReviewModeImpersonation}}, want: "impersonation"},
+		{cfg: &v1alpha1.ClusterConfig{Spec: v1alpha1.ClusterConfigSpec{AccessReviewMode: v1alpha1.AccessReviewModeSubjectAccessReview}}, want: "sar"},
+	} {
+		_, _ = wc.canDoFnFor(tc.cfg)(context.Background(), nil, nil, authorizationv1.SubjectAccessReview{}, "c")
+		if used != tc.want {
+			t.Fatalf("expected %s check, got %s", tc.want, used)
+		}
+	}
+}
+
+// Test that the ClusterConfig checker's probe is answered without consulting RBAC or sessions
+func TestHandleAuthorize_WebhookProbe(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(breakglass.Scheme).Build()
+	logger, _ := zap.NewDevelopment()
+	wc := NewWebhookController(logger.Sugar(), config.Config{}, &breakglass.SessionManager{Client: cli}, &breakglass.EscalationManager{Client: cli}, nil, policy.NewEvaluator(cli, logger.Sugar()))
+	wc.SetCanDoFn(func(context.Context, *rest.Config, []string, authorizationv1.SubjectAccessReview, string) (bool, error) {
+		t.Fatalf("probe must not reach the RBAC check")
+		return true, nil
+	})
+
+	sar := authorizationv1.SubjectAccessReview{TypeMeta: metav1.TypeMeta{APIVersion: "authorization.k8s.io/v1", Kind: "SubjectAccessReview"}, Spec: authorizationv1.SubjectAccessReviewSpec{User: breakglass.WebhookProbeUser, ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Group: v1alpha1.GroupVersion.Group, Resource: "webhookprobes"}}}
+	body, _ := json.Marshal(sar)
+	engine := gin.New()
+	_ = wc.Register(engine.Group("/" + wc.BasePath()))
+	req, _ := http.NewRequest(http.MethodPost, "/breakglass/webhook/authorize/test-cluster", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var resp SubjectAccessReviewResponse
+	if err := json.NewDecoder(w.Result().Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Status.Allowed || resp.Status.Reason != breakglass.WebhookProbeReason {
+		t.Fatalf("expected probe denial with probe reason, got allowed=%v reason=%q", resp.Status.Allowed, resp.Status.Reason)
+	}
+}
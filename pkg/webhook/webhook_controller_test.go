@@ -167,7 +167,7 @@ func SetupController(interceptFuncs *interceptor.Funcs) *WebhookController {
 				ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: "default"},
 				Spec: v1alpha1.ClusterConfigSpec{
 					Tenant:              fmt.Sprintf("tenant-%s", clusterName),
-					KubeconfigSecretRef: v1alpha1.SecretKeyReference{Name: secretName, Namespace: "default"},
+					KubeconfigSecretRef: &v1alpha1.SecretKeyReference{Name: secretName, Namespace: "default"},
 				},
 			},
 		)
@@ -591,7 +591,7 @@ func TestAuthorizeViaSessions_AllowsWhenSessionSARAllowed(t *testing.T) {
 
 	rc := &rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
 
-	allowed, grp, name, impersonated := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster")
+	allowed, grp, name, impersonated := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster", nil)
 	if !allowed {
 		t.Fatalf("expected session SAR to allow but it did not")
 	}
@@ -632,7 +632,7 @@ func TestAuthorizeViaSessions_WithIdentityProviderRecordsMetric(t *testing.T) {
 
 	rc := &rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
 
-	allowed, _, _, _ := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster")
+	allowed, _, _, _ := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster", nil)
 	if !allowed {
 		t.Fatalf("expected IDP session SAR to allow but it did not")
 	}
@@ -691,7 +691,7 @@ func TestAuthorizeViaSessions_PrefixedAllowed(t *testing.T) {
 	prefSAR := sar
 	prefSAR.Spec.Groups = []string{"oidc:breakglass-create-all"}
 
-	allowed, grp, name, impersonated := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, prefSAR, "test-cluster")
+	allowed, grp, name, impersonated := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, prefSAR, "test-cluster", nil)
 	if !allowed {
 		t.Fatalf("expected prefixed session SAR to allow but it did not")
 	}
@@ -706,6 +706,49 @@ func TestAuthorizeViaSessions_PrefixedAllowed(t *testing.T) {
 	}
 }
 
+// Test that authorizeViaSessions impersonates only the mapped group when the cluster declares a group mapping
+func TestAuthorizeViaSessions_GroupMapping(t *testing.T) {
+	controller := SetupController(nil)
+	// global prefixes must not be tried for clusters with a mapping
+	controller.config.Kubernetes.OIDCPrefixes = []string{"oidc:", "keycloak:"}
+
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(bodyBytes))
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(string(bodyBytes), "view-all") {
+			_, _ = io.WriteString(w, `{"apiVersion":"authorization.k8s.io/v1","kind":"SubjectAccessReview","status":{"allowed":true}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"apiVersion":"authorization.k8s.io/v1","kind":"SubjectAccessReview","status":{"allowed":false}}`)
+	}))
+	defer srv.Close()
+	rc := &rest.Config{Host: srv.URL}
+
+	mapping := &v1alpha1.GroupMapping{
+		OIDCPrefix: "sso:",
+		Rules:      []v1alpha1.GroupMappingRule{{IDPGroup: "breakglass-read-only", ClusterGroup: "view-all"}},
+	}
+	sessions := []v1alpha1.BreakglassSession{
+		{ObjectMeta: metav1.ObjectMeta{Name: "sess-map-1"}, Spec: v1alpha1.BreakglassSessionSpec{GrantedGroup: "breakglass-create-all"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "sess-map-2"}, Spec: v1alpha1.BreakglassSessionSpec{GrantedGroup: "breakglass-read-only"}},
+	}
+
+	allowed, grp, name, impersonated := controller.authorizeViaSessions(context.Background(), rc, sessions, sar, "test-cluster", mapping)
+	if !allowed || grp != "breakglass-read-only" || name != "sess-map-2" || impersonated != "view-all" {
+		t.Fatalf("expected sess-map-2 to allow via view-all, got allowed=%v group=%s session=%s impersonated=%s", allowed, grp, name, impersonated)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[0], "sso:breakglass-create-all") {
+		t.Fatalf("expected exactly one SAR per session with mapped groups, got %d requests", len(bodies))
+	}
+	for _, b := range bodies {
+		if strings.Contains(b, "oidc:") || strings.Contains(b, "keycloak:") {
+			t.Fatalf("expected global OIDC prefixes not to be tried, got request %q", b)
+		}
+	}
+}
+
 // Test that authorizeViaSessions properly reports errors when SAR creation fails
 func TestAuthorizeViaSessions_ErrorPath(t *testing.T) {
 	controller := SetupController(nil)
@@ -725,7 +768,7 @@ func TestAuthorizeViaSessions_ErrorPath(t *testing.T) {
 
 	rc := &rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
 
-	allowed, _, _, _ := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster")
+	allowed, _, _, _ := controller.authorizeViaSessions(context.Background(), rc, []v1alpha1.BreakglassSession{ses}, sar, "test-cluster", nil)
 	if allowed {
 		t.Fatalf("expected session SAR to not allow when server errors")
 	}